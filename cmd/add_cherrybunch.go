@@ -52,15 +52,7 @@ func runAddCherryBunch(cmd *cobra.Command, args []string) {
 	logger.Info("Adding cherry bunch from: %s", source)
 
 	// Load the cherry bunch
-	var cherryBunch *config.CherryBunch
-	var err error
-
-	if isURL(source) {
-		cherryBunch, err = loadCherryBunchFromURL(source)
-	} else {
-		cherryBunch, err = config.LoadCherryBunch(source)
-	}
-
+	cherryBunch, err := loadCherryBunch(source)
 	if err != nil {
 		logger.Fatal("Failed to load cherry bunch: %v", err)
 	}
@@ -87,7 +79,7 @@ func runAddCherryBunch(cmd *cobra.Command, args []string) {
 	}
 
 	// Save configuration
-	if err := cfg.Save(configFile); err != nil {
+	if err := saveConfig(); err != nil {
 		logger.Fatal("Failed to save configuration: %v", err)
 	}
 
@@ -95,6 +87,14 @@ func runAddCherryBunch(cmd *cobra.Command, args []string) {
 	logger.Info("Run 'cherry-go sync %s' to synchronize the files", cherryBunch.Name)
 }
 
+// loadCherryBunch loads a cherry bunch from a file or an http(s) URL
+func loadCherryBunch(location string) (*config.CherryBunch, error) {
+	if isURL(location) {
+		return loadCherryBunchFromURL(location)
+	}
+	return config.LoadCherryBunch(location)
+}
+
 func loadCherryBunchFromURL(url string) (*config.CherryBunch, error) {
 	logger.Debug("Downloading cherry bunch from URL: %s", url)
 
@@ -1,20 +1,19 @@
 package cmd
 
 import (
-	"strings"
-
 	"github.com/spf13/cobra"
 
-	"cherry-go/internal/config"
 	"cherry-go/internal/logger"
-	"cherry-go/internal/utils"
 )
 
 var (
-	dirRepoName  string
-	dirLocalPath string
-	dirBranch    string
-	dirExcludes  []string
+	dirRepoName    string
+	dirLocalPath   string
+	dirBranch      string
+	dirAutoAddRepo bool
+	dirYes         bool
+	dirAutoRename  bool
+	dirExcludes    []string
 )
 
 // addDirectoryCmd represents the add directory command
@@ -27,7 +26,12 @@ var addDirectoryCmd = &cobra.Command{
 Format: cherry-go add directory REPOSITORY_URL/path/to/dir/
 
 The repository is auto-detected from the URL. If multiple repositories are configured
-and the URL doesn't specify a repository, you must specify --repo.
+and the URL doesn't specify a repository, you are asked to pick one interactively;
+in non-interactive runs (CI, pipes) you must specify --repo.
+
+A repository URL that isn't configured yet is shown (URL, name, auth type) and
+added as a new source only once you confirm it. Non-interactive runs need
+--auto-add-repo; --yes adds it without asking.
 
 When syncing a directory:
 - New files will be added automatically
@@ -35,6 +39,14 @@ When syncing a directory:
 - Deleted files will be removed from local
 - Excluded patterns will be ignored
 
+Exclude patterns are checked against the upstream directory after the initial sync:
+a pattern that matches no file is reported, with the patterns it was likely meant
+to be (a comma-separated list split up, a path made relative to the directory).
+--exclude completes with the directory's files once its repository is cached.
+
+With options.max_path_size set, a directory whose upstream files add up to more
+than it is not added; its size is shown, and --allow-large adds it anyway.
+
 Examples:
   # Add a directory with full URL (repository auto-detected)
   cherry-go add directory https://github.com/user/library.git/src/
@@ -45,167 +57,23 @@ Examples:
   # Add with custom local path
   cherry-go add directory https://github.com/user/lib.git/utils/ --local-path internal/utils/
   
-  # Add from specific branch with exclusions
-  cherry-go add directory https://github.com/user/lib.git/src/ --branch develop --exclude "*.test.go,tmp/"
+  # Add from specific branch with exclusions (quoted, one pattern per --exclude)
+  cherry-go add directory https://github.com/user/lib.git/src/ --branch develop --exclude '*.test.go' --exclude tmp/
   
   # Add from configured repository (if only one exists)
   cherry-go add directory src/`,
 	Run: func(cmd *cobra.Command, args []string) {
-		urlPath := args[0]
-
-		// Parse the URL path to extract repository URL and directory path
-		repoURL, dirPath := utils.ParseURLPath(urlPath)
-
-		// Ensure directory path ends with /
-		if dirPath != "" && !strings.HasSuffix(dirPath, "/") {
-			dirPath += "/"
-		}
-
-		var source *config.Source
-		var exists bool
-
-		if repoURL != "" {
-			// Repository URL provided in the path, find or create repository
-			repoName := utils.ExtractRepoName(repoURL)
-
-			// Override with explicit repo name if provided
-			if dirRepoName != "" {
-				repoName = dirRepoName
-			}
-
-			source, exists = cfg.GetSource(repoName)
-			if !exists {
-				// Auto-add repository if it doesn't exist
-				logger.Info("Repository '%s' not found, adding automatically...", repoName)
-
-				authType := detectAuthType(repoURL)
-				auth := config.AuthConfig{
-					Type:     authType,
-					Username: "", // Will be detected automatically
-					SSHKey:   "",
-				}
-
-				source = &config.Source{
-					Name:       repoName,
-					Repository: repoURL,
-					Auth:       auth,
-					Paths:      []config.PathSpec{},
-				}
-
-				cfg.AddSource(*source)
-				logger.Info("✅ Auto-added repository '%s'", repoName)
-			}
-
-			dirRepoName = repoName
-		} else {
-			// No repository URL in path, try to auto-detect from existing repositories
-			if dirRepoName == "" {
-				if len(cfg.Sources) == 0 {
-					logger.Fatal("No repositories configured. Add one first with: cherry-go add repo <URL>")
-				} else if len(cfg.Sources) == 1 {
-					// Only one repository, use it
-					source = &cfg.Sources[0]
-					dirRepoName = source.Name
-					logger.Debug("Auto-detected repository: %s", dirRepoName)
-				} else {
-					logger.Fatal("Multiple repositories configured. Specify which one to use with --repo or use full URL format: cherry-go add directory REPO_URL/path/to/dir/")
-				}
-			} else {
-				// Repository name provided explicitly
-				source, exists = cfg.GetSource(dirRepoName)
-				if !exists {
-					logger.Fatal("Repository '%s' not found. Available repositories: %v", dirRepoName, getRepositoryNames())
-				}
-			}
-		}
-
-		// Set local path - default to same as source path
-		localPath := dirLocalPath
-		if localPath == "" {
-			localPath = dirPath
-		}
-
-		// Ensure local path ends with / if it's a directory
-		if localPath != "" && !strings.HasSuffix(localPath, "/") {
-			localPath += "/"
-		}
-
-		// Check if this directory is already being tracked
-		for _, pathSpec := range source.Paths {
-			if pathSpec.Include == dirPath {
-				logger.Fatal("Directory '%s' is already being tracked in repository '%s'", dirPath, dirRepoName)
-			}
-			// Check for overlapping paths
-			if strings.HasPrefix(dirPath, pathSpec.Include) || strings.HasPrefix(pathSpec.Include, dirPath) {
-				logger.Fatal("Directory '%s' overlaps with existing tracked path '%s' in repository '%s'", dirPath, pathSpec.Include, dirRepoName)
-			}
-		}
-
-		// Create new path spec for the directory
-		newPathSpec := config.PathSpec{
-			Include:   dirPath,
-			LocalPath: localPath,
-			Branch:    dirBranch,
-			Exclude:   dirExcludes,
-			Files:     make(map[string]string), // Will be populated during sync
-		}
-
-		// Add the path spec to the source
-		source.Paths = append(source.Paths, newPathSpec)
-
-		// Update the source in configuration
-		for i, cfgSource := range cfg.Sources {
-			if cfgSource.Name == dirRepoName {
-				cfg.Sources[i] = *source
-				break
-			}
-		}
-
-		// Try to sync the directory first before adding to tracking
-		var syncSuccess bool
-		if !logger.IsDryRun() {
-			logger.Info("🔄 Syncing directory for the first time...")
-
-			// Perform initial sync of the directory
-			if err := performInitialSync(dirRepoName); err != nil {
-				logger.Error("Failed to sync directory: %v", err)
-				logger.Error("Directory will not be added to tracking due to sync failure")
-				return
-			} else {
-				logger.Info("✅ Directory synced successfully!")
-				syncSuccess = true
-			}
-		} else {
-			logger.DryRunInfo("Would sync the directory automatically")
-			syncSuccess = true // Assume success in dry-run
-		}
-
-		// Only save configuration if sync was successful
-		if syncSuccess {
-			if !logger.IsDryRun() {
-				if err := cfg.Save(configFile); err != nil {
-					logger.Fatal("Failed to save configuration: %v", err)
-				}
-			}
-
-			logger.Info("✅ Added directory tracking:")
-			logger.Info("  Repository: %s", dirRepoName)
-			logger.Info("  Source path: %s", dirPath)
-			logger.Info("  Local path: %s", localPath)
-			if dirBranch != "" {
-				logger.Info("  Branch/Tag: %s", dirBranch)
-			} else {
-				logger.Info("  Branch/Tag: (default)")
-			}
-			if len(dirExcludes) > 0 {
-				logger.Info("  Excludes: %v", dirExcludes)
-			}
-
-			if logger.IsDryRun() {
-				logger.DryRunInfo("Configuration would be saved to: %s", configFile)
-			} else {
-				logger.Info("Configuration saved to: %s", configFile)
-			}
+		err := addPathSpec(newOutput(cmd), pathKindDirectory, args[0], addPathOptions{
+			RepoName:    dirRepoName,
+			LocalPath:   dirLocalPath,
+			Branch:      dirBranch,
+			AutoAddRepo: dirAutoAddRepo,
+			Yes:         dirYes,
+			AutoRename:  dirAutoRename,
+			Excludes:    dirExcludes,
+		})
+		if err != nil {
+			logger.Fatal("%v", err)
 		}
 	},
 }
@@ -216,5 +84,10 @@ func init() {
 	addDirectoryCmd.Flags().StringVar(&dirRepoName, "repo", "", "repository name (auto-detected if only one configured)")
 	addDirectoryCmd.Flags().StringVar(&dirLocalPath, "local-path", "", "local path for the directory (defaults to same as source path)")
 	addDirectoryCmd.Flags().StringVar(&dirBranch, "branch", "", "branch or tag to track (defaults to main/master)")
-	addDirectoryCmd.Flags().StringSliceVar(&dirExcludes, "exclude", []string{}, "patterns to exclude (e.g., *.tmp,test_*)")
+	addDirectoryCmd.Flags().StringArrayVar(&dirExcludes, "exclude", []string{}, "pattern to exclude, repeat for more (e.g. --exclude '*.tmp' --exclude 'test_*'); quote globs so the shell doesn't expand them")
+	_ = addDirectoryCmd.RegisterFlagCompletionFunc("exclude", completeExcludes)
+	addDirectoryCmd.Flags().BoolVar(&allowLarge, "allow-large", false, "add the directory even if its upstream files exceed options.max_path_size")
+	addDirectoryCmd.Flags().BoolVar(&dirAutoAddRepo, "auto-add-repo", false, "add the repository if it is not configured yet, without asking")
+	addDirectoryCmd.Flags().BoolVarP(&dirYes, "yes", "y", false, "skip the confirmation and details when auto-adding a repository")
+	addDirectoryCmd.Flags().BoolVar(&dirAutoRename, "auto-rename", false, "when auto-adding, use NAME-2 (NAME-3, ...) if the name taken from the URL belongs to another repository")
 }
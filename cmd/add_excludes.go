@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+// warnUnmatchedExcludes warns about the exclude patterns of a newly synced directory
+// that match none of its upstream files, suggesting the patterns they were likely
+// meant to be. Checking is best effort: a failure is only logged in debug output.
+func warnUnmatchedExcludes(source *config.Source, pathSpec config.PathSpec) {
+	if len(pathSpec.Exclude) == 0 {
+		return
+	}
+
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		logger.Debug("Not checking the excludes of %s: %v", pathSpec.Include, err)
+		return
+	}
+	defer closeRepository(repo)
+
+	unmatched, err := repo.UnmatchedExcludes(pathSpec)
+	if err != nil {
+		logger.Debug("Not checking the excludes of %s: %v", pathSpec.Include, err)
+		return
+	}
+	for _, exclude := range unmatched {
+		logger.Warning("⚠️  Exclude '%s' matches no file in %s", exclude.Pattern, pathSpec.Include)
+		if len(exclude.Suggestions) == 0 {
+			continue
+		}
+		suggested := make([]string, len(exclude.Suggestions))
+		for i, suggestion := range exclude.Suggestions {
+			suggested[i] = "--exclude '" + suggestion + "'"
+		}
+		logger.Info("💡 Did you mean: %s", strings.Join(suggested, " "))
+	}
+}
+
+// completeExcludes completes --exclude with the files and directories of the directory
+// being added, one path segment at a time, as they are in its cached clone. Nothing is
+// cloned or fetched, so a repository that isn't cached yet completes nothing.
+func completeExcludes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	parsed, err := utils.ParseURLPath(args[0])
+	if err != nil || parsed.Path == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	repoURL := completionRepository(parsed.URL, dirRepoName)
+	if repoURL == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Complete the last segment of what was typed, inside the directory it names
+	dir, prefix := path.Split(toComplete)
+	root := filepath.Join(cacheManager.GetRepositoryPath(repoURL), filepath.FromSlash(config.PathKey(parsed.Path)))
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(dir, "/"))))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), prefix) || entry.Name() == ".git" {
+			continue
+		}
+		completion := dir + entry.Name()
+		if entry.IsDir() {
+			completion += "/"
+		}
+		completions = append(completions, completion)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completionRepository is the repository a completion reads: the URL given, as a
+// configured source spells it, or else the --repo source or the only configured one
+func completionRepository(repoURL, repoName string) string {
+	if cfg == nil {
+		return repoURL
+	}
+	for _, source := range cfg.Sources {
+		switch {
+		case repoURL != "" && utils.SameRepository(source.Repository, repoURL):
+			return source.Repository
+		case repoURL == "" && source.Name == repoName:
+			return source.Repository
+		}
+	}
+	if repoURL == "" && repoName == "" && len(cfg.Sources) == 1 {
+		return cfg.Sources[0].Repository
+	}
+	return repoURL
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+// pathKind distinguishes tracked files from tracked directories
+type pathKind string
+
+const (
+	pathKindFile      pathKind = "file"
+	pathKindDirectory pathKind = "directory"
+)
+
+// title returns the capitalized kind for user-facing messages
+func (k pathKind) title() string {
+	return strings.ToUpper(string(k[:1])) + string(k[1:])
+}
+
+// addPathOptions holds the flag values shared by `add file` and `add directory`
+type addPathOptions struct {
+	RepoName  string
+	LocalPath string
+	Branch    string
+	Excludes  []string // Directories only
+
+	AutoAddRepo bool // Add an unconfigured repository after showing it, without asking
+	Yes         bool // Add an unconfigured repository without asking or showing it
+	AutoRename  bool // Suffix the auto-added source's name if another repository already uses it
+}
+
+// autoAddMode picks how an unconfigured repository URL is handled
+func (o addPathOptions) autoAddMode() autoAddMode {
+	switch {
+	case o.Yes:
+		return autoAddSilent
+	case o.AutoAddRepo:
+		return autoAddAllowed
+	default:
+		return autoAddConfirm
+	}
+}
+
+// addPathSpec tracks a file or directory given as REPO_URL/path or a plain path:
+// it resolves (or auto-adds) the source, validates the new spec, performs the
+// initial sync, and saves the configuration. Nothing is saved if the sync fails.
+func addPathSpec(out *output, kind pathKind, urlPath string, opts addPathOptions) error {
+	parsed, err := utils.ParseURLPath(urlPath)
+	if err != nil {
+		return err
+	}
+	for _, warning := range parsed.Warnings {
+		logger.Warning("⚠️  %s", warning)
+	}
+	repoURL, includePath := parsed.URL, parsed.Path
+	if includePath == "" {
+		return fmt.Errorf("no %s path given in '%s'", kind, urlPath)
+	}
+	opts.Branch = branchFromURL(parsed, opts.Branch)
+
+	localPath := opts.LocalPath
+	if config.IsPattern(includePath) {
+		// A pattern tracks every file it matches; --local-path is the directory they go under
+		includePath = config.CleanPath(includePath)
+		if localPath != "" {
+			localPath = config.CanonicalPath(localPath, true)
+		}
+	} else {
+		if localPath == "" {
+			localPath = includePath
+		}
+
+		// Directory specs always end with / and file specs never do
+		includePath = config.CanonicalPath(includePath, kind == pathKindDirectory)
+		localPath = config.CanonicalPath(localPath, kind == pathKindDirectory)
+	}
+
+	// Snapshot the sources so a failed sync leaves the configuration untouched
+	previousSources := cloneSources(cfg.Sources)
+
+	source, err := resolveAddSource(repoURL, opts.RepoName, string(kind), opts.autoAddMode(), opts.AutoRename)
+	if err != nil {
+		return err
+	}
+
+	if err := validateNewPath(source, includePath); err != nil {
+		cfg.Sources = previousSources
+		return err
+	}
+
+	pathSpec := config.PathSpec{
+		Include:   includePath,
+		LocalPath: localPath,
+		Branch:    opts.Branch,
+		Files:     make(map[string]string), // Will be populated during sync
+	}
+	if pathSpec.IsPattern() {
+		if err := pathSpec.ValidatePattern(); err != nil {
+			cfg.Sources = previousSources
+			return err
+		}
+	}
+	if err := pathSpec.ValidateLocalPath(); err != nil {
+		cfg.Sources = previousSources
+		return err
+	}
+	if kind == pathKindDirectory {
+		pathSpec.Exclude = opts.Excludes
+	}
+
+	source.Paths = append(source.Paths, pathSpec)
+	for i, cfgSource := range cfg.Sources {
+		if cfgSource.Name == source.Name {
+			cfg.Sources[i] = *source
+			break
+		}
+	}
+
+	// Sync before saving so only paths that actually synced are tracked
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would sync the %s automatically", kind)
+	} else {
+		logger.Info("🔄 Syncing %s for the first time...", kind)
+		if err := performInitialSync(out, source.Name); err != nil {
+			cfg.Sources = previousSources
+			return fmt.Errorf("failed to sync %s: %w (%s will not be added to tracking)", kind, err, includePath)
+		}
+		logger.Info("✅ %s synced successfully!", kind.title())
+		if kind == pathKindDirectory {
+			warnSyncedExcludes(source.Name, includePath)
+		}
+
+		if err := saveConfig(); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+	}
+
+	logger.Info("✅ Added %s tracking:", kind)
+	logger.Info("  Repository: %s", source.Name)
+	logger.Info("  Source path: %s", includePath)
+	logger.Info("  Local path: %s", pathSpec.LocalRoot())
+	if opts.Branch != "" {
+		logger.Info("  Branch/Tag: %s", opts.Branch)
+	} else {
+		logger.Info("  Branch/Tag: (default)")
+	}
+	if len(pathSpec.Exclude) > 0 {
+		logger.Info("  Excludes: %v", pathSpec.Exclude)
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Configuration would be saved to: %s", configFile)
+	} else {
+		logger.Info("Configuration saved to: %s", configFile)
+	}
+
+	return nil
+}
+
+// warnSyncedExcludes warns about the excludes of a directory the initial sync of source
+// just synced that match nothing upstream
+func warnSyncedExcludes(sourceName, include string) {
+	source, exists := cfg.GetSource(sourceName)
+	if !exists {
+		return
+	}
+	for _, pathSpec := range source.Paths {
+		if config.SamePath(pathSpec.Include, include) {
+			warnUnmatchedExcludes(source, pathSpec)
+			return
+		}
+	}
+}
+
+// validateNewPath rejects includes that are already tracked or overlap a tracked directory
+func validateNewPath(source *config.Source, include string) error {
+	for _, pathSpec := range source.Paths {
+		if config.SamePath(pathSpec.Include, include) {
+			return fmt.Errorf("'%s' is already being tracked in repository '%s'", include, source.Name)
+		}
+		if pathsOverlap(pathSpec.Include, include) {
+			return fmt.Errorf("'%s' overlaps with existing tracked path '%s' in repository '%s'", include, pathSpec.Include, source.Name)
+		}
+	}
+	return nil
+}
+
+// pathsOverlap reports whether one include is a directory containing the other.
+// Paths are compared canonically, so a directory written without its slash still counts.
+func pathsOverlap(a, b string) bool {
+	a, b = config.PathKey(a), config.PathKey(b)
+	return strings.HasPrefix(b, a+"/") || strings.HasPrefix(a, b+"/")
+}
+
+// cloneSources deep-copies sources so in-place path edits can be rolled back
+func cloneSources(sources []config.Source) []config.Source {
+	cloned := make([]config.Source, len(sources))
+	for i, source := range sources {
+		cloned[i] = source
+		cloned[i].Paths = append([]config.PathSpec(nil), source.Paths...)
+	}
+	return cloned
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+)
+
+func TestAddPath_AutoAddsRepository(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+
+	output := mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+	if !strings.Contains(output, "Auto-added repository 'library'") {
+		t.Errorf("Expected the repository to be auto-added, got:\n%s", output)
+	}
+
+	source := requireSource(t, project, "library")
+	if source.Repository != upstream.URL() {
+		t.Errorf("Expected repository %s, got %s", upstream.URL(), source.Repository)
+	}
+	if len(source.Paths) != 1 || source.Paths[0].Include != "src/main.go" {
+		t.Errorf("Expected src/main.go to be tracked, got %+v", source.Paths)
+	}
+}
+
+func TestAddPath_AutoAddDeclinedSavesNothing(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+
+	// Non-interactive runs need --auto-add-repo
+	result := runCLI(t, "add", "file", upstream.PathURL("src/main.go"))
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "--auto-add-repo") {
+		t.Errorf("Expected the auto-add to be refused, got %s", result)
+	}
+	for _, expected := range []string{upstream.URL(), "Name: library", "Auth: "} {
+		if !strings.Contains(result.Output, expected) {
+			t.Errorf("Expected the would-be source to be shown (%q), got:\n%s", expected, result.Output)
+		}
+	}
+
+	// Declining the interactive confirmation aborts as well
+	withPrompt(t, true, selectSourceInteractively)
+	withConfirm(t, false)
+	if result := runCLI(t, "add", "directory", upstream.PathURL("lib/")); result.ExitCode == 0 || !strings.Contains(result.Output, "not added") {
+		t.Errorf("Expected the declined auto-add to fail, got %s", result)
+	}
+
+	if sources := loadProjectConfig(t, project).Sources; len(sources) != 0 {
+		t.Errorf("Expected no source to be persisted, got %+v", sources)
+	}
+	if project.Exists("src/main.go") || project.Exists("lib/a.go") {
+		t.Error("Expected nothing to be synced")
+	}
+}
+
+func TestAddPath_SingleRepositoryAutoDetected(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "repo", upstream.URL(), "--name", "library")
+
+	mustRunCLI(t, "add", "file", "src/main.go")
+	mustRunCLI(t, "add", "directory", "lib", "--exclude", "b.go")
+
+	source := requireSource(t, project, "library")
+	if len(source.Paths) != 2 {
+		t.Fatalf("Expected 2 tracked paths, got %+v", source.Paths)
+	}
+
+	dir := source.Paths[1]
+	if dir.Include != "lib/" || dir.LocalPath != "lib/" {
+		t.Errorf("Expected directory paths to be normalized with a trailing slash, got %+v", dir)
+	}
+	if len(dir.Exclude) != 1 || dir.Exclude[0] != "b.go" {
+		t.Errorf("Expected excludes to be recorded, got %v", dir.Exclude)
+	}
+	if !project.Exists("lib/a.go") || project.Exists("lib/b.go") {
+		t.Error("Expected lib/a.go to be synced and lib/b.go to be excluded")
+	}
+}
+
+func TestAddPath_RejectsDuplicatesAndOverlaps(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo")
+
+	testCases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"duplicate directory", []string{"add", "directory", upstream.PathURL("lib/")}, "already being tracked"},
+		{"duplicate directory without slash", []string{"add", "directory", upstream.PathURL("lib")}, "already being tracked"},
+		{"file inside tracked directory", []string{"add", "file", upstream.PathURL("lib/a.go")}, "overlaps"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := runCLI(t, tc.args...)
+			if result.ExitCode == 0 || !strings.Contains(result.Output, tc.expected) {
+				t.Errorf("Expected failure mentioning %q, got %s", tc.expected, result)
+			}
+		})
+	}
+
+	if paths := requireSource(t, project, "library").Paths; len(paths) != 1 {
+		t.Errorf("Expected rejected adds to leave 1 tracked path, got %+v", paths)
+	}
+}
+
+func TestAddPath_SyncFailureSavesNothing(t *testing.T) {
+	project := newCLIProject(t)
+	missing := "file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "missing.git")) + "/src/main.go"
+
+	result := runCLI(t, "add", "file", missing, "--auto-add-repo")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected add from an unreachable repository to fail, got %s", result)
+	}
+	if !strings.Contains(result.Output, "will not be added to tracking") {
+		t.Errorf("Expected the failure to explain nothing was tracked, got:\n%s", result.Output)
+	}
+
+	if sources := loadProjectConfig(t, project).Sources; len(sources) != 0 {
+		t.Errorf("Expected no sources after a failed initial sync, got %+v", sources)
+	}
+}
+
+func TestPathsOverlap(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"lib/", "lib/a.go", true},
+		{"lib/a.go", "lib/", true},
+		{"lib/", "lib/sub/", true},
+		{"lib/", "library/", false},
+		{"lib", "lib/a.go", true}, // A directory written without its slash
+		{"lib/sub", "lib/", true},
+		{"lib", "library", false},
+		{"lib.go", "lib.go.bak", false},
+		{"src/main.go", "src/util.go", false},
+	}
+
+	for _, tc := range testCases {
+		if got := pathsOverlap(tc.a, tc.b); got != tc.expected {
+			t.Errorf("pathsOverlap(%q, %q) = %t, expected %t", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
+
+func TestCloneSources_IsolatesPaths(t *testing.T) {
+	original := []config.Source{{Name: "lib", Paths: []config.PathSpec{{Include: "a.go"}}}}
+	cloned := cloneSources(original)
+
+	original[0].Paths = append(original[0].Paths, config.PathSpec{Include: "b.go"})
+	original[0].Paths[0].Include = "changed.go"
+
+	if len(cloned[0].Paths) != 1 || cloned[0].Paths[0].Include != "a.go" {
+		t.Errorf("Expected clone to be unaffected by edits, got %+v", cloned[0].Paths)
+	}
+}
+
+func TestAddPath_WarnsAboutUnmatchedExcludes(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.WriteFile("lib/notes.tmp", "scratch\n")
+	upstream.WriteFile("lib/testdata/in.txt", "input\n")
+	upstream.Commit("notes and testdata")
+	project := newCLIProject(t)
+
+	// Repeated --exclude flags are separate patterns; a comma no longer splits one
+	output := mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo",
+		"--exclude", "*.tmp", "--exclude", "b.go,testdata/", "--exclude", "lib/a.go")
+
+	for _, expected := range []string{
+		"Exclude 'b.go,testdata/' matches no file in lib/",
+		"Did you mean: --exclude 'b.go' --exclude 'testdata/'",
+		"Exclude 'lib/a.go' matches no file in lib/",
+		"Did you mean: --exclude 'a.go'",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "Exclude '*.tmp'") {
+		t.Errorf("Expected no warning for a pattern that matches, got:\n%s", output)
+	}
+
+	source := requireSource(t, project, "library")
+	if excludes := source.Paths[0].Exclude; len(excludes) != 3 || excludes[1] != "b.go,testdata/" {
+		t.Errorf("Expected the patterns to be recorded as given, got %v", excludes)
+	}
+	if project.Exists("lib/notes.tmp") || !project.Exists("lib/b.go") {
+		t.Error("Expected lib/notes.tmp to be excluded and lib/b.go synced")
+	}
+}
+
+func TestCompleteExcludes(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.WriteFile("lib/internal/c.go", "package internal\n")
+	upstream.Commit("nested helper")
+	newCLIProject(t)
+	target := upstream.PathURL("lib/")
+
+	// Nothing to complete before the repository is cached
+	if completions, _ := completeExcludes(addDirectoryCmd, []string{target}, ""); len(completions) != 0 {
+		t.Errorf("Expected no completions without a cached clone, got %v", completions)
+	}
+
+	mustRunCLI(t, "add", "directory", target, "--auto-add-repo")
+	testCases := []struct {
+		toComplete string
+		expected   []string
+	}{
+		{"", []string{"a.go", "b.go", "internal/"}},
+		{"b", []string{"b.go"}},
+		{"internal/", []string{"internal/c.go"}},
+		{"missing/", nil},
+	}
+	for _, tc := range testCases {
+		completions, _ := completeExcludes(addDirectoryCmd, []string{target}, tc.toComplete)
+		if !reflect.DeepEqual(completions, tc.expected) {
+			t.Errorf("Completing %q: expected %v, got %v", tc.toComplete, tc.expected, completions)
+		}
+	}
+}
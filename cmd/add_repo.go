@@ -16,6 +16,7 @@ var (
 	repoAuthType string
 	repoAuthUser string
 	repoSSHKey   string
+	repoDepth    int
 )
 
 // addRepoCmd represents the add repo command
@@ -29,6 +30,19 @@ that can be used later to track files and directories from any branch or tag.
 The repository name is automatically extracted from the URL unless specified with --name.
 Authentication type is automatically detected based on the repository URL.
 
+The URL is checked and normalized first: a URL copied from the browser, like
+https://github.com/user/library/tree/main/src, is cut back to the repository
+(https://github.com/user/library.git) and the branch it shows is suggested for
+the paths you add. scp-like SSH URLs (git@host:owner/repo.git) are accepted,
+plain http:// is accepted with a warning, and malformed URLs are rejected.
+
+With --depth N the repository is cloned with only the last N commits of each
+branch, which saves time and cache space on large repositories. A tag or
+pinned commit older than that, and commands that read history (changelog,
+status --check-remote), fetch the full history when they need it. The depth
+applies when the clone is created: a repository already in the cache keeps
+the history it has.
+
 Examples:
   # Add a public repository (name auto-detected)
   cherry-go add repo https://github.com/user/library.git
@@ -40,9 +54,26 @@ Examples:
   cherry-go add repo git@github.com:company/private.git
   
   # Add with custom SSH key
-  cherry-go add repo git@git.company.com:team/repo.git --auth-ssh-key ~/.ssh/company_key`,
+  cherry-go add repo git@git.company.com:team/repo.git --auth-ssh-key ~/.ssh/company_key
+
+  # Clone only the last commit of each branch of a large repository
+  cherry-go add repo https://github.com/kubernetes/kubernetes.git --depth 1`,
 	Run: func(cmd *cobra.Command, args []string) {
-		repoURL := args[0]
+		if repoDepth < 0 {
+			logger.Fatal("--depth must be 0 (full clone) or greater")
+		}
+
+		parsed, err := utils.NormalizeRepoURL(args[0])
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		for _, warning := range parsed.Warnings {
+			logger.Warning("⚠️  %s", warning)
+		}
+		repoURL := parsed.URL
+		if repoURL != args[0] {
+			logger.Info("Normalized repository URL to %s", repoURL)
+		}
 
 		// Auto-generate repository name if not provided
 		if repoName == "" {
@@ -73,6 +104,7 @@ Examples:
 			Repository: repoURL,
 			Auth:       auth,
 			Paths:      []config.PathSpec{}, // Empty initially
+			CloneDepth: repoDepth,
 		}
 
 		// Add to configuration
@@ -80,7 +112,7 @@ Examples:
 
 		// Save configuration
 		if !logger.IsDryRun() {
-			if err := cfg.Save(configFile); err != nil {
+			if err := saveConfig(); err != nil {
 				logger.Fatal("Failed to save configuration: %v", err)
 			}
 		}
@@ -88,10 +120,16 @@ Examples:
 		logger.Info("✅ Added repository '%s'", repoName)
 		logger.Info("  URL: %s", repoURL)
 		logger.Info("  Authentication: %s", repoAuthType)
+		if repoDepth > 0 {
+			logger.Info("  Clone depth: %d commit(s) per branch", repoDepth)
+		}
 		logger.Info("")
 		logger.Info("Next steps:")
 		logger.Info("  Add files: cherry-go add file %s/path/to/file.ext", repoURL)
 		logger.Info("  Add directories: cherry-go add directory %s/path/to/dir/", repoURL)
+		if parsed.Branch != "" {
+			logger.Info("  The URL pointed at branch '%s': add its paths with --branch %s", parsed.Branch, parsed.Branch)
+		}
 
 		if logger.IsDryRun() {
 			logger.DryRunInfo("Configuration would be saved to: %s", configFile)
@@ -137,4 +175,5 @@ func init() {
 	addRepoCmd.Flags().StringVar(&repoAuthType, "auth-type", "auto", "authentication type (auto, ssh, basic)")
 	addRepoCmd.Flags().StringVar(&repoAuthUser, "auth-user", "", "username for basic auth")
 	addRepoCmd.Flags().StringVar(&repoSSHKey, "auth-ssh-key", "", "path to SSH private key")
+	addRepoCmd.Flags().IntVar(&repoDepth, "depth", 0, "clone only this many commits of each branch (0 clones the full history)")
 }
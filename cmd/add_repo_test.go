@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddRepo_NormalizesURL(t *testing.T) {
+	project := newCLIProject(t)
+
+	output := mustRunCLI(t, "add", "repo", "https://github.com/user/library/tree/develop/src", "--name", "library")
+	if source := requireSource(t, project, "library"); source.Repository != "https://github.com/user/library.git" {
+		t.Errorf("Expected the browser URL to be cut back to the repository, got %s", source.Repository)
+	}
+	if !strings.Contains(output, "--branch develop") {
+		t.Errorf("Expected the branch in the URL to be suggested, got:\n%s", output)
+	}
+
+	result := runCLI(t, "add", "repo", "github.com/user/other")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "did you mean https://github.com/user/other?") {
+		t.Errorf("Expected a URL without scheme to be rejected with a suggestion, got:\n%s", result.Output)
+	}
+	if _, exists := loadProjectConfig(t, project).GetSource("other"); exists {
+		t.Error("Expected nothing to be added for a rejected URL")
+	}
+}
+
+func TestAddRepo_Depth(t *testing.T) {
+	project := newCLIProject(t)
+
+	mustRunCLI(t, "add", "repo", "https://github.com/user/library.git", "--name", "library", "--depth", "1")
+	if source := requireSource(t, project, "library"); source.CloneDepth != 1 {
+		t.Errorf("Expected a clone depth of 1, got %d", source.CloneDepth)
+	}
+
+	result := runCLI(t, "add", "repo", "https://github.com/user/other.git", "--depth", "-1")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "--depth must be 0") {
+		t.Errorf("Expected a negative depth to be rejected, got:\n%s", result.Output)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/interactive"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+// Hooks for the interactive repository picker and confirmations, replaced in tests
+var (
+	shouldPromptForSource = interactive.ShouldPrompt
+	promptForSource       = selectSourceInteractively
+	confirmPrompt         = interactive.AskYesNo
+)
+
+// autoAddMode controls whether `add file` / `add directory` may create a source for a
+// repository URL that isn't configured yet
+type autoAddMode int
+
+const (
+	autoAddConfirm autoAddMode = iota // Default: ask interactively, refuse otherwise
+	autoAddAllowed                    // --auto-add-repo: show what is added, don't ask
+	autoAddSilent                     // --yes: add without asking
+)
+
+// resolveAddSource finds the source an `add file` or `add directory` target belongs to.
+// A repository URL in the target selects (or auto-adds) its source; otherwise the
+// --repo name, the only configured source, or an interactive choice is used.
+// kind is the add subcommand ("file" or "directory") and is only used in messages.
+// autoRename lets an auto-added source take a suffixed name when the name derived from
+// its URL already belongs to another repository.
+func resolveAddSource(repoURL, repoName, kind string, autoAdd autoAddMode, autoRename bool) (*config.Source, error) {
+	if repoURL != "" {
+		return findOrAddSource(repoURL, repoName, autoAdd, autoRename)
+	}
+
+	if repoName != "" {
+		source, exists := cfg.GetSource(repoName)
+		if !exists {
+			return nil, fmt.Errorf("repository '%s' not found. Available repositories: %v", repoName, getRepositoryNames())
+		}
+		return source, nil
+	}
+
+	switch len(cfg.Sources) {
+	case 0:
+		return nil, fmt.Errorf("no repositories configured. Add one first with: cherry-go add repo <URL>")
+	case 1:
+		source := &cfg.Sources[0]
+		logger.Debug("Auto-detected repository: %s", source.Name)
+		return source, nil
+	}
+
+	if !shouldPromptForSource() {
+		return nil, fmt.Errorf("multiple repositories configured (%s). Specify which one to use with --repo or use full URL format: cherry-go add %s REPO_URL/path",
+			strings.Join(getRepositoryNames(), ", "), kind)
+	}
+
+	name, err := promptForSource(cfg.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	source, exists := cfg.GetSource(name)
+	if !exists {
+		return nil, fmt.Errorf("repository '%s' not found", name)
+	}
+	return source, nil
+}
+
+// branchFromURL returns the branch to track for a path added by URL: --branch when
+// given, otherwise the branch a browser URL (.../tree/<branch>/...) points at
+func branchFromURL(parsed utils.RepoURL, flagBranch string) string {
+	switch {
+	case parsed.Branch == "":
+	case flagBranch == "":
+		logger.Info("Using branch '%s' from the URL", parsed.Branch)
+		return parsed.Branch
+	case flagBranch != parsed.Branch:
+		logger.Warning("⚠️  The URL points at branch '%s', tracking --branch %s instead", parsed.Branch, flagBranch)
+	}
+	return flagBranch
+}
+
+// findOrAddSource returns the source for a repository URL, adding it to the configuration
+// if missing once the user has confirmed it
+func findOrAddSource(repoURL, repoName string, autoAdd autoAddMode, autoRename bool) (*config.Source, error) {
+	// A source already tracking the URL wins, whatever it is called
+	derived := repoName == ""
+	if derived {
+		for i, source := range cfg.Sources {
+			if utils.SameRepository(source.Repository, repoURL) {
+				return &cfg.Sources[i], nil
+			}
+		}
+		repoName = utils.ExtractRepoName(repoURL)
+	}
+
+	if source, exists := cfg.GetSource(repoName); exists {
+		if utils.SameRepository(source.Repository, repoURL) {
+			return source, nil
+		}
+		// Same name, another repository: appending to it would track files from the wrong place
+		if !derived || !autoRename {
+			return nil, nameCollisionError(source, repoURL, derived)
+		}
+		repoName = uniqueSourceName(repoName)
+	}
+
+	source := &config.Source{
+		Name:       repoName,
+		Repository: repoURL,
+		Auth: config.AuthConfig{
+			Type: detectAuthType(repoURL), // Username and SSH key are detected automatically
+		},
+		Paths: []config.PathSpec{},
+	}
+
+	if err := confirmAutoAdd(source, autoAdd); err != nil {
+		return nil, err
+	}
+
+	cfg.AddSource(*source)
+	logger.Info("✅ Auto-added repository '%s'", repoName)
+	return source, nil
+}
+
+// nameCollisionError explains that the source called like the new repository tracks
+// another URL, and how to add the repository under a name of its own
+func nameCollisionError(existing *config.Source, repoURL string, derived bool) error {
+	suggestion := fmt.Sprintf("pass --repo with a different name (e.g. --repo %s)", existing.Name+"-2")
+	if derived {
+		suggestion += ", or use --auto-rename to pick one automatically"
+	}
+	return fmt.Errorf("repository '%s' already exists for %s, not %s; %s",
+		existing.Name, existing.Repository, repoURL, suggestion)
+}
+
+// uniqueSourceName returns name with the first free -N suffix, starting at -2
+func uniqueSourceName(name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, exists := cfg.GetSource(candidate); !exists {
+			return candidate
+		}
+	}
+}
+
+// confirmAutoAdd shows the source about to be created and asks whether to go ahead.
+// A typo in the URL would otherwise silently start tracking files from the wrong repository.
+func confirmAutoAdd(source *config.Source, autoAdd autoAddMode) error {
+	if autoAdd == autoAddSilent {
+		return nil
+	}
+
+	logger.Warning("⚠️  Repository '%s' is not configured yet. A new source would be added:", source.Name)
+	logger.Info("  URL:  %s", source.Repository)
+	logger.Info("  Name: %s", source.Name)
+	logger.Info("  Auth: %s", source.Auth.Type)
+
+	if autoAdd == autoAddAllowed {
+		return nil
+	}
+
+	if !shouldPromptForSource() {
+		return fmt.Errorf("not adding repository %s without confirmation; check the URL and rerun with --auto-add-repo (or --yes), or add it first with: cherry-go add repo %s",
+			source.Repository, source.Repository)
+	}
+
+	if !confirmPrompt(fmt.Sprintf("Add repository %s?", source.Repository), false) {
+		return fmt.Errorf("repository %s not added, nothing was changed", source.Repository)
+	}
+	return nil
+}
+
+// selectSourceInteractively lets the user pick one of the configured sources
+func selectSourceInteractively(sources []config.Source) (string, error) {
+	items := make([]string, len(sources))
+	for i, source := range sources {
+		items[i] = fmt.Sprintf("%s (%s)", source.Name, source.Repository)
+	}
+
+	idx, err := interactive.SelectOne(items, "Multiple repositories configured. Select the one to use:")
+	if err != nil {
+		return "", err
+	}
+	return sources[idx].Name, nil
+}
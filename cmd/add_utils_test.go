@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// withSources replaces the global config with the given sources for the duration of a test
+func withSources(t *testing.T, sources ...config.Source) {
+	t.Helper()
+	logger.Init()
+
+	previous := cfg
+	cfg = &config.Config{Sources: sources}
+	t.Cleanup(func() { cfg = previous })
+}
+
+// withPrompt stubs the interactive picker
+func withPrompt(t *testing.T, interactive bool, choose func([]config.Source) (string, error)) {
+	t.Helper()
+
+	prevShould, prevPrompt := shouldPromptForSource, promptForSource
+	shouldPromptForSource = func() bool { return interactive }
+	promptForSource = choose
+	t.Cleanup(func() {
+		shouldPromptForSource, promptForSource = prevShould, prevPrompt
+	})
+}
+
+// withConfirm stubs yes/no confirmations with a fixed answer
+func withConfirm(t *testing.T, answer bool) {
+	t.Helper()
+
+	previous := confirmPrompt
+	confirmPrompt = func(question string, defaultYes bool) bool { return answer }
+	t.Cleanup(func() { confirmPrompt = previous })
+}
+
+func TestResolveAddSource(t *testing.T) {
+	lib := config.Source{Name: "lib", Repository: "https://github.com/user/lib.git"}
+	tools := config.Source{Name: "tools", Repository: "https://github.com/user/tools.git"}
+
+	pickTools := func(sources []config.Source) (string, error) { return "tools", nil }
+	neverPrompt := func(sources []config.Source) (string, error) {
+		return "", fmt.Errorf("prompt must not be shown")
+	}
+
+	testCases := []struct {
+		name        string
+		sources     []config.Source
+		repoURL     string
+		repoName    string
+		interactive bool
+		prompt      func([]config.Source) (string, error)
+		autoAdd     autoAddMode
+		autoRename  bool
+		confirm     bool
+		expected    string
+		errContains string
+	}{
+		{name: "single source", sources: []config.Source{lib}, prompt: neverPrompt, expected: "lib"},
+		{name: "explicit name", sources: []config.Source{lib, tools}, repoName: "tools", prompt: neverPrompt, expected: "tools"},
+		{name: "unknown name", sources: []config.Source{lib}, repoName: "missing", prompt: neverPrompt, errContains: "not found"},
+		{name: "no sources", prompt: neverPrompt, errContains: "no repositories configured"},
+		{name: "ambiguous interactive", sources: []config.Source{lib, tools}, interactive: true, prompt: pickTools, expected: "tools"},
+		{name: "ambiguous non-interactive", sources: []config.Source{lib, tools}, prompt: neverPrompt, errContains: "lib, tools"},
+		{name: "picker cancelled", sources: []config.Source{lib, tools}, interactive: true, prompt: neverPrompt, errContains: "prompt must not be shown"},
+		{name: "URL of existing source", sources: []config.Source{lib, tools}, repoURL: tools.Repository, prompt: neverPrompt, expected: "tools"},
+		{name: "URL of source with another name", sources: []config.Source{{Name: "my-tools", Repository: tools.Repository}}, repoURL: tools.Repository, prompt: neverPrompt, expected: "my-tools"},
+		{name: "URL auto-adds source with --auto-add-repo", sources: []config.Source{lib}, repoURL: "https://github.com/user/new.git", prompt: neverPrompt, autoAdd: autoAddAllowed, expected: "new"},
+		{name: "URL auto-adds source with --yes", sources: []config.Source{lib}, repoURL: "https://github.com/user/new.git", prompt: neverPrompt, autoAdd: autoAddSilent, expected: "new"},
+		{name: "URL auto-add confirmed", sources: []config.Source{lib}, repoURL: "https://github.com/user/new.git", interactive: true, prompt: neverPrompt, confirm: true, expected: "new"},
+		{name: "URL auto-add declined", sources: []config.Source{lib}, repoURL: "https://github.com/user/new.git", interactive: true, prompt: neverPrompt, errContains: "not added"},
+		{name: "URL auto-add non-interactive", sources: []config.Source{lib}, repoURL: "https://github.com/user/new.git", prompt: neverPrompt, errContains: "--auto-add-repo"},
+		{name: "URL whose name belongs to another repository", sources: []config.Source{lib}, repoURL: "https://github.com/other/lib.git", prompt: neverPrompt, autoAdd: autoAddSilent, errContains: "already exists for https://github.com/user/lib.git"},
+		{name: "URL with --repo of another repository", sources: []config.Source{lib, tools}, repoURL: "https://github.com/other/lib.git", repoName: "tools", prompt: neverPrompt, autoAdd: autoAddSilent, errContains: "--repo tools-2"},
+		{name: "URL with --repo of the same repository", sources: []config.Source{lib, tools}, repoURL: tools.Repository, repoName: "tools", prompt: neverPrompt, expected: "tools"},
+		{name: "URL whose name is taken, --auto-rename", sources: []config.Source{lib}, repoURL: "https://github.com/other/lib.git", prompt: neverPrompt, autoAdd: autoAddSilent, autoRename: true, expected: "lib-2"},
+		{name: "URL whose name and suffix are taken, --auto-rename", sources: []config.Source{lib, {Name: "lib-2", Repository: "https://github.com/third/lib.git"}}, repoURL: "https://github.com/other/lib.git", prompt: neverPrompt, autoAdd: autoAddSilent, autoRename: true, expected: "lib-3"},
+		{name: "URL with --repo of another repository ignores --auto-rename", sources: []config.Source{lib, tools}, repoURL: "https://github.com/other/lib.git", repoName: "tools", prompt: neverPrompt, autoAdd: autoAddSilent, autoRename: true, errContains: "already exists"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSources(t, tc.sources...)
+			withPrompt(t, tc.interactive, tc.prompt)
+			withConfirm(t, tc.confirm)
+
+			source, err := resolveAddSource(tc.repoURL, tc.repoName, "file", tc.autoAdd, tc.autoRename)
+			if tc.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errContains) {
+					t.Fatalf("Expected error containing %q, got %v", tc.errContains, err)
+				}
+				if len(cfg.Sources) != len(tc.sources) {
+					t.Errorf("Expected no source to be added, got %+v", cfg.Sources)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if source.Name != tc.expected {
+				t.Errorf("Expected source %s, got %s", tc.expected, source.Name)
+			}
+			added, exists := cfg.GetSource(tc.expected)
+			if !exists {
+				t.Fatalf("Expected source %s to be in the configuration", tc.expected)
+			}
+			if tc.repoURL != "" && added.Repository != tc.repoURL {
+				t.Errorf("Expected source %s to track %s, got %s", tc.expected, tc.repoURL, added.Repository)
+			}
+		})
+	}
+}
@@ -2,11 +2,25 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
 	"cherry-go/internal/logger"
+	"cherry-go/internal/units"
+)
+
+var (
+	cleanOrphaned bool
+	cleanForce    bool
+	cleanMaxAge   string
+	cleanRepo     string
 )
 
 // cacheCmd represents the cache command
@@ -21,7 +35,8 @@ repository downloads.
 Available subcommands:
   list  - List cached repositories
   clean - Clean old cached repositories
-	info  - Show cache information`,
+	info  - Show cache information
+  warm  - Clone or fetch configured sources into the cache`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show help when cache is called without subcommands
 		_ = cmd.Help()
@@ -32,7 +47,11 @@ Available subcommands:
 var cacheListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List cached repositories",
-	Long:  `List all repositories currently stored in the global cache.`,
+	Long: `List all repositories currently stored in the global cache, with their origin
+URL and the sources in the current project's config that use them.
+
+Entries no source in this project uses are marked (orphaned); other
+projects sharing the cache may still use them.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cacheManager, err := cache.NewManager()
 		if err != nil {
@@ -49,9 +68,16 @@ var cacheListCmd = &cobra.Command{
 			return
 		}
 
+		users := cacheUsers(cacheManager, cfg.Sources)
+
 		logger.Info("Cached Repositories (%d):", len(repos))
 		for i, repo := range repos {
 			logger.Info("  %d. %s", i+1, repo.String())
+			if names, ok := users[repo.Path]; ok {
+				logger.Info("     used by: %s", strings.Join(names, ", "))
+			} else {
+				logger.Info("     (orphaned)")
+			}
 		}
 	},
 }
@@ -81,7 +107,11 @@ var cacheInfoCmd = &cobra.Command{
 		if err != nil {
 			logger.Error("Failed to calculate cache size: %v", err)
 		} else {
-			logger.Info("  Cache size: %s", formatBytes(size))
+			logger.Info("  Cache size: %s", format.Bytes(size))
+		}
+
+		if !cfg.Options.BaseSnapshotsEnabled() {
+			logger.Info("  Base snapshots: disabled for this project (options.base_snapshots: false)")
 		}
 	},
 }
@@ -92,42 +122,220 @@ var cacheCleanCmd = &cobra.Command{
 	Short: "Clean old cached repositories",
 	Long: `Remove old cached repositories to free up disk space.
 
-By default, repositories older than 30 days are removed.`,
+By default, repositories not used for 30 days (--max-age) are removed,
+along with base-content snapshot blobs no snapshot references anymore.
+--max-age takes a duration like 12h, 7d or 2w; a plain number is days.
+
+With --repo, only the named repository is removed, whatever its age: give
+a source name from the current config, a repository URL, or a cache entry
+name as shown by 'cherry-go cache list'. Combined with --max-age, it is
+only removed if it is older than that.
+
+With --orphaned, repositories no source in the current project's config
+uses are removed instead. The cache is shared by every project, so this
+only lists them unless --force is given.
+
+The number of repositories removed and the disk space freed are reported.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		maxAge, err := parseMaxAge(cleanMaxAge)
+		if err != nil {
+			logger.Fatal("--max-age: %v", err)
+		}
+
 		cacheManager, err := cache.NewManager()
 		if err != nil {
 			logger.Fatal("Failed to initialize cache manager: %v", err)
 		}
 
-		maxAge := int64(30) // 30 days default
+		if cleanOrphaned {
+			cleanOrphanedEntries(cacheManager)
+			return
+		}
+
+		maxAgeDisplay := format.Duration(maxAge)
+
+		if cleanRepo != "" {
+			entries := cacheEntriesFor(cacheManager, cleanRepo)
+			if cmd.Flags().Changed("max-age") {
+				var expired []cache.CachedRepository
+				for _, repo := range entries {
+					if repo.UnusedFor(maxAge) {
+						expired = append(expired, repo)
+					}
+				}
+				entries = expired
+				if len(entries) == 0 {
+					logger.Info("%s was used within the last %s, keeping it", cleanRepo, maxAgeDisplay)
+					return
+				}
+			}
+			removeCacheEntries(cacheManager, entries, "repositories")
+			return
+		}
 
 		if logger.IsDryRun() {
-			logger.DryRunInfo("Would clean repositories older than %d days", maxAge)
+			expired, err := cacheManager.ExpiredRepositories(maxAge)
+			if err != nil {
+				logger.Fatal("Failed to list cached repositories: %v", err)
+			}
+			logger.DryRunInfo("Would remove %d repositories not used for %s", len(expired), maxAgeDisplay)
+			for _, repo := range expired {
+				logger.DryRunInfo("  - %s", repo.String())
+			}
 			return
 		}
 
-		logger.Info("Cleaning cache (removing repositories older than %d days)...", maxAge)
+		logger.Info("Cleaning cache (removing repositories not used for %s)...", maxAgeDisplay)
 
-		if err := cacheManager.CleanCache(maxAge); err != nil {
+		before := cacheSize(cacheManager)
+		removed, err := cacheManager.CleanCache(maxAge)
+		for _, repo := range removed {
+			logger.Info("  - %s", repo.String())
+		}
+		if err != nil {
 			logger.Fatal("Failed to clean cache: %v", err)
 		}
 
-		logger.Info("✅ Cache cleaned successfully")
+		collectSnapshotBlobs()
+		logger.Info("✅ Cache cleaned: removed %d repositories, freeing %s", len(removed), format.Bytes(before-cacheSize(cacheManager)))
 	},
 }
 
-// formatBytes formats bytes into human readable format
-func formatBytes(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
+// parseMaxAge reads --max-age: a duration like 7d, or a plain number of days as the flag
+// originally took
+func parseMaxAge(value string) (time.Duration, error) {
+	if days, err := strconv.Atoi(value); err == nil {
+		if days < 0 {
+			return 0, fmt.Errorf("must be 0 or more, got %d", days)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return units.ParseDuration(value)
+}
+
+// cacheSize returns the size of the repository cache, 0 if it can't be measured
+func cacheSize(cacheManager *cache.Manager) int64 {
+	size, err := cacheManager.GetCacheSize()
+	if err != nil {
+		logger.Debug("Failed to calculate cache size: %v", err)
+		return 0
+	}
+	return size
+}
+
+// cacheEntriesFor finds the cache entries a --repo value names: a configured source,
+// a repository URL, or a cache entry name
+func cacheEntriesFor(cacheManager *cache.Manager, value string) []cache.CachedRepository {
+	repos, err := cacheManager.ListCachedRepositories()
+	if err != nil {
+		logger.Fatal("Failed to list cached repositories: %v", err)
+	}
+
+	paths := map[string]bool{cacheManager.GetRepositoryPath(value): true}
+	if source, ok := cfg.GetSource(value); ok {
+		paths[cacheManager.GetRepositoryPath(source.Repository)] = true
+	}
+
+	var entries []cache.CachedRepository
+	for _, repo := range repos {
+		if paths[repo.Path] || repo.Name == value || repo.URL == value {
+			entries = append(entries, repo)
+		}
+	}
+
+	if len(entries) == 0 {
+		logger.Fatal("No cached repository matches '%s' (give a source name, a repository URL, or an entry from 'cherry-go cache list')", value)
+	}
+	return entries
+}
+
+// removeCacheEntries deletes the given cache entries and reports the space freed
+func removeCacheEntries(cacheManager *cache.Manager, repos []cache.CachedRepository, kind string) {
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would remove %d %s", len(repos), kind)
+		for _, repo := range repos {
+			logger.DryRunInfo("  - %s", repo.String())
+		}
+		return
 	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+
+	before := cacheSize(cacheManager)
+	for _, repo := range repos {
+		if err := cacheManager.RemoveEntry(repo); err != nil {
+			logger.Fatal("Failed to clean cache: %v", err)
+		}
+		logger.Info("  - %s", repo.String())
 	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	logger.Info("✅ Removed %d %s, freeing %s", len(repos), kind, format.Bytes(before-cacheSize(cacheManager)))
+}
+
+// collectSnapshotBlobs removes base-content blobs no snapshot references anymore
+func collectSnapshotBlobs() {
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		logger.Warning("Could not access base-content snapshots: %v", err)
+		return
+	}
+
+	removed, freed, err := baseManager.GarbageCollect()
+	if err != nil {
+		logger.Warning("Failed to collect unused snapshot content: %v", err)
+		return
+	}
+	if removed > 0 {
+		logger.Info("Removed %d unused snapshot blob(s), freeing %s", removed, format.Bytes(freed))
+	}
+}
+
+// cacheUsers maps each cache entry path to the configured sources that use it
+func cacheUsers(cacheManager *cache.Manager, sources []config.Source) map[string][]string {
+	users := make(map[string][]string)
+	for _, source := range sources {
+		// Ephemeral sources never use the cache, so a clone left from before is orphaned
+		if source.Ephemeral() {
+			continue
+		}
+		path := cacheManager.GetRepositoryPath(source.Repository)
+		users[path] = append(users[path], source.Name)
+	}
+	for _, names := range users {
+		sort.Strings(names)
+	}
+	return users
+}
+
+// cleanOrphanedEntries removes cache entries the current project doesn't use
+func cleanOrphanedEntries(cacheManager *cache.Manager) {
+	repos, err := cacheManager.ListCachedRepositories()
+	if err != nil {
+		logger.Fatal("Failed to list cached repositories: %v", err)
+	}
+
+	users := cacheUsers(cacheManager, cfg.Sources)
+	var orphaned []cache.CachedRepository
+	for _, repo := range repos {
+		if _, used := users[repo.Path]; !used {
+			orphaned = append(orphaned, repo)
+		}
+	}
+
+	if len(orphaned) == 0 {
+		logger.Info("No orphaned repositories in cache")
+		return
+	}
+
+	logger.Info("Orphaned repositories (%d), not used by %s:", len(orphaned), configFile)
+	for _, repo := range orphaned {
+		logger.Info("  - %s", repo.String())
+	}
+
+	if !cleanForce {
+		logger.Warning("⚠️  The cache is shared by all projects, other projects may still use these repositories")
+		logger.Info("💡 Run 'cherry-go cache clean --orphaned --force' to remove them anyway")
+		return
+	}
+
+	removeCacheEntries(cacheManager, orphaned, "orphaned repositories")
 }
 
 func init() {
@@ -137,4 +345,10 @@ func init() {
 	cacheCmd.AddCommand(cacheListCmd)
 	cacheCmd.AddCommand(cacheInfoCmd)
 	cacheCmd.AddCommand(cacheCleanCmd)
+
+	cacheCleanCmd.Flags().BoolVar(&cleanOrphaned, "orphaned", false, "remove repositories not used by any source in the current config")
+	cacheCleanCmd.Flags().BoolVar(&cleanForce, "force", false, "with --orphaned, remove even though other projects may use them")
+	cacheCleanCmd.Flags().StringVar(&cleanMaxAge, "max-age", "30d", "remove repositories not used for this long (e.g. 12h, 7d, 2w; a plain number is days)")
+	cacheCleanCmd.Flags().StringVar(&cleanRepo, "repo", "", "remove only this repository (source name, repository URL, or cache entry name)")
+	cacheCleanCmd.MarkFlagsMutuallyExclusive("orphaned", "repo")
 }
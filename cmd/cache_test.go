@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/testutil"
+)
+
+func TestE2E_CacheListAndCleanOrphaned(t *testing.T) {
+	library := newLibraryFixture(t)
+	retired := testutil.NewFixtureRepo(t, "retired")
+	retired.WriteFile("old.txt", "old\n")
+	retired.Commit("initial")
+
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: library.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "library-main", Repository: library.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}}},
+		config.Source{Name: "retired", Repository: retired.URL(), Paths: []config.PathSpec{{Include: "old.txt"}}},
+	)
+	mustRunCLI(t, "cache", "warm")
+
+	// Drop the retired source from the project; its clone stays in the shared cache
+	cfg := loadProjectConfig(t, project)
+	cfg.RemoveSource("retired")
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output := mustRunCLI(t, "cache", "list")
+	for _, expected := range []string{library.URL(), "used by: library, library-main", retired.URL(), "(orphaned)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected cache list to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	// Without --force the shared cache is only inspected
+	output = mustRunCLI(t, "cache", "clean", "--orphaned")
+	if !strings.Contains(output, "other projects may still use") || !strings.Contains(output, retired.URL()) {
+		t.Errorf("Expected the orphan and the shared-cache caveat, got:\n%s", output)
+	}
+	if !cacheManager.RepositoryExists(retired.URL()) {
+		t.Fatal("Expected nothing to be removed without --force")
+	}
+
+	output = mustRunCLI(t, "cache", "clean", "--orphaned", "--force")
+	if !strings.Contains(output, "Removed 1 orphaned repositories") {
+		t.Errorf("Expected one removal, got:\n%s", output)
+	}
+	if cacheManager.RepositoryExists(retired.URL()) {
+		t.Error("Expected the orphaned clone to be removed")
+	}
+	if !cacheManager.RepositoryExists(library.URL()) {
+		t.Error("Expected the clone still in use to be kept")
+	}
+}
+
+func TestE2E_CacheCleanRepoAndMaxAge(t *testing.T) {
+	library := newLibraryFixture(t)
+	other := testutil.NewFixtureRepo(t, "other")
+	other.WriteFile("other.txt", "other\n")
+	other.Commit("initial")
+
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: library.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "other", Repository: other.URL(), Paths: []config.PathSpec{{Include: "other.txt"}}},
+	)
+	mustRunCLI(t, "cache", "warm")
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	if result := runCLI(t, "cache", "clean", "--repo", "unknown"); result.ExitCode == 0 {
+		t.Errorf("Expected an unknown --repo to fail, got:\n%s", result.Output)
+	}
+
+	// Just used, so a retention limit keeps it, given in days or as a duration
+	for _, maxAge := range []string{"7", "2w", "12h"} {
+		output := mustRunCLI(t, "cache", "clean", "--repo", "library", "--max-age", maxAge)
+		if !strings.Contains(output, "keeping it") || !cacheManager.RepositoryExists(library.URL()) {
+			t.Errorf("Expected --max-age %s to keep the recently used clone, got:\n%s", maxAge, output)
+		}
+	}
+	if result := runCLI(t, "cache", "clean", "--max-age", "30days"); result.ExitCode == 0 || !strings.Contains(result.Output, `invalid duration "30days"`) {
+		t.Errorf("Expected an invalid --max-age to be refused, got:\n%s", result.Output)
+	}
+
+	output := mustRunCLI(t, "cache", "clean", "--repo", "library")
+	if !strings.Contains(output, "Removed 1 repositories, freeing") {
+		t.Errorf("Expected one removal with the space freed, got:\n%s", output)
+	}
+	if cacheManager.RepositoryExists(library.URL()) || !cacheManager.RepositoryExists(other.URL()) {
+		t.Error("Expected only the named repository to be removed")
+	}
+
+	output = mustRunCLI(t, "cache", "clean")
+	if !strings.Contains(output, "removed 0 repositories") || !cacheManager.RepositoryExists(other.URL()) {
+		t.Errorf("Expected the default retention to keep a recently used clone, got:\n%s", output)
+	}
+
+	output = mustRunCLI(t, "cache", "clean", "--max-age", "0")
+	if !strings.Contains(output, "removed 1 repositories") || cacheManager.RepositoryExists(other.URL()) {
+		t.Errorf("Expected a zero max age to remove every clone, got:\n%s", output)
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+var (
+	warmJobs    int
+	warmTimeout time.Duration
+)
+
+// warmResult is the outcome of warming the cache for one repository
+type warmResult struct {
+	Repository string
+	Sources    []string // Configured sources that use the repository
+	Cloned     bool     // Freshly cloned rather than fetched into an existing clone
+	Downloaded int64    // Growth of the cached clone on disk
+	Duration   time.Duration
+	Error      error
+}
+
+// cacheWarmCmd represents the cache warm command
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm [source...]",
+	Short: "Clone or fetch configured sources into the cache",
+	Long: `Clone or fetch every configured source (or only the named ones) into the
+global cache without copying any files into the project.
+
+Useful to bake the cache into CI images so later syncs skip cloning.
+Sources sharing a repository are fetched once. Exits non-zero if any
+repository fails.
+
+Examples:
+  # Warm the cache for every source in .cherry-go.yaml
+  cherry-go cache warm
+
+  # Warm selected sources from another project's config, 8 at a time
+  cherry-go cache warm --config ../app/.cherry-go.yaml --jobs 8 mylib utils`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		sources = skipEphemeralSources(sources)
+		if len(sources) == 0 {
+			logger.Info("No sources configured to warm")
+			return
+		}
+		if warmJobs < 1 {
+			logger.Fatal("--jobs must be at least 1")
+		}
+
+		start := time.Now()
+		results := warmSources(sources, warmJobs, warmTimeout)
+
+		var downloaded int64
+		failed := 0
+		for _, result := range results {
+			names := strings.Join(result.Sources, ", ")
+			if result.Error != nil {
+				logger.Error("✗ %s: %v (%s)", names, result.Error, format.Duration(result.Duration))
+				failed++
+				continue
+			}
+
+			action := "fetched"
+			if result.Cloned {
+				action = "cloned"
+			}
+			logger.Info("✓ %s: %s, %s downloaded in %s", names, action, format.Bytes(result.Downloaded), format.Duration(result.Duration))
+			downloaded += result.Downloaded
+		}
+
+		logger.Info("Warmed %d of %d repositories: %s downloaded in %s",
+			len(results)-failed, len(results), format.Bytes(downloaded), format.Duration(time.Since(start)))
+
+		if failed > 0 {
+			logger.Fatal("%d repositories failed to warm", failed)
+		}
+	},
+}
+
+// selectSources returns the sources named in args, or all sources when args is empty
+func selectSources(all []config.Source, args []string) ([]config.Source, error) {
+	if len(args) == 0 {
+		return all, nil
+	}
+
+	byName := make(map[string]config.Source, len(all))
+	for _, source := range all {
+		byName[source.Name] = source
+	}
+
+	var selected []config.Source
+	var missing []string
+	for _, name := range args {
+		source, ok := byName[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		selected = append(selected, source)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unknown source(s): %s", strings.Join(missing, ", "))
+	}
+	return selected, nil
+}
+
+// skipEphemeralSources leaves out `cache: ephemeral` sources, which are never cached
+func skipEphemeralSources(sources []config.Source) []config.Source {
+	var cached []config.Source
+	for _, source := range sources {
+		if source.Ephemeral() {
+			logger.Info("Skipping %s: cache: ephemeral sources are cloned fresh on every sync", source.Name)
+			continue
+		}
+		cached = append(cached, source)
+	}
+	return cached
+}
+
+// warmSources warms one cached clone per distinct repository, at most jobs at a time.
+// Results are ordered by repository URL.
+func warmSources(sources []config.Source, jobs int, timeout time.Duration) []warmResult {
+	// Sources that share a repository share a cache directory, so warm each one once
+	byRepository := make(map[string]*warmResult)
+	representative := make(map[string]config.Source)
+	var repositories []string
+	for _, source := range sources {
+		if result, ok := byRepository[source.Repository]; ok {
+			result.Sources = append(result.Sources, source.Name)
+			continue
+		}
+		byRepository[source.Repository] = &warmResult{Repository: source.Repository, Sources: []string{source.Name}}
+		representative[source.Repository] = source
+		repositories = append(repositories, source.Repository)
+	}
+	sort.Strings(repositories)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+	for _, repository := range repositories {
+		wg.Add(1)
+		go func(result *warmResult, source config.Source) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			warmRepository(result, source, timeout)
+		}(byRepository[repository], representative[repository])
+	}
+	wg.Wait()
+
+	results := make([]warmResult, 0, len(repositories))
+	for _, repository := range repositories {
+		results = append(results, *byRepository[repository])
+	}
+	return results
+}
+
+// warmRepository clones or fetches a single source's repository into the cache
+func warmRepository(result *warmResult, source config.Source, timeout time.Duration) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		result.Error = fmt.Errorf("failed to initialize cache manager: %w", err)
+		return
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result.Cloned = !cacheManager.RepositoryExists(source.Repository)
+	sizeBefore := cacheManager.RepositorySize(source.Repository)
+
+	repo, err := git.NewRepositoryContext(ctx, &source)
+	if err != nil {
+		result.Error = err
+		return
+	}
+
+	// A fresh clone already has every ref
+	if !result.Cloned {
+		if err := repo.Fetch(ctx); err != nil {
+			result.Error = err
+			return
+		}
+	}
+
+	result.Downloaded = max(cacheManager.RepositorySize(source.Repository)-sizeBefore, 0)
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheWarmCmd)
+
+	cacheWarmCmd.Flags().IntVar(&warmJobs, "jobs", 4, "number of repositories to clone or fetch concurrently")
+	cacheWarmCmd.Flags().DurationVar(&warmTimeout, "timeout", 10*time.Minute, "maximum time per repository (0 for no limit)")
+}
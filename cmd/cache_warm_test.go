@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/testutil"
+)
+
+// snapshotTree records every file below dir with its content
+func snapshotTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to snapshot %s: %v", dir, err)
+	}
+	return files
+}
+
+// configureSources writes sources straight into the project config, without syncing
+func configureSources(t *testing.T, project *testutil.Project, sources ...config.Source) {
+	t.Helper()
+	cfg := loadProjectConfig(t, project)
+	for _, source := range sources {
+		cfg.AddSource(source)
+	}
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("configure sources")
+}
+
+func TestE2E_CacheWarm(t *testing.T) {
+	library := newLibraryFixture(t)
+	tools := testutil.NewFixtureRepo(t, "tools")
+	tools.WriteFile("bin/tool.sh", "echo v1\n")
+	tools.Commit("initial")
+
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: library.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "library-main", Repository: library.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}}},
+		config.Source{Name: "tools", Repository: tools.URL(), Paths: []config.PathSpec{{Include: "bin/"}}},
+	)
+	before := snapshotTree(t, project.Dir)
+
+	output := mustRunCLI(t, "cache", "warm")
+	for _, expected := range []string{"library, library-main: cloned", "tools: cloned", "Warmed 2 of 2 repositories"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	for _, url := range []string{library.URL(), tools.URL()} {
+		if !cacheManager.RepositoryExists(url) {
+			t.Errorf("Expected %s to be cached", url)
+		}
+	}
+
+	if after := snapshotTree(t, project.Dir); !reflect.DeepEqual(before, after) {
+		t.Error("Expected cache warm to leave the project directory untouched")
+	}
+
+	// A second run fetches new upstream commits into the existing clone
+	head := tools.Commit("empty follow-up")
+	output = mustRunCLI(t, "cache", "warm", "tools")
+	if !strings.Contains(output, "tools: fetched") || strings.Contains(output, "library") {
+		t.Errorf("Expected only tools to be fetched, got:\n%s", output)
+	}
+
+	repo, err := git.NewRepository(&config.Source{Name: "tools", Repository: tools.URL()})
+	if err != nil {
+		t.Fatalf("Failed to open cached repository: %v", err)
+	}
+	if got, err := repo.GetCommitForRef("origin/" + testutil.DefaultBranch); err != nil || got != head {
+		t.Errorf("Expected the cache to have fetched %s, got %s (%v)", head, got, err)
+	}
+
+	if after := snapshotTree(t, project.Dir); !reflect.DeepEqual(before, after) {
+		t.Error("Expected fetching to leave the project directory untouched")
+	}
+}
+
+func TestE2E_CacheWarmFailures(t *testing.T) {
+	library := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: library.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "broken", Repository: "file://" + filepath.Join(t.TempDir(), "missing"), Paths: []config.PathSpec{{Include: "x"}}},
+	)
+
+	result := runCLI(t, "cache", "warm", "--jobs", "1")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected a failing repository to fail the command, got %s", result)
+	}
+	if !strings.Contains(result.Output, "Warmed 1 of 2 repositories") {
+		t.Errorf("Expected the summary to count the failure, got:\n%s", result.Output)
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if !cacheManager.RepositoryExists(library.URL()) {
+		t.Error("Expected the healthy repository to be cached despite the failure")
+	}
+
+	if result := runCLI(t, "cache", "warm", "nope"); result.ExitCode == 0 || !strings.Contains(result.Output, "unknown source(s): nope") {
+		t.Errorf("Expected an unknown source to fail, got %s", result)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+var (
+	changelogSince         string
+	changelogUntil         string
+	changelogIncludeMerges bool
+	changelogGroupBy       string
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog [source...]",
+	Short: "List upstream commits that touch tracked paths",
+	Long: `List the upstream commits that changed the tracked paths of every source
+(or only the named ones), newest first, independent of what was last synced.
+
+--since and --until take a date (YYYY-MM-DD, or RFC 3339 for a precise time)
+or a branch, tag, or commit of the source repository. A ref as --since lists
+only commits that are not already in it; a ref as --until reads history from
+that ref instead of each path's branch. An --until date includes the whole day.
+
+Merge commits are left out unless --include-merges is given.
+
+Examples:
+  # What changed upstream in the second quarter
+  cherry-go changelog --since 2025-03-01 --until 2025-06-30
+
+  # Everything between two releases of one source, by month
+  cherry-go changelog mylib --since v1.2.0 --until v1.4.0 --group-by month`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		if len(sources) == 0 {
+			logger.Info("No sources configured")
+			return
+		}
+		if changelogGroupBy != "" && changelogGroupBy != "month" {
+			logger.Fatal("invalid --group-by '%s' (expected month)", changelogGroupBy)
+		}
+
+		options := git.ChangelogOptions{
+			Since:         changelogSince,
+			Until:         changelogUntil,
+			IncludeMerges: changelogIncludeMerges,
+		}
+
+		failed := 0
+		for i := range sources {
+			entries, err := sourceChangelog(&sources[i], options)
+			if err != nil {
+				logger.Error("✗ %s: %v", sources[i].Name, err)
+				failed++
+				continue
+			}
+			printChangelog(sources[i].Name, entries, changelogGroupBy)
+		}
+
+		if failed > 0 {
+			logger.Fatal("Failed to read the changelog of %d source(s)", failed)
+		}
+	},
+}
+
+// sourceChangelog fetches a source's repository and lists its commits within options
+func sourceChangelog(source *config.Source, options git.ChangelogOptions) ([]git.ChangelogEntry, error) {
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRepository(repo)
+	if err := repo.Fetch(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo.Changelog(options)
+}
+
+// printChangelog shows a source's commits, optionally under one heading per month
+func printChangelog(name string, entries []git.ChangelogEntry, groupBy string) {
+	logger.Info("📜 %s: %d commit(s)", name, len(entries))
+
+	month := ""
+	for _, entry := range entries {
+		indent := "  "
+		if groupBy == "month" {
+			indent = "    "
+			if current := entry.When.Format("2006-01"); current != month {
+				month = current
+				logger.Info("  %s", month)
+			}
+		}
+		logger.Info("%s%s", indent, formatChangelogEntry(entry))
+	}
+	logger.Info("")
+}
+
+// formatChangelogEntry renders one commit as "hash date subject (author) [paths]"
+func formatChangelogEntry(entry git.ChangelogEntry) string {
+	return fmt.Sprintf("%s %s %s (%s) [%s]",
+		git.ShortHash(entry.Hash), entry.When.Format("2006-01-02"), entry.Subject,
+		entry.Author, strings.Join(entry.Paths, ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "only commits after this date (YYYY-MM-DD) or ref")
+	changelogCmd.Flags().StringVar(&changelogUntil, "until", "", "only commits up to this date (YYYY-MM-DD) or ref")
+	changelogCmd.Flags().BoolVar(&changelogIncludeMerges, "include-merges", false, "include merge commits")
+	changelogCmd.Flags().StringVar(&changelogGroupBy, "group-by", "", "group commits under a heading: month")
+}
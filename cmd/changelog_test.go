@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+)
+
+func TestE2E_ChangelogFiltersAndGroups(t *testing.T) {
+	upstream := newLibraryFixture(t) // 2025-01-01
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "cache", "warm")
+
+	// Land after the cache was cloned, so the changelog has to fetch them
+	upstream.AdvanceClock(40 * 24 * time.Hour)
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, fixed\nfunc A() {}\n")
+	upstream.Commit("lib: fix A")
+	upstream.WriteFile("src/main.go", "package main\n")
+	upstream.Commit("main: untracked change")
+	upstream.AdvanceClock(30 * 24 * time.Hour)
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("lib: add C")
+
+	output := mustRunCLI(t, "changelog", "--since", "2025-02-01", "--group-by", "month")
+	for _, expected := range []string{"library: 2 commit(s)", "2025-03", "lib: add C", "2025-02", "lib: fix A", "[lib/]"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in changelog output:\n%s", expected, output)
+		}
+	}
+	if strings.Index(output, "2025-03") > strings.Index(output, "2025-02") {
+		t.Errorf("Expected newest month first:\n%s", output)
+	}
+	if strings.Contains(output, "untracked change") || strings.Contains(output, "initial import") {
+		t.Errorf("Expected only commits touching lib/ after the bound:\n%s", output)
+	}
+
+	if result := runCLI(t, "changelog", "--group-by", "week"); result.ExitCode == 0 {
+		t.Errorf("Expected an unknown --group-by to fail:\n%s", result.Output)
+	}
+	if result := runCLI(t, "changelog", "--until", "no-such-ref"); result.ExitCode == 0 {
+		t.Errorf("Expected an unresolvable --until to fail:\n%s", result.Output)
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"bufio"
-	"fmt"
 	"os"
 	"strings"
 
@@ -83,28 +82,29 @@ func runCherryBunchCreate(cmd *cobra.Command, args []string) {
 	}
 
 	// Interactive setup
+	out := newOutput(cmd)
 	scanner := bufio.NewScanner(os.Stdin)
 
 	// Get basic information
-	fmt.Print("Cherry bunch name: ")
+	out.Print("Cherry bunch name: ")
 	scanner.Scan()
 	name := strings.TrimSpace(scanner.Text())
 	if name == "" {
 		name = "my-cherrybunch"
 	}
 
-	fmt.Print("Description (optional): ")
+	out.Print("Description (optional): ")
 	scanner.Scan()
 	description := strings.TrimSpace(scanner.Text())
 
-	fmt.Printf("Repository URL [%s]: ", repoURL)
+	out.Printf("Repository URL [%s]: ", repoURL)
 	scanner.Scan()
 	inputURL := strings.TrimSpace(scanner.Text())
 	if inputURL != "" {
 		repoURL = inputURL
 	}
 
-	fmt.Printf("Default branch [%s]: ", cherryBunchBranch)
+	out.Printf("Default branch [%s]: ", cherryBunchBranch)
 	scanner.Scan()
 	inputBranch := strings.TrimSpace(scanner.Text())
 	if inputBranch != "" {
@@ -145,9 +145,9 @@ func runCherryBunchCreate(cmd *cobra.Command, args []string) {
 	}
 
 	// Interactive selection of files and directories
-	fmt.Println("\n=== Interactive file and directory selection ===")
-	fmt.Println("Use arrow keys to navigate, Tab to select, Enter to confirm")
-	fmt.Println("Ctrl+C to cancel")
+	out.Println("\n=== Interactive file and directory selection ===")
+	out.Println("Use arrow keys to navigate, Tab to select, Enter to confirm")
+	out.Println("Ctrl+C to cancel")
 
 	selectedFiles, selectedDirs, err := selector.SelectMixed(
 		allFiles,
@@ -226,7 +226,7 @@ func runCherryBunchCreate(cmd *cobra.Command, args []string) {
 			// Ask for exclude patterns if configuring custom paths
 			var exclude []string
 			if configureCustomPaths {
-				fmt.Printf("Exclude patterns for %s (comma-separated, optional): ", pathConfig.SourcePath)
+				out.Printf("Exclude patterns for %s (comma-separated, optional): ", pathConfig.SourcePath)
 				scanner.Scan()
 				excludeStr := strings.TrimSpace(scanner.Text())
 				if excludeStr != "" {
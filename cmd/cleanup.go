@@ -31,10 +31,7 @@ Examples:
   # Delete all conflict branches
   cherry-go cleanup --all`,
 	Run: func(cmd *cobra.Command, args []string) {
-		workDir, err := os.Getwd()
-		if err != nil {
-			logger.Fatal("Failed to get current directory: %v", err)
-		}
+		workDir := enterProjectRoot()
 
 		// Get branch prefix from config
 		branchPrefix := cfg.Options.BranchPrefix
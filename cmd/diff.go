@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+)
+
+var (
+	diffNameOnly bool
+	diffStat     bool
+)
+
+// Exit codes of the diff command, as with diff(1)
+const (
+	diffExitDifferent = 1 // Some tracked file differs from upstream
+	diffExitTrouble   = 2 // A source could not be compared
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff [source...]",
+	Short: "Show how tracked files differ from upstream, without syncing",
+	Long: `Fetch every source (or only the named ones) into the cache and print a
+unified diff from the local copies of the tracked files to their latest
+upstream versions: what sync --force would change. Files added upstream or
+missing locally show as new, files removed upstream as deleted. Nothing in
+the project or the cache checkout is modified.
+
+Local files that a tracked directory doesn't track, and excluded files, are
+not compared. Pinned paths are compared with their pinned commit.
+
+The diff goes to stdout and applies with git apply (or patch -p1); log lines
+go to stderr. The exit code is 0 when everything matches, 1 when something
+differs and 2 when a source could not be compared, so CI can gate on drift.
+
+Examples:
+  cherry-go diff
+  cherry-go diff mylib --name-only
+  cherry-go diff --stat`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if diffNameOnly && diffStat {
+			logger.Fatal("Cannot specify both --name-only and --stat")
+		}
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		if len(sources) == 0 {
+			logger.Info("No sources configured")
+			return
+		}
+
+		workDir := enterProjectRoot()
+
+		out := newOutput(cmd)
+		var results []git.SyncResult
+		different, failed := 0, 0
+		for i := range sources {
+			diffs, err := sourceDiff(&sources[i], workDir)
+			if err != nil {
+				logger.Error("✗ %s: %v", sources[i].Name, err)
+				failed++
+				continue
+			}
+			if len(diffs) == 0 {
+				logger.Info("✓ %s matches upstream", sources[i].Name)
+				continue
+			}
+
+			different++
+			logger.Info("%s: %d file(s) differ from upstream", sources[i].Name, len(diffs))
+			result := git.SyncResult{SourceName: sources[i].Name}
+			for _, diff := range diffs {
+				result.FileActions = append(result.FileActions, diff.Action)
+			}
+			results = append(results, result)
+			if !diffStat {
+				renderDiff(out, diffs)
+			}
+		}
+
+		if diffStat && len(results) > 0 {
+			renderSyncStat(out.Writer(), results)
+		}
+
+		if failed > 0 {
+			logger.Error("Failed to compare %d source(s)", failed)
+			logger.Exit(diffExitTrouble)
+		}
+		if different > 0 {
+			logger.Exit(diffExitDifferent)
+		}
+	},
+}
+
+// sourceDiff fetches a source's repository and compares its tracked files with upstream
+func sourceDiff(source *config.Source, workDir string) ([]git.FileDiff, error) {
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRepository(repo)
+	repo.SetSyncOptions(cfg.Options)
+	if err := repo.Fetch(context.Background()); err != nil {
+		return nil, err
+	}
+	return repo.Diff(workDir)
+}
+
+// renderDiff writes a source's differences: their paths with --name-only, else a
+// unified diff from the local files (a/) to upstream (b/)
+func renderDiff(out *output, diffs []git.FileDiff) {
+	for _, diff := range diffs {
+		path := filepath.ToSlash(diff.Action.Path)
+		if diffNameOnly {
+			out.Println(path)
+			continue
+		}
+
+		oldName, newName := "a/"+path, "b/"+path
+		switch diff.Action.Type {
+		case git.FileActionAdded:
+			oldName = "/dev/null"
+		case git.FileActionDeleted:
+			newName = "/dev/null"
+		}
+		merge.UnifiedDiff(out.Writer(), oldName, newName, diff.Local, diff.Remote)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "only list the paths of the files that differ")
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "summarize the differences per file instead of showing them")
+}
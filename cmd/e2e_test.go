@@ -0,0 +1,2374 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/history"
+	"cherry-go/internal/interactive"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// newLibraryFixture creates an upstream repository with one file and one directory
+func newLibraryFixture(t *testing.T) *testutil.FixtureRepo {
+	t.Helper()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"v1\")\n}\n")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper\nfunc A() {}\n")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B is the second helper\nfunc B() {}\n")
+	upstream.Commit("initial import")
+	return upstream
+}
+
+func TestE2E_AddRepoFileAndDirectory(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+
+	mustRunCLI(t, "add", "repo", upstream.URL(), "--name", "library")
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--local-path", "vendor/lib/")
+
+	if got := project.ReadFile("src/main.go"); !strings.Contains(got, "v1") {
+		t.Errorf("Expected src/main.go to be synced, got %q", got)
+	}
+	for _, file := range []string{"vendor/lib/a.go", "vendor/lib/b.go"} {
+		if !project.Exists(file) {
+			t.Errorf("Expected %s to be synced", file)
+		}
+	}
+
+	source := requireSource(t, project, "library")
+	if len(source.Paths) != 2 {
+		t.Fatalf("Expected 2 tracked paths, got %d", len(source.Paths))
+	}
+	if len(source.Paths[1].Files) != 2 {
+		t.Errorf("Expected 2 tracked files for lib/, got %v", source.Paths[1].Files)
+	}
+}
+
+func TestE2E_SyncDetectMergeForce(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+
+	// Local edit at the top of the file, upstream edit at the bottom
+	project.WriteFile("src/main.go", "// local header\npackage main\n\nfunc main() {\n\tprintln(\"v1\")\n}\n")
+	project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"v2\")\n}\n")
+	upstream.Commit("upstream change")
+
+	// Detect mode reports the difference without touching the file
+	output := mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "Differences detected") {
+		t.Errorf("Expected detect mode to report differences, got:\n%s", output)
+	}
+	if got := project.ReadFile("src/main.go"); strings.Contains(got, "v2") {
+		t.Error("Detect mode must not modify local files")
+	}
+
+	// Merge mode combines both edits
+	mustRunCLI(t, "sync", "library", "--merge")
+	merged := project.ReadFile("src/main.go")
+	if !strings.Contains(merged, "// local header") || !strings.Contains(merged, "v2") {
+		t.Errorf("Expected merged content with both changes, got %q", merged)
+	}
+
+	// Force mode replaces local content with upstream
+	project.WriteFile("src/main.go", "local rewrite\n")
+	project.Commit("local rewrite")
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("src/main.go"); got != "package main\n\nfunc main() {\n\tprintln(\"v2\")\n}\n" {
+		t.Errorf("Expected force sync to restore upstream content, got %q", got)
+	}
+}
+
+func TestE2E_BranchOnConflict(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+
+	// Both sides change the same line
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n")
+	project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n")
+	upstream.Commit("upstream change")
+
+	output := mustRunCLI(t, "sync", "library", "--merge", "--branch-on-conflict")
+	if !strings.Contains(output, "Conflict branch created") {
+		t.Errorf("Expected a conflict branch to be created, got:\n%s", output)
+	}
+
+	branches, err := git.ListSourceConflictBranches(project.Dir, "cherry-go/sync", "library")
+	if err != nil {
+		t.Fatalf("Failed to list conflict branches: %v", err)
+	}
+	if len(branches) != 1 {
+		t.Fatalf("Expected 1 conflict branch, got %v", branches)
+	}
+
+	// The working tree keeps the local version
+	if got := project.ReadFile("src/main.go"); !strings.Contains(got, "local") {
+		t.Errorf("Expected local content to be preserved, got %q", got)
+	}
+}
+
+func TestE2E_MarkConflicts(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+
+	// Both sides change the same line
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n")
+	localChange := project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n")
+	upstream.Commit("upstream change")
+
+	output := mustRunCLI(t, "sync", "library", "--merge", "--mark-conflicts", "--autocommit")
+	marked := project.ReadFile("src/main.go")
+	for _, expected := range []string{"<<<<<<<", "println(\"local\")", "println(\"remote\")", ">>>>>>>"} {
+		if !strings.Contains(marked, expected) {
+			t.Errorf("Expected %q in the marked file, got:\n%s", expected, marked)
+		}
+	}
+	if !strings.Contains(output, "Conflict markers written to 1 file(s)") || !strings.Contains(output, "- src/main.go") {
+		t.Errorf("Expected a summary of the marked files, got:\n%s", output)
+	}
+	if current, err := project.Repo().Head(); err != nil || current.Hash().String() != localChange {
+		t.Errorf("Expected the marked file not to be committed, HEAD is %v", current)
+	}
+
+	// Unresolved markers are never synced over, whatever the merge would make of them
+	for _, args := range [][]string{{"sync", "library"}, {"sync", "library", "--merge"}} {
+		result := runCLI(t, args...)
+		if result.ExitCode == 0 || !strings.Contains(result.Output, "still has conflict markers in src/main.go") {
+			t.Errorf("Expected %v to refuse the marked file, got:\n%s", args, result.Output)
+		}
+		if got := project.ReadFile("src/main.go"); got != marked {
+			t.Errorf("Expected %v to leave the marked file alone, got:\n%s", args, got)
+		}
+	}
+
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("src/main.go"); strings.Contains(got, "<<<<<<<") || !strings.Contains(got, "remote") {
+		t.Errorf("Expected --force to overwrite the markers with upstream, got:\n%s", got)
+	}
+}
+
+func TestE2E_SyncUnknownSourceFails(t *testing.T) {
+	newCLIProject(t)
+
+	result := runCLI(t, "sync", "missing")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected sync of an unknown source to fail, got %s", result)
+	}
+}
+
+func TestE2E_RemoveKeepsStateOtherProjectsUse(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	addLibrary := func(project *testutil.Project) {
+		t.Helper()
+		projectConfig := loadProjectConfig(t, project)
+		projectConfig.AddSource(config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+		if err := projectConfig.Save(project.ConfigPath()); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+	}
+
+	first := newCLIProject(t)
+	addLibrary(first)
+	mustRunCLI(t, "sync", "library", "--force")
+
+	// A second project on the same machine, sharing the cache, with a source of the same name and URL
+	second := newCLIProject(t)
+	t.Setenv("HOME", first.Home)
+	addLibrary(second)
+	mustRunCLI(t, "sync", "library", "--force")
+
+	snapshots, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+	clones, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open repository cache: %v", err)
+	}
+
+	output := mustRunCLI(t, "remove", "library")
+	if !strings.Contains(output, "Keeping base-content snapshots for 'library' (still used by: "+first.ConfigPath()+")") {
+		t.Errorf("Expected the snapshots to be kept for the first project, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Keeping cached repository (still used by: "+first.ConfigPath()+")") {
+		t.Errorf("Expected the clone to be kept for the first project, got:\n%s", output)
+	}
+	if !snapshots.HasSnapshot("library", "lib/") || !clones.RepositoryExists(upstream.URL()) {
+		t.Fatal("Expected the first project's snapshot and clone to survive")
+	}
+
+	// The first project can still merge against its base, and is the last user
+	first.Chdir()
+	mustRunCLI(t, "sync", "library", "--merge")
+	output = mustRunCLI(t, "remove", "library")
+	if !strings.Contains(output, "Deleted base-content snapshots for 'library'") || !strings.Contains(output, "Deleted cached repository") {
+		t.Errorf("Expected the last user to delete the snapshots and clone, got:\n%s", output)
+	}
+	if snapshots.HasSourceSnapshots("library") || clones.RepositoryExists(upstream.URL()) {
+		t.Error("Expected no snapshot or clone to be left")
+	}
+
+	// Snapshots and clones from before projects were recorded may belong to anyone
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("change A")
+	addLibrary(first)
+	mustRunCLI(t, "sync", "library", "--force")
+	if err := os.Remove(filepath.Join(snapshots.GetBaseDir(), "library", "projects.yaml")); err != nil {
+		t.Fatalf("Failed to drop the snapshot record: %v", err)
+	}
+	if err := os.Remove(filepath.Join(clones.GetRepositoryPath(upstream.URL()), ".git", "cherry-go-cache.yaml")); err != nil {
+		t.Fatalf("Failed to drop the clone record: %v", err)
+	}
+	output = mustRunCLI(t, "remove", "library")
+	if !strings.Contains(output, "Keeping base-content snapshots for 'library': they were saved by an older cherry-go") ||
+		!strings.Contains(output, "it was cached by an older cherry-go") {
+		t.Errorf("Expected unrecorded state to be kept with a warning, got:\n%s", output)
+	}
+	if !snapshots.HasSourceSnapshots("library") || !clones.RepositoryExists(upstream.URL()) {
+		t.Error("Expected unrecorded snapshots and clone to be kept")
+	}
+}
+
+func TestE2E_ProtectedPaths(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo")
+
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.ProtectedPaths = []string{"lib/b.go"}
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("protect lib/b.go")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B changed\nfunc B() {}\n")
+	upstream.Commit("update helpers")
+
+	// The protected file is refused and the sync fails, naming the rule
+	result := runCLI(t, "sync", "library", "--force")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected a refused protected write to fail the sync, got %s", result)
+	}
+	if !strings.Contains(result.Output, "protected by rule 'lib/b.go'") {
+		t.Errorf("Expected the error to name the rule, got:\n%s", result.Output)
+	}
+	if got := project.ReadFile("lib/b.go"); strings.Contains(got, "B changed") {
+		t.Error("Expected the protected file to be left untouched")
+	}
+
+	// The override lets this run write it
+	mustRunCLI(t, "sync", "library", "--force", "--override-protected")
+	if got := project.ReadFile("lib/b.go"); !strings.Contains(got, "B changed") {
+		t.Errorf("Expected --override-protected to update the file, got %q", got)
+	}
+}
+
+func TestE2E_UntrackedErrorPolicy(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo")
+
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.Untracked = "error"
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.WriteFile("lib/local.go", "package lib\n")
+	project.Commit("local file")
+
+	result := runCLI(t, "sync", "library", "--merge")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected untracked files to fail the sync under the error policy, got %s", result)
+	}
+	if !strings.Contains(result.Output, "lib/local.go") {
+		t.Errorf("Expected the untracked file to be named, got:\n%s", result.Output)
+	}
+}
+
+func TestE2E_DirectoryIncludeWithOrWithoutSlash(t *testing.T) {
+	for _, include := range []string{"lib", "lib/"} {
+		t.Run(include, func(t *testing.T) {
+			upstream := newLibraryFixture(t)
+			project := newCLIProject(t)
+			configureSources(t, project,
+				config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: include}}})
+
+			mustRunCLI(t, "sync", "library", "--force")
+
+			// The first sync settles the directory's canonical form
+			paths := requireSource(t, project, "library").Paths
+			if len(paths) != 1 || paths[0].Include != "lib/" {
+				t.Fatalf("Expected the include to be saved as lib/, got %+v", paths)
+			}
+			if files := paths[0].Files; len(files) != 2 || files["a.go"] == "" || files["b.go"] == "" {
+				t.Errorf("Expected hashes keyed by directory-relative paths, got %v", files)
+			}
+
+			// Merges find the snapshot taken by the first sync
+			project.WriteFile("lib/a.go", "// local\npackage lib\n\nfunc A() {}\n")
+			project.Commit("local change")
+			upstream.WriteFile("lib/a.go", "package lib\n\nfunc A() {}\n\nfunc A2() {}\n")
+			upstream.Commit("upstream change")
+			mustRunCLI(t, "sync", "library", "--merge")
+			if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "// local") || !strings.Contains(got, "A2") {
+				t.Errorf("Expected both changes to be merged, got %q", got)
+			}
+
+			for _, spelling := range []string{"lib", "lib/"} {
+				if result := runCLI(t, "add", "directory", upstream.PathURL(spelling)); !strings.Contains(result.Output, "already being tracked") {
+					t.Errorf("Expected %s to be recognized as the tracked directory, got %s", spelling, result)
+				}
+			}
+		})
+	}
+}
+
+func TestE2E_GeneratedByDowngradeWarning(t *testing.T) {
+	library := newLibraryFixture(t)
+	project := newCLIProject(t)
+
+	output := mustRunCLI(t, "add", "repo", library.URL(), "--name", "library")
+	if strings.Contains(output, "was last written by") {
+		t.Errorf("Expected no downgrade warning for a config this binary wrote, got:\n%s", output)
+	}
+	if cfg := loadProjectConfig(t, project); cfg.GeneratedBy != "cherry-go v"+Version {
+		t.Fatalf("Expected cherry-go v%s to be recorded, got %q", Version, cfg.GeneratedBy)
+	}
+
+	// Pretend a future major version saved the file
+	data := strings.Replace(project.ReadFile(".cherry-go.yaml"), "generated_by: cherry-go v"+Version, "generated_by: cherry-go v99.0.0", 1)
+	project.WriteFile(".cherry-go.yaml", data)
+
+	// Reading only shows the writer
+	output = mustRunCLI(t, "status")
+	if !strings.Contains(output, "Last written by: cherry-go v99.0.0") || strings.Contains(output, "was last written by") {
+		t.Errorf("Expected status to show the writer without the save warning, got:\n%s", output)
+	}
+
+	output = mustRunCLI(t, "remove", "library")
+	if !strings.Contains(output, "was last written by cherry-go v99.0.0") {
+		t.Errorf("Expected a downgrade warning before saving, got:\n%s", output)
+	}
+	if cfg := loadProjectConfig(t, project); cfg.GeneratedBy != "cherry-go v"+Version {
+		t.Errorf("Expected the save to record this binary, got %q", cfg.GeneratedBy)
+	}
+}
+
+func TestE2E_BaseSnapshotsDisabled(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	snapshots, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+
+	// --no-snapshots skips the snapshot for this run only
+	mustRunCLI(t, "sync", "library", "--force", "--no-snapshots")
+	if snapshots.HasSnapshot("library", "lib/") {
+		t.Fatal("Expected --no-snapshots to skip writing the base snapshot")
+	}
+	output := mustRunCLI(t, "sync", "library", "--merge", "--no-snapshots")
+	if !strings.Contains(output, "Base snapshots are disabled") {
+		t.Errorf("Expected merge mode to warn up front, got:\n%s", output)
+	}
+	if output := mustRunCLI(t, "sync", "library", "--force", "--no-snapshots"); strings.Contains(output, "Base snapshots are disabled") {
+		t.Errorf("Expected no merge warning in force mode, got:\n%s", output)
+	}
+
+	// options.base_snapshots: false turns them off for the project, and cache info says so
+	cfg := loadProjectConfig(t, project)
+	disabled := false
+	cfg.Options.BaseSnapshots = &disabled
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("disable snapshots")
+
+	if output := mustRunCLI(t, "cache", "info"); !strings.Contains(output, "Base snapshots: disabled") {
+		t.Errorf("Expected cache info to mention disabled snapshots, got:\n%s", output)
+	}
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("update A")
+	mustRunCLI(t, "sync", "library", "--force")
+	if snapshots.HasSnapshot("library", "lib/") {
+		t.Error("Expected options.base_snapshots: false to skip writing the base snapshot")
+	}
+
+	// Back on, the next sync records the base again
+	cfg = loadProjectConfig(t, project)
+	cfg.Options.BaseSnapshots = nil
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("enable snapshots")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B changed\nfunc B() {}\n")
+	upstream.Commit("update B")
+	mustRunCLI(t, "sync", "library", "--force")
+	if !snapshots.HasSnapshot("library", "lib/") {
+		t.Error("Expected the base snapshot once snapshots are enabled again")
+	}
+}
+
+func TestE2E_EphemeralSourceCleansUp(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Cache:      config.CacheEphemeral,
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	})
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	requireNoClones := func(when string) {
+		t.Helper()
+		if entries, _ := os.ReadDir(tempDir); len(entries) > 0 {
+			t.Errorf("Expected the temporary clone to be removed %s, found %s", when, entries[0].Name())
+		}
+		if entries, _ := os.ReadDir(project.CacheDir()); len(entries) > 0 {
+			t.Errorf("Expected nothing in the cache %s, found %s", when, entries[0].Name())
+		}
+	}
+
+	output := mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected lib/ to be synced, got %q", got)
+	}
+	for _, expected := range []string{"cloned from scratch", "includes fresh clones of 1 ephemeral source(s)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the timing output:\n%s", expected, output)
+		}
+	}
+	requireNoClones("after a successful sync")
+
+	// A sync that fails after cloning still removes the clone
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.ProtectedPaths = []string{"lib/"}
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("protect lib/")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("update A")
+
+	if result := runCLI(t, "sync", "library", "--force"); result.ExitCode == 0 {
+		t.Fatalf("Expected the protected write to fail the sync, got:\n%s", result.Output)
+	}
+	requireNoClones("after a failed sync")
+}
+
+func TestE2E_NoCache(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	})
+	cacheRoot := filepath.Join(project.Home, ".cache", "cherry-go")
+
+	requireNoClones := func(dir, when string) {
+		t.Helper()
+		if _, err := os.Stat(cacheRoot); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing under %s %s, got %v", cacheRoot, when, err)
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, ".cherry-go-clone-*"))
+		if len(matches) > 0 {
+			t.Errorf("Expected the workspace clone to be removed %s, found %v", when, matches)
+		}
+	}
+
+	output := mustRunCLI(t, "sync", "library", "--force", "--no-cache")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected lib/ to be synced, got %q", got)
+	}
+	for _, expected := range []string{"is not cached (--no-cache)", "base-content snapshots are neither read nor recorded"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output:\n%s", expected, output)
+		}
+	}
+	requireNoClones(project.Dir, "after a sync")
+
+	if result := runCLI(t, "sync", "library", "--no-fetch", "--no-cache"); result.ExitCode == 0 {
+		t.Errorf("Expected --no-fetch to be refused without a cache, got:\n%s", result.Output)
+	}
+
+	// The environment variable does the same, cloning into options.tmp_dir
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.TmpDir = "build/tmp"
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("sync lib/ and set tmp_dir")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("update A")
+
+	t.Setenv("CHERRY_GO_NO_CACHE", "1")
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A changed") {
+		t.Errorf("Expected the update to be synced, got %q", got)
+	}
+	if !project.Exists("build/tmp") {
+		t.Error("Expected options.tmp_dir to be used for the clone")
+	}
+	requireNoClones(project.Path("build/tmp"), "with CHERRY_GO_NO_CACHE")
+}
+
+func TestE2E_StatusFixTracking(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	project.WriteFile("lib/a.go", "package lib\n\n// A edited locally\nfunc A() {}\n")
+	before := snapshotTree(t, project.Path("lib"))
+	tracked := requireSource(t, project, "library").Paths[0].Files
+
+	// Without --yes or a terminal to confirm on, nothing is written
+	withPrompt(t, false, nil)
+	if result := runCLI(t, "status", "--fix-tracking"); result.ExitCode == 0 || !strings.Contains(result.Output, "--yes") {
+		t.Fatalf("Expected a non-interactive run to require --yes, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if files := requireSource(t, project, "library").Paths[0].Files; files["a.go"] != tracked["a.go"] {
+		t.Fatal("Expected tracking data to be unchanged without confirmation")
+	}
+
+	// Declining the prompt leaves it alone too
+	withPrompt(t, true, nil)
+	withConfirm(t, false)
+	if result := runCLI(t, "status", "--fix-tracking"); result.ExitCode == 0 {
+		t.Fatalf("Expected a declined confirmation to fail:\n%s", result.Output)
+	}
+
+	withConfirm(t, true)
+	output := mustRunCLI(t, "status", "--fix-tracking", "--refresh-snapshots")
+	if !strings.Contains(output, "library: lib/ a.go: drifted") || !strings.Contains(output, "Corrected 1 tracking entries") {
+		t.Errorf("Expected the corrected entry to be reported, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Refreshed base snapshot of library: lib/") {
+		t.Errorf("Expected snapshots to be refreshed, got:\n%s", output)
+	}
+	if files := requireSource(t, project, "library").Paths[0].Files; files["a.go"] == tracked["a.go"] || files["b.go"] != tracked["b.go"] {
+		t.Errorf("Expected only a.go's hash to change, got %v", files)
+	}
+	if after := snapshotTree(t, project.Path("lib")); !reflect.DeepEqual(before, after) {
+		t.Error("Expected --fix-tracking to leave file contents untouched")
+	}
+
+	// Running it again finds nothing left to fix
+	if output := mustRunCLI(t, "status", "--fix-tracking", "--yes"); !strings.Contains(output, "Tracking data matches the local files") {
+		t.Errorf("Expected nothing left to fix, got:\n%s", output)
+	}
+}
+
+func TestE2E_StatusRefreshSnapshotsDisabled(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	disabled := false
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.BaseSnapshots = &disabled
+	cfg.AddSource(config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	mustRunCLI(t, "sync", "library", "--force")
+	project.WriteFile("lib/a.go", "package lib\n\n// A edited locally\nfunc A() {}\n")
+
+	output := mustRunCLI(t, "status", "--fix-tracking", "--refresh-snapshots", "--yes")
+	if !strings.Contains(output, "Not refreshing snapshots: base snapshots are disabled") || !strings.Contains(output, "Corrected 1 tracking entries") {
+		t.Errorf("Expected the tracking fix without a snapshot refresh, got:\n%s", output)
+	}
+	if strings.Contains(output, "Refreshed base snapshot") {
+		t.Errorf("Expected no snapshot to be refreshed, got:\n%s", output)
+	}
+
+	// The flag keeps what was asked for; only this run's refresh was dropped
+	if !refreshSnapshots {
+		t.Error("Expected --refresh-snapshots to be left as given")
+	}
+}
+
+// newUnauthorizedRemote serves a git remote that rejects every credential
+func newUnauthorizedRemote(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="nightly"`)
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + "/nightly.git"
+}
+
+func TestE2E_OptionalSourceSkippedOnAuthFailure(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	t.Setenv("GIT_PASSWORD", "bogus-token")
+	nightly := config.Source{
+		Name:       "nightly",
+		Repository: newUnauthorizedRemote(t),
+		Auth:       config.AuthConfig{Type: "basic", Username: "ci"},
+		Paths:      []config.PathSpec{{Include: "tools/"}},
+	}
+
+	// A required source that can't authenticate still fails the run
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		nightly)
+	if result := runCLI(t, "sync", "--all", "--force"); result.ExitCode == 0 {
+		t.Fatalf("Expected a required source's auth failure to fail the sync:\n%s", result.Output)
+	}
+
+	// --skip-unauthorized downgrades it to a warning for this run
+	output := mustRunCLI(t, "sync", "--all", "--force", "--skip-unauthorized")
+	if !strings.Contains(output, "Skipped 1 optional source(s) that could not be authenticated or cloned: nightly") {
+		t.Errorf("Expected the skipped source to be listed, got:\n%s", output)
+	}
+
+	// optional: true does the same, and --stat lists it too
+	cfg := loadProjectConfig(t, project)
+	cfg.Sources[1].Optional = true
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("make nightly optional")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("update A")
+	output = mustRunCLI(t, "sync", "--all", "--force", "--stat")
+	if !strings.Contains(output, "skipped (optional): ") || !strings.Contains(output, "Skipped 1 optional source(s)") {
+		t.Errorf("Expected the summary and --stat to list the skipped source, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A changed") {
+		t.Errorf("Expected the required source to sync, got %q", got)
+	}
+	if output := mustRunCLI(t, "sync", "nightly", "--force"); !strings.Contains(output, "Skipped optional source nightly") {
+		t.Errorf("Expected a single-source sync to report the skip, got:\n%s", output)
+	}
+}
+
+func TestE2E_StrictRemotes(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	// Someone repoints the cached clone by hand
+	manager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	cached, err := gogit.PlainOpen(manager.GetRepositoryPath(upstream.URL()))
+	if err != nil {
+		t.Fatalf("Failed to open cached clone: %v", err)
+	}
+	repoConfig, err := cached.Config()
+	if err != nil {
+		t.Fatalf("Failed to read cache config: %v", err)
+	}
+	repoConfig.Remotes["origin"].URLs = []string{"https://example.invalid/elsewhere.git"}
+	if err := cached.SetConfig(repoConfig); err != nil {
+		t.Fatalf("Failed to write cache config: %v", err)
+	}
+
+	result := runCLI(t, "sync", "library", "--force", "--strict-remotes")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "example.invalid/elsewhere.git") {
+		t.Fatalf("Expected --strict-remotes to fail naming the cached origin, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+
+	output := mustRunCLI(t, "sync", "library", "--force")
+	if !strings.Contains(output, "reset its origin to the configured "+upstream.URL()) {
+		t.Errorf("Expected the correction to be logged, got:\n%s", output)
+	}
+}
+
+func TestE2E_SyncNoFetch(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	// Nothing cached yet: --no-fetch has nothing to work from
+	if result := runCLI(t, "sync", "library", "--force", "--no-fetch"); result.ExitCode == 0 || !strings.Contains(result.Output, "needs a cached clone") {
+		t.Fatalf("Expected --no-fetch without a cached clone to fail, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+
+	mustRunCLI(t, "sync", "library", "--force")
+	cached := upstream.Head()
+
+	// Upstream moves on; --no-fetch keeps syncing from the cached commit and records it
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("update A")
+	project.WriteFile("lib/b.go", "local edit\n")
+
+	output := mustRunCLI(t, "sync", "library", "--force", "--no-fetch")
+	if !strings.Contains(output, "lib/ from cached default branch at "+git.ShortHash(cached)) || !strings.Contains(output, "(not fetched)") {
+		t.Errorf("Expected the cached commit to be named, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); strings.Contains(got, "A changed") {
+		t.Error("Expected --no-fetch not to pick up the new upstream commit")
+	}
+	if got := project.ReadFile("lib/b.go"); !strings.Contains(got, "B is the second helper") {
+		t.Errorf("Expected lib/b.go to be restored from the cache, got %q", got)
+	}
+	if commit := requireSource(t, project, "library").Paths[0].Commit; commit != cached {
+		t.Errorf("Expected the cached commit %s to be recorded, got %s", cached, commit)
+	}
+
+	// A normal sync fetches and records the new commit
+	mustRunCLI(t, "sync", "library", "--force")
+	if commit := requireSource(t, project, "library").Paths[0].Commit; commit != upstream.Head() {
+		t.Errorf("Expected the fetched commit %s to be recorded, got %s", upstream.Head(), commit)
+	}
+}
+
+func TestE2E_MaxConflicts(t *testing.T) {
+	upstream := testutil.NewFixtureRepo(t, "generated")
+	for i := 1; i <= 30; i++ {
+		upstream.WriteFile(fmt.Sprintf("gen/f%02d.go", i), "package gen\n\nconst V = 1\n")
+	}
+	upstream.Commit("initial")
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "generated", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "gen/"}}})
+	mustRunCLI(t, "sync", "generated", "--force")
+
+	// Every file changes on both sides on the same line
+	for i := 1; i <= 30; i++ {
+		name := fmt.Sprintf("gen/f%02d.go", i)
+		project.WriteFile(name, "package gen\n\nconst V = 2 // local\n")
+		upstream.WriteFile(name, "package gen\n\nconst V = 3 // upstream\n")
+	}
+	project.Commit("local edits")
+	upstream.Commit("upstream edits")
+
+	result := runCLI(t, "sync", "generated", "--merge", "-v", "--max-conflicts", "5")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected the merge conflicts to fail the sync:\n%s", result.Output)
+	}
+	if shown := strings.Count(result.Output, "Merge conflict detected"); shown != 5 {
+		t.Errorf("Expected 5 conflicts rendered in detail, got %d:\n%s", shown, result.Output)
+	}
+	if !strings.Contains(result.Output, "…and 25 more conflicting file(s) not shown in detail (30 in total") {
+		t.Errorf("Expected the hidden conflicts to be counted, got:\n%s", result.Output)
+	}
+
+	// Detect mode lists the first ones and counts the rest
+	output := mustRunCLI(t, "sync", "generated", "-v", "--max-conflicts", "5")
+	if listed := strings.Count(output, "    • f"); listed != 5 || !strings.Contains(output, "…and 25 more\n") {
+		t.Errorf("Expected 5 listed differences and 25 more, got %d:\n%s", listed, output)
+	}
+
+	// 0 lifts the limit
+	result = runCLI(t, "sync", "generated", "--merge", "-v", "--max-conflicts", "0")
+	if shown := strings.Count(result.Output, "Merge conflict detected"); shown != 30 || strings.Contains(result.Output, "not shown in detail") {
+		t.Errorf("Expected all 30 conflicts rendered with --max-conflicts 0, got %d", shown)
+	}
+}
+
+func TestE2E_SyncRefOverride(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{Name: "library", Repository: upstream.URL(),
+		Paths: []config.PathSpec{{Include: "src/main.go"}, {Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+	project.Commit("sync library")
+
+	upstream.CreateBranch("feature/fix-123")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is fixed on the feature branch\nfunc A() {}\n")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"feature\")\n}\n")
+	upstream.Commit("fix A")
+
+	configBefore := project.ReadFile(".cherry-go.yaml")
+	snapshots, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+	snapshotBefore, _ := snapshots.GetSnapshot("library", "lib/")
+	head, _ := project.Repo().Head()
+
+	// --ref needs a single source
+	if result := runCLI(t, "sync", "--all", "--ref", "feature/fix-123"); result.ExitCode == 0 {
+		t.Fatalf("Expected --ref with --all to fail:\n%s", result.Output)
+	}
+	if result := runCLI(t, "sync", "library", "--path", "docs/", "--ref", "feature/fix-123"); result.ExitCode == 0 || !strings.Contains(result.Output, "does not track docs/") {
+		t.Fatalf("Expected an unknown --path to fail, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+
+	output := mustRunCLI(t, "sync", "library", "--path", "lib/", "--ref", "feature/fix-123", "--force")
+	if !strings.Contains(output, "REF OVERRIDE: syncing lib/ of library from 'feature/fix-123'") {
+		t.Errorf("Expected the override to be announced, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "fixed on the feature branch") {
+		t.Errorf("Expected lib/ to come from the feature branch, got %q", got)
+	}
+	if got := project.ReadFile("src/main.go"); strings.Contains(got, "feature") {
+		t.Error("Expected paths outside --path to be left alone")
+	}
+
+	// Nothing records the override: config, snapshots and git history are unchanged
+	if project.ReadFile(".cherry-go.yaml") != configBefore {
+		t.Error("Expected the config to be left unchanged without --update-tracking")
+	}
+	if snapshotAfter, _ := snapshots.GetSnapshot("library", "lib/"); !reflect.DeepEqual(snapshotBefore, snapshotAfter) {
+		t.Error("Expected the base snapshot to be left unchanged without --update-tracking")
+	}
+	if after, _ := project.Repo().Head(); after.Hash() != head.Hash() {
+		t.Error("Expected no commit of content from a --ref override")
+	}
+
+	// A normal sync goes back to the configured branch
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); strings.Contains(got, "feature branch") {
+		t.Errorf("Expected a normal sync to restore the configured branch, got %q", got)
+	}
+
+	// --update-tracking records the content but keeps the configured branch
+	mustRunCLI(t, "sync", "library", "--path", "lib", "--ref", "feature/fix-123", "--force", "--update-tracking")
+	paths := requireSource(t, project, "library").Paths
+	if paths[1].Commit != upstream.Head() || paths[1].Branch != "" {
+		t.Errorf("Expected lib/ to record %s on its configured branch, got commit %s branch %q", upstream.Head(), paths[1].Commit, paths[1].Branch)
+	}
+	if snapshot, _ := snapshots.GetSnapshot("library", "lib/"); !strings.Contains(string(snapshot["a.go"]), "fixed on the feature branch") {
+		t.Error("Expected --update-tracking to refresh the base snapshot")
+	}
+}
+
+func TestE2E_SyncSelectedPaths(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.WriteFile("docs/guide.md", "# Guide\n")
+	upstream.Commit("add docs")
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{Name: "library", Repository: upstream.URL(),
+		Paths: []config.PathSpec{{Include: "src/main.go"}, {Include: "lib/"}, {Include: "docs/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+	before := requireSource(t, project, "library").Paths
+
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"v2\")\n}\n")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is improved\nfunc A() {}\n")
+	upstream.WriteFile("docs/guide.md", "# Guide v2\n")
+	upstream.Commit("update everything")
+
+	// Unknown paths and several sources are refused
+	result := runCLI(t, "sync", "library:tests/")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "does not track tests/ (tracked paths: src/main.go, lib/, docs/)") {
+		t.Errorf("Expected an unknown path to fail listing the tracked paths, got:\n%s", result.Output)
+	}
+	if result := runCLI(t, "sync", "library:lib/", "tools:bin/"); result.ExitCode == 0 || !strings.Contains(result.Output, "single source name") {
+		t.Errorf("Expected paths of two sources to be refused, got:\n%s", result.Output)
+	}
+
+	// source:path and --path combine, and only the selected paths sync
+	mustRunCLI(t, "sync", "library:lib", "--path", "docs/", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "improved") {
+		t.Errorf("Expected lib/ to be synced, got %q", got)
+	}
+	if got := project.ReadFile("docs/guide.md"); got != "# Guide v2\n" {
+		t.Errorf("Expected docs/ to be synced, got %q", got)
+	}
+	if got := project.ReadFile("src/main.go"); strings.Contains(got, "v2") {
+		t.Error("Expected src/main.go to be left alone")
+	}
+
+	after := requireSource(t, project, "library").Paths
+	if !reflect.DeepEqual(after[0], before[0]) {
+		t.Errorf("Expected the tracking of src/main.go to be untouched, got %+v (was %+v)", after[0], before[0])
+	}
+	if after[1].Commit != upstream.Head() || after[2].Commit != upstream.Head() {
+		t.Errorf("Expected lib/ and docs/ to record %s, got %s and %s", upstream.Head(), after[1].Commit, after[2].Commit)
+	}
+	if after[1].Files["a.go"] == before[1].Files["a.go"] {
+		t.Error("Expected the hash of lib/a.go to be updated")
+	}
+
+	// A plain sync still picks up the rest
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("src/main.go"); !strings.Contains(got, "v2") {
+		t.Errorf("Expected a plain sync to update src/main.go, got %q", got)
+	}
+}
+
+func TestE2E_SyncAllowEmpty(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Exclude: []string{"*.go"}}}})
+
+	result := runCLI(t, "sync", "library", "--force")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "0 files matched include 'lib/' after excludes") {
+		t.Fatalf("Expected a directory excluded to nothing to fail the sync, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if commit := requireSource(t, project, "library").Paths[0].Commit; commit != "" {
+		t.Errorf("Expected lib/ not to be recorded as synced, got %s", commit)
+	}
+
+	output := mustRunCLI(t, "sync", "library", "--force", "--allow-empty")
+	if !strings.Contains(output, "lib/ matches no files after excludes; syncing it empty") {
+		t.Errorf("Expected --allow-empty to warn about the empty directory, got:\n%s", output)
+	}
+}
+
+func TestE2E_MaxPathSize(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}}})
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.MaxPathSize = 64 // src/main.go fits, lib/ doesn't
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("set max_path_size")
+	mustRunCLI(t, "sync", "library")
+
+	result := runCLI(t, "add", "directory", upstream.PathURL("lib/"), "--repo", "library")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "lib/ is 101 B upstream, over options.max_path_size (64B)") {
+		t.Fatalf("Expected adding a directory over the budget to fail with its size, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if paths := requireSource(t, project, "library").Paths; len(paths) != 1 {
+		t.Errorf("Expected lib/ not to be tracked, got %+v", paths)
+	}
+	if project.Exists("lib/a.go") {
+		t.Error("Expected nothing to be copied for a directory over the budget")
+	}
+
+	output := mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--repo", "library", "--allow-large")
+	if !strings.Contains(output, "lib/ is 101 B upstream, over options.max_path_size (64B); syncing it anyway (--allow-large)") {
+		t.Errorf("Expected --allow-large to still show the size, got:\n%s", output)
+	}
+	if !project.Exists("lib/a.go") {
+		t.Error("Expected --allow-large to add lib/")
+	}
+
+	// Every later sync checks the budget too
+	result = runCLI(t, "sync", "library", "--force")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "1 tracked path(s) over options.max_path_size") || !strings.Contains(result.Output, "--allow-large") {
+		t.Fatalf("Expected a sync over the budget to fail, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	mustRunCLI(t, "sync", "library", "--force", "--allow-large")
+
+	output = mustRunCLI(t, "status", "--usage")
+	if !strings.Contains(output, "Size: 45 B, 1 file(s)") {
+		t.Errorf("Expected status --usage to show the size of src/main.go, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Size: 101 B, 2 file(s) ⚠️  over options.max_path_size (64B)") {
+		t.Errorf("Expected status --usage to flag lib/, got:\n%s", output)
+	}
+}
+
+func TestE2E_SyncRendersToCommandOutput(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	var rendered bytes.Buffer
+	rootCmd.SetOut(&rendered)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// Log lines still go to stdout; the --stat table goes to the command's writer
+	output := mustRunCLI(t, "sync", "library", "--force", "--stat")
+	if !strings.Contains(rendered.String(), "2 files changed") {
+		t.Errorf("Expected the --stat table in the command output, got:\n%s", rendered.String())
+	}
+	if strings.Contains(output, "2 files changed") {
+		t.Errorf("Expected the --stat table not to be printed to stdout directly, got:\n%s", output)
+	}
+}
+
+func TestE2E_UpdatePin(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	pin := upstream.Head()
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A moved on\nfunc A() {}\n")
+	upstream.Commit("move A")
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Branch: testutil.DefaultBranch}}})
+
+	result := runCLI(t, "update", "--pin", "library", "lib/", pin[:7])
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "is not a full 40-character commit SHA") {
+		t.Errorf("Expected an abbreviated SHA to be rejected, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	result = runCLI(t, "update", "--pin", "library", "lib/", strings.Repeat("ab", 20))
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "not found in") {
+		t.Errorf("Expected a commit upstream doesn't have to be rejected, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if pathSpec := requireSource(t, project, "library").Paths[0]; pathSpec.Pin != "" || pathSpec.Branch != testutil.DefaultBranch {
+		t.Fatalf("Expected a rejected pin to leave the config alone, got pin %q, branch %q", pathSpec.Pin, pathSpec.Branch)
+	}
+
+	output := mustRunCLI(t, "update", "--pin", "library", "lib", strings.ToUpper(pin))
+	if !strings.Contains(output, "Pinned lib/ of library") {
+		t.Errorf("Expected the pin to be reported, got:\n%s", output)
+	}
+	// Stored like a pin set with the pin command, keeping the branch for unpin
+	if pathSpec := requireSource(t, project, "library").Paths[0]; pathSpec.Pin != pin || pathSpec.Branch != testutil.DefaultBranch {
+		t.Fatalf("Expected lib/ pinned to %s with its branch kept, got pin %q, branch %q", pin, pathSpec.Pin, pathSpec.Branch)
+	}
+	if output := mustRunCLI(t, "update", "--pin", "library", "lib/", pin); !strings.Contains(output, "lib/ of library is already pinned to "+pin) {
+		t.Errorf("Expected the same pin to be recognized, got:\n%s", output)
+	}
+
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A is the first helper") {
+		t.Errorf("Expected the pinned content to be synced, got %q", got)
+	}
+
+	output = mustRunCLI(t, "status")
+	if !strings.Contains(output, "[pinned "+pin+"]") {
+		t.Errorf("Expected status to show the pin, got:\n%s", output)
+	}
+
+	mustRunCLI(t, "unpin", "library", "lib/")
+	if pathSpec := requireSource(t, project, "library").Paths[0]; pathSpec.Pin != "" || pathSpec.Branch != testutil.DefaultBranch {
+		t.Errorf("Expected unpin to return lib/ to its branch, got pin %q, branch %q", pathSpec.Pin, pathSpec.Branch)
+	}
+}
+
+func TestE2E_PinTag(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.Tag("v1.0.0")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A moved on\nfunc A() {}\n")
+	upstream.Commit("move A")
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	result := runCLI(t, "pin", "library", "lib/", "v1.0.O")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "pinned tag 'v1.0.O' not found") {
+		t.Errorf("Expected a mistyped tag to be rejected, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if pin := requireSource(t, project, "library").Paths[0].Pin; pin != "" {
+		t.Fatalf("Expected a rejected pin to leave the config alone, got %q", pin)
+	}
+
+	output := mustRunCLI(t, "pin", "library", "lib", "v1.0.0")
+	if !strings.Contains(output, "Pinned lib/ of library to v1.0.0") {
+		t.Errorf("Expected the pin to be reported, got:\n%s", output)
+	}
+	if pathSpec := requireSource(t, project, "library").Paths[0]; pathSpec.Pin != "v1.0.0" || pathSpec.Branch != "" {
+		t.Fatalf("Expected lib/ pinned to v1.0.0 with its branch kept, got pin %q, branch %q", pathSpec.Pin, pathSpec.Branch)
+	}
+
+	// The project commit names the tag rather than the upstream HEAD
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A is the first helper") {
+		t.Errorf("Expected the tagged content to be synced, got %q", got)
+	}
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read the project commit: %v", err)
+	}
+	if !strings.Contains(commit.Message, "(v1.0.0 at ") {
+		t.Errorf("Expected the commit message to name the pinned tag, got %q", commit.Message)
+	}
+
+	if output := mustRunCLI(t, "status"); !strings.Contains(output, "[pinned v1.0.0]") {
+		t.Errorf("Expected status to show the pin, got:\n%s", output)
+	}
+
+	output = mustRunCLI(t, "unpin", "library", "lib/")
+	if !strings.Contains(output, "Unpinned lib/ of library from v1.0.0") {
+		t.Errorf("Expected the unpin to be reported, got:\n%s", output)
+	}
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A moved on") {
+		t.Errorf("Expected an unpinned path to follow its branch again, got %q", got)
+	}
+}
+
+func TestE2E_SyncPorcelain(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	project.WriteFile("lib/a.go", "package lib\n\n// A was changed locally\nfunc A() {}\n")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A was changed upstream\nfunc A() {}\n")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("change A, add C")
+
+	var records bytes.Buffer
+	rootCmd.SetOut(&records)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// Only the records reach the command's stdout; logs and the diff go to stderr
+	output := mustRunCLI(t, "sync", "library", "--porcelain", "-vv")
+	expected := "CONFLICT\tlibrary\tlib/a.go\tmodified\n"
+	if records.String() != expected {
+		t.Errorf("Expected porcelain records %q, got %q", expected, records.String())
+	}
+	if !strings.Contains(output, "Differences detected in lib/") || strings.Contains(output, "CONFLICT\t") {
+		t.Errorf("Expected log lines, and no records, outside stdout, got:\n%s", output)
+	}
+}
+
+func TestE2E_SyncReportsProjectCommit(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	var records bytes.Buffer
+	rootCmd.SetOut(&records)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	output := mustRunCLI(t, "sync", "library", "--force", "--porcelain")
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit := head.Hash().String()
+
+	if !strings.Contains(records.String(), "COMMIT\tlibrary\t"+commit+"\n") {
+		t.Errorf("Expected a COMMIT record for HEAD %s, got %q", commit, records.String())
+	}
+	if !strings.Contains(output, "Successfully synced library (1 paths updated, committed as "+git.ShortHash(commit)+")") {
+		t.Errorf("Expected the summary to name the commit, got:\n%s", output)
+	}
+
+	entries, _, err := history.Read(history.Path(project.ConfigPath()))
+	if err != nil || len(entries) != 1 || entries[0].Sources[0].Commit != commit {
+		t.Errorf("Expected the history to record commit %s, got %+v, %v", commit, entries, err)
+	}
+}
+
+func TestE2E_SyncAutoCommitOverride(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	headMessage := func() string {
+		head, err := project.Repo().Head()
+		if err != nil {
+			t.Fatalf("Failed to read HEAD: %v", err)
+		}
+		commit, err := project.Repo().CommitObject(head.Hash())
+		if err != nil {
+			t.Fatalf("Failed to read HEAD commit: %v", err)
+		}
+		return commit.Message
+	}
+
+	// auto_commit is on by default; --autocommit=false skips the commit for this run
+	output := mustRunCLI(t, "sync", "library", "--force", "--autocommit=false")
+	if !strings.Contains(output, "--autocommit=false overrides options.auto_commit (true)") {
+		t.Errorf("Expected the override to be announced, got:\n%s", output)
+	}
+	if msg := headMessage(); msg != "configure sources" {
+		t.Errorf("Expected no sync commit with --autocommit=false, got HEAD %q", msg)
+	}
+
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.AutoCommit = false
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("disable auto_commit")
+
+	// A dry run reports the commit the override would create
+	project.WriteFile("lib/a.go", "package lib\n\n// A changed locally\nfunc A() {}\n")
+	output = mustRunCLI(t, "sync", "library", "--force", "--autocommit", "--dry-run")
+	if !strings.Contains(output, "Would create commit with message: cherry-go: sync library") {
+		t.Errorf("Expected the dry run to report the commit, got:\n%s", output)
+	}
+	if msg := headMessage(); msg != "disable auto_commit" {
+		t.Errorf("Expected no commit during a dry run, got HEAD %q", msg)
+	}
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed upstream\nfunc A() {}\n")
+	upstream.Commit("change A")
+	mustRunCLI(t, "sync", "library", "--force", "--autocommit")
+	if msg := headMessage(); !strings.HasPrefix(msg, "cherry-go: sync library") {
+		t.Errorf("Expected --autocommit to commit despite auto_commit: false, got HEAD %q", msg)
+	}
+
+	// Without the flag the config applies again
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B changed upstream\nfunc B() {}\n")
+	upstream.Commit("change B")
+	before := headMessage()
+	mustRunCLI(t, "sync", "library", "--force")
+	if msg := headMessage(); msg != before {
+		t.Errorf("Expected auto_commit: false to apply without the flag, got HEAD %q", msg)
+	}
+}
+
+func TestE2E_ListJSON(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "tools", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/main.go", LocalPath: "tools/main.go"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// Log lines go to stderr, so stdout is the JSON document alone
+	mustRunCLI(t, "list", "--format", "json", "-v")
+	var listed listing
+	if err := json.Unmarshal(stdout.Bytes(), &listed); err != nil {
+		t.Fatalf("Expected JSON on stdout, got %q: %v", stdout.String(), err)
+	}
+	if len(listed.Sources) != 2 || listed.Sources[0].Paths[0].Include != "lib/" || listed.Sources[1].Paths[0].LocalPath != "tools/main.go" {
+		t.Fatalf("Unexpected listing: %+v", listed)
+	}
+	library := listed.Sources[0].Paths[0]
+	if library.TrackedFiles != 2 || library.Commit != upstream.Head() {
+		t.Errorf("Expected lib/ with 2 tracked files at %s, got %+v", upstream.Head(), library)
+	}
+	if listed.Sources[1].Paths[0].Commit != "" {
+		t.Errorf("Expected no commit for the unsynced tools path, got %q", listed.Sources[1].Paths[0].Commit)
+	}
+
+	stdout.Reset()
+	mustRunCLI(t, "list", "--format", "json", "--source", "tools")
+	listed = listing{}
+	if err := json.Unmarshal(stdout.Bytes(), &listed); err != nil || len(listed.Sources) != 1 || listed.Sources[0].Name != "tools" {
+		t.Errorf("Expected only the tools source, got %q (%v)", stdout.String(), err)
+	}
+
+	if result := runCLI(t, "list", "--source", "missing"); result.ExitCode == 0 {
+		t.Error("Expected an unknown source to fail")
+	}
+}
+
+func TestE2E_SyncHistory(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "broken", Repository: project.Path("no-such-repo"), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	mustRunCLI(t, "sync", "library", "--force")
+	mustRunCLI(t, "sync", "library", "--force", "--dry-run") // Dry runs aren't recorded
+	if result := runCLI(t, "sync", "--all"); result.ExitCode == 0 {
+		t.Fatalf("Expected the broken source to fail the sync:\n%s", result.Output)
+	}
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	mustRunCLI(t, "history", "--output", "json")
+	var entries []history.Entry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("Expected a JSON history on stdout, got %q: %v", stdout.String(), err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 recorded runs, got %d: %+v", len(entries), entries)
+	}
+
+	// Newest first: the failed --all run, then the first sync
+	failed, first := entries[0], entries[1]
+	if !reflect.DeepEqual(first.Args, []string{"sync", "library", "--force"}) || first.Version != Version {
+		t.Errorf("Unexpected command line or version: %v %s", first.Args, first.Version)
+	}
+	library := first.Sources[0]
+	if library.Name != "library" || library.Status != history.StatusUpdated || library.Commit == "" || len(library.UpdatedPaths) != 1 {
+		t.Errorf("Expected library updated with a commit, got %+v", library)
+	}
+	statuses := map[string]string{}
+	for _, source := range failed.Sources {
+		statuses[source.Name] = source.Status
+	}
+	if statuses["broken"] != history.StatusFailed || statuses["library"] != history.StatusUpToDate {
+		t.Errorf("Expected broken failed and library up to date, got %v", statuses)
+	}
+
+	stdout.Reset()
+	mustRunCLI(t, "history", "--limit", "1")
+	if !strings.Contains(stdout.String(), "sync --all") || strings.Contains(stdout.String(), "sync library") {
+		t.Errorf("Expected only the newest run, got:\n%s", stdout.String())
+	}
+
+	mustRunCLI(t, "history", "prune", "--keep", "1")
+	remaining, _, err := history.Read(history.Path(project.ConfigPath()))
+	if err != nil || len(remaining) != 1 || remaining[0].Args[1] != "--all" {
+		t.Errorf("Expected only the newest run kept, got %+v (%v)", remaining, err)
+	}
+}
+
+func TestE2E_SyncFromMonorepoSubdirectory(t *testing.T) {
+	logger.Init()
+	upstream := newLibraryFixture(t)
+	project := testutil.NewProject(t)
+
+	// cherry-go runs from services/payments/, two levels below the repository root
+	service := project.Path("services/payments")
+	if err := os.MkdirAll(service, 0755); err != nil {
+		t.Fatal(err)
+	}
+	previous, _ := os.Getwd()
+	if err := os.Chdir(service); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(previous) })
+
+	mustRunCLI(t, "init")
+	configPath := filepath.Join(service, ".cherry-go.yaml")
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AddSource(config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}}})
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatal(err)
+	}
+	project.Commit("configure payments")
+
+	// auto_commit stages the synced file relative to the repository root
+	output := mustRunCLI(t, "sync", "library", "--force")
+	if !strings.Contains(output, "Created commit") {
+		t.Fatalf("Expected a commit to be created, got:\n%s", output)
+	}
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commit.File("services/payments/src/main.go"); err != nil {
+		t.Errorf("Expected services/payments/src/main.go in the sync commit: %v", err)
+	}
+	if _, err := commit.File("src/main.go"); err == nil {
+		t.Error("Expected nothing synced at the repository root")
+	}
+
+	// The conflict branch holds the upstream version at the same place
+	project.WriteFile("services/payments/src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n")
+	project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n")
+	upstream.Commit("upstream change")
+
+	mustRunCLI(t, "sync", "library", "--merge", "--branch-on-conflict")
+	branches, err := git.ListSourceConflictBranches(service, "cherry-go/sync", "library")
+	if err != nil || len(branches) != 1 {
+		t.Fatalf("Expected 1 conflict branch listed from the subdirectory, got %v (%v)", branches, err)
+	}
+	ref, err := project.Repo().Reference(plumbing.NewBranchReferenceName(branches[0]), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchCommit, err := project.Repo().CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := branchCommit.File("services/payments/src/main.go")
+	if err != nil {
+		t.Fatalf("Expected the conflicting file in the branch: %v", err)
+	}
+	if content, _ := file.Contents(); !strings.Contains(content, "remote") {
+		t.Errorf("Expected the upstream version in the branch, got %q", content)
+	}
+	if got := project.ReadFile("services/payments/src/main.go"); !strings.Contains(got, "local") {
+		t.Errorf("Expected the local version to stay checked out, got %q", got)
+	}
+
+	if err := git.DeleteConflictBranch(service, branches[0]); err != nil {
+		t.Errorf("Failed to delete the conflict branch from the subdirectory: %v", err)
+	}
+}
+
+func TestE2E_SyncJobsRecordsEverySource(t *testing.T) {
+	project := newCLIProject(t)
+	var sources []config.Source
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("lib%d", i)
+		sources = append(sources, config.Source{
+			Name:       name,
+			Repository: newLibraryFixture(t).URL(),
+			Paths: []config.PathSpec{
+				{Include: "lib/", LocalPath: "vendor/" + name + "/lib/"},
+				{Include: "src/main.go", LocalPath: "vendor/" + name + "/main.go"},
+			},
+		})
+	}
+	configureSources(t, project, sources...)
+	configured, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+
+	// Every source saves its tracking and commits while the others are syncing
+	mustRunCLI(t, "sync", "--all", "--force", "--jobs", "6")
+
+	for _, source := range sources {
+		saved := requireSource(t, project, source.Name)
+		for _, pathSpec := range saved.Paths {
+			if len(pathSpec.Files) == 0 || pathSpec.Commit == "" {
+				t.Errorf("Expected the tracking of %s of %s to be saved, got %+v", pathSpec.Include, source.Name, pathSpec)
+			}
+		}
+	}
+
+	commits, err := project.Repo().Log(&gogit.LogOptions{})
+	if err != nil {
+		t.Fatalf("Failed to read the project log: %v", err)
+	}
+	count := 0
+	_ = commits.ForEach(func(commit *object.Commit) error {
+		if commit.Hash == configured.Hash() {
+			return storer.ErrStop
+		}
+		count++
+		return nil
+	})
+	if count != len(sources) {
+		t.Errorf("Expected one commit per source, got %d", count)
+	}
+	if got := project.ReadFile("vendor/lib5/lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected the files of every source to be synced, got %q", got)
+	}
+}
+
+func TestE2E_RemovePath(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}, {Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+	project.WriteFile("lib/local.go", "package lib\n")
+
+	snapshots, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+	if !snapshots.HasSnapshot("library", "lib/") {
+		t.Fatal("Expected a snapshot for lib/ after syncing")
+	}
+
+	result := runCLI(t, "remove", "path", "library", "docs/")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "tracked paths: src/main.go, lib/") {
+		t.Errorf("Expected an unknown path to fail listing the tracked ones, got:\n%s", result.Output)
+	}
+
+	// A dry run changes nothing
+	mustRunCLI(t, "remove", "path", "library", "lib", "--delete-local", "--dry-run")
+	if source := requireSource(t, project, "library"); len(source.Paths) != 2 || !project.Exists("lib/a.go") || !snapshots.HasSnapshot("library", "lib/") {
+		t.Fatal("Expected --dry-run to keep the path, its files and its snapshot")
+	}
+
+	mustRunCLI(t, "remove", "path", "library", "lib", "--delete-local")
+	source := requireSource(t, project, "library")
+	if len(source.Paths) != 1 || source.Paths[0].Include != "src/main.go" {
+		t.Fatalf("Expected only src/main.go left, got %+v", source.Paths)
+	}
+	if project.Exists("lib/a.go") || project.Exists("lib/b.go") {
+		t.Error("Expected --delete-local to delete the tracked files")
+	}
+	if !project.Exists("lib/local.go") {
+		t.Error("Expected untracked files to be kept")
+	}
+	if snapshots.HasSnapshot("library", "lib/") {
+		t.Error("Expected the path's snapshot to be deleted")
+	}
+
+	// REPO_URL/path works like add, and the local copy is kept by default
+	mustRunCLI(t, "remove", "path", upstream.PathURL("src/main.go"))
+	if source := requireSource(t, project, "library"); len(source.Paths) != 0 {
+		t.Errorf("Expected no paths left, got %+v", source.Paths)
+	}
+	if !project.Exists("src/main.go") {
+		t.Error("Expected the local copy to be kept without --delete-local")
+	}
+}
+
+func TestE2E_SyncFromCherryBunch(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/main.go"}}})
+
+	bunch := &config.CherryBunch{
+		Name:        "scaffold",
+		Repository:  upstream.URL(),
+		Files:       []config.CherryBunchFileSpec{{Path: "src/main.go", LocalPath: "tools/main.go"}},
+		Directories: []config.CherryBunchDirSpec{{Path: "lib/"}},
+	}
+	bunchPath := filepath.Join(t.TempDir(), "scaffold.cherrybunch")
+	if err := bunch.Save(bunchPath); err != nil {
+		t.Fatalf("Failed to save cherry bunch: %v", err)
+	}
+
+	configBefore := project.ReadFile(".cherry-go.yaml")
+	headBefore, err := project.Repo().Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result := runCLI(t, "sync", "library", "--from-cherrybunch", bunchPath); result.ExitCode == 0 {
+		t.Error("Expected --from-cherrybunch with a source name to fail")
+	}
+
+	mustRunCLI(t, "sync", "--from-cherrybunch", bunchPath, "--force")
+	for _, file := range []string{"tools/main.go", "lib/a.go", "lib/b.go"} {
+		if !project.Exists(file) {
+			t.Errorf("Expected %s to be synced from the cherry bunch", file)
+		}
+	}
+	if got := project.ReadFile(".cherry-go.yaml"); got != configBefore {
+		t.Errorf("Expected the config to be untouched, got:\n%s", got)
+	}
+	if head, _ := project.Repo().Head(); head.Hash() != headBefore.Hash() {
+		t.Error("Expected no commit without --autocommit, even with options.auto_commit on")
+	}
+	if _, err := os.Stat(history.Path(project.ConfigPath())); !os.IsNotExist(err) {
+		t.Error("Expected a one-off sync to leave no history entry")
+	}
+
+	// --autocommit commits the one-off files, and the config still isn't saved
+	project.WriteFile("lib/a.go", "package lib\n\n// A was edited\nfunc A() {}\n")
+	project.Commit("local edit")
+	mustRunCLI(t, "sync", "--from-cherrybunch", bunchPath, "--force", "--autocommit")
+	if got := project.ReadFile("lib/a.go"); strings.Contains(got, "edited") {
+		t.Errorf("Expected --force to restore lib/a.go, got %q", got)
+	}
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(commit.Message, "scaffold") {
+		t.Errorf("Expected a commit for the cherry bunch, got %q", commit.Message)
+	}
+	if got := project.ReadFile(".cherry-go.yaml"); got != configBefore {
+		t.Errorf("Expected the config to be untouched after committing, got:\n%s", got)
+	}
+}
+
+func TestE2E_SyncCommitsUpstreamDeletion(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library")
+
+	upstream.RemoveFile("lib/b.go")
+	upstream.Commit("drop B")
+
+	// Plain sync only reports the deletion
+	output := mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "lib/b.go was removed upstream") || !project.Exists("lib/b.go") {
+		t.Fatalf("Expected the deletion to be reported without deleting, got:\n%s", output)
+	}
+
+	mustRunCLI(t, "sync", "library", "--merge")
+
+	if project.Exists("lib/b.go") {
+		t.Fatal("Expected lib/b.go to be deleted after upstream removed it")
+	}
+
+	// The auto-commit records the deletion, so git sees nothing left to stage for it
+	worktree, err := project.Repo().Worktree()
+	if err != nil {
+		t.Fatalf("Failed to open worktree: %v", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	if _, pending := status["lib/b.go"]; pending {
+		t.Errorf("Expected the deletion to be committed, got status:\n%s", status)
+	}
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %v", err)
+	}
+	if _, err := commit.File("lib/b.go"); err == nil {
+		t.Error("Expected lib/b.go to be gone from the sync commit")
+	}
+
+	if _, ok := requireSource(t, project, "library").Paths[0].Files["b.go"]; ok {
+		t.Error("Expected b.go to be dropped from tracking")
+	}
+}
+
+func TestE2E_AddPatternInclude(t *testing.T) {
+	upstream := testutil.NewFixtureRepo(t, "protos")
+	upstream.WriteFile("api/user.proto", "message User {}\n")
+	upstream.WriteFile("api/v1/order.proto", "message Order {}\n")
+	upstream.WriteFile("api/v1/order.go", "package v1\n")
+	upstream.Commit("initial")
+	project := newCLIProject(t)
+
+	mustRunCLI(t, "add", "file", upstream.PathURL("api/**/*.proto"), "--local-path", "proto", "--yes")
+
+	if !project.Exists("proto/user.proto") || !project.Exists("proto/v1/order.proto") {
+		t.Error("Expected the matching files to be synced under proto/")
+	}
+	if project.Exists("proto/v1/order.go") {
+		t.Error("Expected files outside the pattern to be left out")
+	}
+	pathSpec := requireSource(t, project, "protos").Paths[0]
+	if pathSpec.Include != "api/**/*.proto" || pathSpec.LocalPath != "proto/" {
+		t.Errorf("Expected the pattern tracked under proto/, got %q -> %q", pathSpec.Include, pathSpec.LocalPath)
+	}
+
+	// A new match is synced and committed
+	upstream.WriteFile("api/v2/invoice.proto", "message Invoice {}\n")
+	upstream.Commit("add invoice")
+	mustRunCLI(t, "sync", "protos", "--merge")
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %v", err)
+	}
+	if _, err := commit.File("proto/v2/invoice.proto"); err != nil {
+		t.Errorf("Expected the new match in the sync commit: %v", err)
+	}
+
+	// A pattern that matches nothing is not added
+	result := runCLI(t, "add", "file", upstream.PathURL("api/**/*.thrift"))
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "0 files matched include 'api/**/*.thrift'") {
+		t.Errorf("Expected a pattern without matches to fail, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+	if len(requireSource(t, project, "protos").Paths) != 1 {
+		t.Error("Expected the failed pattern not to be tracked")
+	}
+}
+
+func TestE2E_StatusShowsLastSync(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "never", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/"}}})
+
+	output := mustRunCLI(t, "status")
+	if strings.Count(output, "Last synced: never") != 2 {
+		t.Errorf("Expected both sources to show they were never synced, got:\n%s", output)
+	}
+
+	mustRunCLI(t, "sync", "library", "--force")
+	configBefore := project.ReadFile(".cherry-go.yaml")
+	stateBefore := project.ReadFile(".cherry-go/state.yaml")
+
+	// A dry run records nothing
+	mustRunCLI(t, "sync", "library", "--force", "--dry-run")
+	if project.ReadFile(".cherry-go/state.yaml") != stateBefore {
+		t.Error("Expected a dry run not to record the sync")
+	}
+	output = mustRunCLI(t, "status")
+	if !strings.Contains(output, "Last synced: just now ("+git.ShortHash(upstream.Head())+")") {
+		t.Errorf("Expected status to show the sync and its upstream commit, got:\n%s", output)
+	}
+
+	// An up-to-date sync refreshes the time without touching the configuration
+	mustRunCLI(t, "sync", "library")
+	if project.ReadFile(".cherry-go.yaml") != configBefore {
+		t.Error("Expected the sync state to stay out of the configuration file")
+	}
+
+	output = mustRunCLI(t, "status", "--stale", "7d")
+	if strings.Contains(output, "Source 1: library") || !strings.Contains(output, "Source 2: never") {
+		t.Errorf("Expected only the never-synced source to be listed, got:\n%s", output)
+	}
+	if !strings.Contains(output, "stale (not synced within 7d)") || !strings.Contains(output, "1 of 2 source(s) not synced within 7d") {
+		t.Errorf("Expected the stale source to be highlighted and counted, got:\n%s", output)
+	}
+
+	// Left with conflicts, a source keeps its last successful sync
+	project.WriteFile("lib/a.go", "package lib\n\n// changed locally\n")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// changed upstream\n")
+	previous := upstream.Head()
+	upstream.Commit("change a")
+	runCLI(t, "sync", "library")
+	output = mustRunCLI(t, "status")
+	if !strings.Contains(output, "("+git.ShortHash(previous)+")") {
+		t.Errorf("Expected the last successful sync to be kept, got:\n%s", output)
+	}
+
+	if result := runCLI(t, "status", "--stale", "soon"); result.ExitCode == 0 || !strings.Contains(result.Output, "--stale needs a positive duration") {
+		t.Errorf("Expected an invalid window to be rejected, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+}
+
+func TestE2E_Diff(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+
+	// In sync: exit 0 and nothing on stdout
+	mustRunCLI(t, "diff")
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no diff right after a sync, got %q", stdout.String())
+	}
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, improved\nfunc A() {}\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("change a, drop b, add c")
+
+	stdout.Reset()
+	if result := runCLI(t, "diff", "library"); result.ExitCode != 1 {
+		t.Fatalf("Expected exit code 1 when files differ, got %d:\n%s", result.ExitCode, result.Output)
+	}
+	for _, want := range []string{
+		"--- a/lib/a.go\n+++ b/lib/a.go\n@@ -1,4 +1,4 @@\n package lib\n \n-// A is the first helper\n+// A is the first helper, improved\n func A() {}\n",
+		"--- a/lib/b.go\n+++ /dev/null\n",
+		"--- /dev/null\n+++ b/lib/c.go\n@@ -0,0 +1 @@\n+package lib\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected the diff to contain:\n%s\ngot:\n%s", want, stdout.String())
+		}
+	}
+	if !project.Exists("lib/b.go") || project.Exists("lib/c.go") {
+		t.Error("Expected diff to leave the project alone")
+	}
+
+	stdout.Reset()
+	runCLI(t, "diff", "--name-only")
+	if stdout.String() != "lib/a.go\nlib/b.go\nlib/c.go\n" {
+		t.Errorf("Expected only the paths, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	runCLI(t, "diff", "--stat")
+	if !strings.Contains(stdout.String(), "library\n") || !strings.Contains(stdout.String(), " 3 files changed, 2 insertions(+), 5 deletions(-)") {
+		t.Errorf("Expected a per-file summary, got:\n%s", stdout.String())
+	}
+
+	if result := runCLI(t, "diff", "missing"); result.ExitCode == 0 || !strings.Contains(result.Output, "unknown source(s): missing") {
+		t.Errorf("Expected an unknown source to be rejected, got exit %d:\n%s", result.ExitCode, result.Output)
+	}
+}
+
+func TestE2E_SyncFromOutsideProjectRoot(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	// Run from an unrelated directory, pointing at the project's configuration
+	elsewhere := t.TempDir()
+	if err := os.Chdir(elsewhere); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	mustRunCLI(t, "sync", "library", "--force", "--config", project.ConfigPath())
+
+	if !project.Exists("lib/a.go") || !project.Exists("lib/b.go") {
+		t.Error("Expected the files to land next to the configuration")
+	}
+	if _, err := os.Stat(filepath.Join(elsewhere, "lib")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written to the directory cherry-go ran from, got %v", err)
+	}
+}
+
+func TestE2E_LocalPathOutsideProjectRoot(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL()})
+
+	result := runCLI(t, "add", "directory", upstream.URL()+"/lib/", "--local-path", "../vendor/lib")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected a local path outside the project to be refused:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "outside the project root") {
+		t.Errorf("Expected the error to explain the refusal, got:\n%s", result.Output)
+	}
+	if _, err := os.Stat(filepath.Join(project.Dir, "..", "vendor")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the project, got %v", err)
+	}
+	if source := requireSource(t, project, "library"); len(source.Paths) != 0 {
+		t.Errorf("Expected the path not to be added, got %v", source.Paths)
+	}
+}
+
+func TestE2E_AddDirectoryThenSyncHasNoConflicts(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.WriteFile("lib/internal/c.go", "package internal\n\nfunc C() {}\n")
+	upstream.WriteFile("lib/notes.tmp", "scratch\n")
+	upstream.Commit("nested helper and notes")
+	project := newCLIProject(t)
+
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo", "--exclude", "*.tmp")
+	project.Commit("track lib/")
+
+	// The initial sync tracks every file it copied, keyed by its path inside the directory
+	source := requireSource(t, project, "library")
+	files := source.Paths[0].Files
+	expected := []string{"a.go", "b.go", "internal/c.go"}
+	if len(files) != len(expected) {
+		t.Fatalf("Expected %v to be tracked, got %v", expected, files)
+	}
+	hasher := hash.NewFileHasher()
+	for _, file := range expected {
+		want, err := hasher.HashFile(project.Path("lib/" + file))
+		if err != nil {
+			t.Fatalf("Failed to hash lib/%s: %v", file, err)
+		}
+		if files[file] != want {
+			t.Errorf("Expected %s to be tracked with the hash of the synced file, got %q", file, files[file])
+		}
+	}
+	if source.Paths[0].Commit != upstream.Head() {
+		t.Errorf("Expected lib/ to record commit %s, got %q", upstream.Head(), source.Paths[0].Commit)
+	}
+
+	// A detect-mode sync right after the add finds nothing to report
+	output := mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "Source library is up to date") || strings.Contains(output, "conflict") {
+		t.Errorf("Expected no conflicts right after add, got:\n%s", output)
+	}
+	if project.Exists("lib/notes.tmp") {
+		t.Error("Expected the excluded file not to be synced")
+	}
+	if after := requireSource(t, project, "library"); !reflect.DeepEqual(after.Paths[0].Files, files) {
+		t.Errorf("Expected tracking to be unchanged, got %v", after.Paths[0].Files)
+	}
+}
+
+// withConflictPrompt simulates a terminal where --merge answers each conflicting file as
+// answers says (skipping the others), recording the files it was asked about
+func withConflictPrompt(t *testing.T, answers map[string]interactive.ConflictChoice) *[]string {
+	t.Helper()
+
+	var asked []string
+	prevShould, prevResolve := shouldPromptConflicts, resolveConflictPrompt
+	shouldPromptConflicts = func() bool { return true }
+	resolveConflictPrompt = func(fileName string, local, remote, merged []byte) interactive.ConflictChoice {
+		asked = append(asked, fileName)
+		if !bytes.Contains(merged, []byte("<<<<<<<")) {
+			t.Errorf("Expected the merge of %s to have conflict markers, got %q", fileName, merged)
+		}
+		return answers[fileName]
+	}
+	t.Cleanup(func() {
+		shouldPromptConflicts, resolveConflictPrompt = prevShould, prevResolve
+		git.SetConflictPrompt(nil)
+	})
+	return &asked
+}
+
+func TestE2E_SyncMergePromptsForConflicts(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"))
+
+	local := "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n"
+	remote := "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n"
+	localB := "package lib\n\n// B is the local helper\nfunc B() {}\n"
+	project.WriteFile("src/main.go", local)
+	project.WriteFile("lib/a.go", "package lib\n\n// A is the local helper\nfunc A() {}\n")
+	project.WriteFile("lib/b.go", localB)
+	project.Commit("local changes")
+	upstream.WriteFile("src/main.go", remote)
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the upstream helper\nfunc A() {}\n")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B is the upstream helper\nfunc B() {}\n")
+	upstream.Commit("upstream changes")
+
+	// --non-interactive aborts without asking, even on a terminal
+	asked := withConflictPrompt(t, nil)
+	if result := runCLI(t, "sync", "library", "--merge", "--non-interactive"); result.ExitCode == 0 || len(*asked) != 0 {
+		t.Fatalf("Expected --non-interactive to abort without asking, asked about %v:\n%s", *asked, result.Output)
+	}
+
+	// A skipped file keeps today's abort; markers written for another stay on disk
+	asked = withConflictPrompt(t, map[string]interactive.ConflictChoice{"lib/b.go": interactive.ConflictWriteMarkers})
+	result := runCLI(t, "sync", "library", "--merge")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "merge conflicts detected") {
+		t.Fatalf("Expected a skipped conflict to abort the sync, got:\n%s", result.Output)
+	}
+	sort.Strings(*asked)
+	if !reflect.DeepEqual(*asked, []string{"lib/a.go", "lib/b.go", "src/main.go"}) {
+		t.Fatalf("Expected to be asked about each conflicting file, got %v", *asked)
+	}
+	if got := project.ReadFile("lib/b.go"); !strings.Contains(got, "<<<<<<<") {
+		t.Errorf("Expected markers in the file answered with markers, got %q", got)
+	}
+
+	// Settling every file completes the sync and tracks what was kept
+	project.WriteFile("lib/b.go", localB)
+	project.Commit("undo markers")
+	withConflictPrompt(t, map[string]interactive.ConflictChoice{
+		"src/main.go": interactive.ConflictKeepLocal,
+		"lib/a.go":    interactive.ConflictTakeRemote,
+		"lib/b.go":    interactive.ConflictKeepLocal,
+	})
+	mustRunCLI(t, "sync", "library", "--merge")
+	if got := project.ReadFile("src/main.go"); got != local {
+		t.Errorf("Expected the local src/main.go to be kept, got %q", got)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "upstream helper") {
+		t.Errorf("Expected the upstream lib/a.go to be taken, got %q", got)
+	}
+	source := requireSource(t, project, "library")
+	if want := hash.NewFileHasher().HashBytes([]byte(local)); source.Paths[0].Files["main.go"] != want {
+		t.Errorf("Expected the kept src/main.go to be tracked, got %v", source.Paths[0].Files)
+	}
+	if source.Paths[1].Commit != upstream.Head() {
+		t.Errorf("Expected lib/ to record the upstream commit, got %q", source.Paths[1].Commit)
+	}
+}
+
+func TestE2E_ReproducibleAutoCommit(t *testing.T) {
+	testCases := []struct {
+		policy string
+		epoch  string
+	}{
+		{policy: config.CommitDateSource},
+		{policy: config.CommitDateEpoch, epoch: "1700000000"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			t.Setenv("SOURCE_DATE_EPOCH", tc.epoch)
+			upstream := newLibraryFixture(t)
+			project := newCLIProject(t)
+			configureSources(t, project,
+				config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+			cfg := loadProjectConfig(t, project)
+			cfg.Options.CommitDate = tc.policy
+			if err := cfg.Save(project.ConfigPath()); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+			base := project.Commit("commit_date: " + tc.policy)
+
+			expected := time.Unix(1700000000, 0)
+			if tc.policy == config.CommitDateSource {
+				synced, err := upstream.Repo().CommitObject(plumbing.NewHash(upstream.Head()))
+				if err != nil {
+					t.Fatalf("Failed to read the upstream commit: %v", err)
+				}
+				expected = synced.Committer.When
+			}
+
+			// The same sync of the same inputs, twice
+			var commits []*object.Commit
+			for run := 0; run < 2; run++ {
+				worktree, err := project.Repo().Worktree()
+				if err != nil {
+					t.Fatalf("Failed to get worktree: %v", err)
+				}
+				if err := worktree.Reset(&gogit.ResetOptions{Commit: plumbing.NewHash(base), Mode: gogit.HardReset}); err != nil {
+					t.Fatalf("Failed to reset the project: %v", err)
+				}
+				if run > 0 {
+					time.Sleep(1100 * time.Millisecond) // Commit dates have second resolution
+				}
+
+				mustRunCLI(t, "sync", "library", "--force")
+				head, err := project.Repo().Head()
+				if err != nil {
+					t.Fatalf("Failed to read HEAD: %v", err)
+				}
+				commit, err := project.Repo().CommitObject(head.Hash())
+				if err != nil {
+					t.Fatalf("Failed to read HEAD commit: %v", err)
+				}
+				if commit.Hash.String() == base {
+					t.Fatal("Expected the sync to commit")
+				}
+				commits = append(commits, commit)
+			}
+
+			if commits[0].Hash != commits[1].Hash {
+				t.Errorf("Expected both runs to create the same commit, got %s and %s", commits[0].Hash, commits[1].Hash)
+			}
+			if !commits[0].Author.When.Equal(expected) || !commits[0].Committer.When.Equal(expected) {
+				t.Errorf("Expected the commit to be dated %v, got author %v and committer %v",
+					expected, commits[0].Author.When, commits[0].Committer.When)
+			}
+		})
+	}
+}
+
+func TestE2E_CommitTemplate(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.CommitDate = config.CommitDateEpoch
+	cfg.Options.CommitTemplate = `chore: vendor {{.Source}} {{.ShortCommit}} on {{.Date.Format "2006-01-02"}}` +
+		"\n\n{{.FileCount}} file(s) in {{.Paths}} from {{.Repository}}@{{.Commit}}"
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("commit_template")
+
+	mustRunCLI(t, "sync", "library", "--force")
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD commit: %v", err)
+	}
+
+	expected := fmt.Sprintf("chore: vendor library %s on 2023-11-14\n\n2 file(s) in lib/ from %s@%s",
+		upstream.Head()[:8], upstream.URL(), upstream.Head())
+	if commit.Message != expected {
+		t.Errorf("Expected the commit message %q, got %q", expected, commit.Message)
+	}
+}
+
+func TestE2E_SortSources(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "zeta", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "alpha", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "src/"}}})
+	indexOf := func(output, text string) int {
+		t.Helper()
+		i := strings.Index(output, text)
+		if i == -1 {
+			t.Fatalf("Expected %q in the output, got:\n%s", text, output)
+		}
+		return i
+	}
+
+	// Manual order is the order the sources were added in
+	output := mustRunCLI(t, "status")
+	if indexOf(output, "Source 1: zeta") > indexOf(output, "Source 2: alpha") {
+		t.Errorf("Expected status to keep the configured order, got:\n%s", output)
+	}
+
+	// The summary of a sync lists sources by name, whichever order they finished in
+	mustRunCLI(t, "sync", "--all", "--force")
+	project.WriteFile("lib/a.go", "package lib\n\n// changed locally\n")
+	project.WriteFile("src/main.go", "package main\n\n// changed locally\n")
+	output = runCLI(t, "sync", "--all", "-v").Output
+	summary := output[indexOf(output, "DIFFERENCES DETECTED"):]
+	if indexOf(summary, "Source: \033[36malpha") > indexOf(summary, "Source: \033[36mzeta") {
+		t.Errorf("Expected the summary to list alpha before zeta, got:\n%s", summary)
+	}
+
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.SortSources = config.SortSourcesName
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if saved := project.ReadFile(".cherry-go.yaml"); strings.Index(saved, "name: alpha") > strings.Index(saved, "name: zeta") {
+		t.Errorf("Expected the configuration to be saved in name order, got:\n%s", saved)
+	}
+
+	output = mustRunCLI(t, "status")
+	if indexOf(output, "Source 1: alpha") > indexOf(output, "Source 2: zeta") {
+		t.Errorf("Expected status to list sources by name, got:\n%s", output)
+	}
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	t.Cleanup(func() { rootCmd.SetOut(nil) })
+	mustRunCLI(t, "list", "--format", "json")
+	var listed listing
+	if err := json.Unmarshal(stdout.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to parse the listing: %v\n%s", err, stdout.String())
+	}
+	if len(listed.Sources) != 2 || listed.Sources[0].Name != "alpha" || listed.Sources[1].Name != "zeta" {
+		t.Errorf("Expected list to show alpha then zeta, got %+v", listed.Sources)
+	}
+}
+
+func TestE2E_StatusCheckRemote(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	tools := testutil.NewFixtureRepo(t, "tools")
+	tools.WriteFile("bin/tool.sh", "echo v1\n")
+	tools.Commit("initial")
+
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}, {Include: "src/main.go"}}},
+		config.Source{Name: "tools", Repository: tools.URL(), Paths: []config.PathSpec{{Include: "bin/"}}})
+	mustRunCLI(t, "sync", "--all", "--force")
+
+	output := mustRunCLI(t, "status", "--check-remote")
+	if strings.Count(output, "Upstream: ✓ up to date with "+testutil.DefaultBranch) != 3 {
+		t.Errorf("Expected every path to be up to date, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Every tracked path is up to date with upstream") {
+		t.Errorf("Expected the check to sum up as up to date, got:\n%s", output)
+	}
+
+	synced := upstream.Head()
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, fixed\nfunc A() {}\n")
+	upstream.Commit("lib: fix A")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	newest := upstream.Commit("lib: add C")
+
+	result := runCLI(t, "status", "--check-remote")
+	if result.ExitCode != statusExitPending {
+		t.Fatalf("Expected exit code %d with upstream updates, got %d:\n%s", statusExitPending, result.ExitCode, result.Output)
+	}
+	expected := fmt.Sprintf("Upstream: ⬆️  2 new commit(s) on %s since %s, newest: %s lib: add C",
+		testutil.DefaultBranch, git.ShortHash(synced), git.ShortHash(newest))
+	if !strings.Contains(result.Output, expected) {
+		t.Errorf("Expected lib/ to show its pending commits as %q, got:\n%s", expected, result.Output)
+	}
+	if strings.Count(result.Output, "up to date with") != 2 {
+		t.Errorf("Expected src/main.go and bin/ to stay up to date, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "1 path(s) in 1 source(s) have upstream updates to sync") {
+		t.Errorf("Expected the pending path to be counted, got:\n%s", result.Output)
+	}
+}
+
+func TestE2E_StatusCheckRemoteFiles(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}, {Include: "src/main.go"}}})
+	mustRunCLI(t, "sync", "--all", "--force")
+
+	// Upstream moves, but not in any tracked file
+	upstream.WriteFile("README.md", "# Library\n")
+	upstream.Commit("Add a readme")
+	result := runCLI(t, "status", "--check-remote", "--files")
+	if strings.Count(result.Output, "no relevant upstream changes") != 2 {
+		t.Errorf("Expected both paths to have no relevant upstream changes, got:\n%s", result.Output)
+	}
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, fixed\nfunc A() {}\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("lib: rework")
+
+	result = runCLI(t, "status", "--check-remote", "--files")
+	if result.ExitCode != statusExitPending {
+		t.Fatalf("Expected exit code %d with upstream updates, got %d:\n%s", statusExitPending, result.ExitCode, result.Output)
+	}
+	for _, expected := range []string{"modified upstream: lib/a.go", "deleted upstream: lib/b.go", "added upstream: lib/c.go"} {
+		if !strings.Contains(result.Output, expected) {
+			t.Errorf("Expected %q in the file list, got:\n%s", expected, result.Output)
+		}
+	}
+	if strings.Count(result.Output, "no relevant upstream changes") != 1 {
+		t.Errorf("Expected src/main.go to still have no relevant upstream changes, got:\n%s", result.Output)
+	}
+
+	// Without --files only the commits are shown
+	if result := runCLI(t, "status", "--check-remote"); strings.Contains(result.Output, "upstream: lib/") {
+		t.Errorf("Expected no file list without --files, got:\n%s", result.Output)
+	}
+	if result := runCLI(t, "status", "--files"); result.ExitCode == 0 || !strings.Contains(result.Output, "--files requires --check-remote") {
+		t.Errorf("Expected --files without --check-remote to be refused, got %s", result)
+	}
+}
+
+func TestE2E_EOL(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library")
+
+	// What a checkout with core.autocrlf leaves on Windows: the same files, with CRLF
+	crlf := func(content string) string { return strings.ReplaceAll(content, "\n", "\r\n") }
+	for _, file := range []string{"lib/a.go", "lib/b.go"} {
+		project.WriteFile(file, crlf(project.ReadFile(file)))
+	}
+	project.Commit("checkout with CRLF")
+
+	output := mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "Differences detected") {
+		t.Fatalf("Expected CRLF files to differ byte for byte without options.eol, got:\n%s", output)
+	}
+
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.EOL = config.EOLAuto
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("set eol")
+
+	output = mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "Source library is up to date") || strings.Contains(output, "Differences detected") {
+		t.Fatalf("Expected LF upstream and CRLF locally to be up to date under eol: auto, got:\n%s", output)
+	}
+
+	// An upstream change is merged in and written with the CRLF the local file uses
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, fixed\nfunc A() {}\n")
+	upstream.Commit("fix A")
+	mustRunCLI(t, "sync", "library", "--merge")
+	if got := project.ReadFile("lib/a.go"); got != crlf("package lib\n\n// A is the first helper, fixed\nfunc A() {}\n") {
+		t.Errorf("Expected lib/a.go to take the fix and keep CRLF, got %q", got)
+	}
+	if got := project.ReadFile("lib/b.go"); got != crlf("package lib\n\n// B is the second helper\nfunc B() {}\n") {
+		t.Errorf("Expected lib/b.go to be left alone, got %q", got)
+	}
+	if output := mustRunCLI(t, "sync", "library"); !strings.Contains(output, "Source library is up to date") {
+		t.Errorf("Expected the merged CRLF file to be up to date, got:\n%s", output)
+	}
+}
+
+// isShallowClone reports whether the cached clone of repoURL is missing history
+func isShallowClone(t *testing.T, repoURL string) bool {
+	t.Helper()
+	manager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open the cache: %v", err)
+	}
+	cached, err := gogit.PlainOpen(manager.GetRepositoryPath(repoURL))
+	if err != nil {
+		t.Fatalf("Failed to open the cached clone: %v", err)
+	}
+	shallow, err := cached.Storer.Shallow()
+	if err != nil {
+		t.Fatalf("Failed to read the shallow commits: %v", err)
+	}
+	return len(shallow) > 0
+}
+
+func TestE2E_ShallowClone(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	first := upstream.Head()
+	for i := 2; i <= 4; i++ {
+		upstream.WriteFile("lib/a.go", fmt.Sprintf("package lib\n\n// A, revision %d\nfunc A() {}\n", i))
+		upstream.Commit(fmt.Sprintf("revise A (%d)", i))
+	}
+	upstream.CreateBranch("feature")
+	upstream.WriteFile("lib/extra.go", "package lib\n\n// Extra only exists on feature\nfunc Extra() {}\n")
+	upstream.Commit("add Extra")
+	upstream.Checkout(testutil.DefaultBranch)
+
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		CloneDepth: 1,
+		Paths: []config.PathSpec{
+			{Include: "lib/a.go"},
+			{Include: "lib/extra.go", Branch: "feature"},
+		},
+	})
+
+	// Every branch is cloned at the given depth, so paths on other branches sync without more history
+	output := mustRunCLI(t, "sync", "library", "--force")
+	if strings.Contains(output, "fetching its full history") {
+		t.Errorf("Expected branch heads to be in the shallow clone, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "revision 4") {
+		t.Errorf("Expected the latest lib/a.go, got %q", got)
+	}
+	if !project.Exists("lib/extra.go") {
+		t.Error("Expected lib/extra.go to be synced from the feature branch")
+	}
+	if !isShallowClone(t, upstream.URL()) {
+		t.Fatal("Expected a depth 1 source to be cloned shallow")
+	}
+
+	// Later fetches stay shallow
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A, revision 5\nfunc A() {}\n")
+	upstream.Commit("revise A (5)")
+	mustRunCLI(t, "sync", "library", "--force")
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "revision 5") {
+		t.Errorf("Expected the new upstream commit to be synced, got %q", got)
+	}
+	if !isShallowClone(t, upstream.URL()) {
+		t.Fatal("Expected the clone to stay shallow after a fetch")
+	}
+
+	// Without fetching, a commit outside the clone can't be reached
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		CloneDepth: 1,
+		Paths: []config.PathSpec{
+			{Include: "lib/a.go", Pin: first},
+			{Include: "lib/extra.go", Branch: "feature"},
+		},
+	})
+	if result := runCLI(t, "sync", "library", "--force", "--no-fetch"); result.ExitCode == 0 {
+		t.Errorf("Expected a commit outside the shallow clone to fail with --no-fetch, got:\n%s", result.Output)
+	}
+	if !isShallowClone(t, upstream.URL()) {
+		t.Fatal("Expected --no-fetch to leave the clone shallow")
+	}
+
+	// A pin to a commit outside the clone fetches the full history
+	output = mustRunCLI(t, "sync", "library", "--force")
+	if !strings.Contains(output, "is not in the shallow clone, fetching its full history") {
+		t.Errorf("Expected the deepening to be reported, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A is the first helper") {
+		t.Errorf("Expected the pinned lib/a.go, got %q", got)
+	}
+	if isShallowClone(t, upstream.URL()) {
+		t.Error("Expected the clone to have its full history after deepening")
+	}
+}
+
+func TestE2E_ShallowCloneChangelog(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A, revised\nfunc A() {}\n")
+	upstream.Commit("revise A")
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		CloneDepth: 1,
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	})
+
+	output := mustRunCLI(t, "changelog", "library")
+	if !strings.Contains(output, "initial import") || !strings.Contains(output, "revise A") {
+		t.Errorf("Expected the changelog to read past the clone depth, got:\n%s", output)
+	}
+	if isShallowClone(t, upstream.URL()) {
+		t.Error("Expected the changelog to fetch the full history")
+	}
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// exitCode is the panic value used to intercept logger.Fatal during tests
+type exitCode int
+
+// cliResult captures the outcome of a single command invocation
+type cliResult struct {
+	Output   string
+	ExitCode int
+}
+
+// runCLI executes cherry-go with the given arguments in the current directory,
+// capturing stdout/stderr and converting fatal exits into an exit code
+func runCLI(t *testing.T, args ...string) cliResult {
+	t.Helper()
+
+	resetCommandFlags(rootCmd)
+	rootCmd.SetArgs(args)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = writer, writer
+
+	var captured bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(&captured, reader)
+		close(done)
+	}()
+
+	logger.SetExitFunc(func(code int) { panic(exitCode(code)) })
+
+	result := cliResult{}
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				code, ok := r.(exitCode)
+				if !ok {
+					panic(r)
+				}
+				result.ExitCode = int(code)
+			}
+		}()
+		if err := rootCmd.Execute(); err != nil {
+			result.ExitCode = 1
+		}
+	}()
+
+	logger.SetExitFunc(nil)
+	os.Stdout, os.Stderr = stdout, stderr
+	_ = writer.Close()
+	<-done
+
+	result.Output = captured.String()
+	if testing.Verbose() {
+		t.Logf("$ cherry-go %v (exit %d)\n%s", args, result.ExitCode, result.Output)
+	}
+	return result
+}
+
+// mustRunCLI runs a command and fails the test if it exits non-zero
+func mustRunCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	result := runCLI(t, args...)
+	if result.ExitCode != 0 {
+		t.Fatalf("cherry-go %v exited with %d:\n%s", args, result.ExitCode, result.Output)
+	}
+	return result.Output
+}
+
+// resetCommandFlags restores every flag in the command tree to its default,
+// since cobra binds flags to package-level variables that persist between runs
+func resetCommandFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetCommandFlags(sub)
+	}
+}
+
+// newCLIProject creates an isolated project, switches into it, and initializes the config
+func newCLIProject(t *testing.T) *testutil.Project {
+	t.Helper()
+	logger.Init()
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+	mustRunCLI(t, "init")
+	return project
+}
+
+// loadProjectConfig reads the project's configuration file from disk
+func loadProjectConfig(t *testing.T, project *testutil.Project) *config.Config {
+	t.Helper()
+	loaded, err := config.Load(project.ConfigPath())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return loaded
+}
+
+// requireSource returns the named source from the project's config
+func requireSource(t *testing.T, project *testutil.Project, name string) *config.Source {
+	t.Helper()
+	source, ok := loadProjectConfig(t, project).GetSource(name)
+	if !ok {
+		t.Fatalf("Source %s not found in config", name)
+	}
+	return source
+}
+
+// String makes cliResult readable in failure messages
+func (r cliResult) String() string {
+	return fmt.Sprintf("exit %d:\n%s", r.ExitCode, r.Output)
+}
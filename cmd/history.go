@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/history"
+	"cherry-go/internal/logger"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	historyLimit int
+	historyKeep  int
+
+	// syncCommandLine is the running sync's command line, recorded in its history entry
+	syncCommandLine []string
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show what past syncs did in this project",
+	Long: `Show the sync history of this project, newest first. Every sync run
+(except dry runs) appends an entry to .cherry-go/history.jsonl next to the
+configuration file: when it ran, the cherry-go version and command line, and
+for each source its status, the paths it updated, the commit auto_commit
+created and how many conflicts were left.
+
+Examples:
+  cherry-go history
+  cherry-go history --limit 5
+  cherry-go history --output json | jq '.[0].sources'
+  cherry-go history prune --keep 200`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyLimit < 0 {
+			logger.Fatal("--limit must be 0 (everything) or more")
+		}
+		path := history.Path(configFile)
+		entries, malformed, err := history.Read(path)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		if malformed > 0 {
+			logger.Warning("⚠️  Ignored %d unreadable line(s) in %s", malformed, path)
+		}
+
+		// Newest first, like git log
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[:historyLimit]
+		}
+
+		switch outputFormat {
+		case "json":
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			if entries == nil {
+				entries = []history.Entry{}
+			}
+			if err := encoder.Encode(entries); err != nil {
+				logger.Fatal("Failed to write history: %v", err)
+			}
+		case "text":
+			if len(entries) == 0 {
+				logger.Info("No syncs recorded yet")
+				return
+			}
+			renderHistory(cmd.OutOrStdout(), entries, time.Local)
+		}
+	},
+}
+
+// historyPruneCmd represents the history prune command
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Drop all but the newest history entries",
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyKeep < 0 {
+			logger.Fatal("--keep must be 0 or more")
+		}
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would keep the newest %d history entries", historyKeep)
+			return
+		}
+		removed, err := history.Prune(history.Path(configFile), historyKeep)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		logger.Info("✓ Removed %d history entries, kept up to %d", removed, historyKeep)
+	},
+}
+
+// renderHistory writes a block per entry: when and how cherry-go ran, then a line per source
+func renderHistory(w io.Writer, entries []history.Entry, location *time.Location) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s  %s  (cherry-go v%s)\n", entry.Time.In(location).Format("2006-01-02 15:04:05"),
+			strings.Join(entry.Args, " "), strings.TrimPrefix(entry.Version, "v"))
+
+		nameWidth, statusWidth := 0, 0
+		for _, source := range entry.Sources {
+			nameWidth = max(nameWidth, len(source.Name))
+			statusWidth = max(statusWidth, len(source.Status))
+		}
+		for _, source := range entry.Sources {
+			line := fmt.Sprintf("  %-*s  %-*s  %s", nameWidth, source.Name, statusWidth, source.Status, describeHistorySource(source))
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+}
+
+// describeHistorySource details a source's outcome, e.g. "2 path(s), commit 1a2b3c4d"
+func describeHistorySource(source history.Source) string {
+	var details []string
+	if len(source.UpdatedPaths) > 0 {
+		details = append(details, fmt.Sprintf("%d path(s)", len(source.UpdatedPaths)))
+	}
+	if source.Conflicts > 0 {
+		details = append(details, fmt.Sprintf("%d conflict(s)", source.Conflicts))
+	}
+	if source.Commit != "" {
+		details = append(details, "commit "+git.ShortHash(source.Commit))
+	}
+	if source.Error != "" {
+		details = append(details, source.Error)
+	}
+	return strings.Join(details, ", ")
+}
+
+// recordSyncHistory appends this sync run to the project history. It runs at the end of
+// every sync, failed ones included, except dry runs, which change nothing worth recording,
+// and one-off --from-cherrybunch syncs, which leave no trace in the project's state.
+func recordSyncHistory(results []git.SyncResult) {
+	if logger.IsDryRun() || fromCherryBunch != "" || len(results) == 0 {
+		return
+	}
+	entry := history.Entry{
+		Time:    time.Now().UTC(),
+		Version: Version,
+		Args:    syncCommandLine,
+		Sources: make([]history.Source, 0, len(results)),
+	}
+	for _, result := range results {
+		entry.Sources = append(entry.Sources, historySource(result))
+	}
+	if err := history.Append(history.Path(configFile), entry); err != nil {
+		logger.Warning("⚠️  Failed to record the sync in the history: %v", err)
+	}
+}
+
+// recordSyncState stores when each source of this run last synced successfully, for
+// status. Runs that don't sync what the config tracks (--ref without --update-tracking,
+// --from-cherrybunch) and sources left with conflicts or errors aren't recorded.
+func recordSyncState(results []git.SyncResult) {
+	if logger.IsDryRun() || freezesTracking() {
+		return
+	}
+	now := time.Now().UTC()
+	synced := make(map[string]history.SourceState)
+	for _, result := range results {
+		if result.Error != nil || result.Skipped != nil || result.Duplicate || result.BranchCreated != "" || len(result.Conflicts) > 0 {
+			continue
+		}
+		synced[result.SourceName] = history.SourceState{LastSyncedAt: now, LastSyncedCommit: result.CommitHash}
+	}
+	if err := history.RecordSyncs(history.StatePath(configFile), synced); err != nil {
+		logger.Warning("⚠️  Failed to record the last sync of each source: %v", err)
+	}
+}
+
+// historySource summarizes a source's sync result for the history
+func historySource(result git.SyncResult) history.Source {
+	source := history.Source{
+		Name:         result.SourceName,
+		UpdatedPaths: result.UpdatedPaths,
+		Status:       result.Status(),
+		Commit:       result.ProjectCommit,
+		Conflicts:    len(result.Conflicts),
+	}
+	if result.Error != nil {
+		source.Error = result.Error.Error()
+	} else if result.Skipped != nil {
+		source.Error = result.Skipped.Error()
+	}
+	return source
+}
+
+// commandLine rebuilds how a command was invoked from its parsed flags and arguments,
+// e.g. ["sync", "library", "--force"]
+func commandLine(cmd *cobra.Command) []string {
+	line := strings.Fields(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	line = append(line, cmd.Flags().Args()...)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		if flag.Value.Type() == "bool" && flag.Value.String() == "true" {
+			line = append(line, "--"+flag.Name)
+			return
+		}
+		line = append(line, "--"+flag.Name+"="+flag.Value.String())
+	})
+	return line
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyPruneCmd)
+
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 20, "show at most this many entries, newest first (0 for all)")
+	historyPruneCmd.Flags().IntVar(&historyKeep, "keep", 200, "number of newest entries to keep")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/history"
+)
+
+func TestRenderHistory(t *testing.T) {
+	results := []git.SyncResult{
+		{SourceName: "library", HasChanges: true, UpdatedPaths: []string{"lib/", "docs/"}, ProjectCommit: "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"},
+		{SourceName: "tools"},
+		{SourceName: "private", Error: errors.New("failed to pull changes: authentication required")},
+		{SourceName: "vendor", Conflicts: []hash.FileConflict{{Path: "a.go"}, {Path: "b.go"}}},
+		{SourceName: "assets", Conflicts: []hash.FileConflict{{Path: "logo.png"}}, BranchCreated: "cherry-go/sync/assets"},
+		{SourceName: "extras", Skipped: errors.New("repository not found")},
+	}
+	entry := history.Entry{
+		Time:    time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC),
+		Version: "1.4.0",
+		Args:    []string{"sync", "--all", "--merge"},
+	}
+	for _, result := range results {
+		entry.Sources = append(entry.Sources, historySource(result))
+	}
+	older := history.Entry{
+		Time:    time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC),
+		Version: "1.4.0",
+		Args:    []string{"sync", "library", "--force"},
+		Sources: []history.Source{{Name: "library", Status: history.StatusUpToDate}},
+	}
+
+	var buf bytes.Buffer
+	renderHistory(&buf, []history.Entry{entry, older}, time.UTC)
+	assertGolden(t, "history/entries", buf.Bytes())
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+// postSyncHook is one post_sync command and the environment it runs with
+type postSyncHook struct {
+	owner   string // What it is configured on, for messages: "lib/ of mylib", "mylib" or "options"
+	command string
+	env     []string
+}
+
+// postSyncHooks lists the hooks a sync that updated copyResult's paths runs, in order:
+// each updated path's own, then the source's, then options.post_sync. The path hooks
+// see that path in CHERRY_GO_PATH, CHERRY_GO_LOCAL_PATH and CHERRY_GO_COMMIT; the
+// others see every updated path, one per line, and the source's primary commit.
+func postSyncHooks(source *config.Source, copyResult *git.CopyResult) []postSyncHook {
+	if len(copyResult.UpdatedPaths) == 0 {
+		return nil
+	}
+	updated := make(map[string]bool, len(copyResult.UpdatedPaths))
+	for _, include := range copyResult.UpdatedPaths {
+		updated[include] = true
+	}
+
+	var hooks []postSyncHook
+	add := func(owner string, commands []string, path, localPath, commit string) {
+		env := []string{
+			"CHERRY_GO_SOURCE=" + source.Name,
+			"CHERRY_GO_REPOSITORY=" + source.Repository,
+			"CHERRY_GO_PATH=" + path,
+			"CHERRY_GO_LOCAL_PATH=" + localPath,
+			"CHERRY_GO_COMMIT=" + commit,
+		}
+		for _, command := range commands {
+			hooks = append(hooks, postSyncHook{owner: owner, command: command, env: env})
+		}
+	}
+
+	var includes, localPaths []string
+	for _, pathSpec := range source.Paths {
+		if !updated[pathSpec.Include] {
+			continue
+		}
+		includes = append(includes, pathSpec.Include)
+		localPaths = append(localPaths, pathSpec.LocalRoot())
+		add(fmt.Sprintf("%s of %s", pathSpec.Include, source.Name), pathSpec.PostSync,
+			pathSpec.Include, pathSpec.LocalRoot(), copyResult.PathCommits[pathSpec.Include])
+	}
+	commit := primaryCommit(source, copyResult)
+	add(source.Name, source.PostSync, strings.Join(includes, "\n"), strings.Join(localPaths, "\n"), commit)
+	add("options", cfg.Options.PostSync, strings.Join(includes, "\n"), strings.Join(localPaths, "\n"), commit)
+	return hooks
+}
+
+// runPostSyncHooks runs the post_sync hooks of a source's updated paths from the project
+// root; a dry run only lists them. Under options.on_hook_failure: fail the first failing
+// hook stops the rest and is returned; under warn each failure is only reported.
+func runPostSyncHooks(source *config.Source, copyResult *git.CopyResult, workDir string) error {
+	for _, hook := range postSyncHooks(source, copyResult) {
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would run post_sync hook of %s: %s", hook.owner, hook.command)
+			continue
+		}
+
+		logger.Info("🪝 Running post_sync hook of %s: %s", hook.owner, hook.command)
+		if err := runHook(hook, workDir); err != nil {
+			err = fmt.Errorf("post_sync hook '%s' of %s failed: %w", hook.command, hook.owner, err)
+			if cfg.Options.HookFailurePolicy() == config.HookFailureWarn {
+				logger.Warning("⚠️  %v", err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook runs a hook's command with the platform's shell, logging what it prints as it
+// prints it
+func runHook(hook postSyncHook, workDir string) error {
+	cmd := exec.Command("sh", "-c", hook.command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", hook.command)
+	}
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), hook.env...)
+
+	output := &hookOutput{}
+	cmd.Stdout, cmd.Stderr = output, output
+	err := cmd.Run()
+	output.flush()
+	return err
+}
+
+// hookOutput logs a hook's output a line at a time. The command writes stdout and
+// stderr to the same hookOutput, so exec calls Write from one goroutine at a time.
+type hookOutput struct {
+	pending []byte // Start of a line not terminated yet
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	o.pending = append(o.pending, p...)
+	for {
+		end := bytes.IndexByte(o.pending, '\n')
+		if end < 0 {
+			break
+		}
+		o.log(o.pending[:end])
+		o.pending = o.pending[end+1:]
+	}
+	return len(p), nil
+}
+
+// flush logs a last line the command didn't terminate
+func (o *hookOutput) flush() {
+	if len(o.pending) > 0 {
+		o.log(o.pending)
+		o.pending = nil
+	}
+}
+
+func (o *hookOutput) log(line []byte) {
+	logger.Info("   │ %s", strings.TrimRight(string(line), "\r"))
+}
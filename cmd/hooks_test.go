@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/testutil"
+)
+
+// writeHookScript writes an executable script that appends a line with its name and
+// the hook environment to hooks.log in the project root
+func writeHookScript(t *testing.T, project *testutil.Project, name string) string {
+	t.Helper()
+	script := "hooks/" + name + ".sh"
+	project.WriteFile(script, "#!/bin/sh\n"+
+		"echo \""+name+" $CHERRY_GO_SOURCE [$CHERRY_GO_PATH] [$CHERRY_GO_LOCAL_PATH] $CHERRY_GO_COMMIT\" | tr '\\n' ' ' >> hooks.log\n"+
+		"echo >> hooks.log\n"+
+		"echo \"generated by "+name+"\"\n")
+	if err := os.Chmod(filepath.Join(project.Dir, script), 0755); err != nil {
+		t.Fatalf("Failed to make %s executable: %v", script, err)
+	}
+	return "./" + script
+}
+
+// hookLog returns the lines the hook scripts logged, or nil before any ran
+func hookLog(project *testutil.Project) []string {
+	if !project.Exists("hooks.log") {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(project.ReadFile("hooks.log")), "\n")
+}
+
+func TestE2E_PostSyncHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	pathHook := writeHookScript(t, project, "path")
+	sourceHook := writeHookScript(t, project, "source")
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "lib/", LocalPath: "vendor/lib/", PostSync: []string{pathHook}},
+			{Include: "src/main.go"},
+		},
+		PostSync: []string{sourceHook},
+	})
+
+	// Both paths are new: the path's hook runs, then the source's with both paths
+	output := mustRunCLI(t, "sync", "library")
+	if !strings.Contains(output, "generated by path") || !strings.Contains(output, "generated by source") {
+		t.Errorf("Expected the hooks' output in the log, got:\n%s", output)
+	}
+	lines := hookLog(project)
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "path library [lib/] [vendor/lib/] ") ||
+		!strings.HasPrefix(lines[1], "source library [lib/ src/main.go] [vendor/lib/ src/main.go] ") {
+		t.Fatalf("Expected the path hook then the source hook, got %q", lines)
+	}
+
+	// Nothing updated, nothing runs
+	mustRunCLI(t, "sync", "library")
+	if lines := hookLog(project); len(lines) != 2 {
+		t.Fatalf("Expected no hook to run when nothing updated, got %q", lines)
+	}
+
+	// Only src/main.go updates: lib/'s hook stays quiet
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"v2\")\n}\n")
+	commit := upstream.Commit("main v2")
+	mustRunCLI(t, "sync", "library", "--merge")
+	lines = hookLog(project)
+	if len(lines) != 3 || lines[2] != "source library [src/main.go] [src/main.go] "+commit {
+		t.Fatalf("Expected only the source hook to run, for src/main.go at %s, got %q", commit, lines)
+	}
+
+	// A dry run only lists the hooks it would run
+	project.WriteFile("vendor/lib/a.go", "package lib\n\n// A changed locally\nfunc A() {}\n")
+	output = mustRunCLI(t, "sync", "library", "--force", "--dry-run")
+	if !strings.Contains(output, "Would run post_sync hook of lib/ of library: "+pathHook) {
+		t.Errorf("Expected the dry run to list the path hook, got:\n%s", output)
+	}
+	if lines := hookLog(project); len(lines) != 3 {
+		t.Errorf("Expected no hook to run in a dry run, got %q", lines)
+	}
+}
+
+func TestE2E_PostSyncHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	sourceHook := writeHookScript(t, project, "source")
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/", PostSync: []string{"echo broken >&2; exit 3"}}},
+		PostSync:   []string{sourceHook},
+	})
+
+	// By default the failing hook fails the sync and the hooks after it don't run
+	result := runCLI(t, "sync", "library")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected a failing hook to fail the sync, got %s", result)
+	}
+	if !strings.Contains(result.Output, "of lib/ of library failed: exit status 3") || !strings.Contains(result.Output, "broken") {
+		t.Errorf("Expected the error to name the hook and show its output, got:\n%s", result.Output)
+	}
+	if lines := hookLog(project); lines != nil {
+		t.Errorf("Expected the source hook to be skipped, got %q", lines)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected the synced files to stay in place, got %q", got)
+	}
+
+	// Under warn it is reported and the rest run
+	cfg := loadProjectConfig(t, project)
+	cfg.Options.OnHookFailure = config.HookFailureWarn
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("warn on hook failures")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("change A")
+
+	output := mustRunCLI(t, "sync", "library", "--merge")
+	if !strings.Contains(output, "of lib/ of library failed: exit status 3") {
+		t.Errorf("Expected a warning naming the hook, got:\n%s", output)
+	}
+	if lines := hookLog(project); len(lines) != 1 || !strings.HasPrefix(lines[0], "source library [lib/]") {
+		t.Errorf("Expected the source hook to run after the warning, got %q", lines)
+	}
+}
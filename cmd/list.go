@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	listFormat string
+	listSource string
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tracked sources and paths for scripts",
+	Long: `Print the tracked sources and their paths on stdout as JSON, YAML or a
+table. Unlike status, the output carries no log formatting, so scripts can
+consume it. Log messages, if any, go to stderr.
+
+The JSON and YAML documents have a stable shape: a "sources" list whose
+entries hold name, repository and a "paths" list, each path with include,
+local_path, branch, exclude, tracked_files and commit (empty until the path
+is synced). Fields may be added in future versions but never renamed.
+
+Examples:
+  cherry-go list
+  cherry-go list --format json | jq '.sources[].paths[].include'
+  cherry-go list --format yaml --source library`,
+	Run: func(cmd *cobra.Command, args []string) {
+		formatFromOutput(cmd, &listFormat)
+		sources := cfg.OrderedSources()
+		if listSource != "" {
+			source, exists := cfg.GetSource(listSource)
+			if !exists {
+				logger.Fatal("Source '%s' not found", listSource)
+			}
+			sources = []config.Source{*source}
+		}
+
+		if err := renderList(cmd.OutOrStdout(), listFormat, newListing(sources)); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// listing is the document `list` prints. Its field names are a stable interface.
+type listing struct {
+	Sources []listedSource `json:"sources" yaml:"sources"`
+}
+
+// listedSource is a tracked source in `list` output
+type listedSource struct {
+	Name       string       `json:"name" yaml:"name"`
+	Repository string       `json:"repository" yaml:"repository"`
+	Paths      []listedPath `json:"paths" yaml:"paths"`
+}
+
+// listedPath is a tracked path in `list` output
+type listedPath struct {
+	Include      string   `json:"include" yaml:"include"`
+	LocalPath    string   `json:"local_path" yaml:"local_path"`       // Defaults to include, as sync does
+	Branch       string   `json:"branch" yaml:"branch"`               // Empty for the repository's default branch
+	Pin          string   `json:"pin,omitempty" yaml:"pin,omitempty"` // Tag or commit the path is pinned to, overriding branch
+	Exclude      []string `json:"exclude" yaml:"exclude"`
+	TrackedFiles int      `json:"tracked_files" yaml:"tracked_files"`
+	Commit       string   `json:"commit" yaml:"commit"` // Last synced commit, empty before the first sync
+}
+
+// newListing collects the listed fields of sources. Lists are never nil, so JSON always
+// has arrays where scripts expect them.
+func newListing(sources []config.Source) listing {
+	result := listing{Sources: make([]listedSource, 0, len(sources))}
+	for _, source := range sources {
+		listed := listedSource{Name: source.Name, Repository: source.Repository, Paths: make([]listedPath, 0, len(source.Paths))}
+		for _, pathSpec := range source.Paths {
+			localPath := pathSpec.LocalRoot()
+			exclude := pathSpec.Exclude
+			if exclude == nil {
+				exclude = []string{}
+			}
+			listed.Paths = append(listed.Paths, listedPath{
+				Include:      pathSpec.Include,
+				LocalPath:    localPath,
+				Branch:       pathSpec.Branch,
+				Pin:          pathSpec.Pin,
+				Exclude:      exclude,
+				TrackedFiles: len(pathSpec.Files),
+				Commit:       pathSpec.Commit,
+			})
+		}
+		result.Sources = append(result.Sources, listed)
+	}
+	return result
+}
+
+// renderList writes the listing in the given format: json, yaml or table
+func renderList(w io.Writer, format string, l listing) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(l)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(l); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SOURCE\tINCLUDE\tLOCAL PATH\tBRANCH\tFILES\tCOMMIT")
+		for _, source := range l.Sources {
+			for _, path := range source.Paths {
+				branch := path.Branch
+				if path.Pin != "" {
+					branch = "pin " + path.Pin
+				} else if branch == "" {
+					branch = "(default)"
+				}
+				commit := "-"
+				if path.Commit != "" {
+					commit = git.ShortHash(path.Commit)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", source.Name, path.Include, path.LocalPath, branch, path.TrackedFiles, commit)
+			}
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format '%s' (expected json, yaml or table)", format)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().StringVar(&listFormat, "format", "table", "output format: json, yaml or table")
+	listCmd.Flags().StringVar(&listSource, "source", "", "only list this source")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"cherry-go/internal/config"
+)
+
+// The JSON and YAML documents are a stable interface: only ever add fields to these golden files
+func TestRenderList(t *testing.T) {
+	sources := []config.Source{
+		{
+			Name:       "library",
+			Repository: "https://github.com/example/library.git",
+			Paths: []config.PathSpec{
+				{
+					Include: "lib/",
+					Exclude: []string{"*_test.go"},
+					Commit:  "0123456789abcdef0123456789abcdef01234567",
+					Files:   map[string]string{"a.go": "aaa", "b.go": "bbb"},
+				},
+				{Include: "docs/guide.md", LocalPath: "third_party/guide.md", Branch: "v2"},
+			},
+		},
+		{Name: "empty", Repository: "https://gitlab.com/example/empty.git"},
+	}
+
+	for _, format := range []string{"json", "yaml", "table"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := renderList(&buf, format, newListing(sources)); err != nil {
+				t.Fatalf("renderList failed: %v", err)
+			}
+			assertGolden(t, "list/"+format, buf.Bytes())
+		})
+	}
+
+	if err := renderList(&bytes.Buffer{}, "xml", newListing(sources)); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
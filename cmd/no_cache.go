@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+// noCacheEnv turns on --no-cache when set to a true value, e.g. on CI runners
+const noCacheEnv = "CHERRY_GO_NO_CACHE"
+
+// applyNoCache turns the cache off for this run under --no-cache or CHERRY_GO_NO_CACHE:
+// sources are cloned into temporary directories in the workspace and removed after use,
+// and base-content snapshots are neither read nor written
+func applyNoCache() {
+	if env, err := strconv.ParseBool(os.Getenv(noCacheEnv)); err == nil && env {
+		noCache = true
+	}
+	cache.SetDisabled(noCache)
+	git.SetNoCache("")
+	if !noCache {
+		return
+	}
+
+	dir := noCacheCloneDir()
+	git.SetNoCache(dir)
+	logger.Debug("Cache disabled, cloning sources under %s", dir)
+	if cfg.Options.BaseSnapshotsEnabled() {
+		logger.Warning("⚠️  --no-cache: base-content snapshots are neither read nor recorded in this run")
+	}
+}
+
+// noCacheCloneDir returns the directory --no-cache clones go in: options.tmp_dir,
+// relative to the project root, or the project root itself
+func noCacheCloneDir() string {
+	root, err := config.ProjectRoot(configFile)
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+
+	dir := root
+	if tmpDir := cfg.Options.TmpDir; tmpDir != "" {
+		dir = tmpDir
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Fatal("Failed to create options.tmp_dir %s: %v", dir, err)
+	}
+	return dir
+}
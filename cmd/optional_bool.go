@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// optionalBool is a boolean flag that remembers whether it was given at all, for flags
+// that override a config option only when set. Register it with optionalBoolVar.
+type optionalBool struct {
+	value *bool
+}
+
+// Get returns the flag's value and whether it was given
+func (b *optionalBool) Get() (bool, bool) {
+	if b.value == nil {
+		return false, false
+	}
+	return *b.value, true
+}
+
+// Set parses a boolean; the empty string (the default) clears the flag
+func (b *optionalBool) Set(s string) error {
+	if s == "" {
+		b.value = nil
+		return nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value = &v
+	return nil
+}
+
+// String returns the value, or the empty string when unset
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b.value)
+}
+
+// Type names the flag's value in help output
+func (b *optionalBool) Type() string {
+	return "true|false"
+}
+
+// optionalBoolVar registers an optionalBool flag that also accepts `--name` alone for true
+func optionalBoolVar(cmd *cobra.Command, b *optionalBool, name, usage string) {
+	cmd.Flags().Var(b, name, usage)
+	cmd.Flags().Lookup(name).NoOptDefVal = "true"
+}
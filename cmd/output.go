@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/logger"
+)
+
+// outputFormat is the global --output: "text", or "json" for a JSON document on stdout
+var outputFormat string
+
+// jsonOutput reports whether --output json was given
+func jsonOutput() bool {
+	return outputFormat == "json"
+}
+
+// checkOutputFormat fails on an unknown --output, or json for a command without a JSON document
+func checkOutputFormat(cmd *cobra.Command) {
+	switch outputFormat {
+	case "text":
+	case "json":
+		if cmd != syncCmd && cmd != historyCmd && cmd != listCmd && cmd != verifyCmd && cmd != usageCmd {
+			logger.Fatal("%s has no JSON output (--output json works with sync, history, list, verify and usage)", cmd.CommandPath())
+		}
+	default:
+		logger.Fatal("Unknown output '%s' (expected text or json)", outputFormat)
+	}
+}
+
+// formatFromOutput makes --output json pick the json value of a command's own --format,
+// unless --format was given too
+func formatFromOutput(cmd *cobra.Command, format *string) {
+	if jsonOutput() && !cmd.Flags().Changed("format") {
+		*format = "json"
+	}
+}
+
+// output writes the user-facing blocks commands print besides log lines: conflict
+// instructions, --stat tables, merge diffs and interactive prompts. Commands get one
+// from their cobra.Command and pass it to whatever renders, instead of printing with
+// fmt directly, so the destination can be swapped and captured.
+type output struct {
+	w io.Writer
+}
+
+// newOutput returns the output of a command, stdout unless the command was given another writer
+func newOutput(cmd *cobra.Command) *output {
+	return &output{w: cmd.OutOrStdout()}
+}
+
+// Writer exposes the underlying writer for renderers that take an io.Writer
+func (o *output) Writer() io.Writer {
+	return o.w
+}
+
+// Print writes its arguments as fmt.Print does
+func (o *output) Print(a ...any) {
+	fmt.Fprint(o.w, a...)
+}
+
+// Printf writes formatted text as fmt.Printf does
+func (o *output) Printf(format string, a ...any) {
+	fmt.Fprintf(o.w, format, a...)
+}
+
+// Println writes its arguments and a newline as fmt.Println does
+func (o *output) Println(a ...any) {
+	fmt.Fprintln(o.w, a...)
+}
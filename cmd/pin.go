@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin SOURCE PATH REF",
+	Short: "Pin a tracked path to a tag or commit",
+	Long: `Pin PATH of SOURCE to REF, a tag or a full 40-character commit SHA, without syncing it.
+
+The pin is stored as the path's pin field, next to its branch. Every sync then
+reads the path at the commit the pin resolves to, and checks the checkout is at
+that commit before copying anything. A pin is only ever looked up as a tag or
+a commit, never as a branch, so a mistyped tag fails the sync instead of
+following a moving branch. The command fetches if the cache doesn't have REF
+yet and refuses a ref upstream doesn't have.
+
+Run 'cherry-go sync SOURCE' afterwards to apply the pin, and 'cherry-go unpin'
+to follow the branch again.
+
+Examples:
+  cherry-go pin mylib src/ v1.2.0
+  cherry-go pin mylib LICENSE 3f2c9a0e4b1d7c8e9f00112233445566778899aa`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := pinPathRef(args[0], args[1], args[2]); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin SOURCE PATH",
+	Short: "Let a pinned path follow its branch again",
+	Long: `Remove the pin of PATH of SOURCE, set with pin (or update --pin), without syncing it.
+The path follows its branch again, or the default branch when it has none.
+
+Example:
+  cherry-go unpin mylib src/`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := unpinPath(args[0], args[1]); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// pinPathRef pins a tracked path to a tag or commit that upstream is verified to have
+func pinPathRef(sourceName, include, ref string) error {
+	if config.IsCommitSHA(strings.ToLower(ref)) {
+		ref = strings.ToLower(ref)
+	}
+
+	source, pathSpec, err := findTrackedPath(sourceName, include)
+	if err != nil {
+		return err
+	}
+	if pathSpec.Pin == ref {
+		logger.Info("%s of %s is already pinned to %s", pathSpec.Include, source.Name, ref)
+		return nil
+	}
+
+	commit, err := resolvePinUpstream(source, ref)
+	if err != nil {
+		return err
+	}
+
+	previous := pathSpec.PinnedRef()
+	if previous == "" {
+		previous = "unpinned"
+	}
+	pathSpec.Pin = ref
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would pin %s of %s to %s (was %s)", pathSpec.Include, source.Name, ref, previous)
+		return nil
+	}
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	logger.Info("📌 Pinned %s of %s to %s (%s) (was %s)", pathSpec.Include, source.Name, ref, commit, previous)
+	logger.Info("Run 'cherry-go sync %s' to apply the pin", source.Name)
+	return nil
+}
+
+// resolvePinUpstream resolves a pin in the source's repository, fetching if the cache
+// doesn't have it yet, and describes the commit it names
+func resolvePinUpstream(source *config.Source, ref string) (string, error) {
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", source.Repository, err)
+	}
+	defer closeRepository(repo)
+
+	commit, err := repo.ResolvePin(ref)
+	if err != nil {
+		if fetchErr := repo.Fetch(context.Background()); fetchErr != nil {
+			return "", fetchErr
+		}
+		if commit, err = repo.ResolvePin(ref); err != nil {
+			return "", err
+		}
+	}
+
+	described := git.ShortHash(commit)
+	if when, err := repo.CommitTime(commit); err == nil {
+		described += ", committed " + format.Since(when)
+	}
+	return described, nil
+}
+
+// unpinPath removes a path's pin, whether stored as its pin or as a commit branch by older versions
+func unpinPath(sourceName, include string) error {
+	source, pathSpec, err := findTrackedPath(sourceName, include)
+	if err != nil {
+		return err
+	}
+	pinned := pathSpec.PinnedRef()
+	if pinned == "" {
+		logger.Info("%s of %s is not pinned", pathSpec.Include, source.Name)
+		return nil
+	}
+
+	pathSpec.Pin = ""
+	if config.IsCommitSHA(pathSpec.Branch) {
+		pathSpec.Branch = ""
+	}
+	follows := pathSpec.Branch
+	if follows == "" {
+		follows = "the default branch"
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would unpin %s of %s from %s; it would follow %s", pathSpec.Include, source.Name, pinned, follows)
+		return nil
+	}
+	if err := saveConfig(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	logger.Info("Unpinned %s of %s from %s; it follows %s again", pathSpec.Include, source.Name, pinned, follows)
+	logger.Info("Run 'cherry-go sync %s' to bring it up to date", source.Name)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+var planOut string
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan [source...]",
+	Short: "Write the file changes a sync would make to a plan file, for apply",
+	Long: `Fetch every source (or only the named ones) and work out, file by file, what
+a merge-mode sync would do, without changing the project or the cache checkout.
+The result is a plan: a JSON document listing each file to add, update, merge or
+delete, with the upstream commit it comes from and the hash of the local file
+it was planned against. Review it, then run 'cherry-go apply' to carry it out.
+
+Files unchanged locally take the upstream version. Files changed on both sides
+are merged against their version at the path's last synced commit; the merged
+content is stored in the plan, and files that don't merge are listed as
+conflicts that apply leaves alone. Files changed locally only are left alone.
+Upstream renames are planned as a delete and an add, and protected paths are
+left out.
+
+The plan goes to stdout, or to the file given with -o; log lines go to stderr.
+
+Examples:
+  cherry-go plan -o plan.json
+  cherry-go plan mylib -o plan.json
+  cherry-go apply plan.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		workDir := enterProjectRoot()
+
+		plan := git.NewPlan()
+		for i := range sources {
+			if len(sources[i].Paths) == 0 {
+				continue
+			}
+			sourcePlan, err := planSource(&sources[i], workDir)
+			if err != nil {
+				logger.Fatal("Failed to plan %s: %v", sources[i].Name, err)
+			}
+			for _, path := range sourcePlan.Paths {
+				for _, conflict := range path.Conflicts {
+					logger.Warning("⚠️  %s: %s conflict, left out of the plan", conflict.Path, conflict.Type)
+				}
+			}
+			plan.Sources = append(plan.Sources, sourcePlan)
+		}
+
+		if err := writePlan(cmd, plan, planOut); err != nil {
+			logger.Fatal("Failed to write the plan: %v", err)
+		}
+		actions, conflicts := plan.FileCount()
+		where := "stdout"
+		if planOut != "" && planOut != "-" {
+			where = planOut
+		}
+		logger.Info("📋 Planned %d file change(s) in %d source(s), %d conflict(s) left alone; written to %s",
+			actions, len(plan.Sources), conflicts, where)
+	},
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply PLAN",
+	Short: "Carry out the file changes of a plan file written by plan",
+	Long: `Apply exactly the file changes of a plan written by 'cherry-go plan', and
+update the tracked hashes and commits as a sync would.
+
+Before anything is written, every source is fetched and the plan checked
+against it. The plan is refused as stale, with nothing changed, when upstream
+has moved past the commit it was planned from, a path was synced since, or a
+local file it changes no longer hashes as it did. Plan again then.
+
+Files are written from the planned upstream commit (merges from the plan) and
+options.auto_commit commits them as sync does. Conflicts listed in the plan
+are left alone, and their paths keep their previous commit.
+
+Examples:
+  cherry-go plan -o plan.json
+  cherry-go apply plan.json
+  cherry-go apply plan.json --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plan, err := readPlan(args[0])
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		workDir := enterProjectRoot()
+		if err := applyPlan(plan, workDir); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// planSource fetches a source's repository and plans its sync
+func planSource(source *config.Source, workDir string) (git.SourcePlan, error) {
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		return git.SourcePlan{}, err
+	}
+	defer closeRepository(repo)
+	repo.SetSyncOptions(cfg.Options)
+	if err := repo.Fetch(context.Background()); err != nil {
+		return git.SourcePlan{}, err
+	}
+	return repo.Plan(workDir)
+}
+
+// writePlan writes the plan as indented JSON to path, or to stdout for "" and "-"
+func writePlan(cmd *cobra.Command, plan *git.Plan, path string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" || path == "-" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// readPlan reads a plan file, refusing formats this version doesn't know
+func readPlan(path string) (*git.Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the plan: %w", err)
+	}
+	var plan git.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%s is not a plan: %w", path, err)
+	}
+	if plan.Version < 1 || plan.Version > git.PlanVersion {
+		return nil, fmt.Errorf("%s is a version %d plan; this cherry-go applies version %d", path, plan.Version, git.PlanVersion)
+	}
+	return &plan, nil
+}
+
+// plannedSource is a planned source with the configured source and repository it applies to
+type plannedSource struct {
+	plan   git.SourcePlan
+	source *config.Source
+	repo   *git.Repository
+}
+
+// applyPlan checks every source of the plan before applying any of them, so a stale
+// plan changes nothing, then applies them, saves the configuration and commits
+func applyPlan(plan *git.Plan, workDir string) error {
+	var planned []plannedSource
+	defer func() {
+		for _, p := range planned {
+			closeRepository(p.repo)
+		}
+	}()
+
+	for _, sourcePlan := range plan.Sources {
+		// The configured source itself, so applying updates its tracking
+		var source *config.Source
+		for i := range cfg.Sources {
+			if cfg.Sources[i].Name == sourcePlan.Name {
+				source = &cfg.Sources[i]
+			}
+		}
+		if source == nil {
+			return fmt.Errorf("the plan is stale: source '%s' is no longer configured", sourcePlan.Name)
+		}
+		repo, err := git.NewRepository(source)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", source.Name, err)
+		}
+		planned = append(planned, plannedSource{plan: sourcePlan, source: source, repo: repo})
+		repo.SetSyncOptions(cfg.Options)
+		if err := repo.Fetch(context.Background()); err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", source.Name, err)
+		}
+		if err := repo.CheckPlan(sourcePlan, workDir); err != nil {
+			return fmt.Errorf("the plan is stale: %s: %v; run 'cherry-go plan' again", source.Name, err)
+		}
+	}
+
+	applied, failed := 0, 0
+	for _, p := range planned {
+		result, err := p.repo.ApplyPlan(p.plan, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to apply the plan for %s: %w", p.source.Name, err)
+		}
+		applied += len(result.FileActions)
+		failed += len(result.Failed) + len(result.Refused)
+		if len(result.UpdatedPaths) == 0 || logger.IsDryRun() {
+			continue
+		}
+
+		if err := saveConfig(); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		if cfg.Options.AutoCommit {
+			when, err := p.repo.CommitDate(result)
+			var message string
+			if err == nil {
+				message, err = syncCommitMessage(p.source, result, when)
+			}
+			if err == nil {
+				_, err = git.CreateCommit(workDir, message, result.CommitPaths, when, cfg.Options)
+			}
+			if err != nil {
+				logger.Error("Failed to create commit: %v", err)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("applied %d file change(s), %d failed; the next sync retries them", applied, failed)
+	}
+	logger.Info("✓ Applied %d file change(s) from the plan made %s", applied, format.Since(plan.Created))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	planCmd.Flags().StringVarP(&planOut, "out", "o", "", "file to write the plan to (default: stdout)")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+)
+
+func TestE2E_PlanAndApply(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+	project.Commit("sync library")
+	synced := project.ReadFile("lib/a.go")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, improved\nfunc A() {}\n")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("improve A")
+
+	// Without -o the plan goes to stdout, and nothing changes
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	defer rootCmd.SetOut(nil)
+	mustRunCLI(t, "plan")
+	var plan git.Plan
+	if err := json.Unmarshal(stdout.Bytes(), &plan); err != nil {
+		t.Fatalf("Expected a JSON plan on stdout, got %v:\n%s", err, stdout.String())
+	}
+	if actions, _ := plan.FileCount(); actions != 2 {
+		t.Errorf("Expected 2 planned changes, got %+v", plan)
+	}
+	if project.ReadFile("lib/a.go") != synced || project.Exists("lib/c.go") {
+		t.Fatal("Expected plan to leave the project alone")
+	}
+
+	mustRunCLI(t, "plan", "-o", "plan.json")
+	output := mustRunCLI(t, "apply", "plan.json")
+	if !strings.Contains(output, "Applied 2 file change(s)") {
+		t.Errorf("Expected apply to report its changes, got:\n%s", output)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "improved") || !project.Exists("lib/c.go") {
+		t.Errorf("Expected the plan to be applied, got a.go %q", got)
+	}
+	if source := requireSource(t, project, "library"); source.Paths[0].Commit != upstream.Head() {
+		t.Errorf("Expected lib/ to be at %s, got %s", upstream.Head(), source.Paths[0].Commit)
+	}
+
+	// A plan upstream has moved past is refused, with nothing changed
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B is the second helper, improved\nfunc B() {}\n")
+	upstream.Commit("improve B")
+	mustRunCLI(t, "plan", "-o", "plan.json")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B is the second helper, improved again\nfunc B() {}\n")
+	upstream.Commit("improve B again")
+
+	result := runCLI(t, "apply", "plan.json")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "the plan is stale") {
+		t.Errorf("Expected a stale plan to be refused, got:\n%s", result)
+	}
+	if got := project.ReadFile("lib/b.go"); strings.Contains(got, "improved") {
+		t.Errorf("Expected a refused plan to change nothing, got b.go %q", got)
+	}
+
+	if result := runCLI(t, "apply", "missing.json"); result.ExitCode == 0 || !strings.Contains(result.Output, "failed to read the plan") {
+		t.Errorf("Expected a missing plan file to fail, got:\n%s", result)
+	}
+}
+
+func TestE2E_PlanApplyVerifyModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions aren't POSIX on Windows")
+	}
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "lib/", Mode: "0600", Modes: map[string]string{"b.go": "0640"}},
+			{Include: "src/main.go", Mode: "0755"},
+		},
+	})
+
+	mustRunCLI(t, "plan", "-o", "plan.json")
+	mustRunCLI(t, "apply", "plan.json")
+
+	for file, expected := range map[string]os.FileMode{"lib/a.go": 0600, "lib/b.go": 0640, "src/main.go": 0755} {
+		info, err := os.Stat(project.Path(file))
+		if err != nil {
+			t.Fatalf("Expected %s to be applied: %v", file, err)
+		}
+		if got := info.Mode().Perm(); got != expected {
+			t.Errorf("Expected %s to get mode %o, got %o", file, expected, got)
+		}
+	}
+	source := requireSource(t, project, "library")
+	if got := source.Paths[0].FileModes; got["a.go"] != "0600" || got["b.go"] != "0640" {
+		t.Errorf("Expected the modes of lib/ to be tracked, got %v", got)
+	}
+
+	output := mustRunCLI(t, "verify")
+	if !strings.Contains(output, "library matches its tracking data") {
+		t.Errorf("Expected a clean verify right after apply, got:\n%s", output)
+	}
+}
@@ -1,26 +1,55 @@
 package cmd
 
 import (
+	"os"
+	"strings"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/history"
 	"cherry-go/internal/logger"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	removeKeepCache     bool
+	removeKeepSnapshots bool
+)
+
 // removeCmd represents the remove command
 var removeCmd = &cobra.Command{
 	Use:   "remove [source-name]",
 	Short: "Remove a source repository from tracking",
 	Long: `Remove a source repository and stop tracking its files.
 
+Besides editing the configuration, remove also cleans up state derived from the source:
+- Base-content snapshots used for three-way merges (keep with --keep-snapshots)
+- The cached clone, unless another configured source uses the same URL (keep with --keep-cache)
+- Its last sync in the project's sync state (.cherry-go/state.yaml)
+
+Snapshots and clones live in the cache shared by every project, so they are
+also kept while another project that used them still has a source of the same
+name or URL, and when they were saved by a version that didn't record their
+projects. cherry-go has no lock file; the sync state is the only per-source
+record besides the configuration.
+
+Outstanding conflict branches for the source are listed but never deleted;
+use 'cherry-go cleanup' to remove them. To stop tracking a single file or
+directory instead of the whole source, use 'cherry-go remove path'.
+
 Examples:
   cherry-go remove mylib
-  cherry-go remove private`,
+  cherry-go remove private --keep-cache
+  cherry-go remove mylib --dry-run`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		sourceName := args[0]
 
 		// Check if source exists
-		if _, exists := cfg.GetSource(sourceName); !exists {
+		source, exists := cfg.GetSource(sourceName)
+		if !exists {
 			logger.Fatal("Source '%s' not found", sourceName)
 		}
 
@@ -31,7 +60,7 @@ Examples:
 
 		// Save configuration
 		if !logger.IsDryRun() {
-			if err := cfg.Save(configFile); err != nil {
+			if err := saveConfig(); err != nil {
 				logger.Fatal("Failed to save configuration: %v", err)
 			}
 		}
@@ -42,9 +71,182 @@ Examples:
 		} else {
 			logger.Info("Configuration saved to: %s", configFile)
 		}
+
+		cleanupSourceState(source)
 	},
 }
 
+// cleanupSourceState removes snapshots, cache entries and the sync state derived from a
+// removed source and reports conflict branches that still reference it
+func cleanupSourceState(source *config.Source) {
+	if removeKeepSnapshots {
+		logger.Debug("Keeping base-content snapshots for '%s'", source.Name)
+	} else {
+		removeSourceSnapshots(source.Name)
+	}
+
+	if removeKeepCache {
+		logger.Debug("Keeping cached repository for '%s'", source.Name)
+	} else {
+		removeSourceCache(source)
+	}
+
+	forgetSourceState(source.Name)
+	reportSourceConflictBranches(source.Name)
+}
+
+// removeSourceSnapshots deletes the base-content snapshots of a source. Snapshots are
+// keyed by source name alone and shared by every project, so they are kept while another
+// project still has a source of that name, or when they don't record who uses them.
+func removeSourceSnapshots(sourceName string) {
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		logger.Warning("Could not access base-content snapshots: %v", err)
+		return
+	}
+	if !baseManager.HasSourceSnapshots(sourceName) {
+		logger.Debug("No base-content snapshots for '%s'", sourceName)
+		return
+	}
+
+	projects, recorded := baseManager.SnapshotProjects(sourceName)
+	if !recorded {
+		logger.Warning("⚠️  Keeping base-content snapshots for '%s': they were saved by an older cherry-go that didn't record which projects use them, and another project may have a source of that name", sourceName)
+		return
+	}
+	if users := otherProjectsUsing(projects, func(source config.Source) bool { return source.Name == sourceName }); len(users) > 0 {
+		logger.Info("Keeping base-content snapshots for '%s' (still used by: %s)", sourceName, strings.Join(users, ", "))
+		return
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would delete base-content snapshots for '%s'", sourceName)
+		return
+	}
+
+	if err := baseManager.DeleteSourceSnapshots(sourceName); err != nil {
+		logger.Warning("Failed to delete base-content snapshots: %v", err)
+		return
+	}
+	logger.Info("Deleted base-content snapshots for '%s'", sourceName)
+	collectSnapshotBlobs()
+}
+
+// removeSourceCache deletes the cached clone unless another source, of this project or
+// another one, still uses the URL
+func removeSourceCache(source *config.Source) {
+	if shared := cfg.SourcesUsingRepository(source.Repository, source.Name); len(shared) > 0 {
+		logger.Info("Keeping cached repository (still used by: %v)", shared)
+		return
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		logger.Warning("Could not access repository cache: %v", err)
+		return
+	}
+
+	if !cacheManager.RepositoryExists(source.Repository) {
+		logger.Debug("No cached repository for %s", source.Repository)
+		return
+	}
+
+	repoPath := cacheManager.GetRepositoryPath(source.Repository)
+	if users, known := cachedRepositoryUsers(cacheManager, source.Repository); !known {
+		logger.Warning("⚠️  Keeping cached repository %s: it was cached by an older cherry-go that didn't record which projects use it (remove it with 'cherry-go cache clean --repo %s')", repoPath, source.Repository)
+		return
+	} else if len(users) > 0 {
+		logger.Info("Keeping cached repository (still used by: %s)", strings.Join(users, ", "))
+		return
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would delete cached repository: %s", repoPath)
+		return
+	}
+
+	if err := cacheManager.RemoveRepository(source.Repository); err != nil {
+		logger.Warning("%v", err)
+		return
+	}
+	logger.Info("Deleted cached repository: %s", repoPath)
+}
+
+// cachedRepositoryUsers returns the other projects that still use the cached clone of a
+// repository; known is false when the clone doesn't record its projects
+func cachedRepositoryUsers(cacheManager *cache.Manager, repoURL string) (users []string, known bool) {
+	projects, recorded := cacheManager.RepositoryProjects(repoURL)
+	if !recorded {
+		return nil, false
+	}
+	repoPath := cacheManager.GetRepositoryPath(repoURL)
+	return otherProjectsUsing(projects, func(source config.Source) bool {
+		return cacheManager.GetRepositoryPath(source.Repository) == repoPath
+	}), true
+}
+
+// otherProjectsUsing returns the projects, among those the cache recorded, other than this
+// one whose configuration still has a source uses matches. A project whose configuration
+// is gone needs nothing anymore; one whose configuration can't be read is assumed to.
+func otherProjectsUsing(projects []string, uses func(config.Source) bool) []string {
+	current := projectConfigPath()
+	var users []string
+	for _, project := range projects {
+		if project == current {
+			continue
+		}
+		if _, err := os.Stat(project); os.IsNotExist(err) {
+			continue
+		}
+		other, err := config.Load(project)
+		if err != nil {
+			users = append(users, project)
+			continue
+		}
+		for _, source := range other.Sources {
+			if uses(source) {
+				users = append(users, project)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// forgetSourceState drops a removed source's last sync from the project's sync state
+func forgetSourceState(sourceName string) {
+	if logger.IsDryRun() {
+		return
+	}
+	if err := history.ForgetSources(history.StatePath(configFile), sourceName); err != nil {
+		logger.Warning("⚠️  Failed to forget the last sync of %s: %v", sourceName, err)
+	}
+}
+
+// reportSourceConflictBranches lists conflict branches left behind by the source
+func reportSourceConflictBranches(sourceName string) {
+	workDir := enterProjectRoot()
+	branches, err := git.ListSourceConflictBranches(workDir, cfg.Options.BranchPrefix, sourceName)
+	if err != nil {
+		// Not a git repository - there can't be any conflict branches
+		logger.Debug("Could not list conflict branches: %v", err)
+		return
+	}
+
+	if len(branches) == 0 {
+		return
+	}
+
+	logger.Warning("Source '%s' still has %d conflict branch(es):", sourceName, len(branches))
+	for _, branch := range branches {
+		logger.Warning("  • %s", branch)
+	}
+	logger.Info("Run 'cherry-go cleanup --all' to delete conflict branches")
+}
+
 func init() {
 	rootCmd.AddCommand(removeCmd)
+
+	removeCmd.Flags().BoolVar(&removeKeepCache, "keep-cache", false, "keep the cached repository clone")
+	removeCmd.Flags().BoolVar(&removeKeepSnapshots, "keep-snapshots", false, "keep base-content snapshots used for merges")
 }
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var removePathDeleteLocal bool
+
+// removePathCmd represents the remove path command
+var removePathCmd = &cobra.Command{
+	Use:   "path [source-name] [include-path] | path [url-path]",
+	Short: "Stop tracking a single file or directory of a source",
+	Long: `Stop tracking one file or directory while keeping the rest of its source.
+
+The path is given as the source name and its include path, or as REPO_URL/path
+like 'add file' and 'add directory' take it. Its base-content snapshot is
+deleted; the local copy is kept unless --delete-local is given, which deletes
+the tracked files (untracked files in the same directory are left alone).
+
+Examples:
+  cherry-go remove path mylib src/utils/
+  cherry-go remove path https://github.com/user/repo.git/src/utils/
+  cherry-go remove path mylib config.yaml --delete-local --dry-run`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		source, tracked, err := resolveRemovedPath(args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		pathSpec := *tracked
+		for i := range source.Paths {
+			if &source.Paths[i] == tracked {
+				source.Paths = append(source.Paths[:i], source.Paths[i+1:]...)
+				break
+			}
+		}
+
+		if !logger.IsDryRun() {
+			if err := saveConfig(); err != nil {
+				logger.Fatal("Failed to save configuration: %v", err)
+			}
+		}
+
+		logger.Info("Removed path '%s' from source '%s'", pathSpec.Include, source.Name)
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Configuration would be saved to: %s", configFile)
+		} else {
+			logger.Info("Configuration saved to: %s", configFile)
+		}
+
+		removePathSnapshot(source.Name, pathSpec.Include)
+		if removePathDeleteLocal {
+			deleteTrackedFiles(pathSpec)
+		}
+	},
+}
+
+// resolveRemovedPath locates the path named by `remove path` arguments: a source name and
+// include path, or a single REPO_URL/path
+func resolveRemovedPath(args []string) (*config.Source, *config.PathSpec, error) {
+	if len(args) == 2 {
+		return findTrackedPath(args[0], args[1])
+	}
+
+	parsed, err := utils.ParseURLPath(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	repoURL, includePath := parsed.URL, parsed.Path
+	if repoURL == "" || includePath == "" {
+		return nil, nil, fmt.Errorf("expected <source-name> <include-path> or REPO_URL/path, got '%s'", args[0])
+	}
+
+	// Several sources may track the URL (on different branches); the one tracking the path wins
+	var firstErr error
+	for _, source := range cfg.Sources {
+		if !utils.SameRepository(source.Repository, repoURL) {
+			continue
+		}
+		found, pathSpec, err := findTrackedPath(source.Name, includePath)
+		if err == nil {
+			return found, pathSpec, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		return nil, nil, fmt.Errorf("no source tracks repository %s", repoURL)
+	}
+	return nil, nil, firstErr
+}
+
+// removePathSnapshot deletes the base-content snapshot of a removed path
+func removePathSnapshot(sourceName, include string) {
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		logger.Warning("Could not access base-content snapshots: %v", err)
+		return
+	}
+
+	if !baseManager.HasSnapshot(sourceName, include) {
+		logger.Debug("No base-content snapshot for '%s'", include)
+		return
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would delete the base-content snapshot for '%s'", include)
+		return
+	}
+
+	if err := baseManager.DeleteSnapshot(sourceName, include); err != nil {
+		logger.Warning("Failed to delete base-content snapshot: %v", err)
+		return
+	}
+	logger.Info("Deleted the base-content snapshot for '%s'", include)
+	collectSnapshotBlobs()
+}
+
+// deleteTrackedFiles deletes the local copies of a path's tracked files. For a directory,
+// directories left empty are removed as well; untracked files keep theirs.
+func deleteTrackedFiles(pathSpec config.PathSpec) {
+	localPath := filepath.FromSlash(config.PathKey(pathSpec.LocalRoot()))
+
+	info, err := os.Lstat(localPath)
+	if os.IsNotExist(err) {
+		logger.Info("Local copy %s no longer exists", localPath)
+		return
+	}
+	if err != nil {
+		logger.Warning("Failed to inspect %s: %v", localPath, err)
+		return
+	}
+
+	if !info.IsDir() {
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would delete %s", localPath)
+			return
+		}
+		if err := os.Remove(localPath); err != nil {
+			logger.Warning("Failed to delete %s: %v", localPath, err)
+			return
+		}
+		logger.Info("Deleted %s", localPath)
+		return
+	}
+
+	keys := make([]string, 0, len(pathSpec.Files))
+	for key := range pathSpec.Files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	deleted := 0
+	for _, key := range keys {
+		file := filepath.Join(localPath, filepath.FromSlash(key))
+		if _, err := os.Lstat(file); os.IsNotExist(err) {
+			continue
+		}
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would delete %s", file)
+			deleted++
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			logger.Warning("Failed to delete %s: %v", file, err)
+			continue
+		}
+		// A pattern's root holds files it doesn't own, so only directories it emptied go
+		if pathSpec.IsPattern() {
+			removeEmptyParents(filepath.Dir(file), localPath)
+		}
+		deleted++
+	}
+
+	if logger.IsDryRun() {
+		return
+	}
+	if !pathSpec.IsPattern() {
+		removeEmptyDirectories(localPath)
+	}
+	logger.Info("Deleted %d tracked file(s) from %s", deleted, localPath)
+}
+
+// removeEmptyParents removes dir and its parents while they are empty, stopping at root
+func removeEmptyParents(dir, root string) {
+	root = filepath.Clean(root)
+	inside := func(dir string) bool {
+		return root == "." || strings.HasPrefix(dir, root+string(filepath.Separator))
+	}
+	for dir = filepath.Clean(dir); dir != "." && inside(dir); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return // Not empty - stop here
+		}
+	}
+}
+
+// removeEmptyDirectories removes root and the directories under it that hold no files,
+// deepest first. The working directory itself is never removed.
+func removeEmptyDirectories(root string) {
+	var dirs []string
+	_ = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if filepath.Clean(dirs[i]) == "." {
+			continue
+		}
+		_ = os.Remove(dirs[i]) // Fails, as intended, on directories that still hold something
+	}
+}
+
+func init() {
+	removeCmd.AddCommand(removePathCmd)
+
+	removePathCmd.Flags().BoolVar(&removePathDeleteLocal, "delete-local", false, "also delete the local copy of the tracked files")
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/interactive"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+	"cherry-go/internal/utils"
+)
+
+var resolveDeleteBranch bool
+
+// Hooks for the interactive resolution steps, replaced in tests
+var (
+	shouldPromptResolve = interactive.ShouldPrompt
+	conflictEditor      = interactive.Editor
+	editConflictFile    = interactive.EditFile
+)
+
+// resolveCmd represents the resolve command
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <branch>",
+	Short: "Merge a conflict branch into the current branch",
+	Long: `Merge a conflict branch created by 'sync --merge --branch-on-conflict' into the
+current branch, replacing the git commands its instructions list.
+
+In a terminal, files left with conflicts are opened one by one in $VISUAL or
+$EDITOR (without one, each file is named and cherry-go waits until you have
+fixed it). Files without conflict markers left are staged, the merge is
+committed and you are offered to delete the branch.
+
+Otherwise the merge runs and, if files conflict, they are listed and the command
+fails with the merge left in progress. Fix and 'git add' them, then run resolve
+again to commit the merge, or run 'git merge --abort' to give up.
+
+Examples:
+  cherry-go cleanup                                    # List conflict branches
+  cherry-go resolve cherry-go/sync/mylib-20241212-120000
+  cherry-go resolve cherry-go/sync/mylib-20241212-120000 --delete-branch`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workDir := enterProjectRoot()
+		branchName := args[0]
+
+		if err := merge.CheckGit(); err != nil {
+			logger.Fatal("resolve runs the merge with the git CLI: %v", err)
+		}
+		requireConflictBranch(workDir, branchName)
+
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would merge %s into the current branch", branchName)
+			return
+		}
+
+		result, err := git.MergeConflictBranch(workDir, branchName)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		if result.Resumed {
+			logger.Info("Continuing the merge of %s into %s", branchName, result.Into)
+		}
+
+		prompt := shouldPromptResolve()
+		conflicts := result.Conflicts
+		if len(conflicts) > 0 && prompt {
+			conflicts = resolveConflictsInteractively(workDir, conflicts)
+		}
+		if len(conflicts) > 0 {
+			logger.Error("❌ %d file(s) have conflicts merging %s into %s:", len(conflicts), branchName, result.Into)
+			for _, file := range conflicts {
+				logger.Error("  • %s", utils.DisplayPath(file))
+			}
+			logger.Info("")
+			logger.Info("Fix them and stage each with 'git add', then run 'cherry-go resolve %s' again", branchName)
+			logger.Info("to commit the merge, or give up with 'git merge --abort'.")
+			logger.Exit(1)
+		}
+
+		if !result.Committed {
+			if err := git.CommitMerge(workDir); err != nil {
+				logger.Fatal("%v", err)
+			}
+		}
+		logger.Info("✓ Merged %s into %s", branchName, result.Into)
+
+		if resolveDeleteBranch || prompt && confirmPrompt("Delete branch "+branchName+"?", true) {
+			if err := git.DeleteConflictBranch(workDir, branchName); err != nil {
+				logger.Fatal("%v", err)
+			}
+			logger.Info("✓ Deleted branch %s", branchName)
+		} else {
+			logger.Info("Delete it with 'cherry-go cleanup --all' or 'git branch -d %s'", branchName)
+		}
+	},
+}
+
+// requireConflictBranch fails unless branchName is one of the conflict branches, listing them
+func requireConflictBranch(workDir, branchName string) {
+	branches, err := git.ListConflictBranches(workDir, cfg.Options.BranchPrefix)
+	if err != nil {
+		logger.Fatal("Failed to list conflict branches: %v", err)
+	}
+	for _, branch := range branches {
+		if branch == branchName {
+			return
+		}
+	}
+
+	if len(branches) == 0 {
+		logger.Fatal("'%s' is not a conflict branch; there are none with prefix '%s'", branchName, cfg.Options.BranchPrefix)
+	}
+	logger.Error("'%s' is not a conflict branch. Conflict branches:", branchName)
+	for _, branch := range branches {
+		logger.Error("  • %s", branch)
+	}
+	logger.Exit(1)
+}
+
+// resolveConflictsInteractively walks through the conflicted files, in the editor when one
+// is set, and stages each one left without conflict markers. It returns the files still
+// unresolved.
+func resolveConflictsInteractively(workDir string, conflicts []string) []string {
+	editor := conflictEditor()
+	if editor == "" {
+		logger.Info("No $VISUAL or $EDITOR set: fix each file in your editor of choice when asked")
+	}
+
+	var remaining []string
+	for i, file := range conflicts {
+		logger.Info("")
+		logger.Info("Conflict %d/%d: %s", i+1, len(conflicts), file)
+		if editor != "" {
+			if !confirmPrompt("Open "+file+" in "+editor+"?", true) {
+				remaining = append(remaining, file)
+				continue
+			}
+			if err := editConflictFile(editor, file); err != nil {
+				logger.Warning("⚠️  %v", err)
+				remaining = append(remaining, file)
+				continue
+			}
+		} else if !confirmPrompt("Is "+file+" fixed?", true) {
+			remaining = append(remaining, file)
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err == nil && merge.ContainsConflictMarkers(content) {
+			logger.Warning("⚠️  %s still has conflict markers", file)
+			remaining = append(remaining, file)
+			continue
+		}
+		// A missing file resolves the conflict as a deletion, which git add stages too
+		if err := git.StageResolvedFile(workDir, file); err != nil {
+			logger.Warning("⚠️  %v", err)
+			remaining = append(remaining, file)
+			continue
+		}
+		logger.Info("✓ Resolved %s", file)
+	}
+	return remaining
+}
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+
+	resolveCmd.Flags().BoolVar(&resolveDeleteBranch, "delete-branch", false, "delete the branch once it is merged, without asking")
+}
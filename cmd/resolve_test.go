@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/testutil"
+)
+
+// newConflictBranchProject syncs a conflicting upstream change into a conflict branch, then
+// changes the same line locally again so merging the branch conflicts
+func newConflictBranchProject(t *testing.T) (*testutil.Project, string) {
+	t.Helper()
+	// The merge commit is made by the git CLI, which needs an identity
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test User")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+	project.Commit("track main.go")
+
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n")
+	project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n")
+	upstream.Commit("upstream change")
+	mustRunCLI(t, "sync", "library", "--merge", "--branch-on-conflict")
+
+	branches, err := git.ListSourceConflictBranches(project.Dir, "cherry-go/sync", "library")
+	if err != nil || len(branches) != 1 {
+		t.Fatalf("Expected one conflict branch, got %v (%v)", branches, err)
+	}
+
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local, again\")\n}\n")
+	project.Commit("another local change")
+	return project, branches[0]
+}
+
+// withResolvePrompt stubs whether resolve runs interactively and the editor it opens
+func withResolvePrompt(t *testing.T, prompt bool, editor string, edit func(editor, path string) error) {
+	t.Helper()
+
+	prevShould, prevEditor, prevEdit := shouldPromptResolve, conflictEditor, editConflictFile
+	shouldPromptResolve = func() bool { return prompt }
+	conflictEditor = func() string { return editor }
+	editConflictFile = edit
+	t.Cleanup(func() {
+		shouldPromptResolve, conflictEditor, editConflictFile = prevShould, prevEditor, prevEdit
+	})
+}
+
+func branchExists(t *testing.T, project *testutil.Project, branch string) bool {
+	t.Helper()
+	branches, err := git.ListConflictBranches(project.Dir, "cherry-go/sync")
+	if err != nil {
+		t.Fatalf("Failed to list conflict branches: %v", err)
+	}
+	for _, name := range branches {
+		if name == branch {
+			return true
+		}
+	}
+	return false
+}
+
+func TestE2E_Resolve_NonInteractive(t *testing.T) {
+	project, branch := newConflictBranchProject(t)
+	withResolvePrompt(t, false, "", nil)
+
+	result := runCLI(t, "resolve", "cherry-go/sync/unknown-20240101-000000")
+	if result.ExitCode == 0 || !strings.Contains(result.Output, "• "+branch) {
+		t.Errorf("Expected an unknown branch to fail listing the conflict branches, got:\n%s", result.Output)
+	}
+
+	mustRunCLI(t, "resolve", branch, "--dry-run")
+	if strings.Contains(project.ReadFile("src/main.go"), "<<<<<<<") {
+		t.Fatal("Expected a dry run to leave the project alone")
+	}
+
+	// The conflict is reported and the merge left in progress
+	result = runCLI(t, "resolve", branch)
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected conflicts to fail resolve, got:\n%s", result.Output)
+	}
+	for _, expected := range []string{"1 file(s) have conflicts", "• src/main.go", "git merge --abort"} {
+		if !strings.Contains(result.Output, expected) {
+			t.Errorf("Expected %q in the output, got:\n%s", expected, result.Output)
+		}
+	}
+	if !strings.Contains(project.ReadFile("src/main.go"), "<<<<<<<") {
+		t.Error("Expected conflict markers in src/main.go")
+	}
+
+	// Running it again before the file is staged still reports it
+	if result := runCLI(t, "resolve", branch); result.ExitCode == 0 || !strings.Contains(result.Output, "Continuing the merge") {
+		t.Errorf("Expected the unresolved merge to be picked up and still fail, got:\n%s", result.Output)
+	}
+
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"merged\")\n}\n")
+	add := exec.Command("git", "add", "src/main.go")
+	add.Dir = project.Dir
+	if output, err := add.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, output)
+	}
+
+	output := mustRunCLI(t, "resolve", branch)
+	if !strings.Contains(output, "✓ Merged "+branch) || !strings.Contains(output, "cherry-go cleanup --all") {
+		t.Errorf("Expected the merge to be committed and the branch kept, got:\n%s", output)
+	}
+	if !branchExists(t, project, branch) {
+		t.Error("Expected the branch to be kept without --delete-branch")
+	}
+	if _, err := os.Stat(project.Path(".git/MERGE_HEAD")); !os.IsNotExist(err) {
+		t.Error("Expected the merge to be finished")
+	}
+
+	// Once merged, merging again is a no-op that can delete the branch
+	mustRunCLI(t, "resolve", branch, "--delete-branch")
+	if branchExists(t, project, branch) {
+		t.Error("Expected --delete-branch to delete the branch")
+	}
+}
+
+func TestE2E_Resolve_Interactive(t *testing.T) {
+	project, branch := newConflictBranchProject(t)
+
+	var edited []string
+	withResolvePrompt(t, true, "vi", func(editor, path string) error {
+		edited = append(edited, path)
+		return os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(\"merged\")\n}\n"), 0644)
+	})
+	withConfirm(t, true)
+
+	output := mustRunCLI(t, "resolve", branch)
+	if len(edited) != 1 || edited[0] != "src/main.go" {
+		t.Errorf("Expected src/main.go to be opened in the editor, got %v", edited)
+	}
+	for _, expected := range []string{"Conflict 1/1: src/main.go", "✓ Resolved src/main.go", "✓ Merged " + branch, "✓ Deleted branch"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output, got:\n%s", expected, output)
+		}
+	}
+	if got := project.ReadFile("src/main.go"); !strings.Contains(got, "merged") {
+		t.Errorf("Expected the edited content to be committed, got %q", got)
+	}
+	if branchExists(t, project, branch) {
+		t.Error("Expected the branch to be deleted after confirming")
+	}
+}
+
+func TestE2E_Resolve_InteractiveMarkersLeft(t *testing.T) {
+	_, branch := newConflictBranchProject(t)
+
+	// Closing the editor without fixing the file keeps the merge in progress
+	withResolvePrompt(t, true, "vi", func(editor, path string) error { return nil })
+	withConfirm(t, true)
+
+	result := runCLI(t, "resolve", branch)
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected resolve to fail while markers remain, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "src/main.go still has conflict markers") {
+		t.Errorf("Expected a warning about the markers, got:\n%s", result.Output)
+	}
+}
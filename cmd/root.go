@@ -3,11 +3,14 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"cherry-go/internal/cache"
 	"cherry-go/internal/config"
+	"cherry-go/internal/git"
 	"cherry-go/internal/logger"
 )
 
@@ -15,6 +18,8 @@ var (
 	configFile   string
 	dryRun       bool
 	verboseCount int
+	strictRemote bool
+	noCache      bool
 	cfg          *config.Config
 )
 
@@ -35,7 +40,15 @@ Features:
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Configure logger based on flags
 		logger.SetVerbosityLevel(verboseCount)
+		checkOutputFormat(cmd)
+		if syncPorcelain || syncJSON || jsonOutput() || cmd == listCmd || cmd == historyCmd || cmd == diffCmd || cmd == usageCmd || cmd == planCmd {
+			// Log lines make way for the records sync --porcelain, --output json, list, history, diff, usage and plan print on stdout
+			logger.SetOutput(cmd.ErrOrStderr())
+		} else {
+			logger.SetOutput(nil)
+		}
 		logger.SetDryRun(dryRun)
+		git.SetStrictRemotes(strictRemote)
 
 		if verboseCount > 0 {
 			if verboseCount == 1 {
@@ -55,11 +68,62 @@ Features:
 		if err != nil {
 			logger.Fatal("Failed to load configuration: %v", err)
 		}
+		git.SetTokenHosts(cfg.Options.GitHubHosts, cfg.Options.GitLabHosts)
+		applyNoCache()
 
 		logger.Debug("Configuration loaded from: %s", configFile)
+		root, err := config.ProjectRoot(configFile)
+		if err == nil {
+			logger.Debug("Project root: %s", root)
+		}
+		cache.SetProject(projectConfigPath())
+		git.SetHTTPTransport(cfg.Options.Proxy, root)
+
+		// Every command is a run of its own: sources it syncs start unsynced and unrefused
+		syncRunSources = newSyncedSources()
+		syncAuthFailures = newAuthFailures()
 	},
 }
 
+// enterProjectRoot makes the directory of the configuration file the working directory
+// and returns it, so tracked local paths land next to the configuration wherever
+// cherry-go was started from
+func enterProjectRoot() string {
+	root, err := config.ProjectRoot(configFile)
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+	if err := os.Chdir(root); err != nil {
+		logger.Fatal("Failed to enter the project root %s: %v", root, err)
+	}
+	return root
+}
+
+// projectConfigPath returns the absolute path of the configuration file, which names
+// the project in what the shared cache records about its users
+func projectConfigPath() string {
+	abs, err := filepath.Abs(configFile)
+	if err != nil {
+		return configFile
+	}
+	return abs
+}
+
+// saveConfig writes the configuration back to configFile, warning first when a newer
+// major version of cherry-go wrote it last: fields this binary doesn't know are dropped.
+// Once saved, cfg records this binary as the writer, so the warning isn't repeated.
+func saveConfig() error {
+	if cfg.WrittenByNewerMajor() {
+		logger.Warning("⚠️  %s was last written by %s, newer than this cherry-go v%s; saving may drop settings it doesn't understand. Consider upgrading.",
+			configFile, cfg.GeneratedBy, strings.TrimPrefix(Version, "v"))
+	}
+	if err := cfg.Save(configFile); err != nil {
+		return err
+	}
+	cfg.GeneratedBy = config.GeneratedBy()
+	return nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
 	return rootCmd.Execute()
@@ -67,10 +131,14 @@ func Execute() error {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	config.ToolVersion = Version
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is .cherry-go.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "simulate actions without making changes")
+	rootCmd.PersistentFlags().BoolVar(&strictRemote, "strict-remotes", false, "fail instead of correcting a cached clone whose origin isn't the configured repository URL")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "don't use ~/.cache/cherry-go: clone sources into the workspace (or options.tmp_dir) for this run and delete them afterwards (also CHERRY_GO_NO_CACHE=1)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "output format: text, or json for a JSON document on stdout and log lines on stderr (sync, history, list, verify, usage)")
 	rootCmd.PersistentFlags().CountVarP(&verboseCount, "verbose", "v", "verbose output (use -v, -vv for detailed diffs)")
 }
 
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/logger"
+)
+
+var sourcesPruneApply bool
+
+// sourcesCmd groups maintenance commands for the configured sources
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Maintain the configured sources",
+}
+
+// sourcesPruneCmd represents the sources prune command
+var sourcesPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove sources that no longer track any path",
+	Long: `List the sources that track no path, left behind when every path was removed
+with 'cherry-go remove path' or by abandoned experiments. Sync skips them,
+but they stay in the configuration.
+
+With --apply the sources are removed from the configuration and their
+cached clone and base-content snapshots are deleted, as 'cherry-go remove'
+does (keep them with --keep-cache and --keep-snapshots).
+
+Examples:
+  cherry-go sources prune
+  cherry-go sources prune --apply
+  cherry-go sources prune --apply --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		empty := cfg.SourcesWithoutPaths()
+		if len(empty) == 0 {
+			logger.Info("✓ Every source tracks at least one path")
+			return
+		}
+
+		logger.Info("%d source(s) track no path:", len(empty))
+		for _, source := range empty {
+			logger.Info("  • %s (%s)", source.Name, source.Repository)
+		}
+		if !sourcesPruneApply {
+			logger.Info("Run 'cherry-go sources prune --apply' to remove them")
+			return
+		}
+
+		for _, source := range empty {
+			cfg.RemoveSource(source.Name)
+		}
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would remove %d source(s) and save the configuration to: %s", len(empty), configFile)
+		} else {
+			if err := saveConfig(); err != nil {
+				logger.Fatal("Failed to save configuration: %v", err)
+			}
+			logger.Info("✓ Removed %d source(s); configuration saved to: %s", len(empty), configFile)
+		}
+
+		// Once every pruned source is gone, so clones only they used are deleted
+		for i := range empty {
+			cleanupSourceState(&empty[i])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sourcesCmd)
+	sourcesCmd.AddCommand(sourcesPruneCmd)
+
+	sourcesPruneCmd.Flags().BoolVar(&sourcesPruneApply, "apply", false, "remove the listed sources from the configuration")
+	sourcesPruneCmd.Flags().BoolVar(&removeKeepCache, "keep-cache", false, "keep the cached repository clones")
+	sourcesPruneCmd.Flags().BoolVar(&removeKeepSnapshots, "keep-snapshots", false, "keep base-content snapshots used for merges")
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/testutil"
+)
+
+func TestE2E_SourcesPrune(t *testing.T) {
+	library := newLibraryFixture(t)
+	tools := testutil.NewFixtureRepo(t, "tools")
+	tools.WriteFile("bin/tool.sh", "echo v1\n")
+	tools.Commit("initial")
+
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: library.URL(), Paths: []config.PathSpec{{Include: "lib/"}}},
+		config.Source{Name: "tools", Repository: tools.URL(), Paths: []config.PathSpec{{Include: "bin/"}}},
+	)
+	mustRunCLI(t, "sync", "--all", "--force")
+
+	// Left behind by hand edits and abandoned experiments
+	cfg := loadProjectConfig(t, project)
+	cfg.Sources[1].Paths = []config.PathSpec{}
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	configureSources(t, project,
+		config.Source{Name: "library-old", Repository: library.URL(), Paths: []config.PathSpec{}},
+		config.Source{Name: "experiment", Repository: "file:///nonexistent/experiment.git"},
+	)
+
+	// Sync skips them instead of cloning
+	output := mustRunCLI(t, "sync", "--all", "--force", "-v")
+	for _, name := range []string{"tools", "library-old", "experiment"} {
+		if !strings.Contains(output, "Skipping "+name+": it tracks no path") {
+			t.Errorf("Expected a note about skipping %s, got:\n%s", name, output)
+		}
+	}
+	if !strings.Contains(output, "Syncing 1 source(s)") {
+		t.Errorf("Expected only library to be synced, got:\n%s", output)
+	}
+	output = mustRunCLI(t, "sync", "experiment")
+	if strings.Contains(output, "Failed") {
+		t.Errorf("Expected a single source without paths to be skipped, got:\n%s", output)
+	}
+
+	// Detection lists them and changes nothing
+	before := project.ReadFile(".cherry-go.yaml")
+	output = mustRunCLI(t, "sources", "prune")
+	for _, expected := range []string{"3 source(s) track no path", "• tools", "• library-old", "• experiment", "--apply"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the listing, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "• library ") {
+		t.Errorf("Expected library not to be listed, got:\n%s", output)
+	}
+	mustRunCLI(t, "sources", "prune", "--apply", "--dry-run")
+	if project.ReadFile(".cherry-go.yaml") != before {
+		t.Error("Expected the configuration to be left alone without --apply, or with --dry-run")
+	}
+
+	// --apply removes them with their cache and snapshots, keeping what library still uses
+	snapshots, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store: %v", err)
+	}
+	if !snapshots.HasSnapshot("tools", "bin/") {
+		t.Fatal("Expected the first sync to snapshot tools")
+	}
+	mustRunCLI(t, "sources", "prune", "--apply")
+
+	cfg = loadProjectConfig(t, project)
+	if len(cfg.Sources) != 1 || cfg.Sources[0].Name != "library" {
+		t.Errorf("Expected only library to remain, got %+v", cfg.Sources)
+	}
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if cacheManager.RepositoryExists(tools.URL()) {
+		t.Error("Expected the tools clone to be deleted")
+	}
+	if !cacheManager.RepositoryExists(library.URL()) {
+		t.Error("Expected the library clone, still used by library, to be kept")
+	}
+	if snapshots.HasSnapshot("tools", "bin/") {
+		t.Error("Expected the tools snapshots to be deleted")
+	}
+
+	if output := mustRunCLI(t, "sources", "prune"); !strings.Contains(output, "Every source tracks at least one path") {
+		t.Errorf("Expected nothing left to prune, got:\n%s", output)
+	}
+}
@@ -1,11 +1,31 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/history"
 	"cherry-go/internal/logger"
+	"cherry-go/internal/units"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	fixTracking      bool
+	refreshSnapshots bool
+	fixTrackingYes   bool
+	staleAfter       string
+	statusUsage      bool
+	checkRemote      bool
+	remoteFiles      bool
+)
+
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
 	Use:   "status",
@@ -13,35 +33,159 @@ var statusCmd = &cobra.Command{
 	Long: `Display the current status of all tracked source repositories,
 including their configuration and last sync information.
 
+With --fix-tracking, re-hash the local files of every path and correct
+the tracking hashes in the configuration that no longer match them:
+drifted hashes are updated, files upstream has that are missing an entry
+are added, and entries for files that no longer exist are removed. File
+contents are never changed. Add --refresh-snapshots to also rewrite the
+base-content snapshots from the cache at each path's synced commit.
+
+Each source shows when it last synced successfully and from which upstream
+commit, as recorded in .cherry-go/state.yaml next to the configuration file.
+With --stale, only the sources not synced within the given window are listed
+(e.g. 12h, 7d, 2w), including sources never synced.
+
+With --usage, each path also shows the size and file count of its managed
+files on disk, as the usage command measures them, flagging paths over
+options.max_path_size.
+
+With --check-remote, every source is fetched (several at once, like
+sync --all) and each path shows how many upstream commits touched it since
+the commit it was last synced from, with the newest one's subject. The
+command then exits with 2 when any path has updates to sync, for drift
+checks in CI. Add --files to also list each path's files that changed
+upstream (modified, added or deleted) between that commit and the branch,
+or "no relevant upstream changes" when none did.
+
 Examples:
   cherry-go status
-  cherry-go status --verbose`,
+  cherry-go status --verbose
+  cherry-go status --stale 7d
+  cherry-go status --usage
+  cherry-go status --check-remote
+  cherry-go status --check-remote --files
+  cherry-go status --fix-tracking
+  cherry-go status --fix-tracking --refresh-snapshots --yes`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if refreshSnapshots && !fixTracking {
+			logger.Fatal("--refresh-snapshots requires --fix-tracking")
+		}
+		if staleAfter != "" && fixTracking {
+			logger.Fatal("Cannot specify both --stale and --fix-tracking")
+		}
+		if statusUsage && fixTracking {
+			logger.Fatal("Cannot specify both --usage and --fix-tracking")
+		}
+		if remoteFiles && !checkRemote {
+			logger.Fatal("--files requires --check-remote")
+		}
+		if checkRemote && fixTracking {
+			logger.Fatal("Cannot specify both --check-remote and --fix-tracking")
+		}
+		var staleWindow time.Duration
+		if staleAfter != "" {
+			window, err := units.ParseDuration(staleAfter)
+			if err != nil || window <= 0 {
+				logger.Fatal("--stale needs a positive duration like 12h, 7d or 2w, got '%s'", staleAfter)
+			}
+			staleWindow = window
+		}
+		if fixTracking {
+			enterProjectRoot()
+			if err := fixTrackingData(refreshSnapshots); err != nil {
+				logger.Fatal("%v", err)
+			}
+			return
+		}
+
 		if len(cfg.Sources) == 0 {
 			logger.Info("No sources configured")
 			return
 		}
 
+		// Cache information is optional - status still works without it
+		cacheManager, err := cache.NewManager()
+		if err != nil {
+			logger.Debug("Cache unavailable: %v", err)
+		}
+
+		// Sizes are measured from the project root, where tracked paths are relative to
+		if statusUsage {
+			enterProjectRoot()
+		}
+
 		logger.Info("Cherry-go Status Report")
 		logger.Info("Configuration file: %s", configFile)
+		logger.Info("Last written by: %s", getGeneratedByDisplay(cfg))
 		logger.Info("")
 
-		for i, source := range cfg.Sources {
+		// Sync state is optional too - old projects have none
+		state, err := history.ReadState(history.StatePath(configFile))
+		if err != nil {
+			logger.Warning("⚠️  %v", err)
+		}
+
+		stale := 0
+		sources := cfg.OrderedSources()
+
+		// Upstream is checked for every source up front, several at once
+		var remotes map[string]remoteStatus
+		if checkRemote {
+			remotes = checkRemotes(sources)
+			logger.Info("")
+		}
+		for i, source := range sources {
+			lastSync, synced := state.Sources[source.Name]
+			isStale := !synced || time.Since(lastSync.LastSyncedAt) > staleWindow
+			if staleWindow > 0 {
+				if !isStale {
+					continue
+				}
+				stale++
+			}
+
 			logger.Info("Source %d: %s", i+1, source.Name)
 			logger.Info("  Repository: %s", source.Repository)
 			logger.Info("  Authentication: %s", getAuthTypeDisplay(source.Auth.Type))
+			if source.Optional {
+				logger.Info("  Optional: yes (skipped when it can't be authenticated or cloned)")
+			}
+			if logger.GetVerbosityLevel() > 0 {
+				logger.Info("  Default excludes: %s", getDefaultExcludesDisplay(cfg.Options, &sources[i]))
+			}
+			if staleWindow > 0 {
+				logger.Warning("  Last synced: %s ⚠️  stale (not synced within %s)", getLastSyncedDisplay(lastSync, synced), staleAfter)
+			} else {
+				logger.Info("  Last synced: %s", getLastSyncedDisplay(lastSync, synced))
+			}
+			if cacheManager != nil {
+				logger.Info("  Cache updated: %s", getCacheStalenessDisplay(cacheManager, source.Repository))
+			}
 			logger.Info("  Paths (%d):", len(source.Paths))
 
-			for j, path := range source.Paths {
-				localPathDisplay := path.LocalPath
-				if localPathDisplay == "" {
-					localPathDisplay = path.Include // Default: same as source path
+			remote, checked := remotes[source.Name]
+			if checked && remote.err != nil {
+				logger.Warning("  ⚠️  Could not check upstream: %v", remote.err)
+			}
+
+			var usage []git.PathUsage
+			if statusUsage {
+				usage, err = git.MeasureUsage(&sources[i], cfg.Options)
+				if err != nil {
+					logger.Warning("  ⚠️  Failed to measure %s: %v", source.Name, err)
 				}
+			}
+
+			for j, path := range source.Paths {
+				localPathDisplay := path.LocalRoot() // Default: same as source path
 
 				branchDisplay := path.Branch
 				if branchDisplay == "" {
 					branchDisplay = "(default)"
 				}
+				if path.Pinned() {
+					branchDisplay = "pinned " + path.PinnedRef()
+				}
 
 				logger.Info("    %d. %s -> %s [%s]", j+1, path.Include, localPathDisplay, branchDisplay)
 
@@ -52,10 +196,33 @@ Examples:
 				if len(path.Files) > 0 {
 					logger.Info("       Tracked files: %d", len(path.Files))
 				}
+
+				if j < len(usage) {
+					logger.Info("       Size: %s", getUsageDisplay(usage[j], cfg.Options))
+				}
+
+				if checked && j < len(remote.paths) {
+					logger.Info("       Upstream: %s", getPendingDisplay(remote.paths[j]))
+					if remoteFiles {
+						for _, line := range getUpstreamFilesDisplay(remote.paths[j]) {
+							logger.Info("         %s", line)
+						}
+					}
+				}
 			}
 			logger.Info("")
 		}
 
+		if staleWindow > 0 {
+			if stale == 0 {
+				logger.Info("✓ Every source synced within %s", staleAfter)
+			} else {
+				logger.Info("%d of %d source(s) not synced within %s", stale, len(cfg.Sources), staleAfter)
+			}
+			reportRemotes(remotes)
+			return
+		}
+
 		logger.Info("Sync Options:")
 		logger.Info("  Auto-commit: %t", cfg.Options.AutoCommit)
 		logger.Info("  Commit prefix: %s", cfg.Options.CommitPrefix)
@@ -63,9 +230,87 @@ Examples:
 		if cfg.Options.CreateBranch {
 			logger.Info("  Branch prefix: %s", cfg.Options.BranchPrefix)
 		}
+		if cfg.Options.MaxPathSize > 0 {
+			logger.Info("  Max path size: %s", cfg.Options.MaxPathSize)
+		}
+		reportRemotes(remotes)
 	},
 }
 
+// fixTrackingData reconciles every source's tracking hashes with the local files,
+// after showing the corrections and getting confirmation. With refresh, it also rewrites
+// the base snapshots from the cache.
+func fixTrackingData(refresh bool) error {
+	fixes := make([][]git.TrackingFix, len(cfg.Sources))
+	total := 0
+	for i := range cfg.Sources {
+		fixes[i] = git.PlanTrackingFixes(&cfg.Sources[i], cfg.Options)
+		total += len(fixes[i])
+	}
+
+	if total == 0 {
+		logger.Info("✓ Tracking data matches the local files")
+		if !refresh {
+			return nil
+		}
+	} else {
+		logger.Info("Tracking entries to correct (%d):", total)
+		for i, sourceFixes := range fixes {
+			for _, fix := range sourceFixes {
+				logger.Info("  %s: %s", cfg.Sources[i].Name, fix)
+			}
+		}
+	}
+	if refresh && !cfg.Options.BaseSnapshotsEnabled() {
+		logger.Warning("⚠️  Not refreshing snapshots: base snapshots are disabled for this project (options.base_snapshots: false)")
+		refresh = false
+		if total == 0 {
+			return nil
+		}
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would correct %d tracking entries", total)
+		if refresh {
+			logger.DryRunInfo("Would refresh base snapshots from the cache")
+		}
+		return nil
+	}
+
+	if !fixTrackingYes {
+		if !shouldPromptForSource() {
+			return fmt.Errorf("--fix-tracking rewrites tracking data; rerun with --yes to confirm")
+		}
+		if !confirmPrompt("Record the current local files as the tracked versions (file contents are not changed)?", false) {
+			return fmt.Errorf("tracking data not changed")
+		}
+	}
+
+	if total > 0 {
+		for i := range cfg.Sources {
+			git.ApplyTrackingFixes(&cfg.Sources[i], fixes[i])
+		}
+		if err := saveConfig(); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		logger.Info("✓ Corrected %d tracking entries", total)
+	}
+
+	if refresh {
+		for i := range cfg.Sources {
+			refreshed, err := git.RefreshBaseSnapshots(&cfg.Sources[i], cfg.Options)
+			if err != nil {
+				logger.Warning("⚠️  Failed to refresh snapshots of %s: %v", cfg.Sources[i].Name, err)
+				continue
+			}
+			for _, include := range refreshed {
+				logger.Info("✓ Refreshed base snapshot of %s: %s", cfg.Sources[i].Name, include)
+			}
+		}
+	}
+	return nil
+}
+
 func getAuthTypeDisplay(authType string) string {
 	if authType == "" {
 		return "none"
@@ -73,6 +318,66 @@ func getAuthTypeDisplay(authType string) string {
 	return authType
 }
 
+// getDefaultExcludesDisplay describes whether the built-in junk-file excludes apply to a source
+func getDefaultExcludesDisplay(options config.SyncOptions, source *config.Source) string {
+	if !options.DefaultExcludesEnabled(source) {
+		return "disabled"
+	}
+	return fmt.Sprintf("active (%s)", strings.Join(config.BuiltinExcludes, ", "))
+}
+
+// getGeneratedByDisplay describes which cherry-go version last saved the configuration
+func getGeneratedByDisplay(c *config.Config) string {
+	if c.GeneratedBy == "" {
+		return "unknown (saved before versions were recorded)"
+	}
+	if c.WrittenByNewerMajor() {
+		return fmt.Sprintf("%s (⚠️  newer than this cherry-go v%s)", c.GeneratedBy, strings.TrimPrefix(Version, "v"))
+	}
+	return c.GeneratedBy
+}
+
+// getLastSyncedDisplay describes a source's last successful sync, e.g. "2 days ago (1a2b3c4d)"
+func getLastSyncedDisplay(lastSync history.SourceState, synced bool) string {
+	if !synced {
+		return "never"
+	}
+	if lastSync.LastSyncedCommit == "" {
+		return format.Since(lastSync.LastSyncedAt)
+	}
+	return fmt.Sprintf("%s (%s)", format.Since(lastSync.LastSyncedAt), git.ShortHash(lastSync.LastSyncedCommit))
+}
+
+// getUsageDisplay describes the disk usage of a tracked path, e.g. "12.3 MB, 40 file(s)",
+// flagging a path over options.max_path_size
+func getUsageDisplay(usage git.PathUsage, options config.SyncOptions) string {
+	if usage.Missing {
+		return "(missing)"
+	}
+	display := fmt.Sprintf("%s, %s file(s)", format.Bytes(usage.Bytes), format.Count(usage.Files))
+	if budget := options.MaxPathSize.Bytes(); budget > 0 && usage.Bytes > budget {
+		display += fmt.Sprintf(" ⚠️  over options.max_path_size (%s)", options.MaxPathSize)
+	}
+	return display
+}
+
+// getCacheStalenessDisplay describes how long ago the source's cached clone was updated
+func getCacheStalenessDisplay(cacheManager *cache.Manager, repoURL string) string {
+	lastUsed, ok := cacheManager.LastUsed(repoURL)
+	if !ok {
+		return "never (not cached)"
+	}
+	return format.Since(lastUsed)
+}
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().BoolVar(&fixTracking, "fix-tracking", false, "correct tracking hashes that don't match the local files")
+	statusCmd.Flags().BoolVar(&refreshSnapshots, "refresh-snapshots", false, "with --fix-tracking, also rewrite base snapshots from the cache")
+	statusCmd.Flags().StringVar(&staleAfter, "stale", "", "only list sources not synced within this duration (e.g. 12h, 7d, 2w)")
+	statusCmd.Flags().BoolVar(&statusUsage, "usage", false, "show the size and file count of each tracked path on disk")
+	statusCmd.Flags().BoolVar(&checkRemote, "check-remote", false, "fetch every source and show upstream commits to tracked paths not synced yet (exit code 2 if any)")
+	statusCmd.Flags().BoolVar(&remoteFiles, "files", false, "with --check-remote, list the tracked files upstream changed since each path's last sync")
+	statusCmd.Flags().BoolVarP(&fixTrackingYes, "yes", "y", false, "apply --fix-tracking without asking for confirmation")
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+// statusExitPending is the exit code of status --check-remote when a tracked path has
+// upstream commits that aren't synced yet, for drift checks in CI
+const statusExitPending = 2
+
+// remoteStatus is what status --check-remote found upstream for a source
+type remoteStatus struct {
+	name  string
+	paths []git.PendingPath // By path index
+	err   error
+}
+
+// pending counts the source's paths with upstream changes to sync
+func (s remoteStatus) pending() int {
+	n := 0
+	for _, path := range s.paths {
+		if path.Pending() {
+			n++
+		}
+	}
+	return n
+}
+
+// checkRemotes fetches every source and lists what upstream has for its tracked paths,
+// running sources concurrently with the worker pool of sync --all
+func checkRemotes(sources []config.Source) map[string]remoteStatus {
+	statuses := make(map[string]remoteStatus, len(sources))
+	for status := range runSourcesConcurrently(sources, syncParallelism(), "Checking upstream", checkSourceRemote) {
+		statuses[status.name] = status
+	}
+	return statuses
+}
+
+// checkSourceRemote fetches a source's repository and compares each tracked path's branch
+// against the commit the path was last synced from
+func checkSourceRemote(source *config.Source) remoteStatus {
+	status := remoteStatus{name: source.Name}
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	defer closeRepository(repo)
+
+	if err := repo.Fetch(context.Background()); err != nil {
+		status.err = err
+		return status
+	}
+	status.paths, status.err = repo.PendingUpdates()
+	return status
+}
+
+// getPendingDisplay describes what upstream has for a path, e.g.
+// "3 new commit(s) on main since 1a2b3c4d, newest: 9f8e7d6c Fix the parser"
+func getPendingDisplay(path git.PendingPath) string {
+	switch {
+	case path.SyncedCommit == "":
+		return fmt.Sprintf("⬆️  never synced (follows %s)", path.Ref)
+	case path.Commits == 0:
+		return fmt.Sprintf("✓ up to date with %s", path.Ref)
+	}
+	return fmt.Sprintf("⬆️  %d new commit(s) on %s since %s, newest: %s %s", path.Commits, path.Ref,
+		git.ShortHash(path.SyncedCommit), git.ShortHash(path.Newest.Hash), path.Newest.Subject)
+}
+
+// getUpstreamFilesDisplay lists a synced path's files that changed upstream since its
+// last sync, e.g. "modified upstream: lib/a.go"
+func getUpstreamFilesDisplay(path git.PendingPath) []string {
+	if path.SyncedCommit == "" {
+		return nil
+	}
+	if len(path.Files) == 0 {
+		return []string{"no relevant upstream changes"}
+	}
+	lines := make([]string, 0, len(path.Files))
+	for _, file := range path.Files {
+		lines = append(lines, fmt.Sprintf("%s upstream: %s", file.Change, utils.DisplayPath(file.Path)))
+	}
+	return lines
+}
+
+// reportRemotes sums up status --check-remote and ends the command with its exit code:
+// 1 when a source couldn't be checked, 2 when a path has upstream updates to sync
+func reportRemotes(remotes map[string]remoteStatus) {
+	if remotes == nil {
+		return
+	}
+
+	failed, pendingSources, pendingPaths := 0, 0, 0
+	for _, status := range remotes {
+		if status.err != nil {
+			failed++
+			continue
+		}
+		if n := status.pending(); n > 0 {
+			pendingSources++
+			pendingPaths += n
+		}
+	}
+
+	logger.Info("")
+	if failed > 0 {
+		logger.Fatal("Could not check %d source(s) upstream", failed)
+	}
+	if pendingPaths == 0 {
+		logger.Info("✓ Every tracked path is up to date with upstream")
+		return
+	}
+	logger.Info("%d path(s) in %d source(s) have upstream updates to sync", pendingPaths, pendingSources)
+	logger.Exit(statusExitPending)
+}
@@ -2,15 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"cherry-go/internal/cache"
 	"cherry-go/internal/config"
+	"cherry-go/internal/format"
 	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/interactive"
 	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+	"cherry-go/internal/utils"
 )
 
 var (
@@ -19,11 +30,36 @@ var (
 	mergeSync        bool
 	branchOnConflict bool
 	markConflicts    bool
+	syncStat         bool
+	overrideProtect  bool
+	noSnapshots      bool
+	skipUnauthorized bool
+	noFetch          bool
+	maxConflicts     int
+	syncJobs         int
+	syncPaths        []string
+	syncRef          string
+	updateTracking   bool
+	allowEmpty       bool
+	allowLarge       bool
+	syncPorcelain    bool
+	syncJSON         bool
+	fromCherryBunch  string
+	autoCommit       optionalBool
+	nonInteractive   bool
+)
+
+// shouldPromptConflicts reports whether --merge may ask how to settle each conflict, and
+// resolveConflictPrompt asks
+// Note: Replaced by tests to simulate a terminal
+var (
+	shouldPromptConflicts = interactive.ShouldPrompt
+	resolveConflictPrompt = interactive.ResolveConflict
 )
 
 // syncCmd represents the sync command
 var syncCmd = &cobra.Command{
-	Use:   "sync [source-name]",
+	Use:   "sync [source-name | source:path...]",
 	Short: "Synchronize files from tracked repositories",
 	Long: `Synchronize files from one or all tracked source repositories.
 This will pull the latest changes and update local files accordingly.
@@ -32,6 +68,9 @@ By default, cherry-go will detect and report conflicts WITHOUT making changes.
 This allows you to review what would change before deciding how to proceed.
 
 Use --merge to attempt automatic merging, or --force to overwrite local changes.
+On a terminal, --merge asks what to do with each file it can't merge: keep the
+local file, take the upstream one, write conflict markers, or skip it. Skipped
+files abort the sync as they do in CI; --non-interactive never asks.
 
 Examples:
   # Check for updates and conflicts (default - no changes made)
@@ -43,21 +82,68 @@ Examples:
   # Force sync (override local changes)
   cherry-go sync --all --force
   
+  # Merge, aborting on conflicts without asking even on a terminal
+  cherry-go sync --all --merge --non-interactive
+
   # Merge with branch creation on conflict
   cherry-go sync --all --merge --branch-on-conflict
   
   # Merge with conflict markers for manual resolution
   cherry-go sync --all --merge --mark-conflicts
   
+  # Show per-file change statistics after syncing
+  cherry-go sync --all --merge --stat
+
+  # Write to paths listed in options.protected_paths for this run only
+  cherry-go sync --all --force --override-protected
+
+  # Force-sync without recording base-content snapshots (saves cache space)
+  cherry-go sync --all --force --no-snapshots
+
+  # Refresh only some of the paths a source tracks (both forms are the same)
+  cherry-go sync mylib --path src/ --path docs/ --merge
+  cherry-go sync mylib:src/ mylib:docs/ --merge
+
+  # Try an upstream feature branch for one path, without changing the config
+  cherry-go sync mylib --path src/ --ref feature/fix-123 --force
+
+  # Re-run a merge against the cached upstream state without fetching
+  cherry-go sync mylib --merge --no-fetch
+
+  # Warn about and skip sources that can't be authenticated or cloned,
+  # as if every source were marked optional: true
+  cherry-go sync --all --skip-unauthorized
+
+  # Commit this run's changes even though options.auto_commit is false (or --autocommit=false to skip)
+  cherry-go sync --all --force --autocommit
+
+  # Machine-readable findings for scripts, one tab-separated record per line
+  cherry-go sync --all --porcelain 2>/dev/null
+
+  # Sync at most 8 sources at once (default: options.max_parallel, or 4)
+  cherry-go sync --all --jobs 8
+
+  # Scaffold the files of a cherry bunch once, without tracking them in the config
+  cherry-go sync --from-cherrybunch template.cherrybunch --force
+
   # Dry run to preview changes
   cherry-go sync --all --dry-run`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var sourceName string
-		if len(args) > 0 {
-			sourceName = args[0]
+		sourceName, targetPaths, err := splitSyncTargets(args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		syncPaths = append(syncPaths, targetPaths...)
+
+		if fromCherryBunch != "" && (syncAll || sourceName != "") {
+			logger.Fatal("--from-cherrybunch syncs the cherry bunch alone, without a source name or --all")
+		}
+
+		if fromCherryBunch != "" && (syncRef != "" || len(syncPaths) > 0 || updateTracking) {
+			logger.Fatal("--from-cherrybunch can't be combined with --ref, --path or --update-tracking")
 		}
 
-		if !syncAll && sourceName == "" {
+		if !syncAll && sourceName == "" && fromCherryBunch == "" {
 			logger.Fatal("Either specify a source name or use --all flag")
 		}
 
@@ -85,22 +171,150 @@ Examples:
 			logger.Fatal("Cannot specify both --mark-conflicts and --branch-on-conflict")
 		}
 
-		workDir, err := os.Getwd()
-		if err != nil {
-			logger.Fatal("Failed to get current directory: %v", err)
+		if (syncRef != "" || len(syncPaths) > 0) && sourceName == "" {
+			logger.Fatal("--ref and --path need a single source name, not --all")
+		}
+
+		if updateTracking && syncRef == "" {
+			logger.Fatal("--update-tracking requires --ref")
+		}
+
+		if noFetch && noCache {
+			logger.Fatal("--no-fetch syncs from the cache, it can't be combined with --no-cache")
+		}
+
+		syncJSON = syncJSON || jsonOutput()
+		if syncPorcelain && syncJSON {
+			logger.Fatal("Cannot specify both --porcelain and --json")
+		}
+
+		if maxConflicts < 0 {
+			logger.Fatal("--max-conflicts must be 0 (no limit) or more")
 		}
+		git.SetMaxConflicts(maxConflicts)
+
+		if syncJobs < 0 {
+			logger.Fatal("--jobs must be 0 (default) or greater")
+		}
+
+		// A cherry bunch file is named relative to where cherry-go was started
+		if fromCherryBunch != "" && !isURL(fromCherryBunch) {
+			if abs, err := filepath.Abs(fromCherryBunch); err == nil {
+				fromCherryBunch = abs
+			}
+		}
+		workDir := enterProjectRoot()
+
+		syncCommandLine = commandLine(cmd)
 
 		// Determine sync mode
 		mode := getSyncMode()
+		if mode.Merges() {
+			if err := merge.CheckGit(); err != nil {
+				logger.Fatal("%v", err)
+			}
+		}
 
+		// On a terminal --merge asks about each conflicting file instead of aborting
+		git.SetConflictPrompt(nil)
+		if mode == git.SyncModeMerge && !nonInteractive && !syncPorcelain && !syncJSON && shouldPromptConflicts() {
+			git.SetConflictPrompt(resolveConflictPrompt)
+		}
+		start := time.Now()
+		warnMergeWithoutSnapshots(mode, syncOptions())
+		if commit, set := autoCommit.Get(); set {
+			logger.Info("📝 --autocommit=%t overrides options.auto_commit (%t) for this run", commit, cfg.Options.AutoCommit)
+		}
+
+		// With --porcelain or --json the records own stdout and everything else goes to stderr
+		out := newOutput(cmd)
+		var records io.Writer
+		if syncPorcelain || syncJSON {
+			records = out.Writer()
+			out = &output{w: cmd.ErrOrStderr()}
+		}
+
+		var results []git.SyncResult
 		if syncAll {
-			syncAllSources(workDir, mode)
+			results = syncAllSources(out, records, workDir, mode)
+		} else if fromCherryBunch != "" {
+			results = syncCherryBunchOnce(out, records, fromCherryBunch, workDir, mode)
 		} else {
-			syncSingleSource(sourceName, workDir, mode)
+			results = syncSingleSource(out, records, sourceName, workDir, mode)
 		}
+
+		logger.Info("Sync finished in %s%s%s", format.Duration(time.Since(start)), describeSyncMetrics(results), ephemeralTimingNote(sourceName))
+		recordSyncHistory(results)
+		recordSyncState(results)
 	},
 }
 
+// describeSyncMetrics sums up the file work of a run for the timing line, e.g.
+// " (files: 30,000 compared, 8,200 copied (410.0 MB), 30,000 hashed)"
+func describeSyncMetrics(results []git.SyncResult) string {
+	var total git.SyncMetrics
+	for _, result := range results {
+		total.Add(result.Metrics)
+	}
+
+	var parts []string
+	if total.FilesCompared > 0 {
+		parts = append(parts, format.Count(total.FilesCompared)+" compared")
+	}
+	if total.FilesCopied > 0 {
+		parts = append(parts, fmt.Sprintf("%s copied (%s)", format.Count(total.FilesCopied), format.Bytes(total.BytesCopied)))
+	}
+	if total.FilesHashed > 0 {
+		parts = append(parts, format.Count(total.FilesHashed)+" hashed")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (files: " + strings.Join(parts, ", ") + ")"
+}
+
+// ephemeralTimingNote explains sync time spent cloning `cache: ephemeral` sources, or
+// every source under --no-cache, from scratch, for the sources a run covers (all of
+// them when name is empty)
+func ephemeralTimingNote(name string) string {
+	count := 0
+	for i := range cfg.Sources {
+		if (name == "" || cfg.Sources[i].Name == name) && (cfg.Sources[i].Ephemeral() || noCache) {
+			count++
+		}
+	}
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (includes fresh clones of %d ephemeral source(s))", count)
+}
+
+// syncOptions returns the project's sync options with this run's flags applied
+func syncOptions() config.SyncOptions {
+	options := cfg.Options
+	if noSnapshots || noCache || fromCherryBunch != "" {
+		disabled := false
+		options.BaseSnapshots = &disabled
+	}
+	if commit, set := autoCommit.Get(); set {
+		options.AutoCommit = commit
+	}
+	// The same limit bounds the paths of a source synced at once
+	if syncJobs > 0 {
+		options.MaxParallel = syncJobs
+	}
+	return options
+}
+
+// warnMergeWithoutSnapshots warns once, before syncing, that merging modes have no
+// recorded base to merge against when base-content snapshots are turned off
+func warnMergeWithoutSnapshots(mode git.SyncMode, options config.SyncOptions) {
+	if mode == git.SyncModeDetect || mode == git.SyncModeForce || options.BaseSnapshotsEnabled() {
+		return
+	}
+	logger.Warning("⚠️  Base snapshots are disabled: merges have no recorded base, so changes made on both sides will mostly be reported as conflicts instead of merged")
+}
+
 // getSyncMode determines the sync mode based on flags
 func getSyncMode() git.SyncMode {
 	if forceSync {
@@ -118,79 +332,223 @@ func getSyncMode() git.SyncMode {
 	return git.SyncModeDetect // Default: only detect conflicts, don't make changes
 }
 
-func syncAllSources(workDir string, mode git.SyncMode) {
+// syncAllSources syncs every source concurrently and returns their results; records
+// receives the --porcelain records when not nil
+func syncAllSources(out *output, records io.Writer, workDir string, mode git.SyncMode) []git.SyncResult {
 	if len(cfg.Sources) == 0 {
 		logger.Info("No sources configured to sync")
-		return
+		return nil
 	}
 
-	if mode == git.SyncModeDetect {
-		logger.Info("Checking %d source(s) for updates...", len(cfg.Sources))
-	} else {
-		logger.Info("Syncing %d source(s)...", len(cfg.Sources))
+	sources := uniqueSources(sourcesWithPaths(cfg.OrderedSources()))
+	if len(sources) == 0 {
+		logger.Info("No sources with paths to sync")
+		return nil
 	}
 
-	// Use goroutines for concurrent syncing
-	var wg sync.WaitGroup
-	results := make(chan git.SyncResult, len(cfg.Sources))
-
-	for _, source := range cfg.Sources {
-		wg.Add(1)
-		go func(src config.Source) {
-			defer wg.Done()
-			result := syncSource(&src, workDir, mode)
-			results <- result
-		}(source)
+	jobs := syncParallelism()
+	if mode == git.SyncModeDetect {
+		logger.Info("Checking %d source(s) for updates, %d at a time...", len(sources), jobs)
+	} else {
+		logger.Info("Syncing %d source(s), %d at a time...", len(sources), jobs)
 	}
 
-	// Wait for all goroutines to complete
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	results := syncConcurrently(sources, jobs, mode, func(source *config.Source) git.SyncResult {
+		return syncSource(out, source, workDir, mode)
+	})
 
 	// Collect results
 	var totalUpdated int
-	var hasErrors bool
+	var failedSources int
+	var skippedSources []string
+	var duplicateSources []string
 	var hasConflicts bool
 	var branchesCreated []git.SyncResult
 	var conflictResults []git.SyncResult
+	var allResults []git.SyncResult
 
 	for result := range results {
+		allResults = append(allResults, result)
 		if result.Error != nil {
-			logger.Error("Failed to sync %s: %v", result.SourceName, result.Error)
-			hasErrors = true
+			// Authentication failures are reported once per host and credential below
+			if !isAuthFailure(result.Error) {
+				logger.Error("Failed to sync %s: %v", result.SourceName, result.Error)
+			}
+			failedSources++
+		} else if result.Duplicate {
+			duplicateSources = append(duplicateSources, result.SourceName)
+		} else if result.Skipped != nil {
+			skippedSources = append(skippedSources, result.SourceName)
 		} else if result.BranchCreated != "" {
 			branchesCreated = append(branchesCreated, result)
+		} else if result.PlannedBranch != nil {
+			out.Println(result.MergeInstructions)
 		} else if len(result.Conflicts) > 0 && mode == git.SyncModeDetect {
 			hasConflicts = true
 			conflictResults = append(conflictResults, result)
 		} else if result.HasChanges {
-			logger.Info("Successfully synced %s (%d paths updated)", result.SourceName, len(result.UpdatedPaths))
+			logger.Info("Successfully synced %s (%d paths updated%s)", result.SourceName, len(result.UpdatedPaths), describeProjectCommit(result))
 			totalUpdated += len(result.UpdatedPaths)
 		} else {
 			logger.Info("Source %s is up to date", result.SourceName)
 		}
 	}
 
-	if hasErrors {
-		logger.Error("Some sources failed to sync")
-	} else if len(branchesCreated) > 0 {
-		// Show detailed instructions for conflict resolution
-		printConflictResolutionInstructions(branchesCreated)
-	} else if hasConflicts {
-		// Show instructions for detected conflicts
-		printDetectedConflictsInstructions(conflictResults)
-	} else {
-		if mode == git.SyncModeDetect {
+	// Sources finish in any order; the summary always lists them by name
+	sortResultsByName(allResults)
+	sortResultsByName(branchesCreated)
+	sortResultsByName(conflictResults)
+
+	printHiddenConflicts(allResults)
+	if records != nil {
+		renderSyncRecords(records, allResults)
+	}
+
+	// Failures are reported once the optional --stat table is out
+	if failedSources == 0 {
+		if len(branchesCreated) > 0 {
+			// Show detailed instructions for conflict resolution
+			printConflictResolutionInstructions(out, branchesCreated)
+		} else if hasConflicts {
+			// Show instructions for detected conflicts
+			printDetectedConflictsInstructions(out, conflictResults)
+		} else if mode == git.SyncModeDetect {
 			logger.Info("Check completed. %d paths updated (no conflicts detected)", totalUpdated)
 		} else {
 			logger.Info("Sync completed successfully. Total paths updated: %d", totalUpdated)
 		}
 	}
+
+	if syncStat {
+		out.Println()
+		renderSyncStat(out.Writer(), allResults)
+	}
+
+	syncAuthFailures.report()
+
+	// Listed last so skipped sources aren't lost above the summary
+	if len(duplicateSources) > 0 {
+		sort.Strings(duplicateSources)
+		logger.Warning("⚠️  Skipped %d source(s) already synced in this run: %s",
+			len(duplicateSources), strings.Join(duplicateSources, ", "))
+	}
+	if len(skippedSources) > 0 {
+		sort.Strings(skippedSources)
+		logger.Warning("⚠️  Skipped %d optional source(s) that could not be authenticated or cloned: %s",
+			len(skippedSources), strings.Join(skippedSources, ", "))
+	}
+
+	if failedSources > 0 {
+		recordSyncHistory(allResults)
+		logger.Fatal("%d of %d source(s) failed to sync", failedSources, len(allResults))
+	}
+	return allResults
+}
+
+// sortResultsByName orders sync results by source name
+func sortResultsByName(results []git.SyncResult) {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].SourceName < results[j].SourceName })
+}
+
+// sourcesWithPaths returns the sources that track at least one path. The others would
+// only be cloned and pulled, so they are left out with a debug note.
+func sourcesWithPaths(sources []config.Source) []config.Source {
+	var syncable []config.Source
+	for _, source := range sources {
+		if len(source.Paths) == 0 {
+			logger.Debug("Skipping %s: it tracks no path (remove it with 'cherry-go sources prune --apply')", source.Name)
+			continue
+		}
+		syncable = append(syncable, source)
+	}
+	return syncable
+}
+
+// syncParallelism returns how many sources sync at once: --jobs, else options.max_parallel
+func syncParallelism() int {
+	if syncJobs > 0 {
+		return syncJobs
+	}
+	return cfg.Options.Parallelism()
+}
+
+// syncConcurrently runs syncFn for every source, at most jobs at a time, and announces each
+// source as it starts ("syncing 3/40"). Results are delivered as sources finish and the
+// channel is closed once all have.
+func syncConcurrently(sources []config.Source, jobs int, mode git.SyncMode, syncFn func(*config.Source) git.SyncResult) <-chan git.SyncResult {
+	verb := "Syncing"
+	if mode == git.SyncModeDetect {
+		verb = "Checking"
+	}
+	return runSourcesConcurrently(sources, jobs, verb, syncFn)
+}
+
+// runSourcesConcurrently is the worker pool of sync --all: it runs fn for every source, at
+// most jobs at a time, announcing each as it starts with verb. Each call gets its own
+// clone of the source, which shares nothing with cfg. Sources of the same repository
+// share its cache clone, so they take turns. Results are delivered as sources finish and
+// the channel is closed once all have.
+func runSourcesConcurrently[T any](sources []config.Source, jobs int, verb string, fn func(*config.Source) T) <-chan T {
+	var wg sync.WaitGroup
+	var started atomic.Int32
+	results := make(chan T, len(sources))
+	slots := make(chan struct{}, jobs)
+	clones := newCloneLocks()
+	for _, source := range sources {
+		wg.Add(1)
+		go func(src config.Source) {
+			defer wg.Done()
+			// Wait for the clone before taking a slot, so waiting sources don't hold slots
+			// the one using it may need
+			defer clones.lock(&src)()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			logger.Info("%s %d/%d: %s", verb, started.Add(1), len(sources), src.Name)
+			results <- fn(&src)
+		}(source.Clone())
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
 }
 
-func syncSingleSource(name string, workDir string, mode git.SyncMode) {
+// cloneLocks hands out one lock per cached repository clone. Sources tracking the same
+// repository check out, pull and copy from the same clone, which can't serve two of
+// them at once.
+type cloneLocks struct {
+	sync.Mutex
+	repositories map[string]*sync.Mutex
+}
+
+func newCloneLocks() *cloneLocks {
+	return &cloneLocks{repositories: make(map[string]*sync.Mutex)}
+}
+
+// lock waits until the clone of source's repository is free and returns the function
+// releasing it. Ephemeral sources clone a repository of their own and never wait.
+func (c *cloneLocks) lock(source *config.Source) func() {
+	if source.Ephemeral() {
+		return func() {}
+	}
+	c.Lock()
+	clone, ok := c.repositories[source.Repository]
+	if !ok {
+		clone = &sync.Mutex{}
+		c.repositories[source.Repository] = clone
+	}
+	c.Unlock()
+
+	clone.Lock()
+	return clone.Unlock
+}
+
+// syncSingleSource syncs one source and returns its result; records receives the
+// --porcelain records when not nil
+func syncSingleSource(out *output, records io.Writer, name string, workDir string, mode git.SyncMode) []git.SyncResult {
 	source, exists := cfg.GetSource(name)
 	if !exists {
 		logger.Fatal("Source '%s' not found", name)
@@ -201,53 +559,134 @@ func syncSingleSource(name string, workDir string, mode git.SyncMode) {
 	} else {
 		logger.Info("Syncing source '%s'...", name)
 	}
-	result := syncSource(source, workDir, mode)
+	return reportSingleSource(out, records, source, workDir, mode)
+}
+
+// syncCherryBunchOnce syncs the files of a cherry bunch file or URL into the project one
+// time: the source lives only in memory, so nothing is tracked and the config isn't saved
+func syncCherryBunchOnce(out *output, records io.Writer, location string, workDir string, mode git.SyncMode) []git.SyncResult {
+	cherryBunch, err := loadCherryBunch(location)
+	if err != nil {
+		logger.Fatal("Failed to load cherry bunch: %v", err)
+	}
+	source, err := cherryBunch.Source()
+	if err != nil {
+		logger.Fatal("%v", err)
+	}
+
+	logger.Info("Syncing cherry bunch '%s' from %s once: %d path(s), not tracked in %s",
+		cherryBunch.Name, cherryBunch.Repository, len(source.Paths), configFile)
+	return reportSingleSource(out, records, &source, workDir, mode)
+}
+
+// reportSingleSource syncs one source and reports its outcome, failing the command if it failed
+func reportSingleSource(out *output, records io.Writer, source *config.Source, workDir string, mode git.SyncMode) []git.SyncResult {
+	result := syncSource(out, source, workDir, mode)
+	printHiddenConflicts([]git.SyncResult{result})
+	if records != nil {
+		renderSyncRecords(records, []git.SyncResult{result})
+	}
 
 	if result.Error != nil {
+		recordSyncHistory([]git.SyncResult{result})
 		logger.Fatal("Failed to sync %s: %v", result.SourceName, result.Error)
 	}
 
-	if result.BranchCreated != "" {
+	if result.Duplicate {
+		logger.Warning("⚠️  Skipped %s: it was already synced in this run", result.SourceName)
+	} else if result.Skipped != nil {
+		logger.Warning("⚠️  Skipped optional source %s: it could not be authenticated or cloned", result.SourceName)
+	} else if result.BranchCreated != "" {
 		// Branch was created for conflict resolution
 		logger.Info("Conflict branch created: %s", result.BranchCreated)
 		if result.MergeInstructions != "" {
-			fmt.Println(result.MergeInstructions)
+			out.Println(result.MergeInstructions)
 		}
+	} else if result.PlannedBranch != nil {
+		out.Println(result.MergeInstructions)
 	} else if len(result.Conflicts) > 0 && mode == git.SyncModeDetect {
 		// Conflicts detected in detect mode
-		printDetectedConflictsInstructions([]git.SyncResult{result})
+		printDetectedConflictsInstructions(out, []git.SyncResult{result})
 	} else if result.HasChanges {
-		logger.Info("Successfully synced %s (%d paths updated)", result.SourceName, len(result.UpdatedPaths))
+		logger.Info("Successfully synced %s (%d paths updated%s)", result.SourceName, len(result.UpdatedPaths), describeProjectCommit(result))
 	} else {
 		logger.Info("Source %s is up to date", result.SourceName)
 	}
+
+	if syncStat {
+		out.Println()
+		renderSyncStat(out.Writer(), []git.SyncResult{result})
+	}
+	return []git.SyncResult{result}
 }
 
-func syncSource(source *config.Source, workDir string, mode git.SyncMode) git.SyncResult {
+func syncSource(out *output, source *config.Source, workDir string, mode git.SyncMode) git.SyncResult {
 	result := git.SyncResult{
 		SourceName: source.Name,
 	}
 
-	// Create repository wrapper
-	repo, err := git.NewRepository(source)
-	if err != nil {
-		result.Error = fmt.Errorf("failed to initialize repository: %w", err)
+	if len(source.Paths) == 0 {
+		logger.Debug("Skipping %s: it tracks no path (remove it with 'cherry-go sources prune --apply')", source.Name)
 		return result
 	}
 
-	// Pull latest changes
-	if pullErr := repo.Pull(); pullErr != nil {
-		result.Error = fmt.Errorf("failed to pull changes: %w", pullErr)
+	// A source already synced in this run would only redo its work over its own tracking
+	if !syncRunSources.claim(source.Name) {
+		result.Duplicate = true
 		return result
 	}
 
-	// Get latest commit hash
-	commitHash, err := repo.GetLatestCommit()
+	// The paths picked with --path, at --ref when overridden
+	scope, err := scopeSource(source)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get commit hash: %w", err)
+		result.Error = err
 		return result
 	}
-	result.CommitHash = commitHash
+
+	if noFetch && !source.Ephemeral() {
+		if err := requireCachedClone(source); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// A host that refused this source's credential earlier in the run isn't asked again
+	if !noFetch {
+		if refused := syncAuthFailures.refused(scope.source); refused != nil {
+			return skipUnavailableSource(source, result, fmt.Errorf("not contacting %s again: %w", refused.Host, refused))
+		}
+	}
+
+	// Create repository wrapper
+	cloneStart := time.Now()
+	repo, err := git.NewRepository(scope.source)
+	if err != nil {
+		return skipUnavailableSource(source, result, fmt.Errorf("failed to initialize repository: %w", err))
+	}
+	defer closeRepository(repo)
+	scope.announceRefOverride()
+
+	if repo.Ephemeral() {
+		reason := "uses cache: ephemeral"
+		if noCache {
+			reason = "is not cached (--no-cache)"
+		}
+		logger.Info("⏱  %s %s - cloned from scratch in %s", source.Name, reason, format.Duration(time.Since(cloneStart)))
+	}
+	repo.SetSyncOptions(syncOptions())
+	repo.SetOverrideProtected(overrideProtect)
+	repo.SetFreezeTracking(freezesTracking())
+	repo.SetAllowEmpty(allowEmpty)
+	repo.SetAllowLarge(allowLarge)
+	repo.SetOutput(out.Writer())
+	repo.SetNoFetch(noFetch)
+
+	// Pull latest changes, unless working from the cache as it is
+	if noFetch {
+		describeCachedCommits(repo, scope.source)
+	} else if pullErr := repo.Pull(); pullErr != nil {
+		return skipUnavailableSource(source, result, fmt.Errorf("failed to pull changes: %w", pullErr))
+	}
 
 	// Copy paths to local directory with the specified mode
 	copyResult, err := repo.CopyPaths(mode, workDir)
@@ -255,12 +694,21 @@ func syncSource(source *config.Source, workDir string, mode git.SyncMode) git.Sy
 		result.Error = fmt.Errorf("failed to copy paths: %w", err)
 		return result
 	}
+	scope.writeBack(source)
 
 	result.UpdatedPaths = copyResult.UpdatedPaths
+	result.PathCommits = copyResult.PathCommits
+	result.LinkTargets = copyResult.LinkTargets
+	result.CommitHash = primaryCommit(scope.source, copyResult)
 	result.Conflicts = copyResult.Conflicts
 	result.HasChanges = len(copyResult.UpdatedPaths) > 0
 	result.BranchCreated = copyResult.BranchCreated
+	result.PlannedBranch = copyResult.PlannedBranch
 	result.MergeInstructions = copyResult.MergeInstructions
+	result.FileActions = copyResult.FileActions
+	result.Untracked = copyResult.Untracked
+	result.Failed = copyResult.Failed
+	result.Metrics = copyResult.Metrics
 
 	// Handle conflicts in merge mode (abort)
 	if len(copyResult.Conflicts) > 0 && mode == git.SyncModeMerge {
@@ -271,114 +719,469 @@ func syncSource(source *config.Source, workDir string, mode git.SyncMode) git.Sy
 		}
 	}
 
-	// Save updated configuration with new hashes
-	if result.HasChanges && !logger.IsDryRun() {
-		// Update the source in the configuration
-		for i, cfgSource := range cfg.Sources {
-			if cfgSource.Name == source.Name {
-				cfg.Sources[i] = *source
-				break
-			}
-		}
-
-		// Save configuration
-		if err := cfg.Save(configFile); err != nil {
-			logger.Error("Failed to save updated configuration: %v", err)
-		} else {
-			logger.Debug("Updated configuration saved with new file hashes")
-		}
+	// Save updated configuration with new hashes (a --ref override has none to record)
+	if result.HasChanges && !logger.IsDryRun() && !freezesTracking() {
+		saveSourceTracking(source)
 	}
 
-	// Create commit if auto-commit is enabled and there are changes
+	// Create commit if auto-commit is enabled (options.auto_commit or --autocommit) and there are
+	// changes; a dry run only reports the commit it would create
 	// BUT skip commit if using --mark-conflicts mode with conflicts (user needs to resolve manually)
-	shouldCommit := cfg.Options.AutoCommit && result.HasChanges && !logger.IsDryRun()
+	shouldCommit := syncOptions().AutoCommit && result.HasChanges
 
 	// Don't commit if mark-conflicts mode and there are conflicts
 	if mode == git.SyncModeMarkConflicts && len(copyResult.Conflicts) > 0 {
 		shouldCommit = false
-		logger.Info("Changes staged but not committed - resolve conflict markers and commit manually")
+		reportConflictMarkers(copyResult.Conflicts)
 	}
 
-	if shouldCommit {
-		commitMessage := fmt.Sprintf("%s %s from %s (%s)",
-			cfg.Options.CommitPrefix,
-			source.Name,
-			source.Repository,
-			commitHash[:8])
+	// Content from a --ref override isn't what the config tracks, so leave committing to the user
+	if shouldCommit && syncRef != "" && !updateTracking {
+		shouldCommit = false
+		logger.Info("Not committing: the files come from the --ref override '%s'", syncRef)
+	}
+
+	// One-off cherry bunch files are only committed when --autocommit asks for it
+	if _, requested := autoCommit.Get(); shouldCommit && fromCherryBunch != "" && !requested {
+		shouldCommit = false
+		logger.Info("Not committing the one-off cherry bunch files (pass --autocommit to commit them)")
+	}
 
-		if err := git.CreateCommit(workDir, commitMessage, copyResult.UpdatedPaths); err != nil {
+	if shouldCommit {
+		when, err := repo.CommitDate(copyResult)
+		var commitMessage string
+		if err == nil {
+			commitMessage, err = syncCommitMessage(source, copyResult, when)
+		}
+		if err == nil {
+			if logger.IsDryRun() {
+				result.PlannedCommit = &git.PlannedCommit{Message: commitMessage, Paths: copyResult.CommitPaths}
+			}
+			projectMu.Lock()
+			result.ProjectCommit, err = git.CreateCommit(workDir, commitMessage, copyResult.CommitPaths, when, cfg.Options)
+			projectMu.Unlock()
+		}
+		if err != nil {
 			logger.Error("Failed to create commit: %v", err)
 		}
 	}
 
+	// post_sync hooks of the updated paths; a failing one fails the sync once the checks
+	// below have had their say
+	hookErr := runPostSyncHooks(scope.source, copyResult, workDir)
+
+	// Directories skipped for untracked files fail the sync under the error policy
+	if len(copyResult.Untracked) > 0 && cfg.Options.UntrackedPolicy() == config.UntrackedError {
+		result.Error = fmt.Errorf("%d untracked local file(s) in managed directories; move them out, add them to exclude, or set options.untracked to report",
+			len(copyResult.Untracked))
+		return result
+	}
+
+	// Refused protected writes fail the sync once the unprotected paths are in place
+	if len(copyResult.Refused) > 0 {
+		result.Error = fmt.Errorf("%d write(s) refused by protected_paths (first: %v); use --override-protected to allow",
+			len(copyResult.Refused), copyResult.Refused[0])
+	}
+
+	// Files that could not be read or copied fail the sync; the next sync retries them
+	if len(copyResult.Failed) > 0 && result.Error == nil {
+		result.Error = fmt.Errorf("%d file(s) failed to sync: %s",
+			len(copyResult.Failed), describeFailedFiles(copyResult.Failed, failedFilesLimit))
+	}
+
+	// Directories excluded down to nothing were left alone; an empty sync must be asked for
+	if len(copyResult.Empty) > 0 && result.Error == nil {
+		result.Error = fmt.Errorf("%d tracked path(s) matched no files (first: %v); use --allow-empty if that is intended",
+			len(copyResult.Empty), copyResult.Empty[0])
+	}
+
+	// Paths over options.max_path_size were left alone; syncing that much must be asked for
+	if len(copyResult.Large) > 0 && result.Error == nil {
+		result.Error = fmt.Errorf("%d tracked path(s) over options.max_path_size (first: %v); use --allow-large if that is intended",
+			len(copyResult.Large), copyResult.Large[0])
+	}
+
+	// Paths still holding conflict markers were left alone until they are resolved
+	if len(copyResult.Unresolved) > 0 && result.Error == nil {
+		result.Error = fmt.Errorf("%d tracked path(s) still contain conflict markers (first: %v)",
+			len(copyResult.Unresolved), copyResult.Unresolved[0])
+	}
+
+	if hookErr != nil && result.Error == nil {
+		result.Error = hookErr
+	}
+
 	return result
 }
 
-// printDetectedConflictsInstructions prints instructions when conflicts are detected in detect mode
-func printDetectedConflictsInstructions(results []git.SyncResult) {
-	// If verbosity is 0, print compact single-line format
-	if logger.GetVerbosityLevel() == 0 {
-		var allConflicts []string
-		var sourceName string
-		for _, result := range results {
-			sourceName = result.SourceName
-			for _, conflict := range result.Conflicts {
-				allConflicts = append(allConflicts, conflict.Path)
+// projectMu serializes what concurrent source syncs write to the project: cfg and the
+// config file, and the auto-commits
+var projectMu sync.Mutex
+
+// saveSourceTracking records a synced source's new tracking state in cfg and saves it
+func saveSourceTracking(source *config.Source) {
+	projectMu.Lock()
+	defer projectMu.Unlock()
+
+	for i, cfgSource := range cfg.Sources {
+		if cfgSource.Name == source.Name {
+			cfg.Sources[i] = source.Clone()
+			break
+		}
+	}
+
+	if err := saveConfig(); err != nil {
+		logger.Error("Failed to save updated configuration: %v", err)
+	} else {
+		logger.Debug("Updated configuration saved with new file hashes")
+	}
+}
+
+// syncCommitMessage is the message of the project commit auto_commit creates for a
+// source's synced paths, rendered from options.commit_template; dry runs report the
+// same message
+func syncCommitMessage(source *config.Source, copyResult *git.CopyResult, when time.Time) (string, error) {
+	var commit string
+	if len(copyResult.UpdatedPaths) > 0 {
+		commit = copyResult.PathCommits[copyResult.UpdatedPaths[0]]
+	}
+	return cfg.Options.RenderCommitMessage(config.CommitMessageData{
+		Prefix:      cfg.Options.CommitPrefix,
+		Source:      source.Name,
+		Repository:  source.Repository,
+		Commit:      commit,
+		ShortCommit: git.ShortHash(commit),
+		Commits:     describePathCommits(copyResult.UpdatedPaths, copyResult.PathCommits, pathPins(source)),
+		Paths:       strings.Join(copyResult.UpdatedPaths, ", "),
+		Date:        when,
+		FileCount:   len(copyResult.FileActions),
+	})
+}
+
+// reportConflictMarkers lists the conflicting files --mark-conflicts wrote conflict
+// markers into; other conflicts, like files deleted upstream, have none
+func reportConflictMarkers(conflicts []hash.FileConflict) {
+	var marked []string
+	for _, conflict := range conflicts {
+		content, err := os.ReadFile(filepath.FromSlash(conflict.LocalPath))
+		if err == nil && len(merge.ParseConflictHunks(content)) > 0 {
+			marked = append(marked, conflict.LocalPath)
+		}
+	}
+	if len(marked) == 0 {
+		logger.Info("Conflicts left unresolved, not committing")
+		return
+	}
+
+	logger.Warning("⚠️  Conflict markers written to %d file(s), not committed:", len(marked))
+	for _, path := range marked {
+		logger.Warning("   - %s", utils.DisplayPath(path))
+	}
+	logger.Info("💡 Resolve the <<<<<<< / >>>>>>> blocks and commit; until then sync refuses these paths unless --force is given")
+}
+
+// failedFilesLimit caps how many failed files a sync error names
+const failedFilesLimit = 5
+
+// requireCachedClone fails --no-fetch for a source that was never cloned into the cache
+func requireCachedClone(source *config.Source) error {
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize cache manager: %w", err)
+	}
+	if !cacheManager.RepositoryExists(source.Repository) {
+		return fmt.Errorf("--no-fetch needs a cached clone of %s; sync without --no-fetch (or run cherry-go cache warm %s) first",
+			source.Repository, source.Name)
+	}
+	return nil
+}
+
+// describeCachedCommits says which cached upstream commit each path is synced from
+// under --no-fetch, and how old it is
+func describeCachedCommits(repo *git.Repository, source *config.Source) {
+	for _, pathSpec := range source.Paths {
+		branch := pathSpec.Branch
+		if pathSpec.Pin != "" {
+			branch = "pin " + pathSpec.Pin
+		} else if branch == "" {
+			branch = "default branch"
+		}
+		ref, err := repo.PathRef(pathSpec)
+		if err != nil {
+			logger.Warning("⚠️  %s: %s: %v", source.Name, pathSpec.Include, err)
+			continue
+		}
+		commit, err := repo.GetCommitForRef(ref)
+		if err != nil {
+			logger.Warning("⚠️  %s: %s is not in the cache (--no-fetch): %v", source.Name, pathSpec.Include, err)
+			continue
+		}
+		age := ""
+		if when, err := repo.CommitTime(commit); err == nil {
+			age = fmt.Sprintf(", committed %s", format.Since(when))
+		}
+		logger.Info("📦 %s: %s from cached %s at %s%s (not fetched)", source.Name, pathSpec.Include, branch, git.ShortHash(commit), age)
+	}
+}
+
+// skipUnavailableSource records an auth or clone failure: a skip for optional sources (or
+// any source with --skip-unauthorized), an error for required ones. Authentication
+// failures are also grouped by host and credential for the run's diagnostics.
+func skipUnavailableSource(source *config.Source, result git.SyncResult, err error) git.SyncResult {
+	skip := source.Optional || skipUnauthorized
+	authFailure, first := syncAuthFailures.record(source.Name, err, skip)
+	if !skip {
+		result.Error = err
+		return result
+	}
+	if authFailure && !first {
+		logger.Debug("Skipping optional source %s: %v", source.Name, err)
+	} else {
+		logger.Warning("⚠️  Skipping optional source %s: %v", source.Name, err)
+	}
+	result.Skipped = err
+	return result
+}
+
+// describeFailedFiles lists the local paths of failed files, at most limit of them
+func describeFailedFiles(failures []git.FileFailure, limit int) string {
+	names := make([]string, 0, limit)
+	for i, failure := range failures {
+		if i == limit {
+			names = append(names, fmt.Sprintf("(+%d more)", len(failures)-limit))
+			break
+		}
+		names = append(names, failure.LocalPath)
+	}
+	return strings.Join(names, ", ")
+}
+
+// closeRepository releases a repository once a command is done with it, deleting
+// the temporary clone of an ephemeral source
+func closeRepository(repo *git.Repository) {
+	if err := repo.Close(); err != nil {
+		logger.Warning("⚠️  %v", err)
+	}
+}
+
+// primaryCommit picks the commit that best represents a sync: the first updated
+// path's commit, falling back to the first configured path's
+func primaryCommit(source *config.Source, copyResult *git.CopyResult) string {
+	for _, include := range copyResult.UpdatedPaths {
+		if commit := copyResult.PathCommits[include]; commit != "" {
+			return commit
+		}
+	}
+	for _, pathSpec := range source.Paths {
+		if commit := copyResult.PathCommits[pathSpec.Include]; commit != "" {
+			return commit
+		}
+	}
+	return ""
+}
+
+// describeProjectCommit notes the project commit auto_commit created for a source's changes,
+// e.g. ", committed as 1a2b3c4d"
+func describeProjectCommit(result git.SyncResult) string {
+	if result.ProjectCommit == "" {
+		return ""
+	}
+	return ", committed as " + git.ShortHash(result.ProjectCommit)
+}
+
+// describePathCommits formats the upstream commits of the updated paths for a commit message.
+// A single shared commit is shown once; otherwise each path is listed with its own commit.
+// A path pinned to a tag is shown with the tag, e.g. "v1.2.0 at 3f2c9a0e".
+func describePathCommits(updatedPaths []string, pathCommits map[string]string, pins map[string]string) string {
+	label := func(include string) string {
+		commit := git.ShortHash(pathCommits[include])
+		if pin := pins[include]; pin != "" && !config.IsCommitSHA(pin) {
+			return fmt.Sprintf("%s at %s", pin, commit)
+		}
+		return commit
+	}
+
+	unique := make(map[string]bool)
+	for _, include := range updatedPaths {
+		unique[label(include)] = true
+	}
+
+	if len(unique) == 1 {
+		for described := range unique {
+			return described
+		}
+	}
+
+	parts := make([]string, 0, len(updatedPaths))
+	for _, include := range updatedPaths {
+		parts = append(parts, fmt.Sprintf("%s@%s", include, label(include)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// pathPins returns the pin of each pinned path of a source, by include
+func pathPins(source *config.Source) map[string]string {
+	pins := make(map[string]string)
+	for _, pathSpec := range source.Paths {
+		if pathSpec.Pin != "" {
+			pins[pathSpec.Include] = pathSpec.Pin
+		}
+	}
+	return pins
+}
+
+// compactConflictLimit caps how many conflicted files the single-line summary names
+const compactConflictLimit = 10
+
+// compactConflictSummary lists conflicted files grouped by source for a single line, e.g.
+// "lib: a.go, b.go; tools: c.go (+3 more)". Duplicate paths are listed once, sources
+// appear in name order whatever order their concurrent syncs finished in, and at most
+// limit files are named in total.
+func compactConflictSummary(results []git.SyncResult, limit int) string {
+	bySource := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, result := range results {
+		for _, conflict := range result.Conflicts {
+			key := result.SourceName + "\x00" + conflict.Path
+			if seen[key] {
+				continue
 			}
+			seen[key] = true
+			bySource[result.SourceName] = append(bySource[result.SourceName], conflict.Path)
 		}
+	}
 
-		conflictsList := strings.Join(allConflicts, ", ")
-		logger.Warning("⚠️  Differences detected in %s: %s. Use --merge (auto-merge), --merge --branch-on-conflict (branch), --merge --mark-conflicts (markers), or --force (overwrite)", sourceName, conflictsList)
+	names := make([]string, 0, len(bySource))
+	for name := range bySource {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make([]string, 0, len(names))
+	remaining := limit
+	for _, name := range names {
+		paths := bySource[name]
+		shown := len(paths)
+		if shown > remaining {
+			shown = remaining
+		}
+		remaining -= shown
+
+		switch hidden := len(paths) - shown; {
+		case shown == 0:
+			groups = append(groups, fmt.Sprintf("%s: %d file(s)", name, hidden))
+		case hidden > 0:
+			groups = append(groups, fmt.Sprintf("%s: %s (+%d more)", name, strings.Join(paths[:shown], ", "), hidden))
+		default:
+			groups = append(groups, fmt.Sprintf("%s: %s", name, strings.Join(paths, ", ")))
+		}
+	}
+
+	return strings.Join(groups, "; ")
+}
+
+// printHiddenConflicts says how many conflicting files --max-conflicts kept from being
+// shown in detail; they still count toward the summary and exit code
+func printHiddenConflicts(results []git.SyncResult) {
+	if !git.ConflictDetailsTruncated() {
+		return
+	}
+	total := 0
+	for _, result := range results {
+		total += len(result.Conflicts)
+	}
+	if hidden := total - maxConflicts; hidden > 0 {
+		logger.Warning("…and %d more conflicting file(s) not shown in detail (%d in total; raise --max-conflicts, or 0 for no limit)", hidden, total)
+	}
+}
+
+// limitConflicts returns the conflicts that fit in what is left of the --max-conflicts
+// budget and uses it up (a budget of 0 means no limit)
+func limitConflicts(conflicts []hash.FileConflict, remaining *int) []hash.FileConflict {
+	if maxConflicts == 0 {
+		return conflicts
+	}
+	shown := min(len(conflicts), *remaining)
+	*remaining -= shown
+	return conflicts[:shown]
+}
+
+// printDetectedConflictsInstructions prints instructions when conflicts are detected in detect mode
+func printDetectedConflictsInstructions(out *output, results []git.SyncResult) {
+	// If verbosity is 0, print compact single-line format
+	if logger.GetVerbosityLevel() == 0 {
+		logger.Warning("⚠️  Differences detected in %s. Use --merge (auto-merge), --merge --branch-on-conflict (branch), --merge --mark-conflicts (markers), or --force (overwrite)",
+			compactConflictSummary(results, compactConflictLimit))
 		return
 	}
 
 	// Verbose output
-	fmt.Println()
-	fmt.Println("\033[33m⚠ DIFFERENCES DETECTED\033[0m")
-	fmt.Println()
+	out.Println()
+	out.Println("\033[33m⚠ DIFFERENCES DETECTED\033[0m")
+	out.Println()
 
+	remaining := maxConflicts
 	for _, result := range results {
-		fmt.Printf("  Source: \033[36m%s\033[0m\n", result.SourceName)
-		for _, conflict := range result.Conflicts {
-			fmt.Printf("    • %s\n", conflict.Path)
+		out.Printf("  Source: \033[36m%s\033[0m\n", result.SourceName)
+		shown := limitConflicts(result.Conflicts, &remaining)
+		for _, conflict := range shown {
+			out.Printf("    • %s%s\n", utils.DisplayPath(conflict.Path), conflictAttribution(conflict))
+		}
+		if hidden := len(result.Conflicts) - len(shown); hidden > 0 {
+			out.Printf("    …and %d more\n", hidden)
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("\033[1mHow to proceed:\033[0m")
-	fmt.Println()
-	fmt.Println("  \033[32m--merge\033[0m                        Auto-merge (preserves local changes)")
-	fmt.Println("  \033[32m--merge --branch-on-conflict\033[0m   Merge with manual control via git branch")
-	fmt.Println("  \033[32m--merge --mark-conflicts\033[0m       Write conflict markers to files for manual resolution")
-	fmt.Println("  \033[31m--force\033[0m                        Overwrite with remote version")
-	fmt.Println()
+	out.Println()
+	out.Println("\033[1mHow to proceed:\033[0m")
+	out.Println()
+	out.Println("  \033[32m--merge\033[0m                        Auto-merge (preserves local changes)")
+	out.Println("  \033[32m--merge --branch-on-conflict\033[0m   Merge with manual control via git branch")
+	out.Println("  \033[32m--merge --mark-conflicts\033[0m       Write conflict markers to files for manual resolution")
+	out.Println("  \033[31m--force\033[0m                        Overwrite with remote version")
+	out.Println()
+}
+
+// conflictAttribution names the upstream commit a conflict comes from, e.g.
+// " — 1a2b3c4d by Jane Doe, 2025-01-02: Fix the parser"; empty when it isn't known
+func conflictAttribution(conflict hash.FileConflict) string {
+	upstream := conflict.Upstream
+	if upstream == nil {
+		return ""
+	}
+	return fmt.Sprintf(" — %s by %s, %s: %s", git.ShortHash(upstream.Hash), upstream.Author, upstream.When.Format("2006-01-02"), upstream.Subject)
 }
 
 // printConflictResolutionInstructions prints instructions for resolving merge conflicts via branch
-func printConflictResolutionInstructions(results []git.SyncResult) {
-	fmt.Println()
-	fmt.Println("\033[33m⚠️  Merge Conflicts - Remote changes saved to branch\033[0m")
-	fmt.Println()
+func printConflictResolutionInstructions(out *output, results []git.SyncResult) {
+	out.Println()
+	out.Println("\033[33m⚠️  Merge Conflicts - Remote changes saved to branch\033[0m")
+	out.Println()
 
+	remaining := maxConflicts
 	for _, result := range results {
-		fmt.Printf("Source: \033[36m%s\033[0m\n", result.SourceName)
-		fmt.Printf("Branch: \033[32m%s\033[0m\n", result.BranchCreated)
+		out.Printf("Source: \033[36m%s\033[0m\n", result.SourceName)
+		out.Printf("Branch: \033[32m%s\033[0m\n", result.BranchCreated)
 
 		if len(result.Conflicts) > 0 {
-			fmt.Println("\nFiles with conflicts:")
-			for _, conflict := range result.Conflicts {
-				fmt.Printf("  • %s\n", conflict.Path)
+			out.Println("\nFiles with conflicts:")
+			shown := limitConflicts(result.Conflicts, &remaining)
+			for _, conflict := range shown {
+				out.Printf("  • %s%s\n", utils.DisplayPath(conflict.Path), conflictAttribution(conflict))
+			}
+			if hidden := len(result.Conflicts) - len(shown); hidden > 0 {
+				out.Printf("  …and %d more\n", hidden)
 			}
 		}
 
-		fmt.Println("\n\033[1mNext steps:\033[0m")
-		fmt.Println("Review the changes in the branch and merge when ready.")
-		fmt.Println("The branch contains the remote version - adjust as needed before merging.")
-		fmt.Println()
-		fmt.Printf("  git diff %s              # Review changes\n", result.BranchCreated)
-		fmt.Printf("  git merge %s             # Merge when ready\n", result.BranchCreated)
-		fmt.Printf("  git branch -d %s   # Delete branch after merge\n", result.BranchCreated)
-		fmt.Println()
+		out.Println("\n\033[1mNext steps:\033[0m")
+		out.Println("Review the changes in the branch and merge when ready.")
+		out.Println("The branch contains the remote version - adjust as needed before merging.")
+		out.Println()
+		out.Printf("  git diff %s              # Review changes\n", result.BranchCreated)
+		out.Printf("  git merge %s             # Merge when ready\n", result.BranchCreated)
+		out.Printf("  git branch -d %s   # Delete branch after merge\n", result.BranchCreated)
+		out.Println()
 	}
 }
 
@@ -392,4 +1195,21 @@ func init() {
 		"with --merge, create a branch with remote changes when merge conflicts are detected")
 	syncCmd.Flags().BoolVar(&markConflicts, "mark-conflicts", false,
 		"with --merge, write conflict markers to files for manual resolution (no commit)")
+	syncCmd.Flags().BoolVar(&syncStat, "stat", false, "show per-file added/removed line counts after syncing")
+	syncCmd.Flags().BoolVar(&overrideProtect, "override-protected", false, "allow writes to paths listed in options.protected_paths for this run")
+	syncCmd.Flags().BoolVar(&skipUnauthorized, "skip-unauthorized", false, "skip, with a warning, any source that can't be authenticated or cloned (like optional: true)")
+	syncCmd.Flags().IntVar(&syncJobs, "jobs", 0, "sync at most this many sources, and paths of a source, at once (default: options.max_parallel, or 4)")
+	syncCmd.Flags().IntVar(&maxConflicts, "max-conflicts", git.DefaultMaxConflicts, "show at most this many conflicting files in detail; the rest are only counted (0 for no limit)")
+	syncCmd.Flags().StringSliceVar(&syncPaths, "path", nil, "only sync these tracked paths of the source (repeatable)")
+	syncCmd.Flags().StringVar(&syncRef, "ref", "", "sync from this branch, tag or commit for this run instead of the configured one (single source)")
+	syncCmd.Flags().BoolVar(&updateTracking, "update-tracking", false, "with --ref, record the synced content in tracking hashes and base snapshots")
+	syncCmd.Flags().BoolVar(&noFetch, "no-fetch", false, "don't fetch: sync from the commits already in the cache (fails if a source isn't cached)")
+	syncCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "never ask how to settle --merge conflicts, even on a terminal: abort as in CI")
+	syncCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "sync tracked directories that match no files after excludes, instead of failing")
+	syncCmd.Flags().BoolVar(&allowLarge, "allow-large", false, "sync tracked paths whose upstream files exceed options.max_path_size, instead of failing")
+	optionalBoolVar(syncCmd, &autoCommit, "autocommit", "commit synced changes (true) or not (false) for this run, overriding options.auto_commit")
+	syncCmd.Flags().BoolVar(&syncPorcelain, "porcelain", false, "print findings as stable tab-separated records on stdout, everything else on stderr")
+	syncCmd.Flags().BoolVar(&syncJSON, "json", false, "print a JSON report on stdout, with the planned commits and branches in a dry run; everything else on stderr (same as --output json)")
+	syncCmd.Flags().StringVar(&fromCherryBunch, "from-cherrybunch", "", "sync the files of this cherry bunch file or URL once, without tracking them in the config")
+	syncCmd.Flags().BoolVar(&noSnapshots, "no-snapshots", false, "don't read or write base-content snapshots for this run (like options.base_snapshots: false)")
 }
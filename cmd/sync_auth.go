@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+// authFailures groups a sync run's authentication failures by host and credential, so
+// an expired token shared by many sources is reported once rather than once per source,
+// and the host isn't asked again with it for the remaining sources
+type authFailures struct {
+	sync.Mutex
+	groups map[git.RemoteCredential]*authFailureGroup
+}
+
+// authFailureGroup is the sources one host refused one credential for
+type authFailureGroup struct {
+	err      *git.AuthError // The first failure, with what the host answered
+	sources  []string
+	failed   bool // Some source failed the run rather than being skipped as optional
+	rejected bool // The host rejected the credential itself, for any repository
+}
+
+func newAuthFailures() *authFailures {
+	return &authFailures{groups: make(map[git.RemoteCredential]*authFailureGroup)}
+}
+
+// syncAuthFailures holds the current sync run's authentication failures
+var syncAuthFailures = newAuthFailures()
+
+// record adds a source that failed with err, or was skipped for it, to its host and
+// credential's group. It returns false when err isn't an authentication failure, and
+// first reports whether the source is the first of its group.
+func (f *authFailures) record(sourceName string, err error, skipped bool) (recorded, first bool) {
+	var authErr *git.AuthError
+	if !errors.As(err, &authErr) {
+		return false, false
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	group, ok := f.groups[authErr.RemoteCredential]
+	if !ok {
+		group = &authFailureGroup{err: authErr}
+		f.groups[authErr.RemoteCredential] = group
+	}
+	group.sources = append(group.sources, sourceName)
+	group.failed = group.failed || !skipped
+	group.rejected = group.rejected || authErr.Rejected()
+	return true, !ok
+}
+
+// refused returns the failure of a source's host and credential earlier in the run, or
+// nil if the host hasn't rejected the credential. Failures without a credential or with
+// a 403 only concern the repositories they happened on, so other sources are still tried.
+func (f *authFailures) refused(source *config.Source) *git.AuthError {
+	credential := git.CredentialFor(source)
+	if credential.Host == "" {
+		return nil
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if group, ok := f.groups[credential]; ok && group.rejected {
+		return group.err
+	}
+	return nil
+}
+
+// authDiagnostic is the message about one host and credential, and whether it failed the run
+type authDiagnostic struct {
+	message string
+	failed  bool
+}
+
+// diagnostics returns one message per host and credential that failed, ordered by host
+// and credential, naming the sources affected
+func (f *authFailures) diagnostics() []authDiagnostic {
+	f.Lock()
+	defer f.Unlock()
+
+	keys := make([]git.RemoteCredential, 0, len(f.groups))
+	for key := range f.groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Host != keys[j].Host {
+			return keys[i].Host < keys[j].Host
+		}
+		return keys[i].Credential < keys[j].Credential
+	})
+
+	diagnostics := make([]authDiagnostic, 0, len(keys))
+	for _, key := range keys {
+		group := f.groups[key]
+		sources := append([]string(nil), group.sources...)
+		sort.Strings(sources)
+		message := fmt.Sprintf("%s — %d source(s) affected: %s\n   %s answered: %v",
+			describeAuthFailure(group.err), len(sources), strings.Join(sources, ", "), key.Host, group.err.Err)
+		diagnostics = append(diagnostics, authDiagnostic{message: message, failed: group.failed})
+	}
+	return diagnostics
+}
+
+// isAuthFailure reports whether a source failed because its host refused to authenticate it
+func isAuthFailure(err error) bool {
+	var authErr *git.AuthError
+	return errors.As(err, &authErr)
+}
+
+// describeAuthFailure says what an authentication failure likely means
+func describeAuthFailure(err *git.AuthError) string {
+	switch {
+	case err.Credential == "":
+		return fmt.Sprintf("%s requires authentication and no credential was found for it (set GITHUB_TOKEN, GITLAB_TOKEN, GIT_TOKEN, or auth.token_env on the sources)", err.Host)
+	case err.Forbidden():
+		return fmt.Sprintf("%s was accepted by %s but can't read these repositories", err.Credential, err.Host)
+	default:
+		return fmt.Sprintf("%s appears invalid for %s", err.Credential, err.Host)
+	}
+}
+
+// report logs the run's authentication failures, one diagnostic per host and credential:
+// an error when a required source failed, a warning when only optional ones were skipped
+func (f *authFailures) report() {
+	for _, diagnostic := range f.diagnostics() {
+		if diagnostic.failed {
+			logger.Error("🔑 %s", diagnostic.message)
+		} else {
+			logger.Warning("🔑 %s", diagnostic.message)
+		}
+	}
+}
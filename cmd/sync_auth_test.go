@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+func TestAuthFailures(t *testing.T) {
+	logger.Init()
+	for _, name := range []string{"GITLAB_TOKEN", "GIT_TOKEN", "GIT_USERNAME", "GIT_PASSWORD"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITHUB_TOKEN", "expired")
+
+	github := git.RemoteCredential{Host: "github.com", Credential: "GITHUB_TOKEN from the environment"}
+	gitlab := git.RemoteCredential{Host: "gitlab.com"}
+	refused := func(credential git.RemoteCredential, err error) error {
+		return fmt.Errorf("failed to pull changes: failed to fetch: %w", &git.AuthError{RemoteCredential: credential, Err: err})
+	}
+
+	failures := newAuthFailures()
+	if recorded, first := failures.record("api", refused(github, transport.ErrAuthenticationRequired), false); !recorded || !first {
+		t.Errorf("Expected the first failure to start a group, got recorded=%t first=%t", recorded, first)
+	}
+	if recorded, first := failures.record("web", refused(github, transport.ErrAuthenticationRequired), true); !recorded || first {
+		t.Errorf("Expected the same host and credential to join the group, got recorded=%t first=%t", recorded, first)
+	}
+	failures.record("cli", refused(github, transport.ErrAuthenticationRequired), false)
+	failures.record("docs", refused(gitlab, transport.ErrAuthenticationRequired), true)
+	if recorded, _ := failures.record("broken", errors.New("failed to clone repository: connection refused"), false); recorded {
+		t.Error("Expected other failures to be left out")
+	}
+
+	// Later sources on the same host with the same credential aren't tried again
+	if err := failures.refused(&config.Source{Name: "sdk", Repository: "https://github.com/org/sdk.git"}); err == nil || err.Host != "github.com" {
+		t.Errorf("Expected github.com's refusal of GITHUB_TOKEN to be remembered, got %v", err)
+	}
+	if err := failures.refused(&config.Source{Name: "sdk", Repository: "https://github.com/org/sdk.git", Auth: config.AuthConfig{Type: "ssh"}}); err != nil {
+		t.Errorf("Expected another credential for the host to be tried, got %v", err)
+	}
+	if err := failures.refused(&config.Source{Name: "local", Repository: "/srv/git/lib.git"}); err != nil {
+		t.Errorf("Expected local repositories to be tried, got %v", err)
+	}
+
+	diagnostics := failures.diagnostics()
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected one diagnostic per host and credential, got %+v", diagnostics)
+	}
+	if got := diagnostics[0]; !got.failed || !strings.HasPrefix(got.message,
+		"GITHUB_TOKEN from the environment appears invalid for github.com — 3 source(s) affected: api, cli, web\n   github.com answered: authentication required") {
+		t.Errorf("Unexpected github.com diagnostic: %+v", got)
+	}
+	if got := diagnostics[1]; got.failed || !strings.HasPrefix(got.message,
+		"gitlab.com requires authentication and no credential was found for it") || !strings.Contains(got.message, "1 source(s) affected: docs") {
+		t.Errorf("Unexpected gitlab.com diagnostic: %+v", got)
+	}
+}
+
+func TestAuthFailures_RepositorySpecific(t *testing.T) {
+	logger.Init()
+	for _, name := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "GIT_TOKEN", "GIT_USERNAME", "GIT_PASSWORD"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITHUB_TOKEN", "scoped")
+
+	github := git.RemoteCredential{Host: "github.com", Credential: "GITHUB_TOKEN from the environment"}
+	gitlab := git.RemoteCredential{Host: "gitlab.com"}
+	failures := newAuthFailures()
+	failures.record("private", &git.AuthError{RemoteCredential: github, Err: transport.ErrAuthorizationFailed}, false)
+	failures.record("internal", &git.AuthError{RemoteCredential: gitlab, Err: transport.ErrAuthenticationRequired}, false)
+
+	// A 403 only says the credential can't read that repository
+	if err := failures.refused(&config.Source{Name: "sdk", Repository: "https://github.com/org/sdk.git"}); err != nil {
+		t.Errorf("Expected other repositories to be tried with a credential that got a 403, got %v", err)
+	}
+	// Without a credential, public repositories on the host still work
+	if err := failures.refused(&config.Source{Name: "docs", Repository: "https://gitlab.com/org/docs.git"}); err != nil {
+		t.Errorf("Expected other repositories to be tried after an anonymous failure, got %v", err)
+	}
+
+	// Both are still reported once per host and credential
+	if diagnostics := failures.diagnostics(); len(diagnostics) != 2 {
+		t.Errorf("Expected one diagnostic per host and credential, got %+v", diagnostics)
+	}
+}
+
+func TestDescribeAuthFailure(t *testing.T) {
+	forbidden := &git.AuthError{
+		RemoteCredential: git.RemoteCredential{Host: "github.com", Credential: "GITHUB_TOKEN from the environment"},
+		Err:              transport.ErrAuthorizationFailed,
+	}
+	if got := describeAuthFailure(forbidden); got != "GITHUB_TOKEN from the environment was accepted by github.com but can't read these repositories" {
+		t.Errorf("Unexpected description of a 403: %q", got)
+	}
+}
+
+// newCountingRefusingRemote serves git remotes under one host that answer every request
+// with status, counting the requests each repository gets
+func newCountingRefusingRemote(t *testing.T, status int) (string, func(repo string) int) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[strings.TrimSuffix(strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0], ".git")]++
+		mu.Unlock()
+		w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+		http.Error(w, http.StatusText(status), status)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func(repo string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[repo]
+	}
+}
+
+func TestE2E_AuthFailuresGroupedByHost(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	t.Setenv("GIT_PASSWORD", "expired")
+	serverURL, requests := newCountingRefusingRemote(t, http.StatusUnauthorized)
+	host := strings.TrimPrefix(serverURL, "http://")
+
+	private := func(name string) config.Source {
+		return config.Source{
+			Name:       name,
+			Repository: serverURL + "/" + name + ".git",
+			Auth:       config.AuthConfig{Type: "basic", Username: "ci"},
+			Paths:      []config.PathSpec{{Include: "lib/"}},
+		}
+	}
+	configureSources(t, project,
+		private("api"), private("cli"), private("web"),
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	result := runCLI(t, "sync", "--all", "--force", "--jobs", "1")
+	if result.ExitCode == 0 {
+		t.Fatalf("Expected the refused sources to fail the sync, got %s", result)
+	}
+	expected := "GIT_PASSWORD from the environment appears invalid for " + host + " — 3 source(s) affected: api, cli, web"
+	if strings.Count(result.Output, expected) != 1 {
+		t.Errorf("Expected a single diagnostic naming the host, the credential and the sources, got:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "Failed to sync api") || strings.Contains(result.Output, "Failed to sync web") {
+		t.Errorf("Expected no per-source error for the refused sources, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "3 of 4 source(s) failed to sync") {
+		t.Errorf("Expected the refused sources to count as failed, got:\n%s", result.Output)
+	}
+
+	// Only the first source to start reached the host; the credential wasn't sent again
+	contacted := func() (sources, total int) {
+		for _, name := range []string{"api", "cli", "web"} {
+			if n := requests(name); n > 0 {
+				sources++
+				total += n
+			}
+		}
+		return sources, total
+	}
+	sources, first := contacted()
+	if sources != 1 {
+		t.Errorf("Expected a single source to contact the host, got api=%d cli=%d web=%d", requests("api"), requests("cli"), requests("web"))
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected the public source to sync, got %q", got)
+	}
+
+	// Each run asks again
+	runCLI(t, "sync", "--all", "--force", "--jobs", "1")
+	if _, total := contacted(); total <= first {
+		t.Errorf("Expected a new run to contact the host again, got %d request(s) after %d", total, first)
+	}
+}
+
+func TestE2E_AuthFailuresPerRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		auth     config.AuthConfig
+		expected string
+	}{
+		{"forbidden", http.StatusForbidden, config.AuthConfig{Type: "basic", Username: "ci"},
+			"GIT_PASSWORD from the environment was accepted by %s but can't read these repositories — 3 source(s) affected: api, cli, web"},
+		{"anonymous", http.StatusUnauthorized, config.AuthConfig{},
+			"%s requires authentication and no credential was found for it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := newCLIProject(t)
+			for _, name := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "GIT_TOKEN", "GIT_USERNAME", "GIT_PASSWORD"} {
+				t.Setenv(name, "")
+			}
+			if tt.auth.Type != "" {
+				t.Setenv("GIT_PASSWORD", "scoped")
+			}
+			serverURL, requests := newCountingRefusingRemote(t, tt.status)
+			host := strings.TrimPrefix(serverURL, "http://")
+
+			var sources []config.Source
+			for _, name := range []string{"api", "cli", "web"} {
+				sources = append(sources, config.Source{
+					Name:       name,
+					Repository: serverURL + "/" + name + ".git",
+					Auth:       tt.auth,
+					Paths:      []config.PathSpec{{Include: "lib/"}},
+				})
+			}
+			configureSources(t, project, sources...)
+
+			result := runCLI(t, "sync", "--all", "--force", "--jobs", "1")
+			if result.ExitCode == 0 {
+				t.Fatalf("Expected the refused sources to fail the sync, got %s", result)
+			}
+			if expected := fmt.Sprintf(tt.expected, host); strings.Count(result.Output, expected) != 1 {
+				t.Errorf("Expected a single diagnostic for the host, got:\n%s", result.Output)
+			}
+
+			// The failure may be specific to a repository, so every source is tried
+			for _, name := range []string{"api", "cli", "web"} {
+				if requests(name) == 0 {
+					t.Errorf("Expected %s to contact the host, got:\n%s", name, result.Output)
+				}
+			}
+		})
+	}
+}
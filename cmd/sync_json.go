@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+// renderSyncRecords writes a run's results to records as --porcelain or --json asked
+func renderSyncRecords(records io.Writer, results []git.SyncResult) {
+	if syncJSON {
+		if err := renderSyncJSON(records, results, logger.IsDryRun()); err != nil {
+			logger.Error("Failed to write the JSON report: %v", err)
+		}
+		return
+	}
+	renderPorcelain(records, results)
+}
+
+// renderSyncJSON writes the results as one indented git.SyncReport document
+func renderSyncJSON(w io.Writer, results []git.SyncResult, dryRun bool) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(git.NewSyncReport(results, dryRun))
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+)
+
+// runSyncJSON runs a sync with --json and decodes the report it prints on stdout
+func runSyncJSON(t *testing.T, args ...string) git.SyncReport {
+	t.Helper()
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	defer rootCmd.SetOut(nil)
+
+	mustRunCLI(t, append(args, "--json")...)
+	var report git.SyncReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report on stdout, got %v:\n%s", err, stdout.String())
+	}
+	return report
+}
+
+// commitFiles lists the files a commit changed, sorted
+func commitFiles(t *testing.T, commit *object.Commit) []string {
+	t.Helper()
+	stats, err := commit.Stats()
+	if err != nil {
+		t.Fatalf("Failed to read the stats of %s: %v", commit.Hash, err)
+	}
+	var files []string
+	for _, stat := range stats {
+		files = append(files, stat.Name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestE2E_DryRunPlansConflictBranch(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	mustRunCLI(t, "add", "file", upstream.PathURL("src/main.go"), "--auto-add-repo")
+
+	project.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"local\")\n}\n")
+	project.Commit("local change")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {\n\tprintln(\"remote\")\n}\n")
+	upstream.Commit("upstream change")
+
+	// A dry run plans against the cache, so bring it up to date first
+	mustRunCLI(t, "sync", "library")
+	planned := runSyncJSON(t, "sync", "library", "--merge", "--branch-on-conflict", "--dry-run")
+	if branches, _ := git.ListSourceConflictBranches(project.Dir, "cherry-go/sync", "library"); len(branches) != 0 {
+		t.Fatalf("Expected a dry run not to create a branch, got %v", branches)
+	}
+	if !planned.DryRun || planned.Planned == nil || len(planned.Planned.Branches) != 1 {
+		t.Fatalf("Expected one planned conflict branch, got %+v", planned)
+	}
+	plan := planned.Planned.Branches[0]
+	if !strings.Contains(plan.MergeInstructions, "git merge "+plan.Name) {
+		t.Errorf("Expected the merge instructions preview to name %s, got:\n%s", plan.Name, plan.MergeInstructions)
+	}
+
+	actual := runSyncJSON(t, "sync", "library", "--merge", "--branch-on-conflict")
+	if actual.Planned != nil {
+		t.Errorf("Expected no planned section outside a dry run, got %+v", actual.Planned)
+	}
+	name := actual.Sources[0].Branch
+	if name == "" || actual.Sources[0].Status != "branch" {
+		t.Fatalf("Expected a conflict branch to be created, got %+v", actual.Sources[0])
+	}
+
+	// Only the timestamp in the name may differ between the plan and the real run
+	stamp := len("20060102-150405")
+	if plan.Name[:len(plan.Name)-stamp] != name[:len(name)-stamp] {
+		t.Errorf("Expected the planned branch %s to match the created %s", plan.Name, name)
+	}
+	ref, err := project.Repo().Reference(plumbing.NewBranchReferenceName(name), true)
+	if err != nil {
+		t.Fatalf("Failed to read branch %s: %v", name, err)
+	}
+	commit, err := project.Repo().CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read the branch commit: %v", err)
+	}
+	if expected := strings.ReplaceAll(plan.CommitMessage, plan.Name, name); commit.Message != expected {
+		t.Errorf("Expected the branch commit message to be the planned one:\n%s\ngot:\n%s", expected, commit.Message)
+	}
+	if files := commitFiles(t, commit); strings.Join(files, ",") != strings.Join(plan.Files, ",") {
+		t.Errorf("Expected the branch to commit the planned files %v, got %v", plan.Files, files)
+	}
+	if head, _ := project.Repo().Head(); head.Name().Short() != plan.From {
+		t.Errorf("Expected the branch to be planned from %s, HEAD is %s", head.Name().Short(), plan.From)
+	}
+}
+
+func TestE2E_DryRunPlansAutoCommit(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}, {Include: "src/main.go"}}})
+
+	// Nothing is cached yet, and a dry run doesn't clone
+	if output := mustRunCLI(t, "sync", "library", "--force", "--dry-run"); !strings.Contains(output, "nothing to compare against yet") {
+		t.Errorf("Expected a dry run of an uncached source to say so, got:\n%s", output)
+	}
+	mustRunCLI(t, "sync", "library", "--force", "--autocommit")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed upstream\nfunc A() {}\n")
+	upstream.WriteFile("src/main.go", "package main\n\nfunc main() {}\n")
+	upstream.Commit("update A and main")
+	mustRunCLI(t, "sync", "library")
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	before := head.Hash().String()
+
+	planned := runSyncJSON(t, "sync", "library", "--force", "--autocommit", "--dry-run")
+	if head, _ := project.Repo().Head(); head.Hash().String() != before {
+		t.Fatalf("Expected a dry run not to commit, HEAD moved to %s", head.Hash())
+	}
+	if planned.Planned == nil || len(planned.Planned.Commits) != 1 {
+		t.Fatalf("Expected one planned commit, got %+v", planned)
+	}
+	plan := planned.Planned.Commits[0]
+
+	actual := runSyncJSON(t, "sync", "library", "--force", "--autocommit")
+	head, err = project.Repo().Head()
+	if err != nil || head.Hash().String() != actual.Sources[0].Commit {
+		t.Fatalf("Expected the report to name HEAD as the commit, got %+v, %v", actual.Sources[0], err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	if commit.Message != plan.Message {
+		t.Errorf("Expected the commit message to be the planned one:\n%s\ngot:\n%s", plan.Message, commit.Message)
+	}
+
+	// Directories are staged whole, so the plan names them and the commit their files
+	for _, file := range commitFiles(t, commit) {
+		staged := false
+		for _, path := range plan.Paths {
+			staged = staged || file == path || strings.HasPrefix(file, strings.TrimSuffix(path, "/")+"/")
+		}
+		if !staged {
+			t.Errorf("Expected %s to be covered by the planned paths %v", file, plan.Paths)
+		}
+	}
+}
+
+func TestE2E_SyncOutputJSON(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetErr(nil)
+
+	mustRunCLI(t, "sync", "library", "--force", "--output", "json")
+
+	// stdout holds the report alone; log lines go to stderr
+	decoder := json.NewDecoder(&stdout)
+	decoder.DisallowUnknownFields()
+	var report git.SyncReport
+	if err := decoder.Decode(&report); err != nil {
+		t.Fatalf("Expected a JSON report on stdout, got %v", err)
+	}
+	if decoder.More() {
+		t.Error("Expected nothing on stdout after the report")
+	}
+	if len(report.Sources) != 1 || report.Sources[0].Status != "updated" || strings.Join(report.Sources[0].Updated, ",") != "lib/a.go,lib/b.go" {
+		t.Errorf("Expected library to be reported updated, got %+v", report.Sources)
+	}
+	if !strings.Contains(stderr.String(), "Syncing source 'library'") {
+		t.Errorf("Expected log lines on stderr, got:\n%s", stderr.String())
+	}
+
+	if result := runCLI(t, "status", "--output", "json"); result.ExitCode == 0 || !strings.Contains(result.Output, "has no JSON output") {
+		t.Errorf("Expected --output json to be refused by status, got:\n%s", result.Output)
+	}
+	if result := runCLI(t, "sync", "library", "--output", "xml"); result.ExitCode == 0 || !strings.Contains(result.Output, "Unknown output 'xml'") {
+		t.Errorf("Expected an unknown --output to be refused, got:\n%s", result.Output)
+	}
+}
+
+func TestE2E_ConflictsNameUpstreamCommits(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	project.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, edited locally\nfunc A() {}\n")
+	project.WriteFile("lib/b.go", "package lib\n\n// B is the second helper, edited locally\nfunc B() {}\n")
+	project.Commit("local edits")
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, fixed upstream\nfunc A() {}\n")
+	fixA := upstream.Commit("lib: fix A")
+	upstream.WriteFile("lib/b.go", "package lib\n\n// B is the second helper, fixed upstream\nfunc B() {}\n")
+	fixB := upstream.Commit("lib: fix B")
+
+	report := runSyncJSON(t, "sync", "library")
+	conflicts := report.Sources[0].Conflicts
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected lib/a.go and lib/b.go to conflict, got %+v", conflicts)
+	}
+	for i, want := range []struct{ path, hash, subject string }{{"lib/a.go", fixA, "lib: fix A"}, {"lib/b.go", fixB, "lib: fix B"}} {
+		commit := conflicts[i].UpstreamCommit
+		if conflicts[i].Path != want.path || commit == nil || commit.Hash != want.hash || commit.Subject != want.subject {
+			t.Errorf("Expected %s to be attributed to %s %q, got %+v (%+v)", want.path, want.hash, want.subject, conflicts[i], commit)
+		}
+	}
+
+	output := mustRunCLI(t, "sync", "library", "-v")
+	for _, want := range []string{
+		"    • a.go — " + git.ShortHash(fixA) + " by ",
+		": lib: fix A\n",
+		"    • b.go — " + git.ShortHash(fixB) + " by ",
+		": lib: fix B\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the differences listed, got:\n%s", want, output)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/testutil"
+)
+
+// unusualNames are the files of newUnusualNamesFixture, below docs/: spaces, a leading
+// space, "#", non-ASCII and glob metacharacters
+var unusualNames = []string{
+	"release notes.md",
+	" leading space.md",
+	"#ideas.md",
+	"café/naïve résumé.md",
+	"日本語.md",
+	"[draft] plan.md",
+	"what?.md",
+	"star*.md",
+	"a{b,c}.md",
+}
+
+// newUnusualNamesFixture creates an upstream whose docs/ directory holds unusualNames
+func newUnusualNamesFixture(t *testing.T) *testutil.FixtureRepo {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows file names can't hold glob metacharacters")
+	}
+	upstream := testutil.NewFixtureRepo(t, "names")
+	for _, name := range unusualNames {
+		upstream.WriteFile("docs/"+name, "# "+name+"\n\nline one\nline two\n")
+	}
+	upstream.Commit("initial import")
+	return upstream
+}
+
+func TestE2E_SyncUnusualNames(t *testing.T) {
+	upstream := newUnusualNamesFixture(t)
+	project := newCLIProject(t)
+
+	// Excludes name files as written, metacharacters and all
+	excluded := map[string]bool{"[draft] plan.md": true, "star*.md": true}
+	mustRunCLI(t, "add", "directory", upstream.PathURL("docs/"), "--auto-add-repo",
+		"--exclude", "[draft] plan.md", "--exclude", "star*.md")
+	mustRunCLI(t, "sync", "names", "--force", "--autocommit")
+
+	// Copied, tracked under their own names and committed
+	files := requireSource(t, project, "names").Paths[0].Files
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	commit, err := project.Repo().CommitObject(head.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read the sync commit: %v", err)
+	}
+	for _, name := range unusualNames {
+		if project.Exists("docs/"+name) == excluded[name] {
+			t.Errorf("Expected docs/%s to be synced: %v", name, !excluded[name])
+		}
+		if _, tracked := files[name]; tracked == excluded[name] {
+			t.Errorf("Expected %q to be tracked: %v", name, !excluded[name])
+		}
+		if _, err := commit.File("docs/" + name); (err == nil) == excluded[name] {
+			t.Errorf("Expected docs/%s to be committed: %v", name, !excluded[name])
+		}
+	}
+	mustRunCLI(t, "verify")
+
+	// Both sides change every file: the conflict branch stages each of them
+	for _, name := range unusualNames {
+		if !excluded[name] {
+			project.WriteFile("docs/"+name, "# "+name+"\n\nline one local\nline two\n")
+		}
+		upstream.WriteFile("docs/"+name, "# "+name+"\n\nline one remote\nline two\n")
+	}
+	project.Commit("local changes")
+	upstream.Commit("upstream changes")
+
+	output := mustRunCLI(t, "sync", "names", "--merge", "--branch-on-conflict")
+	for _, listed := range []string{`• "docs/ leading space.md"`, "• docs/#ideas.md", "• docs/café/naïve résumé.md", "• docs/what?.md"} {
+		if !strings.Contains(output, listed) {
+			t.Errorf("Expected %s in the conflict list, got:\n%s", listed, output)
+		}
+	}
+
+	branches, err := git.ListSourceConflictBranches(project.Dir, "cherry-go/sync", "names")
+	if err != nil || len(branches) != 1 {
+		t.Fatalf("Expected a conflict branch, got %v (%v)", branches, err)
+	}
+	ref, err := project.Repo().Reference(plumbing.NewBranchReferenceName(branches[0]), true)
+	if err != nil {
+		t.Fatalf("Failed to resolve %s: %v", branches[0], err)
+	}
+	branch, err := project.Repo().CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", branches[0], err)
+	}
+	for _, name := range unusualNames {
+		if excluded[name] {
+			continue
+		}
+		file, err := branch.File("docs/" + name)
+		if err != nil {
+			t.Errorf("Expected docs/%s on the conflict branch: %v", name, err)
+			continue
+		}
+		if content, _ := file.Contents(); !strings.Contains(content, "remote") {
+			t.Errorf("Expected upstream content for docs/%s, got %q", name, content)
+		}
+	}
+}
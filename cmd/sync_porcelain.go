@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cherry-go/internal/git"
+)
+
+// Record kinds of `sync --porcelain`. The format is stable: scripts parse it, so records
+// may be added in future versions but existing ones never change shape.
+const (
+	porcelainConflict = "CONFLICT" // CONFLICT<TAB>source<TAB>path<TAB>type
+	porcelainUpdated  = "UPDATED"  // UPDATED<TAB>source<TAB>path
+	porcelainError    = "ERROR"    // ERROR<TAB>source<TAB>message
+	porcelainCommit   = "COMMIT"   // COMMIT<TAB>source<TAB>hash
+)
+
+// renderPorcelain writes one tab-separated record per sync finding, naming files by their
+// path in the working directory. Sources come in name order and, within a source,
+// conflicts then updated files (each sorted by path), the project commit auto_commit
+// created for them, then its error, so the output
+// doesn't depend on which concurrent sync finished first.
+func renderPorcelain(w io.Writer, results []git.SyncResult) {
+	sorted := make([]git.SyncResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SourceName < sorted[j].SourceName })
+
+	for _, result := range sorted {
+		conflicts := make([]string, 0, len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			path := conflict.LocalPath
+			if path == "" {
+				path = conflict.Path
+			}
+			conflicts = append(conflicts, porcelainRecord(porcelainConflict, result.SourceName, path, string(conflict.Type)))
+		}
+		sort.Strings(conflicts)
+
+		updated := make([]string, 0, len(result.FileActions))
+		for _, action := range result.FileActions {
+			updated = append(updated, porcelainRecord(porcelainUpdated, result.SourceName, action.Path))
+		}
+		sort.Strings(updated)
+
+		for _, record := range append(conflicts, updated...) {
+			fmt.Fprintln(w, record)
+		}
+		if result.ProjectCommit != "" {
+			fmt.Fprintln(w, porcelainRecord(porcelainCommit, result.SourceName, result.ProjectCommit))
+		}
+		if result.Error != nil {
+			fmt.Fprintln(w, porcelainRecord(porcelainError, result.SourceName, result.Error.Error()))
+		}
+	}
+}
+
+// porcelainRecord joins a record's fields with tabs. Tabs and line breaks inside a field
+// become spaces, so every record is one line with a fixed number of fields.
+func porcelainRecord(kind string, fields ...string) string {
+	clean := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+	parts := []string{kind}
+	for _, field := range fields {
+		parts = append(parts, clean.Replace(field))
+	}
+	return strings.Join(parts, "\t")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+)
+
+// The porcelain format is a stable interface: a change to these golden files breaks
+// every script parsing it, so only ever add new record kinds
+func TestRenderPorcelain(t *testing.T) {
+	testCases := []struct {
+		name    string
+		results []git.SyncResult
+	}{
+		{
+			name: "detected_differences",
+			results: []git.SyncResult{
+				{
+					SourceName: "tools",
+					Conflicts: []hash.FileConflict{
+						{Path: "lint.sh", LocalPath: "tools/lint.sh", Type: hash.ConflictTypeModified},
+					},
+				},
+				{
+					SourceName: "library",
+					Conflicts: []hash.FileConflict{
+						{Path: "b.go", LocalPath: "lib/b.go", Type: hash.ConflictTypeDeleted},
+						{Path: "a.go", LocalPath: "lib/a.go", Type: hash.ConflictTypeModified},
+						{Path: "lib/c", LocalPath: "lib/c", Type: hash.ConflictTypeKind},
+					},
+					FileActions: []git.FileAction{
+						{Type: git.FileActionAdded, Path: "lib/new.go"},
+					},
+				},
+			},
+		},
+		{
+			name: "updates_and_errors",
+			results: []git.SyncResult{
+				{
+					SourceName: "library",
+					FileActions: []git.FileAction{
+						{Type: git.FileActionUpdated, Path: "src/main.go", Added: 3, Removed: 1},
+						{Type: git.FileActionRenamed, OldPath: "pkg/utils/strings.go", Path: "pkg/strutil/strings.go"},
+					},
+				},
+				{
+					SourceName: "private",
+					Error:      errors.New("failed to pull changes: authentication required"),
+				},
+				{
+					SourceName: "assets",
+					FileActions: []git.FileAction{
+						{Type: git.FileActionAdded, Path: "logo.png", Binary: true},
+					},
+					Error: errors.New("1 file(s) failed to sync: icon.ico"),
+				},
+			},
+		},
+		{
+			name: "fields_stay_on_one_line",
+			results: []git.SyncResult{{
+				SourceName: "odd",
+				Conflicts:  []hash.FileConflict{{Path: "tab\tname.md", LocalPath: "docs/tab\tname.md", Type: hash.ConflictTypeAdded}},
+				Error:      errors.New("first line\nsecond\tline"),
+			}},
+		},
+		{
+			name: "auto_commit",
+			results: []git.SyncResult{
+				{
+					SourceName:    "library",
+					FileActions:   []git.FileAction{{Type: git.FileActionUpdated, Path: "lib/a.go"}},
+					ProjectCommit: "1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d",
+				},
+				{
+					SourceName:  "tools",
+					FileActions: []git.FileAction{{Type: git.FileActionAdded, Path: "tools/lint.sh"}},
+				},
+			},
+		},
+		{
+			name:    "nothing_to_report",
+			results: []git.SyncResult{{SourceName: "library"}, {SourceName: "skipped", Skipped: errors.New("auth failed")}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			renderPorcelain(&buf, tc.results)
+			assertGolden(t, "sync_porcelain/"+tc.name, buf.Bytes())
+		})
+	}
+}
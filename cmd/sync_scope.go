@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// scopedSource is the part of a source a run syncs: the paths picked with --path,
+// checked out at --ref when given
+type scopedSource struct {
+	source  *config.Source // What the run syncs
+	indexes []int          // Index in the configured source of each scoped path
+}
+
+// splitSyncTargets reads sync's positional arguments: a source name, or "source:path"
+// to select tracked paths like --path does. Several arguments may name paths of the
+// same source ("mylib:src/ mylib:docs/"). An argument that is the name of a configured
+// source is taken as a name even if it holds a colon.
+func splitSyncTargets(args []string) (string, []string, error) {
+	var sourceName string
+	var paths []string
+	for _, arg := range args {
+		name, path := arg, ""
+		if _, exists := cfg.GetSource(arg); !exists {
+			if before, after, found := strings.Cut(arg, ":"); found {
+				name, path = before, after
+				if path == "" {
+					return "", nil, fmt.Errorf("'%s' names no path; use %s:<path>", arg, name)
+				}
+			}
+		}
+		if sourceName != "" && name != sourceName {
+			return "", nil, fmt.Errorf("sync takes a single source name, got '%s' and '%s' (use --all for every source)", sourceName, name)
+		}
+		if path == "" && name == sourceName {
+			logger.Warning("⚠️  Source %s is selected more than once; syncing it once", name)
+		}
+		sourceName = name
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return sourceName, paths, nil
+}
+
+// scopeSource narrows a source to the run's --path selection and --ref override.
+// Without either flag the configured source is synced as is.
+func scopeSource(source *config.Source) (*scopedSource, error) {
+	if len(syncPaths) == 0 && syncRef == "" {
+		indexes := make([]int, len(source.Paths))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return &scopedSource{source: source, indexes: indexes}, nil
+	}
+
+	scoped := *source
+	scoped.Paths = nil
+	var indexes []int
+	for i, pathSpec := range source.Paths {
+		if !pathSelected(pathSpec) {
+			continue
+		}
+		if syncRef != "" {
+			pathSpec.Branch, pathSpec.Pin = syncRef, ""
+		}
+		scoped.Paths = append(scoped.Paths, pathSpec)
+		indexes = append(indexes, i)
+	}
+
+	if missing := unmatchedPaths(source); len(missing) > 0 {
+		includes := make([]string, len(source.Paths))
+		for i, pathSpec := range source.Paths {
+			includes[i] = pathSpec.Include
+		}
+		return nil, fmt.Errorf("source '%s' does not track %s (tracked paths: %s)",
+			source.Name, strings.Join(missing, ", "), strings.Join(includes, ", "))
+	}
+
+	return &scopedSource{source: &scoped, indexes: indexes}, nil
+}
+
+// pathSelected reports whether --path selects a path (all paths without --path)
+func pathSelected(pathSpec config.PathSpec) bool {
+	if len(syncPaths) == 0 {
+		return true
+	}
+	for _, selected := range syncPaths {
+		if config.SamePath(selected, pathSpec.Include) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmatchedPaths returns the --path values that name none of the source's paths
+func unmatchedPaths(source *config.Source) []string {
+	var missing []string
+	for _, selected := range syncPaths {
+		found := false
+		for _, pathSpec := range source.Paths {
+			if config.SamePath(selected, pathSpec.Include) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, selected)
+		}
+	}
+	return missing
+}
+
+// announceRefOverride states loudly that the run doesn't sync the configured branches
+func (s *scopedSource) announceRefOverride() {
+	if syncRef == "" {
+		return
+	}
+	includes := make([]string, len(s.source.Paths))
+	for i, pathSpec := range s.source.Paths {
+		includes[i] = pathSpec.Include
+	}
+	logger.Warning("⚠️  REF OVERRIDE: syncing %s of %s from '%s' instead of the configured branch, for this run only",
+		strings.Join(includes, ", "), s.source.Name, syncRef)
+	if updateTracking {
+		logger.Warning("⚠️  --update-tracking: tracking hashes and base snapshots will record '%s'", syncRef)
+	} else {
+		logger.Warning("⚠️  Tracking hashes, base snapshots and the config are left unchanged (pass --update-tracking to record this content)")
+	}
+}
+
+// freezesTracking reports whether the run must not record what it synced: content from
+// a --ref override, or a one-off --from-cherrybunch sync
+func freezesTracking() bool {
+	return (syncRef != "" && !updateTracking) || fromCherryBunch != ""
+}
+
+// writeBack copies the synced paths' tracking data into the configured source, keeping
+// each path's configured branch and pin
+func (s *scopedSource) writeBack(source *config.Source) {
+	if s.source == source {
+		return
+	}
+	for k, i := range s.indexes {
+		synced := s.source.Paths[k]
+		synced.Branch, synced.Pin = source.Paths[i].Branch, source.Paths[i].Pin
+		source.Paths[i] = synced
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"sync"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// uniqueSources returns the selected sources in order, each name once: a source selected
+// again, e.g. listed twice in the configuration, is warned about and synced only once, as
+// its first entry. Syncing it twice would have the second pass overwrite the first's
+// tracking and commit the same files again.
+func uniqueSources(sources []config.Source) []config.Source {
+	seen := make(map[string]bool, len(sources))
+	unique := make([]config.Source, 0, len(sources))
+	for _, source := range sources {
+		if seen[source.Name] {
+			logger.Warning("⚠️  Source %s is selected more than once; syncing it once", source.Name)
+			continue
+		}
+		seen[source.Name] = true
+		unique = append(unique, source)
+	}
+	return unique
+}
+
+// syncedSources is the sources a sync run has started, so that however a source ends up
+// selected twice, the run syncs it once
+type syncedSources struct {
+	sync.Mutex
+	names map[string]bool
+}
+
+func newSyncedSources() *syncedSources {
+	return &syncedSources{names: make(map[string]bool)}
+}
+
+// syncRunSources holds the sources the current sync run has started
+var syncRunSources = newSyncedSources()
+
+// claim records that a source is being synced and reports whether it wasn't already
+func (s *syncedSources) claim(name string) bool {
+	s.Lock()
+	defer s.Unlock()
+	if s.names[name] {
+		return false
+	}
+	s.names[name] = true
+	return true
+}
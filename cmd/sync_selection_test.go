@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	gogit "github.com/go-git/go-git/v5"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/history"
+	"cherry-go/internal/logger"
+)
+
+func TestUniqueSources(t *testing.T) {
+	logger.Init()
+	sources := []config.Source{
+		{Name: "zeta", Repository: "https://example.com/zeta.git"},
+		{Name: "alpha", Repository: "https://example.com/alpha.git"},
+		{Name: "zeta", Repository: "https://example.com/other.git"},
+		{Name: "alpha"},
+		{Name: "beta"},
+	}
+
+	unique := uniqueSources(sources)
+	var names []string
+	for _, source := range unique {
+		names = append(names, source.Name)
+	}
+	if strings.Join(names, ",") != "zeta,alpha,beta" {
+		t.Fatalf("Expected each source once in selection order, got %v", names)
+	}
+	if unique[0].Repository != "https://example.com/zeta.git" {
+		t.Errorf("Expected the first entry of a duplicate to be kept, got %s", unique[0].Repository)
+	}
+
+	synced := newSyncedSources()
+	if !synced.claim("zeta") || synced.claim("zeta") || !synced.claim("alpha") {
+		t.Error("Expected a source to be claimed once per run")
+	}
+}
+
+func TestE2E_SyncDuplicateSelection(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+
+	// The same source listed twice, as a merge of two config branches can leave it
+	library := config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	cfg := loadProjectConfig(t, project)
+	cfg.Sources = append(cfg.Sources, library, library)
+	if err := cfg.Save(project.ConfigPath()); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	project.Commit("configure library twice")
+	head := func() string {
+		t.Helper()
+		ref, err := project.Repo().Head()
+		if err != nil {
+			t.Fatalf("Failed to read HEAD: %v", err)
+		}
+		return ref.Hash().String()
+	}
+	commits := func(since string) int {
+		t.Helper()
+		log, err := project.Repo().Log(&gogit.LogOptions{})
+		if err != nil {
+			t.Fatalf("Failed to read the project log: %v", err)
+		}
+		count := 0
+		for commit, err := log.Next(); err == nil && commit.Hash.String() != since; commit, err = log.Next() {
+			count++
+		}
+		return count
+	}
+
+	before := head()
+	output := mustRunCLI(t, "sync", "--all", "--force")
+	if !strings.Contains(output, "Source library is selected more than once; syncing it once") {
+		t.Errorf("Expected a warning about the duplicate, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Syncing 1 source(s)") || strings.Count(output, "Successfully synced library") != 1 {
+		t.Errorf("Expected library to sync once, got:\n%s", output)
+	}
+	if got := commits(before); got != 1 {
+		t.Errorf("Expected a single commit for library, got %d", got)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "func A()") {
+		t.Errorf("Expected lib/ to be synced, got %q", got)
+	}
+
+	// Named twice on the command line, it syncs once too
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("change A")
+	before = head()
+	output = mustRunCLI(t, "sync", "library", "library", "--force")
+	if !strings.Contains(output, "Source library is selected more than once; syncing it once") {
+		t.Errorf("Expected a warning about the repeated name, got:\n%s", output)
+	}
+	if got := commits(before); got != 1 {
+		t.Errorf("Expected a single commit for library, got %d", got)
+	}
+	if got := project.ReadFile("lib/a.go"); !strings.Contains(got, "A changed") {
+		t.Errorf("Expected lib/ to be updated, got %q", got)
+	}
+}
+
+func TestSyncSource_DuplicateInRun(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A changed\nfunc A() {}\n")
+	upstream.Commit("change A")
+
+	// A selection that slipped past uniqueSources still syncs the source once
+	syncRunSources = newSyncedSources()
+	library := *requireSource(t, project, "library")
+	var results []git.SyncResult
+	for result := range syncConcurrently([]config.Source{library, library}, 2, git.SyncModeForce, func(source *config.Source) git.SyncResult {
+		return syncSource(&output{w: io.Discard}, source, project.Dir, git.SyncModeForce)
+	}) {
+		results = append(results, result)
+	}
+
+	var duplicates, updated int
+	for _, result := range results {
+		if result.Error != nil {
+			t.Fatalf("Expected no error, got %v", result.Error)
+		}
+		switch result.Status() {
+		case history.StatusDuplicate:
+			duplicates++
+		case history.StatusUpdated:
+			updated++
+		}
+	}
+	if duplicates != 1 || updated != 1 {
+		t.Errorf("Expected one update and one duplicate, got %d and %d", updated, duplicates)
+	}
+
+	// The duplicate is reported as skipped, not as up to date
+	var stat bytes.Buffer
+	renderSyncStat(&stat, results)
+	if !strings.Contains(stat.String(), "skipped: already synced in this run") {
+		t.Errorf("Expected the duplicate to be reported, got:\n%s", stat.String())
+	}
+	report := git.NewSyncReport(results, false)
+	if report.Sources[0].Status == history.StatusUpToDate || report.Sources[1].Status == history.StatusUpToDate {
+		t.Errorf("Expected no source reported up to date, got %+v", report.Sources)
+	}
+}
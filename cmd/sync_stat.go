@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/utils"
+)
+
+// statBarWidth is the maximum width of the +/- histogram in --stat output
+const statBarWidth = 40
+
+// renderSyncStat writes a `git diff --stat`-style summary of the files each source changed
+func renderSyncStat(w io.Writer, results []git.SyncResult) {
+	sorted := make([]git.SyncResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SourceName < sorted[j].SourceName })
+
+	rendered := false
+	for _, result := range sorted {
+		if len(result.FileActions) == 0 {
+			continue
+		}
+		if rendered {
+			fmt.Fprintln(w)
+		}
+		renderSourceStat(w, result.SourceName, result.FileActions)
+		rendered = true
+	}
+
+	if !rendered {
+		fmt.Fprintln(w, "No file changes")
+	}
+
+	for _, result := range sorted {
+		if result.Duplicate {
+			fmt.Fprintf(w, "\n%s\n skipped: already synced in this run\n", result.SourceName)
+		} else if result.Skipped != nil {
+			fmt.Fprintf(w, "\n%s\n skipped (optional): %v\n", result.SourceName, result.Skipped)
+		}
+	}
+}
+
+// renderSourceStat writes the stat block for a single source
+func renderSourceStat(w io.Writer, sourceName string, actions []git.FileAction) {
+	names := make([]string, len(actions))
+	nameWidth, countWidth, maxChanges := 0, 1, 0
+	for i, action := range actions {
+		names[i] = statName(action)
+		// Columns, not bytes: "日本語.md" is 9 bytes but 8 columns wide
+		nameWidth = max(nameWidth, runewidth.StringWidth(names[i]))
+		if !action.Binary && !action.TooLarge {
+			changes := action.Added + action.Removed
+			countWidth = max(countWidth, len(fmt.Sprint(changes)))
+			maxChanges = max(maxChanges, changes)
+		}
+	}
+
+	fmt.Fprintln(w, sourceName)
+
+	insertions, deletions := 0, 0
+	for i, action := range actions {
+		fmt.Fprintf(w, " %s | ", runewidth.FillRight(names[i], nameWidth))
+
+		switch {
+		case action.Binary:
+			fmt.Fprintf(w, "bin %s\n", sizeChange(action))
+		case action.TooLarge:
+			fmt.Fprintf(w, "large %s\n", sizeChange(action))
+		default:
+			changes := action.Added + action.Removed
+			plus, minus := statBar(action.Added, action.Removed, maxChanges)
+			line := fmt.Sprintf("%*d %s%s", countWidth, changes, strings.Repeat("+", plus), strings.Repeat("-", minus))
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+			insertions += action.Added
+			deletions += action.Removed
+		}
+	}
+
+	fmt.Fprintf(w, " %d %s changed, %d %s(+), %d %s(-)\n",
+		len(actions), plural(len(actions), "file", "files"),
+		insertions, plural(insertions, "insertion", "insertions"),
+		deletions, plural(deletions, "deletion", "deletions"))
+}
+
+// statName is the path shown for an action, with the old name for renames
+func statName(action git.FileAction) string {
+	if action.Type == git.FileActionRenamed {
+		return fmt.Sprintf("%s => %s", utils.DisplayPath(action.OldPath), utils.DisplayPath(action.Path))
+	}
+	return utils.DisplayPath(action.Path)
+}
+
+// statBar scales added/removed counts so the largest change fits in statBarWidth
+func statBar(added, removed, maxChanges int) (plus, minus int) {
+	if maxChanges <= statBarWidth {
+		return added, removed
+	}
+
+	total := (added + removed) * statBarWidth / maxChanges
+	if total == 0 && added+removed > 0 {
+		total = 1
+	}
+	plus = (added*total + (added+removed)/2) / (added + removed)
+	return plus, total - plus
+}
+
+// sizeChange formats the size difference of a file compared by size only
+func sizeChange(action git.FileAction) string {
+	delta := action.NewSize - action.OldSize
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	return fmt.Sprintf("%s -> %s (%s%s)", format.Bytes(action.OldSize), format.Bytes(action.NewSize), sign, format.Bytes(delta))
+}
+
+// plural picks the singular or plural word for n
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
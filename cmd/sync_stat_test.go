@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/git"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// assertGolden compares output with testdata/<name>.golden, rewriting it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s:\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestRenderSyncStat(t *testing.T) {
+	testCases := []struct {
+		name    string
+		results []git.SyncResult
+	}{
+		{
+			name: "single_source",
+			results: []git.SyncResult{{
+				SourceName: "library",
+				FileActions: []git.FileAction{
+					{Type: git.FileActionUpdated, Path: "src/main.go", Added: 3, Removed: 1},
+					{Type: git.FileActionAdded, Path: "lib/new.go", Added: 12},
+					{Type: git.FileActionRenamed, OldPath: "pkg/utils/strings.go", Path: "pkg/strutil/strings.go"},
+				},
+			}},
+		},
+		{
+			name: "scaled_and_sorted",
+			results: []git.SyncResult{
+				{
+					SourceName: "tools",
+					FileActions: []git.FileAction{
+						{Type: git.FileActionUpdated, Path: "generated.pb.go", Added: 150, Removed: 50},
+						{Type: git.FileActionUpdated, Path: "small.go", Added: 1},
+					},
+				},
+				{SourceName: "unchanged"},
+				{
+					SourceName: "docs",
+					FileActions: []git.FileAction{
+						{Type: git.FileActionUpdated, Path: "README.md", Removed: 2},
+					},
+				},
+			},
+		},
+		{
+			name: "binary_and_large",
+			results: []git.SyncResult{{
+				SourceName: "assets",
+				FileActions: []git.FileAction{
+					{Type: git.FileActionUpdated, Path: "logo.png", Binary: true, OldSize: 1200, NewSize: 1512},
+					{Type: git.FileActionUpdated, Path: "dump.sql", TooLarge: true, OldSize: 3 << 20, NewSize: 2 << 20},
+					{Type: git.FileActionAdded, Path: "icon.ico", Binary: true, NewSize: 318},
+				},
+			}},
+		},
+		{
+			name: "unusual_names",
+			results: []git.SyncResult{{
+				SourceName: "names",
+				FileActions: []git.FileAction{
+					{Type: git.FileActionUpdated, Path: "docs/日本語.md", Added: 1},
+					{Type: git.FileActionUpdated, Path: "docs/café/naïve résumé.md", Added: 2, Removed: 1},
+					{Type: git.FileActionAdded, Path: "docs/ leading space.md", Added: 3},
+					{Type: git.FileActionUpdated, Path: "docs/[draft] #1.md", Removed: 1},
+				},
+			}},
+		},
+		{
+			name:    "no_changes",
+			results: []git.SyncResult{{SourceName: "library"}},
+		},
+		{
+			name: "skipped_optional",
+			results: []git.SyncResult{
+				{
+					SourceName: "library",
+					FileActions: []git.FileAction{
+						{Type: git.FileActionUpdated, Path: "lib/a.go", Added: 2, Removed: 2},
+					},
+				},
+				{SourceName: "nightly", Skipped: errors.New("failed to clone repository: authentication required")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			renderSyncStat(&out, tc.results)
+			assertGolden(t, filepath.Join("sync_stat", tc.name), out.Bytes())
+		})
+	}
+}
+
+func TestE2E_SyncStat(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	newCLIProject(t)
+	mustRunCLI(t, "add", "directory", upstream.PathURL("lib/"), "--auto-add-repo")
+
+	upstream.WriteFile("lib/a.go", "package lib\n\n// A is the first helper, now documented\nfunc A() {}\n")
+	upstream.WriteFile("lib/c.go", "package lib\n")
+	upstream.Commit("update lib")
+
+	output := mustRunCLI(t, "sync", "library", "--merge", "--stat")
+	for _, expected := range []string{"lib/a.go | 2 +-", "lib/c.go | 1 +", "2 files changed, 2 insertions(+), 1 deletion(-)"} {
+		if !bytes.Contains([]byte(output), []byte(expected)) {
+			t.Errorf("Expected stat output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// conflictResult builds a sync result with modified-file conflicts
+func conflictResult(source string, paths ...string) git.SyncResult {
+	result := git.SyncResult{SourceName: source}
+	for _, path := range paths {
+		result.Conflicts = append(result.Conflicts, hash.FileConflict{Path: path, Type: hash.ConflictTypeModified})
+	}
+	return result
+}
+
+func TestCompactConflictSummary(t *testing.T) {
+	var many []string
+	for i := 1; i <= 14; i++ {
+		many = append(many, fmt.Sprintf("f%02d.go", i))
+	}
+
+	testCases := []struct {
+		name     string
+		results  []git.SyncResult
+		expected string
+	}{
+		{
+			name:     "single source",
+			results:  []git.SyncResult{conflictResult("lib", "a.go", "b.go")},
+			expected: "lib: a.go, b.go",
+		},
+		{
+			name: "each file attributed to its own source",
+			results: []git.SyncResult{
+				conflictResult("tools", "run.sh"),
+				conflictResult("clean"),
+				conflictResult("lib", "a.go"),
+			},
+			expected: "lib: a.go; tools: run.sh",
+		},
+		{
+			name: "duplicates listed once",
+			results: []git.SyncResult{
+				conflictResult("lib", "a.go", "a.go"),
+				conflictResult("lib", "a.go", "b.go"),
+				conflictResult("tools", "a.go"),
+			},
+			expected: "lib: a.go, b.go; tools: a.go",
+		},
+		{
+			name:     "long list capped",
+			results:  []git.SyncResult{conflictResult("lib", many...)},
+			expected: "lib: f01.go, f02.go, f03.go, f04.go, f05.go, f06.go, f07.go, f08.go, f09.go, f10.go (+4 more)",
+		},
+		{
+			name: "cap shared across sources",
+			results: []git.SyncResult{
+				conflictResult("b-lib", many[:9]...),
+				conflictResult("a-lib", "x.go"),
+				conflictResult("c-lib", "y.go", "z.go"),
+			},
+			expected: "a-lib: x.go; b-lib: f01.go, f02.go, f03.go, f04.go, f05.go, f06.go, f07.go, f08.go, f09.go; c-lib: 2 file(s)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := compactConflictSummary(tc.results, 10); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDescribeFailedFiles(t *testing.T) {
+	var failures []git.FileFailure
+	for i := 1; i <= 7; i++ {
+		failures = append(failures, git.FileFailure{LocalPath: fmt.Sprintf("lib/f%d.go", i)})
+	}
+
+	testCases := []struct {
+		count    int
+		expected string
+	}{
+		{1, "lib/f1.go"},
+		{5, "lib/f1.go, lib/f2.go, lib/f3.go, lib/f4.go, lib/f5.go"},
+		{7, "lib/f1.go, lib/f2.go, lib/f3.go, lib/f4.go, lib/f5.go, (+2 more)"},
+	}
+
+	for _, tc := range testCases {
+		if got := describeFailedFiles(failures[:tc.count], failedFilesLimit); got != tc.expected {
+			t.Errorf("describeFailedFiles(%d failures) = %q, expected %q", tc.count, got, tc.expected)
+		}
+	}
+}
+
+func TestDescribeSyncMetrics(t *testing.T) {
+	testCases := []struct {
+		results  []git.SyncResult
+		expected string
+	}{
+		{nil, ""},
+		{[]git.SyncResult{{SourceName: "library"}}, ""},
+		{
+			[]git.SyncResult{
+				{SourceName: "library", Metrics: git.SyncMetrics{FilesCompared: 20000, FilesCopied: 8000, BytesCopied: 400 << 20, FilesHashed: 20000}},
+				{SourceName: "tools", Metrics: git.SyncMetrics{FilesCompared: 10000, FilesCopied: 200, BytesCopied: 10 << 20, FilesHashed: 10000}},
+			},
+			" (files: 30,000 compared, 8,200 copied (410.0 MB), 30,000 hashed)",
+		},
+		{
+			[]git.SyncResult{{SourceName: "library", Metrics: git.SyncMetrics{FilesCompared: 3}}},
+			" (files: 3 compared)",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := describeSyncMetrics(tc.results); got != tc.expected {
+			t.Errorf("describeSyncMetrics(%+v) = %q, expected %q", tc.results, got, tc.expected)
+		}
+	}
+}
+
+// withVerbosity sets the logger verbosity for the duration of a test
+func withVerbosity(t *testing.T, level int) {
+	t.Helper()
+	previous := logger.GetVerbosityLevel()
+	logger.SetVerbosityLevel(level)
+	t.Cleanup(func() { logger.SetVerbosityLevel(previous) })
+}
+
+func TestPrintDetectedConflictsInstructions(t *testing.T) {
+	withVerbosity(t, 1)
+	previous := maxConflicts
+	maxConflicts = 2
+	t.Cleanup(func() { maxConflicts = previous })
+
+	var buf bytes.Buffer
+	printDetectedConflictsInstructions(&output{w: &buf}, []git.SyncResult{conflictResult("lib", "a.go", "b.go", "c.go")})
+
+	for _, want := range []string{"DIFFERENCES DETECTED", "lib", "    • a.go\n", "    • b.go\n", "    …and 1 more\n", "--mark-conflicts", "--force"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "c.go") {
+		t.Errorf("Expected c.go to be over the --max-conflicts limit, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintConflictResolutionInstructions(t *testing.T) {
+	result := conflictResult("lib", "a.go")
+	result.BranchCreated = "cherry-go/sync/lib-1"
+
+	var buf bytes.Buffer
+	printConflictResolutionInstructions(&output{w: &buf}, []git.SyncResult{result})
+
+	for _, want := range []string{"Remote changes saved to branch", "Files with conflicts:", "  • a.go\n", "git merge cherry-go/sync/lib-1", "git branch -d cherry-go/sync/lib-1"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected %q in output, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSyncConcurrently_BoundsParallelism(t *testing.T) {
+	logger.Init()
+	sources := make([]config.Source, 40)
+	for i := range sources {
+		sources[i] = config.Source{Name: fmt.Sprintf("source-%02d", i), Repository: fmt.Sprintf("https://github.com/user/source-%02d.git", i)}
+	}
+
+	// The fake sync records how many syncs overlap and holds each long enough to pile up
+	var running, peak atomic.Int32
+	fakeSync := func(source *config.Source) git.SyncResult {
+		now := running.Add(1)
+		for {
+			seen := peak.Load()
+			if now <= seen || peak.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return git.SyncResult{SourceName: source.Name}
+	}
+
+	synced := make(map[string]bool)
+	for result := range syncConcurrently(sources, 3, git.SyncModeForce, fakeSync) {
+		synced[result.SourceName] = true
+	}
+
+	if len(synced) != len(sources) {
+		t.Errorf("Expected all %d sources synced, got %d", len(sources), len(synced))
+	}
+	if got := peak.Load(); got > 3 {
+		t.Errorf("Expected at most 3 concurrent syncs, saw %d", got)
+	}
+	if got := peak.Load(); got < 2 {
+		t.Errorf("Expected sources to sync concurrently, saw at most %d at once", got)
+	}
+}
+
+func TestSyncConcurrently_SharedRepositoryTakesTurns(t *testing.T) {
+	logger.Init()
+	sources := make([]config.Source, 8)
+	for i := range sources {
+		repository := "https://github.com/user/shared.git"
+		if i%2 == 1 {
+			repository = fmt.Sprintf("https://github.com/user/other-%d.git", i)
+		}
+		sources[i] = config.Source{Name: fmt.Sprintf("source-%d", i), Repository: repository}
+	}
+
+	// Sources of the shared repository must never overlap; the others still may
+	var shared, sharedPeak, running, peak atomic.Int32
+	record := func(counter, max *atomic.Int32) {
+		now := counter.Add(1)
+		for {
+			seen := max.Load()
+			if now <= seen || max.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+	}
+	fakeSync := func(source *config.Source) git.SyncResult {
+		if source.Repository == "https://github.com/user/shared.git" {
+			record(&shared, &sharedPeak)
+			defer shared.Add(-1)
+		}
+		record(&running, &peak)
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return git.SyncResult{SourceName: source.Name}
+	}
+
+	synced := 0
+	for range syncConcurrently(sources, 4, git.SyncModeForce, fakeSync) {
+		synced++
+	}
+
+	if synced != len(sources) {
+		t.Errorf("Expected all %d sources synced, got %d", len(sources), synced)
+	}
+	if got := sharedPeak.Load(); got != 1 {
+		t.Errorf("Expected sources of one repository to sync one at a time, saw %d at once", got)
+	}
+	if got := peak.Load(); got < 2 {
+		t.Errorf("Expected other sources to sync concurrently, saw at most %d at once", got)
+	}
+}
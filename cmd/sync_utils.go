@@ -2,19 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"cherry-go/internal/git"
 	"cherry-go/internal/logger"
 )
 
 // performInitialSync performs the initial sync for a newly added file/directory
-func performInitialSync(repoName string) error {
-	// Get current working directory
-	workDir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
+func performInitialSync(out *output, repoName string) error {
+	workDir := enterProjectRoot()
 
 	// Get the source from configuration
 	source, exists := cfg.GetSource(repoName)
@@ -23,7 +18,7 @@ func performInitialSync(repoName string) error {
 	}
 
 	// Perform sync for this specific source using default merge mode
-	result := syncSource(source, workDir, git.SyncModeMerge)
+	result := syncSource(out, source, workDir, git.SyncModeMerge)
 
 	if result.Error != nil {
 		return result.Error
@@ -32,7 +27,7 @@ func performInitialSync(repoName string) error {
 	if len(result.Conflicts) > 0 {
 		logger.Warning("Conflicts detected during initial sync:")
 		for _, conflict := range result.Conflicts {
-			logger.Warning("  - %s", conflict.String())
+			logger.Warning("  - %s%s", conflict.String(), conflictAttribution(conflict))
 		}
 	}
 
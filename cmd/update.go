@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+
+	"github.com/spf13/cobra"
+)
+
+var updatePin bool
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update --pin SOURCE PATH SHA",
+	Short: "Change the commit a tracked path is pinned to",
+	Long: `Change how a tracked path follows upstream, without syncing it.
+
+With --pin, PATH of SOURCE is pinned to the commit SHA (a full 40-character hash),
+after checking that upstream has it. Like 'cherry-go pin', the commit is stored as
+the path's pin and its branch is kept for when the pin is removed. A pinned path
+never moves - every sync reads it at that commit, and a source whose paths are all
+pinned isn't even fetched. Run 'cherry-go sync SOURCE' afterwards to apply the new
+pin, and 'cherry-go unpin' to follow the branch again.
+
+To change a source's repository URL, authentication or name, see 'cherry-go update repo'.
+
+Examples:
+  cherry-go update --pin mylib src/ 3f2c9a0e4b1d7c8e9f00112233445566778899aa
+  cherry-go update --pin mylib LICENSE 3f2c9a0e4b1d7c8e9f00112233445566778899aa --dry-run`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !updatePin {
+			logger.Fatal("nothing to update: pass --pin to pin a path to a commit")
+		}
+		if err := pinPath(args[0], args[1], args[2]); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// pinPath pins a tracked path to a commit that upstream is verified to have, as pin does
+func pinPath(sourceName, include, sha string) error {
+	if !config.IsCommitSHA(strings.ToLower(sha)) {
+		return fmt.Errorf("'%s' is not a full 40-character commit SHA", sha)
+	}
+	return pinPathRef(sourceName, include, sha)
+}
+
+// findTrackedPath returns the configured source and path spec an update applies to
+func findTrackedPath(sourceName, include string) (*config.Source, *config.PathSpec, error) {
+	for i := range cfg.Sources {
+		source := &cfg.Sources[i]
+		if source.Name != sourceName {
+			continue
+		}
+		includes := make([]string, len(source.Paths))
+		for j := range source.Paths {
+			if config.SamePath(source.Paths[j].Include, include) {
+				return source, &source.Paths[j], nil
+			}
+			includes[j] = source.Paths[j].Include
+		}
+		return nil, nil, fmt.Errorf("source '%s' does not track %s (tracked paths: %s)",
+			sourceName, include, strings.Join(includes, ", "))
+	}
+	return nil, nil, fmt.Errorf("source '%s' not found. Available sources: %v", sourceName, getRepositoryNames())
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().BoolVar(&updatePin, "pin", false, "pin PATH of SOURCE to the commit SHA")
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/history"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+var (
+	updateRepoURL      string
+	updateRepoAuthType string
+	updateRepoAuthUser string
+	updateRepoSSHKey   string
+	updateRepoRefresh  bool
+	updateRepoRename   string
+)
+
+// updateRepoCmd represents the update repo command
+var updateRepoCmd = &cobra.Command{
+	Use:   "repo NAME",
+	Short: "Change a source's repository URL, authentication or name in place",
+	Long: `Change where a source's repository lives, how it authenticates or what the
+source is called, keeping its tracked paths, hashes and commits, so a repository
+that moved hosts doesn't have to be removed and added again.
+
+With --url the cached clone moves along with the URL, keeping the history the
+source was synced from; it is left in place while another source still uses the
+old URL. The authentication type is detected again from the new URL unless
+--auth-type is given. --refresh clones the repository again right away instead,
+checking that the new URL and credentials work before anything is saved.
+
+With --rename the source's base-content snapshots, used for three-way merges,
+and its last sync move to the new name. Existing conflict branches keep theirs.
+
+Examples:
+  cherry-go update repo mylib --url git@gitlab.company.com:team/mylib.git
+  cherry-go update repo mylib --url https://gitlab.company.com/team/mylib.git --auth-type basic --auth-user ci
+  cherry-go update repo mylib --auth-ssh-key ~/.ssh/company_key --refresh
+  cherry-go update repo mylib --rename company-lib`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := updateRepository(cmd, args[0]); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// updateRepository rewrites a source's repository, authentication and name as the flags ask.
+// Cached clones and snapshots follow before the configuration is saved, and a failed
+// --refresh clone saves nothing.
+func updateRepository(cmd *cobra.Command, name string) error {
+	flags := cmd.Flags()
+	if !flags.Changed("url") && !flags.Changed("auth-type") && !flags.Changed("auth-user") &&
+		!flags.Changed("auth-ssh-key") && updateRepoRename == "" && !updateRepoRefresh {
+		return fmt.Errorf("nothing to update: pass --url, --auth-type, --auth-user, --auth-ssh-key, --rename or --refresh")
+	}
+
+	// The configured source itself, so the update is saved
+	var source *config.Source
+	for i := range cfg.Sources {
+		if cfg.Sources[i].Name == name {
+			source = &cfg.Sources[i]
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("source '%s' not found. Available sources: %v", name, getRepositoryNames())
+	}
+	if updateRepoRename != "" && updateRepoRename != name {
+		if _, exists := cfg.GetSource(updateRepoRename); exists {
+			return fmt.Errorf("source '%s' already exists; choose another name", updateRepoRename)
+		}
+	}
+
+	updated := *source
+	if flags.Changed("url") {
+		parsed, err := utils.NormalizeRepoURL(updateRepoURL)
+		if err != nil {
+			return err
+		}
+		for _, warning := range parsed.Warnings {
+			logger.Warning("⚠️  %s", warning)
+		}
+		if parsed.URL != updateRepoURL {
+			logger.Info("Normalized repository URL to %s", parsed.URL)
+		}
+		if parsed.Branch != "" {
+			logger.Warning("⚠️  The URL pointed at branch '%s'; tracked paths keep their own branches", parsed.Branch)
+		}
+		updated.Repository = parsed.URL
+		if !flags.Changed("auth-type") && updated.Repository != source.Repository {
+			updated.Auth.Type = detectAuthType(updated.Repository)
+		}
+	}
+	if flags.Changed("auth-type") {
+		updated.Auth.Type = updateRepoAuthType
+		if updated.Auth.Type == "" || updated.Auth.Type == "auto" {
+			updated.Auth.Type = detectAuthType(updated.Repository)
+		}
+	}
+	if flags.Changed("auth-user") {
+		updated.Auth.Username = updateRepoAuthUser
+	}
+	if flags.Changed("auth-ssh-key") {
+		updated.Auth.SSHKey = updateRepoSSHKey
+	}
+	if updateRepoRename != "" {
+		updated.Name = updateRepoRename
+	}
+
+	describeRepositoryUpdate(source, &updated)
+	if logger.IsDryRun() {
+		return nil
+	}
+
+	if updateRepoRefresh {
+		if err := recloneRepository(&updated); err != nil {
+			return fmt.Errorf("failed to clone %s, nothing was updated: %w", updated.Repository, err)
+		}
+	}
+
+	renamed := updated.Name != source.Name
+	if renamed {
+		if err := renameSourceSnapshots(source.Name, updated.Name); err != nil {
+			return err
+		}
+	}
+
+	previous := *source
+	*source = updated
+	if err := saveConfig(); err != nil {
+		*source = previous
+		if renamed {
+			if undoErr := renameSourceSnapshots(updated.Name, previous.Name); undoErr != nil {
+				logger.Warning("⚠️  %v", undoErr)
+			}
+		}
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	if updated.Repository != previous.Repository && !updated.Ephemeral() {
+		followRepositoryMove(previous.Repository, source)
+	}
+	if renamed {
+		renameSourceState(previous.Name, updated.Name)
+	}
+
+	logger.Info("✅ Updated repository '%s'", updated.Name)
+	if !updateRepoRefresh && updated.Repository != previous.Repository {
+		logger.Info("Run 'cherry-go sync %s' to sync from the new repository", updated.Name)
+	}
+	return nil
+}
+
+// describeRepositoryUpdate logs what changes between the source and its update
+func describeRepositoryUpdate(source, updated *config.Source) {
+	log := logger.Info
+	if logger.IsDryRun() {
+		log = logger.DryRunInfo
+	}
+
+	changed := false
+	describe := func(field, from, to string) {
+		if from == to {
+			return
+		}
+		if from == "" {
+			from = "(none)"
+		}
+		if to == "" {
+			to = "(none)"
+		}
+		log("  %s: %s → %s", field, from, to)
+		changed = true
+	}
+	describe("Name", source.Name, updated.Name)
+	describe("URL", source.Repository, updated.Repository)
+	describe("Authentication", source.Auth.Type, updated.Auth.Type)
+	describe("Username", source.Auth.Username, updated.Auth.Username)
+	describe("SSH key", source.Auth.SSHKey, updated.Auth.SSHKey)
+	if !changed {
+		log("  Repository settings unchanged")
+	}
+	if updateRepoRefresh && logger.IsDryRun() {
+		logger.DryRunInfo("Would clone %s again", updated.Repository)
+	}
+}
+
+// recloneRepository replaces the source's cached clone with a fresh clone of its repository
+func recloneRepository(source *config.Source) error {
+	if !source.Ephemeral() {
+		cacheManager, err := cache.NewManager()
+		if err != nil {
+			return err
+		}
+		if err := cacheManager.RemoveRepository(source.Repository); err != nil {
+			return err
+		}
+	}
+
+	repo, err := git.NewRepository(source)
+	if err != nil {
+		return err
+	}
+	closeRepository(repo)
+	logger.Info("Cloned %s again", source.Repository)
+	return nil
+}
+
+// renameSourceSnapshots moves a source's base-content snapshots to its new name
+func renameSourceSnapshots(oldName, newName string) error {
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		logger.Warning("Could not access base-content snapshots: %v", err)
+		return nil
+	}
+	if err := baseManager.RenameSourceSnapshots(oldName, newName); err != nil {
+		return fmt.Errorf("failed to move base-content snapshots: %w", err)
+	}
+	return nil
+}
+
+// followRepositoryMove moves the cached clone of the source's old URL to its new one, or
+// removes it once no source uses it, in this project or another one. The cache is only a
+// cache: failures are warnings.
+func followRepositoryMove(oldURL string, source *config.Source) {
+	if shared := cfg.SourcesUsingRepository(oldURL, ""); len(shared) > 0 {
+		logger.Info("Keeping the cached clone of %s (still used by: %v)", oldURL, shared)
+		return
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil || !cacheManager.RepositoryExists(oldURL) {
+		return
+	}
+	if users, known := cachedRepositoryUsers(cacheManager, oldURL); !known {
+		logger.Info("Keeping the cached clone of %s: it doesn't record which projects use it", oldURL)
+		return
+	} else if len(users) > 0 {
+		logger.Info("Keeping the cached clone of %s (still used by: %s)", oldURL, strings.Join(users, ", "))
+		return
+	}
+
+	moved, err := git.MoveCache(oldURL, source)
+	if err != nil {
+		logger.Warning("⚠️  Failed to move the cached clone of %s: %v", oldURL, err)
+		return
+	}
+	if moved {
+		logger.Info("Moved the cached clone of %s to %s", oldURL, source.Repository)
+		return
+	}
+
+	if err := cacheManager.RemoveRepository(oldURL); err != nil {
+		logger.Warning("⚠️  %v", err)
+		return
+	}
+	logger.Info("Removed the cached clone of %s", oldURL)
+}
+
+// renameSourceState carries a renamed source's last sync over to its new name
+func renameSourceState(oldName, newName string) {
+	statePath := history.StatePath(configFile)
+	state, err := history.ReadState(statePath)
+	if err != nil {
+		logger.Warning("⚠️  Failed to read the sync state: %v", err)
+		return
+	}
+	last, ok := state.Sources[oldName]
+	if !ok {
+		return
+	}
+	if err := history.RecordSyncs(statePath, map[string]history.SourceState{newName: last}); err != nil {
+		logger.Warning("⚠️  Failed to record the last sync of %s: %v", newName, err)
+	}
+}
+
+func init() {
+	updateCmd.AddCommand(updateRepoCmd)
+
+	updateRepoCmd.Flags().StringVar(&updateRepoURL, "url", "", "new repository URL")
+	updateRepoCmd.Flags().StringVar(&updateRepoAuthType, "auth-type", "auto", "authentication type (auto, ssh, basic)")
+	updateRepoCmd.Flags().StringVar(&updateRepoAuthUser, "auth-user", "", "username for basic auth")
+	updateRepoCmd.Flags().StringVar(&updateRepoSSHKey, "auth-ssh-key", "", "path to SSH private key")
+	updateRepoCmd.Flags().BoolVar(&updateRepoRefresh, "refresh", false, "clone the repository again right away")
+	updateRepoCmd.Flags().StringVar(&updateRepoRename, "rename", "", "new name for the source")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+)
+
+func TestE2E_UpdateRepo(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+	mustRunCLI(t, "sync", "library")
+	project.Commit("sync library")
+	synced := requireSource(t, project, "library")
+
+	// The repository moves; the old URL stops working
+	oldURL := upstream.URL()
+	movedDir := filepath.Join(t.TempDir(), "moved.git")
+	if err := os.Rename(upstream.BareDir, movedDir); err != nil {
+		t.Fatalf("Failed to move the repository: %v", err)
+	}
+	newURL := "file://" + filepath.ToSlash(movedDir)
+
+	if result := runCLI(t, "update", "repo", "library", "--url", "file:///nonexistent/library.git", "--refresh"); result.ExitCode == 0 {
+		t.Errorf("Expected a refresh of a URL that can't be cloned to fail, got:\n%s", result)
+	}
+	if source := requireSource(t, project, "library"); source.Repository != oldURL {
+		t.Errorf("Expected a failed refresh to save nothing, got %s", source.Repository)
+	}
+
+	output := mustRunCLI(t, "update", "repo", "library", "--url", newURL)
+	if !strings.Contains(output, "Moved the cached clone") {
+		t.Errorf("Expected the cached clone to follow the URL, got:\n%s", output)
+	}
+	source := requireSource(t, project, "library")
+	if source.Repository != newURL || !reflect.DeepEqual(source.Paths, synced.Paths) {
+		t.Errorf("Expected only the URL to change, got %+v", source)
+	}
+
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if cacheManager.RepositoryExists(oldURL) || !cacheManager.RepositoryExists(newURL) {
+		t.Error("Expected the clone to be cached under the new URL only")
+	}
+	clone, err := git.PlainOpen(cacheManager.GetRepositoryPath(newURL))
+	if err != nil {
+		t.Fatalf("Failed to open the moved clone: %v", err)
+	}
+	if remote, err := clone.Remote("origin"); err != nil || remote.Config().URLs[0] != newURL {
+		t.Errorf("Expected the moved clone to fetch from the new URL, got %v (err: %v)", remote, err)
+	}
+
+	// Syncs keep working from the new location, merging against the same bases
+	project.WriteFile("lib/a.go", project.ReadFile("lib/a.go")+"\n// Local note\n")
+	if output := mustRunCLI(t, "sync", "library"); strings.Contains(output, "reset its origin") || strings.Contains(output, "Cloning") {
+		t.Errorf("Expected the sync to use the moved clone as it is, got:\n%s", output)
+	}
+
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshots: %v", err)
+	}
+	if !baseManager.HasSnapshot("library", "lib/") {
+		t.Fatal("Expected the sync to leave a base snapshot")
+	}
+
+	mustRunCLI(t, "update", "repo", "library", "--rename", "company-lib")
+	if _, exists := loadProjectConfig(t, project).GetSource("library"); exists {
+		t.Error("Expected the old name to be gone")
+	}
+	if source := requireSource(t, project, "company-lib"); !reflect.DeepEqual(source.Paths, synced.Paths) || source.Repository != newURL {
+		t.Errorf("Expected the renamed source to keep its repository and paths, got %+v", source)
+	}
+	if baseManager.HasSnapshot("library", "lib/") || !baseManager.HasSnapshot("company-lib", "lib/") {
+		t.Error("Expected the base snapshots to follow the rename")
+	}
+
+	configureSources(t, project, config.Source{Name: "other", Repository: newURL})
+	if result := runCLI(t, "update", "repo", "company-lib", "--rename", "other"); result.ExitCode == 0 || !strings.Contains(result.Output, "already exists") {
+		t.Errorf("Expected a rename onto another source to be refused, got:\n%s", result)
+	}
+	if result := runCLI(t, "update", "repo", "company-lib"); result.ExitCode == 0 || !strings.Contains(result.Output, "nothing to update") {
+		t.Errorf("Expected an update without flags to be refused, got:\n%s", result)
+	}
+	if result := runCLI(t, "update", "repo", "missing", "--refresh"); result.ExitCode == 0 || !strings.Contains(result.Output, "not found") {
+		t.Errorf("Expected an unknown source to be refused, got:\n%s", result)
+	}
+}
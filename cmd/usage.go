@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/format"
+	"cherry-go/internal/git"
+	"cherry-go/internal/logger"
+)
+
+var usageTop int
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage [source-name...]",
+	Short: "Show how much disk space the managed paths take",
+	Long: `Count the files and bytes each tracked path takes in the project, per path,
+per source and in total, followed by the largest paths. Only the files a sync
+manages are counted: directories are walked with their excludes, as they are
+hashed, so local files excluded from a path don't count. Nothing is fetched.
+
+With --output json the report goes to stdout as a document with the total
+"files" and "bytes" and the "sources", each with its "paths", for dashboards.
+
+Examples:
+  cherry-go usage
+  cherry-go usage mylib --top 5
+  cherry-go usage --output json | jq '.bytes'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if usageTop < 0 {
+			logger.Fatal("--top must be 0 (every path) or more")
+		}
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+		enterProjectRoot()
+
+		report := usageReport{Sources: make([]usageSource, 0, len(sources))}
+		for i := range sources {
+			paths, err := git.MeasureUsage(&sources[i], cfg.Options)
+			if err != nil {
+				logger.Fatal("Failed to measure %s: %v", sources[i].Name, err)
+			}
+			report.add(sources[i].Name, paths)
+		}
+
+		if jsonOutput() {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				logger.Fatal("Failed to write the usage report: %v", err)
+			}
+			return
+		}
+		if err := renderUsage(cmd.OutOrStdout(), report, usageTop); err != nil {
+			logger.Fatal("%v", err)
+		}
+	},
+}
+
+// usageReport is the document `usage --output json` prints. Its field names are a stable interface.
+type usageReport struct {
+	Files   int           `json:"files"`
+	Bytes   int64         `json:"bytes"`
+	Sources []usageSource `json:"sources"`
+}
+
+// usageSource is a source's disk usage, its paths in config order
+type usageSource struct {
+	Name  string      `json:"name"`
+	Files int         `json:"files"`
+	Bytes int64       `json:"bytes"`
+	Paths []usagePath `json:"paths"`
+}
+
+// usagePath is the disk usage of one tracked path
+type usagePath struct {
+	Include   string `json:"include"`
+	LocalPath string `json:"local_path"`
+	Files     int    `json:"files"`
+	Bytes     int64  `json:"bytes"`
+	Missing   bool   `json:"missing"` // Nothing at the local path yet
+}
+
+// add records the measured paths of a source and adds them to the totals
+func (r *usageReport) add(name string, paths []git.PathUsage) {
+	source := usageSource{Name: name, Paths: make([]usagePath, 0, len(paths))}
+	for _, path := range paths {
+		source.Paths = append(source.Paths, usagePath{
+			Include:   path.Include,
+			LocalPath: path.LocalPath,
+			Files:     path.Files,
+			Bytes:     path.Bytes,
+			Missing:   path.Missing,
+		})
+		source.Files += path.Files
+		source.Bytes += path.Bytes
+	}
+	r.Sources = append(r.Sources, source)
+	r.Files += source.Files
+	r.Bytes += source.Bytes
+}
+
+// renderUsage writes the report as a table of every path, the totals, and the top
+// largest paths (all of them when top is 0)
+func renderUsage(w io.Writer, report usageReport, top int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tINCLUDE\tLOCAL PATH\tFILES\tSIZE")
+	type rankedPath struct {
+		source string
+		path   usagePath
+	}
+	var ranked []rankedPath
+	for _, source := range report.Sources {
+		for _, path := range source.Paths {
+			size := format.Bytes(path.Bytes)
+			if path.Missing {
+				size = "(missing)"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", source.Name, path.Include, path.LocalPath, format.Count(path.Files), size)
+			ranked = append(ranked, rankedPath{source: source.Name, path: path})
+		}
+		if len(source.Paths) > 1 {
+			fmt.Fprintf(tw, "%s\t(total)\t\t%s\t%s\n", source.Name, format.Count(source.Files), format.Bytes(source.Bytes))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nTotal: %s file(s), %s in %d source(s)\n", format.Count(report.Files), format.Bytes(report.Bytes), len(report.Sources))
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].path.Bytes > ranked[j].path.Bytes })
+	if top > 0 && len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	if len(ranked) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nLargest paths:\n")
+	for i, entry := range ranked {
+		share := 0.0
+		if report.Bytes > 0 {
+			share = float64(entry.path.Bytes) * 100 / float64(report.Bytes)
+		}
+		fmt.Fprintf(w, "  %d. %s %s: %s, %s file(s) (%.1f%%)\n", i+1, entry.source, entry.path.Include,
+			format.Bytes(entry.path.Bytes), format.Count(entry.path.Files), share)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().IntVar(&usageTop, "top", 10, "how many of the largest paths to list (0 for all)")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+)
+
+func TestE2E_Usage(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project,
+		config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+			{Include: "lib/", Exclude: []string{"*.tmp"}},
+			{Include: "src/main.go", LocalPath: "cmd/main.go"},
+			{Include: "docs/"},
+		}})
+	mustRunCLI(t, "sync", "library", "--force", "--path", "lib/", "--path", "src/main.go")
+
+	// Excluded local files aren't managed, so they don't count
+	project.WriteFile("lib/notes.tmp", strings.Repeat("x", 4096))
+	libBytes := int64(len(project.ReadFile("lib/a.go")) + len(project.ReadFile("lib/b.go")))
+	mainBytes := int64(len(project.ReadFile("cmd/main.go")))
+
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	defer rootCmd.SetOut(nil)
+
+	mustRunCLI(t, "usage", "--output", "json")
+	var report usageReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Expected a JSON report on stdout, got %v:\n%s", err, stdout.String())
+	}
+	if report.Files != 3 || report.Bytes != libBytes+mainBytes {
+		t.Errorf("Expected 3 files and %d bytes in total, got %d files and %d bytes", libBytes+mainBytes, report.Files, report.Bytes)
+	}
+	paths := report.Sources[0].Paths
+	if paths[0].Files != 2 || paths[0].Bytes != libBytes {
+		t.Errorf("Expected lib/ to count a.go and b.go only, got %+v", paths[0])
+	}
+	if paths[1].LocalPath != "cmd/main.go" || paths[1].Files != 1 || paths[1].Bytes != mainBytes {
+		t.Errorf("Expected src/main.go to be measured at its local path, got %+v", paths[1])
+	}
+	if !paths[2].Missing || paths[2].Files != 0 {
+		t.Errorf("Expected docs/ to be reported missing, got %+v", paths[2])
+	}
+
+	stdout.Reset()
+	mustRunCLI(t, "usage", "library", "--top", "1")
+	output := stdout.String()
+	for _, expected := range []string{"lib/", "cmd/main.go", "(missing)", "Total: 3 file(s)", "Largest paths:", "1. library lib/"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the table, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "2. library") {
+		t.Errorf("Expected --top 1 to list one path, got:\n%s", output)
+	}
+
+	if result := runCLI(t, "usage", "unknown"); result.ExitCode == 0 || !strings.Contains(result.Output, "unknown source(s): unknown") {
+		t.Errorf("Expected an unknown source to fail, got:\n%s", result.Output)
+	}
+}
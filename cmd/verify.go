@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/git"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+var (
+	verifyFormat string
+	verifyUpdate bool
+)
+
+// Exit codes of the verify command
+const (
+	verifyExitDrift   = 1 // Some local file no longer matches its tracking hash
+	verifyExitTrouble = 2 // A source's files could not be read
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [source...]",
+	Short: "Check local files against the hashes recorded by the last sync",
+	Long: `Compare the local copies of every tracked path (or only the named sources')
+with the hashes the last sync recorded, and list the files that were modified,
+deleted, or added to a tracked directory since, and those whose permissions
+alone changed from the ones the last sync set. Only the project is read: no
+network, cache or credentials are needed, so verify runs anywhere offline.
+
+The exit code is 0 when every file matches, 1 when something drifted and 2
+when a file could not be read, so CI can gate on local edits to vendored code.
+With --format json the report goes to stdout as a document with "clean" and a
+"sources" list whose paths hold the drifted "files", each with path,
+local_path, status (modified, deleted, added or mode), expected_hash and
+actual_hash, plus expected_mode and actual_mode for a mode change.
+
+--update accepts the local state as the new baseline: modified files are
+tracked with their current hashes, changed permissions are recorded, and
+deleted files lose their entries, then the configuration is saved. Added
+files stay untracked, since sync would otherwise treat them as removed
+upstream. File contents are never changed.
+
+Examples:
+  cherry-go verify
+  cherry-go verify mylib --format json
+  cherry-go verify --update`,
+	Run: func(cmd *cobra.Command, args []string) {
+		formatFromOutput(cmd, &verifyFormat)
+		if verifyFormat != "text" && verifyFormat != "json" {
+			logger.Fatal("Unknown format '%s': use text or json", verifyFormat)
+		}
+		sources, err := selectSources(cfg.Sources, args)
+		if err != nil {
+			logger.Fatal("%v", err)
+		}
+
+		enterProjectRoot()
+
+		report := verifyReport{Clean: true, Sources: make([]verifiedSource, 0, len(sources))}
+		failed := 0
+		for i := range sources {
+			drifts, err := git.VerifyTracking(&sources[i], cfg.Options)
+			if err != nil {
+				logger.Error("✗ %s: %v", sources[i].Name, err)
+				failed++
+				continue
+			}
+			report.add(sources[i].Name, drifts)
+
+			if verifyFormat == "text" {
+				logVerifiedSource(sources[i].Name, drifts)
+			}
+			if verifyUpdate && len(drifts) > 0 {
+				if err := acceptDrift(sources[i].Name, drifts); err != nil {
+					logger.Fatal("%v", err)
+				}
+			}
+		}
+
+		if verifyFormat == "json" {
+			if err := renderVerifyReport(cmd.OutOrStdout(), report); err != nil {
+				logger.Fatal("%v", err)
+			}
+		}
+
+		if failed > 0 {
+			logger.Error("Failed to verify %d source(s)", failed)
+			logger.Exit(verifyExitTrouble)
+		}
+		if !report.Clean && (!verifyUpdate || logger.IsDryRun()) {
+			logger.Exit(verifyExitDrift)
+		}
+	},
+}
+
+// verifyReport is the document `verify --format json` prints. Its field names are a stable interface.
+type verifyReport struct {
+	Clean   bool             `json:"clean"`
+	Sources []verifiedSource `json:"sources"`
+}
+
+// verifiedSource is a verified source and its drifted paths
+type verifiedSource struct {
+	Name  string         `json:"name"`
+	Paths []verifiedPath `json:"paths"`
+}
+
+// verifiedPath is a tracked path whose local files drifted
+type verifiedPath struct {
+	Include   string         `json:"include"`
+	LocalPath string         `json:"local_path"`
+	Files     []verifiedFile `json:"files"`
+}
+
+// verifiedFile is a local file that doesn't match its tracking hash
+type verifiedFile struct {
+	Path         string `json:"path"`       // Key in the path's tracking hashes
+	LocalPath    string `json:"local_path"` // Relative to the project root
+	Status       string `json:"status"`     // modified, deleted, added or mode
+	ExpectedHash string `json:"expected_hash"`
+	ActualHash   string `json:"actual_hash"`
+	ExpectedMode string `json:"expected_mode,omitempty"` // Permissions the last sync set, for a mode change
+	ActualMode   string `json:"actual_mode,omitempty"`
+}
+
+// add records a source's drifted paths. Lists are never nil, so JSON always has arrays
+// where scripts expect them.
+func (r *verifyReport) add(name string, drifts []git.PathDrift) {
+	source := verifiedSource{Name: name, Paths: make([]verifiedPath, 0, len(drifts))}
+	for _, drift := range drifts {
+		path := verifiedPath{Include: drift.Include, LocalPath: drift.LocalRoot, Files: make([]verifiedFile, 0, len(drift.Files))}
+		for _, file := range drift.Files {
+			path.Files = append(path.Files, verifiedFile{
+				Path:         file.Path,
+				LocalPath:    file.LocalPath,
+				Status:       string(file.Type),
+				ExpectedHash: file.ExpectedHash,
+				ActualHash:   file.ActualHash,
+				ExpectedMode: file.ExpectedMode,
+				ActualMode:   file.ActualMode,
+			})
+		}
+		source.Paths = append(source.Paths, path)
+		r.Clean = false
+	}
+	r.Sources = append(r.Sources, source)
+}
+
+// renderVerifyReport writes the report as indented JSON
+func renderVerifyReport(w io.Writer, report verifyReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// logVerifiedSource logs a source's drifted files, grouped by path
+func logVerifiedSource(name string, drifts []git.PathDrift) {
+	if len(drifts) == 0 {
+		logger.Info("✓ %s matches its tracking data", name)
+		return
+	}
+
+	files := 0
+	for _, drift := range drifts {
+		files += len(drift.Files)
+	}
+	logger.Warning("⚠️  %s: %d file(s) drifted from the last sync", name, files)
+	for _, drift := range drifts {
+		logger.Info("  %s -> %s", drift.Include, drift.LocalRoot)
+		for _, file := range drift.Files {
+			if file.Type == hash.ConflictTypeMode {
+				logger.Info("    %-8s %s (%s, was %s)", file.Type, utils.DisplayPath(file.LocalPath), file.ActualMode, file.ExpectedMode)
+				continue
+			}
+			logger.Info("    %-8s %s", file.Type, utils.DisplayPath(file.LocalPath))
+		}
+	}
+}
+
+// acceptDrift records a source's drifted files as its tracked state and saves the configuration
+func acceptDrift(name string, drifts []git.PathDrift) error {
+	added := 0
+	for _, drift := range drifts {
+		for _, file := range drift.Files {
+			if file.Type == hash.ConflictTypeAdded {
+				added++
+			}
+		}
+	}
+
+	var source *config.Source
+	for i := range cfg.Sources {
+		if cfg.Sources[i].Name == name {
+			source = &cfg.Sources[i]
+		}
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would record the local state of %s as its tracked state", name)
+		return nil
+	}
+	if changed := git.AcceptDrift(source, drifts); changed > 0 {
+		if err := saveConfig(); err != nil {
+			return fmt.Errorf("failed to save configuration: %w", err)
+		}
+		logger.Info("✓ Updated %d tracking entries of %s", changed, name)
+	}
+	if added > 0 {
+		logger.Info("  %d added file(s) of %s left untracked", added, name)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "text", "output format: text or json")
+	verifyCmd.Flags().BoolVar(&verifyUpdate, "update", false, "record the local files as the tracked state")
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+)
+
+func TestE2E_Verify(t *testing.T) {
+	upstream := newLibraryFixture(t)
+	project := newCLIProject(t)
+	configureSources(t, project, config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "lib/"},
+			{Include: "src/main.go", LocalPath: "cmd/main.go"},
+		},
+	})
+	mustRunCLI(t, "sync", "library", "--force")
+
+	output := mustRunCLI(t, "verify")
+	if !strings.Contains(output, "library matches its tracking data") {
+		t.Errorf("Expected a clean verify right after the sync, got:\n%s", output)
+	}
+
+	// Verify never needs the upstream repository or the cache
+	if err := os.RemoveAll(strings.TrimPrefix(upstream.URL(), "file://")); err != nil {
+		t.Fatalf("Failed to remove upstream: %v", err)
+	}
+	if err := os.RemoveAll(project.CacheDir()); err != nil {
+		t.Fatalf("Failed to remove cache: %v", err)
+	}
+
+	project.WriteFile("lib/a.go", "package lib // edited\n")
+	project.WriteFile("lib/extra.go", "package lib // mine\n")
+	if err := os.Remove(project.Path("cmd/main.go")); err != nil {
+		t.Fatalf("Failed to remove cmd/main.go: %v", err)
+	}
+
+	result := runCLI(t, "verify", "library")
+	if result.ExitCode != verifyExitDrift {
+		t.Fatalf("Expected exit code %d for drift, got %d:\n%s", verifyExitDrift, result.ExitCode, result.Output)
+	}
+	for _, expected := range []string{"3 file(s) drifted", "lib/a.go", "lib/extra.go", "cmd/main.go"} {
+		if !strings.Contains(result.Output, expected) {
+			t.Errorf("Expected %q in the output:\n%s", expected, result.Output)
+		}
+	}
+
+	result = runCLI(t, "verify", "--format", "json")
+	if result.ExitCode != verifyExitDrift {
+		t.Fatalf("Expected exit code %d for drift, got %d:\n%s", verifyExitDrift, result.ExitCode, result.Output)
+	}
+	var report verifyReport
+	if err := json.Unmarshal([]byte(result.Output), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %v:\n%s", err, result.Output)
+	}
+	statuses := make(map[string]string)
+	for _, path := range report.Sources[0].Paths {
+		for _, file := range path.Files {
+			statuses[file.LocalPath] = file.Status
+		}
+	}
+	expected := map[string]string{"lib/a.go": "modified", "lib/extra.go": "added", "cmd/main.go": "deleted"}
+	if report.Clean || len(statuses) != len(expected) {
+		t.Fatalf("Expected drift %v, got %+v", expected, report)
+	}
+	for path, status := range expected {
+		if statuses[path] != status {
+			t.Errorf("Expected %s to be %s, got %q", path, status, statuses[path])
+		}
+	}
+
+	mustRunCLI(t, "verify", "--update")
+	source := requireSource(t, project, "library")
+	if _, ok := source.Paths[1].Files["main.go"]; ok {
+		t.Error("Expected the entry of the deleted cmd/main.go to be removed")
+	}
+	if _, ok := source.Paths[0].Files["extra.go"]; ok {
+		t.Error("Expected the added lib/extra.go to stay untracked")
+	}
+
+	// Only the untracked addition is left
+	result = runCLI(t, "verify", "--format", "json")
+	if err := json.Unmarshal([]byte(result.Output), &report); err != nil {
+		t.Fatalf("Expected a JSON report, got %v:\n%s", err, result.Output)
+	}
+	if files := report.Sources[0].Paths[0].Files; len(report.Sources[0].Paths) != 1 || len(files) != 1 || files[0].Status != "added" {
+		t.Errorf("Expected only lib/extra.go to be reported after --update, got %+v", report)
+	}
+}
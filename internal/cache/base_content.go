@@ -2,18 +2,49 @@ package cache
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"cherry-go/internal/config"
 )
 
-// BaseContentManager handles snapshots of synced content for three-way merge
+// objectsDir is the directory under the base directory holding snapshot blobs
+const objectsDir = "objects"
+
+// blobGracePeriod protects blobs written or reused recently from garbage collection, so a
+// snapshot being saved while the collector runs never loses a blob before its manifest lands
+var blobGracePeriod = time.Hour
+
+// snapshotName matches the hashed path spec that names a snapshot manifest or legacy directory
+var snapshotName = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// BaseContentManager handles snapshots of synced content for three-way merge.
+//
+// File contents are stored once, as blobs named by their sha256 under objects/; a snapshot
+// is a manifest mapping each relative path to its blob. Identical files across sources,
+// paths and syncs share a blob, and saving a snapshot of unchanged files writes no content.
+// Blobs no manifest references are removed by GarbageCollect.
 type BaseContentManager struct {
 	baseDir string
 }
 
+// snapshotManifest is a saved snapshot: the blob holding each file's content
+type snapshotManifest struct {
+	Files map[string]string `json:"files"` // Relative path -> sha256 of the content
+}
+
 // NewBaseContentManager creates a new base content manager
 func NewBaseContentManager() (*BaseContentManager, error) {
+	if disabled {
+		return nil, ErrDisabled
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
@@ -37,79 +68,221 @@ func (m *BaseContentManager) GetBaseDir() string {
 	return m.baseDir
 }
 
-// getSnapshotPath returns the path for a specific source/path snapshot
+// getSnapshotPath returns the manifest path for a specific source/path snapshot. Snapshots
+// are keyed by the canonical path, so "src" and "src/" share one.
 func (m *BaseContentManager) getSnapshotPath(sourceName, pathSpec string) string {
-	// Hash the pathSpec to create a safe directory name
+	return m.hashedSnapshotPath(sourceName, config.PathKey(pathSpec)) + ".json"
+}
+
+// hashedSnapshotPath hashes a path spec into a safe name. Older versions stored a snapshot
+// as a directory of files at this path; manifests add a .json extension.
+func (m *BaseContentManager) hashedSnapshotPath(sourceName, pathSpec string) string {
 	pathHash := fmt.Sprintf("%x", sha256.Sum256([]byte(pathSpec)))[:16]
 	return filepath.Join(m.baseDir, sourceName, pathHash)
 }
 
-// SaveSnapshot saves the content of files after a successful sync
-func (m *BaseContentManager) SaveSnapshot(sourceName, pathSpec string, files map[string][]byte) error {
-	snapshotPath := m.getSnapshotPath(sourceName, pathSpec)
+// blobPath returns where the blob with the given sha256 is stored, fanned out like git objects
+func (m *BaseContentManager) blobPath(sum string) string {
+	return filepath.Join(m.baseDir, objectsDir, sum[:2], sum[2:])
+}
 
-	// Remove existing snapshot if any
-	if err := os.RemoveAll(snapshotPath); err != nil {
-		return fmt.Errorf("failed to remove existing snapshot: %w", err)
+// loadManifest reads the snapshot of a source/path, or returns nil if there is none.
+// Snapshots left by older versions, as directories or under another spelling of the
+// path, are migrated to a canonical manifest first.
+func (m *BaseContentManager) loadManifest(sourceName, pathSpec string) (*snapshotManifest, error) {
+	if err := m.migrateLegacySnapshot(sourceName, pathSpec); err != nil {
+		return nil, err
 	}
 
-	// Create snapshot directory
-	if err := os.MkdirAll(snapshotPath, 0755); err != nil {
-		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	data, err := os.ReadFile(m.getSnapshotPath(sourceName, pathSpec))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
 	}
 
-	// Save each file
-	for relPath, content := range files {
-		filePath := filepath.Join(snapshotPath, relPath)
+	var manifest snapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot manifest %s: %w", m.getSnapshotPath(sourceName, pathSpec), err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]string)
+	}
+	return &manifest, nil
+}
 
-		// Ensure parent directory exists
-		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", relPath, err)
+// migrateLegacySnapshot converts a snapshot written by an older version into a canonical
+// manifest: a directory snapshot has its files moved into blobs, and one saved under
+// another spelling of the path ("src/" for "src") is renamed. Nothing happens once a
+// canonical manifest exists.
+func (m *BaseContentManager) migrateLegacySnapshot(sourceName, pathSpec string) error {
+	manifestPath := m.getSnapshotPath(sourceName, pathSpec)
+	if _, err := os.Stat(manifestPath); err == nil {
+		return nil
+	}
+
+	key := config.PathKey(pathSpec)
+	for _, spelling := range []string{key, pathSpec, key + "/"} {
+		legacyPath := m.hashedSnapshotPath(sourceName, spelling)
+
+		if spelling != key {
+			if _, err := os.Stat(legacyPath + ".json"); err == nil {
+				if err := os.Rename(legacyPath+".json", manifestPath); err != nil {
+					return fmt.Errorf("failed to migrate snapshot of %s: %w", pathSpec, err)
+				}
+				return nil
+			}
 		}
 
-		if err := os.WriteFile(filePath, content, 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", relPath, err)
+		if info, err := os.Stat(legacyPath); err == nil && info.IsDir() {
+			files, err := readSnapshotDirectory(legacyPath)
+			if err != nil {
+				return fmt.Errorf("failed to migrate snapshot of %s: %w", pathSpec, err)
+			}
+			if err := m.SaveSnapshot(sourceName, pathSpec, files); err != nil {
+				return fmt.Errorf("failed to migrate snapshot of %s: %w", pathSpec, err)
+			}
+			return os.RemoveAll(legacyPath)
 		}
 	}
-
 	return nil
 }
 
-// GetSnapshot retrieves the base content for three-way merge
-func (m *BaseContentManager) GetSnapshot(sourceName, pathSpec string) (map[string][]byte, error) {
-	snapshotPath := m.getSnapshotPath(sourceName, pathSpec)
-
-	if _, err := os.Stat(snapshotPath); os.IsNotExist(err) {
-		return nil, nil // No snapshot exists
-	}
-
+// readSnapshotDirectory reads a legacy directory snapshot into memory
+func readSnapshotDirectory(dir string) (map[string][]byte, error) {
 	files := make(map[string][]byte)
-
-	err := filepath.Walk(snapshotPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-
 		if info.IsDir() {
 			return nil
 		}
 
-		relPath, err := filepath.Rel(snapshotPath, path)
+		relPath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return err
 		}
-
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read file %s: %w", path, err)
 		}
-
 		files[relPath] = content
 		return nil
 	})
+	return files, err
+}
 
+// SaveSnapshot saves the content of files after a successful sync
+func (m *BaseContentManager) SaveSnapshot(sourceName, pathSpec string, files map[string][]byte) error {
+	manifest := snapshotManifest{Files: make(map[string]string, len(files))}
+	for relPath, content := range files {
+		sum, err := m.writeBlob(content)
+		if err != nil {
+			return fmt.Errorf("failed to write file %s: %w", relPath, err)
+		}
+		manifest.Files[relPath] = sum
+	}
+
+	if err := m.writeManifest(sourceName, pathSpec, manifest); err != nil {
+		return err
+	}
+	return m.recordSnapshotProject(sourceName)
+}
+
+// writeBlob stores content under its sha256 and returns the hash. Content already stored
+// isn't written again; its blob is touched so garbage collection treats it as recent.
+func (m *BaseContentManager) writeBlob(content []byte) (string, error) {
+	digest := sha256.Sum256(content)
+	sum := hex.EncodeToString(digest[:])
+	path := m.blobPath(sum)
+
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		_ = os.Chtimes(path, now, now) // Only shortens the blob's grace period if it fails
+		return sum, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, content); err != nil {
+		return "", err
+	}
+	return sum, nil
+}
+
+// writeManifest replaces the snapshot of a source/path, dropping any legacy directory
+// snapshot stored where the manifest's name would point
+func (m *BaseContentManager) writeManifest(sourceName, pathSpec string, manifest snapshotManifest) error {
+	manifestPath := m.getSnapshotPath(sourceName, pathSpec)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		return fmt.Errorf("failed to encode snapshot manifest: %w", err)
+	}
+	if err := writeFileAtomic(manifestPath, data); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+
+	if err := os.RemoveAll(strings.TrimSuffix(manifestPath, ".json")); err != nil {
+		return fmt.Errorf("failed to remove existing snapshot: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file through a temporary file and a rename, so readers never
+// see it half-written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// readBlob returns the content stored under a sha256
+func (m *BaseContentManager) readBlob(sum string) ([]byte, error) {
+	if len(sum) < 3 {
+		return nil, fmt.Errorf("invalid blob name %q", sum)
+	}
+	content, err := os.ReadFile(m.blobPath(sum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob %s: %w", sum, err)
+	}
+	return content, nil
+}
+
+// GetSnapshot retrieves the base content for three-way merge
+func (m *BaseContentManager) GetSnapshot(sourceName, pathSpec string) (map[string][]byte, error) {
+	manifest, err := m.loadManifest(sourceName, pathSpec)
+	if err != nil || manifest == nil {
+		return nil, err // No snapshot exists, or it can't be read
+	}
+
+	files := make(map[string][]byte, len(manifest.Files))
+	for relPath, sum := range manifest.Files {
+		content, err := m.readBlob(sum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		files[relPath] = content
 	}
 
 	return files, nil
@@ -118,40 +291,136 @@ func (m *BaseContentManager) GetSnapshot(sourceName, pathSpec string) (map[strin
 // GetFileContent retrieves a single file from the snapshot
 // Note: Used primarily for testing and debugging
 func (m *BaseContentManager) GetFileContent(sourceName, pathSpec, relPath string) ([]byte, error) {
-	snapshotPath := m.getSnapshotPath(sourceName, pathSpec)
-	filePath := filepath.Join(snapshotPath, relPath)
-
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, nil // File doesn't exist in snapshot
+	manifest, err := m.loadManifest(sourceName, pathSpec)
+	if err != nil || manifest == nil {
+		return nil, err
 	}
 
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	sum, exists := manifest.Files[relPath]
+	if !exists {
+		return nil, nil // File doesn't exist in snapshot
 	}
 
-	return content, nil
+	return m.readBlob(sum)
 }
 
 // HasSnapshot checks if a snapshot exists for the given source/path
 func (m *BaseContentManager) HasSnapshot(sourceName, pathSpec string) bool {
-	snapshotPath := m.getSnapshotPath(sourceName, pathSpec)
-	_, err := os.Stat(snapshotPath)
-	return err == nil
+	manifest, err := m.loadManifest(sourceName, pathSpec)
+	return err == nil && manifest != nil
 }
 
-// DeleteSnapshot removes a snapshot for a source/path
+// RenameSnapshotFile moves a file inside a snapshot so its base content follows an upstream rename.
+// A missing snapshot file is not an error: there is simply no base to carry over.
+func (m *BaseContentManager) RenameSnapshotFile(sourceName, pathSpec, oldRelPath, newRelPath string) error {
+	manifest, err := m.loadManifest(sourceName, pathSpec)
+	if err != nil || manifest == nil {
+		return err
+	}
+
+	sum, exists := manifest.Files[oldRelPath]
+	if !exists {
+		return nil
+	}
+
+	delete(manifest.Files, oldRelPath)
+	manifest.Files[newRelPath] = sum
+	if err := m.writeManifest(sourceName, pathSpec, *manifest); err != nil {
+		return fmt.Errorf("failed to move snapshot file %s to %s: %w", oldRelPath, newRelPath, err)
+	}
+
+	return nil
+}
+
+// DeleteSnapshot removes a snapshot for a source/path. Its blobs stay until GarbageCollect.
 // Note: Used primarily for testing and cleanup operations
 func (m *BaseContentManager) DeleteSnapshot(sourceName, pathSpec string) error {
-	snapshotPath := m.getSnapshotPath(sourceName, pathSpec)
-	return os.RemoveAll(snapshotPath)
+	key := config.PathKey(pathSpec)
+	for _, spelling := range []string{key, pathSpec, key + "/"} {
+		legacyPath := m.hashedSnapshotPath(sourceName, spelling)
+		if err := os.RemoveAll(legacyPath + ".json"); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(legacyPath); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// DeleteSourceSnapshots removes all snapshots for a source
+// DeleteSourceSnapshots removes all snapshots for a source, and the projects recorded as
+// using them
 // Note: Used primarily for cleanup operations when removing a source
 func (m *BaseContentManager) DeleteSourceSnapshots(sourceName string) error {
 	sourcePath := filepath.Join(m.baseDir, sourceName)
-	return os.RemoveAll(sourcePath)
+	entries, err := os.ReadDir(sourcePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots of %s: %w", sourceName, err)
+	}
+
+	// Only snapshots are removed: a source named "objects" shares its directory with the blobs
+	for _, entry := range entries {
+		if !snapshotName.MatchString(strings.TrimSuffix(entry.Name(), ".json")) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(sourcePath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(m.snapshotProjectsPath(sourceName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	_ = os.Remove(sourcePath) // Only succeeds once nothing else is left
+	return nil
+}
+
+// RenameSourceSnapshots moves all snapshots of a source to a new source name, for a
+// renamed source whose merges must keep their bases. Snapshots the new name already has
+// are never overwritten: the rename stops with an error before moving anything.
+func (m *BaseContentManager) RenameSourceSnapshots(oldName, newName string) error {
+	oldPath, newPath := filepath.Join(m.baseDir, oldName), filepath.Join(m.baseDir, newName)
+	entries, err := os.ReadDir(oldPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots of %s: %w", oldName, err)
+	}
+
+	// Entries are moved one by one, as a source named "objects" shares its directory with the blobs
+	var snapshots []string
+	for _, entry := range entries {
+		if !snapshotName.MatchString(strings.TrimSuffix(entry.Name(), ".json")) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(newPath, entry.Name())); err == nil {
+			return fmt.Errorf("source '%s' already has base-content snapshots", newName)
+		}
+		snapshots = append(snapshots, entry.Name())
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(newPath, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory for %s: %w", newName, err)
+	}
+	for _, name := range snapshots {
+		if err := os.Rename(filepath.Join(oldPath, name), filepath.Join(newPath, name)); err != nil {
+			return fmt.Errorf("failed to move snapshots of %s to %s: %w", oldName, newName, err)
+		}
+	}
+	if err := m.recordSnapshotProject(newName); err != nil {
+		return err
+	}
+
+	_ = os.Remove(m.snapshotProjectsPath(oldName)) // No snapshot is left for its projects
+	_ = os.Remove(oldPath)                         // Only succeeds once nothing else is left
+	return nil
 }
 
 // CleanOrphanedSnapshots removes snapshots for sources that no longer exist
@@ -176,8 +445,7 @@ func (m *BaseContentManager) CleanOrphanedSnapshots(validSources []string) error
 		}
 
 		if !validSet[entry.Name()] {
-			sourcePath := filepath.Join(m.baseDir, entry.Name())
-			if err := os.RemoveAll(sourcePath); err != nil {
+			if err := m.DeleteSourceSnapshots(entry.Name()); err != nil {
 				return fmt.Errorf("failed to remove orphaned snapshot %s: %w", entry.Name(), err)
 			}
 		}
@@ -185,3 +453,88 @@ func (m *BaseContentManager) CleanOrphanedSnapshots(validSources []string) error
 
 	return nil
 }
+
+// GarbageCollect removes blobs no snapshot references and returns how many were removed
+// and the bytes freed. Blobs younger than the grace period are kept, as a snapshot being
+// saved may not have written its manifest yet. A manifest that can't be read stops the
+// collection, since its blobs can't be told apart from unreferenced ones.
+func (m *BaseContentManager) GarbageCollect() (int, int64, error) {
+	referenced, err := m.referencedBlobs()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	removed, freed := 0, int64(0)
+	cutoff := time.Now().Add(-blobGracePeriod)
+	root := filepath.Join(m.baseDir, objectsDir)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		// Temporary files left by an interrupted save age out like unreferenced blobs
+		sum := filepath.Base(filepath.Dir(path)) + info.Name()
+		if referenced[sum] || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove blob %s: %w", sum, err)
+		}
+		removed++
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return removed, freed, fmt.Errorf("failed to collect snapshot blobs: %w", err)
+	}
+
+	return removed, freed, nil
+}
+
+// referencedBlobs collects the blobs every snapshot manifest points to
+func (m *BaseContentManager) referencedBlobs() (map[string]bool, error) {
+	sources, err := os.ReadDir(m.baseDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base content directory: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	for _, source := range sources {
+		if !source.IsDir() {
+			continue
+		}
+		sourcePath := filepath.Join(m.baseDir, source.Name())
+		entries, err := os.ReadDir(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshots of %s: %w", source.Name(), err)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".json") || !snapshotName.MatchString(strings.TrimSuffix(name, ".json")) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(sourcePath, name))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read snapshot manifest %s: %w", name, err)
+			}
+			var manifest snapshotManifest
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse snapshot manifest %s: %w", filepath.Join(sourcePath, name), err)
+			}
+			for _, sum := range manifest.Files {
+				referenced[sum] = true
+			}
+		}
+	}
+	return referenced, nil
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBaseContentManager_SaveAndGetSnapshot(t *testing.T) {
@@ -300,3 +301,315 @@ func TestBaseContentManager_PathHashing(t *testing.T) {
 		t.Errorf("Snapshot path should be under source directory: %s", path1)
 	}
 }
+
+func TestBaseContentManager_RenameSnapshotFile(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+
+	files := map[string][]byte{
+		"utils/strings.go": []byte("package utils\n"),
+	}
+	if err := manager.SaveSnapshot("source", "pkg/", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	if err := manager.RenameSnapshotFile("source", "pkg/", "utils/strings.go", "strutil/strings.go"); err != nil {
+		t.Fatalf("RenameSnapshotFile failed: %v", err)
+	}
+
+	moved, err := manager.GetFileContent("source", "pkg/", "strutil/strings.go")
+	if err != nil || string(moved) != "package utils\n" {
+		t.Errorf("Expected base content under the new name, got %q (err: %v)", moved, err)
+	}
+
+	old, _ := manager.GetFileContent("source", "pkg/", "utils/strings.go")
+	if old != nil {
+		t.Errorf("Expected old name to be gone, got %q", old)
+	}
+
+	// Renaming a file the snapshot never had is a no-op
+	if err := manager.RenameSnapshotFile("source", "pkg/", "missing.go", "other.go"); err != nil {
+		t.Errorf("Expected no error for missing snapshot file, got %v", err)
+	}
+}
+
+func TestBaseContentManager_CanonicalSnapshotPaths(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+	files := map[string][]byte{"a.go": []byte("package lib\n")}
+
+	// A snapshot saved under either spelling is found under the other
+	if err := manager.SaveSnapshot("lib", "src", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if content, _ := manager.GetFileContent("lib", "src/", "a.go"); string(content) != "package lib\n" {
+		t.Errorf("Expected src/ to find the snapshot saved as src, got %q", content)
+	}
+
+	// Snapshots written by older versions, hashed from the raw spelling, are migrated
+	legacyPath := manager.hashedSnapshotPath("lib", "pkg/")
+	if err := os.MkdirAll(legacyPath, 0755); err != nil {
+		t.Fatalf("Failed to create legacy snapshot: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(legacyPath, "b.go"), []byte("legacy\n"), 0644); err != nil {
+		t.Fatalf("Failed to write legacy snapshot: %v", err)
+	}
+
+	for _, spelling := range []string{"pkg", "pkg/"} {
+		if content, _ := manager.GetFileContent("lib", spelling, "b.go"); string(content) != "legacy\n" {
+			t.Errorf("Expected %s to find the legacy snapshot, got %q", spelling, content)
+		}
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Error("Expected the legacy snapshot to be moved to its canonical location")
+	}
+}
+
+// countBlobs returns how many blobs the store holds
+func countBlobs(t *testing.T, manager *BaseContentManager) int {
+	t.Helper()
+	count := 0
+	err := filepath.Walk(filepath.Join(manager.baseDir, objectsDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			count++
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Failed to walk blobs: %v", err)
+	}
+	return count
+}
+
+func TestBaseContentManager_DeduplicatesContent(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+	proto := []byte("syntax = \"proto3\";\n")
+
+	// The same file under two sources and a second copy within a path share one blob
+	if err := manager.SaveSnapshot("api", "proto/", map[string][]byte{"user.proto": proto, "copy/user.proto": proto}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.SaveSnapshot("gateway", "gen/", map[string][]byte{"user.proto": proto, "main.go": []byte("package main\n")}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if got := countBlobs(t, manager); got != 2 {
+		t.Errorf("Expected 2 blobs for 2 distinct contents, got %d", got)
+	}
+
+	// Saving unchanged content again writes no new blob
+	if err := manager.SaveSnapshot("api", "proto/", map[string][]byte{"user.proto": proto}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if got := countBlobs(t, manager); got != 2 {
+		t.Errorf("Expected a resave to reuse blobs, got %d", got)
+	}
+
+	for _, source := range []struct{ name, path string }{{"api", "proto/"}, {"gateway", "gen/"}} {
+		if content, err := manager.GetFileContent(source.name, source.path, "user.proto"); err != nil || string(content) != string(proto) {
+			t.Errorf("Expected %s to read the shared blob, got %q (err: %v)", source.name, content, err)
+		}
+	}
+}
+
+func TestBaseContentManager_MigratesDirectorySnapshots(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+
+	// A snapshot as older versions stored it: a directory of files
+	legacyPath := manager.hashedSnapshotPath("lib", "src")
+	if err := os.MkdirAll(filepath.Join(legacyPath, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create legacy snapshot: %v", err)
+	}
+	for name, content := range map[string]string{"a.go": "package a\n", filepath.Join("sub", "b.go"): "package b\n"} {
+		if err := os.WriteFile(filepath.Join(legacyPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write legacy snapshot: %v", err)
+		}
+	}
+
+	files, err := manager.GetSnapshot("lib", "src")
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+	if len(files) != 2 || string(files["a.go"]) != "package a\n" || string(files[filepath.Join("sub", "b.go")]) != "package b\n" {
+		t.Errorf("Expected the legacy files to be read, got %v", files)
+	}
+
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Error("Expected the legacy directory to be removed once migrated")
+	}
+	if _, err := os.Stat(manager.getSnapshotPath("lib", "src")); err != nil {
+		t.Errorf("Expected a manifest after migration: %v", err)
+	}
+	if got := countBlobs(t, manager); got != 2 {
+		t.Errorf("Expected the legacy files moved into 2 blobs, got %d", got)
+	}
+}
+
+func TestBaseContentManager_GarbageCollect(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+
+	if err := manager.SaveSnapshot("lib", "src/", map[string][]byte{"kept.go": []byte("kept\n"), "old.go": []byte("old\n")}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.SaveSnapshot("lib", "src/", map[string][]byte{"kept.go": []byte("kept\n")}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.SaveSnapshot("tools", "bin/", map[string][]byte{"deleted.sh": []byte("deleted\n")}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.DeleteSnapshot("tools", "bin/"); err != nil {
+		t.Fatalf("DeleteSnapshot failed: %v", err)
+	}
+
+	// Recent blobs are kept even when unreferenced: a save may still be writing its manifest
+	removed, _, err := manager.GarbageCollect()
+	if err != nil || removed != 0 {
+		t.Fatalf("Expected nothing collected within the grace period, got %d (err: %v)", removed, err)
+	}
+
+	// Age every blob past the grace period
+	past := time.Now().Add(-2 * blobGracePeriod)
+	_ = filepath.Walk(filepath.Join(manager.baseDir, objectsDir), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			return os.Chtimes(path, past, past)
+		}
+		return err
+	})
+
+	removed, freed, err := manager.GarbageCollect()
+	if err != nil {
+		t.Fatalf("GarbageCollect failed: %v", err)
+	}
+	if removed != 2 || freed != int64(len("old\n")+len("deleted\n")) {
+		t.Errorf("Expected the 2 unreferenced blobs collected, got %d (%d bytes)", removed, freed)
+	}
+	if content, err := manager.GetFileContent("lib", "src/", "kept.go"); err != nil || string(content) != "kept\n" {
+		t.Errorf("Expected referenced content to survive, got %q (err: %v)", content, err)
+	}
+
+	// An unreadable manifest stops the collection instead of orphaning its blobs
+	if err := manager.SaveSnapshot("broken", "x/", map[string][]byte{"x.go": []byte("x\n")}); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := os.WriteFile(manager.getSnapshotPath("lib", "src/"), []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt manifest: %v", err)
+	}
+	_ = filepath.Walk(filepath.Join(manager.baseDir, objectsDir), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			return os.Chtimes(path, past, past)
+		}
+		return err
+	})
+	if _, _, err := manager.GarbageCollect(); err == nil {
+		t.Error("Expected a corrupt manifest to stop garbage collection")
+	}
+	if got := countBlobs(t, manager); got != 2 {
+		t.Errorf("Expected no blob removed when collection stops, got %d left", got)
+	}
+}
+
+func TestBaseContentManager_SourceNamedObjects(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+	files := map[string][]byte{"a.go": []byte("package a\n")}
+
+	// A source called "objects" shares its directory with the blobs; removing it keeps them
+	if err := manager.SaveSnapshot("objects", "src/", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.SaveSnapshot("other", "src/", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	if err := manager.DeleteSourceSnapshots("objects"); err != nil {
+		t.Fatalf("DeleteSourceSnapshots failed: %v", err)
+	}
+	if manager.HasSnapshot("objects", "src/") {
+		t.Error("Expected the objects source's snapshot to be deleted")
+	}
+	if content, err := manager.GetFileContent("other", "src/", "a.go"); err != nil || string(content) != "package a\n" {
+		t.Errorf("Expected other snapshots to keep their blobs, got %q (err: %v)", content, err)
+	}
+}
+
+func TestBaseContentManager_RenameSourceSnapshots(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+	files := map[string][]byte{"a.go": []byte("package a\n")}
+
+	for _, path := range []string{"src/", "LICENSE"} {
+		if err := manager.SaveSnapshot("old", path, files); err != nil {
+			t.Fatalf("SaveSnapshot failed: %v", err)
+		}
+	}
+	if err := manager.SaveSnapshot("taken", "src/", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	if err := manager.RenameSourceSnapshots("old", "taken"); err == nil {
+		t.Error("Expected a rename onto existing snapshots to be refused")
+	}
+	if !manager.HasSnapshot("old", "src/") || !manager.HasSnapshot("old", "LICENSE") {
+		t.Fatal("Expected a refused rename to move nothing")
+	}
+
+	if err := manager.RenameSourceSnapshots("old", "new"); err != nil {
+		t.Fatalf("RenameSourceSnapshots failed: %v", err)
+	}
+	if manager.HasSnapshot("old", "src/") {
+		t.Error("Expected the old name to have no snapshots left")
+	}
+	if content, err := manager.GetFileContent("new", "src/", "a.go"); err != nil || string(content) != "package a\n" {
+		t.Errorf("Expected the snapshot under the new name, got %q (err: %v)", content, err)
+	}
+	if !manager.HasSnapshot("new", "LICENSE") {
+		t.Error("Expected every snapshot to move")
+	}
+	if _, err := os.Stat(filepath.Join(manager.baseDir, "old")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old directory to be removed, got %v", err)
+	}
+
+	// A source renamed to or from "objects" leaves the blobs where they are
+	if err := manager.RenameSourceSnapshots("new", "objects"); err != nil {
+		t.Fatalf("RenameSourceSnapshots failed: %v", err)
+	}
+	if err := manager.RenameSourceSnapshots("objects", "last"); err != nil {
+		t.Fatalf("RenameSourceSnapshots failed: %v", err)
+	}
+	if content, err := manager.GetFileContent("last", "src/", "a.go"); err != nil || string(content) != "package a\n" {
+		t.Errorf("Expected the blobs to stay in place, got %q (err: %v)", content, err)
+	}
+	if err := manager.RenameSourceSnapshots("missing", "other"); err != nil {
+		t.Errorf("Expected a source without snapshots to rename trivially, got %v", err)
+	}
+}
+
+func TestBaseContentManager_SnapshotProjects(t *testing.T) {
+	manager := &BaseContentManager{baseDir: t.TempDir()}
+	files := map[string][]byte{"file.go": []byte("content")}
+	t.Cleanup(func() { SetProject("") })
+
+	if manager.HasSourceSnapshots("shared") {
+		t.Error("Expected no snapshots before any is saved")
+	}
+	SetProject("/work/a/.cherry-go.yaml")
+	if err := manager.SaveSnapshot("shared", "lib", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+	SetProject("/work/b/.cherry-go.yaml")
+	if err := manager.SaveSnapshot("shared", "docs", files); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	if !manager.HasSourceSnapshots("shared") {
+		t.Error("Expected the source to have snapshots")
+	}
+	projects, recorded := manager.SnapshotProjects("shared")
+	if !recorded || len(projects) != 2 || projects[0] != "/work/a/.cherry-go.yaml" {
+		t.Errorf("Expected both projects to be recorded, got %v (recorded %v)", projects, recorded)
+	}
+
+	if err := manager.DeleteSourceSnapshots("shared"); err != nil {
+		t.Fatalf("DeleteSourceSnapshots failed: %v", err)
+	}
+	if _, recorded := manager.SnapshotProjects("shared"); recorded || manager.HasSourceSnapshots("shared") {
+		t.Error("Expected the record to go with the snapshots")
+	}
+}
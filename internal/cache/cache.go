@@ -7,6 +7,10 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"cherry-go/internal/format"
 )
 
 // Manager handles the global cache directory for repositories
@@ -16,6 +20,10 @@ type Manager struct {
 
 // NewManager creates a new cache manager
 func NewManager() (*Manager, error) {
+	if disabled {
+		return nil, ErrDisabled
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
@@ -93,6 +101,72 @@ func (m *Manager) RepositoryExists(repoURL string) bool {
 	return err == nil
 }
 
+// RemoveRepository deletes the cached clone of a repository, if present
+func (m *Manager) RemoveRepository(repoURL string) error {
+	repoPath := m.GetRepositoryPath(repoURL)
+	if err := os.RemoveAll(repoPath); err != nil {
+		return fmt.Errorf("failed to remove cached repository %s: %w", repoPath, err)
+	}
+	return nil
+}
+
+// MoveRepository moves the cached clone of oldURL to where newURL is cached, for a
+// repository that moved, and reports whether it did. There is nothing to move without a
+// clone of oldURL, and an existing clone of newURL is never replaced.
+func (m *Manager) MoveRepository(oldURL, newURL string) (bool, error) {
+	if !m.RepositoryExists(oldURL) || m.RepositoryExists(newURL) {
+		return false, nil
+	}
+
+	oldPath, newPath := m.GetRepositoryPath(oldURL), m.GetRepositoryPath(newURL)
+	if err := os.RemoveAll(newPath); err != nil {
+		return false, fmt.Errorf("failed to clear %s: %w", newPath, err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return false, fmt.Errorf("failed to move cached repository %s: %w", oldPath, err)
+	}
+
+	if metadata, err := readMetadata(newPath); err == nil {
+		metadata.URL = newURL
+		if data, err := yaml.Marshal(metadata); err == nil {
+			_ = os.WriteFile(metadataPath(newPath), data, 0644)
+		}
+	}
+	return true, nil
+}
+
+// RemoveEntry deletes a listed cache entry, including ones whose origin URL is unknown
+func (m *Manager) RemoveEntry(repo CachedRepository) error {
+	if filepath.Dir(repo.Path) != m.cacheDir {
+		return fmt.Errorf("refusing to remove %s: not a cache entry", repo.Path)
+	}
+	if err := os.RemoveAll(repo.Path); err != nil {
+		return fmt.Errorf("failed to remove cached repository %s: %w", repo.Name, err)
+	}
+	return nil
+}
+
+// LastUsed returns when the cached repository was last checked out or updated
+func (m *Manager) LastUsed(repoURL string) (time.Time, bool) {
+	repoPath := m.GetRepositoryPath(repoURL)
+
+	if metadata, err := readMetadata(repoPath); err == nil && !metadata.LastUsed.IsZero() {
+		return metadata.LastUsed, true
+	}
+
+	// The index is rewritten on every checkout, so it tracks usage better than the directory
+	for _, candidate := range []string{
+		filepath.Join(repoPath, ".git", "index"),
+		filepath.Join(repoPath, ".git"),
+	} {
+		if info, err := os.Stat(candidate); err == nil {
+			return info.ModTime(), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
 // ListCachedRepositories returns a list of cached repositories
 func (m *Manager) ListCachedRepositories() ([]CachedRepository, error) {
 	entries, err := os.ReadDir(m.cacheDir)
@@ -120,45 +194,95 @@ func (m *Manager) ListCachedRepositories() ([]CachedRepository, error) {
 				continue
 			}
 
-			repos = append(repos, CachedRepository{
+			repo := CachedRepository{
 				Name:         entry.Name(),
 				Path:         repoPath,
 				LastModified: info.ModTime(),
-			})
+			}
+			if metadata, err := readMetadata(repoPath); err == nil {
+				repo.URL = metadata.URL
+				repo.FirstSeen = metadata.FirstSeen
+				repo.LastUsed = metadata.LastUsed
+			}
+			repos = append(repos, repo)
 		}
 	}
 
 	return repos, nil
 }
 
-// CleanCache removes old or unused cached repositories
-func (m *Manager) CleanCache(maxAge int64) error {
+// LastActivity returns when the entry was last used, falling back to when its
+// directory changed for entries cached before metadata existed
+func (cr CachedRepository) LastActivity() time.Time {
+	if !cr.LastUsed.IsZero() {
+		return cr.LastUsed
+	}
+	return cr.LastModified
+}
+
+// UnusedFor reports whether the entry has not been used within maxAge
+func (cr CachedRepository) UnusedFor(maxAge time.Duration) bool {
+	return cr.LastActivity().Before(time.Now().Add(-maxAge))
+}
+
+// ExpiredRepositories returns the cached repositories not used within maxAge
+func (m *Manager) ExpiredRepositories(maxAge time.Duration) ([]CachedRepository, error) {
 	repos, err := m.ListCachedRepositories()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	currentTime := time.Now().Unix()
-
+	var expired []CachedRepository
 	for _, repo := range repos {
-		// Check if repository is older than maxAge days
-		daysSinceModified := repo.LastModified.Unix()
+		if repo.UnusedFor(maxAge) {
+			expired = append(expired, repo)
+		}
+	}
 
-		if (currentTime - daysSinceModified) > (maxAge * 24 * 60 * 60) {
-			if err := os.RemoveAll(repo.Path); err != nil {
-				return fmt.Errorf("failed to remove cached repository %s: %w", repo.Name, err)
-			}
+	return expired, nil
+}
+
+// CleanCache removes the cached repositories not used within maxAge and returns them
+func (m *Manager) CleanCache(maxAge time.Duration) ([]CachedRepository, error) {
+	expired, err := m.ExpiredRepositories(maxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []CachedRepository
+	for _, repo := range expired {
+		if err := m.RemoveEntry(repo); err != nil {
+			return removed, err
 		}
+		removed = append(removed, repo)
 	}
 
-	return nil
+	return removed, nil
 }
 
 // GetCacheSize returns the total size of the cache directory
 func (m *Manager) GetCacheSize() (int64, error) {
+	size, err := dirSize(m.cacheDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return size, err
+}
+
+// RepositorySize returns the on-disk size of a cached repository (0 if not cached)
+func (m *Manager) RepositorySize(repoURL string) int64 {
+	size, err := dirSize(m.GetRepositoryPath(repoURL))
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// dirSize sums the sizes of all files below dir
+func dirSize(dir string) (int64, error) {
 	var size int64
 
-	err := filepath.Walk(m.cacheDir, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -176,9 +300,15 @@ type CachedRepository struct {
 	Name         string
 	Path         string
 	LastModified time.Time
+	URL          string    // Origin URL from the entry's metadata, empty if unknown
+	FirstSeen    time.Time // Zero if the entry has no metadata
+	LastUsed     time.Time // Zero if the entry has no metadata
 }
 
 // String returns a string representation of the cached repository
 func (cr CachedRepository) String() string {
-	return fmt.Sprintf("%s (%s)", cr.Name, cr.LastModified.Format("2006-01-02 15:04:05"))
+	if cr.URL == "" {
+		return fmt.Sprintf("%s (updated %s, origin unknown)", cr.Name, format.Since(cr.LastModified))
+	}
+	return fmt.Sprintf("%s (first seen %s, last used %s)", cr.URL, format.Since(cr.FirstSeen), format.Since(cr.LastUsed))
 }
@@ -0,0 +1,20 @@
+package cache
+
+import "errors"
+
+// ErrDisabled is returned by the managers while the cache is turned off
+var ErrDisabled = errors.New("the cherry-go cache is disabled (--no-cache)")
+
+// disabled keeps NewManager and NewBaseContentManager from touching ~/.cache/cherry-go
+var disabled bool
+
+// SetDisabled turns the repository cache and base-content snapshots off (or back on)
+// for this process
+func SetDisabled(off bool) {
+	disabled = off
+}
+
+// Disabled reports whether the cache has been turned off
+func Disabled() bool {
+	return disabled
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// metadataFileName is stored inside each clone's .git directory, so it never shows up
+// as an untracked file in the cached worktree
+const metadataFileName = "cherry-go-cache.yaml"
+
+// Metadata records where a cache entry came from, when it was used, and by which projects
+type Metadata struct {
+	URL       string    `yaml:"url"`
+	FirstSeen time.Time `yaml:"first_seen"`
+	LastUsed  time.Time `yaml:"last_used"`
+	Projects  []string  `yaml:"projects,omitempty"` // Configuration files of the projects that used the entry
+}
+
+// project is the configuration file of the project running, recorded in the cache entries
+// and snapshots it uses, so cleaning up after one project can tell what others still need
+var project string
+
+// SetProject names the project using the cache from now on, by the absolute path of its
+// configuration file
+func SetProject(configFile string) {
+	project = configFile
+}
+
+// withProject adds the running project, if one is set, to a sorted list of projects
+func withProject(projects []string) ([]string, bool) {
+	if project == "" || slices.Contains(projects, project) {
+		return projects, false
+	}
+	projects = append(slices.Clone(projects), project)
+	sort.Strings(projects)
+	return projects, true
+}
+
+// metadataPath returns the metadata file location for a cache entry
+func metadataPath(repoPath string) string {
+	return filepath.Join(repoPath, ".git", metadataFileName)
+}
+
+// readMetadata loads a cache entry's metadata; entries cached before metadata existed have none
+func readMetadata(repoPath string) (*Metadata, error) {
+	data, err := os.ReadFile(metadataPath(repoPath))
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata Metadata
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse cache metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// RecordUse stamps a cached repository's metadata with the current time, creating it
+// (first seen now) if the entry has none yet
+func (m *Manager) RecordUse(repoURL string) error {
+	repoPath := m.GetRepositoryPath(repoURL)
+	now := time.Now()
+
+	metadata, err := readMetadata(repoPath)
+	if err != nil {
+		metadata = &Metadata{FirstSeen: now}
+	}
+	metadata.URL = repoURL
+	metadata.LastUsed = now
+	metadata.Projects, _ = withProject(metadata.Projects)
+
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache metadata: %w", err)
+	}
+	if err := os.WriteFile(metadataPath(repoPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache metadata: %w", err)
+	}
+	return nil
+}
+
+// RepositoryProjects returns the projects recorded as using a cached repository. recorded
+// is false when the entry predates project records, so any project may be using it.
+func (m *Manager) RepositoryProjects(repoURL string) (projects []string, recorded bool) {
+	metadata, err := readMetadata(m.GetRepositoryPath(repoURL))
+	if err != nil || len(metadata.Projects) == 0 {
+		return nil, false
+	}
+	return metadata.Projects, true
+}
@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestManager_RecordUse(t *testing.T) {
+	manager := &Manager{cacheDir: t.TempDir()}
+	url := "https://github.com/user/repo.git"
+
+	// A cached clone, as far as the manager is concerned
+	repoPath := manager.GetRepositoryPath(url)
+	if err := os.MkdirAll(filepath.Join(repoPath, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create fake clone: %v", err)
+	}
+	legacyPath := filepath.Join(manager.cacheDir, "legacy-entry-12345678")
+	if err := os.MkdirAll(filepath.Join(legacyPath, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create legacy clone: %v", err)
+	}
+
+	if err := manager.RecordUse(url); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	first, err := readMetadata(repoPath)
+	if err != nil {
+		t.Fatalf("Expected metadata to be written: %v", err)
+	}
+	if first.URL != url || first.FirstSeen.IsZero() || !first.LastUsed.Equal(first.FirstSeen) {
+		t.Errorf("Unexpected initial metadata %+v", first)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := manager.RecordUse(url); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	second, _ := readMetadata(repoPath)
+	if !second.FirstSeen.Equal(first.FirstSeen) || !second.LastUsed.After(first.LastUsed) {
+		t.Errorf("Expected only last-used to move, got %+v then %+v", first, second)
+	}
+
+	if lastUsed, ok := manager.LastUsed(url); !ok || !lastUsed.Equal(second.LastUsed) {
+		t.Errorf("Expected LastUsed to come from metadata, got %v", lastUsed)
+	}
+
+	repos, err := manager.ListCachedRepositories()
+	if err != nil {
+		t.Fatalf("ListCachedRepositories failed: %v", err)
+	}
+	byPath := make(map[string]CachedRepository)
+	for _, repo := range repos {
+		byPath[repo.Path] = repo
+	}
+	if got := byPath[repoPath]; got.URL != url || !got.FirstSeen.Equal(first.FirstSeen) {
+		t.Errorf("Expected the listed entry to carry its metadata, got %+v", got)
+	}
+	if got, ok := byPath[legacyPath]; !ok || got.URL != "" {
+		t.Errorf("Expected entries without metadata to be listed with an unknown origin, got %+v", got)
+	}
+}
+
+func TestManager_RemoveEntry(t *testing.T) {
+	manager := &Manager{cacheDir: t.TempDir()}
+	entry := CachedRepository{Name: "entry", Path: filepath.Join(manager.cacheDir, "entry")}
+	if err := os.MkdirAll(filepath.Join(entry.Path, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create fake clone: %v", err)
+	}
+
+	outside := CachedRepository{Name: "outside", Path: t.TempDir()}
+	if err := manager.RemoveEntry(outside); err == nil {
+		t.Error("Expected paths outside the cache to be refused")
+	}
+	if _, err := os.Stat(outside.Path); err != nil {
+		t.Errorf("Expected the outside path to survive: %v", err)
+	}
+
+	if err := manager.RemoveEntry(entry); err != nil {
+		t.Fatalf("RemoveEntry failed: %v", err)
+	}
+	if _, err := os.Stat(entry.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected the entry to be removed, got %v", err)
+	}
+}
+
+func TestManager_CleanCache(t *testing.T) {
+	manager := &Manager{cacheDir: t.TempDir()}
+	recent := "https://github.com/user/recent.git"
+	stale := "https://github.com/user/stale.git"
+	for _, url := range []string{recent, stale} {
+		if err := os.MkdirAll(filepath.Join(manager.GetRepositoryPath(url), ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create fake clone: %v", err)
+		}
+		if err := manager.RecordUse(url); err != nil {
+			t.Fatalf("RecordUse failed: %v", err)
+		}
+	}
+
+	// Last used long ago, though its directory was just created
+	metadata, _ := readMetadata(manager.GetRepositoryPath(stale))
+	metadata.LastUsed = time.Now().Add(-10 * 24 * time.Hour)
+	data, _ := yaml.Marshal(metadata)
+	if err := os.WriteFile(metadataPath(manager.GetRepositoryPath(stale)), data, 0644); err != nil {
+		t.Fatalf("Failed to age metadata: %v", err)
+	}
+
+	removed, err := manager.CleanCache(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("CleanCache failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0].URL != stale {
+		t.Fatalf("Expected only the stale entry to be removed, got %+v", removed)
+	}
+	if manager.RepositoryExists(stale) || !manager.RepositoryExists(recent) {
+		t.Error("Expected the stale clone removed and the recent one kept")
+	}
+
+	if removed, err := manager.CleanCache(0); err != nil || len(removed) != 1 {
+		t.Errorf("Expected a zero max age to remove everything, got %+v (%v)", removed, err)
+	}
+}
+
+func TestManager_MoveRepository(t *testing.T) {
+	manager := &Manager{cacheDir: t.TempDir()}
+	oldURL, newURL := "https://github.com/user/repo.git", "https://gitlab.example.com/team/repo.git"
+
+	if moved, err := manager.MoveRepository(oldURL, newURL); moved || err != nil {
+		t.Errorf("Expected nothing to move without a clone, got %v (err: %v)", moved, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(manager.GetRepositoryPath(oldURL), ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create fake clone: %v", err)
+	}
+	if err := manager.RecordUse(oldURL); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	moved, err := manager.MoveRepository(oldURL, newURL)
+	if !moved || err != nil {
+		t.Fatalf("Expected the clone to move, got %v (err: %v)", moved, err)
+	}
+	if manager.RepositoryExists(oldURL) || !manager.RepositoryExists(newURL) {
+		t.Error("Expected the clone to be cached under the new URL only")
+	}
+	if metadata, err := readMetadata(manager.GetRepositoryPath(newURL)); err != nil || metadata.URL != newURL {
+		t.Errorf("Expected the metadata to name the new URL, got %+v (err: %v)", metadata, err)
+	}
+
+	// An existing clone of the new URL is kept
+	if err := os.MkdirAll(filepath.Join(manager.GetRepositoryPath(oldURL), ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create fake clone: %v", err)
+	}
+	if moved, err := manager.MoveRepository(oldURL, newURL); moved || err != nil {
+		t.Errorf("Expected an existing clone not to be replaced, got %v (err: %v)", moved, err)
+	}
+	if !manager.RepositoryExists(oldURL) {
+		t.Error("Expected the unmoved clone to stay")
+	}
+}
+
+func TestManager_RepositoryProjects(t *testing.T) {
+	manager := &Manager{cacheDir: t.TempDir()}
+	url := "https://github.com/user/repo.git"
+	if err := os.MkdirAll(filepath.Join(manager.GetRepositoryPath(url), ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create fake clone: %v", err)
+	}
+	t.Cleanup(func() { SetProject("") })
+
+	// Used before projects were recorded: any project may be using it
+	if err := manager.RecordUse(url); err != nil {
+		t.Fatalf("RecordUse failed: %v", err)
+	}
+	if _, recorded := manager.RepositoryProjects(url); recorded {
+		t.Error("Expected no project record without a running project")
+	}
+
+	for _, configFile := range []string{"/work/b/.cherry-go.yaml", "/work/a/.cherry-go.yaml", "/work/b/.cherry-go.yaml"} {
+		SetProject(configFile)
+		if err := manager.RecordUse(url); err != nil {
+			t.Fatalf("RecordUse failed: %v", err)
+		}
+	}
+	projects, recorded := manager.RepositoryProjects(url)
+	if !recorded || len(projects) != 2 || projects[0] != "/work/a/.cherry-go.yaml" || projects[1] != "/work/b/.cherry-go.yaml" {
+		t.Errorf("Expected each project once, sorted, got %v (recorded %v)", projects, recorded)
+	}
+}
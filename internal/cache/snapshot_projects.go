@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotProjectsFile lists, next to a source's snapshots, the projects that saved them.
+// Snapshots are keyed by source name alone, so projects with a source of the same name
+// share them.
+const snapshotProjectsFile = "projects.yaml"
+
+// snapshotProjects is the content of a snapshotProjectsFile
+type snapshotProjects struct {
+	Projects []string `yaml:"projects"` // Configuration files of the projects
+}
+
+// snapshotProjectsPath returns the file listing the projects using a source's snapshots
+func (m *BaseContentManager) snapshotProjectsPath(sourceName string) string {
+	return filepath.Join(m.baseDir, sourceName, snapshotProjectsFile)
+}
+
+// SnapshotProjects returns the projects recorded as using a source's snapshots. recorded
+// is false when the snapshots predate project records, so any project may be using them.
+func (m *BaseContentManager) SnapshotProjects(sourceName string) (projects []string, recorded bool) {
+	data, err := os.ReadFile(m.snapshotProjectsPath(sourceName))
+	if err != nil {
+		return nil, false
+	}
+	var record snapshotProjects
+	if err := yaml.Unmarshal(data, &record); err != nil || len(record.Projects) == 0 {
+		return nil, false
+	}
+	return record.Projects, true
+}
+
+// recordSnapshotProject adds the running project to those using a source's snapshots
+func (m *BaseContentManager) recordSnapshotProject(sourceName string) error {
+	existing, _ := m.SnapshotProjects(sourceName)
+	projects, added := withProject(existing)
+	if !added {
+		return nil
+	}
+
+	data, err := yaml.Marshal(snapshotProjects{Projects: projects})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot projects: %w", err)
+	}
+	if err := writeFileAtomic(m.snapshotProjectsPath(sourceName), data); err != nil {
+		return fmt.Errorf("failed to record snapshot projects: %w", err)
+	}
+	return nil
+}
+
+// HasSourceSnapshots reports whether a source has any snapshot
+func (m *BaseContentManager) HasSourceSnapshots(sourceName string) bool {
+	entries, err := os.ReadDir(filepath.Join(m.baseDir, sourceName))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if snapshotName.MatchString(strings.TrimSuffix(entry.Name(), ".json")) {
+			return true
+		}
+	}
+	return false
+}
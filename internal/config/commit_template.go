@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultCommitTemplate is the auto-commit message used unless options.commit_template is set
+const DefaultCommitTemplate = "{{.Prefix}} {{.Source}} from {{.Repository}} ({{.Commits}})"
+
+// CommitMessageData holds the variables options.commit_template can use
+type CommitMessageData struct {
+	Prefix      string    // options.commit_prefix
+	Source      string    // Name of the synced source
+	Repository  string    // URL of the source's repository
+	Commit      string    // Upstream commit of the first updated path
+	ShortCommit string    // Commit shortened to 8 characters
+	Commits     string    // Short commit of each updated path, or the one they share
+	Paths       string    // Updated paths, comma separated
+	Date        time.Time // Author and committer date of the commit, as options.commit_date sets it
+	FileCount   int       // Files the sync added, updated, renamed or deleted
+}
+
+// CommitMessageTemplate returns the configured auto-commit message template or the default
+func (o SyncOptions) CommitMessageTemplate() string {
+	if o.CommitTemplate == "" {
+		return DefaultCommitTemplate
+	}
+	return o.CommitTemplate
+}
+
+// RenderCommitMessage fills the auto-commit message template with data. Surrounding
+// whitespace is trimmed, and a template that renders nothing is an error.
+func (o SyncOptions) RenderCommitMessage(data CommitMessageData) (string, error) {
+	tmpl, err := template.New("commit_template").Parse(o.CommitMessageTemplate())
+	if err != nil {
+		return "", fmt.Errorf("invalid options.commit_template: %w", err)
+	}
+
+	var message bytes.Buffer
+	if err := tmpl.Execute(&message, data); err != nil {
+		return "", fmt.Errorf("invalid options.commit_template: %w", err)
+	}
+	rendered := strings.TrimSpace(message.String())
+	if rendered == "" {
+		return "", fmt.Errorf("invalid options.commit_template: it renders an empty message")
+	}
+	return rendered, nil
+}
+
+// validateCommitTemplate renders the commit template with sample values, so unknown
+// variables and syntax errors fail loading the configuration rather than a commit
+func (o SyncOptions) validateCommitTemplate() error {
+	_, err := o.RenderCommitMessage(CommitMessageData{
+		Prefix:      o.CommitPrefix,
+		Source:      "source",
+		Repository:  "https://example.com/repository.git",
+		Commit:      strings.Repeat("0", 40),
+		ShortCommit: strings.Repeat("0", 8),
+		Commits:     strings.Repeat("0", 8),
+		Paths:       "path/",
+		Date:        time.Unix(0, 0).UTC(),
+		FileCount:   1,
+	})
+	return err
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderCommitMessage(t *testing.T) {
+	data := CommitMessageData{
+		Prefix:      "cherry-go: sync",
+		Source:      "mylib",
+		Repository:  "https://github.com/example/mylib.git",
+		Commit:      "0123456789abcdef0123456789abcdef01234567",
+		ShortCommit: "01234567",
+		Commits:     "01234567",
+		Paths:       "lib/, docs/README.md",
+		Date:        time.Date(2025, 3, 14, 15, 9, 26, 0, time.UTC),
+		FileCount:   3,
+	}
+
+	testCases := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{
+			name:     "default",
+			template: "",
+			expected: "cherry-go: sync mylib from https://github.com/example/mylib.git (01234567)",
+		},
+		{
+			name:     "conventional commit",
+			template: "chore(vendor): update {{.Source}} to {{.ShortCommit}}",
+			expected: "chore(vendor): update mylib to 01234567",
+		},
+		{
+			name:     "body with paths and file count",
+			template: "Sync {{.Source}}\n\n{{.FileCount}} file(s) in {{.Paths}}\nUpstream: {{.Repository}}@{{.Commit}}\n",
+			expected: "Sync mylib\n\n3 file(s) in lib/, docs/README.md\nUpstream: https://github.com/example/mylib.git@0123456789abcdef0123456789abcdef01234567",
+		},
+		{
+			name:     "formatted date",
+			template: `{{.Source}} as of {{.Date.Format "2006-01-02"}}`,
+			expected: "mylib as of 2025-03-14",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := SyncOptions{CommitTemplate: tc.template}
+			got, err := options.RenderCommitMessage(data)
+			if err != nil {
+				t.Fatalf("RenderCommitMessage failed: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoad_CommitTemplate(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name     string
+		template string
+		errorMsg string
+	}{
+		{"default", "", ""},
+		{"valid", "{{.Prefix}} {{.Source}} ({{.FileCount}} files)", ""},
+		{"unknown variable", "sync {{.Branch}}", "can't evaluate field Branch"},
+		{"syntax error", "sync {{.Source", "unclosed action"},
+		{"empty message", "{{/* nothing */}}  ", "empty message"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "-")+".yaml")
+			config := DefaultConfig()
+			config.Options.CommitTemplate = tc.template
+			if err := config.Save(configPath); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+
+			_, err := Load(configPath)
+			if tc.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "options.commit_template") || !strings.Contains(err.Error(), tc.errorMsg) {
+				t.Errorf("Expected an options.commit_template error containing %q, got %v", tc.errorMsg, err)
+			}
+		})
+	}
+}
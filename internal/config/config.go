@@ -2,17 +2,25 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 
 	"gopkg.in/yaml.v3"
+
+	"cherry-go/internal/units"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	Version string      `yaml:"version"`
-	Sources []Source    `yaml:"sources"`
-	Options SyncOptions `yaml:"options,omitempty"`
+	Version     string      `yaml:"version"`
+	GeneratedBy string      `yaml:"generated_by,omitempty"` // cherry-go version that last saved the file, for diagnostics only
+	Sources     []Source    `yaml:"sources"`
+	Options     SyncOptions `yaml:"options,omitempty"`
 }
 
 // Source represents a remote repository source
@@ -21,6 +29,46 @@ type Source struct {
 	Repository string     `yaml:"repository"`
 	Auth       AuthConfig `yaml:"auth,omitempty"`
 	Paths      []PathSpec `yaml:"paths"`
+
+	DefaultExcludes *bool `yaml:"default_excludes,omitempty"` // Overrides options.default_excludes for this source
+
+	Cache string `yaml:"cache,omitempty"` // "shared" (default) or "ephemeral"
+
+	CloneDepth int `yaml:"depth,omitempty"` // Commits of history to clone per branch; 0 (default) clones everything
+
+	Optional bool `yaml:"optional,omitempty"` // Skip with a warning, instead of failing, when it can't be authenticated or cloned
+
+	CACert string `yaml:"ca_cert,omitempty"` // PEM file of extra CAs trusted for an HTTPS repository, relative to the project root
+
+	PostSync []string `yaml:"post_sync,omitempty"` // Shell commands run after a sync updated any of its paths
+}
+
+// Where a source's repository is cloned
+const (
+	CacheShared    = "shared"    // Long-lived clone in the global cache, reused across syncs and projects
+	CacheEphemeral = "ephemeral" // Throwaway clone in a temporary directory, removed after each use
+)
+
+// Shallow reports whether the source is cloned with only the last CloneDepth commits of
+// each branch
+func (s *Source) Shallow() bool {
+	return s.CloneDepth > 0
+}
+
+// Ephemeral reports whether the source is cloned afresh for every use instead of cached
+func (s *Source) Ephemeral() bool {
+	return s.Cache == CacheEphemeral
+}
+
+// Clone returns a copy of the source that shares no paths, tracking maps or hooks with
+// it, so a sync can update the copy while the source is read elsewhere
+func (s Source) Clone() Source {
+	s.Paths = slices.Clone(s.Paths)
+	for i := range s.Paths {
+		s.Paths[i] = s.Paths[i].Clone()
+	}
+	s.PostSync = slices.Clone(s.PostSync)
+	return s
 }
 
 // PathSpec represents a path specification with includes and excludes
@@ -29,14 +77,72 @@ type PathSpec struct {
 	Exclude   []string          `yaml:"exclude,omitempty"`
 	LocalPath string            `yaml:"local_path,omitempty"` // Exact local path where file/dir should be placed
 	Branch    string            `yaml:"branch,omitempty"`     // Branch or tag to track for this specific path
+	Pin       string            `yaml:"pin,omitempty"`        // Tag or commit SHA the path must sync from, overriding branch
+	Commit    string            `yaml:"commit,omitempty"`     // Upstream commit the path was last synced from
+	Link      string            `yaml:"link,omitempty"`       // "copy" (default) or "hardlink" to the cache checkout
+	Mode      string            `yaml:"mode,omitempty"`       // Octal permissions for every synced file, e.g. "0600"
+	Modes     map[string]string `yaml:"modes,omitempty"`      // File inside a directory -> octal permissions, overriding mode
 	Files     map[string]string `yaml:"files,omitempty"`      // filename -> hash mapping
+	FileModes map[string]string `yaml:"file_modes,omitempty"` // filename -> permissions set by the last sync
+	PostSync  []string          `yaml:"post_sync,omitempty"`  // Shell commands run after a sync updated this path
+	Excludes  Excludes          `yaml:"-"`                    // While the path syncs: what leaves its files out (see SyncOptions.PathExcludes)
+}
+
+// How synced files are materialized locally
+const (
+	LinkCopy     = "copy"     // Independent copies of the cache files
+	LinkHardlink = "hardlink" // Hard links to the cache checkout, for large read-only trees
+)
+
+// HardLinked reports whether the path's files are hard-linked to the cache checkout
+func (p PathSpec) HardLinked() bool {
+	return p.Link == LinkHardlink
+}
+
+// Clone returns a copy of the path spec that shares no excludes, modes or tracking maps with it
+func (p PathSpec) Clone() PathSpec {
+	p.Exclude = slices.Clone(p.Exclude)
+	p.Modes = maps.Clone(p.Modes)
+	p.Files = maps.Clone(p.Files)
+	p.FileModes = maps.Clone(p.FileModes)
+	p.PostSync = slices.Clone(p.PostSync)
+	p.Excludes.Patterns = slices.Clone(p.Excludes.Patterns)
+	return p
+}
+
+// commitSHA matches a full commit hash
+var commitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommitSHA reports whether ref is a full 40-character commit hash rather than a branch or tag
+func IsCommitSHA(ref string) bool {
+	return commitSHA.MatchString(ref)
+}
+
+// Pinned reports whether the path tracks one exact commit (pin, or branch: <40-char sha>).
+// Its content never moves until the pin itself is changed.
+func (p PathSpec) Pinned() bool {
+	return p.Pin != "" || IsCommitSHA(p.Branch)
+}
+
+// PinnedRef returns what the path is pinned to (its pin or pinned branch), or ""
+func (p PathSpec) PinnedRef() string {
+	if p.Pin != "" {
+		return p.Pin
+	}
+	if IsCommitSHA(p.Branch) {
+		return p.Branch
+	}
+	return ""
 }
 
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
-	Type     string `yaml:"type,omitempty"`     // "ssh", "basic", "auto"
-	Username string `yaml:"username,omitempty"` // For basic auth only
-	SSHKey   string `yaml:"ssh_key,omitempty"`  // Optional: specific SSH key path
+	Type     string `yaml:"type,omitempty"`      // "ssh", "basic", "auto"
+	Username string `yaml:"username,omitempty"`  // For basic auth only
+	SSHKey   string `yaml:"ssh_key,omitempty"`   // Optional: specific SSH key path
+	TokenEnv string `yaml:"token_env,omitempty"` // Optional: env var whose token is sent to this source's host, whatever it is
+
+	SSHKeyPassphraseEnv string `yaml:"ssh_key_passphrase_env,omitempty"` // Optional: env var holding the SSH key passphrase
 	// Note: Tokens and passwords are NOT stored in config for security
 	// Use environment variables or SSH agent instead
 }
@@ -47,6 +153,229 @@ type SyncOptions struct {
 	CommitPrefix string `yaml:"commit_prefix,omitempty"`
 	CreateBranch bool   `yaml:"create_branch"`
 	BranchPrefix string `yaml:"branch_prefix,omitempty"`
+
+	// Whether --branch-on-conflict creates a branch every time (new) or adds to the
+	// source's unmerged one (reuse)
+	ConflictBranchMode string `yaml:"conflict_branch_mode,omitempty"`
+
+	// Rename detection inside tracked directories (enabled unless set to false)
+	RenameDetection *bool   `yaml:"rename_detection,omitempty"`
+	RenameThreshold float64 `yaml:"rename_threshold,omitempty"` // Minimum similarity (0-1) for modified-and-moved files
+
+	// Skip BuiltinExcludes (OS/editor junk) in tracked directories (enabled unless set to false)
+	DefaultExcludes *bool `yaml:"default_excludes,omitempty"`
+
+	// Local paths (globs) that sync must never write to
+	ProtectedPaths []string `yaml:"protected_paths,omitempty"`
+
+	// What to do about local files in managed directories that upstream doesn't have
+	Untracked string `yaml:"untracked,omitempty"` // "report" (default), "ignore", or "error"
+
+	// Timestamp of auto-commits: "now" (default), "source" or "epoch"
+	CommitDate string `yaml:"commit_date,omitempty"`
+
+	// Go template of auto-commit messages (DefaultCommitTemplate when unset)
+	CommitTemplate string `yaml:"commit_template,omitempty"`
+
+	// Author of cherry-go's commits (git config user.name and user.email when unset)
+	CommitAuthorName  string `yaml:"commit_author_name,omitempty"`
+	CommitAuthorEmail string `yaml:"commit_author_email,omitempty"`
+
+	// Credit cherry-go with a Co-authored-by trailer on commits someone else authors
+	CoAuthorTrailer bool `yaml:"co_author_trailer,omitempty"`
+
+	// Signing of auto-commits: "false" (default), "true", or "optional" to commit unsigned when signing fails
+	SignCommits string `yaml:"sign_commits,omitempty"`
+	SigningKey  string `yaml:"signing_key,omitempty"` // OpenPGP private key file, or the ID of a key to export from gpg
+	UseGitCLI   bool   `yaml:"use_git_cli,omitempty"` // Sign with `git commit -S`, as git's own signing config says
+
+	// Record upstream content after each sync as the base for three-way merges (enabled unless set to false)
+	BaseSnapshots *bool `yaml:"base_snapshots,omitempty"`
+
+	// Treat files that only differ by a final newline as equal (enabled unless set to false)
+	IgnoreTrailingNewline *bool `yaml:"ignore_trailing_newline,omitempty"`
+
+	// Line endings text files are compared and written with: auto, lf, crlf or native
+	// (byte for byte unless set)
+	EOL string `yaml:"eol,omitempty"`
+
+	// Copy what symbolic links inside tracked directories point to instead of the links (disabled unless set to true)
+	FollowSymlinks bool `yaml:"follow_symlinks,omitempty"`
+
+	// Give synced files the permissions upstream has, e.g. keep scripts executable (enabled unless set to false)
+	PreservePermissions *bool `yaml:"preserve_permissions,omitempty"`
+
+	// Hosts besides github.com and gitlab.com that GITHUB_TOKEN and GITLAB_TOKEN are sent to
+	GitHubHosts []string `yaml:"github_hosts,omitempty"`
+	GitLabHosts []string `yaml:"gitlab_hosts,omitempty"`
+
+	// Order sources are saved, listed and synced in: "manual" (default) or "name"
+	SortSources string `yaml:"sort_sources,omitempty"`
+
+	// Most a tracked path's upstream files may add up to before a sync needs --allow-large (no limit when unset)
+	MaxPathSize units.Size `yaml:"max_path_size,omitempty"`
+
+	// Most sources `sync --all` syncs at once (DefaultMaxParallel when unset)
+	MaxParallel int `yaml:"max_parallel,omitempty"`
+
+	// Where --no-cache clones sources, relative to the project root (the root itself when unset)
+	TmpDir string `yaml:"tmp_dir,omitempty"`
+
+	// Proxy URL for HTTP(S) repositories (HTTPS_PROXY and HTTP_PROXY when unset); NO_PROXY still applies
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// Shell commands run after a sync updated any path of a source, after its path and source hooks
+	PostSync []string `yaml:"post_sync,omitempty"`
+
+	// What a failing post_sync command does: "fail" (default) or "warn"
+	OnHookFailure string `yaml:"on_hook_failure,omitempty"`
+}
+
+// Policies for untracked local files inside managed directories
+const (
+	UntrackedReport = "report" // Warn about them and keep syncing
+	UntrackedIgnore = "ignore" // Say nothing
+	UntrackedError  = "error"  // Refuse to sync the directory
+)
+
+// UntrackedPolicy returns the configured untracked-file policy or the default
+func (o SyncOptions) UntrackedPolicy() string {
+	if o.Untracked == "" {
+		return UntrackedReport
+	}
+	return o.Untracked
+}
+
+// Timestamps auto-commits can carry, as author and committer date
+const (
+	CommitDateNow    = "now"    // The time of the sync
+	CommitDateSource = "source" // The committer date of the newest upstream commit synced
+	CommitDateEpoch  = "epoch"  // SOURCE_DATE_EPOCH, for reproducible builds
+)
+
+// CommitDatePolicy returns the configured auto-commit timestamp or the default
+func (o SyncOptions) CommitDatePolicy() string {
+	if o.CommitDate == "" {
+		return CommitDateNow
+	}
+	return o.CommitDate
+}
+
+// Whether auto-commits are signed
+const (
+	SignCommitsOff      = "false"    // Never
+	SignCommitsOn       = "true"     // Always; a key that can't be used fails the commit
+	SignCommitsOptional = "optional" // When possible; otherwise warn and commit unsigned
+)
+
+// SignCommitsPolicy returns the configured commit signing policy or the default
+func (o SyncOptions) SignCommitsPolicy() string {
+	if o.SignCommits == "" {
+		return SignCommitsOff
+	}
+	return o.SignCommits
+}
+
+// What --branch-on-conflict does when the source already has a conflict branch
+const (
+	ConflictBranchNew   = "new"   // Create another timestamped branch
+	ConflictBranchReuse = "reuse" // Commit the latest remote files on the newest one, unless it was merged
+)
+
+// ConflictBranchModePolicy returns the configured conflict branch mode or the default
+func (o SyncOptions) ConflictBranchModePolicy() string {
+	if o.ConflictBranchMode == "" {
+		return ConflictBranchNew
+	}
+	return o.ConflictBranchMode
+}
+
+// What a post_sync command exiting non-zero does to the sync
+const (
+	HookFailureFail = "fail" // Fail the source's sync and skip its remaining hooks
+	HookFailureWarn = "warn" // Warn and run the remaining hooks
+)
+
+// HookFailurePolicy returns the configured post_sync failure policy or the default
+func (o SyncOptions) HookFailurePolicy() string {
+	if o.OnHookFailure == "" {
+		return HookFailureFail
+	}
+	return o.OnHookFailure
+}
+
+// Orders sources can be kept in
+const (
+	SortSourcesManual = "manual" // The order they were added in, or written in the file
+	SortSourcesName   = "name"   // Alphabetical by name
+)
+
+// SortSourcesPolicy returns the configured source order or the default
+func (o SyncOptions) SortSourcesPolicy() string {
+	if o.SortSources == "" {
+		return SortSourcesManual
+	}
+	return o.SortSources
+}
+
+// DefaultRenameThreshold is the similarity needed to pair a removed and an added file
+// whose contents differ, matching git's default of 50%
+const DefaultRenameThreshold = 0.5
+
+// RenameDetectionEnabled reports whether upstream file moves should be followed locally
+func (o SyncOptions) RenameDetectionEnabled() bool {
+	return o.RenameDetection == nil || *o.RenameDetection
+}
+
+// BaseSnapshotsEnabled reports whether base-content snapshots are written and used for merges
+func (o SyncOptions) BaseSnapshotsEnabled() bool {
+	return o.BaseSnapshots == nil || *o.BaseSnapshots
+}
+
+// IgnoreTrailingNewlineEnabled reports whether a missing or added final newline is ignored
+// when comparing, hashing and merging files
+func (o SyncOptions) IgnoreTrailingNewlineEnabled() bool {
+	return o.IgnoreTrailingNewline == nil || *o.IgnoreTrailingNewline
+}
+
+// PreservePermissionsEnabled reports whether synced files take their upstream permissions
+// when no mode is configured for them
+func (o SyncOptions) PreservePermissionsEnabled() bool {
+	return o.PreservePermissions == nil || *o.PreservePermissions
+}
+
+// Line endings synced text files get. Under any of them CRLF and LF compare, hash and
+// merge the same; they differ in what is written.
+const (
+	EOLAuto   = "auto"   // What the project's .gitattributes says, else what the local file already uses
+	EOLLF     = "lf"     // Always LF
+	EOLCRLF   = "crlf"   // Always CRLF
+	EOLNative = "native" // CRLF on Windows, LF elsewhere
+)
+
+// EOLPolicy returns the configured line-ending policy, empty when files are compared
+// and written byte for byte
+func (o SyncOptions) EOLPolicy() string {
+	return o.EOL
+}
+
+// DefaultMaxParallel is how many sources `sync --all` syncs at once unless configured
+const DefaultMaxParallel = 4
+
+// Parallelism returns the configured number of concurrent source syncs or the default
+func (o SyncOptions) Parallelism() int {
+	if o.MaxParallel <= 0 {
+		return DefaultMaxParallel
+	}
+	return o.MaxParallel
+}
+
+// RenameSimilarityThreshold returns the configured rename threshold or the default
+func (o SyncOptions) RenameSimilarityThreshold() float64 {
+	if o.RenameThreshold <= 0 || o.RenameThreshold > 1 {
+		return DefaultRenameThreshold
+	}
+	return o.RenameThreshold
 }
 
 // CherryBunch represents a cherry bunch template file
@@ -102,7 +431,7 @@ func Load(configPath string) (*Config, error) {
 
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, fmt.Errorf("failed to parse config file: %w", units.AnnotateField(data, err))
 	}
 
 	// Set defaults for missing fields
@@ -116,17 +445,114 @@ func Load(configPath string) (*Config, error) {
 		config.Options.BranchPrefix = "cherry-go/sync"
 	}
 
+	// Compare and persist paths in a single canonical form
+	for i := range config.Sources {
+		if err := config.Sources[i].normalizePaths(); err != nil {
+			return nil, err
+		}
+		switch config.Sources[i].Cache {
+		case "", CacheShared, CacheEphemeral:
+		default:
+			return nil, fmt.Errorf("invalid cache '%s' in source '%s' (expected shared or ephemeral)",
+				config.Sources[i].Cache, config.Sources[i].Name)
+		}
+		if config.Sources[i].CloneDepth < 0 {
+			return nil, fmt.Errorf("invalid depth %d in source '%s' (expected 0 for a full clone, or more)",
+				config.Sources[i].CloneDepth, config.Sources[i].Name)
+		}
+		for _, pathSpec := range config.Sources[i].Paths {
+			switch pathSpec.Link {
+			case "", LinkCopy, LinkHardlink:
+			default:
+				return nil, fmt.Errorf("invalid link '%s' for %s in source '%s' (expected copy or hardlink)",
+					pathSpec.Link, pathSpec.Include, config.Sources[i].Name)
+			}
+			if err := pathSpec.validateModes(); err != nil {
+				return nil, fmt.Errorf("%w in source '%s'", err, config.Sources[i].Name)
+			}
+			if err := pathSpec.ValidateLocalPath(); err != nil {
+				return nil, fmt.Errorf("%w in source '%s'", err, config.Sources[i].Name)
+			}
+		}
+	}
+
+	switch config.Options.UntrackedPolicy() {
+	case UntrackedReport, UntrackedIgnore, UntrackedError:
+	default:
+		return nil, fmt.Errorf("invalid options.untracked '%s' (expected report, ignore, or error)", config.Options.Untracked)
+	}
+
+	switch config.Options.CommitDatePolicy() {
+	case CommitDateNow, CommitDateSource, CommitDateEpoch:
+	default:
+		return nil, fmt.Errorf("invalid options.commit_date '%s' (expected now, source, or epoch)", config.Options.CommitDate)
+	}
+
+	switch config.Options.ConflictBranchModePolicy() {
+	case ConflictBranchNew, ConflictBranchReuse:
+	default:
+		return nil, fmt.Errorf("invalid options.conflict_branch_mode '%s' (expected new or reuse)", config.Options.ConflictBranchMode)
+	}
+
+	switch config.Options.HookFailurePolicy() {
+	case HookFailureFail, HookFailureWarn:
+	default:
+		return nil, fmt.Errorf("invalid options.on_hook_failure '%s' (expected fail or warn)", config.Options.OnHookFailure)
+	}
+
+	switch config.Options.SortSourcesPolicy() {
+	case SortSourcesManual, SortSourcesName:
+	default:
+		return nil, fmt.Errorf("invalid options.sort_sources '%s' (expected manual or name)", config.Options.SortSources)
+	}
+
+	switch config.Options.EOLPolicy() {
+	case "", EOLAuto, EOLLF, EOLCRLF, EOLNative:
+	default:
+		return nil, fmt.Errorf("invalid options.eol '%s' (expected auto, lf, crlf, or native)", config.Options.EOL)
+	}
+
+	switch config.Options.SignCommitsPolicy() {
+	case SignCommitsOff:
+	case SignCommitsOn, SignCommitsOptional:
+		if config.Options.SigningKey == "" && !config.Options.UseGitCLI {
+			return nil, fmt.Errorf("options.sign_commits needs options.signing_key, or options.use_git_cli to sign as git is configured to")
+		}
+	default:
+		return nil, fmt.Errorf("invalid options.sign_commits '%s' (expected true, false, or optional)", config.Options.SignCommits)
+	}
+
+	if err := config.Options.validateCommitTemplate(); err != nil {
+		return nil, err
+	}
+
+	if config.Options.MaxParallel < 0 {
+		return nil, fmt.Errorf("invalid options.max_parallel %d (expected 1 or more)", config.Options.MaxParallel)
+	}
+
+	if proxy := config.Options.Proxy; proxy != "" {
+		if parsed, err := url.Parse(proxy); err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid options.proxy '%s' (expected a URL like http://proxy.example.com:3128)", proxy)
+		}
+	}
+
 	return &config, nil
 }
 
-// Save saves configuration to a file
+// Save saves configuration to a file, stamping it with the running cherry-go version.
+// The stamp only changes when something else is saved; it never triggers a save itself.
+// Only the file is stamped: c is left as it is, so concurrent saves don't write to it.
 func (c *Config) Save(configPath string) error {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	stamped := *c
+	stamped.GeneratedBy = GeneratedBy()
+	stamped.Sources = c.OrderedSources()
+
+	data, err := yaml.Marshal(&stamped)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -161,6 +587,18 @@ func (c *Config) RemoveSource(name string) bool {
 	return false
 }
 
+// OrderedSources returns the sources in the order options.sort_sources asks for, as a
+// copy: alphabetical by name, or as they are in the configuration. Sources themselves
+// are never reordered, so indexes into Sources stay valid.
+func (c *Config) OrderedSources() []Source {
+	ordered := make([]Source, len(c.Sources))
+	copy(ordered, c.Sources)
+	if c.Options.SortSourcesPolicy() == SortSourcesName {
+		sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Name < ordered[j].Name })
+	}
+	return ordered
+}
+
 // GetSource returns a source by name
 func (c *Config) GetSource(name string) (*Source, bool) {
 	for _, source := range c.Sources {
@@ -171,6 +609,33 @@ func (c *Config) GetSource(name string) (*Source, bool) {
 	return nil, false
 }
 
+// SourcesUsingRepository returns the names of sources tracking the given repository URL,
+// ignoring the source named exclude (pass "" to include every source)
+func (c *Config) SourcesUsingRepository(repoURL string, exclude string) []string {
+	var names []string
+	for _, source := range c.Sources {
+		if source.Name == exclude {
+			continue
+		}
+		if source.Repository == repoURL {
+			names = append(names, source.Name)
+		}
+	}
+	return names
+}
+
+// SourcesWithoutPaths returns the sources that track no path, in config order. Syncing
+// them only clones and pulls.
+func (c *Config) SourcesWithoutPaths() []Source {
+	var empty []Source
+	for _, source := range c.Sources {
+		if len(source.Paths) == 0 {
+			empty = append(empty, source)
+		}
+	}
+	return empty
+}
+
 // LoadCherryBunch loads a cherry bunch from a file or URL
 func LoadCherryBunch(path string) (*CherryBunch, error) {
 	var data []byte
@@ -229,6 +694,19 @@ func LoadCherryBunchFromData(data []byte) (*CherryBunch, error) {
 
 // ApplyCherryBunch applies a cherry bunch to the current configuration
 func (c *Config) ApplyCherryBunch(cb *CherryBunch) error {
+	source, err := cb.Source()
+	if err != nil {
+		return err
+	}
+
+	// Add or update source in configuration
+	c.AddSource(source)
+	return nil
+}
+
+// Source converts a cherry bunch into the source that tracks its files, without adding
+// it to any configuration
+func (cb *CherryBunch) Source() (Source, error) {
 	// Create source from cherry bunch
 	source := Source{
 		Name:       cb.Name,
@@ -240,9 +718,11 @@ func (c *Config) ApplyCherryBunch(cb *CherryBunch) error {
 	// Add files as path specs
 	for _, file := range cb.Files {
 		pathSpec := PathSpec{
-			Include:   file.Path,
-			LocalPath: file.LocalPath,
-			Branch:    file.Branch,
+			Include: CanonicalPath(file.Path, false),
+			Branch:  file.Branch,
+		}
+		if file.LocalPath != "" {
+			pathSpec.LocalPath = CanonicalPath(file.LocalPath, false)
 		}
 		source.Paths = append(source.Paths, pathSpec)
 	}
@@ -250,17 +730,20 @@ func (c *Config) ApplyCherryBunch(cb *CherryBunch) error {
 	// Add directories as path specs
 	for _, dir := range cb.Directories {
 		pathSpec := PathSpec{
-			Include:   dir.Path,
-			LocalPath: dir.LocalPath,
-			Branch:    dir.Branch,
-			Exclude:   dir.Exclude,
+			Include: CanonicalPath(dir.Path, true),
+			Branch:  dir.Branch,
+			Exclude: dir.Exclude,
+		}
+		if dir.LocalPath != "" {
+			pathSpec.LocalPath = CanonicalPath(dir.LocalPath, true)
 		}
 		source.Paths = append(source.Paths, pathSpec)
 	}
 
-	// Add or update source in configuration
-	c.AddSource(source)
-	return nil
+	if err := source.normalizePaths(); err != nil {
+		return Source{}, fmt.Errorf("invalid cherry bunch '%s': %w", cb.Name, err)
+	}
+	return source, nil
 }
 
 // SaveCherryBunch saves a cherry bunch to a file
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"strings"
+
+	"cherry-go/internal/pathmatch"
+)
+
+// BuiltinExcludes are OS and editor junk files skipped in every tracked directory
+// unless default_excludes is turned off
+var BuiltinExcludes = []string{
+	".DS_Store",   // macOS Finder metadata
+	"._*",         // macOS resource forks
+	"Thumbs.db",   // Windows thumbnail cache
+	"desktop.ini", // Windows folder settings
+	"*.swp",       // Vim swap files
+	"*.swo",       // Vim swap files
+	"*~",          // Editor backup files
+	".#*",         // Emacs lock files
+	"#*#",         // Emacs auto-save files
+}
+
+// DefaultExcludesEnabled reports whether BuiltinExcludes apply to a source's directories.
+// A source-level setting wins over the project-wide one; both default to enabled.
+func (o SyncOptions) DefaultExcludesEnabled(source *Source) bool {
+	if source != nil && source.DefaultExcludes != nil {
+		return *source.DefaultExcludes
+	}
+	return o.DefaultExcludes == nil || *o.DefaultExcludes
+}
+
+// EffectiveExcludes returns the exclude patterns for a tracked directory: the user's
+// patterns followed by the built-in defaults when enabled. The input is not modified.
+func (o SyncOptions) EffectiveExcludes(source *Source, excludes []string) []string {
+	effective := make([]string, 0, len(excludes)+len(BuiltinExcludes))
+	effective = append(effective, excludes...)
+	if !o.DefaultExcludesEnabled(source) {
+		return effective
+	}
+
+	seen := make(map[string]bool, len(excludes))
+	for _, exclude := range excludes {
+		seen[exclude] = true
+	}
+	for _, exclude := range BuiltinExcludes {
+		if !seen[exclude] {
+			effective = append(effective, exclude)
+		}
+	}
+	return effective
+}
+
+// Excludes is what leaves files of a tracked directory out of a sync
+type Excludes struct {
+	Patterns []string // Exclude patterns, with gitignore rules (see MatchingExclude)
+	Glob     string   // For a pattern include, the glob its files must match below its root
+}
+
+// IsExcluded reports whether a file, given by its path relative to a tracked directory, is
+// excluded: matched by an exclude pattern, or left out by a pattern include's glob.
+func IsExcluded(relPath string, excludes Excludes) bool {
+	return NewExcludeMatcher(excludes).Excluded(relPath)
+}
+
+// MatchingExclude returns the exclude pattern that excludes a file, given by its path
+// relative to a tracked directory, or "" when none does. Patterns follow gitignore rules
+// (see package pathmatch): "config" excludes config/ and a/config/ but not config.yaml,
+// "/build" only build/ at the root, "tmp/" only directories, "**" any number of
+// directories, and a later "!keep.txt" brings back a file an earlier pattern excluded.
+// A file a pattern include's glob leaves out has no matching exclude.
+func MatchingExclude(relPath string, excludes Excludes) string {
+	return NewExcludeMatcher(excludes).Match(relPath)
+}
+
+// ExcludeMatcher is MatchingExclude with the exclude patterns compiled once, for
+// matching many files against the same excludes
+type ExcludeMatcher struct {
+	patterns *pathmatch.Matcher
+	glob     string
+}
+
+// NewExcludeMatcher compiles a tracked directory's excludes
+func NewExcludeMatcher(excludes Excludes) *ExcludeMatcher {
+	return &ExcludeMatcher{patterns: pathmatch.New(excludes.Patterns), glob: excludes.Glob}
+}
+
+// Match returns the exclude pattern that excludes a file, given by its path relative to
+// the tracked directory, or "" when none does, including for files outside the glob
+func (m *ExcludeMatcher) Match(relPath string) string {
+	if !m.InGlob(relPath) {
+		return ""
+	}
+	return m.patterns.Match(relPath, false)
+}
+
+// InGlob reports whether a file, given by its path relative to the tracked directory, is
+// one a pattern include's glob matches; every file is when there is no glob
+func (m *ExcludeMatcher) InGlob(relPath string) bool {
+	return m.glob == "" || MatchPattern(m.glob, relPath)
+}
+
+// Excluded reports whether a file, given by its path relative to the tracked directory,
+// is left out by the glob or excluded by a pattern
+func (m *ExcludeMatcher) Excluded(relPath string) bool {
+	return !m.InGlob(relPath) || m.patterns.Match(relPath, false) != ""
+}
+
+// UnmatchedExclude is an exclude pattern of a tracked directory that matches none of its
+// files, with patterns it was likely meant to be
+type UnmatchedExclude struct {
+	Pattern     string
+	Suggestions []string // Corrected patterns that do match, or the parts of a comma-separated list
+}
+
+// UnmatchedExcludes returns the user's exclude patterns that match none of files, the
+// paths of a tracked directory's upstream files relative to it, in exclude order. A
+// pattern matching nothing usually means shell quoting or a path written from the wrong
+// root went wrong, and the files it was meant for are synced after all.
+func UnmatchedExcludes(include string, excludes []string, files []string) []UnmatchedExclude {
+	var unmatched []UnmatchedExclude
+	for _, exclude := range excludes {
+		if matchesAnyFile(exclude, files) {
+			continue
+		}
+		unmatched = append(unmatched, UnmatchedExclude{Pattern: exclude, Suggestions: suggestExcludes(include, exclude, files)})
+	}
+	return unmatched
+}
+
+// matchesAnyFile reports whether a pattern matches one of files or a directory above it
+func matchesAnyFile(exclude string, files []string) bool {
+	for _, file := range files {
+		if pathmatch.Matches(exclude, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestExcludes proposes patterns for an exclude that matches nothing: the parts of a
+// comma-separated list passed as one pattern, and, when they match, the pattern relative
+// to the tracked directory instead of the repository root, unanchored, or as a prefix
+func suggestExcludes(include, exclude string, files []string) []string {
+	if strings.Contains(exclude, ",") {
+		var parts []string
+		for _, part := range strings.Split(exclude, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return parts
+	}
+
+	negation, body := "", exclude
+	if rest, ok := strings.CutPrefix(body, "!"); ok {
+		negation, body = "!", rest
+	}
+	unanchored := strings.TrimPrefix(body, "/")
+
+	var candidates []string
+	if rel, ok := strings.CutPrefix(unanchored, PathKey(include)+"/"); ok && rel != "" {
+		candidates = append(candidates, rel)
+	}
+	if unanchored != body {
+		candidates = append(candidates, unanchored)
+	}
+	if !strings.ContainsAny(body, "*?[/") {
+		candidates = append(candidates, body+"*")
+	}
+
+	var suggestions []string
+	for _, candidate := range candidates {
+		if matchesAnyFile(candidate, files) {
+			suggestions = append(suggestions, negation+candidate)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsExcluded(t *testing.T) {
+	testCases := []struct {
+		path     string
+		excludes []string
+		expected bool
+	}{
+		{"notes.tmp", []string{"*.tmp"}, true},
+		{"sub/notes.tmp", []string{"*.tmp"}, true}, // Globs also match the base name
+		{"sub/notes.go", []string{"sub/*.go"}, true},
+		{"test_utils.go", []string{"test_*"}, true},
+		{"vendor/lib/a.go", []string{"vendor"}, true}, // Whole segments match
+		{"src/vendor/a.go", []string{"vendor"}, true},
+		{"vendor.go", []string{"vendor"}, false},
+		{"config.yaml", []string{"config"}, false},
+		{"myconfig/a.yaml", []string{"config"}, false},
+		{"a/vendor/lib/x.go", []string{"vendor/lib"}, true},
+		{"vendor/library.go", []string{"vendor/lib"}, false},
+		{"tmp/cache.bin", []string{"tmp/"}, true},
+		{"tmp", []string{"tmp/"}, false},                          // A trailing slash only matches directories
+		{"src/document_parser.go", []string{"doc"}, false},        // Never a substring
+		{"pkg/testdata/in.txt", []string{"**/testdata/**"}, true}, // ** spans directories
+		{"keep.tmp", []string{"*.tmp", "!keep.tmp"}, false},       // Negation brings a file back
+		{"src/build/out.bin", []string{"/build"}, false},          // A leading slash anchors to the root
+		{"main.go", []string{"*.tmp", "test_*"}, false},
+		{"main.go", nil, false},
+	}
+
+	for _, tc := range testCases {
+		if got := IsExcluded(tc.path, Excludes{Patterns: tc.excludes}); got != tc.expected {
+			t.Errorf("IsExcluded(%q, %v) = %t, expected %t", tc.path, tc.excludes, got, tc.expected)
+		}
+	}
+}
+
+func TestMatchingExclude(t *testing.T) {
+	excludes := []string{"*.tmp", "config", "docs/"}
+	testCases := map[string]string{
+		"a.tmp":          "*.tmp",
+		"config/app.yml": "config",
+		"docs/guide.md":  "docs/",
+		"config.yaml":    "",
+		"docs.md":        "",
+	}
+	for path, expected := range testCases {
+		if got := MatchingExclude(path, Excludes{Patterns: excludes}); got != expected {
+			t.Errorf("MatchingExclude(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
+func TestBuiltinExcludes(t *testing.T) {
+	junk := []string{
+		".DS_Store", "docs/.DS_Store", "._icon.png", "Thumbs.db", "img/desktop.ini",
+		".main.go.swp", "src/.a.go.swo", "README.md~", ".#main.go", "#main.go#",
+	}
+	for _, path := range junk {
+		if !IsExcluded(path, Excludes{Patterns: BuiltinExcludes}) {
+			t.Errorf("Expected %s to be excluded by default", path)
+		}
+	}
+
+	kept := []string{"main.go", "docs/guide.md", "Makefile", ".gitignore", "config.db", "issue#12.md"}
+	for _, path := range kept {
+		if IsExcluded(path, Excludes{Patterns: BuiltinExcludes}) {
+			t.Errorf("Expected %s not to be excluded by default", path)
+		}
+	}
+}
+
+func TestEffectiveExcludes(t *testing.T) {
+	disabled, enabled := false, true
+	user := []string{"*.tmp", ".DS_Store"}
+
+	// User patterns first, then the defaults the user didn't already list
+	withDefaults := []string{"*.tmp", ".DS_Store"}
+	for _, exclude := range BuiltinExcludes {
+		if exclude != ".DS_Store" {
+			withDefaults = append(withDefaults, exclude)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		options  SyncOptions
+		source   *Source
+		expected []string
+	}{
+		{"defaults appended after user patterns", SyncOptions{}, &Source{}, withDefaults},
+		{"disabled globally", SyncOptions{DefaultExcludes: &disabled}, &Source{}, user},
+		{"source re-enables", SyncOptions{DefaultExcludes: &disabled}, &Source{DefaultExcludes: &enabled}, withDefaults},
+		{"source disables", SyncOptions{}, &Source{DefaultExcludes: &disabled}, user},
+	}
+
+	for _, tc := range testCases {
+		got := tc.options.EffectiveExcludes(tc.source, user)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: EffectiveExcludes = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+
+	if !reflect.DeepEqual(user, []string{"*.tmp", ".DS_Store"}) {
+		t.Errorf("Expected the user's excludes to be left unmodified, got %v", user)
+	}
+}
+
+func TestUnmatchedExcludes(t *testing.T) {
+	files := []string{"a.go", "notes.tmp", "tests/unit/a_test.go", "testdata/in.txt", "internal/gen/out.go"}
+
+	testCases := []struct {
+		name     string
+		excludes []string
+		expected []UnmatchedExclude
+	}{
+		{"every pattern matches", []string{"*.tmp", "tests/", "!tests/unit/a_test.go"}, nil},
+		{"comma-separated list", []string{"*.tmp,test_*"}, []UnmatchedExclude{{Pattern: "*.tmp,test_*", Suggestions: []string{"*.tmp", "test_*"}}}},
+		{"written from the repository root", []string{"lib/notes.tmp"}, []UnmatchedExclude{{Pattern: "lib/notes.tmp", Suggestions: []string{"notes.tmp"}}}},
+		{"anchored below the root", []string{"/gen"}, []UnmatchedExclude{{Pattern: "/gen", Suggestions: []string{"gen"}}}},
+		{"prefix of names", []string{"test"}, []UnmatchedExclude{{Pattern: "test", Suggestions: []string{"test*"}}}},
+		{"negated", []string{"!/gen"}, []UnmatchedExclude{{Pattern: "!/gen", Suggestions: []string{"!gen"}}}},
+		{"nothing close", []string{"*.bak"}, []UnmatchedExclude{{Pattern: "*.bak"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := UnmatchedExcludes("lib/", tc.excludes, files); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("UnmatchedExcludes(%v) = %+v, expected %+v", tc.excludes, got, tc.expected)
+			}
+		})
+	}
+}
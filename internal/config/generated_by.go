@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ToolVersion is the running cherry-go version, recorded as generated_by on every save
+var ToolVersion = "dev"
+
+// generatedByPrefix precedes the version in the generated_by field
+const generatedByPrefix = "cherry-go "
+
+// GeneratedBy describes the running binary as Save records it in the generated_by field
+func GeneratedBy() string {
+	return generatedByPrefix + "v" + strings.TrimPrefix(ToolVersion, "v")
+}
+
+// majorVersion extracts the major version from "cherry-go v1.2.3", "v1.2.3" or "1.2.3"
+func majorVersion(version string) (int, bool) {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, generatedByPrefix), "v")
+	major, _, _ := strings.Cut(version, ".")
+	n, err := strconv.Atoi(major)
+	return n, err == nil
+}
+
+// WrittenByNewerMajor reports whether the configuration was last saved by a cherry-go
+// with a higher major version than the running one, which may not understand all of it.
+// Unknown or unparsable versions never count as newer.
+func (c *Config) WrittenByNewerMajor() bool {
+	writer, ok := majorVersion(c.GeneratedBy)
+	if !ok {
+		return false
+	}
+	current, ok := majorVersion(ToolVersion)
+	return ok && writer > current
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withToolVersion pretends the running binary is the given version
+func withToolVersion(t *testing.T, version string) {
+	t.Helper()
+	previous := ToolVersion
+	ToolVersion = version
+	t.Cleanup(func() { ToolVersion = previous })
+}
+
+func TestSave_RecordsGeneratedBy(t *testing.T) {
+	withToolVersion(t, "1.4.2")
+	configPath := filepath.Join(t.TempDir(), ".cherry-go.yaml")
+
+	config := DefaultConfig()
+	config.GeneratedBy = "cherry-go v0.9.0"
+	if err := config.Save(configPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if !strings.Contains(string(data), "generated_by: cherry-go v1.4.2") {
+		t.Errorf("Expected the running version to be recorded, got:\n%s", data)
+	}
+	if config.GeneratedBy != "cherry-go v0.9.0" {
+		t.Errorf("Expected Save to leave the config it saved alone, got %q", config.GeneratedBy)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.GeneratedBy != "cherry-go v1.4.2" {
+		t.Errorf("Expected generated_by to load, got %q", loaded.GeneratedBy)
+	}
+}
+
+func TestWrittenByNewerMajor(t *testing.T) {
+	testCases := []struct {
+		generatedBy string
+		current     string
+		expected    bool
+	}{
+		{"cherry-go v2.0.0", "1.9.3", true},
+		{"cherry-go v1.9.0", "1.2.0", false}, // Same major: compatible
+		{"cherry-go v1.0.0", "v2.1.0", false},
+		{"", "1.0.0", false},               // Written before versions were recorded
+		{"cherry-go vdev", "1.0.0", false}, // Unparsable writer
+		{"cherry-go v3.0.0", "dev", false}, // Unparsable current binary
+		{"cherry-go v1.0.0-rc1", "0.1.0", true},
+	}
+
+	for _, tc := range testCases {
+		withToolVersion(t, tc.current)
+		config := &Config{GeneratedBy: tc.generatedBy}
+		if got := config.WrittenByNewerMajor(); got != tc.expected {
+			t.Errorf("WrittenByNewerMajor(%q vs %q) = %t, expected %t", tc.generatedBy, tc.current, got, tc.expected)
+		}
+	}
+}
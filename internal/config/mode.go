@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ParseFileMode parses octal permissions such as "0600" or "755"
+func ParseFileMode(mode string) (os.FileMode, error) {
+	bits, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || bits > 0o777 {
+		return 0, fmt.Errorf("'%s' is not an octal file mode like 0644", mode)
+	}
+	return os.FileMode(bits), nil
+}
+
+// FormatFileMode formats permissions the way mode and file_modes store them, e.g. "0600"
+func FormatFileMode(mode os.FileMode) string {
+	return fmt.Sprintf("%04o", mode.Perm())
+}
+
+// HasModes reports whether the path sets the permissions of its synced files
+func (p PathSpec) HasModes() bool {
+	return p.Mode != "" || len(p.Modes) > 0
+}
+
+// FileMode returns the permissions configured for a file of the path, keyed like Files:
+// relative to a directory include, or the file name for a file include. Modes entries
+// win over mode. Modes are validated when the config is loaded.
+func (p PathSpec) FileMode(key string) (os.FileMode, bool) {
+	mode, ok := p.Modes[key]
+	if !ok {
+		if p.Mode == "" {
+			return 0, false
+		}
+		mode = p.Mode
+	}
+	parsed, err := ParseFileMode(mode)
+	return parsed, err == nil
+}
+
+// validateModes checks that mode and modes hold octal permissions, that modes names files
+// inside the path, and that the path isn't hardlinked to the cache
+func (p PathSpec) validateModes() error {
+	if p.HasModes() && p.HardLinked() {
+		return fmt.Errorf("invalid mode for %s: link: hardlink files share their permissions with the cache", p.Include)
+	}
+	if p.Mode != "" {
+		if _, err := ParseFileMode(p.Mode); err != nil {
+			return fmt.Errorf("invalid mode for %s: %w", p.Include, err)
+		}
+	}
+	for file, mode := range p.Modes {
+		clean := path.Clean(file)
+		if file == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid modes entry '%s' for %s: expected a file path inside it", file, p.Include)
+		}
+		if _, err := ParseFileMode(mode); err != nil {
+			return fmt.Errorf("invalid modes entry '%s' for %s: %w", file, p.Include, err)
+		}
+	}
+	return nil
+}
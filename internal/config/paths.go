@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CleanPath puts an include or local path in canonical form: slash-separated, without
+// ./ segments or doubled slashes, keeping the trailing slash that marks a directory
+func CleanPath(p string) string {
+	if p == "" {
+		return ""
+	}
+
+	p = filepath.ToSlash(p)
+	isDir := strings.HasSuffix(p, "/")
+	cleaned := path.Clean(p)
+	if isDir && !strings.HasSuffix(cleaned, "/") {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// CanonicalPath returns the canonical form of a path whose kind is known:
+// directories end with a slash, files don't
+func CanonicalPath(p string, isDir bool) string {
+	key := PathKey(p)
+	if isDir && key != "" && key != "." {
+		return key + "/"
+	}
+	return key
+}
+
+// PathKey returns the form paths are compared by, so "src" and "src/" are the same path
+func PathKey(p string) string {
+	cleaned := CleanPath(p)
+	if cleaned == "/" {
+		return cleaned
+	}
+	return strings.TrimSuffix(cleaned, "/")
+}
+
+// SamePath reports whether two include or local paths name the same path
+func SamePath(a, b string) bool {
+	return PathKey(a) == PathKey(b)
+}
+
+// normalizePaths canonicalizes a source's includes and local paths. A trailing slash on
+// either side marks the spec as a directory, so both get one; specs whose kind isn't
+// known yet are settled on their first sync.
+func (s *Source) normalizePaths() error {
+	seen := make(map[string]string)
+
+	for i := range s.Paths {
+		spec := &s.Paths[i]
+		spec.Include = CleanPath(spec.Include)
+		spec.LocalPath = CleanPath(spec.LocalPath)
+
+		// A pattern's local path is the directory its matches go under
+		if spec.IsPattern() {
+			if err := spec.ValidatePattern(); err != nil {
+				return fmt.Errorf("%w in source '%s'", err, s.Name)
+			}
+			if spec.LocalPath != "" {
+				spec.LocalPath = CanonicalPath(spec.LocalPath, true)
+			}
+		} else if strings.HasSuffix(spec.Include, "/") || strings.HasSuffix(spec.LocalPath, "/") {
+			spec.Include = CanonicalPath(spec.Include, true)
+			if spec.LocalPath != "" {
+				spec.LocalPath = CanonicalPath(spec.LocalPath, true)
+			}
+		}
+
+		key := PathKey(spec.Include)
+		if previous, ok := seen[key]; ok {
+			return fmt.Errorf("source '%s' tracks '%s' twice (as '%s' and '%s'); remove one of them",
+				s.Name, key, previous, spec.Include)
+		}
+		seen[key] = spec.Include
+	}
+
+	return nil
+}
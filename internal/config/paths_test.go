@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalPaths(t *testing.T) {
+	testCases := []struct {
+		path      string
+		clean     string
+		key       string
+		directory string
+		file      string
+	}{
+		{"src", "src", "src", "src/", "src"},
+		{"src/", "src/", "src", "src/", "src"},
+		{"./src//lib/", "src/lib/", "src/lib", "src/lib/", "src/lib"},
+		{"docs/./guide.md", "docs/guide.md", "docs/guide.md", "docs/guide.md/", "docs/guide.md"},
+		{"", "", "", "", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := CleanPath(tc.path); got != tc.clean {
+			t.Errorf("CleanPath(%q) = %q, expected %q", tc.path, got, tc.clean)
+		}
+		if got := PathKey(tc.path); got != tc.key {
+			t.Errorf("PathKey(%q) = %q, expected %q", tc.path, got, tc.key)
+		}
+		if got := CanonicalPath(tc.path, true); got != tc.directory {
+			t.Errorf("CanonicalPath(%q, dir) = %q, expected %q", tc.path, got, tc.directory)
+		}
+		if got := CanonicalPath(tc.path, false); got != tc.file {
+			t.Errorf("CanonicalPath(%q, file) = %q, expected %q", tc.path, got, tc.file)
+		}
+	}
+
+	if !SamePath("src", "./src/") || SamePath("src", "src2") {
+		t.Error("Expected SamePath to compare canonical forms")
+	}
+}
+
+func TestLoad_NormalizesPaths(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".cherry-go.yaml")
+	content := `version: "1.0"
+sources:
+  - name: lib
+    repository: https://github.com/user/lib.git
+    paths:
+      - include: ./src//
+      - include: vendor
+        local_path: third_party/vendor/
+      - include: docs/guide.md
+      - include: pkg
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expected := []struct{ include, localPath string }{
+		{"src/", ""},
+		{"vendor/", "third_party/vendor/"}, // A slash on either side marks a directory
+		{"docs/guide.md", ""},
+		{"pkg", ""}, // Kind unknown until the first sync
+	}
+	for i, want := range expected {
+		got := loaded.Sources[0].Paths[i]
+		if got.Include != want.include || got.LocalPath != want.localPath {
+			t.Errorf("Path %d: expected %q -> %q, got %q -> %q", i, want.include, want.localPath, got.Include, got.LocalPath)
+		}
+	}
+}
+
+func TestLoad_RejectsDuplicatePaths(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".cherry-go.yaml")
+	content := `version: "1.0"
+sources:
+  - name: lib
+    repository: https://github.com/user/lib.git
+    paths:
+      - include: src
+      - include: src/
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := Load(configPath)
+	if err == nil || !strings.Contains(err.Error(), "tracks 'src' twice") {
+		t.Errorf("Expected 'src' and 'src/' to be rejected as duplicates, got %v", err)
+	}
+}
+
+func TestApplyCherryBunch_NormalizesPaths(t *testing.T) {
+	config := DefaultConfig()
+	err := config.ApplyCherryBunch(&CherryBunch{
+		Name:        "bunch",
+		Repository:  "https://github.com/user/bunch.git",
+		Files:       []CherryBunchFileSpec{{Path: "./README.md"}},
+		Directories: []CherryBunchDirSpec{{Path: "src", LocalPath: "vendor/src"}},
+	})
+	if err != nil {
+		t.Fatalf("ApplyCherryBunch failed: %v", err)
+	}
+
+	paths := config.Sources[0].Paths
+	if paths[0].Include != "README.md" || paths[1].Include != "src/" || paths[1].LocalPath != "vendor/src/" {
+		t.Errorf("Expected canonical paths, got %+v", paths)
+	}
+
+	err = config.ApplyCherryBunch(&CherryBunch{
+		Name:        "dupes",
+		Repository:  "https://github.com/user/bunch.git",
+		Directories: []CherryBunchDirSpec{{Path: "src"}, {Path: "src/"}},
+	})
+	if err == nil {
+		t.Error("Expected a bunch listing the same directory twice to be rejected")
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"cherry-go/internal/pathmatch"
+)
+
+// IsPattern reports whether an include is a glob rather than a literal path
+func IsPattern(include string) bool {
+	return strings.ContainsAny(include, "*?[")
+}
+
+// IsPattern reports whether the spec tracks the files matching a glob, like
+// `api/**/*.proto`, instead of one file or directory
+func (p PathSpec) IsPattern() bool {
+	return IsPattern(p.Include)
+}
+
+// EscapePattern escapes the glob metacharacters in a path, giving a pattern include that
+// matches only the name as written: `docs/notes[1].md` becomes `docs/notes\[1\].md`
+func EscapePattern(p string) string {
+	var escaped strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune("*?[]\\", r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// SplitPattern splits a pattern include into the directory its literal leading segments
+// name ("api/", or "" for the repository root) and the glob below it ("**/*.proto")
+func SplitPattern(include string) (root, glob string) {
+	segments := splitProtectedPath(include)
+	for i, segment := range segments {
+		if IsPattern(segment) {
+			if i > 0 {
+				root = strings.Join(segments[:i], "/") + "/"
+			}
+			return root, strings.Join(segments[i:], "/")
+		}
+	}
+	return CanonicalPath(include, true), ""
+}
+
+// SourceRoot returns the repository path a spec's files are read from: the include
+// itself, or the directory a pattern is rooted at ("" for the repository root)
+func (p PathSpec) SourceRoot() string {
+	if p.IsPattern() {
+		root, _ := SplitPattern(p.Include)
+		return root
+	}
+	return p.Include
+}
+
+// LocalRoot returns the local path a spec's files are written under. local_path wins;
+// otherwise a pattern's files keep their place relative to the project root.
+func (p PathSpec) LocalRoot() string {
+	if p.LocalPath != "" {
+		return p.LocalPath
+	}
+	if p.IsPattern() {
+		if root := p.SourceRoot(); root != "" {
+			return root
+		}
+		return "."
+	}
+	return p.Include
+}
+
+// MatchPattern reports whether a path relative to a pattern's root matches its glob.
+// `*`, `?` and `[...]` match within a path segment and `**` matches any number of
+// segments, as in exclude patterns (see package pathmatch); a segment also matches the
+// name it spells out. Nothing inside a .git directory matches.
+func MatchPattern(glob, relPath string) bool {
+	for _, segment := range splitProtectedPath(relPath) {
+		if segment == ".git" {
+			return false
+		}
+	}
+	return pathmatch.MatchGlob(glob, relPath)
+}
+
+// PathExcludes returns the excludes a directory or pattern spec is synced with: the
+// EffectiveExcludes patterns, and for a pattern the glob its files must match
+func (o SyncOptions) PathExcludes(source *Source, spec PathSpec) Excludes {
+	excludes := Excludes{Patterns: o.EffectiveExcludes(source, spec.Exclude)}
+	if spec.IsPattern() {
+		_, excludes.Glob = SplitPattern(spec.Include)
+	}
+	return excludes
+}
+
+// IsExcludedDir is IsExcluded for a directory: a pattern include's glob never skips a
+// directory, since files below it may still match
+func IsExcludedDir(relPath string, excludes Excludes) bool {
+	return pathmatch.Match(excludes.Patterns, relPath, true) != ""
+}
+
+// ValidatePattern checks a pattern include: its glob must be well-formed and match files,
+// and its local path must be a plain directory
+func (p PathSpec) ValidatePattern() error {
+	_, glob := SplitPattern(p.Include)
+	if strings.HasSuffix(p.Include, "/") {
+		return fmt.Errorf("pattern include '%s' must match files, not directories (drop the trailing slash)", p.Include)
+	}
+	for _, segment := range strings.Split(glob, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern include '%s': %w", p.Include, err)
+		}
+	}
+	if IsPattern(p.LocalPath) {
+		return fmt.Errorf("local_path '%s' of '%s' can't be a pattern; it names the directory matches are placed under", p.LocalPath, p.Include)
+	}
+	return nil
+}
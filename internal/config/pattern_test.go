@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPattern(t *testing.T) {
+	testCases := []struct {
+		include      string
+		expectedRoot string
+		expectedGlob string
+	}{
+		{"api/**/*.proto", "api/", "**/*.proto"},
+		{"api/v1/*.proto", "api/v1/", "*.proto"},
+		{"**/*.proto", "", "**/*.proto"},
+		{"*.md", "", "*.md"},
+		{"docs/*/index.md", "docs/", "*/index.md"},
+	}
+
+	for _, tc := range testCases {
+		root, glob := SplitPattern(tc.include)
+		if root != tc.expectedRoot || glob != tc.expectedGlob {
+			t.Errorf("SplitPattern(%q) = (%q, %q), expected (%q, %q)", tc.include, root, glob, tc.expectedRoot, tc.expectedGlob)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	testCases := []struct {
+		glob     string
+		relPath  string
+		expected bool
+	}{
+		// ** matches zero or more segments
+		{"**/*.proto", "user.proto", true},
+		{"**/*.proto", "v1/user.proto", true},
+		{"**/*.proto", "v1/internal/user.proto", true},
+		{"**/*.proto", "v1/user.go", false},
+
+		// * stays within one segment, and the whole path must match
+		{"*.proto", "user.proto", true},
+		{"*.proto", "v1/user.proto", false},
+		{"v*/*.proto", "v2/user.proto", true},
+		{"v1/**", "v1/a/b.txt", true},
+		{"v1", "v1/a.txt", false},
+
+		// Separators are normalized, and .git is never matched
+		{"**/*.proto", filepath.Join("v1", "user.proto"), true},
+		{"**", ".git/config", false},
+		{"**/*.proto", "vendor/.git/x.proto", false},
+
+		// A glob also matches the name it spells out, even a malformed one
+		{"notes[1].md", "notes[1].md", true},
+		{"notes[1].md", "notes1.md", true},
+		{"[invalid", "[invalid", true},
+		{"[invalid", "invalid", false},
+
+		// A backslash escapes a metacharacter, matching only the literal name
+		{`notes\[1\].md`, "notes[1].md", true},
+		{`notes\[1\].md`, "notes1.md", false},
+	}
+
+	for _, tc := range testCases {
+		if got := MatchPattern(tc.glob, tc.relPath); got != tc.expected {
+			t.Errorf("MatchPattern(%q, %q) = %t, expected %t", tc.glob, tc.relPath, got, tc.expected)
+		}
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"docs/notes.md", "docs/notes.md"},
+		{"docs/notes[1].md", `docs/notes\[1\].md`},
+		{"what?/*.md", `what\?/\*.md`},
+		{`a\b`, `a\\b`},
+	}
+
+	for _, tc := range testCases {
+		escaped := EscapePattern(tc.path)
+		if escaped != tc.expected {
+			t.Errorf("EscapePattern(%q) = %q, expected %q", tc.path, escaped, tc.expected)
+		}
+		if root, glob := SplitPattern(escaped); IsPattern(escaped) && !MatchPattern(glob, strings.TrimPrefix(tc.path, root)) {
+			t.Errorf("Expected %q to match %q", escaped, tc.path)
+		}
+	}
+}
+
+func TestPathSpec_PatternRoots(t *testing.T) {
+	testCases := []struct {
+		spec          PathSpec
+		expectedRoot  string
+		expectedLocal string
+	}{
+		{PathSpec{Include: "api/**/*.proto"}, "api/", "api/"},
+		{PathSpec{Include: "api/**/*.proto", LocalPath: "proto/"}, "api/", "proto/"},
+		{PathSpec{Include: "*.md"}, "", "."},
+		{PathSpec{Include: "src/"}, "src/", "src/"},
+		{PathSpec{Include: "main.go", LocalPath: "cmd/main.go"}, "main.go", "cmd/main.go"},
+	}
+
+	for _, tc := range testCases {
+		if root := tc.spec.SourceRoot(); root != tc.expectedRoot {
+			t.Errorf("SourceRoot of %q = %q, expected %q", tc.spec.Include, root, tc.expectedRoot)
+		}
+		if local := tc.spec.LocalRoot(); local != tc.expectedLocal {
+			t.Errorf("LocalRoot of %q = %q, expected %q", tc.spec.Include, local, tc.expectedLocal)
+		}
+	}
+}
+
+func TestPathExcludes_PatternFilter(t *testing.T) {
+	spec := PathSpec{Include: "api/**/*.proto", Exclude: []string{"internal"}}
+	excludes := SyncOptions{}.PathExcludes(nil, spec)
+
+	// The glob is kept apart from the exclude patterns, which are reported to the user
+	if excludes.Glob != "**/*.proto" {
+		t.Errorf("Expected the pattern's glob, got %q", excludes.Glob)
+	}
+	for _, pattern := range excludes.Patterns {
+		if strings.Contains(pattern, "proto") {
+			t.Errorf("Expected the glob not to be an exclude pattern, got %v", excludes.Patterns)
+		}
+	}
+
+	testCases := []struct {
+		relPath  string
+		expected bool
+	}{
+		{"v1/user.proto", false},
+		{"v1/user.go", true},            // Not matched by the pattern
+		{"internal/secret.proto", true}, // Matched, but excluded
+		{".DS_Store", true},             // Built-in exclude
+		{filepath.Join("v2", "a.proto"), false},
+	}
+	for _, tc := range testCases {
+		if got := IsExcluded(tc.relPath, excludes); got != tc.expected {
+			t.Errorf("IsExcluded(%q) = %t, expected %t", tc.relPath, got, tc.expected)
+		}
+	}
+
+	// Directories are only skipped by real excludes, since files below may match
+	if IsExcludedDir("v1", excludes) {
+		t.Error("Expected the pattern filter not to skip directories")
+	}
+	if !IsExcludedDir("internal", excludes) {
+		t.Error("Expected excluded directories to still be skipped")
+	}
+}
+
+func TestLoad_PatternIncludes(t *testing.T) {
+	write := func(t *testing.T, paths string) string {
+		t.Helper()
+		configPath := filepath.Join(t.TempDir(), ".cherry-go.yaml")
+		content := `version: "1.0"
+sources:
+  - name: lib
+    repository: https://github.com/user/lib.git
+    paths:
+` + paths
+		if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		return configPath
+	}
+
+	cfg, err := Load(write(t, "      - include: ./api/**/*.proto\n        local_path: proto\n"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	spec := cfg.Sources[0].Paths[0]
+	if spec.Include != "api/**/*.proto" || spec.LocalPath != "proto/" {
+		t.Errorf("Expected the pattern cleaned and its local path made a directory, got %q -> %q", spec.Include, spec.LocalPath)
+	}
+
+	invalid := map[string]string{
+		"malformed glob":    "      - include: api/[v1/*.proto\n",
+		"directory pattern": "      - include: api/*/\n",
+		"pattern local":     "      - include: api/*.proto\n        local_path: proto/*\n",
+	}
+	for name, paths := range invalid {
+		if _, err := Load(write(t, paths)); err == nil || !strings.Contains(err.Error(), "in source 'lib'") {
+			t.Errorf("%s: expected the pattern to be rejected, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ProtectedRule returns the options.protected_paths rule matching a local path, if any.
+//
+// Rules are globs relative to the project root using forward slashes: `*` and `?`
+// match within a single path segment, `**` matches any number of segments, and a rule
+// that matches a directory protects everything beneath it (so `vendor/` and
+// `vendor/**` are equivalent).
+func (o SyncOptions) ProtectedRule(localPath string) (string, bool) {
+	if len(o.ProtectedPaths) == 0 {
+		return "", false
+	}
+
+	segments := splitProtectedPath(normalizeLocalPath(localPath))
+	if len(segments) == 0 {
+		return "", false
+	}
+
+	for _, rule := range o.ProtectedPaths {
+		pattern := splitProtectedPath(rule)
+		if len(pattern) == 0 {
+			continue
+		}
+		if matchProtectedSegments(pattern, segments) {
+			return rule, true
+		}
+	}
+	return "", false
+}
+
+// normalizeLocalPath makes absolute paths relative to the working directory so they
+// can be compared against project-relative rules
+func normalizeLocalPath(localPath string) string {
+	if filepath.IsAbs(localPath) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, localPath); err == nil && !strings.HasPrefix(rel, "..") {
+				localPath = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(localPath))
+}
+
+// splitProtectedPath splits a slash-separated path or rule into segments,
+// dropping "." and empty segments from leading "./", "/" or trailing slashes
+func splitProtectedPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(p), "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// matchProtectedSegments reports whether pattern matches segments or one of their ancestors
+func matchProtectedSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(segments); i++ {
+				if matchProtectedSegments(rest, segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], segments[0]); err != nil || !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	// The whole pattern matched the path or one of its parent directories
+	return true
+}
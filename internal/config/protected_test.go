@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProtectedRule(t *testing.T) {
+	options := SyncOptions{ProtectedPaths: []string{
+		"LICENSE",
+		"vendor/",
+		"config/*.yaml",
+		"**/generated/**",
+		"docs/**/*.md",
+		"./scripts/release.sh",
+		"[invalid",
+	}}
+
+	testCases := []struct {
+		path         string
+		expectedRule string
+	}{
+		// Exact file
+		{"LICENSE", "LICENSE"},
+		{"./LICENSE", "LICENSE"},
+		{"pkg/LICENSE", ""},
+
+		// Directory rule protects everything beneath it
+		{"vendor", "vendor/"},
+		{"vendor/lib/a.go", "vendor/"},
+		{"vendored/a.go", ""},
+
+		// Single-segment wildcard
+		{"config/app.yaml", "config/*.yaml"},
+		{"config/env/app.yaml", ""},
+		{"config/app.json", ""},
+
+		// ** matches zero or more segments
+		{"generated/types.go", "**/generated/**"},
+		{"api/v1/generated/types.go", "**/generated/**"},
+		{"docs/guide.md", "docs/**/*.md"},
+		{"docs/a/b/guide.md", "docs/**/*.md"},
+		{"docs/a/b/guide.txt", ""},
+
+		// Rules are normalized like paths
+		{"scripts/release.sh", "./scripts/release.sh"},
+		{"scripts/build.sh", ""},
+
+		// Malformed patterns never match
+		{"[invalid", ""},
+	}
+
+	for _, tc := range testCases {
+		rule, ok := options.ProtectedRule(tc.path)
+		if rule != tc.expectedRule || ok != (tc.expectedRule != "") {
+			t.Errorf("ProtectedRule(%q) = (%q, %t), expected %q", tc.path, rule, ok, tc.expectedRule)
+		}
+	}
+}
+
+func TestProtectedRule_FirstMatchWins(t *testing.T) {
+	options := SyncOptions{ProtectedPaths: []string{"src/**", "src/main.go"}}
+
+	if rule, _ := options.ProtectedRule("src/main.go"); rule != "src/**" {
+		t.Errorf("Expected the first matching rule to be reported, got %q", rule)
+	}
+}
+
+func TestProtectedRule_AbsolutePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	options := SyncOptions{ProtectedPaths: []string{"vendor/"}}
+
+	if _, ok := options.ProtectedRule(filepath.Join(wd, "vendor", "a.go")); !ok {
+		t.Error("Expected absolute paths inside the working directory to be matched relative to it")
+	}
+	if _, ok := (SyncOptions{}).ProtectedRule("vendor/a.go"); ok {
+		t.Error("Expected nothing to be protected without rules")
+	}
+}
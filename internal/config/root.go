@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cherry-go/internal/utils"
+)
+
+// ProjectRoot returns the directory holding the configuration file. Tracked local paths
+// are relative to it, whatever directory cherry-go runs from.
+func ProjectRoot(configFile string) (string, error) {
+	abs, err := filepath.Abs(configFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the project root: %w", err)
+	}
+	return filepath.Dir(abs), nil
+}
+
+// ResolveLocalPath returns the absolute path of a local path relative to the project root,
+// refusing an absolute path or one that leads outside the root. Both are judged the same
+// on every platform, so a configuration refused on Windows (C:/work, ..\lib) is refused
+// everywhere.
+func ResolveLocalPath(root, localPath string) (string, error) {
+	if filepath.IsAbs(localPath) || utils.IsAbsolutePath(localPath) {
+		return "", fmt.Errorf("local path '%s' must be relative to the project root", localPath)
+	}
+	slashed := path.Clean(strings.ReplaceAll(localPath, `\`, "/"))
+	if slashed == ".." || strings.HasPrefix(slashed, "../") {
+		return "", fmt.Errorf("local path '%s' resolves outside the project root", localPath)
+	}
+	return filepath.Join(root, filepath.Clean(localPath)), nil
+}
+
+// ValidateLocalPath checks that the spec's files land inside the project root
+func (p PathSpec) ValidateLocalPath() error {
+	if _, err := ResolveLocalPath(".", p.LocalRoot()); err != nil {
+		return fmt.Errorf("invalid local path for %s: %w", p.Include, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveLocalPath(t *testing.T) {
+	root := filepath.FromSlash("/work/app")
+	testCases := []struct {
+		localPath string
+		expected  string // Empty when refused
+	}{
+		{"lib/", filepath.FromSlash("/work/app/lib")},
+		{"./vendor/lib/a.go", filepath.FromSlash("/work/app/vendor/lib/a.go")},
+		{".", root},
+		{"lib/../docs", filepath.FromSlash("/work/app/docs")},
+		{"../lib", ""},
+		{"lib/../../lib", ""},
+		{"..", ""},
+		{"/etc/lib", ""},
+		{`C:\work\lib`, ""},
+		{"C:/work/lib", ""},
+		{"c:lib", ""},
+		{`\\server\share\lib`, ""},
+		{`..\lib`, ""},
+		{`lib\..\..\lib`, ""},
+	}
+
+	for _, tc := range testCases {
+		localPath := tc.localPath
+		if !strings.Contains(localPath, `\`) {
+			localPath = filepath.FromSlash(localPath)
+		}
+		got, err := ResolveLocalPath(root, localPath)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("ResolveLocalPath(%q) = %q, expected an error", tc.localPath, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("ResolveLocalPath(%q) = %q, %v, expected %q", tc.localPath, got, err, tc.expected)
+		}
+	}
+}
+
+func TestProjectRoot(t *testing.T) {
+	root, err := ProjectRoot(filepath.FromSlash("/work/app/.cherry-go.yaml"))
+	if err != nil || root != filepath.FromSlash("/work/app") {
+		t.Errorf("ProjectRoot() = %q, %v, expected /work/app", root, err)
+	}
+}
@@ -0,0 +1,121 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Bytes formats a byte count into a human readable size (e.g. "1.5 MB")
+func Bytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// Count formats a number with thousands separators (e.g. "12,400")
+func Count(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits
+}
+
+// Duration formats a duration compactly using its two most significant units
+// (e.g. "3d4h", "5m12s"). Durations under a minute keep one decimal ("12.3s").
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	var out string
+	shown := 0
+	for _, u := range units {
+		if shown == 2 {
+			break
+		}
+		n := d / u.size
+		if n == 0 {
+			// Only skip leading zero units; a zero after the first unit ends the output
+			if shown > 0 {
+				break
+			}
+			continue
+		}
+		out += fmt.Sprintf("%d%s", n, u.suffix)
+		d -= n * u.size
+		shown++
+	}
+
+	return out
+}
+
+// RelativeTime describes t relative to now in plain English (e.g. "2 weeks ago")
+func RelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	if d < time.Minute {
+		return "just now"
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 7*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(7*24*time.Hour)), "week"
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(d/(365*24*time.Hour)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s %s", n, unit, suffix)
+}
+
+// Since describes t relative to the current time
+func Since(t time.Time) string {
+	return RelativeTime(t, time.Now())
+}
@@ -0,0 +1,102 @@
+package format
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBytes(t *testing.T) {
+	testCases := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{10 * 1024 * 1024, "10.0 MB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GB"},
+	}
+
+	for _, tc := range testCases {
+		if result := Bytes(tc.input); result != tc.expected {
+			t.Errorf("Bytes(%d) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	testCases := []struct {
+		input    int
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{12400, "12,400"},
+		{1234567, "1,234,567"},
+		{-30000, "-30,000"},
+	}
+
+	for _, tc := range testCases {
+		if result := Count(tc.input); result != tc.expected {
+			t.Errorf("Count(%d) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	testCases := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{350 * time.Millisecond, "350ms"},
+		{12300 * time.Millisecond, "12.3s"},
+		{5*time.Minute + 12*time.Second, "5m12s"},
+		{2 * time.Hour, "2h"},
+		{2*time.Hour + 30*time.Second, "2h"},
+		{3*24*time.Hour + 4*time.Hour + 10*time.Minute, "3d4h"},
+		{30 * 24 * time.Hour, "30d"},
+		{-90 * time.Second, "1m30s"},
+	}
+
+	for _, tc := range testCases {
+		if result := Duration(tc.input); result != tc.expected {
+			t.Errorf("Duration(%v) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		input    time.Time
+		expected string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-1 * time.Minute), "1 minute ago"},
+		{now.Add(-45 * time.Minute), "45 minutes ago"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{now.Add(-2 * 24 * time.Hour), "2 days ago"},
+		{now.Add(-15 * 24 * time.Hour), "2 weeks ago"},
+		{now.Add(-65 * 24 * time.Hour), "2 months ago"},
+		{now.Add(-400 * 24 * time.Hour), "1 year ago"},
+		{now.Add(3 * 24 * time.Hour), "3 days from now"},
+	}
+
+	for _, tc := range testCases {
+		if result := RelativeTime(tc.input, now); result != tc.expected {
+			t.Errorf("RelativeTime(%v) = %s, expected %s", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestRelativeTime_IgnoresTimezone(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	other := now.Add(-2 * time.Hour).In(time.FixedZone("UTC+9", 9*60*60))
+
+	if result := RelativeTime(other, now); result != "2 hours ago" {
+		t.Errorf("Expected '2 hours ago' regardless of timezone, got %s", result)
+	}
+}
@@ -0,0 +1,189 @@
+package git
+
+import (
+	"container/list"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// attributionCommitLimit bounds how many upstream commits are read to attribute one
+// conflict; a file whose change is further back is left unattributed
+const attributionCommitLimit = 500
+
+// changedFilesCacheSize is how many commits' changed files are kept between lookups
+const changedFilesCacheSize = 64
+
+// changedFilesCache is a small LRU of the files each commit changed, so conflicting
+// files changed by the same commits don't diff those commits again. The paths of a
+// source share it; its lock also takes turns reading the clone's history, which go-git
+// doesn't read concurrently.
+type changedFilesCache struct {
+	sync.Mutex
+	order   *list.List // *changedFilesEntry, most recently used first
+	entries map[plumbing.Hash]*list.Element
+}
+
+type changedFilesEntry struct {
+	commit plumbing.Hash
+	files  []string
+}
+
+func newChangedFilesCache() *changedFilesCache {
+	return &changedFilesCache{order: list.New(), entries: make(map[plumbing.Hash]*list.Element)}
+}
+
+// get returns the files a commit changed, if they are cached
+func (c *changedFilesCache) get(commit plumbing.Hash) ([]string, bool) {
+	element, ok := c.entries[commit]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*changedFilesEntry).files, true
+}
+
+// add caches the files a commit changed, evicting the least recently used commit when full
+func (c *changedFilesCache) add(commit plumbing.Hash, files []string) {
+	if element, ok := c.entries[commit]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[commit] = c.order.PushFront(&changedFilesEntry{commit: commit, files: files})
+	if c.order.Len() > changedFilesCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*changedFilesEntry).commit)
+	}
+}
+
+// attributeConflicts sets, on each conflict of a path read from sourcePath at commit,
+// the newest upstream commit since synced that changed the conflicting file. Attribution
+// is informational: a file it can't find a commit for keeps none.
+func (r *Repository) attributeConflicts(conflicts []hash.FileConflict, sourcePath string, inDirectory bool, commit, synced string) {
+	if commit == "" || len(conflicts) == 0 {
+		return
+	}
+	root, err := filepath.Rel(r.path, sourcePath)
+	if err != nil {
+		return
+	}
+	root = filepath.ToSlash(root)
+
+	for i := range conflicts {
+		file := root
+		if inDirectory {
+			file = filepath.ToSlash(filepath.Join(root, conflicts[i].Path))
+		}
+		upstream, err := r.lastUpstreamChange(plumbing.NewHash(commit), synced, file)
+		if err != nil {
+			logger.Debug("Could not attribute the conflict in %s to an upstream commit: %v", conflicts[i].Path, err)
+			continue
+		}
+		conflicts[i].Upstream = upstream
+	}
+}
+
+// lastUpstreamChange walks back from start, newest first, to the newest non-merge commit
+// that changed file (or anything under it), stopping at synced or the first commit older
+// than it. Without a synced commit only attributionCommitLimit bounds the walk. It returns
+// nil when no commit in range changed the file.
+func (r *Repository) lastUpstreamChange(start plumbing.Hash, synced, file string) (*hash.UpstreamCommit, error) {
+	r.changedFiles.Lock()
+	defer r.changedFiles.Unlock()
+
+	var stop plumbing.Hash
+	var stopCommit *object.Commit
+	if synced != "" {
+		stop = plumbing.NewHash(synced)
+		stopCommit, _ = r.repo.CommitObject(stop) // Unknown upstream: only the limit applies
+	}
+
+	commits, err := r.repo.Log(&git.LogOptions{From: start, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer commits.Close()
+
+	var found *hash.UpstreamCommit
+	for visited := 0; visited < attributionCommitLimit; visited++ {
+		c, err := commits.Next()
+		if err != nil {
+			break // End of history
+		}
+		if c.Hash == stop || (stopCommit != nil && c.Committer.When.Before(stopCommit.Committer.When)) {
+			break
+		}
+		if c.NumParents() > 1 {
+			continue
+		}
+
+		files, ok := r.changedFiles.get(c.Hash)
+		if !ok {
+			if files, err = commitChangedFiles(c); err != nil {
+				return nil, fmt.Errorf("failed to read commit %s: %w", ShortHash(c.Hash.String()), err)
+			}
+			r.changedFiles.add(c.Hash, files)
+		}
+		if changesFile(files, file) {
+			entry := changelogEntry(c, nil)
+			found = &hash.UpstreamCommit{Hash: entry.Hash, Author: entry.Author, When: entry.When, Subject: entry.Subject}
+			break
+		}
+	}
+	return found, nil
+}
+
+// commitChangedFiles lists the repository files a commit added, changed or removed
+// compared to its first parent
+func commitChangedFiles(c *object.Commit) ([]string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.From.Name != "" {
+			files = append(files, change.From.Name)
+		}
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			files = append(files, change.To.Name)
+		}
+	}
+	return files, nil
+}
+
+// changesFile reports whether file, or a file under it, is among the changed files
+func changesFile(changed []string, file string) bool {
+	for _, name := range changed {
+		if name == file || strings.HasPrefix(name, file+"/") {
+			return true
+		}
+	}
+	return false
+}
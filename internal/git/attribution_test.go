@@ -0,0 +1,101 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_AttributesConflictsToUpstreamCommits(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // a\n")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.WriteFile("lib/c.go", "package lib // c\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	sync := func(mode SyncMode) *CopyResult {
+		t.Helper()
+		repo, err := NewRepository(source)
+		if err != nil {
+			t.Fatalf("Failed to open repository: %v", err)
+		}
+		defer repo.Close()
+		if err := repo.Pull(); err != nil {
+			t.Fatalf("Failed to pull: %v", err)
+		}
+		result, err := repo.CopyPaths(mode, project.Dir)
+		if err != nil {
+			t.Fatalf("CopyPaths failed: %v", err)
+		}
+		return result
+	}
+	sync(SyncModeForce)
+
+	project.WriteFile("lib/a.go", "package lib // a, local\n")
+	project.WriteFile("lib/b.go", "package lib // b, local\n")
+	project.WriteFile("lib/c.go", "package lib // c, local\n")
+	upstream.WriteFile("lib/a.go", "package lib // a, first fix\n")
+	upstream.Commit("lib: fix a")
+	upstream.WriteFile("lib/b.go", "package lib // b, fixed\n")
+	fixB := upstream.Commit("lib: fix b\n\nWith a body that isn't part of the subject.")
+	upstream.WriteFile("lib/a.go", "package lib // a, second fix\n")
+	fixA := upstream.Commit("lib: fix a again")
+
+	result := sync(SyncModeDetect)
+	if len(result.Conflicts) != 3 {
+		t.Fatalf("Expected a conflict per file, got %+v", result.Conflicts)
+	}
+	attributed := make(map[string]string)
+	for _, conflict := range result.Conflicts {
+		if upstream := conflict.Upstream; upstream != nil {
+			if upstream.Author == "" || upstream.When.IsZero() {
+				t.Errorf("Expected the author and date of %s's commit, got %+v", conflict.Path, upstream)
+			}
+			attributed[conflict.Path] = upstream.Hash + " " + upstream.Subject
+		}
+	}
+
+	// Each file goes to the newest commit that changed it
+	if got := attributed["a.go"]; got != fixA+" lib: fix a again" {
+		t.Errorf("Expected a.go to be attributed to 'lib: fix a again', got %q", got)
+	}
+	if got := attributed["b.go"]; got != fixB+" lib: fix b" {
+		t.Errorf("Expected b.go to be attributed to 'lib: fix b', got %q", got)
+	}
+	// c.go differs locally only; nothing upstream changed it since the last sync
+	if _, ok := attributed["c.go"]; ok {
+		t.Errorf("Expected c.go to be left unattributed, got %q", attributed["c.go"])
+	}
+}
+
+func TestChangedFilesCache(t *testing.T) {
+	cache := newChangedFilesCache()
+	commit := func(i int) plumbing.Hash { return plumbing.Hash{byte(i), byte(i >> 8)} }
+
+	for i := 0; i < changedFilesCacheSize; i++ {
+		cache.add(commit(i), []string{"lib/a.go"})
+	}
+	// Using the oldest entry keeps it when the next one evicts
+	if _, ok := cache.get(commit(0)); !ok {
+		t.Fatal("Expected the first commit to be cached")
+	}
+	cache.add(commit(changedFilesCacheSize), []string{"lib/b.go"})
+
+	if _, ok := cache.get(commit(1)); ok {
+		t.Error("Expected the least recently used commit to be evicted")
+	}
+	if files, ok := cache.get(commit(0)); !ok || len(files) != 1 || files[0] != "lib/a.go" {
+		t.Errorf("Expected the recently used commit to be kept, got %v", files)
+	}
+	if cache.order.Len() != changedFilesCacheSize {
+		t.Errorf("Expected the cache to hold %d commits, got %d", changedFilesCacheSize, cache.order.Len())
+	}
+}
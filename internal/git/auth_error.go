@@ -0,0 +1,124 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"cherry-go/internal/config"
+)
+
+// RemoteCredential is the host a source's repository is on and the credential sent to
+// it. Sources with the same RemoteCredential that fail to authenticate fail for the
+// same reason.
+type RemoteCredential struct {
+	Host       string
+	Credential string // Where the credential comes from, e.g. "GITHUB_TOKEN from the environment"; empty when none is sent
+}
+
+// CredentialFor returns the credential a source's clone or fetch sends and the host it
+// goes to, as getAuth picks it, without contacting the host. Host is empty for local
+// repositories.
+func CredentialFor(source *config.Source) RemoteCredential {
+	authConfig := source.Auth
+	host, scheme := remoteHost(source.Repository)
+	if host == "" {
+		return RemoteCredential{}
+	}
+	auto := authConfig.Type == "" || authConfig.Type == "auto"
+
+	switch {
+	case authConfig.Type == "ssh" || (auto && scheme == "ssh"):
+		return RemoteCredential{Host: host, Credential: sshCredential(authConfig)}
+	case authConfig.Type == "basic":
+		return RemoteCredential{Host: host, Credential: "GIT_PASSWORD from the environment"}
+	case auto && scheme == "https":
+		if token := selectToken(authConfig, host); token != nil {
+			return RemoteCredential{Host: host, Credential: token.env + " from the environment"}
+		}
+	}
+	return RemoteCredential{Host: host}
+}
+
+// remoteHost returns the host of a repository URL, with its port, and its scheme;
+// scp-like SSH addresses (git@host:org/repo.git) are "ssh". Local paths and file://
+// URLs have no host.
+func remoteHost(repoURL string) (host, scheme string) {
+	if parsed, err := url.Parse(repoURL); err == nil && parsed.Scheme != "" {
+		return parsed.Host, parsed.Scheme
+	}
+	at, colon := strings.Index(repoURL, "@"), strings.Index(repoURL, ":")
+	if at >= 0 && colon > at {
+		return repoURL[at+1 : colon], "ssh"
+	}
+	return "", ""
+}
+
+// sshCredential names the key getSSHAuth uses for authConfig
+func sshCredential(authConfig config.AuthConfig) string {
+	if authConfig.SSHKey != "" {
+		return "SSH key " + authConfig.SSHKey
+	}
+	if keyPaths := sshCommandKeys(os.Getenv("GIT_SSH_COMMAND")); len(keyPaths) > 0 {
+		return "SSH key " + strings.Join(keyPaths, ", ") + " from GIT_SSH_COMMAND"
+	}
+	return "the SSH agent or default SSH keys"
+}
+
+// AuthError is a host refusing the credential a source was cloned or fetched with, or
+// asking for one when none was sent
+type AuthError struct {
+	RemoteCredential
+	Err error // What the transport returned
+}
+
+func (e *AuthError) Error() string {
+	if e.Credential == "" {
+		return fmt.Sprintf("%s requires authentication and no credential was found for it: %v", e.Host, e.Err)
+	}
+	return fmt.Sprintf("%s refused %s: %v", e.Host, e.Credential, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Forbidden reports whether the host accepted the credential but denied it access
+// (HTTP 403), rather than rejecting it
+func (e *AuthError) Forbidden() bool {
+	return errors.Is(e.Err, transport.ErrAuthorizationFailed)
+}
+
+// Rejected reports whether the host rejected the credential itself (HTTP 401, or every
+// SSH key refused), so it will fail for any repository on the host. Anonymous requests
+// and 403s are not rejections: they depend on the repository asked for.
+func (e *AuthError) Rejected() bool {
+	return e.Credential != "" && !e.Forbidden()
+}
+
+// withAuthError turns a clone or fetch error into an *AuthError naming the host and the
+// credential when the host refused to authenticate the source
+func withAuthError(err error, source *config.Source) error {
+	if err == nil || !isAuthError(err) {
+		return err
+	}
+	credential := CredentialFor(source)
+	if credential.Host == "" {
+		return err
+	}
+	return &AuthError{RemoteCredential: credential, Err: err}
+}
+
+// isAuthError reports whether err is a host refusing authentication: HTTP 401 or 403,
+// which go-git returns as typed errors, or an SSH server rejecting every key offered,
+// which golang.org/x/crypto/ssh only reports in its message
+func isAuthError(err error) bool {
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return true
+	}
+	return strings.Contains(err.Error(), "ssh: unable to authenticate")
+}
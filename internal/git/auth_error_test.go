@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+func TestCredentialFor(t *testing.T) {
+	logger.Init()
+	for _, name := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "GIT_TOKEN", "GIT_USERNAME", "GIT_PASSWORD", "MIRROR_TOKEN", "GIT_SSH_COMMAND"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("GITHUB_TOKEN", "gh")
+	t.Setenv("MIRROR_TOKEN", "mirror")
+
+	testCases := []struct {
+		name     string
+		repoURL  string
+		auth     config.AuthConfig
+		expected RemoteCredential
+	}{
+		{"GitHub token", "https://github.com/org/repo.git", config.AuthConfig{},
+			RemoteCredential{Host: "github.com", Credential: "GITHUB_TOKEN from the environment"}},
+		{"token_env", "https://git.example.com:8443/org/repo.git", config.AuthConfig{TokenEnv: "MIRROR_TOKEN"},
+			RemoteCredential{Host: "git.example.com:8443", Credential: "MIRROR_TOKEN from the environment"}},
+		{"no token for the host", "https://gitlab.com/org/repo.git", config.AuthConfig{},
+			RemoteCredential{Host: "gitlab.com"}},
+		{"plain HTTP sends no token", "http://github.com/org/repo.git", config.AuthConfig{},
+			RemoteCredential{Host: "github.com"}},
+		{"basic", "https://git.example.com/repo.git", config.AuthConfig{Type: "basic", Username: "ci"},
+			RemoteCredential{Host: "git.example.com", Credential: "GIT_PASSWORD from the environment"}},
+		{"scp-like SSH", "git@github.com:org/repo.git", config.AuthConfig{SSHKey: "~/.ssh/deploy"},
+			RemoteCredential{Host: "github.com", Credential: "SSH key ~/.ssh/deploy"}},
+		{"SSH URL", "ssh://git@github.com/org/repo.git", config.AuthConfig{},
+			RemoteCredential{Host: "github.com", Credential: "the SSH agent or default SSH keys"}},
+		{"file URL", "file:///srv/git/repo.git", config.AuthConfig{}, RemoteCredential{}},
+		{"local path", "/srv/git/repo.git", config.AuthConfig{}, RemoteCredential{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := CredentialFor(&config.Source{Name: "lib", Repository: tc.repoURL, Auth: tc.auth})
+			if got != tc.expected {
+				t.Errorf("CredentialFor(%s) = %+v, expected %+v", tc.repoURL, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestWithAuthError(t *testing.T) {
+	logger.Init()
+	t.Setenv("GIT_TOKEN", "expired")
+	source := &config.Source{Name: "lib", Repository: "https://git.example.com/org/lib.git"}
+
+	testCases := []struct {
+		name      string
+		err       error
+		auth      bool
+		forbidden bool
+	}{
+		{"401", fmt.Errorf("failed to clone: %w", transport.ErrAuthenticationRequired), true, false},
+		{"403", transport.ErrAuthorizationFailed, true, true},
+		{"SSH key rejected", errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey], no supported methods remain"), true, false},
+		{"missing repository", transport.ErrRepositoryNotFound, false, false},
+		{"network", errors.New("dial tcp: connection refused"), false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := withAuthError(tc.err, source)
+			var authErr *AuthError
+			if errors.As(err, &authErr) != tc.auth {
+				t.Fatalf("Expected classification as an auth error to be %t, got %v", tc.auth, err)
+			}
+			if !tc.auth {
+				if err != tc.err {
+					t.Errorf("Expected other errors to be returned as they are, got %v", err)
+				}
+				return
+			}
+			if authErr.Host != "git.example.com" || authErr.Credential != "GIT_TOKEN from the environment" {
+				t.Errorf("Expected the host and credential to be named, got %+v", authErr.RemoteCredential)
+			}
+			if authErr.Forbidden() != tc.forbidden {
+				t.Errorf("Forbidden() = %t, expected %t", authErr.Forbidden(), tc.forbidden)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Expected the transport error to stay in the chain, got %v", err)
+			}
+		})
+	}
+
+	// A local repository has no host to blame
+	local := &config.Source{Name: "local", Repository: "/srv/git/lib.git"}
+	if err := withAuthError(transport.ErrAuthenticationRequired, local); err != transport.ErrAuthenticationRequired {
+		t.Errorf("Expected a local repository's error to be left alone, got %v", err)
+	}
+}
+
+func TestCloneRepository_AuthError(t *testing.T) {
+	logger.Init()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GIT_PASSWORD", "expired")
+
+	source := &config.Source{Name: "lib", Repository: server.URL + "/lib.git", Auth: config.AuthConfig{Type: "basic", Username: "ci"}}
+	_, err := cloneRepository(context.Background(), source, filepath.Join(t.TempDir(), "clone"))
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected an AuthError, got %v", err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	if authErr.Host != host || authErr.Credential != "GIT_PASSWORD from the environment" {
+		t.Errorf("Expected %s and GIT_PASSWORD to be named, got %+v", host, authErr.RemoteCredential)
+	}
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// cherry-go's own identity, for commits nobody else can be named as the author of
+const (
+	defaultAuthorName  = "cherry-go"
+	defaultAuthorEmail = "cherry-go@local"
+)
+
+// commitSignature returns who the commits cherry-go makes in the project are by:
+// options.commit_author_name and commit_author_email, then user.name and user.email
+// from the project's git config (with the global and system ones beneath it), and
+// cherry-go itself as a last resort. The name and the email are resolved separately.
+func commitSignature(repo *git.Repository, options config.SyncOptions, when time.Time) *object.Signature {
+	signature := &object.Signature{Name: options.CommitAuthorName, Email: options.CommitAuthorEmail, When: when}
+
+	if signature.Name == "" || signature.Email == "" {
+		if cfg, err := repo.ConfigScoped(gitconfig.SystemScope); err != nil {
+			logger.Debug("Could not read the git config for the commit author: %v", err)
+		} else {
+			if signature.Name == "" {
+				signature.Name = cfg.User.Name
+			}
+			if signature.Email == "" {
+				signature.Email = cfg.User.Email
+			}
+		}
+	}
+
+	if signature.Name == "" {
+		signature.Name = defaultAuthorName
+	}
+	if signature.Email == "" {
+		signature.Email = defaultAuthorEmail
+	}
+	return signature
+}
+
+// withCoAuthorTrailer appends a Co-authored-by trailer naming cherry-go to a commit
+// message when options.co_author_trailer asks for it and someone else is the author
+func withCoAuthorTrailer(message string, options config.SyncOptions, author *object.Signature) string {
+	if !options.CoAuthorTrailer || author.Email == defaultAuthorEmail {
+		return message
+	}
+	return message + "\n\nCo-authored-by: " + defaultAuthorName + " <" + defaultAuthorEmail + ">"
+}
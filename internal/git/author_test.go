@@ -0,0 +1,133 @@
+package git
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// setProjectUser sets user.name and user.email in the project's own git config
+func setProjectUser(t *testing.T, project *testutil.Project, name, email string) {
+	t.Helper()
+	cfg, err := project.Repo().Config()
+	if err != nil {
+		t.Fatalf("Failed to read the git config: %v", err)
+	}
+	cfg.User.Name, cfg.User.Email = name, email
+	if err := project.Repo().SetConfig(cfg); err != nil {
+		t.Fatalf("Failed to write the git config: %v", err)
+	}
+}
+
+func TestCommitAuthor(t *testing.T) {
+	testCases := []struct {
+		name          string
+		gitName       string
+		gitEmail      string
+		options       config.SyncOptions
+		expectedName  string
+		expectedEmail string
+		coAuthored    bool
+	}{
+		{
+			name:          "cherry-go as a last resort",
+			expectedName:  "cherry-go",
+			expectedEmail: "cherry-go@local",
+		},
+		{
+			name:          "git config",
+			gitName:       "Jane Doe",
+			gitEmail:      "jane@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "options over git config",
+			gitName:       "Jane Doe",
+			gitEmail:      "jane@example.com",
+			options:       config.SyncOptions{CommitAuthorName: "Sync Bot", CommitAuthorEmail: "bot@example.com"},
+			expectedName:  "Sync Bot",
+			expectedEmail: "bot@example.com",
+		},
+		{
+			name:          "fields resolved separately",
+			gitEmail:      "jane@example.com",
+			options:       config.SyncOptions{CommitAuthorName: "Sync Bot"},
+			expectedName:  "Sync Bot",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "co-author trailer",
+			gitName:       "Jane Doe",
+			gitEmail:      "jane@example.com",
+			options:       config.SyncOptions{CoAuthorTrailer: true},
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+			coAuthored:    true,
+		},
+		{
+			name:          "no trailer crediting cherry-go itself",
+			options:       config.SyncOptions{CoAuthorTrailer: true},
+			expectedName:  "cherry-go",
+			expectedEmail: "cherry-go@local",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger.Init()
+			t.Setenv("XDG_CONFIG_HOME", "")
+			project := testutil.NewProject(t)
+			setProjectUser(t, project, tc.gitName, tc.gitEmail)
+
+			checkCommit := func(kind string, commit *object.Commit) {
+				t.Helper()
+				if commit.Author.Name != tc.expectedName || commit.Author.Email != tc.expectedEmail {
+					t.Errorf("Expected the %s to be authored by %s <%s>, got %s <%s>",
+						kind, tc.expectedName, tc.expectedEmail, commit.Author.Name, commit.Author.Email)
+				}
+				if commit.Committer.Name != tc.expectedName || commit.Committer.Email != tc.expectedEmail {
+					t.Errorf("Expected the %s to be committed by %s <%s>, got %s <%s>",
+						kind, tc.expectedName, tc.expectedEmail, commit.Committer.Name, commit.Committer.Email)
+				}
+				trailer := strings.HasSuffix(commit.Message, "\n\nCo-authored-by: cherry-go <cherry-go@local>")
+				if trailer != tc.coAuthored {
+					t.Errorf("Expected a Co-authored-by trailer on the %s: %v, got message %q", kind, tc.coAuthored, commit.Message)
+				}
+			}
+
+			project.WriteFile("lib/a.go", "package lib\n")
+			hash, err := CreateCommit(project.Dir, "sync lib", []string{"lib/"}, time.Now(), tc.options)
+			if err != nil {
+				t.Fatalf("CreateCommit failed: %v", err)
+			}
+			commit, err := project.Repo().CommitObject(plumbing.NewHash(hash))
+			if err != nil {
+				t.Fatalf("Failed to read the commit: %v", err)
+			}
+			checkCommit("auto-commit", commit)
+
+			result, err := CreateConflictBranch(project.Dir, "cherry-go/sync", "library",
+				map[string][]byte{"lib/a.go": []byte("package lib // upstream\n")}, tc.options)
+			if err != nil {
+				t.Fatalf("CreateConflictBranch failed: %v", err)
+			}
+			ref, err := project.Repo().Reference(plumbing.NewBranchReferenceName(result.BranchName), true)
+			if err != nil {
+				t.Fatalf("Failed to read the conflict branch: %v", err)
+			}
+			commit, err = project.Repo().CommitObject(ref.Hash())
+			if err != nil {
+				t.Fatalf("Failed to read the conflict branch commit: %v", err)
+			}
+			checkCommit("conflict branch commit", commit)
+		})
+	}
+}
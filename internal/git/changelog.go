@@ -0,0 +1,261 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+)
+
+// ChangelogOptions bounds the upstream history listed by Changelog
+type ChangelogOptions struct {
+	Since         string // Date or ref: only commits after it
+	Until         string // Date or ref: only commits up to it (defaults to each path's branch)
+	IncludeMerges bool   // List merge commits too (compared against their first parent)
+}
+
+// ChangelogEntry is an upstream commit that touched at least one tracked path
+type ChangelogEntry struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Subject string
+	Paths   []string // Includes of the tracked paths it touched
+}
+
+// changelogBound is a resolved --since/--until value: a point in time or a commit
+type changelogBound struct {
+	time   *time.Time
+	commit *plumbing.Hash
+}
+
+// changelogDateLayouts are the date forms accepted for --since/--until
+var changelogDateLayouts = []string{"2006-01-02", "2006-01-02T15:04:05Z07:00", "2006-01-02 15:04"}
+
+// Changelog lists the upstream commits that touched the source's tracked paths,
+// newest first, within the bounds of opts. It reads the cached clone as it is,
+// so fetch first to see the latest upstream history; a shallow clone gets the history
+// it lacks.
+func (r *Repository) Changelog(opts ChangelogOptions) ([]ChangelogEntry, error) {
+	r.requireHistory("the changelog")
+
+	since, err := r.resolveChangelogBound(opts.Since, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := r.resolveChangelogBound(opts.Until, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --until: %w", err)
+	}
+
+	// Commits already contained in a --since ref are excluded wholesale
+	var excluded map[plumbing.Hash]bool
+	if since.commit != nil {
+		if excluded, err = r.ancestors(*since.commit); err != nil {
+			return nil, err
+		}
+	}
+
+	// Paths on the same branch share one walk; a ref given as --until replaces all branches
+	specsByStart := make(map[plumbing.Hash][]config.PathSpec)
+	var starts []plumbing.Hash
+	for _, pathSpec := range r.source.Paths {
+		ref, err := r.PathRef(pathSpec)
+		if err != nil {
+			return nil, err
+		}
+		start, err := r.changelogStart(ref, until)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := specsByStart[start]; !ok {
+			starts = append(starts, start)
+		}
+		specsByStart[start] = append(specsByStart[start], pathSpec)
+	}
+
+	seen := make(map[plumbing.Hash]bool)
+	var entries []ChangelogEntry
+	for _, start := range starts {
+		commits, err := r.repo.Log(&git.LogOptions{
+			From:  start,
+			Order: git.LogOrderCommitterTime,
+			Since: since.time,
+			Until: until.time,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+
+		err = commits.ForEach(func(c *object.Commit) error {
+			if seen[c.Hash] {
+				return nil
+			}
+			if excluded[c.Hash] || (c.NumParents() > 1 && !opts.IncludeMerges) {
+				return nil
+			}
+
+			touched, err := touchedPaths(c, specsByStart[start])
+			if err != nil {
+				return err
+			}
+			if len(touched) == 0 {
+				return nil
+			}
+
+			seen[c.Hash] = true
+			entries = append(entries, changelogEntry(c, touched))
+			return nil
+		})
+		commits.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].When.After(entries[j].When)
+	})
+	return entries, nil
+}
+
+// changelogEntry describes a commit that touched the given tracked paths
+func changelogEntry(c *object.Commit, touched []string) ChangelogEntry {
+	subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return ChangelogEntry{
+		Hash:    c.Hash.String(),
+		Author:  c.Author.Name,
+		When:    c.Committer.When,
+		Subject: subject,
+		Paths:   touched,
+	}
+}
+
+// resolveChangelogBound parses a --since/--until value as a date, then as a ref.
+// Dates are local midnight; as an upper bound a date includes the whole day.
+func (r *Repository) resolveChangelogBound(value string, upper bool) (changelogBound, error) {
+	if value == "" {
+		return changelogBound{}, nil
+	}
+
+	for _, layout := range changelogDateLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		if upper && layout == "2006-01-02" {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return changelogBound{time: &t}, nil
+	}
+
+	commit, err := r.resolveRemoteRef(value)
+	if err != nil {
+		return changelogBound{}, fmt.Errorf("'%s' is neither a date (YYYY-MM-DD) nor a branch, tag, or commit", value)
+	}
+	return changelogBound{commit: &commit}, nil
+}
+
+// changelogStart picks the commit a path's history is read from
+func (r *Repository) changelogStart(branch string, until changelogBound) (plumbing.Hash, error) {
+	if until.commit != nil {
+		return *until.commit, nil
+	}
+	if branch == "" {
+		branch = r.detectDefaultBranch()
+	}
+	return r.resolveRemoteRef(branch)
+}
+
+// resolveRemoteRef resolves a ref preferring the remote-tracking branch, which a fetch
+// keeps current, over the local branch the cache last checked out
+func (r *Repository) resolveRemoteRef(ref string) (plumbing.Hash, error) {
+	if hash, err := r.repo.ResolveRevision(plumbing.Revision("refs/remotes/origin/" + ref)); err == nil {
+		return *hash, nil
+	}
+	commit, err := r.GetCommitForRef(ref)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return plumbing.NewHash(commit), nil
+}
+
+// ancestors returns a commit and everything reachable from it
+func (r *Repository) ancestors(from plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commits, err := r.repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer commits.Close()
+
+	reachable := make(map[plumbing.Hash]bool)
+	err = commits.ForEach(func(c *object.Commit) error {
+		reachable[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return reachable, nil
+}
+
+// touchedPaths returns the includes of the specs whose files a commit changed,
+// comparing merges against their first parent
+func touchedPaths(c *object.Commit, specs []config.PathSpec) ([]string, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var touched []string
+	for _, spec := range specs {
+		for _, change := range changes {
+			if pathTouches(spec, change.From.Name) || pathTouches(spec, change.To.Name) {
+				touched = append(touched, spec.Include)
+				break
+			}
+		}
+	}
+	return touched, nil
+}
+
+// pathTouches reports whether a repository file belongs to a tracked path
+func pathTouches(spec config.PathSpec, file string) bool {
+	if file == "" {
+		return false
+	}
+	if spec.IsPattern() {
+		root, glob := config.SplitPattern(spec.Include)
+		rel, ok := strings.CutPrefix(file, root)
+		return ok && config.MatchPattern(glob, rel) && !shouldExclude(rel, config.Excludes{Patterns: spec.Exclude})
+	}
+
+	key := config.PathKey(spec.Include)
+	if file == key {
+		return true
+	}
+	rel, ok := strings.CutPrefix(file, key+"/")
+	return ok && !shouldExclude(rel, config.Excludes{Patterns: spec.Exclude})
+}
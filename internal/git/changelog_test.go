@@ -0,0 +1,137 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// newChangelogFixture builds an upstream history spread over 2025 where lib/ is tracked:
+//
+//	Jan  initial             lib/a.go, docs/guide.md
+//	Mar  lib: march fix      lib/a.go           (tagged v1)
+//	Mar  docs: typo          docs/guide.md
+//	May  lib: add b          lib/b.go           (on branch feature)
+//	May  lib: may update     lib/a.go
+//	May  Merge feature       lib/b.go vs first parent
+//	Jul  lib: july update    lib/a.go
+func newChangelogFixture(t *testing.T) *Repository {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // jan\n")
+	upstream.WriteFile("docs/guide.md", "# Guide\n")
+	upstream.Commit("initial")
+
+	upstream.AdvanceClock(60 * 24 * time.Hour) // Early March
+	upstream.WriteFile("lib/a.go", "package lib // mar\n")
+	upstream.Commit("lib: march fix")
+	upstream.Tag("v1")
+	upstream.WriteFile("docs/guide.md", "# The guide\n")
+	upstream.Commit("docs: typo")
+
+	upstream.AdvanceClock(60 * 24 * time.Hour) // Early May
+	upstream.CreateBranch("feature")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.Commit("lib: add b")
+	upstream.Checkout(testutil.DefaultBranch)
+	upstream.WriteFile("lib/a.go", "package lib // may\n")
+	upstream.Commit("lib: may update")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.Merge("feature", "Merge feature")
+
+	upstream.AdvanceClock(62 * 24 * time.Hour) // Early July
+	upstream.WriteFile("lib/a.go", "package lib // jul\n")
+	upstream.Commit("lib: july update")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	repo, err := NewRepository(&config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	return repo
+}
+
+func changelogSubjects(entries []ChangelogEntry) []string {
+	subjects := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		subjects = append(subjects, entry.Subject)
+	}
+	return subjects
+}
+
+func TestChangelog_Bounds(t *testing.T) {
+	repo := newChangelogFixture(t)
+
+	testCases := []struct {
+		name     string
+		options  ChangelogOptions
+		expected []string
+	}{
+		{"unbounded", ChangelogOptions{},
+			[]string{"lib: july update", "lib: may update", "lib: add b", "lib: march fix", "initial"}},
+		{"dates", ChangelogOptions{Since: "2025-03-01", Until: "2025-06-30"},
+			[]string{"lib: may update", "lib: add b", "lib: march fix"}},
+		{"until includes the whole day", ChangelogOptions{Since: "2025-03-02", Until: "2025-03-02"},
+			[]string{"lib: march fix"}},
+		{"since ref", ChangelogOptions{Since: "v1"},
+			[]string{"lib: july update", "lib: may update", "lib: add b"}},
+		{"until ref", ChangelogOptions{Until: "v1"},
+			[]string{"lib: march fix", "initial"}},
+		{"ref to branch", ChangelogOptions{Since: "v1", Until: "feature"},
+			[]string{"lib: add b"}},
+		{"merges", ChangelogOptions{Since: "2025-04-01", Until: "2025-06-30", IncludeMerges: true},
+			[]string{"Merge feature", "lib: may update", "lib: add b"}},
+	}
+
+	for _, tc := range testCases {
+		entries, err := repo.Changelog(tc.options)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if got := changelogSubjects(entries); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestChangelog_EntryDetails(t *testing.T) {
+	repo := newChangelogFixture(t)
+
+	entries, err := repo.Changelog(ChangelogOptions{Since: "2025-07-01"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected one commit, got %v", changelogSubjects(entries))
+	}
+	entry := entries[0]
+	if entry.Author != "Fixture" || entry.When.Month() != time.July || len(entry.Hash) != 40 {
+		t.Errorf("Unexpected entry details: %+v", entry)
+	}
+	if !reflect.DeepEqual(entry.Paths, []string{"lib/"}) {
+		t.Errorf("Expected the touched include to be reported, got %v", entry.Paths)
+	}
+}
+
+func TestChangelog_InvalidBound(t *testing.T) {
+	repo := newChangelogFixture(t)
+
+	_, err := repo.Changelog(ChangelogOptions{Since: "last-tuesday"})
+	if err == nil || !strings.Contains(err.Error(), "--since") {
+		t.Errorf("Expected an invalid --since error, got %v", err)
+	}
+}
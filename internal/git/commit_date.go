@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"cherry-go/internal/config"
+)
+
+// sourceDateEpochEnv names the variable reproducible builds set to the time their
+// outputs must carry, in seconds since 1970 (https://reproducible-builds.org/specs/source-date-epoch/)
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// CommitDate returns the author and committer date of the auto-commit for a sync, as
+// options.commit_date asks: the current time, the committer date of the newest upstream
+// commit an updated path was synced from, or SOURCE_DATE_EPOCH. Only the current time
+// makes two commits of the same files differ.
+func (r *Repository) CommitDate(result *CopyResult) (time.Time, error) {
+	switch r.options.CommitDatePolicy() {
+	case config.CommitDateSource:
+		var newest time.Time
+		for _, include := range result.UpdatedPaths {
+			commit := result.PathCommits[include]
+			if commit == "" {
+				continue
+			}
+			when, err := r.CommitTime(commit)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if when.After(newest) {
+				newest = when
+			}
+		}
+		if newest.IsZero() {
+			return time.Time{}, fmt.Errorf("options.commit_date is source, but no upstream commit was synced")
+		}
+		return newest, nil
+	case config.CommitDateEpoch:
+		return sourceDateEpoch()
+	default:
+		return time.Now(), nil
+	}
+}
+
+// sourceDateEpoch returns the time SOURCE_DATE_EPOCH holds, in UTC
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv(sourceDateEpochEnv)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("options.commit_date is epoch, but %s is not set", sourceDateEpochEnv)
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, fmt.Errorf("invalid %s '%s' (expected seconds since 1970)", sourceDateEpochEnv, value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
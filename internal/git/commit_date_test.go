@@ -0,0 +1,75 @@
+package git
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCommitDate(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib\n")
+	older := upstream.Commit("v1")
+	upstream.AdvanceClock(time.Hour)
+	upstream.WriteFile("README.md", "# library\n")
+	newer := upstream.Commit("v2")
+
+	source := &config.Source{Name: "library", Repository: upstream.URL()}
+	repo := fetchedFixture(t, source)
+	result := &CopyResult{
+		UpdatedPaths: []string{"README.md", "lib/"},
+		PathCommits:  map[string]string{"lib/": older, "README.md": newer, "docs/": newer},
+	}
+	newest, err := repo.CommitTime(newer)
+	if err != nil {
+		t.Fatalf("CommitTime failed: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		policy   string
+		epoch    string
+		result   *CopyResult
+		expected time.Time
+		errorMsg string
+	}{
+		{name: "source", policy: config.CommitDateSource, result: result, expected: newest},
+		{name: "source without updates", policy: config.CommitDateSource, result: &CopyResult{}, errorMsg: "no upstream commit"},
+		{name: "epoch", policy: config.CommitDateEpoch, epoch: "1700000000", result: result, expected: time.Unix(1700000000, 0).UTC()},
+		{name: "epoch unset", policy: config.CommitDateEpoch, result: result, errorMsg: "SOURCE_DATE_EPOCH is not set"},
+		{name: "epoch invalid", policy: config.CommitDateEpoch, epoch: "yesterday", result: result, errorMsg: "invalid SOURCE_DATE_EPOCH"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("SOURCE_DATE_EPOCH", tc.epoch)
+			repo.SetSyncOptions(config.SyncOptions{CommitDate: tc.policy})
+
+			when, err := repo.CommitDate(tc.result)
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("Expected an error with %q, got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CommitDate failed: %v", err)
+			}
+			if !when.Equal(tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, when)
+			}
+		})
+	}
+
+	// The default is the time of the sync
+	repo.SetSyncOptions(config.SyncOptions{})
+	before := time.Now()
+	if when, err := repo.CommitDate(result); err != nil || when.Before(before) {
+		t.Errorf("Expected the current time by default, got %v (err: %v)", when, err)
+	}
+}
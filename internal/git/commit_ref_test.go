@@ -0,0 +1,132 @@
+package git
+
+import (
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_RecordsCommitPerBranch(t *testing.T) {
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "diverging")
+	upstream.WriteFile("main.txt", "main v1\n")
+	upstream.WriteFile("develop.txt", "develop v1\n")
+	initial := upstream.Commit("initial")
+	upstream.Tag("v1.0.0")
+
+	upstream.CreateBranch("develop")
+	upstream.WriteFile("develop.txt", "develop v2\n")
+	developHead := upstream.Commit("develop change")
+
+	upstream.Checkout(testutil.DefaultBranch)
+	upstream.WriteFile("main.txt", "main v2\n")
+	mainHead := upstream.Commit("main change")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{
+		Name:       "diverging",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "main.txt"},
+			{Include: "develop.txt", Branch: "develop"},
+		},
+	}
+
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	testCases := []struct {
+		ref      string
+		expected string
+	}{
+		{"", mainHead},
+		{"develop", developHead},
+		{"v1.0.0", initial},
+		{initial, initial},
+	}
+
+	for _, tc := range testCases {
+		got, err := repo.GetCommitForRef(tc.ref)
+		if err != nil {
+			t.Errorf("GetCommitForRef(%q) failed: %v", tc.ref, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("GetCommitForRef(%q) = %s, expected %s", tc.ref, got, tc.expected)
+		}
+	}
+
+	if _, err := repo.GetCommitForRef("does-not-exist"); err == nil {
+		t.Error("Expected an error for an unknown ref")
+	}
+
+	result, err := repo.CopyPaths(SyncModeForce, project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+
+	if result.PathCommits["main.txt"] != mainHead {
+		t.Errorf("Expected main.txt from %s, got %s", mainHead, result.PathCommits["main.txt"])
+	}
+	if result.PathCommits["develop.txt"] != developHead {
+		t.Errorf("Expected develop.txt from %s, got %s", developHead, result.PathCommits["develop.txt"])
+	}
+	if source.Paths[0].Commit == source.Paths[1].Commit {
+		t.Errorf("Expected diverging branches to record different commits, both got %s", source.Paths[0].Commit)
+	}
+	if got := project.ReadFile("develop.txt"); got != "develop v2\n" {
+		t.Errorf("Expected develop.txt from the develop branch, got %q", got)
+	}
+}
+
+func TestCopyPaths_FetchesNonDefaultBranch(t *testing.T) {
+	// Either branch can be the one the cache is left on after a sync
+	orders := map[string][]config.PathSpec{
+		"default last": {{Include: "develop.txt", Branch: "develop"}, {Include: "main.txt"}},
+		"develop last": {{Include: "main.txt"}, {Include: "develop.txt", Branch: "develop"}},
+	}
+	for name, paths := range orders {
+		t.Run(name, func(t *testing.T) {
+			logger.Init()
+
+			upstream := testutil.NewFixtureRepo(t, "diverging")
+			upstream.WriteFile("main.txt", "main v1\n")
+			upstream.WriteFile("develop.txt", "develop v1\n")
+			upstream.Commit("initial")
+			upstream.CreateBranch("develop")
+			upstream.WriteFile("develop.txt", "develop v2\n")
+			upstream.Commit("develop change")
+
+			project := testutil.NewProject(t)
+			project.Chdir()
+
+			source := &config.Source{Name: "diverging", Repository: upstream.URL(), Paths: paths}
+			syncFixture(t, source, SyncModeForce, project.Dir)
+			if got := project.ReadFile("develop.txt"); got != "develop v2\n" {
+				t.Fatalf("Expected develop.txt from develop, got %q", got)
+			}
+
+			// Only the non-default branch moves upstream
+			upstream.WriteFile("develop.txt", "develop v3\n")
+			developHead := upstream.Commit("develop update")
+
+			result := syncFixture(t, source, SyncModeForce, project.Dir)
+			if got := project.ReadFile("develop.txt"); got != "develop v3\n" {
+				t.Errorf("Expected the develop update to be synced, got %q", got)
+			}
+			if result.PathCommits["develop.txt"] != developHead {
+				t.Errorf("Expected develop.txt from %s, got %s", developHead, result.PathCommits["develop.txt"])
+			}
+			if got := project.ReadFile("main.txt"); got != "main v1\n" {
+				t.Errorf("Expected main.txt unchanged, got %q", got)
+			}
+		})
+	}
+}
@@ -4,24 +4,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
 )
 
-// ConflictBranchResult contains information about a created conflict branch
+// ConflictBranchResult contains information about a created conflict branch, or in a
+// dry run the branch that would be created
 type ConflictBranchResult struct {
 	BranchName     string
 	OriginalBranch string
 	FilesCommitted []string
+	CommitMessage  string
+	Planned        bool // Dry run: the branch was only planned, nothing was created
+	Reused         bool // An unmerged branch of the source got a new commit instead (conflict_branch_mode: reuse)
+}
+
+// conflictBranchName names a source's conflict branch <prefix>/<source>-<timestamp>
+func conflictBranchName(branchPrefix, sourceName string, now time.Time) string {
+	return fmt.Sprintf("%s/%s-%s", branchPrefix, sourceName, now.Format("20060102-150405"))
 }
 
-// CreateConflictBranch creates a new branch with the remote content for manual merge
-func CreateConflictBranch(workDir string, branchPrefix string, sourceName string, files map[string][]byte) (*ConflictBranchResult, error) {
-	repo, err := git.PlainOpen(workDir)
+// conflictBranchCommitMessage is the message of the commit holding a conflict branch's remote files
+func conflictBranchCommitMessage(sourceName, branchName string) string {
+	return fmt.Sprintf("cherry-go: remote changes from %s\n\nThis branch contains the remote changes that conflicted with local modifications.\nUse 'git merge %s' from your original branch to resolve conflicts.", sourceName, branchName)
+}
+
+// CreateConflictBranch creates a new branch with the remote content for manual merge.
+// Under options.conflict_branch_mode: reuse, the source's newest conflict branch gets a
+// new commit instead, holding the current branch with the latest remote content, unless
+// it was merged already. In a dry run it only returns the branch it would create or
+// reuse, with the same name, files and commit message, and logs a preview.
+func CreateConflictBranch(workDir string, branchPrefix string, sourceName string, files map[string][]byte, options config.SyncOptions) (*ConflictBranchResult, error) {
+	// workDir may be a subdirectory of the repository, so files are staged relative to its root
+	repo, root, err := openProjectRepository(workDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
@@ -33,9 +56,44 @@ func CreateConflictBranch(workDir string, branchPrefix string, sourceName string
 	}
 	originalBranch := head.Name().Short()
 
-	// Generate branch name with timestamp
-	timestamp := time.Now().Format("20060102-150405")
-	branchName := fmt.Sprintf("%s/%s-%s", branchPrefix, sourceName, timestamp)
+	branchName := conflictBranchName(branchPrefix, sourceName, time.Now())
+	var reused *plumbing.Reference
+	if options.ConflictBranchModePolicy() == config.ConflictBranchReuse {
+		if reused, err = openConflictBranch(repo, head, branchPrefix, sourceName); err != nil {
+			return nil, err
+		}
+		if reused != nil {
+			branchName = reused.Name().Short()
+		}
+	}
+	commitMessage := conflictBranchCommitMessage(sourceName, branchName)
+
+	relPaths := make([]string, 0, len(files))
+	for relPath := range files {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	if logger.IsDryRun() {
+		result := &ConflictBranchResult{
+			BranchName:     branchName,
+			OriginalBranch: originalBranch,
+			FilesCommitted: relPaths,
+			CommitMessage:  commitMessage,
+			Planned:        true,
+			Reused:         reused != nil,
+		}
+		if reused != nil {
+			logger.DryRunInfo("Would add a commit to unmerged branch %s holding %s with the remote version of %d file(s):", branchName, originalBranch, len(relPaths))
+		} else {
+			logger.DryRunInfo("Would create branch %s from %s with the remote version of %d file(s):", branchName, originalBranch, len(relPaths))
+		}
+		for _, relPath := range relPaths {
+			logger.DryRunInfo("  %s", relPath)
+		}
+		logger.DryRunInfo("Would commit them with message: %s", commitMessage)
+		return result, nil
+	}
 
 	// Get worktree
 	worktree, err := repo.Worktree()
@@ -43,19 +101,26 @@ func CreateConflictBranch(workDir string, branchPrefix string, sourceName string
 		return nil, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Create and checkout new branch
+	// Create and checkout new branch, or switch to the reused one keeping the current
+	// branch's files and index, so its new commit holds them with the remote files
 	branchRef := plumbing.NewBranchReferenceName(branchName)
-	err = worktree.Checkout(&git.CheckoutOptions{
-		Branch: branchRef,
-		Create: true,
-	})
+	if reused != nil {
+		err = worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Keep: true})
+	} else {
+		err = worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Create: true})
+	}
 	if err != nil {
+		if reused != nil {
+			_ = worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()})
+			return nil, fmt.Errorf("failed to switch to branch %s: %w", branchName, err)
+		}
 		return nil, fmt.Errorf("failed to create branch %s: %w", branchName, err)
 	}
 
 	// Write remote files to the branch
 	var committedFiles []string
-	for relPath, content := range files {
+	for _, relPath := range relPaths {
+		content := files[relPath]
 		fullPath := filepath.Join(workDir, relPath)
 
 		// Ensure directory exists
@@ -72,7 +137,12 @@ func CreateConflictBranch(workDir string, branchPrefix string, sourceName string
 		}
 
 		// Stage the file
-		if _, addErr := worktree.Add(relPath); addErr != nil {
+		repoPath, err := repoRelativePath(root, workDir, relPath)
+		if err != nil {
+			_ = worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()})
+			return nil, fmt.Errorf("failed to stage file %s: %w", relPath, err)
+		}
+		if _, addErr := worktree.Add(repoPath); addErr != nil {
 			_ = worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()})
 			return nil, fmt.Errorf("failed to stage file %s: %w", relPath, addErr)
 		}
@@ -81,14 +151,9 @@ func CreateConflictBranch(workDir string, branchPrefix string, sourceName string
 	}
 
 	// Create commit with remote changes
-	commitMessage := fmt.Sprintf("cherry-go: remote changes from %s\n\nThis branch contains the remote changes that conflicted with local modifications.\nUse 'git merge %s' from your original branch to resolve conflicts.", sourceName, branchName)
-
-	_, err = worktree.Commit(commitMessage, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "cherry-go",
-			Email: "cherry-go@local",
-			When:  time.Now(),
-		},
+	author := commitSignature(repo, options, time.Now())
+	_, err = worktree.Commit(withCoAuthorTrailer(commitMessage, options, author), &git.CommitOptions{
+		Author: author,
 	})
 	if err != nil {
 		_ = worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()})
@@ -107,21 +172,66 @@ func CreateConflictBranch(workDir string, branchPrefix string, sourceName string
 		BranchName:     branchName,
 		OriginalBranch: originalBranch,
 		FilesCommitted: committedFiles,
+		CommitMessage:  commitMessage,
+		Reused:         reused != nil,
 	}, nil
 }
 
+// openConflictBranch returns the source's newest conflict branch when it hasn't been
+// merged into head yet, i.e. its tip isn't an ancestor of head, or nil to create one
+func openConflictBranch(repo *git.Repository, head *plumbing.Reference, branchPrefix, sourceName string) (*plumbing.Reference, error) {
+	branches, err := sourceConflictBranches(repo, branchPrefix, sourceName)
+	if err != nil || len(branches) == 0 {
+		return nil, err
+	}
+
+	// Timestamps sort in name order
+	newest := branches[len(branches)-1]
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(newest), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch %s: %w", newest, err)
+	}
+	tip, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch %s: %w", newest, err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	merged, err := tip.IsAncestor(headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether %s was merged: %w", newest, err)
+	}
+	if merged {
+		logger.Debug("Conflict branch %s was merged already; creating a new one", newest)
+		return nil, nil
+	}
+	return ref, nil
+}
+
 // GetMergeInstructions generates instructions for manual merge resolution
 func GetMergeInstructions(result *ConflictBranchResult) string {
 	var sb strings.Builder
 
 	sb.WriteString("\n")
-	sb.WriteString("⚠️  Merge Conflicts - Remote changes saved to branch\n\n")
+	switch {
+	case result.Planned && result.Reused:
+		sb.WriteString("⚠️  Merge Conflicts - Remote changes would be added to the unmerged branch (dry run)\n\n")
+	case result.Planned:
+		sb.WriteString("⚠️  Merge Conflicts - Remote changes would be saved to branch (dry run)\n\n")
+	case result.Reused:
+		sb.WriteString("⚠️  Merge Conflicts - Remote changes added to the unmerged branch\n\n")
+	default:
+		sb.WriteString("⚠️  Merge Conflicts - Remote changes saved to branch\n\n")
+	}
 	sb.WriteString(fmt.Sprintf("Branch: %s\n", result.BranchName))
 
 	if len(result.FilesCommitted) > 0 {
 		sb.WriteString("\nFiles with conflicts:\n")
 		for _, file := range result.FilesCommitted {
-			sb.WriteString(fmt.Sprintf("  • %s\n", file))
+			sb.WriteString(fmt.Sprintf("  • %s\n", utils.DisplayPath(file)))
 		}
 	}
 
@@ -131,6 +241,8 @@ func GetMergeInstructions(result *ConflictBranchResult) string {
 	sb.WriteString(fmt.Sprintf("  git diff %s              # Review changes\n", result.BranchName))
 	sb.WriteString(fmt.Sprintf("  git merge %s             # Merge when ready\n", result.BranchName))
 	sb.WriteString(fmt.Sprintf("  git branch -d %s   # Delete branch after merge\n", result.BranchName))
+	sb.WriteString("\nOr let cherry-go guide you through the merge, the conflicts and the cleanup:\n\n")
+	sb.WriteString(fmt.Sprintf("  cherry-go resolve %s\n", result.BranchName))
 	sb.WriteString("\n")
 
 	return sb.String()
@@ -138,7 +250,7 @@ func GetMergeInstructions(result *ConflictBranchResult) string {
 
 // DeleteConflictBranch deletes a conflict branch after successful resolution
 func DeleteConflictBranch(workDir string, branchName string) error {
-	repo, err := git.PlainOpen(workDir)
+	repo, _, err := openProjectRepository(workDir)
 	if err != nil {
 		return fmt.Errorf("failed to open repository: %w", err)
 	}
@@ -154,11 +266,15 @@ func DeleteConflictBranch(workDir string, branchName string) error {
 
 // ListConflictBranches lists all conflict branches matching the given prefix
 func ListConflictBranches(workDir string, branchPrefix string) ([]string, error) {
-	repo, err := git.PlainOpen(workDir)
+	repo, _, err := openProjectRepository(workDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
+	return conflictBranches(repo, branchPrefix)
+}
 
+// conflictBranches lists the branches of repo matching the given prefix
+func conflictBranches(repo *git.Repository, branchPrefix string) ([]string, error) {
 	branches, err := repo.Branches()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list branches: %w", err)
@@ -180,6 +296,44 @@ func ListConflictBranches(workDir string, branchPrefix string) ([]string, error)
 	return conflictBranches, nil
 }
 
+// ListSourceConflictBranches lists the conflict branches created for a single source
+func ListSourceConflictBranches(workDir string, branchPrefix string, sourceName string) ([]string, error) {
+	repo, _, err := openProjectRepository(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return sourceConflictBranches(repo, branchPrefix, sourceName)
+}
+
+// sourceConflictBranches lists the conflict branches of repo created for a source, sorted
+// by name and so oldest first
+func sourceConflictBranches(repo *git.Repository, branchPrefix string, sourceName string) ([]string, error) {
+	branches, err := conflictBranches(repo, branchPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Branch names follow <prefix>/<source>-<timestamp>
+	sourcePrefix := fmt.Sprintf("%s/%s-", branchPrefix, sourceName)
+
+	var sourceBranches []string
+	for _, branch := range branches {
+		// Require the timestamp suffix so "lib" doesn't match branches of "lib-docs"
+		if strings.HasPrefix(branch, sourcePrefix) && isBranchTimestamp(strings.TrimPrefix(branch, sourcePrefix)) {
+			sourceBranches = append(sourceBranches, branch)
+		}
+	}
+
+	sort.Strings(sourceBranches)
+	return sourceBranches, nil
+}
+
+// isBranchTimestamp reports whether s matches the 20060102-150405 timestamp used in branch names
+func isBranchTimestamp(s string) bool {
+	_, err := time.Parse("20060102-150405", s)
+	return err == nil
+}
+
 // DeleteAllConflictBranches deletes all conflict branches matching the given prefix
 func DeleteAllConflictBranches(workDir string, branchPrefix string) ([]string, error) {
 	branches, err := ListConflictBranches(workDir, branchPrefix)
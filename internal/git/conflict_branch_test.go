@@ -11,6 +11,10 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
 )
 
 func TestGetMergeInstructions(t *testing.T) {
@@ -40,6 +44,10 @@ func TestGetMergeInstructions(t *testing.T) {
 	if !strings.Contains(instructions, "git branch -d") {
 		t.Error("Instructions should explain how to delete conflict branch")
 	}
+
+	if !strings.Contains(instructions, "cherry-go resolve "+result.BranchName) {
+		t.Error("Instructions should offer cherry-go resolve")
+	}
 }
 
 // Integration test - requires git to be installed
@@ -97,7 +105,7 @@ func TestCreateConflictBranch_Integration(t *testing.T) {
 		"new_file.txt": []byte("new file content\n"),
 	}
 
-	result, err := CreateConflictBranch(tempDir, "cherry-go/sync", "test-source", files)
+	result, err := CreateConflictBranch(tempDir, "cherry-go/sync", "test-source", files, config.SyncOptions{})
 	if err != nil {
 		t.Fatalf("CreateConflictBranch failed: %v", err)
 	}
@@ -159,7 +167,7 @@ func TestCreateConflictBranch_NotGitRepo(t *testing.T) {
 		"file.txt": []byte("content"),
 	}
 
-	_, err = CreateConflictBranch(tempDir, "prefix", "source", files)
+	_, err = CreateConflictBranch(tempDir, "prefix", "source", files, config.SyncOptions{})
 	if err == nil {
 		t.Error("CreateConflictBranch should fail in non-git directory")
 	}
@@ -196,12 +204,12 @@ func TestListConflictBranches(t *testing.T) {
 	files := map[string][]byte{
 		"conflict1.txt": []byte("conflict 1"),
 	}
-	result1, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source1", files)
+	result1, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source1", files, config.SyncOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create conflict branch 1: %v", err)
 	}
 
-	result2, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source2", files)
+	result2, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source2", files, config.SyncOptions{})
 	if err != nil {
 		t.Fatalf("Failed to create conflict branch 2: %v", err)
 	}
@@ -276,10 +284,10 @@ func TestDeleteAllConflictBranches(t *testing.T) {
 	files := map[string][]byte{
 		"conflict.txt": []byte("conflict"),
 	}
-	if _, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source1", files); err != nil {
+	if _, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source1", files, config.SyncOptions{}); err != nil {
 		t.Fatalf("Failed to create conflict branch 1: %v", err)
 	}
-	if _, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source2", files); err != nil {
+	if _, err := CreateConflictBranch(tempDir, "cherry-go/sync", "source2", files, config.SyncOptions{}); err != nil {
 		t.Fatalf("Failed to create conflict branch 2: %v", err)
 	}
 
@@ -303,3 +311,110 @@ func TestDeleteAllConflictBranches(t *testing.T) {
 		t.Errorf("Expected 0 conflict branches after deletion, got %d", len(branches))
 	}
 }
+
+func TestIsBranchTimestamp(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"20241212-120000", true},
+		{"docs-20241212-120000", false},
+		{"20241212", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if result := isBranchTimestamp(tc.input); result != tc.expected {
+			t.Errorf("isBranchTimestamp(%q) = %t, expected %t", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestCreateConflictBranch_Reuse(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping integration test")
+	}
+	logger.Init()
+	project := testutil.NewProject(t)
+	project.WriteFile("file.txt", "local\n")
+	project.Commit("initial")
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test User", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = project.Dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	branchFile := func(branch, name string) (string, bool) {
+		t.Helper()
+		ref, err := project.Repo().Reference(plumbing.NewBranchReferenceName(branch), true)
+		if err != nil {
+			t.Fatalf("Failed to read branch %s: %v", branch, err)
+		}
+		commit, err := project.Repo().CommitObject(ref.Hash())
+		if err != nil {
+			t.Fatalf("Failed to read the tip of %s: %v", branch, err)
+		}
+		file, err := commit.File(name)
+		if err != nil {
+			return "", false
+		}
+		content, _ := file.Contents()
+		return content, true
+	}
+
+	options := config.SyncOptions{ConflictBranchMode: config.ConflictBranchReuse}
+	create := func(files map[string][]byte) *ConflictBranchResult {
+		t.Helper()
+		result, err := CreateConflictBranch(project.Dir, "cherry-go/sync", "lib", files, options)
+		if err != nil {
+			t.Fatalf("CreateConflictBranch failed: %v", err)
+		}
+		return result
+	}
+
+	first := create(map[string][]byte{"file.txt": []byte("remote v1\n"), "old.txt": []byte("only conflicted once\n")})
+	if first.Reused {
+		t.Fatalf("Expected the first branch to be created, got %+v", first)
+	}
+	// An older timestamp, so the branch created after the merge below gets another name
+	branch := "cherry-go/sync/lib-20240101-000000"
+	runGit("branch", "-m", first.BranchName, branch)
+
+	second := create(map[string][]byte{"file.txt": []byte("remote v2\n")})
+	if !second.Reused || second.BranchName != branch {
+		t.Fatalf("Expected %s to be reused, got %+v", branch, second)
+	}
+	if !strings.Contains(GetMergeInstructions(second), "added to the unmerged branch") {
+		t.Errorf("Expected the instructions to say the branch was reused, got:\n%s", GetMergeInstructions(second))
+	}
+	if content, _ := branchFile(branch, "file.txt"); content != "remote v2\n" {
+		t.Errorf("Expected the reused branch to hold the latest remote content, got %q", content)
+	}
+	if _, ok := branchFile(branch, "old.txt"); ok {
+		t.Error("Expected files that no longer conflict to be reset to the current branch")
+	}
+	if branches, _ := ListSourceConflictBranches(project.Dir, "cherry-go/sync", "lib"); len(branches) != 1 {
+		t.Errorf("Expected a single conflict branch, got %v", branches)
+	}
+	if got := project.ReadFile("file.txt"); got != "local\n" {
+		t.Errorf("Expected the working tree to be back on the original branch, got %q", got)
+	}
+
+	// Its first commit stays: the new one is added on top
+	ref, _ := project.Repo().Reference(plumbing.NewBranchReferenceName(branch), true)
+	tip, _ := project.Repo().CommitObject(ref.Hash())
+	if parent, err := tip.Parent(0); err != nil || parent.NumParents() != 1 {
+		t.Errorf("Expected the reused branch to get a new commit on top of its first one, got %v", err)
+	}
+
+	runGit("merge", "--no-edit", "-X", "theirs", branch)
+	third := create(map[string][]byte{"file.txt": []byte("remote v3\n")})
+	if third.Reused || third.BranchName == branch {
+		t.Fatalf("Expected a new branch once %s was merged, got %+v", branch, third)
+	}
+	if branches, _ := ListSourceConflictBranches(project.Dir, "cherry-go/sync", "lib"); len(branches) != 2 || branches[1] != third.BranchName {
+		t.Errorf("Expected the new branch next to the merged one, got %v", branches)
+	}
+}
@@ -0,0 +1,52 @@
+package git
+
+import "sync"
+
+// DefaultMaxConflicts is how many conflicting files a run shows in detail by default
+const DefaultMaxConflicts = 20
+
+// conflictDisplay counts the conflicting files rendered in detail during a run, across
+// concurrently synced sources, so a runaway sync can't flood the terminal with diffs
+var conflictDisplay = struct {
+	sync.Mutex
+	limit     int
+	shown     map[string]bool
+	truncated bool
+}{limit: DefaultMaxConflicts}
+
+// SetMaxConflicts sets how many conflicting files are shown in detail (0 for no limit)
+// and starts a new count
+func SetMaxConflicts(limit int) {
+	conflictDisplay.Lock()
+	defer conflictDisplay.Unlock()
+	conflictDisplay.limit = limit
+	conflictDisplay.shown = nil
+	conflictDisplay.truncated = false
+}
+
+// ConflictDetailsTruncated reports whether conflicts went unrendered because of the limit
+func ConflictDetailsTruncated() bool {
+	conflictDisplay.Lock()
+	defer conflictDisplay.Unlock()
+	return conflictDisplay.truncated
+}
+
+// showConflictDetail reports whether the conflict in path may be rendered in detail.
+// A path already shown may be shown again; detection of hidden ones carries on as usual.
+func showConflictDetail(path string) bool {
+	conflictDisplay.Lock()
+	defer conflictDisplay.Unlock()
+
+	if conflictDisplay.shown[path] {
+		return true
+	}
+	if conflictDisplay.limit > 0 && len(conflictDisplay.shown) >= conflictDisplay.limit {
+		conflictDisplay.truncated = true
+		return false
+	}
+	if conflictDisplay.shown == nil {
+		conflictDisplay.shown = make(map[string]bool)
+	}
+	conflictDisplay.shown[path] = true
+	return true
+}
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShowConflictDetail(t *testing.T) {
+	SetMaxConflicts(3)
+	t.Cleanup(func() { SetMaxConflicts(DefaultMaxConflicts) })
+
+	for i := 1; i <= 3; i++ {
+		if !showConflictDetail(fmt.Sprintf("lib/f%d.go", i)) {
+			t.Fatalf("Expected conflict %d to be shown", i)
+		}
+	}
+	if ConflictDetailsTruncated() {
+		t.Fatal("Expected no truncation within the limit")
+	}
+	if !showConflictDetail("lib/f2.go") {
+		t.Error("Expected an already shown file to be shown again without using the limit")
+	}
+	if showConflictDetail("lib/f4.go") || !ConflictDetailsTruncated() {
+		t.Error("Expected the fourth file to be hidden and the output marked as truncated")
+	}
+
+	SetMaxConflicts(0)
+	for i := 1; i <= 50; i++ {
+		if !showConflictDetail(fmt.Sprintf("lib/f%d.go", i)) {
+			t.Fatalf("Expected no limit with 0, file %d was hidden", i)
+		}
+	}
+	if ConflictDetailsTruncated() {
+		t.Error("Expected SetMaxConflicts to start a new count")
+	}
+}
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ConflictBranchMerge is the state of merging a conflict branch into the current branch
+type ConflictBranchMerge struct {
+	Branch    string
+	Into      string   // The branch checked out when the merge started
+	Conflicts []string // Files still unmerged, relative to workDir; empty once the merge is clean
+	Resumed   bool     // The merge was already in progress, left by an earlier run
+	Committed bool     // The merge commit exists (or nothing needed committing)
+}
+
+// MergeConflictBranch merges a conflict branch into the current branch with the git CLI,
+// as the instructions printed after --branch-on-conflict suggest. A merge that conflicts
+// is left in progress for the files to be edited; calling it again for the same branch
+// picks that merge up instead of starting another. Any other merge in progress, or local
+// changes the merge would overwrite, fail it.
+func MergeConflictBranch(workDir, branchName string) (*ConflictBranchMerge, error) {
+	repo, root, err := openProjectRepository(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	branch, err := repo.Reference(plumbing.NewBranchReferenceName(branchName), true)
+	if err != nil {
+		return nil, fmt.Errorf("branch %s not found: %w", branchName, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head.Name() == branch.Name() {
+		return nil, fmt.Errorf("%s is checked out; switch to the branch it should be merged into", branchName)
+	}
+
+	result := &ConflictBranchMerge{Branch: branchName, Into: head.Name().Short()}
+
+	if mergeHead, inProgress := runGit(root, "rev-parse", "-q", "--verify", "MERGE_HEAD"); inProgress == nil {
+		if strings.TrimSpace(mergeHead) != branch.Hash().String() {
+			return nil, fmt.Errorf("another merge is in progress in %s; finish it or run 'git merge --abort' first", root)
+		}
+		result.Resumed = true
+	} else if output, mergeErr := runGit(root, "merge", "--no-edit", branchName); mergeErr != nil {
+		conflicts, err := unmergedFiles(root, workDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(conflicts) == 0 {
+			// Refused outright, e.g. local changes would be overwritten
+			return nil, fmt.Errorf("failed to merge %s: %s", branchName, strings.TrimSpace(output))
+		}
+	} else {
+		result.Committed = true
+		return result, nil
+	}
+
+	conflicts, err := unmergedFiles(root, workDir)
+	if err != nil {
+		return nil, err
+	}
+	result.Conflicts = conflicts
+	return result, nil
+}
+
+// StageResolvedFile marks a file of a merge in progress resolved, given relative to workDir
+func StageResolvedFile(workDir, path string) error {
+	_, root, err := openProjectRepository(workDir)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	repoPath, err := repoRelativePath(root, workDir, path)
+	if err != nil {
+		return err
+	}
+	if output, err := runGit(root, "add", "--", repoPath); err != nil {
+		return fmt.Errorf("failed to stage %s: %s", path, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// CommitMerge records the merge in progress once every file is resolved, with git's
+// default merge message
+func CommitMerge(workDir string) error {
+	_, root, err := openProjectRepository(workDir)
+	if err != nil {
+		return fmt.Errorf("failed to open repository: %w", err)
+	}
+	conflicts, err := unmergedFiles(root, workDir)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d file(s) still have conflicts: %s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	if output, err := runGit(root, "commit", "--no-edit"); err != nil {
+		return fmt.Errorf("failed to commit the merge: %s", strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// unmergedFiles lists the files of the merge in progress that still have conflicts,
+// relative to workDir
+func unmergedFiles(root, workDir string) ([]string, error) {
+	output, err := runGit(root, "-c", "core.quotepath=off", "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicted files: %s", strings.TrimSpace(output))
+	}
+	absDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		rel, err := filepath.Rel(absDir, filepath.Join(root, filepath.FromSlash(line)))
+		if err != nil {
+			rel = line
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+	return files, nil
+}
+
+// runGit runs the git CLI in dir and returns its combined output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.String(), err
+}
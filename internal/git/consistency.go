@@ -0,0 +1,44 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// checkTrackedFiles verifies that every file a directory sync is about to track exists in
+// the local directory, and returns the hashes without the ones that don't. A tracked file
+// that was never written would otherwise show up as deleted locally on every later sync.
+// Each discrepancy is a bug (the copy and hash code disagreeing about an exclude), so it is
+// logged as an internal warning naming the exclude pattern responsible, if any.
+func checkTrackedFiles(localPath string, hashes map[string]string, excludes config.Excludes) map[string]string {
+	var missing []string
+	for relPath := range hashes {
+		if _, err := os.Lstat(filepath.Join(localPath, relPath)); err != nil {
+			missing = append(missing, relPath)
+		}
+	}
+	if len(missing) == 0 {
+		return hashes
+	}
+
+	sort.Strings(missing)
+	checked := make(map[string]string, len(hashes)-len(missing))
+	for relPath, h := range hashes {
+		checked[relPath] = h
+	}
+	for _, relPath := range missing {
+		delete(checked, relPath)
+		if exclude := config.MatchingExclude(relPath, excludes); exclude != "" {
+			logger.Warning("Internal: %s would be tracked but was not written, it matches exclude %q; not tracking it",
+				filepath.ToSlash(filepath.Join(localPath, relPath)), exclude)
+		} else {
+			logger.Warning("Internal: %s would be tracked but was not written; not tracking it",
+				filepath.ToSlash(filepath.Join(localPath, relPath)))
+		}
+	}
+	return checked
+}
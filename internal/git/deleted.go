@@ -0,0 +1,105 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// deletedUpstream returns the tracked files of a directory that are gone upstream but still
+// present locally, sorted. Excluded files and the old names of followed renames are skipped.
+func deletedUpstream(pathSpec config.PathSpec, sourcePath, localPath string, renamedFrom map[string]string) []string {
+	renamed := make(map[string]bool, len(renamedFrom))
+	for _, oldRel := range renamedFrom {
+		renamed[oldRel] = true
+	}
+
+	var deleted []string
+	for relPath := range pathSpec.Files {
+		if renamed[relPath] || shouldExclude(relPath, pathSpec.Excludes) {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(sourcePath, relPath)); err == nil {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(localPath, relPath)); err != nil {
+			continue // Already gone locally
+		}
+		deleted = append(deleted, relPath)
+	}
+
+	sort.Strings(deleted)
+	return deleted
+}
+
+// applyUpstreamDeletions deletes the local copies of files removed upstream from a tracked
+// directory. A file edited since the last sync is never deleted: it is reported as a
+// removed-upstream conflict and keeps its tracking entry, so it is reported again until
+// resolved. Detect mode reports every deletion instead of applying it; the other modes
+// apply them only once the rest of the directory synced cleanly. It returns the conflicts
+// and the deletions made (or planned, in dry-run), and updates newHashes to match.
+func (r *Repository) applyUpstreamDeletions(input processPathInput, newHashes map[string]string, clean bool) ([]hash.FileConflict, []FileAction) {
+	deleted := deletedUpstream(input.pathSpec, input.sourcePath, input.localPath, input.renamedFrom)
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+
+	var conflicts []hash.FileConflict
+	var applied []FileAction
+	for _, relPath := range deleted {
+		localFile := filepath.Join(input.localPath, relPath)
+		expected := input.pathSpec.Files[relPath]
+
+		actual, unchanged, err := input.hasher.Matches(localFile, expected)
+		if err != nil {
+			r.recordFailure(input, filepath.Join(input.sourcePath, relPath), err)
+			continue
+		}
+		if unchanged {
+			actual = expected // Matched an entry hashed without a final newline
+		}
+
+		if actual != expected || input.mode == SyncModeDetect {
+			if actual != expected {
+				logger.Warning("⚠️  %s was removed upstream but has local changes - keeping it", r.projectPath(localFile))
+			} else {
+				logger.Warning("⚠️  %s was removed upstream", r.projectPath(localFile))
+			}
+			conflicts = append(conflicts, hash.FileConflict{
+				Path:         relPath,
+				Type:         hash.ConflictTypeRemovedUpstream,
+				ExpectedHash: expected,
+				ActualHash:   actual,
+			})
+			if newHashes != nil {
+				newHashes[relPath] = expected
+			}
+			continue
+		}
+
+		if !clean || newHashes == nil || r.checkWrite(localFile) != nil {
+			continue
+		}
+
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would delete %s (removed upstream)", r.projectPath(localFile))
+			applied = append(applied, FileAction{Type: FileActionDeleted, Path: localFile})
+			continue
+		}
+
+		if err := os.Remove(localFile); err != nil {
+			r.recordFailure(input, filepath.Join(input.sourcePath, relPath), err)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(localFile), input.localPath)
+		delete(newHashes, relPath)
+		logger.Info("🗑  Deleted %s (removed upstream)", r.projectPath(localFile))
+		applied = append(applied, FileAction{Type: FileActionDeleted, Path: localFile})
+	}
+
+	return conflicts, applied
+}
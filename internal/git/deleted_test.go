@@ -0,0 +1,149 @@
+package git
+
+import (
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// newDeletionFixture syncs lib/ with a.go, b.go and nested/c.go, then removes b.go and
+// nested/c.go upstream
+func newDeletionFixture(t *testing.T) (*testutil.Project, *config.Source) {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\n")
+	upstream.WriteFile("lib/b.go", "b\n")
+	upstream.WriteFile("lib/nested/c.go", "c\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	upstream.RemoveFile("lib/b.go")
+	upstream.RemoveFile("lib/nested/c.go")
+	upstream.Commit("remove b and c")
+	return project, source
+}
+
+func TestCopyPaths_DeletesFilesRemovedUpstream(t *testing.T) {
+	modes := map[string]SyncMode{"merge": SyncModeMerge, "force": SyncModeForce}
+	for name, mode := range modes {
+		t.Run(name, func(t *testing.T) {
+			project, source := newDeletionFixture(t)
+
+			result := syncFixture(t, source, mode, project.Dir)
+			if project.Exists("lib/b.go") || project.Exists("lib/nested/c.go") {
+				t.Error("Expected the files removed upstream to be deleted locally")
+			}
+			if project.Exists("lib/nested") {
+				t.Error("Expected the directory left empty to be removed")
+			}
+			if len(result.Conflicts) != 0 {
+				t.Errorf("Expected no conflicts, got %v", result.Conflicts)
+			}
+			if len(result.UpdatedPaths) != 1 || result.UpdatedPaths[0] != "lib/" {
+				t.Errorf("Expected lib/ to be reported as updated, got %v", result.UpdatedPaths)
+			}
+
+			deleted := 0
+			for _, action := range result.FileActions {
+				if action.Type == FileActionDeleted {
+					deleted++
+				}
+			}
+			if deleted != 2 {
+				t.Errorf("Expected 2 deleted file actions, got %+v", result.FileActions)
+			}
+
+			files := source.Paths[0].Files
+			if _, ok := files["b.go"]; ok {
+				t.Errorf("Expected b.go to be dropped from tracking, got %v", files)
+			}
+			if _, ok := files["a.go"]; !ok {
+				t.Errorf("Expected a.go to stay tracked, got %v", files)
+			}
+		})
+	}
+}
+
+func TestCopyPaths_KeepsModifiedFileRemovedUpstream(t *testing.T) {
+	project, source := newDeletionFixture(t)
+	project.WriteFile("lib/b.go", "b with local edits\n")
+
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	if !project.Exists("lib/b.go") {
+		t.Fatal("Expected the locally modified file to be kept")
+	}
+	if project.Exists("lib/nested/c.go") {
+		t.Error("Expected the unmodified file to be deleted")
+	}
+
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected one conflict, got %v", result.Conflicts)
+	}
+	conflict := result.Conflicts[0]
+	if conflict.Type != hash.ConflictTypeRemovedUpstream || conflict.LocalPath != "lib/b.go" {
+		t.Errorf("Expected a removed-upstream conflict on lib/b.go, got %+v", conflict)
+	}
+	if _, ok := source.Paths[0].Files["b.go"]; !ok {
+		t.Error("Expected the kept file to stay tracked so the conflict is reported again")
+	}
+
+	// Still reported on the next sync
+	result = syncFixture(t, source, SyncModeForce, project.Dir)
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Type != hash.ConflictTypeRemovedUpstream {
+		t.Errorf("Expected the conflict to be reported again, got %v", result.Conflicts)
+	}
+}
+
+func TestCopyPaths_DetectReportsFilesRemovedUpstream(t *testing.T) {
+	project, source := newDeletionFixture(t)
+
+	result := syncFixture(t, source, SyncModeDetect, project.Dir)
+	if !project.Exists("lib/b.go") || !project.Exists("lib/nested/c.go") {
+		t.Error("Expected detect mode to leave the files in place")
+	}
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("Expected two conflicts, got %v", result.Conflicts)
+	}
+	for _, conflict := range result.Conflicts {
+		if conflict.Type != hash.ConflictTypeRemovedUpstream {
+			t.Errorf("Expected removed-upstream conflicts, got %+v", conflict)
+		}
+	}
+	if len(result.UpdatedPaths) != 0 {
+		t.Errorf("Expected nothing updated in detect mode, got %v", result.UpdatedPaths)
+	}
+}
+
+func TestDiffLocalFiles_ReportsDeletions(t *testing.T) {
+	before := map[string]localFile{
+		"lib/a.go":    {hash: "a", size: 2, content: []byte("a\n")},
+		"lib/old.go":  {hash: "o", size: 4, content: []byte("old\n")},
+		"lib/gone.go": {hash: "g", size: 10, content: []byte("one\ntwo\nx\n")},
+	}
+	after := map[string]localFile{
+		"lib/a.go":   {hash: "a", size: 2, content: []byte("a\n")},
+		"lib/new.go": {hash: "o", size: 4, content: []byte("old\n")},
+	}
+	renames := []FileAction{{Type: FileActionRenamed, Path: "lib/new.go", OldPath: "lib/old.go", Similarity: 1}}
+
+	actions := diffLocalFiles(before, after, renames)
+	if len(actions) != 2 {
+		t.Fatalf("Expected a deletion and a rename, got %+v", actions)
+	}
+	if actions[0].Type != FileActionDeleted || actions[0].Path != "lib/gone.go" || actions[0].Removed != 3 {
+		t.Errorf("Expected lib/gone.go deleted with 3 removed lines, got %+v", actions[0])
+	}
+	if actions[1].Type != FileActionRenamed {
+		t.Errorf("Expected the rename source not to be reported as deleted, got %+v", actions[1])
+	}
+}
@@ -0,0 +1,160 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+)
+
+// FileDiff is a tracked file whose local copy differs from the latest upstream version.
+// Action says what a forced sync would do to it: add it (new upstream or missing
+// locally), update it, or delete it (removed upstream), with line statistics.
+type FileDiff struct {
+	Action FileAction
+	Local  []byte // nil when the file doesn't exist locally
+	Remote []byte // nil when the file was removed upstream
+}
+
+// Diff compares the local copies of the source's tracked paths with their latest
+// upstream versions, sorted by local path. Upstream is read from the cached clone's
+// remote-tracking branches (or each path's pinned commit), so fetch first; neither the
+// cache checkout nor the project's files are changed. Local files a directory doesn't
+// track and excluded files are left out.
+func (r *Repository) Diff(workDir string) ([]FileDiff, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("repository not available")
+	}
+
+	var diffs []FileDiff
+	for _, pathSpec := range r.source.Paths {
+		pathDiffs, err := r.diffPath(workDir, pathSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pathSpec.Include, err)
+		}
+		diffs = append(diffs, pathDiffs...)
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].Action.Path < diffs[j].Action.Path })
+	return diffs, nil
+}
+
+// latestPathCommit is the upstream commit a sync would read a path from: its pin, or the
+// fetched tip of its branch
+func (r *Repository) latestPathCommit(pathSpec config.PathSpec) (plumbing.Hash, error) {
+	branch, err := r.PathRef(pathSpec)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if branch == "" {
+		branch = r.detectDefaultBranch()
+	}
+	return r.resolveRemoteRef(branch)
+}
+
+// pathKind reports whether a tracked path is a directory, judging by its spec and its
+// local copy, and the excludes its files are matched against
+func (r *Repository) pathKind(workDir string, pathSpec config.PathSpec) (isDir bool, excludes config.Excludes) {
+	info, statErr := os.Stat(filepath.Join(workDir, pathSpec.LocalRoot()))
+	isDir = pathSpec.IsPattern() || config.CanonicalPath(pathSpec.Include, true) == pathSpec.Include || (statErr == nil && info.IsDir())
+
+	excludes = config.Excludes{Patterns: pathSpec.Exclude}
+	if isDir {
+		excludes = r.options.PathExcludes(r.source, pathSpec)
+	}
+	return isDir, excludes
+}
+
+// diffPath compares one tracked path with its upstream version
+func (r *Repository) diffPath(workDir string, pathSpec config.PathSpec) ([]FileDiff, error) {
+	commit, err := r.latestPathCommit(pathSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	localRoot := pathSpec.LocalRoot()
+	isDir, excludes := r.pathKind(workDir, pathSpec)
+
+	atCommit := pathSpec
+	atCommit.Commit = commit.String()
+	upstream, err := upstreamFiles(r.repo, atCommit, isDir, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(upstream)+len(pathSpec.Files))
+	for key := range upstream {
+		keys[key] = true
+	}
+	for key := range pathSpec.Files {
+		if !isDir || !shouldExclude(key, excludes) {
+			keys[key] = true
+		}
+	}
+
+	var diffs []FileDiff
+	for key := range keys {
+		localPath := localRoot
+		if isDir {
+			localPath = filepath.Join(localRoot, key)
+		}
+
+		// Upstream trees store links by target, as directories sync them
+		local, _, err := readEntry(filepath.Join(workDir, localPath), !isDir || r.options.FollowSymlinks)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		localExists := err == nil
+
+		var remote []byte
+		file, remoteExists := upstream[key]
+		if remoteExists {
+			if remote, err = readTreeFile(file); err != nil {
+				return nil, fmt.Errorf("failed to read %s upstream: %w", key, err)
+			}
+		}
+
+		action := FileAction{Path: localPath}
+		switch {
+		case remoteExists && !localExists:
+			action.Type = FileActionAdded
+		case !remoteExists && localExists:
+			action.Type = FileActionDeleted
+		case remoteExists && !bytes.Equal(local, remote):
+			action.Type = FileActionUpdated
+		default:
+			continue // Unchanged, or gone on both sides
+		}
+
+		fillStats(&action, contentFile(local), contentFile(remote))
+		diffs = append(diffs, FileDiff{Action: action, Local: local, Remote: remote})
+	}
+	return diffs, nil
+}
+
+// readTreeFile returns the contents of a file from a commit's tree
+func readTreeFile(file *object.File) ([]byte, error) {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = reader.Close() }()
+	return io.ReadAll(reader)
+}
+
+// contentFile describes content for fillStats, dropping it when over maxStatFileSize
+func contentFile(content []byte) localFile {
+	file := localFile{size: int64(len(content))}
+	if file.size <= maxStatFileSize {
+		file.content = content
+	}
+	return file
+}
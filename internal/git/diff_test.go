@@ -0,0 +1,116 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// diffFixture fetches the source's repository and diffs the project against it
+func diffFixture(t *testing.T, source *config.Source, workDir string) []FileDiff {
+	t.Helper()
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Fetch(context.Background()); err != nil {
+		t.Fatalf("Failed to fetch: %v", err)
+	}
+	diffs, err := repo.Diff(workDir)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	return diffs
+}
+
+func TestDiff_AddedRemovedAndModified(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\n")
+	upstream.WriteFile("lib/b.go", "b\n")
+	upstream.WriteFile("lib/same.go", "same\n")
+	upstream.WriteFile("README.md", "# Library\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "lib/"},
+		{Include: "README.md", LocalPath: "docs/library.md"},
+	}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	if diffs := diffFixture(t, source, project.Dir); len(diffs) != 0 {
+		t.Fatalf("Expected no differences right after a sync, got %+v", diffs)
+	}
+
+	upstream.WriteFile("lib/a.go", "a\nmore\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.WriteFile("lib/c.go", "c\n")
+	upstream.WriteFile("README.md", "# Library v2\n")
+	upstream.Commit("change everything")
+	project.WriteFile("lib/notes.txt", "untracked local notes\n")
+
+	diffs := diffFixture(t, source, project.Dir)
+	expected := []struct {
+		path             string
+		kind             FileActionType
+		added, removed   int
+		hasLocal, hasRem bool
+	}{
+		{filepath.Join("docs", "library.md"), FileActionUpdated, 1, 1, true, true},
+		{filepath.Join("lib", "a.go"), FileActionUpdated, 1, 0, true, true},
+		{filepath.Join("lib", "b.go"), FileActionDeleted, 0, 1, true, false},
+		{filepath.Join("lib", "c.go"), FileActionAdded, 1, 0, false, true},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %d differences, got %+v", len(expected), diffs)
+	}
+	for i, want := range expected {
+		got := diffs[i]
+		if got.Action.Path != want.path || got.Action.Type != want.kind {
+			t.Errorf("Difference %d: expected %s %s, got %s %s", i, want.kind, want.path, got.Action.Type, got.Action.Path)
+		}
+		if got.Action.Added != want.added || got.Action.Removed != want.removed {
+			t.Errorf("%s: expected +%d -%d, got +%d -%d", want.path, want.added, want.removed, got.Action.Added, got.Action.Removed)
+		}
+		if (got.Local != nil) != want.hasLocal || (got.Remote != nil) != want.hasRem {
+			t.Errorf("%s: unexpected contents local=%q remote=%q", want.path, got.Local, got.Remote)
+		}
+	}
+
+	// The project is untouched
+	if project.ReadFile("lib/a.go") != "a\n" || !project.Exists("lib/b.go") || project.Exists("lib/c.go") {
+		t.Error("Expected diff not to change the local files")
+	}
+}
+
+func TestDiff_ExcludedAndMissingLocalFiles(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\n")
+	upstream.WriteFile("lib/gen/out.go", "generated\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Exclude: []string{"gen"}}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	// A tracked file deleted locally shows as added back by a sync
+	if err := os.Remove(filepath.Join(project.Dir, "lib", "a.go")); err != nil {
+		t.Fatal(err)
+	}
+	upstream.WriteFile("lib/gen/out.go", "regenerated\n")
+	upstream.Commit("regenerate")
+
+	diffs := diffFixture(t, source, project.Dir)
+	if len(diffs) != 1 || diffs[0].Action.Type != FileActionAdded || diffs[0].Action.Path != filepath.Join("lib", "a.go") {
+		t.Errorf("Expected only lib/a.go as added back, got %+v", diffs)
+	}
+}
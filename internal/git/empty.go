@@ -0,0 +1,68 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// errFileFound stops a walk at the first file
+var errFileFound = errors.New("file found")
+
+// EmptyPathError reports a tracked directory that has no files left once excludes apply
+type EmptyPathError struct {
+	Include string
+}
+
+func (e *EmptyPathError) Error() string {
+	return fmt.Sprintf("0 files matched include '%s' after excludes — check your patterns", e.Include)
+}
+
+// SetAllowEmpty lets directories that match no files sync (as empty) for this run
+func (r *Repository) SetAllowEmpty(allow bool) {
+	r.allowEmpty = allow
+}
+
+// checkDirectoryFiles walks the upstream files of a directory once, before anything is
+// copied. It refuses a directory with no files left after excludes, since an overly broad
+// exclude otherwise records an empty path and reports success, and one whose files add up
+// to more than options.max_path_size. Without a budget the walk stops at the first file.
+// It returns false when the path must be skipped.
+func (r *Repository) checkDirectoryFiles(include, sourcePath string, excludes config.Excludes) bool {
+	measure := r.options.MaxPathSize.Bytes() > 0
+	files, size := 0, int64(0)
+	err := walkSourceFiles(sourcePath, excludes, func(path, relPath string, info os.FileInfo) error {
+		files++
+		size += info.Size()
+		if !measure {
+			return errFileFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errFileFound) {
+		// A walk error that the copy reports with more detail
+		return true
+	}
+
+	if files == 0 {
+		return r.reportEmpty(include)
+	}
+	return r.checkPathSize(include, size)
+}
+
+// reportEmpty records an include left without files, unless --allow-empty lets it sync
+// empty. It returns whether the sync of the path may go ahead.
+func (r *Repository) reportEmpty(include string) bool {
+	if r.allowEmpty {
+		logger.Warning("⚠️  %s matches no files after excludes; syncing it empty (--allow-empty)", include)
+		return true
+	}
+
+	emptyErr := &EmptyPathError{Include: include}
+	logger.Error("✗ %v", emptyErr)
+	r.empty = append(r.empty, emptyErr)
+	return false
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_DirectoryExcludedToNothing(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib\n")
+	upstream.WriteFile("lib/b.go", "package lib\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	syncFixture(t, source, SyncModeForce, project.Dir)
+	synced := source.Paths[0]
+
+	// An exclude that swallows every file must not look like an empty upstream directory
+	source.Paths[0].Exclude = []string{"*.go"}
+	upstream.WriteFile("lib/a.go", "package lib // v2\n")
+	upstream.Commit("v2")
+
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	if len(result.Empty) != 1 || result.Empty[0].Include != "lib/" {
+		t.Fatalf("Expected lib/ to be reported as matching nothing, got %v", result.Empty)
+	}
+	if len(result.UpdatedPaths) > 0 {
+		t.Errorf("Expected nothing to be synced, got %v", result.UpdatedPaths)
+	}
+	if got := project.ReadFile("lib/a.go"); got != "package lib\n" {
+		t.Errorf("Expected the local files to be left alone, got %q", got)
+	}
+	if source.Paths[0].Commit != synced.Commit || len(source.Paths[0].Files) != len(synced.Files) {
+		t.Errorf("Expected tracking to be left alone, got %+v", source.Paths[0])
+	}
+}
+
+func TestCopyPaths_AllowEmptyDirectory(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("fixtures/.gitkeep", "")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "fixtures/", Exclude: []string{".gitkeep"}}},
+	}
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	repo.SetAllowEmpty(true)
+	result, err := repo.CopyPaths(SyncModeForce, project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	if len(result.Empty) > 0 {
+		t.Errorf("Expected --allow-empty to sync the empty directory, got %v", result.Empty)
+	}
+	if source.Paths[0].Commit == "" || len(source.Paths[0].Files) != 0 {
+		t.Errorf("Expected the empty directory to be tracked as synced, got %+v", source.Paths[0])
+	}
+}
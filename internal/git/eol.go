@@ -0,0 +1,129 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+)
+
+// contentFilter turns content read upstream into what is written to localPath
+type contentFilter func(localPath string, content []byte) []byte
+
+// projectAttributes is the project's top-level .gitattributes, which decides line
+// endings under options.eol: auto
+type projectAttributes struct {
+	root    string // Project root the patterns are relative to
+	matcher gitattributes.Matcher
+}
+
+// loadProjectAttributes reads workDir's .gitattributes; a project without one, or with
+// one that can't be read, leaves line endings to the local files
+func loadProjectAttributes(workDir string) *projectAttributes {
+	file, err := os.Open(filepath.Join(workDir, ".gitattributes"))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	patterns, err := gitattributes.ReadAttributes(file, nil, true)
+	if err != nil {
+		logger.Warning("⚠️  Ignoring .gitattributes for options.eol: %v", err)
+		return nil
+	}
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil
+	}
+	return &projectAttributes{root: root, matcher: gitattributes.NewMatcher(patterns)}
+}
+
+// lineEnding returns the line ending .gitattributes gives localPath: "\n" or "\r\n" for
+// eol=lf or eol=crlf, and empty for files it marks binary or -text, which are written
+// as they are. ok is false when it says nothing about line endings for the file.
+func (a *projectAttributes) lineEnding(localPath string) (eol string, ok bool) {
+	if a == nil {
+		return "", false
+	}
+	path, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(a.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	if binary, found := a.attribute(segments, "binary"); found && binary.IsSet() {
+		return "", true
+	}
+	if text, found := a.attribute(segments, "text"); found && text.IsUnset() {
+		return "", true
+	}
+	if eol, found := a.attribute(segments, "eol"); found && eol.IsValueSet() {
+		switch eol.Value() {
+		case "lf":
+			return "\n", true
+		case "crlf":
+			return "\r\n", true
+		}
+	}
+	return "", false
+}
+
+// attribute looks up one attribute of a path. The matcher only returns the last
+// pattern's value, as git does, when asked for a single attribute at a time.
+func (a *projectAttributes) attribute(segments []string, name string) (gitattributes.Attribute, bool) {
+	attributes, _ := a.matcher.Match(segments, []string{name})
+	attribute, found := attributes[name]
+	return attribute, found
+}
+
+// lineEnding returns the line ending options.eol gives a file written to localPath,
+// "\n" or "\r\n", or empty to write it as upstream has it. Under auto, .gitattributes
+// decides, then the ending the local file already uses.
+func (r *Repository) lineEnding(localPath string) string {
+	switch r.options.EOLPolicy() {
+	case config.EOLLF:
+		return "\n"
+	case config.EOLCRLF:
+		return "\r\n"
+	case config.EOLNative:
+		if runtime.GOOS == "windows" {
+			return "\r\n"
+		}
+		return "\n"
+	case config.EOLAuto:
+		if eol, ok := r.attributes.lineEnding(localPath); ok {
+			return eol
+		}
+		if existing, err := os.ReadFile(localPath); err == nil {
+			return merge.LineEnding(existing)
+		}
+	}
+	return ""
+}
+
+// withLineEnding returns content to write to localPath with options.eol applied
+func (r *Repository) withLineEnding(localPath string, content []byte) []byte {
+	if eol := r.lineEnding(localPath); eol != "" {
+		return merge.WithLineEndings(content, eol)
+	}
+	return content
+}
+
+// writeFilter returns the filter copies go through for options.eol, nil when files
+// are copied as they are
+func (r *Repository) writeFilter() contentFilter {
+	if r.options.EOLPolicy() == "" {
+		return nil
+	}
+	return r.withLineEnding
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"runtime"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_EOL(t *testing.T) {
+	testCases := []struct {
+		name       string
+		eol        string
+		attributes string // The project's .gitattributes
+		local      string // lib/a.go before the sync; absent when empty
+		a, b       string // lib/a.go and lib/b.go afterwards
+	}{
+		{"unset writes upstream's", "", "", "", "a\nb\n", "b\r\n"},
+		{"lf", config.EOLLF, "", "", "a\nb\n", "b\n"},
+		{"crlf", config.EOLCRLF, "", "", "a\r\nb\r\n", "b\r\n"},
+		{"auto keeps the local ending", config.EOLAuto, "", "x\r\n", "a\r\nb\r\n", "b\r\n"},
+		{"auto writes new files as upstream has them", config.EOLAuto, "", "", "a\nb\n", "b\r\n"},
+		{"auto follows .gitattributes", config.EOLAuto, "*.go text eol=lf\nb.go -text\n", "x\r\n", "a\nb\n", "b\r\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger.Init()
+			upstream := testutil.NewFixtureRepo(t, "library")
+			upstream.WriteFile("lib/a.go", "a\nb\n")
+			upstream.WriteFile("lib/b.go", "b\r\n")
+			upstream.Commit("initial")
+			project := testutil.NewProject(t)
+			project.Chdir()
+			if tc.attributes != "" {
+				project.WriteFile(".gitattributes", tc.attributes)
+			}
+			if tc.local != "" {
+				project.WriteFile("lib/a.go", tc.local)
+			}
+
+			repo, err := NewRepository(&config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}})
+			if err != nil {
+				t.Fatalf("Failed to open repository: %v", err)
+			}
+			defer repo.Close()
+			repo.options.EOL = tc.eol
+			if err := repo.Pull(); err != nil {
+				t.Fatalf("Failed to pull: %v", err)
+			}
+			if _, err := repo.CopyPaths(SyncModeForce, project.Dir); err != nil {
+				t.Fatalf("CopyPaths failed: %v", err)
+			}
+
+			if got := project.ReadFile("lib/a.go"); got != tc.a {
+				t.Errorf("Expected lib/a.go to be %q, got %q", tc.a, got)
+			}
+			if got := project.ReadFile("lib/b.go"); got != tc.b {
+				t.Errorf("Expected lib/b.go to be %q, got %q", tc.b, got)
+			}
+		})
+	}
+}
+
+func TestLineEnding_Native(t *testing.T) {
+	r := &Repository{options: config.SyncOptions{EOL: config.EOLNative}}
+	expected := "\n"
+	if runtime.GOOS == "windows" {
+		expected = "\r\n"
+	}
+	if got := r.lineEnding("lib/a.go"); got != expected {
+		t.Errorf("Expected native line endings to be %q, got %q", expected, got)
+	}
+}
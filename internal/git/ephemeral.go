@@ -0,0 +1,67 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// noCacheDir is where every source is cloned while the cache is disabled, empty otherwise
+var noCacheDir string
+
+// SetNoCache clones every source like a `cache: ephemeral` one, into temporary
+// directories under dir (--no-cache); an empty dir goes back to the cache
+func SetNoCache(dir string) {
+	noCacheDir = dir
+}
+
+// newEphemeralRepository clones a `cache: ephemeral` source, or any source under
+// --no-cache, into a fresh temporary directory, bypassing the cache manager entirely.
+// The clone is removed by Close, or right away if cloning fails.
+func newEphemeralRepository(ctx context.Context, source *config.Source) (*Repository, error) {
+	parent, pattern, reason := "", "cherry-go-ephemeral-*", "cache: ephemeral"
+	if noCacheDir != "" {
+		parent, pattern, reason = noCacheDir, ".cherry-go-clone-*", "--no-cache"
+	}
+	tempDir, err := os.MkdirTemp(parent, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary clone directory: %w", err)
+	}
+	repoPath := filepath.Join(tempDir, "repo")
+
+	logger.Info("Cloning repository %s to a temporary directory (%s)", source.Repository, reason)
+	repo, err := cloneRepository(ctx, source, repoPath)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
+	}
+
+	return &Repository{
+		repo:      repo,
+		path:      repoPath,
+		source:    source,
+		ephemeral: true,
+	}, nil
+}
+
+// Close releases the repository. Ephemeral clones are deleted; cached ones are kept.
+func (r *Repository) Close() error {
+	if !r.ephemeral {
+		return nil
+	}
+	tempDir := filepath.Dir(r.path)
+	if err := os.RemoveAll(tempDir); err != nil {
+		return fmt.Errorf("failed to remove temporary clone %s: %w", tempDir, err)
+	}
+	logger.Debug("Removed temporary clone %s", tempDir)
+	return nil
+}
+
+// Ephemeral reports whether the repository is a temporary clone removed by Close
+func (r *Repository) Ephemeral() bool {
+	return r.ephemeral
+}
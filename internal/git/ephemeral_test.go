@@ -0,0 +1,92 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// isolateTempDir points os.TempDir at an empty directory so temporary clones can be counted
+func isolateTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	return dir
+}
+
+// requireEmptyDir fails the test if anything is left in dir
+func requireEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", dir, err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("Expected %s to be empty, found %s", dir, entries[0].Name())
+	}
+}
+
+func TestNewRepository_Ephemeral(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "secret")
+	upstream.WriteFile("lib/a.go", "package lib\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+	tempDir := isolateTempDir(t)
+
+	source := &config.Source{
+		Name:       "secret",
+		Repository: upstream.URL(),
+		Cache:      config.CacheEphemeral,
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	}
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if !repo.Ephemeral() || !strings.HasPrefix(repo.path, tempDir) {
+		t.Fatalf("Expected a temporary clone under %s, got %s", tempDir, repo.path)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if _, err := repo.CopyPaths(SyncModeForce, project.Dir); err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	if got := project.ReadFile("lib/a.go"); got != "package lib\n" {
+		t.Errorf("Expected the ephemeral clone to sync files, got %q", got)
+	}
+
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	requireEmptyDir(t, tempDir)
+
+	manager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if manager.RepositoryExists(source.Repository) {
+		t.Error("Expected an ephemeral source never to be cached")
+	}
+}
+
+func TestNewRepository_EphemeralCloneFailureCleansUp(t *testing.T) {
+	logger.Init()
+	project := testutil.NewProject(t)
+	project.Chdir()
+	missing := "file://" + project.Path("no-such-repo.git")
+	tempDir := isolateTempDir(t)
+
+	_, err := NewRepository(&config.Source{Name: "secret", Repository: missing, Cache: config.CacheEphemeral})
+	if err == nil {
+		t.Fatal("Expected cloning a missing repository to fail")
+	}
+	requireEmptyDir(t, tempDir)
+}
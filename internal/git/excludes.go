@@ -0,0 +1,145 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/utils"
+)
+
+// UnmatchedExcludes returns the exclude patterns of a tracked directory that match none
+// of its upstream files at the commit it last synced from, read from the cached clone
+func (r *Repository) UnmatchedExcludes(pathSpec config.PathSpec) ([]config.UnmatchedExclude, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("repository not available")
+	}
+	if len(pathSpec.Exclude) == 0 {
+		return nil, nil
+	}
+
+	upstream, err := upstreamFiles(r.repo, pathSpec, true, config.Excludes{})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(upstream))
+	for relPath := range upstream {
+		files = append(files, filepath.ToSlash(relPath))
+	}
+	sort.Strings(files)
+	return config.UnmatchedExcludes(pathSpec.Include, pathSpec.Exclude, files), nil
+}
+
+// excludeSummaryPatterns caps how many patterns an exclude summary names
+const excludeSummaryPatterns = 3
+
+// ExcludedFile is an upstream file an exclude pattern left out of a tracked directory
+type ExcludedFile struct {
+	Path    string // Relative to the tracked directory
+	Pattern string // The exclude pattern that left it out
+}
+
+// ExcludeSummary counts the upstream files of a tracked directory its exclude patterns
+// left out, by the pattern that excluded each
+type ExcludeSummary struct {
+	Total     int
+	ByPattern map[string]int
+	Files     []ExcludedFile // Only collected when asked for
+}
+
+// summarizeExcludes walks a tracked directory upstream and counts the files excludes
+// leave out, listing them too with listFiles. Files inside an excluded directory count
+// for the pattern excluding the directory. What a pattern include's glob doesn't match
+// isn't counted: leaving that out is what the include is for.
+func summarizeExcludes(sourcePath string, excludes config.Excludes, listFiles bool) (ExcludeSummary, error) {
+	summary := ExcludeSummary{ByPattern: make(map[string]int)}
+	matcher := config.NewExcludeMatcher(excludes)
+	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		pattern := matcher.Match(relPath)
+		if pattern == "" {
+			return nil
+		}
+
+		summary.Total++
+		summary.ByPattern[pattern]++
+		if listFiles {
+			summary.Files = append(summary.Files, ExcludedFile{Path: filepath.ToSlash(relPath), Pattern: pattern})
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// String describes the summary, naming the patterns that excluded the most files first,
+// e.g. "excluded 84 files: 80 by '*.test.go', 4 by 'testdata'"
+func (s ExcludeSummary) String() string {
+	patterns := make([]string, 0, len(s.ByPattern))
+	for pattern := range s.ByPattern {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if s.ByPattern[patterns[i]] != s.ByPattern[patterns[j]] {
+			return s.ByPattern[patterns[i]] > s.ByPattern[patterns[j]]
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	parts := make([]string, 0, excludeSummaryPatterns+1)
+	for i, pattern := range patterns {
+		if i == excludeSummaryPatterns {
+			parts = append(parts, fmt.Sprintf("%d more by %d other pattern(s)", s.Total-s.countOf(patterns[:i]), len(patterns)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d by '%s'", s.ByPattern[pattern], pattern))
+	}
+
+	files := "files"
+	if s.Total == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("excluded %d %s: %s", s.Total, files, strings.Join(parts, ", "))
+}
+
+// countOf sums the files excluded by the given patterns
+func (s ExcludeSummary) countOf(patterns []string) int {
+	n := 0
+	for _, pattern := range patterns {
+		n += s.ByPattern[pattern]
+	}
+	return n
+}
+
+// reportExcludes tells, when verbose, how many upstream files of a tracked directory its
+// excludes left out and by which patterns, and at verbosity 2 which files. An exclude
+// pattern is the usual reason content doesn't sync, and nothing else names it.
+func reportExcludes(include, sourcePath string, excludes config.Excludes) {
+	verbosity := logger.GetVerbosityLevel()
+	if verbosity == 0 || len(excludes.Patterns) == 0 {
+		return
+	}
+
+	summary, err := summarizeExcludes(sourcePath, excludes, verbosity >= 2)
+	if err != nil {
+		logger.Debug("Could not count the files excluded from %s: %v", include, err)
+		return
+	}
+	if summary.Total == 0 {
+		return
+	}
+
+	logger.Info("🚫 %s: %s", include, summary)
+	for _, file := range summary.Files {
+		logger.Info("   - %s (by '%s')", utils.DisplayPath(file.Path), file.Pattern)
+	}
+}
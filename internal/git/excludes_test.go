@@ -0,0 +1,251 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_DefaultExcludes(t *testing.T) {
+	disabled := false
+
+	testCases := []struct {
+		name         string
+		options      config.SyncOptions
+		source       *bool
+		expectedJunk bool
+	}{
+		{"enabled by default", config.SyncOptions{}, nil, false},
+		{"disabled globally", config.SyncOptions{DefaultExcludes: &disabled}, nil, true},
+		{"disabled for the source", config.SyncOptions{}, &disabled, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger.Init()
+
+			upstream := testutil.NewFixtureRepo(t, "junk")
+			upstream.WriteFile("lib/a.go", "package lib\n")
+			upstream.WriteFile("lib/.DS_Store", "finder\n")
+			upstream.WriteFile("lib/sub/.b.go.swp", "swap\n")
+			upstream.Commit("initial with junk")
+
+			project := testutil.NewProject(t)
+			project.Chdir()
+
+			source := &config.Source{
+				Name:            "junk",
+				Repository:      upstream.URL(),
+				Paths:           []config.PathSpec{{Include: "lib/", Exclude: []string{"*.tmp"}}},
+				DefaultExcludes: tc.source,
+			}
+
+			sync := func(mode SyncMode) *CopyResult {
+				t.Helper()
+				repo, err := NewRepository(source)
+				if err != nil {
+					t.Fatalf("Failed to open repository: %v", err)
+				}
+				repo.SetSyncOptions(tc.options)
+				if err := repo.Pull(); err != nil {
+					t.Fatalf("Failed to pull: %v", err)
+				}
+				result, err := repo.CopyPaths(mode, project.Dir)
+				if err != nil {
+					t.Fatalf("CopyPaths failed: %v", err)
+				}
+				return result
+			}
+
+			sync(SyncModeForce)
+
+			// Copy, hash, and snapshot code all agree on the effective excludes
+			for _, junk := range []string{"lib/.DS_Store", "lib/sub/.b.go.swp"} {
+				if project.Exists(junk) != tc.expectedJunk {
+					t.Errorf("Expected %s copied=%t", junk, tc.expectedJunk)
+				}
+			}
+			files := source.Paths[0].Files
+			if _, tracked := files[".DS_Store"]; tracked != tc.expectedJunk {
+				t.Errorf("Expected .DS_Store tracked=%t, got %v", tc.expectedJunk, files)
+			}
+			if _, tracked := files["a.go"]; !tracked {
+				t.Errorf("Expected a.go to be tracked, got %v", files)
+			}
+
+			baseManager, err := cache.NewBaseContentManager()
+			if err != nil {
+				t.Fatalf("Failed to open base-content manager: %v", err)
+			}
+			if content, _ := baseManager.GetFileContent("junk", "lib/", ".DS_Store"); (content != nil) != tc.expectedJunk {
+				t.Errorf("Expected .DS_Store snapshotted=%t", tc.expectedJunk)
+			}
+
+			// The user's patterns are kept as configured, defaults are never persisted
+			if excludes := source.Paths[0].Exclude; len(excludes) != 1 || excludes[0] != "*.tmp" {
+				t.Errorf("Expected the configured excludes to be unchanged, got %v", excludes)
+			}
+
+			// Excluded upstream files never count as local differences
+			if result := sync(SyncModeDetect); len(result.Conflicts) != 0 || len(result.UpdatedPaths) != 0 {
+				t.Errorf("Expected a clean detect run, got conflicts %v, updated %v", result.Conflicts, result.UpdatedPaths)
+			}
+		})
+	}
+}
+
+func TestCopyPaths_ExcludeMatchesWholeSegments(t *testing.T) {
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "segments")
+	upstream.WriteFile("lib/config.yaml", "name: lib\n")
+	upstream.WriteFile("lib/config/local.yaml", "debug: true\n")
+	upstream.WriteFile("lib/a.go", "package lib\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+	source := &config.Source{
+		Name:       "segments",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/", Exclude: []string{"config"}}},
+	}
+
+	syncFixture(t, source, SyncModeForce, project.Dir)
+
+	// "config" excludes the config/ directory, not every name containing it
+	if !project.Exists("lib/config.yaml") {
+		t.Error("Expected lib/config.yaml to be copied")
+	}
+	if project.Exists("lib/config/local.yaml") {
+		t.Error("Expected lib/config/ to be excluded")
+	}
+	files := source.Paths[0].Files
+	if _, tracked := files["config.yaml"]; !tracked {
+		t.Errorf("Expected config.yaml to be tracked, got %v", files)
+	}
+	if _, tracked := files["config/local.yaml"]; tracked {
+		t.Errorf("Expected config/local.yaml not to be tracked, got %v", files)
+	}
+
+	// Every tracked file was written, so nothing shows up as deleted afterwards
+	result := syncFixture(t, source, SyncModeDetect, project.Dir)
+	if len(result.Conflicts) != 0 || len(result.UpdatedPaths) != 0 {
+		t.Errorf("Expected a clean detect run, got conflicts %v, updated %v", result.Conflicts, result.UpdatedPaths)
+	}
+}
+
+func TestCheckTrackedFiles(t *testing.T) {
+	logger.Init()
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	t.Cleanup(func() { logger.SetOutput(nil) })
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hashes := map[string]string{"a.go": "h1", "config.yaml": "h2"}
+
+	checked := checkTrackedFiles(dir, hashes, config.Excludes{Patterns: []string{"*.yaml"}})
+	if len(checked) != 1 || checked["a.go"] != "h1" {
+		t.Errorf("Expected only a.go to stay tracked, got %v", checked)
+	}
+	if len(hashes) != 2 {
+		t.Errorf("Expected the input hashes to be unchanged, got %v", hashes)
+	}
+	if !strings.Contains(logs.String(), `matches exclude "*.yaml"`) {
+		t.Errorf("Expected a warning naming the exclude, got %q", logs.String())
+	}
+}
+
+func TestSummarizeExcludes(t *testing.T) {
+	dir := t.TempDir()
+	for _, relPath := range []string{
+		"a.go", "a_test.go", "b_test.go", "sub/c_test.go", "sub/c.go",
+		"testdata/x.json", "testdata/deep/y.json", "docs/guide.md", ".DS_Store",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(relPath+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	excludes := config.Excludes{Patterns: config.SyncOptions{}.EffectiveExcludes(&config.Source{}, []string{"*_test.go", "testdata", "docs/", "unused"})}
+	summary, err := summarizeExcludes(dir, excludes, true)
+	if err != nil {
+		t.Fatalf("summarizeExcludes failed: %v", err)
+	}
+	expected := map[string]int{"*_test.go": 3, "testdata": 2, "docs/": 1, ".DS_Store": 1}
+	if summary.Total != 7 || len(summary.ByPattern) != len(expected) {
+		t.Fatalf("Expected 7 files excluded by %v, got %d by %v", expected, summary.Total, summary.ByPattern)
+	}
+	for pattern, count := range expected {
+		if summary.ByPattern[pattern] != count {
+			t.Errorf("Expected %d file(s) excluded by %q, got %d", count, pattern, summary.ByPattern[pattern])
+		}
+	}
+	if len(summary.Files) != 7 {
+		t.Errorf("Expected the 7 excluded files listed, got %v", summary.Files)
+	}
+	if got, want := summary.String(), "excluded 7 files: 3 by '*_test.go', 2 by 'testdata', 1 by '.DS_Store', 1 more by 1 other pattern(s)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// Files are only listed when asked for
+	if summary, _ := summarizeExcludes(dir, excludes, false); summary.Files != nil || summary.Total != 7 {
+		t.Errorf("Expected counts without a file list, got %+v", summary)
+	}
+
+	// What a pattern include's glob doesn't match isn't an exclusion
+	spec := config.PathSpec{Include: "sub/*.go"}
+	summary, err = summarizeExcludes(filepath.Join(dir, "sub"), config.SyncOptions{}.PathExcludes(&config.Source{}, spec), false)
+	if err != nil || summary.Total != 0 {
+		t.Errorf("Expected nothing excluded from a pattern include, got %+v, %v", summary, err)
+	}
+}
+
+func TestCopyPaths_ReportsExcludes(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\n")
+	upstream.WriteFile("lib/a_test.go", "a test\n")
+	upstream.WriteFile("lib/testdata/golden.txt", "golden\n")
+	upstream.Commit("v1")
+	project := testutil.NewProject(t)
+	project.Chdir()
+	source := &config.Source{Name: "library", Repository: upstream.URL(),
+		Paths: []config.PathSpec{{Include: "lib/", Exclude: []string{"*_test.go", "testdata"}}}}
+
+	for _, tc := range []struct {
+		verbosity int
+		summary   bool
+		files     bool
+	}{{0, false, false}, {1, true, false}, {2, true, true}} {
+		previous := logger.GetVerbosityLevel()
+		logger.SetVerbosityLevel(tc.verbosity)
+		var logs bytes.Buffer
+		logger.SetOutput(&logs)
+		syncFixture(t, source, SyncModeForce, project.Dir)
+		logger.SetOutput(nil)
+		logger.SetVerbosityLevel(previous)
+
+		out := logs.String()
+		if got := strings.Contains(out, "lib/: excluded 2 files: 1 by '*_test.go', 1 by 'testdata'"); got != tc.summary {
+			t.Errorf("Verbosity %d: expected the exclude summary: %v, got %q", tc.verbosity, tc.summary, out)
+		}
+		if got := strings.Contains(out, "testdata/golden.txt (by 'testdata')"); got != tc.files {
+			t.Errorf("Verbosity %d: expected the excluded files listed: %v, got %q", tc.verbosity, tc.files, out)
+		}
+	}
+}
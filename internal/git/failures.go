@@ -0,0 +1,106 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"cherry-go/internal/logger"
+)
+
+// FileFailure is a tracked file that could not be read or written during a sync.
+// Its path keeps its previous tracking entry and is not considered fully synced.
+type FileFailure struct {
+	Include   string // Path spec the file belongs to
+	Path      string // File path relative to the include, as keyed in the tracking hashes
+	LocalPath string // Local destination of the file
+	Err       error
+}
+
+func (f FileFailure) String() string {
+	return fmt.Sprintf("%s: %v", f.LocalPath, f.Err)
+}
+
+// fileError is one file a directory copy could not copy
+type fileError struct {
+	path string // Source file
+	err  error
+}
+
+// copyErrors collects the files a directory copy skipped after failing to copy them
+type copyErrors []fileError
+
+func (e copyErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, failure := range e {
+		parts = append(parts, fmt.Sprintf("%s: %v", failure.path, failure.err))
+	}
+	return fmt.Sprintf("failed to copy %d file(s): %s", len(e), strings.Join(parts, "; "))
+}
+
+// recordFailure notes that a source file of the path being processed failed to sync
+func (r *Repository) recordFailure(input processPathInput, sourceFile string, err error) {
+	relPath := filepath.Base(sourceFile)
+	localPath := input.localPath
+	if input.srcInfo != nil && input.srcInfo.IsDir() {
+		if rel, relErr := filepath.Rel(input.sourcePath, sourceFile); relErr == nil {
+			relPath = rel
+		}
+		localPath = filepath.Join(input.localPath, relPath)
+	}
+	localPath = r.projectPath(localPath)
+
+	for _, failure := range r.failed {
+		if failure.Include == input.pathSpec.Include && failure.Path == relPath {
+			return
+		}
+	}
+
+	logger.Error("✗ Failed to sync %s: %v", localPath, err)
+	r.failed = append(r.failed, FileFailure{
+		Include:   input.pathSpec.Include,
+		Path:      relPath,
+		LocalPath: localPath,
+		Err:       err,
+	})
+}
+
+// copyTrackedPath copies a path for processPath. Files that fail to copy are recorded
+// and skipped; it returns false when nothing was copied.
+func (r *Repository) copyTrackedPath(input processPathInput) bool {
+	err := copyPath(input.sourcePath, input.localPath, input.pathSpec.Excludes, r.options.FollowSymlinks, r.preservePermissions(), r.checkWrite, r.writeFilter(), r.copyProgress(input))
+
+	var failures copyErrors
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &failures):
+		for _, failure := range failures {
+			r.recordFailure(input, failure.path, failure.err)
+		}
+		return true
+	case isProtectedPathError(err):
+		return false
+	default:
+		r.recordFailure(input, input.sourcePath, err)
+		return false
+	}
+}
+
+// keepFailedHashes returns the new tracking hashes of a partially synced path, with
+// the entries of failed files kept as they were before the sync
+func keepFailedHashes(previous, current map[string]string, failures []FileFailure) map[string]string {
+	hashes := make(map[string]string, len(current))
+	for path, h := range current {
+		hashes[path] = h
+	}
+	for _, failure := range failures {
+		if h, ok := previous[failure.Path]; ok {
+			hashes[failure.Path] = h
+		} else {
+			delete(hashes, failure.Path)
+		}
+	}
+	return hashes
+}
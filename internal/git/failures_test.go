@@ -0,0 +1,187 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// unreadableFixture syncs lib/ once, then updates both of its files upstream
+type unreadableFixture struct {
+	upstream *testutil.FixtureRepo
+	project  *testutil.Project
+	source   *config.Source
+}
+
+func newUnreadableFixture(t *testing.T, include string) *unreadableFixture {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // a v1\n")
+	upstream.WriteFile("lib/b.go", "package lib // b v1\n")
+	upstream.Commit("v1")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	return &unreadableFixture{
+		upstream: upstream,
+		project:  project,
+		source: &config.Source{
+			Name:       "library",
+			Repository: upstream.URL(),
+			Paths:      []config.PathSpec{{Include: include}},
+		},
+	}
+}
+
+// sync pulls and checks out the cache, makes the given cache files unreadable, and copies
+// the paths; CopyPaths leaves a worktree already at the fetched commit alone
+func (f *unreadableFixture) sync(t *testing.T, mode SyncMode, unreadable ...string) *CopyResult {
+	t.Helper()
+	repo, err := NewRepository(f.source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if err := repo.checkoutBranch(f.source.Paths[0].Branch); err != nil {
+		t.Fatalf("Failed to checkout: %v", err)
+	}
+	for _, rel := range unreadable {
+		if makeUnreadable(t, filepath.Join(repo.path, rel)) {
+			// Links are synced as links, so only following them reads through
+			repo.SetSyncOptions(config.SyncOptions{FollowSymlinks: true})
+		}
+	}
+	result, err := repo.CopyPaths(mode, f.project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return result
+}
+
+// makeUnreadable denies reading a cache file and restores it after the test. Root ignores
+// permissions, so there the file is swapped for a symlink to itself, which fails reads
+// through it; it reports whether it did.
+func makeUnreadable(t *testing.T, path string) bool {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	restore := func() { _ = os.Remove(path); _ = os.WriteFile(path, content, 0644) }
+
+	if err := os.Chmod(path, 0); err != nil {
+		t.Fatalf("Failed to chmod %s: %v", path, err)
+	}
+	t.Cleanup(restore)
+	if _, err := os.ReadFile(path); err == nil {
+		_ = os.Remove(path)
+		if err := os.Symlink(filepath.Base(path), path); err != nil {
+			t.Fatalf("Failed to replace %s: %v", path, err)
+		}
+		return true
+	}
+	return false
+}
+
+func TestCopyPaths_UnreadableFileKeepsTracking(t *testing.T) {
+	for _, mode := range []SyncMode{SyncModeForce, SyncModeMerge} {
+		t.Run(fmt.Sprintf("mode %d", mode), func(t *testing.T) {
+			f := newUnreadableFixture(t, "lib/")
+			f.sync(t, SyncModeForce)
+			f.project.Commit("sync v1")
+			synced := f.source.Paths[0]
+
+			f.upstream.WriteFile("lib/a.go", "package lib // a v2\n")
+			f.upstream.WriteFile("lib/b.go", "package lib // b v2\n")
+			f.upstream.Commit("v2")
+
+			result := f.sync(t, mode, "lib/b.go")
+			if len(result.Failed) != 1 || result.Failed[0].Path != "b.go" || result.Failed[0].LocalPath != filepath.Join("lib", "b.go") {
+				t.Fatalf("Expected lib/b.go to be reported as failed, got %v", result.Failed)
+			}
+
+			// The readable file is synced and still committed with the path
+			if got := f.project.ReadFile("lib/a.go"); got != "package lib // a v2\n" {
+				t.Errorf("Expected a.go to be updated, got %q", got)
+			}
+			if len(result.UpdatedPaths) != 1 {
+				t.Errorf("Expected the partially synced path to be listed for commit, got %v", result.UpdatedPaths)
+			}
+
+			// The failed file keeps its stale copy and previous tracking entry, and the
+			// path is not recorded as synced from the new commit
+			path := f.source.Paths[0]
+			if got := f.project.ReadFile("lib/b.go"); got != "package lib // b v1\n" {
+				t.Errorf("Expected b.go to keep its previous content, got %q", got)
+			}
+			if path.Files["b.go"] != synced.Files["b.go"] {
+				t.Errorf("Expected b.go to keep its tracking hash, got %q", path.Files["b.go"])
+			}
+			if path.Files["a.go"] == synced.Files["a.go"] {
+				t.Error("Expected a.go's tracking hash to be updated")
+			}
+			if path.Commit != synced.Commit {
+				t.Errorf("Expected the path to stay at %s, got %s", synced.Commit, path.Commit)
+			}
+		})
+	}
+}
+
+func TestCopyPaths_UnreadableFileOnFirstSync(t *testing.T) {
+	f := newUnreadableFixture(t, "lib/")
+
+	result := f.sync(t, SyncModeMerge, "lib/b.go")
+	if len(result.Failed) != 1 {
+		t.Fatalf("Expected one failed file, got %v", result.Failed)
+	}
+	files := f.source.Paths[0].Files
+	if _, tracked := files["b.go"]; tracked {
+		t.Errorf("Expected the unread file not to be tracked, got %v", files)
+	}
+	if _, tracked := files["a.go"]; !tracked {
+		t.Errorf("Expected the copied file to be tracked, got %v", files)
+	}
+	if f.source.Paths[0].Commit != "" {
+		t.Errorf("Expected no synced commit for a partially synced path, got %s", f.source.Paths[0].Commit)
+	}
+}
+
+func TestCopyPaths_UnreadableSingleFile(t *testing.T) {
+	f := newUnreadableFixture(t, "lib/a.go")
+
+	result := f.sync(t, SyncModeForce, "lib/a.go")
+	if len(result.Failed) != 1 || result.Failed[0].Include != "lib/a.go" {
+		t.Fatalf("Expected lib/a.go to fail, got %v", result.Failed)
+	}
+	if len(result.UpdatedPaths) != 0 || f.project.Exists("lib/a.go") {
+		t.Errorf("Expected nothing to be synced, got %v", result.UpdatedPaths)
+	}
+	if len(f.source.Paths[0].Files) != 0 {
+		t.Errorf("Expected no tracking hashes, got %v", f.source.Paths[0].Files)
+	}
+}
+
+func TestKeepFailedHashes(t *testing.T) {
+	previous := map[string]string{"a.go": "a1", "b.go": "b1"}
+	current := map[string]string{"a.go": "a2", "b.go": "b2", "c.go": "c2"}
+	failures := []FileFailure{{Path: "b.go"}, {Path: "c.go"}}
+
+	got := keepFailedHashes(previous, current, failures)
+	expected := map[string]string{"a.go": "a2", "b.go": "b1"}
+	if len(got) != len(expected) || got["a.go"] != "a2" || got["b.go"] != "b1" {
+		t.Errorf("keepFailedHashes() = %v, expected %v", got, expected)
+	}
+	if current["b.go"] != "b2" {
+		t.Error("Expected the current hashes to be left untouched")
+	}
+}
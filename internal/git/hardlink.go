@@ -0,0 +1,243 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// linkFile creates hard links, replaced in tests to simulate other filesystems
+var linkFile = os.Link
+
+// linkPath syncs a `link: hardlink` path in force mode. Every file is re-linked to the
+// cache checkout on each sync, so links broken by a cache update are restored; files
+// that can't be linked (different filesystem, unsupported) are copied instead.
+func (r *Repository) linkPath(input processPathInput) (processPathResult, []hash.FileConflict) {
+	result := processPathResult{}
+	changed := r.contentDiffersFromRemote(input)
+
+	var fallback error
+	link := func(src, dst string) error {
+		if r.checkWrite(dst) != nil {
+			return nil // Refusals are collected by checkWrite; link the rest
+		}
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would hard-link %s to %s", dst, src)
+			return nil
+		}
+		linkErr, err := linkOrCopyFile(src, dst)
+		if err != nil {
+			r.recordFailure(input, src, err)
+		} else if linkErr != nil && fallback == nil {
+			fallback = linkErr
+		}
+		return nil
+	}
+
+	var err error
+	if input.srcInfo.IsDir() {
+		err = walkSourceFiles(input.sourcePath, input.pathSpec.Excludes, func(src, relPath string, _ os.FileInfo) error {
+			return link(src, filepath.Join(input.localPath, relPath))
+		})
+	} else {
+		err = link(input.sourcePath, input.localPath)
+	}
+	if err != nil {
+		r.recordFailure(input, input.sourcePath, err)
+		return result, nil
+	}
+
+	if fallback != nil {
+		logger.Warning("⚠️  Could not hard-link %s, copied instead: %v", input.pathSpec.Include, fallback)
+	}
+	if changed {
+		logger.Info("🔧 Force mode: Linking %s to the cache", input.pathSpec.Include)
+	}
+
+	result.newHashes = r.remoteHashes(input)
+	result.updated = changed
+	return result, nil
+}
+
+// linkOrCopyFile replaces dst with a hard link to src, copying it if linking fails.
+// linkErr is the link failure when the file was copied instead; err means neither worked.
+func linkOrCopyFile(src, dst string) (linkErr, err error) {
+	if dstInfo, err := os.Stat(dst); err == nil {
+		if srcInfo, err := os.Stat(src); err == nil && os.SameFile(srcInfo, dstInfo) {
+			return nil, nil // Already linked
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+
+	// Link next to the destination and rename over it, so dst is never missing
+	staged := dst + ".cherry-go-link"
+	_ = os.Remove(staged)
+	linkErr = linkFile(src, staged)
+	if linkErr == nil {
+		if err := os.Rename(staged, dst); err != nil {
+			_ = os.Remove(staged)
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Never write into an existing file in place: it may itself be a link into the cache.
+	// The copy keeps the permissions the link would have shared.
+	_ = os.Remove(dst)
+	if err := copyFile(src, dst, true, nil, nil); err != nil {
+		return linkErr, err
+	}
+	return linkErr, nil
+}
+
+// walkSourceFiles calls fn for every non-excluded file below root with its relative path
+// and the file info the walk read
+func walkSourceFiles(root string, excludes config.Excludes, fn func(path, relPath string, info os.FileInfo) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if info.IsDir() {
+			if config.IsExcludedDir(relPath, excludes) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if shouldExclude(relPath, excludes) {
+			return nil
+		}
+		return fn(path, relPath, info)
+	})
+}
+
+// repairLinks runs before the cache is updated. Local files still hard-linked into the
+// cache checkout are split off when they must not share it: paths no longer set to
+// `link: hardlink`, and linked files whose content drifted from the synced version
+// (edited through the link). Drift also dirtied the cache checkout, so it is reset.
+func (r *Repository) repairLinks() error {
+	hasher := hash.NewFileHasherFor(r.options)
+	drifted := false
+
+	for _, pathSpec := range r.source.Paths {
+		localPath := pathSpec.LocalRoot()
+		cachePath, _, err := resolveSourceLink(r.path, pathSpec.SourceRoot())
+		if err != nil {
+			continue
+		}
+
+		info, err := os.Stat(localPath)
+		if err != nil {
+			continue
+		}
+
+		check := func(local, cached, relPath string) {
+			if !sameFile(local, cached) {
+				return
+			}
+
+			fileDrifted := false
+			if pathSpec.HardLinked() {
+				expected, tracked := pathSpec.Files[relPath]
+				_, matches, err := hasher.Matches(local, expected)
+				fileDrifted = tracked && err == nil && !matches
+				if !fileDrifted {
+					return
+				}
+			}
+
+			if err := breakLink(local); err != nil {
+				logger.Warning("Failed to unlink %s from the cache: %v", local, err)
+				return
+			}
+			if fileDrifted {
+				logger.Warning("⚠️  %s was modified through its hard link into the cache; it is now a separate local copy", local)
+				drifted = true
+			} else {
+				logger.Debug("Unlinked %s from the cache", local)
+			}
+		}
+
+		if info.IsDir() {
+			_ = walkSourceFiles(localPath, config.Excludes{}, func(local, relPath string, _ os.FileInfo) error {
+				check(local, filepath.Join(cachePath, relPath), relPath)
+				return nil
+			})
+		} else {
+			check(localPath, cachePath, filepath.Base(cachePath))
+		}
+	}
+
+	if !drifted {
+		return nil
+	}
+
+	// Put the cache checkout back the way git has it
+	workTree, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to resolve cache HEAD: %w", err)
+	}
+	if err := workTree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset cache checkout: %w", err)
+	}
+	return nil
+}
+
+// sameFile reports whether two paths are the same file on disk (hard links included)
+func sameFile(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// breakLink turns a hard-linked file into an independent copy with the same content
+func breakLink(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	staged := path + ".cherry-go-unlink"
+	if err := os.WriteFile(staged, content, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(staged, path); err != nil {
+		_ = os.Remove(staged)
+		return err
+	}
+	return nil
+}
+
+// skipLinkedPath reports whether a `link: hardlink` path must be skipped in this mode:
+// merging would write through the links into the cache, so only force and detect apply
+func skipLinkedPath(pathSpec config.PathSpec, mode SyncMode) bool {
+	if !pathSpec.HardLinked() || mode == SyncModeForce || mode == SyncModeDetect {
+		return false
+	}
+	logger.Warning("⚠️  Skipping %s: link: hardlink paths only sync with --force (merging would write through to the cache)", pathSpec.Include)
+	return true
+}
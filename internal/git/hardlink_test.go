@@ -0,0 +1,170 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// hardlinkFixture tracks tools/ from an upstream repository with link: hardlink
+type hardlinkFixture struct {
+	upstream *testutil.FixtureRepo
+	project  *testutil.Project
+	source   *config.Source
+}
+
+func newHardlinkFixture(t *testing.T) *hardlinkFixture {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "toolchain")
+	upstream.WriteFile("tools/bin/cc", "cc v1\n")
+	upstream.WriteFile("tools/lib/runtime.a", "runtime v1\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	return &hardlinkFixture{
+		upstream: upstream,
+		project:  project,
+		source: &config.Source{
+			Name:       "toolchain",
+			Repository: upstream.URL(),
+			Paths:      []config.PathSpec{{Include: "tools/", Link: config.LinkHardlink}},
+		},
+	}
+}
+
+func (f *hardlinkFixture) sync(t *testing.T, mode SyncMode) (*Repository, *CopyResult) {
+	t.Helper()
+	repo, err := NewRepository(f.source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	result, err := repo.CopyPaths(mode, f.project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return repo, result
+}
+
+// linked reports whether a project file is a hard link to its cache counterpart
+func (f *hardlinkFixture) linked(repo *Repository, rel string) bool {
+	return sameFile(f.project.Path(rel), filepath.Join(repo.path, rel))
+}
+
+func TestCopyPaths_HardlinkForce(t *testing.T) {
+	f := newHardlinkFixture(t)
+
+	repo, result := f.sync(t, SyncModeForce)
+	if len(result.UpdatedPaths) != 1 {
+		t.Fatalf("Expected tools/ to be synced, got %v", result.UpdatedPaths)
+	}
+	for _, rel := range []string{"tools/bin/cc", "tools/lib/runtime.a"} {
+		if !f.linked(repo, rel) {
+			t.Errorf("Expected %s to be hard-linked to the cache", rel)
+		}
+	}
+	if files := f.source.Paths[0].Files; len(files) != 2 {
+		t.Errorf("Expected linked files to be tracked like copies, got %v", files)
+	}
+
+	// The cache checkout replaces changed files, leaving the old inode behind locally;
+	// the next force sync links the new version
+	f.upstream.WriteFile("tools/bin/cc", "cc v2\n")
+	f.upstream.Commit("cc v2")
+	repo, result = f.sync(t, SyncModeForce)
+	if len(result.UpdatedPaths) != 1 || f.project.ReadFile("tools/bin/cc") != "cc v2\n" {
+		t.Fatalf("Expected the update to be synced, got %v with %q", result.UpdatedPaths, f.project.ReadFile("tools/bin/cc"))
+	}
+	if !f.linked(repo, "tools/bin/cc") || !f.linked(repo, "tools/lib/runtime.a") {
+		t.Error("Expected every file to be re-linked")
+	}
+}
+
+func TestCopyPaths_HardlinkFallsBackToCopy(t *testing.T) {
+	f := newHardlinkFixture(t)
+
+	previous := linkFile
+	linkFile = func(oldname, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { linkFile = previous })
+
+	repo, result := f.sync(t, SyncModeForce)
+	if len(result.UpdatedPaths) != 1 {
+		t.Fatalf("Expected tools/ to be synced by copying, got %v", result.UpdatedPaths)
+	}
+	if f.linked(repo, "tools/bin/cc") {
+		t.Error("Expected a copy when linking fails")
+	}
+	if got := f.project.ReadFile("tools/bin/cc"); got != "cc v1\n" {
+		t.Errorf("Unexpected copied content %q", got)
+	}
+	if f.project.Exists("tools/bin/cc.cherry-go-link") {
+		t.Error("Expected no staging leftovers")
+	}
+}
+
+func TestPull_BreaksDriftedLinks(t *testing.T) {
+	f := newHardlinkFixture(t)
+	repo, _ := f.sync(t, SyncModeForce)
+	f.project.Commit("sync toolchain")
+
+	// Editing in place writes through the link into the cache checkout
+	if err := os.WriteFile(f.project.Path("tools/bin/cc"), []byte("patched\n"), 0644); err != nil {
+		t.Fatalf("Failed to edit linked file: %v", err)
+	}
+
+	repo, result := f.sync(t, SyncModeDetect)
+	if f.linked(repo, "tools/bin/cc") {
+		t.Fatal("Expected the drifted file to be unlinked from the cache")
+	}
+	if got := f.project.ReadFile("tools/bin/cc"); got != "patched\n" {
+		t.Errorf("Expected the local edit to be kept, got %q", got)
+	}
+	cached, _ := os.ReadFile(filepath.Join(repo.path, "tools/bin/cc"))
+	if string(cached) != "cc v1\n" {
+		t.Errorf("Expected the cache checkout to be restored, got %q", cached)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "bin/cc" {
+		t.Errorf("Expected the edit to show up as a local difference, got %v", result.Conflicts)
+	}
+	if !f.linked(repo, "tools/lib/runtime.a") {
+		t.Error("Expected untouched files to stay linked")
+	}
+}
+
+func TestCopyPaths_HardlinkModes(t *testing.T) {
+	f := newHardlinkFixture(t)
+	repo, _ := f.sync(t, SyncModeForce)
+	f.project.Commit("sync toolchain")
+
+	f.upstream.WriteFile("tools/bin/cc", "cc v2\n")
+	f.upstream.Commit("cc v2")
+
+	// Merging would write through the links, so linked paths only sync with --force
+	_, result := f.sync(t, SyncModeMerge)
+	if len(result.UpdatedPaths) != 0 || f.project.ReadFile("tools/bin/cc") != "cc v1\n" {
+		t.Errorf("Expected merge mode to skip the linked path, got %v", result.UpdatedPaths)
+	}
+
+	// Switching back to copies splits the links off before the cache moves
+	f.source.Paths[0].Link = config.LinkCopy
+	repo, _ = f.sync(t, SyncModeDetect)
+	if f.linked(repo, "tools/lib/runtime.a") {
+		t.Error("Expected link: copy paths to stop sharing files with the cache")
+	}
+	if got := f.project.ReadFile("tools/lib/runtime.a"); got != "runtime v1\n" {
+		t.Errorf("Expected content to survive unlinking, got %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// kindName names a path's kind for messages
+func kindName(isDir bool) string {
+	if isDir {
+		return "directory"
+	}
+	return "file"
+}
+
+// localKindChanged reports whether the local destination exists as a different kind
+// (file vs directory) than the upstream path
+func localKindChanged(localPath string, srcInfo os.FileInfo) bool {
+	localInfo, err := os.Stat(localPath)
+	return err == nil && localInfo.IsDir() != srcInfo.IsDir()
+}
+
+// handleKindChange deals with an upstream path that became a file where a directory
+// exists locally, or the reverse. Force mode replaces the local path; every other mode
+// reports a kind conflict and leaves it alone.
+func (r *Repository) handleKindChange(input processPathInput) (processPathResult, []hash.FileConflict) {
+	upstreamKind := kindName(input.srcInfo.IsDir())
+	localKind := kindName(!input.srcInfo.IsDir())
+
+	if input.mode != SyncModeForce {
+		logger.Error("✗ %s is now a %s upstream but %s is a %s locally", input.pathSpec.Include, upstreamKind, r.projectPath(input.localPath), localKind)
+		logger.Info("💡 Move the local %s out of the way, or rerun with --force to replace it", localKind)
+		return processPathResult{}, []hash.FileConflict{{Path: r.projectPath(input.localPath), Type: hash.ConflictTypeKind}}
+	}
+
+	logger.Info("🔧 Force mode: Replacing local %s %s with the upstream %s", localKind, r.projectPath(input.localPath), upstreamKind)
+	if err := r.replaceLocalPath(input); err != nil {
+		if !isProtectedPathError(err) {
+			logger.Error("Failed to replace %s: %v", r.projectPath(input.localPath), err)
+		}
+		return processPathResult{}, nil
+	}
+
+	return processPathResult{
+		updated:   true,
+		newHashes: r.remoteHashes(input),
+	}, nil
+}
+
+// replaceLocalPath swaps the local path for a copy of the upstream one. The new form is
+// staged next to the destination first, so a failure never leaves a half-written path.
+func (r *Repository) replaceLocalPath(input processPathInput) error {
+	// Every file that would be removed or written must be writable
+	for _, path := range r.replacedFiles(input) {
+		if err := r.checkWrite(path); err != nil {
+			return err
+		}
+	}
+
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would replace %s with %s", r.projectPath(input.localPath), input.sourcePath)
+		return nil
+	}
+
+	localPath := filepath.Clean(input.localPath) // Directory specs may carry a trailing slash
+	staged := localPath + ".cherry-go-new"
+	previous := localPath + ".cherry-go-old"
+	_ = os.RemoveAll(staged)
+	_ = os.RemoveAll(previous)
+
+	if err := copyPath(input.sourcePath, staged, input.pathSpec.Excludes, r.options.FollowSymlinks, r.preservePermissions(), nil, r.writeFilter(), r.copyProgress(input)); err != nil {
+		_ = os.RemoveAll(staged)
+		return fmt.Errorf("failed to stage upstream %s: %w", kindName(input.srcInfo.IsDir()), err)
+	}
+
+	if err := os.Rename(localPath, previous); err != nil {
+		_ = os.RemoveAll(staged)
+		return fmt.Errorf("failed to move local %s aside: %w", kindName(!input.srcInfo.IsDir()), err)
+	}
+
+	if err := os.Rename(staged, localPath); err != nil {
+		// Put the original back so nothing is lost
+		_ = os.Rename(previous, localPath)
+		_ = os.RemoveAll(staged)
+		return fmt.Errorf("failed to move upstream %s into place: %w", kindName(input.srcInfo.IsDir()), err)
+	}
+
+	if err := os.RemoveAll(previous); err != nil {
+		logger.Warning("Failed to remove replaced %s %s: %v", kindName(!input.srcInfo.IsDir()), previous, err)
+	}
+	return nil
+}
+
+// replacedFiles lists the local files a replacement removes and writes
+func (r *Repository) replacedFiles(input processPathInput) []string {
+	var files []string
+	collect := func(root, localRoot string, excludes config.Excludes) {
+		_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			relPath, _ := filepath.Rel(root, path)
+			if relPath != "." && shouldExclude(relPath, excludes) {
+				return nil
+			}
+			files = append(files, filepath.Join(localRoot, relPath))
+			return nil
+		})
+	}
+
+	collect(input.localPath, input.localPath, config.Excludes{})
+	collect(input.sourcePath, input.localPath, input.pathSpec.Excludes)
+	return files
+}
@@ -0,0 +1,200 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// kindChangeFixture syncs an upstream path once, then changes its kind upstream
+type kindChangeFixture struct {
+	upstream *testutil.FixtureRepo
+	project  *testutil.Project
+	source   *config.Source
+}
+
+func newKindChangeFixture(t *testing.T, include string, before, after func(*testutil.FixtureRepo)) *kindChangeFixture {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "kinds")
+	before(upstream)
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	f := &kindChangeFixture{
+		upstream: upstream,
+		project:  project,
+		source: &config.Source{
+			Name:       "kinds",
+			Repository: upstream.URL(),
+			Paths:      []config.PathSpec{{Include: include}},
+		},
+	}
+	f.sync(t, SyncModeMerge, config.SyncOptions{})
+	project.Commit("initial sync")
+
+	after(upstream)
+	upstream.Commit("change kind")
+	return f
+}
+
+func (f *kindChangeFixture) sync(t *testing.T, mode SyncMode, options config.SyncOptions) *CopyResult {
+	t.Helper()
+	repo, err := NewRepository(f.source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	repo.SetSyncOptions(options)
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	result, err := repo.CopyPaths(mode, f.project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return result
+}
+
+// directoryToFile turns config/ into a single config file upstream
+func directoryToFile(t *testing.T) *kindChangeFixture {
+	return newKindChangeFixture(t, "config/",
+		func(upstream *testutil.FixtureRepo) {
+			upstream.WriteFile("config/app.yaml", "app: 1\n")
+			upstream.WriteFile("config/db.yaml", "db: 1\n")
+		},
+		func(upstream *testutil.FixtureRepo) {
+			// Staging can't replace a directory with a file in one go, so remove it first
+			upstream.RemoveFile("config/app.yaml")
+			upstream.RemoveFile("config/db.yaml")
+			upstream.Commit("remove config dir")
+			if err := os.Remove(filepath.Join(upstream.WorkDir, "config")); err != nil {
+				t.Fatal(err)
+			}
+			upstream.WriteFile("config", "app: 2\ndb: 2\n")
+		})
+}
+
+// fileToDirectory turns the settings file into a settings/ directory upstream
+func fileToDirectory(t *testing.T) *kindChangeFixture {
+	return newKindChangeFixture(t, "settings",
+		func(upstream *testutil.FixtureRepo) {
+			upstream.WriteFile("settings", "all: 1\n")
+		},
+		func(upstream *testutil.FixtureRepo) {
+			// Staging can't replace a file with a directory in one go, so remove it first
+			upstream.RemoveFile("settings")
+			upstream.Commit("remove settings file")
+			upstream.WriteFile("settings/main.yaml", "main: 2\n")
+			upstream.WriteFile("settings/extra/more.yaml", "more: 2\n")
+		})
+}
+
+func isDir(t *testing.T, project *testutil.Project, rel string) bool {
+	t.Helper()
+	info, err := os.Stat(project.Path(rel))
+	if err != nil {
+		t.Fatalf("Expected %s to exist: %v", rel, err)
+	}
+	return info.IsDir()
+}
+
+func TestCopyPaths_KindChangeRefusedWithoutForce(t *testing.T) {
+	testCases := []struct {
+		name       string
+		fixture    func(*testing.T) *kindChangeFixture
+		localPath  string
+		localIsDir bool
+	}{
+		{"directory to file", directoryToFile, "config", true},
+		{"file to directory", fileToDirectory, "settings", false},
+	}
+
+	for _, tc := range testCases {
+		for _, mode := range []SyncMode{SyncModeDetect, SyncModeMerge, SyncModeBranch, SyncModeMarkConflicts} {
+			t.Run(fmt.Sprintf("%s/mode %d", tc.name, mode), func(t *testing.T) {
+				f := tc.fixture(t)
+				previousFiles := f.source.Paths[0].Files
+
+				result := f.sync(t, mode, config.SyncOptions{})
+
+				if len(result.Conflicts) != 1 || result.Conflicts[0].Type != hash.ConflictTypeKind {
+					t.Fatalf("Mode %d: expected a single kind conflict, got %+v", mode, result.Conflicts)
+				}
+				if len(result.UpdatedPaths) != 0 || result.BranchCreated != "" {
+					t.Errorf("Mode %d: expected nothing to be synced, got %v (branch %q)", mode, result.UpdatedPaths, result.BranchCreated)
+				}
+				if isDir(t, f.project, tc.localPath) != tc.localIsDir {
+					t.Errorf("Mode %d: expected the local %s to keep its kind", mode, tc.localPath)
+				}
+				if len(f.source.Paths[0].Files) != len(previousFiles) {
+					t.Errorf("Mode %d: expected tracked hashes to be left alone, got %v", mode, f.source.Paths[0].Files)
+				}
+			})
+		}
+	}
+}
+
+func TestCopyPaths_KindChangeForceReplaces(t *testing.T) {
+	t.Run("directory to file", func(t *testing.T) {
+		f := directoryToFile(t)
+
+		result := f.sync(t, SyncModeForce, config.SyncOptions{})
+
+		if len(result.Conflicts) != 0 || len(result.UpdatedPaths) != 1 {
+			t.Fatalf("Expected a clean replacement, got conflicts %v, updated %v", result.Conflicts, result.UpdatedPaths)
+		}
+		if isDir(t, f.project, "config") {
+			t.Fatal("Expected config to be a file now")
+		}
+		if got := f.project.ReadFile("config"); got != "app: 2\ndb: 2\n" {
+			t.Errorf("Unexpected config content %q", got)
+		}
+		if files := f.source.Paths[0].Files; len(files) != 1 || files["config"] == "" {
+			t.Errorf("Expected the file's hash to replace the directory's, got %v", files)
+		}
+		if f.project.Exists("config.cherry-go-new") || f.project.Exists("config.cherry-go-old") {
+			t.Error("Expected no staging leftovers")
+		}
+	})
+
+	t.Run("file to directory", func(t *testing.T) {
+		f := fileToDirectory(t)
+
+		result := f.sync(t, SyncModeForce, config.SyncOptions{})
+
+		if len(result.Conflicts) != 0 || len(result.UpdatedPaths) != 1 {
+			t.Fatalf("Expected a clean replacement, got conflicts %v, updated %v", result.Conflicts, result.UpdatedPaths)
+		}
+		if !isDir(t, f.project, "settings") {
+			t.Fatal("Expected settings to be a directory now")
+		}
+		if got := f.project.ReadFile("settings/extra/more.yaml"); got != "more: 2\n" {
+			t.Errorf("Unexpected nested content %q", got)
+		}
+		if files := f.source.Paths[0].Files; len(files) != 2 {
+			t.Errorf("Expected the directory's hashes to replace the file's, got %v", files)
+		}
+	})
+}
+
+func TestCopyPaths_KindChangeRespectsProtectedPaths(t *testing.T) {
+	f := directoryToFile(t)
+
+	result := f.sync(t, SyncModeForce, config.SyncOptions{ProtectedPaths: []string{"config/db.yaml"}})
+
+	if len(result.Refused) == 0 || result.Refused[0].Rule != "config/db.yaml" {
+		t.Fatalf("Expected the replacement to be refused by the protected file, got %+v", result.Refused)
+	}
+	if !isDir(t, f.project, "config") || f.project.ReadFile("config/db.yaml") != "db: 1\n" {
+		t.Error("Expected the local directory to be left intact")
+	}
+}
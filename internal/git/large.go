@@ -0,0 +1,48 @@
+package git
+
+import (
+	"fmt"
+
+	"cherry-go/internal/format"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/units"
+)
+
+// LargePathError reports a tracked path whose upstream files add up to more than options.max_path_size
+type LargePathError struct {
+	Include string
+	Size    int64
+	Budget  int64
+}
+
+func (e *LargePathError) Error() string {
+	return fmt.Sprintf("%s is %s upstream, over options.max_path_size (%s)", e.Include, format.Bytes(e.Size), units.FormatSize(e.Budget))
+}
+
+// SetAllowLarge lets paths over options.max_path_size sync for this run
+func (r *Repository) SetAllowLarge(allow bool) {
+	r.allowLarge = allow
+}
+
+// checkPathSize holds a path's upstream size against options.max_path_size, recording
+// a path over it unless --allow-large lets it sync. A path over the budget is reported
+// either way. It returns whether the sync of the path may go ahead.
+func (r *Repository) checkPathSize(include string, size int64) bool {
+	budget := r.options.MaxPathSize.Bytes()
+	if budget <= 0 {
+		return true
+	}
+	if size <= budget {
+		logger.Debug("%s is %s upstream, within options.max_path_size (%s)", include, format.Bytes(size), units.FormatSize(budget))
+		return true
+	}
+
+	largeErr := &LargePathError{Include: include, Size: size, Budget: budget}
+	if r.allowLarge {
+		logger.Warning("⚠️  %v; syncing it anyway (--allow-large)", largeErr)
+		return true
+	}
+	logger.Error("✗ %v", largeErr)
+	r.large = append(r.large, largeErr)
+	return false
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+)
+
+// UnresolvedMarkersError reports a tracked path whose files still hold conflict markers,
+// typically written by --mark-conflicts and not resolved yet
+type UnresolvedMarkersError struct {
+	Include string
+	Files   []string // Local paths of the files with markers
+}
+
+func (e *UnresolvedMarkersError) Error() string {
+	return fmt.Sprintf("%s still has conflict markers in %s — resolve them, or sync with --force to overwrite",
+		e.Include, strings.Join(e.Files, ", "))
+}
+
+// filesWithConflictMarkers lists the tracked files of a path that contain a complete
+// conflict hunk, as local paths. files holds the path's tracking keys: paths relative
+// to the directory, or the file's base name. A file that is byte for byte the upstream
+// one carries its markers from upstream and is left out.
+func filesWithConflictMarkers(sourcePath, localPath string, isDir bool, files map[string]string) []string {
+	var marked []string
+	for key := range files {
+		filePath, upstreamPath := localPath, sourcePath
+		if isDir {
+			filePath = filepath.Join(localPath, filepath.FromSlash(key))
+			upstreamPath = filepath.Join(sourcePath, filepath.FromSlash(key))
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil || len(merge.ParseConflictHunks(content)) == 0 {
+			continue
+		}
+		if upstream, err := os.ReadFile(upstreamPath); err == nil && bytes.Equal(upstream, content) {
+			continue
+		}
+		marked = append(marked, filepath.ToSlash(filePath))
+	}
+	sort.Strings(marked)
+	return marked
+}
+
+// checkConflictMarkers refuses to sync a path whose files still hold conflict markers:
+// their content is tracked as synced, so a merge would take them as resolved and a
+// detect would see no local change. Force mode overwrites them. It returns false when
+// the path must be skipped.
+func (r *Repository) checkConflictMarkers(pathSpec config.PathSpec, sourcePath, localPath string, isDir bool, mode SyncMode) bool {
+	if mode == SyncModeForce {
+		return true
+	}
+	marked := filesWithConflictMarkers(sourcePath, localPath, isDir, pathSpec.Files)
+	if len(marked) == 0 {
+		return true
+	}
+	for i, file := range marked {
+		marked[i] = filepath.ToSlash(r.projectPath(filepath.FromSlash(file)))
+	}
+
+	markersErr := &UnresolvedMarkersError{Include: pathSpec.Include, Files: marked}
+	logger.Error("✗ %v", markersErr)
+	r.unresolved = append(r.unresolved, markersErr)
+	return false
+}
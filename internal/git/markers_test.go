@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestFilesWithConflictMarkers(t *testing.T) {
+	sourceDir := t.TempDir()
+	localDir := t.TempDir()
+	marked := "a\n<<<<<<< local\nb\n=======\nB\n>>>>>>> remote\nc\n"
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	write(localDir, "marked.txt", marked)
+	write(sourceDir, "marked.txt", "a\nB\nc\n")
+	// Upstream ships these markers itself, e.g. in merge documentation
+	write(localDir, "upstream.txt", marked)
+	write(sourceDir, "upstream.txt", marked)
+	// A markdown heading underline is not a conflict
+	write(localDir, "heading.md", "Title\n=======\n")
+	write(sourceDir, "heading.md", "Title\n")
+	write(localDir, "untracked.txt", marked)
+
+	files := map[string]string{"marked.txt": "x", "upstream.txt": "x", "heading.md": "x", "missing.txt": "x"}
+	got := filesWithConflictMarkers(sourceDir, localDir, true, files)
+	expected := []string{filepath.ToSlash(filepath.Join(localDir, "marked.txt"))}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected only the locally marked tracked file, got %v", got)
+	}
+
+	single := filepath.Join(localDir, "marked.txt")
+	if got := filesWithConflictMarkers(filepath.Join(sourceDir, "marked.txt"), single, false, map[string]string{"marked.txt": "x"}); len(got) != 1 {
+		t.Errorf("Expected a tracked single file with markers to be found, got %v", got)
+	}
+}
+
+func TestCopyPaths_BinaryChangedOnBothSides(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("assets/logo.png", "\x89PNG\r\n\x00v1")
+	upstream.WriteFile("assets/icon.png", "\x89PNG\r\n\x00icon v1")
+	upstream.Commit("v1")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "assets/"}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+	project.Commit("sync v1")
+
+	upstream.WriteFile("assets/logo.png", "\x89PNG\r\n\x00v2 upstream")
+	upstream.WriteFile("assets/icon.png", "\x89PNG\r\n\x00icon v2")
+	upstream.Commit("v2")
+	local := "\x89PNG\r\n\x00v1 edited locally"
+	project.WriteFile("assets/logo.png", local)
+
+	for _, mode := range []SyncMode{SyncModeMerge, SyncModeMarkConflicts} {
+		result := syncFixture(t, source, mode, project.Dir)
+		if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "logo.png" || len(result.Conflicts[0].Hunks) > 0 {
+			t.Errorf("mode %v: expected logo.png to conflict without hunks, got %+v", mode, result.Conflicts)
+		}
+		if got := project.ReadFile("assets/logo.png"); got != local {
+			t.Errorf("mode %v: expected the local binary to be left as is, got %q", mode, got)
+		}
+	}
+
+	// The binary file only changed upstream is taken as is
+	if got := project.ReadFile("assets/icon.png"); got != "\x89PNG\r\n\x00icon v2" {
+		t.Errorf("Expected the unchanged local binary to take upstream's, got %q", got)
+	}
+}
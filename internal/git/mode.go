@@ -0,0 +1,172 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// defaultFilePerm is what files are created with when upstream's permissions aren't kept
+const defaultFilePerm os.FileMode = 0644
+
+// permLookup returns the permissions a file of a path has upstream, keyed like its
+// tracking hashes, and false when they aren't known
+type permLookup func(key string) (os.FileMode, bool)
+
+// sourcePerm returns the permissions a copy of the file at src is created with: its own
+// when preserve is set and it is a regular file, else defaultFilePerm
+func sourcePerm(src string, preserve bool) os.FileMode {
+	if !preserve {
+		return defaultFilePerm
+	}
+	info, err := os.Stat(src)
+	if err != nil || !info.Mode().IsRegular() {
+		return defaultFilePerm
+	}
+	return info.Mode().Perm()
+}
+
+// preservePermissions reports whether synced files take upstream's permissions. Windows
+// only has a read-only flag, which git doesn't record, so there they never do.
+func (r *Repository) preservePermissions() bool {
+	return r.options.PreservePermissionsEnabled() && runtime.GOOS != "windows"
+}
+
+// sourcePerm returns the permissions a file written from the clone's src is created with
+func (r *Repository) sourcePerm(src string) os.FileMode {
+	return sourcePerm(src, r.preservePermissions())
+}
+
+// checkoutPerms looks up upstream permissions in the clone's worktree at sourcePath, a
+// directory when isDir. Links are skipped unless they are followed, as their copies are
+// the links themselves. It returns nil when upstream's permissions aren't kept.
+func (r *Repository) checkoutPerms(sourcePath string, isDir bool) permLookup {
+	if !r.preservePermissions() {
+		return nil
+	}
+	return func(key string) (os.FileMode, bool) {
+		sourceFile := sourcePath
+		if isDir {
+			sourceFile = filepath.Join(sourcePath, filepath.FromSlash(key))
+		}
+		stat := os.Lstat
+		if r.options.FollowSymlinks {
+			stat = os.Stat
+		}
+		info, err := stat(sourceFile)
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Mode().Perm(), true
+	}
+}
+
+// treePerms looks up upstream permissions in the files of an upstream tree, keyed like
+// tracking hashes. It returns nil when upstream's permissions aren't kept.
+func (r *Repository) treePerms(files map[string]*object.File) permLookup {
+	if !r.preservePermissions() {
+		return nil
+	}
+	return func(key string) (os.FileMode, bool) {
+		file, ok := files[key]
+		if !ok {
+			return 0, false
+		}
+		mode, err := file.Mode.ToOSFileMode()
+		if err != nil || !mode.IsRegular() {
+			return 0, false
+		}
+		return mode.Perm(), true
+	}
+}
+
+// perm returns the upstream permissions of key, or defaultFilePerm when they aren't known
+func (l permLookup) perm(key string) os.FileMode {
+	if l != nil {
+		if mode, ok := l(key); ok {
+			return mode
+		}
+	}
+	return defaultFilePerm
+}
+
+// applyFileModes sets the permissions configured by mode/modes on the synced files of a
+// path, keyed like its tracking hashes, once sync or apply has written them; isDir tells
+// whether the keys are relative to a directory. Files without a configured mode get the
+// permissions upstream gives (nil when they aren't kept), so a script stays executable.
+// Permissions are metadata: they never take part in the content comparison, so a mode
+// upstream or locally doesn't make a file differ. With reportOnly (detect mode) files
+// whose permissions drifted are only reported. It returns the modes to track for the path
+// (nil when there are none) and whether any file changed.
+func (r *Repository) applyFileModes(input processPathInput, isDir bool, files map[string]string, upstream permLookup, reportOnly bool) (map[string]string, bool) {
+	// Hard-linked files share their permissions with the cache already
+	if input.pathSpec.HardLinked() {
+		upstream = nil
+	}
+	if !input.pathSpec.HasModes() && upstream == nil {
+		return nil, false
+	}
+
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	modes := make(map[string]string)
+	changed := false
+	for _, key := range keys {
+		mode, ok := input.pathSpec.FileMode(filepath.ToSlash(key))
+		origin := "configured"
+		if !ok && upstream != nil {
+			mode, ok = upstream(filepath.ToSlash(key))
+			origin = "upstream has"
+		}
+		if !ok {
+			continue
+		}
+		localPath, sourceFile := filepath.Clean(input.localPath), input.sourcePath // A directory spec may carry a trailing slash
+		if isDir {
+			localPath = filepath.Join(input.localPath, key)
+			sourceFile = filepath.Join(input.sourcePath, key)
+		}
+
+		info, err := os.Stat(localPath)
+		if err == nil && info.Mode().Perm() == mode {
+			modes[key] = config.FormatFileMode(mode)
+			continue
+		}
+		if err != nil && !(os.IsNotExist(err) && logger.IsDryRun()) {
+			r.recordFailure(input, sourceFile, err)
+			continue
+		}
+
+		if reportOnly {
+			if err == nil {
+				logger.Warning("⚠️  %s has mode %s, %s %s", r.projectPath(localPath), config.FormatFileMode(info.Mode()), origin, config.FormatFileMode(mode))
+			}
+			continue
+		}
+		if r.checkWrite(localPath) != nil {
+			continue
+		}
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would set mode %s on %s", config.FormatFileMode(mode), r.projectPath(localPath))
+		} else {
+			if err := os.Chmod(localPath, mode); err != nil {
+				r.recordFailure(input, sourceFile, err)
+				continue
+			}
+			logger.Info("🔐 Set mode %s on %s", config.FormatFileMode(mode), r.projectPath(localPath))
+		}
+		modes[key] = config.FormatFileMode(mode)
+		changed = true
+	}
+	return modes, changed
+}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// assertMode checks a local file's permissions
+func assertMode(t *testing.T, path string, expected os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	if info.Mode().Perm() != expected {
+		t.Errorf("%s has mode %04o, expected %04o", path, info.Mode().Perm(), expected)
+	}
+}
+
+func TestCopyPaths_FileModes(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("secrets/token.env", "TOKEN=x\n")
+	upstream.WriteFile("scripts/lint.sh", "#!/bin/sh\n")
+	upstream.WriteFile("scripts/README.md", "scripts\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "secrets/token.env", Mode: "0600"},
+		{Include: "scripts/", Mode: "0644", Modes: map[string]string{"lint.sh": "0755"}},
+	}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	assertMode(t, filepath.Join(project.Dir, "secrets/token.env"), 0o600)
+	assertMode(t, filepath.Join(project.Dir, "scripts/lint.sh"), 0o755)
+	assertMode(t, filepath.Join(project.Dir, "scripts/README.md"), 0o644)
+
+	if got := source.Paths[0].FileModes["token.env"]; got != "0600" {
+		t.Errorf("Expected token.env tracked as 0600, got %q", got)
+	}
+	if got := source.Paths[1].FileModes; got["lint.sh"] != "0755" || got["README.md"] != "0644" {
+		t.Errorf("Expected scripts/ modes tracked, got %v", got)
+	}
+
+	// A mode changed locally is metadata only: the next sync restores it without a merge
+	if err := os.Chmod(filepath.Join(project.Dir, "secrets/token.env"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a mode change, got %v", result.Conflicts)
+	}
+	if len(result.UpdatedPaths) != 1 || result.UpdatedPaths[0] != "secrets/token.env" {
+		t.Errorf("Expected only secrets/token.env updated, got %v", result.UpdatedPaths)
+	}
+	assertMode(t, filepath.Join(project.Dir, "secrets/token.env"), 0o600)
+}
+
+func TestCopyPaths_FileModesDetectOnlyReports(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("secrets/token.env", "TOKEN=x\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "secrets/token.env", Mode: "0600"}}}
+	syncFixture(t, source, SyncModeDetect, project.Dir)
+	assertMode(t, filepath.Join(project.Dir, "secrets/token.env"), 0o600)
+
+	if err := os.Chmod(filepath.Join(project.Dir, "secrets/token.env"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := syncFixture(t, source, SyncModeDetect, project.Dir)
+	if len(result.UpdatedPaths) != 0 {
+		t.Errorf("Expected detect mode to change nothing, got %v", result.UpdatedPaths)
+	}
+	assertMode(t, filepath.Join(project.Dir, "secrets/token.env"), 0o644)
+}
+
+func TestPlanTrackingFixes_Mode(t *testing.T) {
+	project, source := newTrackingFixture(t)
+	source.Paths[0].FileModes = map[string]string{"a.go": "0600"}
+	if err := os.Chmod(filepath.Join(project.Dir, "lib/a.go"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixes := PlanTrackingFixes(source, config.SyncOptions{})
+	if len(fixes) != 1 || fixes[0].Kind != TrackingMode || fixes[0].OldMode != "0600" || fixes[0].NewMode != "0644" {
+		t.Fatalf("Expected one mode fix 0600 -> 0644, got %v", fixes)
+	}
+	if got := fixes[0].String(); got != "lib/ a.go: mode 0644, tracked 0600" {
+		t.Errorf("Unexpected description %q", got)
+	}
+
+	ApplyTrackingFixes(source, fixes)
+	if got := source.Paths[0].FileModes["a.go"]; got != "0644" {
+		t.Errorf("Expected a.go tracked as 0644, got %q", got)
+	}
+}
+
+func TestCopyPaths_PreservesUpstreamPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no executable permission bit")
+	}
+	for _, mode := range []SyncMode{SyncModeForce, SyncModeMerge} {
+		t.Run(fmt.Sprintf("mode %d", mode), func(t *testing.T) {
+			logger.Init()
+			upstream := testutil.NewFixtureRepo(t, "library")
+			upstream.WriteFile("scripts/deploy.sh", "#!/bin/sh\necho deploy\n")
+			upstream.WriteFile("scripts/README.md", "scripts\n")
+			upstream.WriteFile("bin/run.sh", "#!/bin/sh\necho run\n")
+			upstream.Chmod("scripts/deploy.sh", 0o755)
+			upstream.Chmod("bin/run.sh", 0o755)
+			upstream.Commit("initial")
+			project := testutil.NewProject(t)
+			project.Chdir()
+
+			source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+				{Include: "scripts/"},
+				{Include: "bin/run.sh"},
+			}}
+			syncFixture(t, source, mode, project.Dir)
+
+			assertMode(t, filepath.Join(project.Dir, "scripts/deploy.sh"), 0o755)
+			assertMode(t, filepath.Join(project.Dir, "scripts/README.md"), 0o644)
+			assertMode(t, filepath.Join(project.Dir, "bin/run.sh"), 0o755)
+			if got := source.Paths[0].FileModes; got["deploy.sh"] != "0755" || got["README.md"] != "0644" {
+				t.Errorf("Expected upstream permissions tracked, got %v", got)
+			}
+
+			// An update keeps the executable bit, as does a file that lost it locally
+			upstream.WriteFile("scripts/deploy.sh", "#!/bin/sh\necho deploy v2\n")
+			upstream.WriteFile("bin/run.sh", "#!/bin/sh\necho run v2\n")
+			upstream.Commit("update scripts")
+			if err := os.Chmod(filepath.Join(project.Dir, "bin/run.sh"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			result := syncFixture(t, source, mode, project.Dir)
+			if len(result.Conflicts) != 0 {
+				t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+			}
+			if got := project.ReadFile("scripts/deploy.sh"); got != "#!/bin/sh\necho deploy v2\n" {
+				t.Errorf("Expected the update to be synced, got %q", got)
+			}
+			assertMode(t, filepath.Join(project.Dir, "scripts/deploy.sh"), 0o755)
+			assertMode(t, filepath.Join(project.Dir, "bin/run.sh"), 0o755)
+
+			// A permission change upstream alone is synced too
+			upstream.Chmod("scripts/README.md", 0o755)
+			upstream.Commit("make README executable")
+			result = syncFixture(t, source, mode, project.Dir)
+			if len(result.UpdatedPaths) != 1 || result.UpdatedPaths[0] != "scripts/" {
+				t.Errorf("Expected only scripts/ updated, got %v", result.UpdatedPaths)
+			}
+			assertMode(t, filepath.Join(project.Dir, "scripts/README.md"), 0o755)
+			if got := source.Paths[0].FileModes["README.md"]; got != "0755" {
+				t.Errorf("Expected README.md tracked as 0755, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCopyPaths_PreservePermissionsDisabled(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("scripts/deploy.sh", "#!/bin/sh\n")
+	upstream.Chmod("scripts/deploy.sh", 0o755)
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	disabled := false
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "scripts/"}}}
+	syncFixtureWith(t, source, SyncModeForce, project.Dir, config.SyncOptions{PreservePermissions: &disabled})
+
+	assertMode(t, filepath.Join(project.Dir, "scripts/deploy.sh"), 0o644)
+	if got := source.Paths[0].FileModes; got != nil {
+		t.Errorf("Expected no modes tracked, got %v", got)
+	}
+}
+
+func TestVerifyTracking_Mode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no executable permission bit")
+	}
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("scripts/deploy.sh", "#!/bin/sh\n")
+	upstream.Chmod("scripts/deploy.sh", 0o755)
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "scripts/"}}}
+	syncFixture(t, source, SyncModeForce, project.Dir)
+	if err := os.Chmod(filepath.Join(project.Dir, "scripts/deploy.sh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	drifts, err := VerifyTracking(source, config.SyncOptions{})
+	if err != nil {
+		t.Fatalf("VerifyTracking failed: %v", err)
+	}
+	if len(drifts) != 1 || len(drifts[0].Files) != 1 {
+		t.Fatalf("Expected one drifted file, got %+v", drifts)
+	}
+	if file := drifts[0].Files[0]; file.Type != hash.ConflictTypeMode || file.ExpectedMode != "0755" || file.ActualMode != "0644" {
+		t.Errorf("Expected a mode change from 0755 to 0644, got %+v", file)
+	}
+
+	if changed := AcceptDrift(source, drifts); changed != 1 || source.Paths[0].FileModes["deploy.sh"] != "0644" {
+		t.Errorf("Expected the local mode to be accepted, got %d change(s), modes %v", changed, source.Paths[0].FileModes)
+	}
+}
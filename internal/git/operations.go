@@ -1,8 +1,10 @@
 package git
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -10,17 +12,18 @@ import (
 	"time"
 
 	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 
 	"cherry-go/internal/cache"
 	"cherry-go/internal/config"
 	"cherry-go/internal/hash"
 	"cherry-go/internal/logger"
 	"cherry-go/internal/merge"
+	"cherry-go/internal/utils"
 )
 
 // SyncMode defines the synchronization mode
@@ -34,35 +37,91 @@ const (
 	SyncModeMarkConflicts                 // Write conflict markers to files without committing
 )
 
+// Merges reports whether the mode three-way merges files changed on both sides
+func (m SyncMode) Merges() bool {
+	return m == SyncModeMerge || m == SyncModeBranch || m == SyncModeMarkConflicts
+}
+
 // Repository represents a Git repository wrapper
 type Repository struct {
-	repo   *git.Repository
-	path   string
-	source *config.Source
+	repo        *git.Repository
+	path        string
+	source      *config.Source
+	options     config.SyncOptions
+	baseManager *cache.BaseContentManager
+
+	root              string                    // Absolute project root local paths resolve against, set by CopyPaths
+	ephemeral         bool                      // path is a temporary clone that Close removes
+	overrideProtected bool                      // Allow writes to options.protected_paths for this run
+	freezeTracking    bool                      // Leave tracking hashes, commits and base snapshots alone
+	allowEmpty        bool                      // Sync directories that match no files instead of refusing them
+	allowLarge        bool                      // Sync paths over options.max_path_size instead of refusing them
+	noFetch           bool                      // Never fetch, not even history a shallow clone lacks
+	out               io.Writer                 // Where conflict diffs are rendered; stdout when unset
+	refused           []*ProtectedPathError     // Protected writes refused during CopyPaths
+	failed            []FileFailure             // Files that failed to read or copy during CopyPaths
+	empty             []*EmptyPathError         // Directories skipped during CopyPaths for matching no files
+	large             []*LargePathError         // Paths skipped during CopyPaths for exceeding options.max_path_size
+	unresolved        []*UnresolvedMarkersError // Paths skipped during CopyPaths for holding conflict markers
+	metrics           SyncMetrics               // File work done during CopyPaths
+	changedFiles      *changedFilesCache        // Files changed by recently read upstream commits, for conflict attribution (shared by path workers)
+	attributes        *projectAttributes        // The project's .gitattributes, read up front under options.eol: auto
 }
 
 // SyncResult represents the result of a sync operation
 type SyncResult struct {
 	SourceName        string
 	UpdatedPaths      []string
-	CommitHash        string
+	CommitHash        string            // Commit of the first updated path (or first path if none updated)
+	PathCommits       map[string]string // include -> upstream commit the path was synced from
+	LinkTargets       map[string]string // include -> path it links to upstream, for includes that are symbolic links
 	HasChanges        bool
 	Conflicts         []hash.FileConflict
-	BranchCreated     string // Name of conflict branch if created
-	MergeInstructions string // Instructions for manual merge
+	BranchCreated     string                // Name of conflict branch if created
+	PlannedBranch     *ConflictBranchResult // Conflict branch a dry run would create
+	PlannedCommit     *PlannedCommit        // Project commit a dry run would create
+	MergeInstructions string                // Instructions for manual merge, previewed in a dry run
+	FileActions       []FileAction
+	Untracked         []hash.FileConflict // Local files in managed directories that upstream doesn't have
+	Failed            []FileFailure       // Files that could not be synced; their paths are only partially synced
+	Skipped           error               // Why an optional source was skipped (auth or clone failed)
+	Duplicate         bool                // The source already synced earlier in the run and was left alone
+	Metrics           SyncMetrics         // Files compared, copied and hashed
+	ProjectCommit     string              // Commit auto_commit created in the project, if any
 	Error             error
 }
 
 // CopyResult represents the result of copying paths
 type CopyResult struct {
 	UpdatedPaths      []string
+	CommitPaths       []string          // Local paths of the updated paths, for auto_commit to stage
+	PathCommits       map[string]string // include -> upstream commit the path was compared against
+	LinkTargets       map[string]string // include -> path it links to upstream, for includes that are symbolic links
 	Conflicts         []hash.FileConflict
 	BranchCreated     string
+	PlannedBranch     *ConflictBranchResult // Conflict branch a dry run would create
 	MergeInstructions string
+	FileActions       []FileAction
+	Refused           []*ProtectedPathError     // Writes refused by options.protected_paths
+	Empty             []*EmptyPathError         // Directories skipped because no files are left after excludes
+	Large             []*LargePathError         // Paths skipped because upstream exceeds options.max_path_size
+	Unresolved        []*UnresolvedMarkersError // Paths skipped because their files still hold conflict markers
+	Untracked         []hash.FileConflict       // Local files in managed directories that upstream doesn't have
+	Failed            []FileFailure             // Files that failed to read or copy
+	Metrics           SyncMetrics               // Files compared, copied and hashed
 }
 
 // NewRepository creates a new repository wrapper using global cache
 func NewRepository(source *config.Source) (*Repository, error) {
+	return NewRepositoryContext(context.Background(), source)
+}
+
+// NewRepositoryContext is NewRepository with a context bounding the initial clone
+func NewRepositoryContext(ctx context.Context, source *config.Source) (*Repository, error) {
+	if source.Ephemeral() || noCacheDir != "" {
+		return newEphemeralRepository(ctx, source)
+	}
+
 	// Initialize cache manager
 	cacheManager, err := cache.NewManager()
 	if err != nil {
@@ -81,15 +140,25 @@ func NewRepository(source *config.Source) (*Repository, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to open cached repository: %w", err)
 		}
+		if err := checkOriginURL(repo, source, repoPath); err != nil {
+			return nil, err
+		}
 	} else {
 		// Clone repository to cache
 		logger.Info("Cloning repository %s to cache: %s", source.Repository, repoPath)
-		repo, err = cloneRepository(source, repoPath)
+		repo, err = cloneRepository(ctx, source, repoPath)
 		if err != nil {
+			// Don't leave a partial clone behind that would later pass for a cached repository
+			_ = os.RemoveAll(repoPath)
 			return nil, fmt.Errorf("failed to clone repository: %w", err)
 		}
 	}
 
+	// Lets cache list map the entry back to its URL and see when it was last used
+	if err := cacheManager.RecordUse(source.Repository); err != nil {
+		logger.Debug("Failed to record cache usage for %s: %v", source.Repository, err)
+	}
+
 	return &Repository{
 		repo:   repo,
 		path:   repoPath,
@@ -97,18 +166,68 @@ func NewRepository(source *config.Source) (*Repository, error) {
 	}, nil
 }
 
-// cloneRepository clones a repository with authentication (full clone for branch flexibility)
-func cloneRepository(source *config.Source, repoPath string) (*git.Repository, error) {
+// SetFreezeTracking makes CopyPaths sync files without recording their hashes, commits or
+// base snapshots, for runs whose content doesn't correspond to the configured branch
+func (r *Repository) SetFreezeTracking(freeze bool) {
+	r.freezeTracking = freeze
+}
+
+// SetOutput sets where conflict diffs are rendered during CopyPaths
+func (r *Repository) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// output returns the writer conflict diffs are rendered to
+func (r *Repository) output() io.Writer {
+	if r.out == nil {
+		return os.Stdout
+	}
+	return r.out
+}
+
+// SetSyncOptions applies project-wide sync options (rename detection, etc.)
+func (r *Repository) SetSyncOptions(options config.SyncOptions) {
+	r.options = options
+}
+
+// baseContentManager lazily opens the base-content snapshot store, returning nil if unavailable
+// or turned off with options.base_snapshots
+func (r *Repository) baseContentManager() *cache.BaseContentManager {
+	if !r.options.BaseSnapshotsEnabled() {
+		return nil
+	}
+	if r.baseManager == nil {
+		manager, err := cache.NewBaseContentManager()
+		if err != nil {
+			logger.Debug("Base-content snapshots unavailable: %v", err)
+			return nil
+		}
+		r.baseManager = manager
+	}
+	return r.baseManager
+}
+
+// cloneRepository clones a repository with authentication: every branch, with all of their
+// history unless the source sets a depth
+func cloneRepository(ctx context.Context, source *config.Source, repoPath string) (*git.Repository, error) {
 	auth, err := getAuth(source.Auth, source.Repository)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get authentication: %w", err)
 	}
 
+	caBundle, proxy, err := transportOptions(source)
+	if err != nil {
+		return nil, err
+	}
+
 	cloneOptions := &git.CloneOptions{
-		URL:  source.Repository,
-		Auth: auth,
+		URL:          source.Repository,
+		Auth:         auth,
+		CABundle:     caBundle,
+		ProxyOptions: proxy,
 		// Don't specify SingleBranch or ReferenceName to get all branches
 		// This allows us to checkout any branch/tag later
+		Depth: source.CloneDepth,
 	}
 
 	if logger.IsDryRun() {
@@ -116,7 +235,8 @@ func cloneRepository(source *config.Source, repoPath string) (*git.Repository, e
 		return nil, nil
 	}
 
-	return git.PlainClone(repoPath, false, cloneOptions)
+	repo, err := git.PlainCloneContext(ctx, repoPath, false, cloneOptions)
+	return repo, withCACertHint(withAuthError(err, source), source)
 }
 
 // getAuth creates authentication based on config and repository URL
@@ -125,7 +245,7 @@ func getAuth(authConfig config.AuthConfig, repoURL string) (transport.AuthMethod
 	if strings.HasPrefix(repoURL, "git@") {
 		// SSH URL detected
 		if authConfig.Type == "" || authConfig.Type == "auto" || authConfig.Type == "ssh" {
-			return getSSHAuth(authConfig.SSHKey)
+			return getSSHAuth(authConfig)
 		}
 	}
 
@@ -135,19 +255,19 @@ func getAuth(authConfig config.AuthConfig, repoURL string) (transport.AuthMethod
 		// If parsing fails and it looks like SSH, try SSH auth
 		if strings.Contains(repoURL, "@") && strings.Contains(repoURL, ":") {
 			logger.Debug("URL parsing failed, assuming SSH format")
-			return getSSHAuth(authConfig.SSHKey)
+			return getSSHAuth(authConfig)
 		}
 		return nil, fmt.Errorf("failed to parse repository URL: %w", err)
 	}
 
 	// Auto-detect authentication method if not specified
 	if authConfig.Type == "" || authConfig.Type == "auto" {
-		return getAutoAuth(parsedURL)
+		return getAutoAuth(authConfig, parsedURL)
 	}
 
 	switch authConfig.Type {
 	case "ssh":
-		return getSSHAuth(authConfig.SSHKey)
+		return getSSHAuth(authConfig)
 
 	case "basic":
 		return getBasicAuth(authConfig.Username)
@@ -158,17 +278,17 @@ func getAuth(authConfig config.AuthConfig, repoURL string) (transport.AuthMethod
 }
 
 // getAutoAuth automatically detects and configures authentication
-func getAutoAuth(parsedURL *url.URL) (transport.AuthMethod, error) {
+func getAutoAuth(authConfig config.AuthConfig, parsedURL *url.URL) (transport.AuthMethod, error) {
 	switch {
 	case parsedURL.Scheme == "ssh" || strings.HasPrefix(parsedURL.String(), "git@"):
 		// For SSH URLs, use SSH authentication
 		logger.Debug("Auto-detecting SSH authentication for %s", parsedURL.Host)
-		return getSSHAuth("")
+		return getSSHAuth(authConfig)
 
 	case parsedURL.Scheme == "https":
 		// For HTTPS URLs, try token from environment first
 		logger.Debug("Auto-detecting HTTPS authentication for %s", parsedURL.Host)
-		auth, err := getHTTPSAuth()
+		auth, err := getHTTPSAuth(authConfig, parsedURL.Host)
 		if err == nil && auth != nil {
 			return auth, nil
 		}
@@ -183,87 +303,16 @@ func getAutoAuth(parsedURL *url.URL) (transport.AuthMethod, error) {
 	}
 }
 
-// getSSHAuth configures SSH authentication using SSH agent or specific key
-func getSSHAuth(keyPath string) (transport.AuthMethod, error) {
-	if keyPath != "" {
-		// Use specific SSH key
-		logger.Debug("Using SSH key: %s", keyPath)
-		publicKeys, err := ssh.NewPublicKeysFromFile("git", keyPath, "")
-		if err != nil {
-			return nil, fmt.Errorf("failed to load SSH key %s: %w", keyPath, err)
-		}
-		return publicKeys, nil
-	}
-
-	// Try to use SSH agent
-	logger.Debug("Using SSH agent authentication")
-	sshAuth, err := ssh.NewSSHAgentAuth("git")
-	if err != nil {
-		logger.Debug("SSH agent not available: %v", err)
-		// Fallback to default SSH key
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-
-		defaultKeyPath := filepath.Join(homeDir, ".ssh", "id_rsa")
-		if _, err := os.Stat(defaultKeyPath); err == nil {
-			logger.Debug("Falling back to default SSH key: %s", defaultKeyPath)
-			publicKeys, err := ssh.NewPublicKeysFromFile("git", defaultKeyPath, "")
-			if err != nil {
-				return nil, fmt.Errorf("failed to load default SSH key: %w", err)
-			}
-			return publicKeys, nil
-		}
-
-		return nil, fmt.Errorf("no SSH authentication method available")
-	}
-
-	return sshAuth, nil
-}
-
-// getHTTPSAuth configures HTTPS authentication using environment variables
-func getHTTPSAuth() (transport.AuthMethod, error) {
-	// Try GitHub token from environment
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-		logger.Debug("Using GitHub token from environment")
-		return &http.BasicAuth{
-			Username: "token",
-			Password: token,
-		}, nil
-	}
-
-	// Try GitLab token from environment
-	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
-		logger.Debug("Using GitLab token from environment")
-		return &http.BasicAuth{
-			Username: "oauth2",
-			Password: token,
-		}, nil
-	}
-
-	// Try generic Git token from environment
-	if token := os.Getenv("GIT_TOKEN"); token != "" {
-		logger.Debug("Using generic Git token from environment")
-		return &http.BasicAuth{
-			Username: "token",
-			Password: token,
-		}, nil
-	}
-
-	// Try Git credentials from environment
-	if username := os.Getenv("GIT_USERNAME"); username != "" {
-		if password := os.Getenv("GIT_PASSWORD"); password != "" {
-			logger.Debug("Using Git credentials from environment")
-			return &http.BasicAuth{
-				Username: username,
-				Password: password,
-			}, nil
-		}
+// getHTTPSAuth configures HTTPS authentication for a host using environment variables
+func getHTTPSAuth(authConfig config.AuthConfig, host string) (transport.AuthMethod, error) {
+	credential := selectToken(authConfig, host)
+	if credential == nil {
+		logger.Debug("No HTTPS authentication found in environment variables for %s", host)
+		return nil, nil
 	}
 
-	logger.Debug("No HTTPS authentication found in environment variables")
-	return nil, nil
+	logger.Debug("Using %s for %s", credential.rule, host)
+	return credential.auth, nil
 }
 
 // getBasicAuth configures basic authentication using environment variables
@@ -287,16 +336,47 @@ func getBasicAuth(username string) (transport.AuthMethod, error) {
 	}, nil
 }
 
-// Pull fetches the latest changes from remote
+// Pull fetches every branch and tag of the remote into the cache
 func (r *Repository) Pull() error {
+	// Pinned paths never move, so there is nothing to fetch once their commits are cached
+	if r.pinsCached() {
+		logger.Info("📌 %s: every path is pinned to a commit already in the cache, not fetching", r.source.Name)
+		return nil
+	}
+
 	if logger.IsDryRun() {
 		logger.DryRunInfo("Would pull latest changes for %s", r.source.Name)
 		return nil
 	}
 
-	workTree, err := r.repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+	// Split off hard links that must not follow the cache update
+	if err := r.repairLinks(); err != nil {
+		return err
+	}
+
+	// Only fetch: pulling would merge into whatever branch the worktree is on, and
+	// checkoutBranch moves each tracked branch to its remote-tracking ref instead
+	return r.Fetch(context.Background())
+}
+
+// fetchRefSpecs fetch all branches as remote-tracking refs, not just the cloned
+// one, and force-update tags that were moved upstream
+var fetchRefSpecs = []gitconfig.RefSpec{
+	"+refs/heads/*:refs/remotes/origin/*",
+	"+refs/tags/*:refs/tags/*",
+}
+
+// Fetch updates the cached clone's remote branches and tags without touching its worktree
+func (r *Repository) Fetch(ctx context.Context) error {
+	return r.fetch(ctx, r.fetchDepth())
+}
+
+// fetch fetches the remote's branches and tags, depth commits deep on each unless depth is
+// 0. Fetching at unshallowDepth leaves the clone with its whole history.
+func (r *Repository) fetch(ctx context.Context, depth int) error {
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would fetch latest changes for %s", r.source.Name)
+		return nil
 	}
 
 	auth, err := getAuth(r.source.Auth, r.source.Repository)
@@ -304,15 +384,29 @@ func (r *Repository) Pull() error {
 		return fmt.Errorf("failed to get authentication: %w", err)
 	}
 
-	pullOptions := &git.PullOptions{
-		Auth: auth,
+	caBundle, proxy, err := transportOptions(r.source)
+	if err != nil {
+		return err
 	}
 
-	err = workTree.Pull(pullOptions)
+	err = r.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName:   "origin",
+		RefSpecs:     fetchRefSpecs,
+		Auth:         auth,
+		CABundle:     caBundle,
+		ProxyOptions: proxy,
+		Depth:        depth,
+	})
 	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to pull: %w", err)
+		return fmt.Errorf("failed to fetch: %w", withCACertHint(withAuthError(err, r.source), r.source))
 	}
 
+	// go-git adds the commits a fetch cut off to the shallow list but never takes them out
+	if depth == unshallowDepth {
+		if err := r.repo.Storer.SetShallow(nil); err != nil {
+			return fmt.Errorf("failed to record the full history: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -326,108 +420,450 @@ func (r *Repository) GetLatestCommit() (string, error) {
 	return ref.Hash().String(), nil
 }
 
+// GetCommitForRef resolves a branch, tag, or commit to its commit hash without
+// checking it out. An empty ref resolves the default branch.
+func (r *Repository) GetCommitForRef(ref string) (string, error) {
+	if r.repo == nil {
+		return "", fmt.Errorf("repository not available")
+	}
+
+	if ref == "" {
+		ref = r.detectDefaultBranch()
+	}
+
+	// Remote-tracking branches win, as checkoutBranch moves local ones onto them,
+	// then local branches, tags and raw revisions
+	candidates := []plumbing.Revision{
+		plumbing.Revision("refs/remotes/origin/" + ref),
+		plumbing.Revision("refs/heads/" + ref),
+		plumbing.Revision("refs/tags/" + ref),
+		plumbing.Revision(ref),
+	}
+
+	for _, candidate := range candidates {
+		hash, err := r.repo.ResolveRevision(candidate)
+		if err == nil {
+			return hash.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to resolve '%s': not a valid branch, tag, or commit", ref)
+}
+
+// CommitTime returns when a commit in the cached clone was made
+func (r *Repository) CommitTime(commit string) (time.Time, error) {
+	object, err := r.repo.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read commit %s: %w", ShortHash(commit), err)
+	}
+	return object.Committer.When, nil
+}
+
+// ShortHash abbreviates a commit hash for display
+func ShortHash(commit string) string {
+	if commit == "" {
+		return "unknown"
+	}
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
 // CopyPaths copies specified paths from the repository to local directory
 // mode: SyncModeMerge (default), SyncModeForce, or SyncModeBranch
 // workDir: the local working directory (for branch creation)
 func (r *Repository) CopyPaths(mode SyncMode, workDir string) (*CopyResult, error) {
-	result := &CopyResult{}
-	hasher := hash.NewFileHasher()
+	result := &CopyResult{PathCommits: make(map[string]string), LinkTargets: make(map[string]string)}
+	hasher := hash.NewFileHasherFor(r.options)
+	r.refused = nil
+	r.failed = nil
+	r.empty = nil
+	r.large = nil
+	r.unresolved = nil
+	r.metrics = SyncMetrics{}
+
+	// Every local path is resolved against the project root, whatever the process's directory
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the project root %s: %w", workDir, err)
+	}
+	r.root = root
 
-	// Collect files for potential branch creation
-	var conflictFiles map[string][]byte
+	// A dry run doesn't clone, so an uncached source has nothing to compare against yet
+	if r.repo == nil && logger.IsDryRun() {
+		logger.DryRunInfo("Would sync every path of %s once it is cloned; nothing to compare against yet", r.source.Name)
+		return result, nil
+	}
+
+	// Opened up front since paths sync concurrently and share them
+	r.baseContentManager()
+	r.changedFiles = newChangedFilesCache()
+	if r.options.EOLPolicy() == config.EOLAuto {
+		r.attributes = loadProjectAttributes(workDir)
+	}
+
+	// Pins are resolved up front: a pin upstream doesn't have fails the source untouched
+	refs, err := r.pathRefs()
+	if err != nil {
+		return nil, err
+	}
 
-	for i, pathSpec := range r.source.Paths {
-		// Checkout the specific branch/tag for this path
-		if err := r.checkoutBranch(pathSpec.Branch); err != nil {
-			logger.Error("Failed to checkout branch '%s' for %s: %v", pathSpec.Branch, pathSpec.Include, err)
+	// Paths read from the same branch share its checkout and sync concurrently; the
+	// groups take turns, and results are applied in path order whatever order they finish in
+	syncs := make([]*pathSync, len(r.source.Paths))
+	for _, group := range r.checkoutGroups(mode, refs) {
+		if err := r.checkoutBranch(group.branch); err != nil {
+			for _, i := range group.paths {
+				logger.Error("Failed to checkout branch '%s' for %s: %v", group.branch, r.source.Paths[i].Include, err)
+			}
 			continue
 		}
-
-		// Determine local path - use specified path or default to same as source
-		localPath := pathSpec.LocalPath
-		if localPath == "" {
-			localPath = pathSpec.Include
+		// Record the commit each path is read from, independent of the cache HEAD
+		commits := make(map[int]string, len(group.paths))
+		for _, i := range group.paths {
+			commit, err := r.GetCommitForRef(refs[i])
+			if err != nil {
+				logger.Debug("Could not resolve commit for %s: %v", r.source.Paths[i].Include, err)
+			}
+			if err := r.verifyPinnedCheckout(r.source.Paths[i], commit); err != nil {
+				return nil, err
+			}
+			commits[i] = commit
 		}
 
-		sourcePath := filepath.Join(r.path, pathSpec.Include)
+		runBounded(group.paths, r.options.Parallelism(), func(i int) {
+			syncs[i] = r.syncPath(i, commits[i], mode, workDir, hasher)
+		})
+	}
 
-		// Check if source path exists
-		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-			logger.Error("Source path does not exist: %s", sourcePath)
+	// Collect files for potential branch creation
+	var conflictFiles map[string][]byte
+	for i, synced := range syncs {
+		if synced == nil {
 			continue
 		}
-
-		srcInfo, err := os.Stat(sourcePath)
-		if err != nil {
-			logger.Error("Failed to stat source path %s: %v", sourcePath, err)
-			continue
+		r.applyPathSync(i, synced, result)
+		for localPath, content := range synced.conflictFiles {
+			if conflictFiles == nil {
+				conflictFiles = make(map[string][]byte)
+			}
+			conflictFiles[localPath] = content
 		}
+	}
 
-		// Process based on mode
-		pathResult, pathConflicts := r.processPath(processPathInput{
-			pathSpec:   pathSpec,
-			sourcePath: sourcePath,
-			localPath:  localPath,
-			srcInfo:    srcInfo,
-			mode:       mode,
-			hasher:     hasher,
-			workDir:    workDir,
-		})
+	// Create conflict branch if needed
+	if mode == SyncModeBranch && len(result.Conflicts) > 0 && conflictFiles != nil && len(conflictFiles) > 0 {
+		branchPrefix := "cherry-go/sync"
 
-		if len(pathConflicts) > 0 {
-			result.Conflicts = append(result.Conflicts, pathConflicts...)
+		// Protected files are left out of the conflict branch too
+		for localPath := range conflictFiles {
+			if r.checkWrite(localPath) != nil {
+				delete(conflictFiles, localPath)
+			}
+		}
 
-			// Collect conflict files for branch creation
-			if mode == SyncModeBranch {
-				if conflictFiles == nil {
-					conflictFiles = make(map[string][]byte)
-				}
-				// Read remote files for branch
-				remoteFiles := r.readRemoteFiles(sourcePath, localPath, srcInfo.IsDir(), pathSpec.Exclude)
-				for k, v := range remoteFiles {
-					conflictFiles[k] = v
+		if len(conflictFiles) > 0 {
+			branchResult, err := CreateConflictBranch(workDir, branchPrefix, r.source.Name, conflictFiles, r.options)
+			if err != nil {
+				logger.Error("Failed to create conflict branch: %v", err)
+			} else {
+				if branchResult.Planned {
+					result.PlannedBranch = branchResult
+				} else {
+					result.BranchCreated = branchResult.BranchName
 				}
+				result.MergeInstructions = GetMergeInstructions(branchResult)
 			}
 		}
+	}
+
+	result.Refused = r.refused
+	result.Failed = r.failed
+	result.Empty = r.empty
+	result.Large = r.large
+	result.Unresolved = r.unresolved
+	result.Metrics = r.metrics
+	return result, nil
+}
+
+// syncPathSpec syncs the path at index, read from commit with its branch checked out,
+// into result and returns the remote files to put on a conflict branch
+func (r *Repository) syncPathSpec(index int, commit string, mode SyncMode, workDir string, hasher *hash.FileHasher, result *CopyResult) map[string][]byte {
+	var conflictFiles map[string][]byte
+	i, pathSpec := index, r.source.Paths[index]
+	if commit != "" {
+		result.PathCommits[pathSpec.Include] = commit
+	}
 
-		if pathResult.updated {
-			result.UpdatedPaths = append(result.UpdatedPaths, pathSpec.Include)
+	// A pattern that spells out a name upstream has, like docs/notes[1].md, tracks just it
+	if pathSpec.IsPattern() {
+		r.escapeLiteralInclude(i, &pathSpec, result)
+	}
 
-			// Update hashes in path spec
-			r.source.Paths[i].Files = pathResult.newHashes
+	// Determine local path - use specified path or default to same as source. Files are
+	// read and written at destPath, its absolute form; localPath is what reports name.
+	localPath := pathSpec.LocalRoot()
+
+	// Nothing is written outside the project, whatever the configuration says
+	destPath, err := config.ResolveLocalPath(r.root, localPath)
+	if err != nil {
+		r.recordFailure(processPathInput{pathSpec: pathSpec, localPath: localPath}, localPath, err)
+		return conflictFiles
+	}
+
+	// A linked include (latest/ -> v2/) is read from wherever it points at this commit
+	sourcePath, linkTarget, err := resolveSourceLink(r.path, pathSpec.SourceRoot())
+	if err != nil {
+		r.recordFailure(processPathInput{pathSpec: pathSpec, sourcePath: sourcePath, localPath: destPath}, sourcePath, err)
+		return conflictFiles
+	}
 
-			logger.Info("Synced %s to %s", pathSpec.Include, localPath)
+	// Check if source path exists
+	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+		// A pattern rooted in a directory upstream doesn't have matches nothing
+		if pathSpec.IsPattern() {
+			r.reportEmpty(pathSpec.Include)
+			return conflictFiles
 		}
+		logger.Error("Source path does not exist: %s", sourcePath)
+		return conflictFiles
 	}
 
-	// Create conflict branch if needed
-	if mode == SyncModeBranch && len(result.Conflicts) > 0 && conflictFiles != nil && len(conflictFiles) > 0 {
-		branchPrefix := "cherry-go/sync"
-		if r.source.Name != "" {
-			branchPrefix = "cherry-go/sync"
+	srcInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		r.recordFailure(processPathInput{pathSpec: pathSpec, sourcePath: sourcePath, localPath: destPath}, sourcePath, err)
+		return conflictFiles
+	}
+
+	// Directories also skip the built-in junk patterns, and a pattern include the files its
+	// glob doesn't match, everywhere pathSpec.Excludes is used
+	pathSpec.Excludes = config.Excludes{Patterns: pathSpec.Exclude}
+	if srcInfo.IsDir() {
+		pathSpec.Excludes = r.options.PathExcludes(r.source, pathSpec)
+	}
+
+	// Upstream may have turned a file into a directory or the reverse
+	kindChanged := localKindChanged(destPath, srcInfo)
+
+	// Now the kind is known, settle the spec's canonical form ("src" becomes "src/")
+	if !kindChanged && !pathSpec.IsPattern() {
+		r.canonicalizePathSpec(i, &pathSpec, srcInfo.IsDir(), result)
+		localPath = pathSpec.LocalRoot()
+	}
+
+	if linkTarget != "" {
+		result.LinkTargets[pathSpec.Include] = linkTarget
+		logger.Info("🔗 %s links to %s at %s", pathSpec.Include, linkTarget, ShortHash(commit))
+	}
+
+	// Verbose syncs say which patterns left out which upstream files
+	if srcInfo.IsDir() {
+		reportExcludes(pathSpec.Include, sourcePath, pathSpec.Excludes)
+	}
+
+	// A directory excluded down to nothing is a pattern mistake, not an empty upstream;
+	// the walk that finds out also measures it against options.max_path_size
+	if srcInfo.IsDir() && !r.checkDirectoryFiles(pathSpec.Include, sourcePath, pathSpec.Excludes) {
+		return conflictFiles
+	}
+	if !srcInfo.IsDir() && !r.checkPathSize(pathSpec.Include, srcInfo.Size()) {
+		return conflictFiles
+	}
+
+	// Markers left by --mark-conflicts must be resolved before syncing over them
+	if !kindChanged && !r.checkConflictMarkers(pathSpec, sourcePath, destPath, srcInfo.IsDir(), mode) {
+		return conflictFiles
+	}
+
+	// Local additions inside managed directories are never removed, only reported
+	if srcInfo.IsDir() && !kindChanged {
+		untracked, proceed := r.checkUntracked(pathSpec, sourcePath, destPath, hasher)
+		result.Untracked = append(result.Untracked, untracked...)
+		if !proceed {
+			return conflictFiles
 		}
+	}
 
-		branchResult, err := CreateConflictBranch(workDir, branchPrefix, r.source.Name, conflictFiles)
-		if err != nil {
-			logger.Error("Failed to create conflict branch: %v", err)
+	// Remember the local files so per-file changes can be reported afterwards
+	before := captureLocalFiles(destPath, srcInfo.IsDir(), hasher)
+	refusedBefore := len(r.refused)
+	failedBefore := len(r.failed)
+
+	// Follow upstream renames first so moved files merge against their old content
+	var renames []FileAction
+	renamedFrom := make(map[string]string)
+	if srcInfo.IsDir() && !kindChanged {
+		renames = r.followRenames(pathSpec, sourcePath, destPath, hasher)
+		for _, rename := range renames {
+			newRel, _ := filepath.Rel(destPath, rename.Path)
+			oldRel, _ := filepath.Rel(destPath, rename.OldPath)
+			renamedFrom[newRel] = oldRel
+		}
+	}
+
+	// Process based on mode
+	pathResult, pathConflicts := r.processPath(processPathInput{
+		pathSpec:    pathSpec,
+		sourcePath:  sourcePath,
+		localPath:   destPath,
+		srcInfo:     srcInfo,
+		mode:        mode,
+		hasher:      hasher,
+		workDir:     workDir,
+		renamedFrom: renamedFrom,
+		kindChanged: kindChanged,
+	})
+
+	// Files removed upstream go too, unless they were edited locally
+	var deletions []FileAction
+	if srcInfo.IsDir() && !kindChanged {
+		input := processPathInput{pathSpec: pathSpec, sourcePath: sourcePath, localPath: destPath, srcInfo: srcInfo, mode: mode, hasher: hasher, renamedFrom: renamedFrom}
+		var deletionConflicts []hash.FileConflict
+		deletionConflicts, deletions = r.applyUpstreamDeletions(input, pathResult.newHashes, len(pathConflicts) == 0)
+		pathConflicts = append(pathConflicts, deletionConflicts...)
+		if len(deletions) > 0 {
+			pathResult.updated = true
+		}
+	}
+
+	// A followed rename is a local change even when the content already matches
+	if len(renamedFrom) > 0 && len(pathConflicts) == 0 && !pathResult.updated && pathResult.newHashes != nil {
+		pathResult.updated = true
+	}
+
+	// Configured permissions apply once the content is in sync; fixing them alone is a change too
+	var fileModes map[string]string
+	if len(pathConflicts) == 0 && pathResult.newHashes != nil {
+		input := processPathInput{pathSpec: pathSpec, sourcePath: sourcePath, localPath: destPath, srcInfo: srcInfo, mode: mode}
+		var modesChanged bool
+		fileModes, modesChanged = r.applyFileModes(input, srcInfo.IsDir(), pathResult.newHashes, r.checkoutPerms(sourcePath, srcInfo.IsDir()), mode == SyncModeDetect && !pathResult.updated)
+		if modesChanged {
+			pathResult.updated = true
+		}
+	}
+
+	// A path with refused protected writes is only partially synced - keep its previous state
+	refused := len(r.refused) > refusedBefore
+	if refused {
+		pathResult.updated = false
+		logger.Warning("%s was not fully synced: some files are protected", pathSpec.Include)
+	}
+
+	// Files that failed keep their previous tracking entries so the next sync retries them
+	partial := len(r.failed) > failedBefore
+	if partial && pathResult.updated {
+		pathResult.newHashes = keepFailedHashes(r.source.Paths[i].Files, pathResult.newHashes, r.failed[failedBefore:])
+	}
+
+	// Every file the directory now tracks must have been written (or already been there)
+	if pathResult.updated && !partial && srcInfo.IsDir() && !kindChanged && !logger.IsDryRun() {
+		pathResult.newHashes = checkTrackedFiles(destPath, pathResult.newHashes, pathSpec.Excludes)
+	}
+
+	if logger.IsDryRun() {
+		result.FileActions = append(result.FileActions, r.projectActions(renames)...)
+		result.FileActions = append(result.FileActions, r.projectActions(deletions)...)
+	} else {
+		after := captureLocalFiles(destPath, srcInfo.IsDir(), hasher)
+		result.FileActions = append(result.FileActions, r.projectActions(diffLocalFiles(before, after, renames))...)
+	}
+
+	if len(pathConflicts) > 0 {
+		setConflictLocalPaths(pathConflicts, localPath, srcInfo.IsDir() && !kindChanged)
+		r.attributeConflicts(pathConflicts, sourcePath, srcInfo.IsDir() && !kindChanged, commit, r.source.Paths[i].Commit)
+		result.Conflicts = append(result.Conflicts, pathConflicts...)
+
+		// Collect conflict files for branch creation (a kind change can't be expressed as file writes)
+		if mode == SyncModeBranch && !kindChanged {
+			if conflictFiles == nil {
+				conflictFiles = make(map[string][]byte)
+			}
+			// Read remote files for branch
+			remoteFiles := r.readRemoteFiles(sourcePath, localPath, srcInfo.IsDir(), pathSpec.Excludes)
+			for k, v := range remoteFiles {
+				conflictFiles[k] = r.withLineEnding(k, v)
+			}
+		}
+	}
+
+	// Paths left with unresolved differences still correspond to their previous commit
+	if commit != "" && !refused && !partial && !r.freezeTracking && (pathResult.updated || len(pathConflicts) == 0) {
+		r.source.Paths[i].Commit = commit
+	}
+
+	if pathResult.updated {
+		result.UpdatedPaths = append(result.UpdatedPaths, pathSpec.Include)
+		result.CommitPaths = append(result.CommitPaths, commitPaths(pathSpec, localPath, r.source.Paths[i].Files, pathResult.newHashes)...)
+
+		// Update hashes in path spec
+		if !r.freezeTracking {
+			r.source.Paths[i].Files = pathResult.newHashes
+			r.source.Paths[i].FileModes = fileModes
+		}
+
+		if partial {
+			logger.Warning("Partially synced %s to %s (%s): %d file(s) failed", pathSpec.Include, localPath, ShortHash(commit), len(r.failed)-failedBefore)
 		} else {
-			result.BranchCreated = branchResult.BranchName
-			result.MergeInstructions = GetMergeInstructions(branchResult)
+			logger.Info("Synced %s to %s (%s)", pathSpec.Include, localPath, ShortHash(commit))
+		}
+
+		if len(pathConflicts) == 0 && !partial && !r.freezeTracking {
+			r.saveBaseSnapshot(pathSpec, sourcePath, srcInfo.IsDir())
 		}
 	}
 
-	return result, nil
+	return conflictFiles
 }
 
 // processPathInput contains input parameters for processPath
 type processPathInput struct {
-	pathSpec   config.PathSpec
-	sourcePath string
-	localPath  string
-	srcInfo    os.FileInfo
-	mode       SyncMode
-	hasher     *hash.FileHasher
-	workDir    string
+	pathSpec    config.PathSpec
+	sourcePath  string
+	localPath   string
+	srcInfo     os.FileInfo
+	mode        SyncMode
+	hasher      *hash.FileHasher
+	workDir     string
+	renamedFrom map[string]string // new relative path -> old relative path for followed renames
+	kindChanged bool              // Local path exists as a file where upstream has a directory, or the reverse
+}
+
+// projectPath returns a path under the project root relative to it, the form reports use
+// and options.protected_paths rules match. Other paths are returned as they are.
+func (r *Repository) projectPath(localPath string) string {
+	if r.root == "" || !filepath.IsAbs(localPath) {
+		return localPath
+	}
+	rel, err := filepath.Rel(r.root, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return localPath
+	}
+	return rel
+}
+
+// projectActions names the local paths of file actions relative to the project root
+func (r *Repository) projectActions(actions []FileAction) []FileAction {
+	for i := range actions {
+		actions[i].Path = r.projectPath(actions[i].Path)
+		if actions[i].OldPath != "" {
+			actions[i].OldPath = r.projectPath(actions[i].OldPath)
+		}
+	}
+	return actions
+}
+
+// setConflictLocalPaths fills in the working-directory path of conflicts found under
+// localPath: conflicts inside a directory are named relative to it, the others name
+// localPath itself
+func setConflictLocalPaths(conflicts []hash.FileConflict, localPath string, inDirectory bool) {
+	for i := range conflicts {
+		if inDirectory {
+			conflicts[i].LocalPath = filepath.ToSlash(filepath.Join(localPath, conflicts[i].Path))
+		} else {
+			conflicts[i].LocalPath = filepath.ToSlash(filepath.Clean(localPath))
+		}
+	}
 }
 
 // processPathResult contains the result of processing a path
@@ -441,6 +877,14 @@ func (r *Repository) processPath(input processPathInput) (processPathResult, []h
 	result := processPathResult{}
 	var conflicts []hash.FileConflict
 
+	if input.kindChanged {
+		return r.handleKindChange(input)
+	}
+
+	if input.pathSpec.HardLinked() && input.mode == SyncModeForce {
+		return r.linkPath(input)
+	}
+
 	// Check if local path exists
 	localExists := r.localPathExists(input.localPath)
 
@@ -449,7 +893,7 @@ func (r *Repository) processPath(input processPathInput) (processPathResult, []h
 
 	// If local and remote are identical, nothing to do
 	if !localDiffersFromRemote {
-		result.newHashes = r.calculateHashes(input.sourcePath, input.srcInfo.IsDir(), input.hasher, input.pathSpec.Exclude)
+		result.newHashes = r.remoteHashes(input)
 		result.updated = false
 		return result, conflicts
 	}
@@ -465,22 +909,20 @@ func (r *Repository) processPath(input processPathInput) (processPathResult, []h
 			conflicts = r.getFileConflicts(input)
 		} else {
 			// Local doesn't exist - this is a new file, just copy it
-			if err := copyPath(input.sourcePath, input.localPath, input.pathSpec.Exclude); err != nil {
-				logger.Error("Failed to copy %s: %v", input.pathSpec.Include, err)
+			if !r.copyTrackedPath(input) {
 				return result, conflicts
 			}
-			result.newHashes = r.calculateHashes(input.sourcePath, input.srcInfo.IsDir(), input.hasher, input.pathSpec.Exclude)
+			result.newHashes = r.remoteHashes(input)
 			result.updated = true
 		}
 
 	case SyncModeForce:
 		// Force mode - overwrite
 		logger.Info("🔧 Force mode: Overriding local changes in %s", input.pathSpec.Include)
-		if err := copyPath(input.sourcePath, input.localPath, input.pathSpec.Exclude); err != nil {
-			logger.Error("Failed to copy %s: %v", input.pathSpec.Include, err)
+		if !r.copyTrackedPath(input) {
 			return result, conflicts
 		}
-		result.newHashes = r.calculateHashes(input.sourcePath, input.srcInfo.IsDir(), input.hasher, input.pathSpec.Exclude)
+		result.newHashes = r.remoteHashes(input)
 		result.updated = true
 
 	case SyncModeMerge, SyncModeBranch:
@@ -490,8 +932,7 @@ func (r *Repository) processPath(input processPathInput) (processPathResult, []h
 		if len(mergeConflicts) > 0 {
 			conflicts = mergeConflicts
 			if input.mode == SyncModeMerge {
-				// Show the conflict diff
-				r.showConflictDiff(input)
+				// The merge already showed each conflicting file's diff
 				logger.Error("⚠️  Merge conflicts in %s - cannot auto-merge", input.pathSpec.Include)
 				logger.Info("💡 Options for manual resolution:")
 				logger.Info("   cherry-go sync --merge --mark-conflicts     (write conflict markers)")
@@ -514,7 +955,7 @@ func (r *Repository) processPath(input processPathInput) (processPathResult, []h
 				return result, conflicts
 			}
 			// Calculate hashes for the files with conflict markers (local path, since we wrote there)
-			result.newHashes = r.calculateHashes(input.localPath, input.srcInfo.IsDir(), input.hasher, input.pathSpec.Exclude)
+			result.newHashes = r.calculateHashes(input.localPath, input.srcInfo.IsDir(), input.hasher, input.pathSpec.Excludes)
 			result.updated = true
 			logger.Warning("⚠️  Conflict markers written to %s - resolve manually and commit", input.pathSpec.Include)
 		} else if mergeResult.updated {
@@ -537,26 +978,33 @@ func (r *Repository) contentDiffersFromRemote(input processPathInput) bool {
 	if input.srcInfo.IsDir() {
 		// For directories, check each file
 		differs := false
-		_ = filepath.Walk(input.sourcePath, func(path string, info os.FileInfo, err error) error {
+		compared := newProgress("comparing "+input.pathSpec.Include, &r.metrics.FilesCompared, nil,
+			countSourceFiles(input.sourcePath, input.pathSpec.Excludes))
+		follow := r.options.FollowSymlinks
+		_ = hash.Walk(input.sourcePath, follow, func(path string, info os.FileInfo, err error) error {
 			if err != nil || info.IsDir() {
 				return err
 			}
 			relPath, _ := filepath.Rel(input.sourcePath, path)
+			if shouldExclude(relPath, input.pathSpec.Excludes) {
+				return nil
+			}
+			compared.add(0)
 			localPath := filepath.Join(input.localPath, relPath)
 
-			localContent, err := os.ReadFile(localPath)
+			localContent, localIsLink, err := readEntry(localPath, follow)
 			if err != nil {
 				// Local doesn't exist - differs
 				differs = true
 				return filepath.SkipAll
 			}
 
-			remoteContent, err := os.ReadFile(path)
+			remoteContent, remoteIsLink, err := readSourceEntry(path, follow)
 			if err != nil {
 				return err
 			}
 
-			if string(localContent) != string(remoteContent) {
+			if localIsLink != remoteIsLink || !r.sameContent(localContent, remoteContent) {
 				differs = true
 				return filepath.SkipAll
 			}
@@ -576,29 +1024,57 @@ func (r *Repository) contentDiffersFromRemote(input processPathInput) bool {
 	if err != nil {
 		return false
 	}
+	r.metrics.FilesCompared++
+
+	return !r.sameContent(localContent, remoteContent)
+}
+
+// sameContent compares two versions of a file, ignoring a final newline unless
+// options.ignore_trailing_newline is false, and CRLF vs LF when options.eol is set
+func (r *Repository) sameContent(a, b []byte) bool {
+	if r.options.EOLPolicy() != "" {
+		a, b = merge.NormalizeLineEndings(a), merge.NormalizeLineEndings(b)
+	}
+	return merge.SameContent(a, b, r.options.IgnoreTrailingNewlineEnabled())
+}
 
-	return string(localContent) != string(remoteContent)
+// mergeOptions returns the merge options matching the sync options
+func (r *Repository) mergeOptions() merge.Options {
+	return merge.Options{
+		IgnoreTrailingNewline: r.options.IgnoreTrailingNewlineEnabled(),
+		NormalizeLineEndings:  r.options.EOLPolicy() != "",
+	}
 }
 
 // showConflictDiff shows the diff between local and remote for conflict detection
 func (r *Repository) showConflictDiff(input processPathInput) {
+	// Nothing is rendered without -v, so skip reading every file
+	if logger.GetVerbosityLevel() == 0 {
+		return
+	}
+
 	if input.srcInfo.IsDir() {
 		// For directories, show diff for each modified file
-		_ = filepath.Walk(input.sourcePath, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return err
+		_ = hash.Walk(input.sourcePath, r.options.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !r.options.FollowSymlinks && hash.IsSymlink(info) {
+				return err // Links have no lines to diff
 			}
 			relPath, _ := filepath.Rel(input.sourcePath, path)
+			if shouldExclude(relPath, input.pathSpec.Excludes) {
+				return nil
+			}
 			localPath := filepath.Join(input.localPath, relPath)
 
 			if _, err := os.Stat(localPath); err == nil {
 				// Local file exists, check if different
 				localContent, _ := os.ReadFile(localPath)
 				remoteContent, _ := os.ReadFile(path)
-				if string(localContent) != string(remoteContent) {
-					// Get base from git history
-					base, _ := getBaseContentFromGitHistory(input.workDir, localPath)
-					merge.ShowDiffFromContent(base, localContent, remoteContent, relPath)
+				if !r.sameContent(localContent, remoteContent) {
+					if !showConflictDetail(localPath) {
+						return filepath.SkipAll // Past the display limit
+					}
+					base := r.baseContent(input, relPath, localPath)
+					merge.ShowDiffFromContent(r.output(), base, localContent, remoteContent, utils.DisplayPath(relPath))
 				}
 			}
 			return nil
@@ -613,10 +1089,9 @@ func (r *Repository) showConflictDiff(input processPathInput) {
 		if err != nil {
 			return
 		}
-		if string(localContent) != string(remoteContent) {
-			// Get base from git history
-			base, _ := getBaseContentFromGitHistory(input.workDir, input.localPath)
-			merge.ShowDiffFromContent(base, localContent, remoteContent, filepath.Base(input.localPath))
+		if !r.sameContent(localContent, remoteContent) && showConflictDetail(input.localPath) {
+			base := r.baseContent(input, filepath.Base(input.sourcePath), input.localPath)
+			merge.ShowDiffFromContent(r.output(), base, localContent, remoteContent, utils.DisplayPath(filepath.Base(input.localPath)))
 		}
 	}
 }
@@ -631,12 +1106,15 @@ func (r *Repository) getFileConflicts(input processPathInput) []hash.FileConflic
 				return err
 			}
 			relPath, _ := filepath.Rel(input.sourcePath, path)
+			if shouldExclude(relPath, input.pathSpec.Excludes) {
+				return nil
+			}
 			localPath := filepath.Join(input.localPath, relPath)
 
 			if _, err := os.Stat(localPath); err == nil {
 				localContent, _ := os.ReadFile(localPath)
 				remoteContent, _ := os.ReadFile(path)
-				if string(localContent) != string(remoteContent) {
+				if !r.sameContent(localContent, remoteContent) {
 					conflicts = append(conflicts, hash.FileConflict{
 						Path: relPath,
 						Type: hash.ConflictTypeModified,
@@ -671,7 +1149,7 @@ func (r *Repository) hasLocalChanges(pathSpec config.PathSpec, localPath string,
 		conflictCheckPath = filepath.Dir(localPath)
 	}
 
-	conflicts, err := hasher.VerifyFileIntegrity(conflictCheckPath, pathSpec.Files)
+	conflicts, err := hasher.VerifyFileIntegrity(conflictCheckPath, pathSpec.Files, pathSpec.FileModes)
 	if err != nil {
 		logger.Debug("Failed to verify file integrity: %v", err)
 		return false
@@ -702,12 +1180,13 @@ func (r *Repository) mergeDirectory(input processPathInput) (processPathResult,
 
 	// Get list of files to process
 	var files []string
-	err := filepath.Walk(input.sourcePath, func(path string, info os.FileInfo, err error) error {
+	follow := r.options.FollowSymlinks
+	err := hash.Walk(input.sourcePath, follow, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return err
 		}
 		relPath, _ := filepath.Rel(input.sourcePath, path)
-		if !shouldExclude(relPath, input.pathSpec.Exclude) {
+		if !shouldExclude(relPath, input.pathSpec.Excludes) {
 			files = append(files, relPath)
 		}
 		return nil
@@ -721,57 +1200,54 @@ func (r *Repository) mergeDirectory(input processPathInput) (processPathResult,
 	for _, relPath := range files {
 		remotePath := filepath.Join(input.sourcePath, relPath)
 		localPath := filepath.Join(input.localPath, relPath)
+		perm := r.sourcePerm(remotePath)
 
 		// Read remote content
-		remoteContent, err := os.ReadFile(remotePath)
+		remoteContent, remoteIsLink, err := readSourceEntry(remotePath, follow)
 		if err != nil {
-			logger.Error("Failed to read remote file %s: %v", relPath, err)
+			r.recordFailure(input, remotePath, err)
 			continue
 		}
 
-		// Check if local file exists
-		localContent, localErr := os.ReadFile(localPath)
-		if localErr != nil {
-			// Local file doesn't exist - just copy
-			if err := os.MkdirAll(filepath.Dir(localPath), 0755); err == nil {
-				if !logger.IsDryRun() {
-					if err := os.WriteFile(localPath, remoteContent, 0644); err != nil {
-						logger.Error("Failed to write file %s: %v", relPath, err)
-					}
+		// Check if local file exists. Links have no lines to merge: on either side, the
+		// upstream entry replaces the local one.
+		localContent, localIsLink, localErr := readEntry(localPath, follow)
+		if localErr != nil || remoteIsLink || localIsLink {
+			if err := r.writeLocalEntry(localPath, remoteContent, remoteIsLink, perm); err != nil {
+				if !isProtectedPathError(err) {
+					r.recordFailure(input, remotePath, err)
 				}
+				continue
 			}
 			result.newHashes[relPath] = input.hasher.HashBytes(remoteContent)
 			continue
 		}
 
-		// Get base content from git history
-		base, err := getBaseContentFromGitHistory(input.workDir, localPath)
-		if err != nil {
-			logger.Debug("Failed to get base from git history for %s: %v", relPath, err)
-			base = []byte{} // Use empty base
-		}
+		// Get base content from the last sync snapshot or git history
+		base := r.baseContent(input, relPath, localPath)
 
 		// Check if local is unchanged from base
-		if bytes.Equal(localContent, base) {
+		if r.sameContent(localContent, base) {
 			// Local unchanged - just take remote
-			if !logger.IsDryRun() {
-				if err := os.WriteFile(localPath, remoteContent, 0644); err != nil {
-					logger.Error("Failed to write file %s: %v", relPath, err)
+			if err := r.writeLocalFile(localPath, remoteContent, perm); err != nil {
+				if !isProtectedPathError(err) {
+					r.recordFailure(input, remotePath, err)
 				}
+				continue
 			}
 			result.newHashes[relPath] = input.hasher.HashBytes(remoteContent)
 			continue
 		}
 
 		// Check if remote is unchanged from base
-		if bytes.Equal(remoteContent, base) {
+		if r.sameContent(base, remoteContent) {
 			// Remote unchanged - keep local
 			result.newHashes[relPath] = input.hasher.HashBytes(localContent)
 			continue
 		}
 
 		// Both changed - attempt three-way merge
-		mergeResult, err := merge.ThreeWayMerge(base, localContent, remoteContent)
+		mergeResult, err := merge.ThreeWayMergeWithOptions(base, localContent, remoteContent, r.mergeOptions())
 		if err != nil {
 			logger.Error("Failed to merge %s: %v", relPath, err)
 			conflicts = append(conflicts, hash.FileConflict{
@@ -783,22 +1259,32 @@ func (r *Repository) mergeDirectory(input processPathInput) (processPathResult,
 		}
 
 		if mergeResult.HasConflict {
-			logger.Error("  - %s (merge conflict - both local and remote modified)", relPath)
-			merge.ShowDiffFromContent(base, localContent, remoteContent, relPath)
+			if showConflictDetail(localPath) {
+				logger.Error("  - %s (merge conflict - both local and remote modified)", utils.DisplayPath(relPath))
+				merge.ShowDiffFromContent(r.output(), base, localContent, remoteContent, utils.DisplayPath(relPath))
+			}
+			settled := r.settleConflict(input, conflictingFile{
+				key: relPath, localPath: localPath, remotePath: remotePath,
+				local: localContent, remote: remoteContent, merged: mergeResult.Content,
+			}, result.newHashes)
+			if settled {
+				continue
+			}
 			conflicts = append(conflicts, hash.FileConflict{
-				Path: relPath,
-				Type: hash.ConflictTypeModified,
+				Path:  relPath,
+				Type:  hash.ConflictTypeModified,
+				Hunks: mergeResult.Hunks,
 			})
 			allMerged = false
 			continue
 		}
 
 		// Merge successful - write result
-		if !logger.IsDryRun() {
-			if err := os.WriteFile(localPath, mergeResult.Content, 0644); err != nil {
-				logger.Error("Failed to write merged file %s: %v", relPath, err)
-				continue
+		if err := r.writeLocalFile(localPath, mergeResult.Content, perm); err != nil {
+			if !isProtectedPathError(err) {
+				r.recordFailure(input, remotePath, err)
 			}
+			continue
 		}
 		logger.Info("  ✓ Merged %s successfully", relPath)
 		result.newHashes[relPath] = input.hasher.HashBytes(mergeResult.Content)
@@ -814,11 +1300,12 @@ func (r *Repository) mergeFile(input processPathInput) (processPathResult, []has
 	var conflicts []hash.FileConflict
 
 	fileName := filepath.Base(input.sourcePath)
+	perm := r.sourcePerm(input.sourcePath)
 
 	// Read remote content
 	remoteContent, err := os.ReadFile(input.sourcePath)
 	if err != nil {
-		logger.Error("Failed to read remote file: %v", err)
+		r.recordFailure(input, input.sourcePath, err)
 		return result, conflicts
 	}
 
@@ -826,29 +1313,27 @@ func (r *Repository) mergeFile(input processPathInput) (processPathResult, []has
 	localContent, err := os.ReadFile(input.localPath)
 	if err != nil {
 		// Local doesn't exist - just copy
-		if !logger.IsDryRun() {
-			if copyErr := copyPath(input.sourcePath, input.localPath, nil); copyErr != nil {
-				logger.Error("Failed to copy file: %v", copyErr)
+		if err := r.writeLocalFile(input.localPath, remoteContent, perm); err != nil {
+			if !isProtectedPathError(err) {
+				r.recordFailure(input, input.sourcePath, err)
 			}
+			return result, conflicts
 		}
 		result.newHashes[fileName] = input.hasher.HashBytes(remoteContent)
 		result.updated = true
 		return result, conflicts
 	}
 
-	// Get base content from git history
-	base, err := getBaseContentFromGitHistory(input.workDir, input.localPath)
-	if err != nil {
-		logger.Debug("Failed to get base from git history: %v", err)
-		base = []byte{} // Use empty base
-	}
+	// Get base content from the last sync snapshot or git history
+	base := r.baseContent(input, fileName, input.localPath)
 
 	// Check if local unchanged
-	if bytes.Equal(localContent, base) {
-		if !logger.IsDryRun() {
-			if err := os.WriteFile(input.localPath, remoteContent, 0644); err != nil {
-				logger.Error("Failed to write file: %v", err)
+	if r.sameContent(localContent, base) {
+		if err := r.writeLocalFile(input.localPath, remoteContent, perm); err != nil {
+			if !isProtectedPathError(err) {
+				r.recordFailure(input, input.sourcePath, err)
 			}
+			return result, conflicts
 		}
 		result.newHashes[fileName] = input.hasher.HashBytes(remoteContent)
 		result.updated = true
@@ -856,14 +1341,14 @@ func (r *Repository) mergeFile(input processPathInput) (processPathResult, []has
 	}
 
 	// Check if remote unchanged
-	if bytes.Equal(remoteContent, base) {
+	if r.sameContent(base, remoteContent) {
 		result.newHashes[fileName] = input.hasher.HashBytes(localContent)
 		result.updated = true
 		return result, conflicts
 	}
 
 	// Both changed - attempt merge
-	mergeResult, err := merge.ThreeWayMerge(base, localContent, remoteContent)
+	mergeResult, err := merge.ThreeWayMergeWithOptions(base, localContent, remoteContent, r.mergeOptions())
 	if err != nil {
 		logger.Error("Failed to merge: %v", err)
 		conflicts = append(conflicts, hash.FileConflict{
@@ -874,21 +1359,32 @@ func (r *Repository) mergeFile(input processPathInput) (processPathResult, []has
 	}
 
 	if mergeResult.HasConflict {
-		logger.Error("  - %s (merge conflict - both local and remote modified)", fileName)
-		merge.ShowDiffFromContent(base, localContent, remoteContent, fileName)
+		if showConflictDetail(input.localPath) {
+			logger.Error("  - %s (merge conflict - both local and remote modified)", utils.DisplayPath(fileName))
+			merge.ShowDiffFromContent(r.output(), base, localContent, remoteContent, utils.DisplayPath(fileName))
+		}
+		settled := r.settleConflict(input, conflictingFile{
+			key: fileName, localPath: input.localPath, remotePath: input.sourcePath,
+			local: localContent, remote: remoteContent, merged: mergeResult.Content,
+		}, result.newHashes)
+		if settled {
+			result.updated = true
+			return result, conflicts
+		}
 		conflicts = append(conflicts, hash.FileConflict{
-			Path: fileName,
-			Type: hash.ConflictTypeModified,
+			Path:  fileName,
+			Type:  hash.ConflictTypeModified,
+			Hunks: mergeResult.Hunks,
 		})
 		return result, conflicts
 	}
 
 	// Merge successful
-	if !logger.IsDryRun() {
-		if err := os.WriteFile(input.localPath, mergeResult.Content, 0644); err != nil {
-			logger.Error("Failed to write merged file: %v", err)
-			return result, conflicts
+	if err := r.writeLocalFile(input.localPath, mergeResult.Content, perm); err != nil {
+		if !isProtectedPathError(err) {
+			r.recordFailure(input, input.sourcePath, err)
 		}
+		return result, conflicts
 	}
 	logger.Info("  ✓ Merged %s successfully", fileName)
 	result.newHashes[fileName] = input.hasher.HashBytes(mergeResult.Content)
@@ -896,8 +1392,52 @@ func (r *Repository) mergeFile(input processPathInput) (processPathResult, []has
 	return result, conflicts
 }
 
+// remoteHashes hashes the upstream files of a path for tracking. Files that can't be
+// read are recorded as failures and left out rather than losing the whole path's hashes.
+func (r *Repository) remoteHashes(input processPathInput) map[string]string {
+	if !input.srcInfo.IsDir() {
+		h, err := input.hasher.HashFile(input.sourcePath)
+		if err != nil {
+			r.recordFailure(input, input.sourcePath, err)
+			return nil
+		}
+		r.metrics.FilesHashed++
+		return map[string]string{filepath.Base(input.sourcePath): h}
+	}
+
+	hashed := newProgress("hashing "+input.pathSpec.Include, &r.metrics.FilesHashed, nil,
+		countSourceFiles(input.sourcePath, input.pathSpec.Excludes))
+	hashes := make(map[string]string)
+	err := hash.Walk(input.sourcePath, input.hasher.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			r.recordFailure(input, path, err)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, _ := filepath.Rel(input.sourcePath, path)
+		if info.IsDir() || shouldExclude(relPath, input.pathSpec.Excludes) {
+			return nil
+		}
+
+		h, err := hashSourceEntry(input.hasher, path, info)
+		if err != nil {
+			r.recordFailure(input, path, err)
+			return nil
+		}
+		hashes[relPath] = h
+		hashed.add(0)
+		return nil
+	})
+	if err != nil {
+		r.recordFailure(input, input.sourcePath, err)
+	}
+	return hashes
+}
+
 // calculateHashes calculates hashes for files in the given path
-func (r *Repository) calculateHashes(sourcePath string, isDir bool, hasher *hash.FileHasher, excludes []string) map[string]string {
+func (r *Repository) calculateHashes(sourcePath string, isDir bool, hasher *hash.FileHasher, excludes config.Excludes) map[string]string {
 	var newHashes map[string]string
 	var err error
 
@@ -921,6 +1461,30 @@ func (r *Repository) calculateHashes(sourcePath string, isDir bool, hasher *hash
 	return newHashes
 }
 
+// canonicalizePathSpec rewrites a spec's include and local path in the canonical form for
+// its kind, in both the working copy and the source, keeping results keyed consistently.
+// Snapshots follow on their own since they are looked up by the canonical path.
+func (r *Repository) canonicalizePathSpec(index int, pathSpec *config.PathSpec, isDir bool, result *CopyResult) {
+	include := config.CanonicalPath(pathSpec.Include, isDir)
+	localPath := pathSpec.LocalPath
+	if localPath != "" {
+		localPath = config.CanonicalPath(localPath, isDir)
+	}
+	if include == pathSpec.Include && localPath == pathSpec.LocalPath {
+		return
+	}
+
+	logger.Debug("Normalizing %s to %s", pathSpec.Include, include)
+	if commit, ok := result.PathCommits[pathSpec.Include]; ok {
+		delete(result.PathCommits, pathSpec.Include)
+		result.PathCommits[include] = commit
+	}
+
+	pathSpec.Include, pathSpec.LocalPath = include, localPath
+	r.source.Paths[index].Include = include
+	r.source.Paths[index].LocalPath = localPath
+}
+
 // writeConflictMarkers writes files with conflict markers for manual resolution
 func (r *Repository) writeConflictMarkers(input processPathInput, conflicts []hash.FileConflict) error {
 	if input.srcInfo.IsDir() {
@@ -929,14 +1493,14 @@ func (r *Repository) writeConflictMarkers(input processPathInput, conflicts []ha
 			sourcePath := filepath.Join(input.sourcePath, conflict.Path)
 			localPath := filepath.Join(input.localPath, conflict.Path)
 
-			if err := r.writeFileWithConflictMarkers(input.workDir, sourcePath, localPath, conflict.Path); err != nil {
+			if err := r.writeFileWithConflictMarkers(input, sourcePath, localPath, conflict.Path); err != nil {
 				return fmt.Errorf("failed to write conflict markers for %s: %w", conflict.Path, err)
 			}
 		}
 	} else {
 		// Single file
 		fileName := filepath.Base(input.sourcePath)
-		if err := r.writeFileWithConflictMarkers(input.workDir, input.sourcePath, input.localPath, fileName); err != nil {
+		if err := r.writeFileWithConflictMarkers(input, input.sourcePath, input.localPath, fileName); err != nil {
 			return fmt.Errorf("failed to write conflict markers: %w", err)
 		}
 	}
@@ -944,7 +1508,7 @@ func (r *Repository) writeConflictMarkers(input processPathInput, conflicts []ha
 }
 
 // writeFileWithConflictMarkers writes a single file with git conflict markers
-func (r *Repository) writeFileWithConflictMarkers(workDir, sourcePath, localPath, fileName string) error {
+func (r *Repository) writeFileWithConflictMarkers(input processPathInput, sourcePath, localPath, fileName string) error {
 	// Read remote content
 	remoteContent, err := os.ReadFile(sourcePath)
 	if err != nil {
@@ -957,32 +1521,35 @@ func (r *Repository) writeFileWithConflictMarkers(workDir, sourcePath, localPath
 		return fmt.Errorf("failed to read local file: %w", err)
 	}
 
-	// Get base content from git history
-	base, err := getBaseContentFromGitHistory(workDir, localPath)
-	if err != nil {
-		logger.Debug("Failed to get base from git history: %v", err)
-		base = []byte{} // Use empty base
-	}
+	// Get base content from the last sync snapshot or git history
+	base := r.baseContent(input, fileName, localPath)
 
 	// Perform merge to get content with conflict markers
-	mergeResult, err := merge.ThreeWayMerge(base, localContent, remoteContent)
+	mergeResult, err := merge.ThreeWayMergeWithOptions(base, localContent, remoteContent, r.mergeOptions())
 	if err != nil {
 		return fmt.Errorf("failed to perform merge: %w", err)
 	}
+	if mergeResult.Binary {
+		logger.Warning("⚠️  %s is binary: no conflict markers written, the local file is left as is", fileName)
+		return nil
+	}
 
 	// Write the merged content (which includes conflict markers if conflicts exist)
-	if !logger.IsDryRun() {
-		if err := os.WriteFile(localPath, mergeResult.Content, 0644); err != nil {
-			return fmt.Errorf("failed to write file with conflict markers: %w", err)
+	if err := r.writeLocalFile(localPath, mergeResult.Content, r.sourcePerm(sourcePath)); err != nil {
+		if isProtectedPathError(err) {
+			return err
 		}
+		return fmt.Errorf("failed to write file with conflict markers: %w", err)
 	}
 
 	logger.Debug("Wrote conflict markers to %s", fileName)
 	return nil
 }
 
-// readRemoteFiles reads all files from the remote path into a map
-func (r *Repository) readRemoteFiles(sourcePath, localPath string, isDir bool, excludes []string) map[string][]byte {
+// readRemoteFiles reads all files from the remote path into a map. Symbolic links in a
+// directory are left out unless followed: they are replaced rather than merged, so they
+// need no base, and a conflict branch only writes files.
+func (r *Repository) readRemoteFiles(sourcePath, localPath string, isDir bool, excludes config.Excludes) map[string][]byte {
 	files := make(map[string][]byte)
 
 	if !isDir {
@@ -994,7 +1561,7 @@ func (r *Repository) readRemoteFiles(sourcePath, localPath string, isDir bool, e
 		return files
 	}
 
-	_ = filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+	_ = hash.Walk(sourcePath, r.options.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
 		if err != nil || info.IsDir() {
 			return err
 		}
@@ -1002,6 +1569,10 @@ func (r *Repository) readRemoteFiles(sourcePath, localPath string, isDir bool, e
 		if shouldExclude(relPath, excludes) {
 			return nil
 		}
+		if !r.options.FollowSymlinks && hash.IsSymlink(info) {
+			logger.Debug("Leaving out symbolic link %s", relPath)
+			return nil
+		}
 		content, err := os.ReadFile(path)
 		if err == nil {
 			// Use the full local path for branch creation
@@ -1014,8 +1585,18 @@ func (r *Repository) readRemoteFiles(sourcePath, localPath string, isDir bool, e
 	return files
 }
 
-// checkoutBranch checks out a specific branch or tag
+// checkoutBranch checks out a specific branch or tag, fetching the full history of a
+// shallow clone that doesn't have it
 func (r *Repository) checkoutBranch(branch string) error {
+	err := r.checkoutRef(branch)
+	if err != nil && r.deepenFor(branch) {
+		return r.checkoutRef(branch)
+	}
+	return err
+}
+
+// checkoutRef checks out a branch, tag or commit as the clone has it
+func (r *Repository) checkoutRef(branch string) error {
 	if branch == "" {
 		// Try to detect default branch
 		branch = r.detectDefaultBranch()
@@ -1033,6 +1614,18 @@ func (r *Repository) checkoutBranch(branch string) error {
 
 	// Try to checkout as branch first
 	branchRef := plumbing.ReferenceName("refs/heads/" + branch)
+
+	// A fetched branch: put the local branch and the worktree where the remote is
+	if remote, remoteErr := r.repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true); remoteErr == nil {
+		return r.checkoutRemoteBranch(workTree, branchRef, remote.Hash())
+	}
+
+	// Already on it, as right after Pull: checking out again would only re-verify the worktree
+	if head, headErr := r.repo.Head(); headErr == nil && head.Name() == branchRef {
+		logger.Debug("Branch %s already checked out", branch)
+		return nil
+	}
+
 	err = workTree.Checkout(&git.CheckoutOptions{
 		Branch: branchRef,
 	})
@@ -1045,14 +1638,14 @@ func (r *Repository) checkoutBranch(branch string) error {
 		})
 
 		if err != nil {
-			// If both fail, try to resolve as a commit hash
-			hash := plumbing.NewHash(branch)
-			if hash.IsZero() {
+			// If both fail, resolve it (remote-only branch or commit) and detach there
+			commit, resolveErr := r.GetCommitForRef(branch)
+			if resolveErr != nil {
 				return fmt.Errorf("failed to checkout '%s': not a valid branch, tag, or commit", branch)
 			}
 
 			err = workTree.Checkout(&git.CheckoutOptions{
-				Hash: hash,
+				Hash: plumbing.NewHash(commit),
 			})
 
 			if err != nil {
@@ -1065,6 +1658,26 @@ func (r *Repository) checkoutBranch(branch string) error {
 	return nil
 }
 
+// checkoutRemoteBranch resets the local branch to the remote-tracking commit and
+// checks it out, discarding whatever the worktree held
+func (r *Repository) checkoutRemoteBranch(workTree *git.Worktree, branchRef plumbing.ReferenceName, hash plumbing.Hash) error {
+	if head, err := r.repo.Head(); err == nil && head.Name() == branchRef && head.Hash() == hash {
+		logger.Debug("Branch %s already at origin/%s", branchRef.Short(), branchRef.Short())
+		return nil
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(branchRef, hash)); err != nil {
+		return fmt.Errorf("failed to reset branch '%s' to origin: %w", branchRef.Short(), err)
+	}
+
+	if err := workTree.Checkout(&git.CheckoutOptions{Branch: branchRef, Force: true}); err != nil {
+		return fmt.Errorf("failed to checkout '%s': %w", branchRef.Short(), err)
+	}
+
+	logger.Debug("Checked out branch %s at %s", branchRef.Short(), ShortHash(hash.String()))
+	return nil
+}
+
 // detectDefaultBranch tries to detect the default branch of the repository
 func (r *Repository) detectDefaultBranch() string {
 	// Try common default branch names
@@ -1092,8 +1705,13 @@ func (r *Repository) detectDefaultBranch() string {
 	return "main"
 }
 
-// copyPath copies a file or directory from source to destination
-func copyPath(src, dst string, excludes []string) error {
+// copyPath copies a file or directory from source to destination.
+// check (if set) vets every destination file; refused protected files are skipped
+// inside directories and returned as the error for a single file. filter (if set) turns
+// each file's content into what is written. Symbolic links inside a directory are
+// recreated as links unless follow copies what they point to. With preserve, files are
+// created with the permissions of their source.
+func copyPath(src, dst string, excludes config.Excludes, follow, preserve bool, check writeCheck, filter contentFilter, copied *progress) error {
 	if logger.IsDryRun() {
 		logger.DryRunInfo("Would copy %s to %s", src, dst)
 		return nil
@@ -1105,35 +1723,66 @@ func copyPath(src, dst string, excludes []string) error {
 	}
 
 	if srcInfo.IsDir() {
-		return copyDir(src, dst, excludes)
+		return copyDir(src, dst, excludes, follow, preserve, check, filter, copied)
+	}
+	if err := copyFile(src, dst, preserve, check, filter); err != nil {
+		return err
 	}
-	return copyFile(src, dst)
+	copied.add(srcInfo.Size())
+	return nil
 }
 
-// copyFile copies a single file
-func copyFile(src, dst string) error {
+// copyFile copies a single file, through filter when set. A new file gets the source's
+// permissions with preserve, else defaultFilePerm; an existing one keeps its own, which
+// applyFileModes brings in line once the path is synced.
+func copyFile(src, dst string, preserve bool, check writeCheck, filter contentFilter) error {
+	if check != nil {
+		if err := check(dst); err != nil {
+			return err
+		}
+	}
+
 	// Ensure destination directory exists
 	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return err
 	}
 
+	// Writing in place into a hard link would change the cache checkout too, and into a
+	// symbolic link whatever it points to
+	if _, isLink, _ := hash.ReadLink(dst); isLink || sameFile(src, dst) {
+		_ = os.Remove(dst)
+	}
+
 	srcData, err := os.ReadFile(src)
 	if err != nil {
 		return err
 	}
+	if filter != nil {
+		srcData = filter(dst, srcData)
+	}
 
-	return os.WriteFile(dst, srcData, 0644)
+	return os.WriteFile(dst, srcData, sourcePerm(src, preserve))
 }
 
-// copyDir recursively copies a directory
-func copyDir(src, dst string, excludes []string) error {
+// copyDir recursively copies a directory, counting the files copied into copied (may be nil)
+func copyDir(src, dst string, excludes config.Excludes, follow, preserve bool, check writeCheck, filter contentFilter, copied *progress) error {
+	return copyDirAt(src, dst, "", excludes, follow, preserve, check, filter, copied)
+}
+
+// copyDirAt copies the directory found at rel below the copied root, so excludes are
+// matched against paths relative to that root
+func copyDirAt(src, dst, rel string, excludes config.Excludes, follow, preserve bool, check writeCheck, filter contentFilter, copied *progress) error {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
 		return err
 	}
 
-	if mkdirErr := os.MkdirAll(dst, srcInfo.Mode()); mkdirErr != nil {
-		return mkdirErr
+	// Subdirectories are created by the files copied into them, so ones whose files are
+	// all excluded (or don't match a pattern) aren't left behind empty
+	if rel == "" {
+		if mkdirErr := os.MkdirAll(dst, srcInfo.Mode()); mkdirErr != nil {
+			return mkdirErr
+		}
 	}
 
 	entries, err := os.ReadDir(src)
@@ -1141,83 +1790,150 @@ func copyDir(src, dst string, excludes []string) error {
 		return err
 	}
 
+	// Carry on past files that fail to copy and report them all at the end
+	var failures copyErrors
 	for _, entry := range entries {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
+		relPath := filepath.Join(rel, entry.Name())
+
+		isLink, isDir := entry.Type()&os.ModeSymlink != 0, entry.IsDir()
+		if isLink && follow {
+			// Followed links are copied as what they point to, unless that is a directory
+			// being copied already; a broken one fails like an unreadable file
+			isLink = false
+			if info, err := os.Stat(srcPath); err == nil && info.IsDir() {
+				if linksBack(src, srcPath) {
+					logger.Warning("Not following %s: it links back into a directory being copied", relPath)
+					continue
+				}
+				isDir = true
+			}
+		}
 
 		// Check if path should be excluded
-		if shouldExclude(entry.Name(), excludes) {
-			logger.Debug("Excluding %s", entry.Name())
+		if isDir && config.IsExcludedDir(relPath, excludes) || !isDir && shouldExclude(relPath, excludes) {
+			logger.Debug("Excluding %s", relPath)
 			continue
 		}
 
-		if entry.IsDir() {
-			if err := copyDir(srcPath, dstPath, excludes); err != nil {
-				return err
+		if isLink {
+			if err := copySymlink(srcPath, dstPath, check); err != nil && !isProtectedPathError(err) {
+				failures = append(failures, fileError{path: srcPath, err: err})
+			} else if err == nil {
+				copied.add(0)
+			}
+			continue
+		}
+
+		if isDir {
+			err := copyDirAt(srcPath, dstPath, relPath, excludes, follow, preserve, check, filter, copied)
+			var nested copyErrors
+			if errors.As(err, &nested) {
+				failures = append(failures, nested...)
+			} else if err != nil {
+				failures = append(failures, fileError{path: srcPath, err: err})
 			}
 		} else {
-			if err := copyFile(srcPath, dstPath); err != nil {
-				return err
+			err := copyFile(srcPath, dstPath, preserve, check, filter)
+			switch {
+			case err == nil:
+				var size int64
+				if info, infoErr := entry.Info(); infoErr == nil {
+					size = info.Size()
+				}
+				copied.add(size)
+			case !isProtectedPathError(err):
+				failures = append(failures, fileError{path: srcPath, err: err})
 			}
 		}
 	}
 
+	if len(failures) > 0 {
+		return failures
+	}
 	return nil
 }
 
-// shouldExclude checks if a path should be excluded based on patterns
-func shouldExclude(path string, excludes []string) bool {
-	for _, exclude := range excludes {
-		if matched, _ := filepath.Match(exclude, path); matched {
-			return true
-		}
-		if strings.Contains(path, exclude) {
-			return true
-		}
-	}
-	return false
+// shouldExclude checks if a file should be excluded based on gitignore-style patterns
+func shouldExclude(path string, excludes config.Excludes) bool {
+	return config.IsExcluded(path, excludes)
+}
+
+// PlannedCommit is the project commit a dry run would create
+type PlannedCommit struct {
+	Message string
+	Paths   []string // Local paths that would be staged
 }
 
-// CreateCommit creates a commit with the updated files
-func CreateCommit(workDir string, message string, updatedPaths []string) error {
+// CreateCommit creates a commit with the updated files, authored and committed at when,
+// and returns its hash, empty in a dry run
+func CreateCommit(workDir string, message string, updatedPaths []string, when time.Time, options config.SyncOptions) (string, error) {
 	if logger.IsDryRun() {
 		logger.DryRunInfo("Would create commit with message: %s", message)
 		logger.DryRunInfo("Updated paths: %v", updatedPaths)
-		return nil
+		return "", nil
 	}
 
-	repo, err := git.PlainOpen(workDir)
+	// workDir may be a subdirectory of the repository, so paths are staged relative to its root
+	repo, root, err := openProjectRepository(workDir)
 	if err != nil {
-		return fmt.Errorf("failed to open local repository: %w", err)
+		return "", fmt.Errorf("failed to open local repository: %w", err)
 	}
 
 	workTree, err := repo.Worktree()
 	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+		return "", fmt.Errorf("failed to get worktree: %w", err)
 	}
 
 	// Add all updated paths
 	for _, path := range updatedPaths {
-		if _, addErr := workTree.Add(path); addErr != nil {
+		repoPath, err := repoRelativePath(root, workDir, path)
+		if err != nil {
+			logger.Error("Failed to add %s: %v", path, err)
+			continue
+		}
+		if _, addErr := workTree.Add(repoPath); addErr != nil {
 			logger.Error("Failed to add %s: %v", path, addErr)
 		}
 	}
 
+	// Nothing to record when the synced files already match HEAD
+	if changed, err := hasStagedChanges(repo); err != nil {
+		return "", err
+	} else if !changed {
+		logger.Info("Nothing to commit: the synced files match HEAD")
+		return "", nil
+	}
+
 	// Create commit
+	signature := commitSignature(repo, options, when)
+	message = withCoAuthorTrailer(message, options, signature)
+	if options.UseGitCLI && options.SignCommitsPolicy() != config.SignCommitsOff {
+		hash, err := commitWithGitCLI(root, message, signature, options)
+		if err != nil {
+			return "", fmt.Errorf("failed to create commit: %w", err)
+		}
+		logger.Info("Created commit: %s", hash)
+		return hash, nil
+	}
+
+	signKey, err := commitSigningKey(options)
+	if err != nil {
+		return "", err
+	}
 	commit, err := workTree.Commit(message, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "cherry-go",
-			Email: "cherry-go@local",
-			When:  time.Now(),
-		},
+		Author:    signature,
+		Committer: signature,
+		SignKey:   signKey,
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to create commit: %w", err)
+		return "", fmt.Errorf("failed to create commit: %w", err)
 	}
 
 	logger.Info("Created commit: %s", commit.String())
-	return nil
+	return commit.String(), nil
 }
 
 // FindFirstCommitForFile finds the first commit that introduced a file in the repository
@@ -1275,19 +1991,63 @@ func GetFileContentFromCommit(commit *object.Commit, filePath string) ([]byte, e
 	return []byte(content), nil
 }
 
+// baseContent returns the merge base for a file: the snapshot taken when its path was last
+// synced, otherwise its first version in local git history (following renames made this sync)
+func (r *Repository) baseContent(input processPathInput, relPath, localPath string) []byte {
+	if manager := r.baseContentManager(); manager != nil {
+		content, err := manager.GetFileContent(r.source.Name, input.pathSpec.Include, relPath)
+		if err == nil && content != nil {
+			logger.Debug("Using base from snapshot for %s", relPath)
+			return content
+		}
+	}
+
+	base, _ := getBaseContentFromGitHistory(input.workDir, localPath)
+	if len(base) == 0 {
+		if oldPath, renamed := input.renamedFrom[relPath]; renamed {
+			base, _ = getBaseContentFromGitHistory(input.workDir, filepath.Join(input.localPath, oldPath))
+		}
+	}
+	return base
+}
+
+// saveBaseSnapshot records the upstream content of a synced path as the base for future merges
+func (r *Repository) saveBaseSnapshot(pathSpec config.PathSpec, sourcePath string, isDir bool) {
+	if logger.IsDryRun() {
+		return
+	}
+
+	manager := r.baseContentManager()
+	if manager == nil {
+		return
+	}
+
+	// Directory snapshots are keyed by path relative to the directory, files by base name
+	var files map[string][]byte
+	if isDir {
+		files = r.readRemoteFiles(sourcePath, "", true, pathSpec.Excludes)
+	} else {
+		files = r.readRemoteFiles(sourcePath, filepath.Base(sourcePath), false, config.Excludes{})
+	}
+
+	if err := manager.SaveSnapshot(r.source.Name, pathSpec.Include, files); err != nil {
+		logger.Warning("Failed to save base snapshot for %s: %v", pathSpec.Include, err)
+	}
+}
+
 // getBaseContentFromGitHistory gets the base content for a file from git history
 // Returns the content from the first commit, or empty byte slice if no history exists
 func getBaseContentFromGitHistory(workDir string, localPath string) ([]byte, error) {
-	// Open local repository
-	localRepo, err := git.PlainOpen(workDir)
+	// Open the repository containing workDir
+	localRepo, root, err := openProjectRepository(workDir)
 	if err != nil {
 		// Not a git repository or error opening - return empty base
 		logger.Debug("Could not open git repository at %s: %v", workDir, err)
 		return []byte{}, nil
 	}
 
-	// Get relative path from workDir
-	relPath, err := filepath.Rel(workDir, localPath)
+	// History paths are relative to the repository root
+	relPath, err := repoRelativePath(root, workDir, localPath)
 	if err != nil {
 		relPath = localPath
 	}
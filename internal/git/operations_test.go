@@ -4,13 +4,15 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"cherry-go/internal/config"
 	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
 )
 
 func TestShouldExclude(t *testing.T) {
-	excludes := []string{"*.tmp", "test_*", "node_modules"}
+	excludes := config.Excludes{Patterns: []string{"*.tmp", "test_*", "node_modules"}}
 
 	testCases := []struct {
 		path     string
@@ -78,7 +80,7 @@ func TestCopyFile(t *testing.T) {
 
 	// Copy file
 	dstPath := filepath.Join(tmpDir, "subdir", "dest.txt")
-	if copyErr := copyFile(srcPath, dstPath); copyErr != nil {
+	if copyErr := copyFile(srcPath, dstPath, false, nil, nil); copyErr != nil {
 		t.Fatalf("Failed to copy file: %v", copyErr)
 	}
 
@@ -128,9 +130,9 @@ func TestCopyDir(t *testing.T) {
 
 	// Copy directory with excludes
 	dstDir := filepath.Join(tmpDir, "dst")
-	excludes := []string{"*.tmp"}
+	excludes := config.Excludes{Patterns: []string{"*.tmp"}}
 
-	if err := copyDir(srcDir, dstDir, excludes); err != nil {
+	if err := copyDir(srcDir, dstDir, excludes, false, false, nil, nil, nil); err != nil {
 		t.Fatalf("Failed to copy directory: %v", err)
 	}
 
@@ -153,3 +155,222 @@ func TestCopyDir(t *testing.T) {
 		t.Error("Expected file2.tmp to be excluded")
 	}
 }
+
+func TestCopyPaths_MergeConflictHunks(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\nb\nc\nd\ne\nf\ng\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	sync := func(mode SyncMode) *CopyResult {
+		t.Helper()
+		repo, err := NewRepository(source)
+		if err != nil {
+			t.Fatalf("Failed to open repository: %v", err)
+		}
+		if err := repo.Pull(); err != nil {
+			t.Fatalf("Failed to pull: %v", err)
+		}
+		result, err := repo.CopyPaths(mode, project.Dir)
+		if err != nil {
+			t.Fatalf("CopyPaths failed: %v", err)
+		}
+		return result
+	}
+	sync(SyncModeForce)
+
+	project.WriteFile("lib/a.go", "a\nB local\nc\nd\ne\nF local\ng\n")
+	upstream.WriteFile("lib/a.go", "a\nB remote\nc\nd\ne\nF remote\ng\n")
+	upstream.Commit("conflicting change")
+
+	result := sync(SyncModeMerge)
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected one conflict, got %+v", result.Conflicts)
+	}
+	hunks := result.Conflicts[0].Hunks
+	if len(hunks) != 2 || hunks[0].StartLine != 2 || hunks[0].EndLine != 8 || hunks[1].Excerpt != "F local" {
+		t.Errorf("Expected the two conflicting hunks to be located, got %+v", hunks)
+	}
+}
+
+func TestContentDiffersFromRemote_TrailingNewline(t *testing.T) {
+	logger.Init()
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+	}
+	write("remote/a.go", "package a")
+	write("local/a.go", "package a\n")
+
+	disabled := false
+	for _, tc := range []struct {
+		name    string
+		options config.SyncOptions
+		differs bool
+	}{
+		{"default", config.SyncOptions{}, false},
+		{"disabled", config.SyncOptions{IgnoreTrailingNewline: &disabled}, true},
+	} {
+		r := &Repository{options: tc.options}
+		for _, rel := range []string{"", "a.go"} { // The directory, then the file
+			sourcePath, localPath := filepath.Join(dir, "remote", rel), filepath.Join(dir, "local", rel)
+			info, err := os.Stat(sourcePath)
+			if err != nil {
+				t.Fatalf("Failed to stat %s: %v", sourcePath, err)
+			}
+			input := processPathInput{pathSpec: config.PathSpec{Include: "lib/"}, sourcePath: sourcePath, localPath: localPath, srcInfo: info}
+			if got := r.contentDiffersFromRemote(input); got != tc.differs {
+				t.Errorf("%s, %q: contentDiffersFromRemote = %t, expected %t", tc.name, rel, got, tc.differs)
+			}
+		}
+	}
+}
+
+func TestCopyPaths_TrailingNewline(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\nb")
+	upstream.WriteFile("lib/b.go", "b\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	sync := func(mode SyncMode, options config.SyncOptions) *CopyResult {
+		t.Helper()
+		repo, err := NewRepository(source)
+		if err != nil {
+			t.Fatalf("Failed to open repository: %v", err)
+		}
+		repo.SetSyncOptions(options)
+		if err := repo.Pull(); err != nil {
+			t.Fatalf("Failed to pull: %v", err)
+		}
+		result, err := repo.CopyPaths(mode, project.Dir)
+		if err != nil {
+			t.Fatalf("CopyPaths failed: %v", err)
+		}
+		return result
+	}
+	sync(SyncModeForce, config.SyncOptions{})
+	project.WriteFile("lib/a.go", "a\nb\n") // An editor added the final newline
+
+	if result := sync(SyncModeDetect, config.SyncOptions{}); len(result.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a missing final newline, got %v", result.Conflicts)
+	}
+	if drifts, err := VerifyTracking(source, config.SyncOptions{}); err != nil || len(drifts) != 0 {
+		t.Errorf("Expected the tracking hashes to match, got %+v (%v)", drifts, err)
+	}
+
+	disabled := false
+	if result := sync(SyncModeDetect, config.SyncOptions{IgnoreTrailingNewline: &disabled}); len(result.Conflicts) != 1 {
+		t.Errorf("Expected the final newline to count with the option off, got %v", result.Conflicts)
+	}
+
+	// The local copy counts as unchanged, so upstream's change is taken without a merge
+	upstream.WriteFile("lib/a.go", "a\nb\nc")
+	upstream.Commit("add c")
+	if result := sync(SyncModeMerge, config.SyncOptions{}); len(result.Conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %v", result.Conflicts)
+	}
+	if got := project.ReadFile("lib/a.go"); got != "a\nb\nc" {
+		t.Errorf("Expected upstream's content, got %q", got)
+	}
+}
+
+func TestCopyPaths_ResolvesAgainstProjectRoot(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "a\nb\nc\n")
+	upstream.WriteFile("lib/b.go", "b\n")
+	upstream.WriteFile("lib/c.go", "c\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+
+	// Run from an unrelated directory: only workDir says where the project is
+	elsewhere := testutil.NewProject(t)
+	elsewhere.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", LocalPath: "vendor/lib/"}}}
+	sync := func(mode SyncMode, options config.SyncOptions) *CopyResult {
+		t.Helper()
+		return syncFixtureWith(t, source, mode, project.Dir, options)
+	}
+
+	// Copy
+	result := sync(SyncModeForce, config.SyncOptions{})
+	if !project.Exists("vendor/lib/a.go") || elsewhere.Exists("vendor") {
+		t.Fatal("Expected the files to land in the project, not the working directory")
+	}
+	if len(result.FileActions) == 0 || result.FileActions[0].Path != filepath.Join("vendor", "lib", "a.go") {
+		t.Errorf("Expected file actions named relative to the project, got %+v", result.FileActions)
+	}
+
+	// Merge
+	project.WriteFile("vendor/lib/a.go", "a local\nb\nc\n")
+	upstream.WriteFile("lib/a.go", "a\nb\nc remote\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.Commit("change a, remove b")
+	result = sync(SyncModeMerge, config.SyncOptions{})
+	if len(result.Conflicts) != 0 {
+		t.Fatalf("Expected a clean merge, got %+v", result.Conflicts)
+	}
+	if got := project.ReadFile("vendor/lib/a.go"); got != "a local\nb\nc remote\n" {
+		t.Errorf("Expected both sides merged, got %q", got)
+	}
+
+	// Prune
+	if project.Exists("vendor/lib/b.go") {
+		t.Error("Expected the file removed upstream to be deleted from the project")
+	}
+	if elsewhere.Exists("vendor") {
+		t.Error("Expected nothing written to the working directory")
+	}
+
+	// Protected rules still match project-relative paths
+	upstream.WriteFile("lib/c.go", "c changed\n")
+	upstream.Commit("change c")
+	result = sync(SyncModeForce, config.SyncOptions{ProtectedPaths: []string{"vendor/lib/c.go"}})
+	if len(result.Refused) != 1 || result.Refused[0].Path != filepath.Join("vendor", "lib", "c.go") {
+		t.Errorf("Expected the protected file to be refused, got %+v", result.Refused)
+	}
+	if got := project.ReadFile("vendor/lib/c.go"); got != "c\n" {
+		t.Errorf("Expected the protected file left alone, got %q", got)
+	}
+}
+
+func TestCreateCommit_ReturnsCreatedCommit(t *testing.T) {
+	logger.Init()
+	project := testutil.NewProject(t)
+	project.WriteFile("lib/a.go", "package lib\n")
+
+	commit, err := CreateCommit(project.Dir, "sync lib", []string{"lib/"}, time.Now(), config.SyncOptions{})
+	if err != nil {
+		t.Fatalf("CreateCommit failed: %v", err)
+	}
+	head, err := project.Repo().Head()
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	if commit == "" || commit != head.Hash().String() {
+		t.Errorf("Expected the created commit %s to be HEAD, got %q", head.Hash(), commit)
+	}
+
+	// Nothing staged differs from HEAD: no commit is made, and none is reported
+	commit, err = CreateCommit(project.Dir, "sync lib again", []string{"lib/"}, time.Now(), config.SyncOptions{})
+	if err != nil || commit != "" {
+		t.Errorf("Expected no commit for unchanged files, got %q, %v", commit, err)
+	}
+	if again, _ := project.Repo().Head(); again.Hash() != head.Hash() {
+		t.Errorf("Expected HEAD to stay at %s, got %s", head.Hash(), again.Hash())
+	}
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"sort"
+	"sync"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+)
+
+// pathSync is one path's share of a CopyPaths run, kept apart from the other paths'
+// until every path is done so concurrent syncs never write shared state
+type pathSync struct {
+	spec          config.PathSpec   // The path's spec with its new tracking state
+	result        CopyResult        // The path's results, protected writes and failures
+	conflictFiles map[string][]byte // Remote files for the conflict branch
+}
+
+// checkoutGroup is the paths of a source that read the same branch, by index
+type checkoutGroup struct {
+	branch string // Branch, tag or commit to check out
+	paths  []int
+}
+
+// checkoutGroups groups the paths to sync by the ref they read (see pathRefs). Paths of
+// one group can sync concurrently from a single checkout. Groups are ordered by their last
+// path, so the cache is left on the last path's branch as when paths synced one by one.
+func (r *Repository) checkoutGroups(mode SyncMode, refs []string) []checkoutGroup {
+	var groups []checkoutGroup
+	index := make(map[string]int)
+	for i, pathSpec := range r.source.Paths {
+		if skipLinkedPath(pathSpec, mode) {
+			continue
+		}
+		g, ok := index[refs[i]]
+		if !ok {
+			g = len(groups)
+			index[refs[i]] = g
+			groups = append(groups, checkoutGroup{branch: refs[i]})
+		}
+		groups[g].paths = append(groups[g].paths, i)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		return groups[a].paths[len(groups[a].paths)-1] < groups[b].paths[len(groups[b].paths)-1]
+	})
+	return groups
+}
+
+// syncPath syncs the path at index on a worker copy of the repository: it shares the
+// clone and its checkout, which are only read, but has its own copy of the source and
+// its own run state
+func (r *Repository) syncPath(index int, commit string, mode SyncMode, workDir string, hasher *hash.FileHasher) *pathSync {
+	source := r.source.Clone()
+	worker := *r
+	worker.source = &source
+	worker.refused, worker.failed, worker.empty, worker.large, worker.unresolved, worker.metrics = nil, nil, nil, nil, nil, SyncMetrics{}
+
+	synced := &pathSync{result: CopyResult{PathCommits: make(map[string]string), LinkTargets: make(map[string]string)}}
+	synced.conflictFiles = worker.syncPathSpec(index, commit, mode, workDir, hasher, &synced.result)
+	synced.spec = source.Paths[index]
+	synced.result.Refused = worker.refused
+	synced.result.Failed = worker.failed
+	synced.result.Empty = worker.empty
+	synced.result.Large = worker.large
+	synced.result.Unresolved = worker.unresolved
+	synced.result.Metrics = worker.metrics
+	return synced
+}
+
+// applyPathSync applies a path's sync to the source and adds its results to result
+func (r *Repository) applyPathSync(index int, synced *pathSync, result *CopyResult) {
+	r.source.Paths[index] = synced.spec
+
+	for include, commit := range synced.result.PathCommits {
+		result.PathCommits[include] = commit
+	}
+	for include, target := range synced.result.LinkTargets {
+		result.LinkTargets[include] = target
+	}
+	result.UpdatedPaths = append(result.UpdatedPaths, synced.result.UpdatedPaths...)
+	result.CommitPaths = append(result.CommitPaths, synced.result.CommitPaths...)
+	result.Conflicts = append(result.Conflicts, synced.result.Conflicts...)
+	result.FileActions = append(result.FileActions, synced.result.FileActions...)
+	result.Untracked = append(result.Untracked, synced.result.Untracked...)
+
+	r.refused = append(r.refused, synced.result.Refused...)
+	r.failed = append(r.failed, synced.result.Failed...)
+	r.empty = append(r.empty, synced.result.Empty...)
+	r.large = append(r.large, synced.result.Large...)
+	r.unresolved = append(r.unresolved, synced.result.Unresolved...)
+	r.metrics.Add(synced.result.Metrics)
+}
+
+// runBounded calls fn for every index, at most jobs at a time, and returns once all
+// calls have. A single index runs on the calling goroutine.
+func runBounded(indexes []int, jobs int, fn func(int)) {
+	if len(indexes) == 1 || jobs <= 1 {
+		for _, i := range indexes {
+			fn(i)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+	for _, i := range indexes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// manyPathsFixture creates an upstream with count directories of files files each, and a
+// source tracking every directory as its own path
+func manyPathsFixture(tb testing.TB, count, files int) (*testutil.FixtureRepo, *config.Source) {
+	tb.Helper()
+	upstream := testutil.NewFixtureRepo(tb, "many")
+	source := &config.Source{Name: "many", Repository: upstream.URL()}
+	for d := 0; d < count; d++ {
+		dir := fmt.Sprintf("pkg%02d", d)
+		for f := 0; f < files; f++ {
+			upstream.WriteFile(fmt.Sprintf("%s/file%02d.go", dir, f), fmt.Sprintf("package %s // %d\n", dir, f))
+		}
+		source.Paths = append(source.Paths, config.PathSpec{Include: dir + "/"})
+	}
+	upstream.Commit("initial")
+	return upstream, source
+}
+
+// Run with -race: the paths of one branch sync concurrently
+func TestCopyPaths_ManyPathsConcurrently(t *testing.T) {
+	logger.Init()
+	upstream, source := manyPathsFixture(t, 12, 5)
+
+	// One more path on another branch syncs from its own checkout
+	upstream.CreateBranch("develop")
+	upstream.WriteFile("extra/x.go", "package extra\n")
+	upstream.Commit("develop: add extra")
+	upstream.Checkout(testutil.DefaultBranch)
+	source.Paths = append(source.Paths[:6], append([]config.PathSpec{{Include: "extra/", Branch: "develop"}}, source.Paths[6:]...)...)
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+
+	// Results are in path order, whatever order the paths finished in
+	if len(result.UpdatedPaths) != len(source.Paths) {
+		t.Fatalf("Expected %d updated paths, got %v", len(source.Paths), result.UpdatedPaths)
+	}
+	for i, pathSpec := range source.Paths {
+		if result.UpdatedPaths[i] != pathSpec.Include {
+			t.Errorf("Expected updated path %d to be %s, got %s", i, pathSpec.Include, result.UpdatedPaths[i])
+		}
+		if _, ok := result.PathCommits[pathSpec.Include]; !ok {
+			t.Errorf("Expected a commit for %s", pathSpec.Include)
+		}
+	}
+	if result.Metrics.FilesCopied != 12*5+1 {
+		t.Errorf("Expected %d files copied, got %d", 12*5+1, result.Metrics.FilesCopied)
+	}
+
+	// Every path's tracking state lands in the source
+	for _, pathSpec := range source.Paths {
+		want := 5
+		if pathSpec.Include == "extra/" {
+			want = 1
+		}
+		if len(pathSpec.Files) != want || pathSpec.Commit == "" {
+			t.Errorf("Expected %s to track %d files at a commit, got %v at %q", pathSpec.Include, want, pathSpec.Files, pathSpec.Commit)
+		}
+	}
+	if !project.Exists("extra/x.go") || !project.Exists("pkg11/file04.go") {
+		t.Error("Expected the files of every path to be copied")
+	}
+
+	// Conflicts are reported in path order too
+	project.WriteFile("pkg09/file01.go", "local edit\n")
+	project.WriteFile("pkg02/file03.go", "local edit\n")
+	upstream.WriteFile("pkg09/file01.go", "upstream edit\n")
+	upstream.WriteFile("pkg02/file03.go", "upstream edit\n")
+	upstream.Commit("update two packages")
+
+	result = syncFixture(t, source, SyncModeDetect, project.Dir)
+	if len(result.Conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %v", result.Conflicts)
+	}
+	if result.Conflicts[0].LocalPath != "pkg02/file03.go" || result.Conflicts[1].LocalPath != "pkg09/file01.go" {
+		t.Errorf("Expected conflicts in path order, got %v", result.Conflicts)
+	}
+}
+
+func BenchmarkCopyPaths_ManyPaths(b *testing.B) {
+	logger.Init()
+	_, source := manyPathsFixture(b, 16, 50)
+	project := testutil.NewProject(b)
+	project.Chdir()
+
+	repo, err := NewRepository(source)
+	if err != nil {
+		b.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		b.Fatalf("Failed to pull: %v", err)
+	}
+	logger.SetOutput(io.Discard)
+	b.Cleanup(func() { logger.SetOutput(nil) })
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := repo.CopyPaths(SyncModeForce, project.Dir); err != nil {
+			b.Fatalf("CopyPaths failed: %v", err)
+		}
+	}
+}
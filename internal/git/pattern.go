@@ -0,0 +1,54 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// commitPaths returns the local paths auto_commit stages for an updated spec: its local
+// path, or for a pattern each file it tracked before or after the sync. A pattern's root
+// holds files the pattern doesn't own (it may be the project root), so it isn't staged whole.
+func commitPaths(pathSpec config.PathSpec, localPath string, before, after map[string]string) []string {
+	if !pathSpec.IsPattern() {
+		return []string{localPath}
+	}
+
+	seen := make(map[string]bool, len(before)+len(after))
+	var paths []string
+	for _, files := range []map[string]string{before, after} {
+		for key := range files {
+			if !seen[key] {
+				seen[key] = true
+				paths = append(paths, filepath.Join(localPath, key))
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// escapeLiteralInclude rewrites a pattern include that names an existing path upstream,
+// like docs/notes[1].md, as the escaped pattern tracking only that path, in both the
+// working copy and the source. A directory's files are tracked with it.
+func (r *Repository) escapeLiteralInclude(index int, pathSpec *config.PathSpec, result *CopyResult) {
+	info, err := os.Stat(filepath.Join(r.path, filepath.FromSlash(pathSpec.Include)))
+	if err != nil {
+		return
+	}
+	include := config.EscapePattern(pathSpec.Include)
+	if info.IsDir() {
+		include += "/**"
+	}
+
+	logger.Info("%s names a path upstream; tracking it as %s", pathSpec.Include, include)
+	if commit, ok := result.PathCommits[pathSpec.Include]; ok {
+		delete(result.PathCommits, pathSpec.Include)
+		result.PathCommits[include] = commit
+	}
+	pathSpec.Include = include
+	r.source.Paths[index].Include = include
+}
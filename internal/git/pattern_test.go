@@ -0,0 +1,218 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// newProtoFixture is a repository with .proto files at several depths among other files
+func newProtoFixture(t *testing.T) *testutil.FixtureRepo {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "protos")
+	upstream.WriteFile("api/user.proto", "message User {}\n")
+	upstream.WriteFile("api/v1/order.proto", "message Order {}\n")
+	upstream.WriteFile("api/v1/internal/audit.proto", "message Audit {}\n")
+	upstream.WriteFile("api/v1/order.go", "package v1\n")
+	upstream.WriteFile("api/README.md", "# API\n")
+	upstream.WriteFile("docs/guide.proto", "message Guide {}\n")
+	upstream.Commit("initial")
+	return upstream
+}
+
+func TestCopyPaths_PatternInclude(t *testing.T) {
+	upstream := newProtoFixture(t)
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "protos", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "api/**/*.proto"}}}
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	for _, file := range []string{"api/user.proto", "api/v1/order.proto", "api/v1/internal/audit.proto"} {
+		if !project.Exists(file) {
+			t.Errorf("Expected %s to be synced", file)
+		}
+	}
+	for _, file := range []string{"api/v1/order.go", "api/README.md", "docs/guide.proto"} {
+		if project.Exists(file) {
+			t.Errorf("Expected %s not to be synced", file)
+		}
+	}
+
+	spec := source.Paths[0]
+	if spec.Include != "api/**/*.proto" {
+		t.Errorf("Expected the pattern to be kept as written, got %q", spec.Include)
+	}
+	if len(spec.Files) != 3 || spec.Files[filepath.Join("v1", "order.proto")] == "" {
+		t.Errorf("Expected the 3 matches tracked relative to api/, got %v", spec.Files)
+	}
+
+	expectedCommit := []string{
+		filepath.Join("api", "user.proto"),
+		filepath.Join("api", "v1", "internal", "audit.proto"),
+		filepath.Join("api", "v1", "order.proto"),
+	}
+	if len(result.CommitPaths) != len(expectedCommit) {
+		t.Fatalf("Expected the matched files to be staged, got %v", result.CommitPaths)
+	}
+	for i, path := range expectedCommit {
+		if result.CommitPaths[i] != path {
+			t.Errorf("Commit path %d: expected %s, got %s", i, path, result.CommitPaths[i])
+		}
+	}
+
+	// A file added upstream that matches is picked up; one that doesn't is ignored
+	upstream.WriteFile("api/v2/invoice.proto", "message Invoice {}\n")
+	upstream.WriteFile("api/v2/invoice.go", "package v2\n")
+	upstream.Commit("v2")
+	result = syncFixture(t, source, SyncModeMerge, project.Dir)
+	if !project.Exists("api/v2/invoice.proto") || project.Exists("api/v2/invoice.go") {
+		t.Error("Expected only the new matching file to be synced")
+	}
+	if len(result.Untracked) != 0 {
+		t.Errorf("Expected files outside the pattern not to count as untracked, got %v", result.Untracked)
+	}
+}
+
+func TestCopyPaths_PatternLocalPathAndExcludes(t *testing.T) {
+	upstream := newProtoFixture(t)
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "protos", Repository: upstream.URL(), Paths: []config.PathSpec{{
+		Include:   "api/**/*.proto",
+		LocalPath: "third_party/proto/",
+		Exclude:   []string{"internal"},
+	}}}
+	syncFixture(t, source, SyncModeForce, project.Dir)
+
+	if !project.Exists("third_party/proto/user.proto") || !project.Exists("third_party/proto/v1/order.proto") {
+		t.Error("Expected matches to be placed under local_path, keeping their relative paths")
+	}
+	if project.Exists("third_party/proto/v1/internal/audit.proto") {
+		t.Error("Expected excludes to apply on top of the pattern")
+	}
+	if project.Exists("api") {
+		t.Error("Expected nothing to be written at the include's own path")
+	}
+}
+
+func TestCopyPaths_LiteralNameWithGlobCharacters(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "docs")
+	upstream.WriteFile("docs/notes[1].md", "# Notes 1\n")
+	upstream.WriteFile("docs/notes1.md", "# Other notes\n")
+	upstream.WriteFile("docs/[draft]/plan.md", "# Plan\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "docs", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "docs/notes[1].md"},
+		{Include: "docs/[draft]"},
+	}}
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	// The names exist upstream, so they are tracked as written rather than as globs
+	if !project.Exists("docs/notes[1].md") || !project.Exists("docs/[draft]/plan.md") {
+		t.Error("Expected the literally named file and directory to be synced")
+	}
+	if project.Exists("docs/notes1.md") {
+		t.Error("Expected docs/notes1.md not to be synced as a match of the character class")
+	}
+
+	expected := []string{`docs/notes\[1\].md`, `docs/\[draft\]/**`}
+	for i, include := range expected {
+		if source.Paths[i].Include != include {
+			t.Errorf("Expected include %d to be escaped as %q, got %q", i, include, source.Paths[i].Include)
+		}
+		if result.PathCommits[include] == "" {
+			t.Errorf("Expected the commit recorded under %q, got %v", include, result.PathCommits)
+		}
+	}
+
+	// The escaped includes keep syncing only those paths
+	upstream.WriteFile("docs/notes[1].md", "# Notes 1, revised\n")
+	upstream.Commit("revise")
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+	if project.ReadFile("docs/notes[1].md") != "# Notes 1, revised\n" || project.Exists("docs/notes1.md") {
+		t.Error("Expected the escaped include to update only the literally named file")
+	}
+}
+
+func TestCopyPaths_PatternWithoutMatches(t *testing.T) {
+	testCases := []struct {
+		name    string
+		include string
+		exclude []string
+	}{
+		{"no matching file", "api/**/*.thrift", nil},
+		{"missing root", "schemas/**/*.proto", nil},
+		{"everything excluded", "api/**/*.proto", []string{"*.proto"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstream := newProtoFixture(t)
+			project := testutil.NewProject(t)
+			project.Chdir()
+
+			source := &config.Source{Name: "protos", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: tc.include, Exclude: tc.exclude}}}
+			result := syncFixture(t, source, SyncModeMerge, project.Dir)
+
+			if len(result.Empty) != 1 || result.Empty[0].Include != tc.include {
+				t.Errorf("Expected %s to be reported as matching nothing, got %v", tc.include, result.Empty)
+			}
+			if len(result.UpdatedPaths) != 0 {
+				t.Errorf("Expected nothing synced, got %v", result.UpdatedPaths)
+			}
+		})
+	}
+}
+
+func TestCopyDir_MatchesPatternAgainstRelativePaths(t *testing.T) {
+	src := t.TempDir()
+	for _, file := range []string{"a.proto", "v1/b.proto", "v1/b.go"} {
+		path := filepath.Join(src, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+
+	// Directories are entered, and files matched by their path from the copied root
+	dst := filepath.Join(t.TempDir(), "dst")
+	excludes := config.SyncOptions{}.PathExcludes(nil, config.PathSpec{Include: "api/*/*.proto"})
+	if err := copyDir(src, dst, excludes, false, false, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to copy directory: %v", err)
+	}
+
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(dst, rel))
+		return err == nil
+	}
+	if !exists("v1/b.proto") {
+		t.Error("Expected v1/b.proto to match */*.proto")
+	}
+	if exists("a.proto") || exists("v1/b.go") {
+		t.Error("Expected files the pattern doesn't match to be left out")
+	}
+
+	// A directory without matches isn't created
+	dst = filepath.Join(t.TempDir(), "dst")
+	excludes = config.SyncOptions{}.PathExcludes(nil, config.PathSpec{Include: "api/*.proto"})
+	if err := copyDir(src, dst, excludes, false, false, nil, nil, nil); err != nil {
+		t.Fatalf("Failed to copy directory: %v", err)
+	}
+	if !exists("a.proto") || exists("v1") {
+		t.Error("Expected only a.proto to be copied, without an empty v1/")
+	}
+}
@@ -0,0 +1,173 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+)
+
+// PendingPath is what upstream has for a tracked path that its last sync didn't take
+type PendingPath struct {
+	Include      string
+	Ref          string          // Branch, tag or pin the path follows upstream
+	SyncedCommit string          // Commit the path was last synced from; empty before its first sync
+	Commits      int             // Commits since SyncedCommit that touched the path, merges left out
+	Newest       *ChangelogEntry // The newest of those commits
+	Files        []UpstreamFile  // The path's files that differ between SyncedCommit and Ref
+}
+
+// Kinds of upstream change to a tracked file
+const (
+	UpstreamModified = "modified"
+	UpstreamAdded    = "added"
+	UpstreamDeleted  = "deleted"
+)
+
+// UpstreamFile is a tracked file upstream changed since the path was last synced
+type UpstreamFile struct {
+	Path   string // Path in the repository
+	Change string // UpstreamModified, UpstreamAdded or UpstreamDeleted
+}
+
+// Pending reports whether the path has upstream changes to sync
+func (p PendingPath) Pending() bool {
+	return p.SyncedCommit == "" || p.Commits > 0
+}
+
+// PendingUpdates lists, for each tracked path in config order, the upstream commits
+// that touched it since the commit it was last synced from, like
+// `git log <synced>..origin/<branch> -- <include>`, and the files that differ between
+// the two, like `git diff --name-status <synced> origin/<branch> -- <include>`. It reads the cached clone as it
+// is, so fetch first to compare against the latest upstream history; a shallow clone
+// gets the history it lacks.
+func (r *Repository) PendingUpdates() ([]PendingPath, error) {
+	r.requireHistory("the pending commits")
+
+	// Paths synced together share their synced commit's history and tree diff
+	syncedHistory := make(map[plumbing.Hash]map[plumbing.Hash]bool)
+	treeChanges := make(map[[2]plumbing.Hash]object.Changes)
+
+	pending := make([]PendingPath, 0, len(r.source.Paths))
+	for _, pathSpec := range r.source.Paths {
+		ref, err := r.PathRef(pathSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pathSpec.Include, err)
+		}
+		if ref == "" {
+			ref = r.detectDefaultBranch()
+		}
+		path := PendingPath{Include: pathSpec.Include, Ref: ref, SyncedCommit: pathSpec.Commit}
+		if pathSpec.Commit == "" {
+			pending = append(pending, path)
+			continue
+		}
+
+		start, err := r.resolveRemoteRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: can't resolve '%s' upstream: %w", pathSpec.Include, ref, err)
+		}
+		synced := plumbing.NewHash(pathSpec.Commit)
+		if start != synced {
+			excluded, ok := syncedHistory[synced]
+			if !ok {
+				if excluded, err = r.ancestors(synced); err != nil {
+					return nil, fmt.Errorf("%s: last synced commit %s is not upstream (was history rewritten?): %w",
+						pathSpec.Include, ShortHash(pathSpec.Commit), err)
+				}
+				syncedHistory[synced] = excluded
+			}
+			if path.Commits, path.Newest, err = r.commitsTouching(start, excluded, pathSpec); err != nil {
+				return nil, fmt.Errorf("%s: %w", pathSpec.Include, err)
+			}
+
+			changes, ok := treeChanges[[2]plumbing.Hash{synced, start}]
+			if !ok {
+				if changes, err = r.diffCommits(synced, start); err != nil {
+					return nil, fmt.Errorf("%s: %w", pathSpec.Include, err)
+				}
+				treeChanges[[2]plumbing.Hash{synced, start}] = changes
+			}
+			path.Files = changedFiles(changes, pathSpec)
+		}
+		pending = append(pending, path)
+	}
+	return pending, nil
+}
+
+// commitsTouching counts the non-merge commits reachable from start, and not excluded,
+// that touched a tracked path, and returns the newest of them
+func (r *Repository) commitsTouching(start plumbing.Hash, excluded map[plumbing.Hash]bool, pathSpec config.PathSpec) (int, *ChangelogEntry, error) {
+	commits, err := r.repo.Log(&git.LogOptions{From: start, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer commits.Close()
+
+	count := 0
+	var newest *ChangelogEntry
+	err = commits.ForEach(func(c *object.Commit) error {
+		if excluded[c.Hash] || c.NumParents() > 1 {
+			return nil
+		}
+		touched, err := touchedPaths(c, []config.PathSpec{pathSpec})
+		if err != nil || len(touched) == 0 {
+			return err
+		}
+		count++
+		if newest == nil {
+			entry := changelogEntry(c, touched)
+			newest = &entry
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return count, newest, nil
+}
+
+// diffCommits compares the trees of two commits
+func (r *Repository) diffCommits(from, to plumbing.Hash) (object.Changes, error) {
+	trees := make([]*object.Tree, 2)
+	for i, hash := range []plumbing.Hash{from, to} {
+		commit, err := r.repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", ShortHash(hash.String()), err)
+		}
+		if trees[i], err = commit.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to read the tree of %s: %w", ShortHash(hash.String()), err)
+		}
+	}
+	changes, err := object.DiffTree(trees[0], trees[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with %s: %w", ShortHash(from.String()), ShortHash(to.String()), err)
+	}
+	return changes, nil
+}
+
+// changedFiles returns the tracked path's files among a tree diff's changes, sorted by path
+func changedFiles(changes object.Changes, pathSpec config.PathSpec) []UpstreamFile {
+	var files []UpstreamFile
+	for _, change := range changes {
+		from, to := change.From.Name, change.To.Name
+		if from == to {
+			if pathTouches(pathSpec, to) {
+				files = append(files, UpstreamFile{Path: to, Change: UpstreamModified})
+			}
+			continue
+		}
+		if pathTouches(pathSpec, from) {
+			files = append(files, UpstreamFile{Path: from, Change: UpstreamDeleted})
+		}
+		if pathTouches(pathSpec, to) {
+			files = append(files, UpstreamFile{Path: to, Change: UpstreamAdded})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
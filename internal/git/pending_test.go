@@ -0,0 +1,147 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestPendingUpdates(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // a\n")
+	upstream.WriteFile("docs/guide.md", "# Guide\n")
+	upstream.WriteFile("src/main.go", "package main\n")
+	initial := upstream.Commit("initial")
+	upstream.WriteFile("lib/a.go", "package lib // a fixed\n")
+	upstream.Commit("lib: fix a")
+	upstream.WriteFile("docs/guide.md", "# The guide\n")
+	docs := upstream.Commit("docs: typo")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.Commit("lib: add b\n\nWith a body that isn't part of the subject.")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	repo := fetchedFixture(t, &config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "lib/", Commit: initial},
+			{Include: "docs/guide.md", Commit: docs},
+			{Include: "src/"},
+		},
+	})
+	defer repo.Close()
+
+	pending, err := repo.PendingUpdates()
+	if err != nil {
+		t.Fatalf("PendingUpdates failed: %v", err)
+	}
+	if len(pending) != 3 {
+		t.Fatalf("Expected one entry per path, got %+v", pending)
+	}
+
+	lib := pending[0]
+	if lib.Commits != 2 || lib.Newest == nil || lib.Newest.Subject != "lib: add b" || !lib.Pending() {
+		t.Errorf("Expected lib/ to have 2 pending commits, the newest 'lib: add b', got %+v (newest %+v)", lib, lib.Newest)
+	}
+	if lib.Ref != testutil.DefaultBranch {
+		t.Errorf("Expected lib/ to follow %s, got %q", testutil.DefaultBranch, lib.Ref)
+	}
+
+	// Commits after docs' sync touched only lib/
+	if guide := pending[1]; guide.Commits != 0 || guide.Newest != nil || guide.Pending() {
+		t.Errorf("Expected docs/guide.md to be up to date, got %+v", guide)
+	}
+
+	if src := pending[2]; src.SyncedCommit != "" || !src.Pending() {
+		t.Errorf("Expected src/ to be pending its first sync, got %+v", src)
+	}
+}
+
+func TestPendingUpdates_Files(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // a\n")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.WriteFile("lib/skip.txt", "skipped\n")
+	upstream.WriteFile("docs/guide.md", "# Guide\n")
+	upstream.WriteFile("README.md", "# Library\n")
+	initial := upstream.Commit("initial")
+	upstream.WriteFile("lib/a.go", "package lib // a fixed\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.WriteFile("lib/c.go", "package lib // c\n")
+	upstream.WriteFile("lib/skip.txt", "still skipped\n")
+	upstream.WriteFile("README.md", "# The library\n")
+	upstream.Commit("lib: rework")
+	upstream.WriteFile("docs/guide.md", "# Guide, draft\n")
+	upstream.Commit("docs: draft")
+	upstream.WriteFile("docs/guide.md", "# Guide\n")
+	upstream.Commit("docs: revert the draft")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	repo := fetchedFixture(t, &config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths: []config.PathSpec{
+			{Include: "lib/", Exclude: []string{"skip.txt"}, Commit: initial},
+			{Include: "docs/", Commit: initial},
+			{Include: "src/"},
+		},
+	})
+	defer repo.Close()
+
+	pending, err := repo.PendingUpdates()
+	if err != nil {
+		t.Fatalf("PendingUpdates failed: %v", err)
+	}
+
+	// Excluded and untracked files are left out
+	expected := []UpstreamFile{
+		{Path: "lib/a.go", Change: UpstreamModified},
+		{Path: "lib/b.go", Change: UpstreamDeleted},
+		{Path: "lib/c.go", Change: UpstreamAdded},
+	}
+	if got := pending[0].Files; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected lib/ to list %+v, got %+v", expected, got)
+	}
+
+	// Commits touched docs/ but left it as it was
+	if docs := pending[1]; docs.Commits != 2 || len(docs.Files) != 0 {
+		t.Errorf("Expected docs/ to have 2 commits and no changed files, got %+v", docs)
+	}
+
+	if src := pending[2]; src.Files != nil {
+		t.Errorf("Expected a never synced path to list no files, got %+v", src.Files)
+	}
+}
+
+func TestPendingUpdates_UnknownSyncedCommit(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	// The commit a rewritten upstream history no longer has
+	repo := fetchedFixture(t, &config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/", Commit: strings.Repeat("ab", 20)}},
+	})
+	defer repo.Close()
+
+	_, err := repo.PendingUpdates()
+	if err == nil || !strings.Contains(err.Error(), "lib/: last synced commit abababab is not upstream") {
+		t.Errorf("Expected an error naming the unknown synced commit, got %v", err)
+	}
+}
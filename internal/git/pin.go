@@ -0,0 +1,95 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// pinsCached reports whether every path of the source is pinned to a commit the cached
+// clone already has. Nothing a fetch brings in could change what those paths sync.
+func (r *Repository) pinsCached() bool {
+	if len(r.source.Paths) == 0 {
+		return false
+	}
+	for _, pathSpec := range r.source.Paths {
+		// A tag pin is fetched: the tag may not be in the cache yet
+		pinned := pathSpec.PinnedRef()
+		if !config.IsCommitSHA(pinned) {
+			return false
+		}
+		if _, err := r.repo.CommitObject(plumbing.NewHash(pinned)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolvePin returns the commit a pin names: a full commit SHA the clone has, or a tag.
+// Branches never match, so a mistyped tag can't silently follow a moving branch. A commit
+// older than a shallow clone's depth is fetched with the rest of the history.
+func (r *Repository) ResolvePin(pin string) (string, error) {
+	commit, err := r.resolvePin(pin)
+	if err != nil && r.deepenFor(pin) {
+		return r.resolvePin(pin)
+	}
+	return commit, err
+}
+
+// resolvePin is ResolvePin on the clone as it is
+func (r *Repository) resolvePin(pin string) (string, error) {
+	if config.IsCommitSHA(pin) {
+		if _, err := r.repo.CommitObject(plumbing.NewHash(pin)); err != nil {
+			return "", fmt.Errorf("pinned commit %s not found in %s", pin, r.source.Repository)
+		}
+		return pin, nil
+	}
+	hash, err := r.repo.ResolveRevision(plumbing.Revision("refs/tags/" + pin))
+	if err != nil {
+		return "", fmt.Errorf("pinned tag '%s' not found in %s (a pin must be a tag or a full commit SHA)", pin, r.source.Repository)
+	}
+	return hash.String(), nil
+}
+
+// PathRef returns the ref a path is read from: the commit its pin resolves to, else
+// its branch ("" for the default branch)
+func (r *Repository) PathRef(pathSpec config.PathSpec) (string, error) {
+	if pathSpec.Pin == "" {
+		return pathSpec.Branch, nil
+	}
+	return r.ResolvePin(pathSpec.Pin)
+}
+
+// pathRefs returns the ref each path of the source is read from, by index. A pin that
+// doesn't resolve fails them all, before anything is synced.
+func (r *Repository) pathRefs() ([]string, error) {
+	refs := make([]string, len(r.source.Paths))
+	for i, pathSpec := range r.source.Paths {
+		ref, err := r.PathRef(pathSpec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pathSpec.Include, err)
+		}
+		refs[i] = ref
+	}
+	return refs, nil
+}
+
+// verifyPinnedCheckout checks that the cache checkout is at the commit a pinned path
+// must sync from
+func (r *Repository) verifyPinnedCheckout(pathSpec config.PathSpec, commit string) error {
+	if pathSpec.Pin == "" || logger.IsDryRun() {
+		return nil
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to resolve cache HEAD: %w", err)
+	}
+	if head.Hash().String() != commit {
+		return fmt.Errorf("%s is pinned to %s (%s) but the checkout is at %s",
+			pathSpec.Include, pathSpec.Pin, ShortHash(commit), ShortHash(head.Hash().String()))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestCopyPaths_PinnedPathStaysAtCommit(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "v1\n")
+	pin := upstream.Commit("v1")
+	upstream.WriteFile("lib/a.go", "v2\n")
+	upstream.Commit("v2")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Branch: pin}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := project.ReadFile("lib/a.go"); got != "v1\n" {
+		t.Fatalf("Expected the pinned content, got %q", got)
+	}
+
+	upstream.WriteFile("lib/a.go", "v3\n")
+	upstream.Commit("v3")
+
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := project.ReadFile("lib/a.go"); got != "v1\n" {
+		t.Errorf("Expected the pin to hold after upstream moved, got %q", got)
+	}
+	if len(result.FileActions) != 0 {
+		t.Errorf("Expected no changes while pinned, got %v", result.FileActions)
+	}
+}
+
+func TestPull_SkipsFetchWhenPinsCached(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "v1\n")
+	pin := upstream.Commit("v1")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Branch: pin}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	// With upstream gone a fetch would fail, so a successful sync proves none was attempted
+	if err := os.Rename(upstream.BareDir, upstream.BareDir+".gone"); err != nil {
+		t.Fatalf("Failed to hide upstream: %v", err)
+	}
+	project.WriteFile("lib/a.go", "local\n")
+
+	syncFixture(t, source, SyncModeForce, project.Dir)
+	if got := project.ReadFile("lib/a.go"); got != "v1\n" {
+		t.Errorf("Expected the pinned content restored from the cache, got %q", got)
+	}
+}
+
+func TestPull_FetchesWhenAnyPathFollowsABranch(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "v1\n")
+	upstream.WriteFile("docs/readme.md", "v1\n")
+	pin := upstream.Commit("v1")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "lib/", Branch: pin},
+		{Include: "docs/"},
+	}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	upstream.WriteFile("lib/a.go", "v2\n")
+	upstream.WriteFile("docs/readme.md", "v2\n")
+	upstream.Commit("v2")
+
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := project.ReadFile("docs/readme.md"); got != "v2\n" {
+		t.Errorf("Expected the unpinned path to follow upstream, got %q", got)
+	}
+	if got := project.ReadFile("lib/a.go"); got != "v1\n" {
+		t.Errorf("Expected the pinned path to hold, got %q", got)
+	}
+}
+
+func TestCopyPaths_PinnedTag(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "v1\n")
+	upstream.Commit("v1")
+	upstream.Tag("v1.0.0")
+
+	// A branch named like the tag must not shadow it
+	upstream.CreateBranch("v1.0.0")
+	upstream.WriteFile("lib/a.go", "branch\n")
+	upstream.Commit("moving branch")
+	upstream.Push()
+	upstream.Checkout(testutil.DefaultBranch)
+	upstream.WriteFile("lib/a.go", "v2\n")
+	upstream.Commit("v2")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/", Pin: "v1.0.0"}}}
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	if got := project.ReadFile("lib/a.go"); got != "v1\n" {
+		t.Fatalf("Expected the tagged content, got %q", got)
+	}
+	if len(result.UpdatedPaths) != 1 || source.Paths[0].Commit == "" {
+		t.Errorf("Expected lib/ synced and its commit recorded, got %v", result.UpdatedPaths)
+	}
+}
+
+func TestCopyPaths_UnknownPinFailsSource(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "v1\n")
+	upstream.Commit("v1")
+	upstream.Tag("v1.0.0")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	// A mistyped tag that names a branch still doesn't resolve
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "lib/a.go"},
+		{Include: "lib/", LocalPath: "vendor/", Pin: testutil.DefaultBranch},
+	}}
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	_, err = repo.CopyPaths(SyncModeForce, project.Dir)
+	if err == nil || !strings.Contains(err.Error(), "pinned tag '"+testutil.DefaultBranch+"' not found") {
+		t.Fatalf("Expected the unknown pin to fail the sync, got %v", err)
+	}
+	if project.Exists("lib/a.go") || project.Exists("vendor") {
+		t.Error("Expected nothing synced when a pin doesn't resolve")
+	}
+}
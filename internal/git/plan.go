@@ -0,0 +1,475 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/merge"
+	"cherry-go/internal/utils"
+)
+
+// PlanVersion is the plan file format this version writes and applies
+const PlanVersion = 1
+
+// Plan is the document `cherry-go plan` writes and `cherry-go apply` carries out: the
+// file actions a sync would take, and the commits and local hashes they were planned
+// against. Its field names are a stable interface; add fields, never rename them.
+type Plan struct {
+	Version int          `json:"version"`
+	Created time.Time    `json:"created"`
+	Sources []SourcePlan `json:"sources"`
+}
+
+// SourcePlan is the planned sync of one source
+type SourcePlan struct {
+	Name       string     `json:"name"`
+	Repository string     `json:"repository"`
+	Paths      []PathPlan `json:"paths"`
+}
+
+// PathPlan is the planned sync of one tracked path
+type PathPlan struct {
+	Include    string           `json:"include"`
+	LocalPath  string           `json:"local_path"`
+	BaseCommit string           `json:"base_commit"` // Commit the path was last synced from, empty if never
+	Commit     string           `json:"commit"`      // Upstream commit the files are planned from
+	Files      []FilePlan       `json:"files"`
+	Conflicts  []ConflictReport `json:"conflicts"` // Changed on both sides and not mergeable: left alone
+}
+
+// PlanAction is what applying a plan does to a file
+type PlanAction string
+
+const (
+	PlanAdd    PlanAction = "add"    // Write the upstream file where there is none locally
+	PlanUpdate PlanAction = "update" // Overwrite a file unchanged locally with the upstream one
+	PlanMerge  PlanAction = "merge"  // Write the merge of the local and upstream changes
+	PlanDelete PlanAction = "delete" // Remove a file unchanged locally that upstream removed
+)
+
+// FilePlan is a planned file action
+type FilePlan struct {
+	Action    PlanAction `json:"action"`
+	Path      string     `json:"path"`       // Local path
+	Key       string     `json:"key"`        // Name in the path's tracked files
+	LocalHash string     `json:"local_hash"` // Of the local file when planned, empty when missing
+	NewHash   string     `json:"new_hash"`   // Of what is written, empty for deletions
+	Link      bool       `json:"link,omitempty"`
+	Content   []byte     `json:"content,omitempty"` // The merged content, for merges
+	Added     int        `json:"added"`
+	Removed   int        `json:"removed"`
+}
+
+// fileAction is the FileAction sync reports for the planned action
+func (f FilePlan) fileAction() FileAction {
+	action := FileAction{Path: f.Path, Added: f.Added, Removed: f.Removed}
+	switch f.Action {
+	case PlanAdd:
+		action.Type = FileActionAdded
+	case PlanDelete:
+		action.Type = FileActionDeleted
+	default:
+		action.Type = FileActionUpdated
+	}
+	return action
+}
+
+// NewPlan starts an empty plan
+func NewPlan() *Plan {
+	return &Plan{Version: PlanVersion, Created: time.Now().UTC(), Sources: []SourcePlan{}}
+}
+
+// FileCount returns how many file actions and conflicts the plan holds
+func (p *Plan) FileCount() (actions, conflicts int) {
+	for _, source := range p.Sources {
+		for _, path := range source.Paths {
+			actions += len(path.Files)
+			conflicts += len(path.Conflicts)
+		}
+	}
+	return actions, conflicts
+}
+
+// Plan works out what a merge-mode sync of the source would do to each file, without
+// changing the project or the cache checkout. Upstream is read from the cached clone's
+// remote-tracking branches (or each path's pinned commit), so fetch first. Files
+// unchanged locally take the upstream version, files changed on both sides are merged
+// against their version at the path's last synced commit, and files changed locally
+// only are left alone. Renames are planned as a delete and an add, and protected files
+// are left out.
+func (r *Repository) Plan(workDir string) (SourcePlan, error) {
+	plan := SourcePlan{Name: r.source.Name, Repository: r.source.Repository, Paths: []PathPlan{}}
+	if r.repo == nil {
+		return plan, fmt.Errorf("repository not available")
+	}
+
+	hasher := hash.NewFileHasherFor(r.options)
+	for _, pathSpec := range r.source.Paths {
+		pathPlan, err := r.planPath(workDir, pathSpec, hasher)
+		if err != nil {
+			return plan, fmt.Errorf("%s: %w", pathSpec.Include, err)
+		}
+		plan.Paths = append(plan.Paths, pathPlan)
+	}
+	return plan, nil
+}
+
+// planPath plans the sync of one tracked path
+func (r *Repository) planPath(workDir string, pathSpec config.PathSpec, hasher *hash.FileHasher) (PathPlan, error) {
+	plan := PathPlan{
+		Include:    pathSpec.Include,
+		LocalPath:  pathSpec.LocalRoot(),
+		BaseCommit: pathSpec.Commit,
+		Files:      []FilePlan{},
+		Conflicts:  []ConflictReport{},
+	}
+
+	commit, err := r.latestPathCommit(pathSpec)
+	if err != nil {
+		return plan, err
+	}
+	plan.Commit = commit.String()
+
+	isDir, excludes := r.pathKind(workDir, pathSpec)
+	atCommit := pathSpec
+	atCommit.Commit = plan.Commit
+	upstream, err := upstreamFiles(r.repo, atCommit, isDir, excludes)
+	if err != nil {
+		return plan, err
+	}
+
+	// The last synced version of each file is the base its changes are merged against
+	var base map[string]*object.File
+	if pathSpec.Commit != "" {
+		if base, err = upstreamFiles(r.repo, pathSpec, isDir, excludes); err != nil {
+			logger.Debug("No merge base for %s: %v", pathSpec.Include, err)
+		}
+	}
+
+	keys := make(map[string]bool, len(upstream)+len(pathSpec.Files))
+	for key := range upstream {
+		keys[key] = true
+	}
+	for key := range pathSpec.Files {
+		if !isDir || !shouldExclude(key, excludes) {
+			keys[key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		localPath := plan.LocalPath
+		if isDir {
+			localPath = filepath.Join(plan.LocalPath, key)
+		}
+		if rule, protected := r.options.ProtectedRule(localPath); protected {
+			logger.Debug("Not planning %s: protected by '%s'", localPath, rule)
+			continue
+		}
+		if file := upstream[key]; isDir && file != nil && file.Mode == filemode.Symlink && r.options.FollowSymlinks {
+			logger.Warning("Not planning %s: plans don't follow symbolic links (options.follow_symlinks)", localPath)
+			continue
+		}
+
+		file, conflict, err := r.planFile(workDir, localPath, key, isDir, upstream[key], base[key], pathSpec.Files[key], hasher)
+		if err != nil {
+			return plan, err
+		}
+		switch {
+		case conflict != nil:
+			plan.Conflicts = append(plan.Conflicts, *conflict)
+		case file != nil:
+			plan.Files = append(plan.Files, *file)
+		}
+	}
+	return plan, nil
+}
+
+// planFile plans one file from its local copy, its upstream version (nil when removed
+// upstream) and its last synced version (nil when unknown). It returns no action for a
+// file that stays as it is, and a conflict for one changed on both sides that doesn't merge.
+func (r *Repository) planFile(workDir, localPath, key string, isDir bool, upstream, base *object.File, tracked string,
+	hasher *hash.FileHasher) (*FilePlan, *ConflictReport, error) {
+	follow := !isDir || r.options.FollowSymlinks
+	local, localIsLink, err := readEntry(filepath.Join(workDir, localPath), follow)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	localExists := err == nil
+
+	var remote, baseContent []byte
+	if upstream != nil {
+		if remote, err = readTreeFile(upstream); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s upstream: %w", key, err)
+		}
+	}
+	if base != nil {
+		if baseContent, err = readTreeFile(base); err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s at the last synced commit: %w", key, err)
+		}
+	}
+
+	file := &FilePlan{Path: localPath, Key: key, Link: upstream != nil && upstream.Mode == filemode.Symlink && !follow}
+	if localExists {
+		file.LocalHash = hasher.HashBytes(local)
+	}
+	// Unchanged since the last sync, by the synced version or else the tracked hash
+	unchanged := localExists && (base != nil && r.sameContent(local, baseContent) || base == nil && tracked == file.LocalHash)
+	linked := file.Link || localIsLink // Links are replaced, never merged
+
+	switch {
+	case upstream == nil && !localExists:
+		return nil, nil, nil
+	case upstream == nil:
+		if !unchanged {
+			return nil, &ConflictReport{Path: localPath, Type: string(hash.ConflictTypeRemovedUpstream)}, nil
+		}
+		file.Action = PlanDelete
+		fillPlanStats(file, local, nil)
+		return file, nil, nil
+	case !localExists:
+		file.Action = PlanAdd
+	case localIsLink == file.Link && r.sameContent(local, remote):
+		return nil, nil, nil
+	case unchanged || linked:
+		file.Action = PlanUpdate
+	case base != nil && r.sameContent(baseContent, remote):
+		return nil, nil, nil // Changed locally only
+	default:
+		merged, err := merge.ThreeWayMergeWithOptions(baseContent, local, remote, r.mergeOptions())
+		if err != nil || merged.HasConflict {
+			return nil, &ConflictReport{Path: localPath, Type: string(hash.ConflictTypeModified)}, nil
+		}
+		file.Action, file.Content, remote = PlanMerge, merged.Content, merged.Content
+	}
+
+	file.NewHash = hasher.HashBytes(remote)
+	fillPlanStats(file, local, remote)
+	return file, nil, nil
+}
+
+// fillPlanStats counts the lines a planned action adds and removes
+func fillPlanStats(file *FilePlan, before, after []byte) {
+	action := file.fileAction()
+	fillStats(&action, contentFile(before), contentFile(after))
+	file.Added, file.Removed = action.Added, action.Removed
+}
+
+// CheckPlan verifies that a source's plan still applies: every path is at the commit it
+// was last synced from when planned, upstream is still at the commit planned from, and
+// every file to change still hashes as it did. Fetch first, so upstream is current.
+func (r *Repository) CheckPlan(plan SourcePlan, workDir string) error {
+	if r.repo == nil {
+		return fmt.Errorf("repository not available")
+	}
+	if !utils.SameRepository(plan.Repository, r.source.Repository) {
+		return fmt.Errorf("planned for %s, but %s now tracks %s", plan.Repository, r.source.Name, r.source.Repository)
+	}
+
+	hasher := hash.NewFileHasherFor(r.options)
+	for _, pathPlan := range plan.Paths {
+		pathSpec := r.plannedPathSpec(pathPlan.Include)
+		if pathSpec == nil {
+			return fmt.Errorf("%s is no longer tracked", pathPlan.Include)
+		}
+		if pathSpec.Commit != pathPlan.BaseCommit {
+			return fmt.Errorf("%s was synced since the plan was made (now at %s, planned from %s)",
+				pathPlan.Include, ShortHash(pathSpec.Commit), ShortHash(pathPlan.BaseCommit))
+		}
+		commit, err := r.latestPathCommit(*pathSpec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathPlan.Include, err)
+		}
+		if commit.String() != pathPlan.Commit {
+			return fmt.Errorf("upstream %s moved to %s since the plan was made at %s",
+				pathPlan.Include, ShortHash(commit.String()), ShortHash(pathPlan.Commit))
+		}
+
+		isDir, _ := r.pathKind(workDir, *pathSpec)
+		for _, file := range pathPlan.Files {
+			local, _, err := readEntry(filepath.Join(workDir, file.Path), !isDir || r.options.FollowSymlinks)
+			current := ""
+			if err == nil {
+				current = hasher.HashBytes(local)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			if current != file.LocalHash {
+				return fmt.Errorf("%s changed locally since the plan was made", file.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// plannedPathSpec returns the tracked path a plan's path applies to
+func (r *Repository) plannedPathSpec(include string) *config.PathSpec {
+	for i := range r.source.Paths {
+		if r.source.Paths[i].Include == include {
+			return &r.source.Paths[i]
+		}
+	}
+	return nil
+}
+
+// ApplyPlan carries out a plan CheckPlan accepted, and only that plan: planned files are
+// written from the upstream commit planned from (merges from the plan itself), after
+// checking they are what was planned, and their tracking is updated. Paths without
+// conflicts are recorded as synced from that commit. Files that can't be written are
+// reported as failed and keep their previous tracking.
+func (r *Repository) ApplyPlan(plan SourcePlan, workDir string) (*CopyResult, error) {
+	result := &CopyResult{PathCommits: make(map[string]string), LinkTargets: make(map[string]string)}
+	r.refused = nil
+	r.failed = nil
+
+	for _, pathPlan := range plan.Paths {
+		pathSpec := r.plannedPathSpec(pathPlan.Include)
+		if pathSpec == nil {
+			return result, fmt.Errorf("%s is no longer tracked", pathPlan.Include)
+		}
+		if err := r.applyPathPlan(workDir, pathSpec, pathPlan, result); err != nil {
+			return result, fmt.Errorf("%s: %w", pathPlan.Include, err)
+		}
+	}
+
+	result.Refused = r.refused
+	result.Failed = r.failed
+	return result, nil
+}
+
+// applyPathPlan applies the planned files of one path
+func (r *Repository) applyPathPlan(workDir string, pathSpec *config.PathSpec, plan PathPlan, result *CopyResult) error {
+	isDir, excludes := r.pathKind(workDir, *pathSpec)
+	atCommit := *pathSpec
+	atCommit.Commit = plan.Commit
+	upstream, err := upstreamFiles(r.repo, atCommit, isDir, excludes)
+	if err != nil {
+		return err
+	}
+
+	hasher := hash.NewFileHasherFor(r.options)
+	before := make(map[string]string, len(pathSpec.Files))
+	for key, h := range pathSpec.Files {
+		before[key] = h
+	}
+	failedBefore, refusedBefore := len(r.failed), len(r.refused)
+	input := processPathInput{pathSpec: *pathSpec, localPath: plan.LocalPath}
+	input.pathSpec.Excludes = excludes
+	perms := r.treePerms(upstream)
+
+	for _, file := range plan.Files {
+		fullPath := filepath.Join(workDir, file.Path)
+		var content []byte
+		switch file.Action {
+		case PlanDelete:
+			if err := r.removeLocalFile(fullPath); err != nil {
+				if !isProtectedPathError(err) {
+					r.recordFailure(input, file.Path, err)
+				}
+				continue
+			}
+		case PlanMerge:
+			content = file.Content
+		default:
+			upstreamFile, ok := upstream[file.Key]
+			if !ok {
+				return fmt.Errorf("%s not found at %s", file.Key, ShortHash(plan.Commit))
+			}
+			if content, err = readTreeFile(upstreamFile); err != nil {
+				return fmt.Errorf("failed to read %s upstream: %w", file.Key, err)
+			}
+		}
+
+		if file.Action != PlanDelete {
+			if hasher.HashBytes(content) != file.NewHash {
+				return fmt.Errorf("%s doesn't hash as planned; the plan file was changed", file.Path)
+			}
+			if err := r.writeLocalEntry(fullPath, content, file.Link, perms.perm(file.Key)); err != nil {
+				if !isProtectedPathError(err) {
+					r.recordFailure(input, file.Path, err)
+				}
+				continue
+			}
+		}
+
+		if pathSpec.Files == nil {
+			pathSpec.Files = make(map[string]string)
+		}
+		if file.Action == PlanDelete {
+			delete(pathSpec.Files, file.Key)
+		} else {
+			pathSpec.Files[file.Key] = file.NewHash
+		}
+		result.FileActions = append(result.FileActions, file.fileAction())
+		logger.Info("  ✓ %s %s", file.Action, file.Path)
+	}
+
+	// Configured and upstream permissions are set and tracked as a sync sets them, once the files are written
+	fileModes, modesChanged := r.applyFileModes(input, isDir, pathSpec.Files, perms, false)
+	pathSpec.FileModes = fileModes
+
+	applied := len(r.failed) == failedBefore && len(r.refused) == refusedBefore
+	if applied && len(plan.Conflicts) == 0 && !logger.IsDryRun() {
+		pathSpec.Commit = plan.Commit
+		r.savePlannedSnapshot(*pathSpec, upstream, isDir)
+	}
+	result.PathCommits[pathSpec.Include] = plan.Commit
+	if len(plan.Files) > 0 || modesChanged {
+		result.UpdatedPaths = append(result.UpdatedPaths, pathSpec.Include)
+		result.CommitPaths = append(result.CommitPaths, commitPaths(*pathSpec, plan.LocalPath, before, pathSpec.Files)...)
+	}
+	return nil
+}
+
+// removeLocalFile deletes a local file after the protection check
+func (r *Repository) removeLocalFile(localPath string) error {
+	if err := r.checkWrite(localPath); err != nil {
+		return err
+	}
+	if logger.IsDryRun() {
+		return nil
+	}
+	if err := os.Remove(localPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// savePlannedSnapshot records the upstream files an applied path was synced from as the
+// base for later merges, as a sync does. Links are left out, as readRemoteFiles does.
+func (r *Repository) savePlannedSnapshot(pathSpec config.PathSpec, upstream map[string]*object.File, isDir bool) {
+	manager := r.baseContentManager()
+	if manager == nil {
+		return
+	}
+
+	files := make(map[string][]byte, len(upstream))
+	for key, file := range upstream {
+		if isDir && file.Mode == filemode.Symlink && !r.options.FollowSymlinks {
+			continue
+		}
+		content, err := readTreeFile(file)
+		if err != nil {
+			logger.Warning("Failed to save base snapshot for %s: %v", pathSpec.Include, err)
+			return
+		}
+		files[key] = content
+	}
+	if err := manager.SaveSnapshot(r.source.Name, pathSpec.Include, files); err != nil {
+		logger.Warning("Failed to save base snapshot for %s: %v", pathSpec.Include, err)
+	}
+}
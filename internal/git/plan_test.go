@@ -0,0 +1,200 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// fetchedFixture opens the source's repository and fetches it, as plan and apply do
+func fetchedFixture(t *testing.T, source *config.Source) *Repository {
+	t.Helper()
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Fetch(context.Background()); err != nil {
+		t.Fatalf("Failed to fetch: %v", err)
+	}
+	return repo
+}
+
+// newPlanFixture syncs lib/, then changes it upstream and locally so a plan has one
+// file of each kind: a.go merges, b.go is deleted, c.go is changed locally only, d.go
+// conflicts and e.go is added
+func newPlanFixture(t *testing.T) (*testutil.FixtureRepo, *testutil.Project, *config.Source) {
+	t.Helper()
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "one\ntwo\nthree\n")
+	upstream.WriteFile("lib/b.go", "b\n")
+	upstream.WriteFile("lib/c.go", "c\n")
+	upstream.WriteFile("lib/d.go", "d\n")
+	upstream.Commit("v1")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+	project.Commit("sync v1")
+
+	upstream.WriteFile("lib/a.go", "one\ntwo\nthree\nfour\n")
+	upstream.RemoveFile("lib/b.go")
+	upstream.WriteFile("lib/d.go", "d upstream\n")
+	upstream.WriteFile("lib/e.go", "e\n")
+	upstream.Commit("v2")
+
+	project.WriteFile("lib/a.go", "zero\none\ntwo\nthree\n")
+	project.WriteFile("lib/c.go", "c local\n")
+	project.WriteFile("lib/d.go", "d local\n")
+	return upstream, project, source
+}
+
+func TestPlan_ApplyRoundTrip(t *testing.T) {
+	upstream, project, source := newPlanFixture(t)
+	synced := source.Paths[0]
+
+	plan, err := fetchedFixture(t, source).Plan(project.Dir)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(plan.Paths) != 1 {
+		t.Fatalf("Expected one planned path, got %+v", plan.Paths)
+	}
+	pathPlan := plan.Paths[0]
+	if pathPlan.Commit != upstream.Head() || pathPlan.BaseCommit != synced.Commit {
+		t.Errorf("Expected the plan to go from %s to %s, got %s to %s", synced.Commit, upstream.Head(), pathPlan.BaseCommit, pathPlan.Commit)
+	}
+	var actions []string
+	for _, file := range pathPlan.Files {
+		actions = append(actions, string(file.Action)+" "+filepath.ToSlash(file.Path))
+	}
+	expected := []string{"merge lib/a.go", "delete lib/b.go", "add lib/e.go"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("Expected actions %v, got %v", expected, actions)
+	}
+	if len(pathPlan.Conflicts) != 1 || filepath.ToSlash(pathPlan.Conflicts[0].Path) != "lib/d.go" {
+		t.Errorf("Expected d.go to conflict, got %v", pathPlan.Conflicts)
+	}
+
+	// Planning changes nothing
+	if got := project.ReadFile("lib/a.go"); got != "zero\none\ntwo\nthree\n" || !project.Exists("lib/b.go") || project.Exists("lib/e.go") {
+		t.Fatal("Expected planning to leave the project alone")
+	}
+
+	// The plan survives its file format
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Failed to encode the plan: %v", err)
+	}
+	var decoded SourcePlan
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode the plan: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, plan) {
+		t.Fatalf("Expected the plan to round-trip, got %+v", decoded)
+	}
+
+	repo := fetchedFixture(t, source)
+	if err := repo.CheckPlan(decoded, project.Dir); err != nil {
+		t.Fatalf("Expected a fresh plan to apply, got %v", err)
+	}
+	result, err := repo.ApplyPlan(decoded, project.Dir)
+	if err != nil {
+		t.Fatalf("ApplyPlan failed: %v", err)
+	}
+	if len(result.FileActions) != 3 || len(result.Failed) > 0 {
+		t.Errorf("Expected the three planned actions, got %+v (failed %v)", result.FileActions, result.Failed)
+	}
+
+	for path, want := range map[string]string{
+		"lib/a.go": "zero\none\ntwo\nthree\nfour\n",
+		"lib/c.go": "c local\n",
+		"lib/d.go": "d local\n",
+		"lib/e.go": "e\n",
+	} {
+		if got := project.ReadFile(path); got != want {
+			t.Errorf("Expected %s to hold %q, got %q", path, want, got)
+		}
+	}
+	if project.Exists("lib/b.go") {
+		t.Error("Expected b.go to be deleted")
+	}
+
+	hasher := hash.NewFileHasherFor(config.SyncOptions{})
+	files := source.Paths[0].Files
+	if _, tracked := files["b.go"]; tracked {
+		t.Errorf("Expected b.go to be untracked, got %v", files)
+	}
+	if files["a.go"] != hasher.HashBytes([]byte("zero\none\ntwo\nthree\nfour\n")) || files["e.go"] != hasher.HashBytes([]byte("e\n")) {
+		t.Errorf("Expected tracking of the written files, got %v", files)
+	}
+	if files["d.go"] != synced.Files["d.go"] {
+		t.Errorf("Expected the conflicting file to keep its tracking, got %v", files)
+	}
+	if source.Paths[0].Commit != synced.Commit {
+		t.Errorf("Expected a path with conflicts to keep its commit, got %s", source.Paths[0].Commit)
+	}
+}
+
+func TestCheckPlan_Stale(t *testing.T) {
+	testCases := []struct {
+		name     string
+		change   func(upstream *testutil.FixtureRepo, project *testutil.Project, source *config.Source)
+		expected string
+	}{
+		{
+			name: "upstream moved",
+			change: func(upstream *testutil.FixtureRepo, project *testutil.Project, source *config.Source) {
+				upstream.WriteFile("lib/f.go", "f\n")
+				upstream.Commit("v3")
+			},
+			expected: "moved to",
+		},
+		{
+			name: "planned file changed",
+			change: func(upstream *testutil.FixtureRepo, project *testutil.Project, source *config.Source) {
+				project.WriteFile("lib/a.go", "edited after planning\n")
+			},
+			expected: "changed locally",
+		},
+		{
+			name: "planned file added locally",
+			change: func(upstream *testutil.FixtureRepo, project *testutil.Project, source *config.Source) {
+				project.WriteFile("lib/e.go", "mine\n")
+			},
+			expected: "changed locally",
+		},
+		{
+			name: "path synced since",
+			change: func(upstream *testutil.FixtureRepo, project *testutil.Project, source *config.Source) {
+				source.Paths[0].Commit = upstream.Head()
+			},
+			expected: "synced since",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			upstream, project, source := newPlanFixture(t)
+			plan, err := fetchedFixture(t, source).Plan(project.Dir)
+			if err != nil {
+				t.Fatalf("Plan failed: %v", err)
+			}
+
+			tc.change(upstream, project, source)
+			err = fetchedFixture(t, source).CheckPlan(plan, project.Dir)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected the plan to be refused with %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
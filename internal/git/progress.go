@@ -0,0 +1,117 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/format"
+	"cherry-go/internal/logger"
+)
+
+// progressInterval is how often a long walk over a tracked directory says how far it got.
+// Walks that finish sooner print nothing.
+const progressInterval = 5 * time.Second
+
+// SyncMetrics counts the file work a sync did, for the summary at the end of a run
+type SyncMetrics struct {
+	FilesCompared int   // Upstream files compared with their local copy
+	FilesCopied   int   // Files written locally
+	BytesCopied   int64 // Bytes written locally
+	FilesHashed   int   // Upstream files hashed for tracking
+}
+
+// Add sums the metrics of another sync into m
+func (m *SyncMetrics) Add(other SyncMetrics) {
+	m.FilesCompared += other.FilesCompared
+	m.FilesCopied += other.FilesCopied
+	m.BytesCopied += other.BytesCopied
+	m.FilesHashed += other.FilesHashed
+}
+
+// progress counts the files of one walk ("hashing src/") into a SyncMetrics counter and
+// logs a rate-limited progress line while the walk is long-running
+type progress struct {
+	label   string
+	files   int
+	bytes   int64
+	counter *int   // SyncMetrics field the files are added to
+	byteSum *int64 // SyncMetrics field the bytes are added to, nil when sizes aren't reported
+
+	expected int        // Files the walk will visit once counted, 0 if unknown
+	count    func() int // Counts the files to expect, only when the first report is due
+
+	interval time.Duration
+	now      func() time.Time
+	last     time.Time
+	report   func(format string, v ...interface{})
+}
+
+// newProgress starts counting a walk labelled like "hashing src/"
+func newProgress(label string, counter *int, byteSum *int64, count func() int) *progress {
+	return &progress{
+		label:    label,
+		counter:  counter,
+		byteSum:  byteSum,
+		count:    count,
+		interval: progressInterval,
+		now:      time.Now,
+		last:     time.Now(),
+		report:   logger.Info,
+	}
+}
+
+// add records a file of size bytes, reporting progress if the interval has passed
+func (p *progress) add(bytes int64) {
+	if p == nil {
+		return
+	}
+	p.files++
+	p.bytes += bytes
+	*p.counter++
+	if p.byteSum != nil {
+		*p.byteSum += bytes
+	}
+
+	now := p.now()
+	if now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	if p.count != nil {
+		p.expected = p.count()
+		p.count = nil
+	}
+	p.report("⏳ %s", p)
+}
+
+// String describes the progress, e.g. "hashing src/: 12,400/30,000 files" or
+// "copying src/: 8,200 files, 410.0 MB"
+func (p *progress) String() string {
+	done := format.Count(p.files)
+	if p.expected > 0 {
+		done += "/" + format.Count(p.expected)
+	}
+	if p.byteSum != nil {
+		return fmt.Sprintf("%s: %s files, %s", p.label, done, format.Bytes(p.bytes))
+	}
+	return fmt.Sprintf("%s: %s files", p.label, done)
+}
+
+// copyProgress starts counting the files copied for a path into the sync metrics
+func (r *Repository) copyProgress(input processPathInput) *progress {
+	return newProgress("copying "+input.pathSpec.Include, &r.metrics.FilesCopied, &r.metrics.BytesCopied, nil)
+}
+
+// countSourceFiles returns a count function for the non-excluded files below root
+func countSourceFiles(root string, excludes config.Excludes) func() int {
+	return func() int {
+		n := 0
+		_ = walkSourceFiles(root, excludes, func(string, string, os.FileInfo) error {
+			n++
+			return nil
+		})
+		return n
+	}
+}
@@ -0,0 +1,109 @@
+package git
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// fakeProgress returns a progress whose clock only moves when the test advances it,
+// collecting the lines it reports
+func fakeProgress(label string, counter *int, byteSum *int64, count func() int) (*progress, *time.Time, *[]string) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var reports []string
+	p := newProgress(label, counter, byteSum, count)
+	p.now = func() time.Time { return now }
+	p.last = now
+	p.report = func(format string, v ...interface{}) { reports = append(reports, fmt.Sprintf(format, v...)) }
+	return p, &now, &reports
+}
+
+func TestProgress_RateLimited(t *testing.T) {
+	var hashed int
+	counted := 0
+	p, now, reports := fakeProgress("hashing src/", &hashed, nil, func() int { counted++; return 30000 })
+
+	// A walk that finishes within the interval says nothing and never counts its files
+	for i := 0; i < 1000; i++ {
+		p.add(0)
+	}
+	if len(*reports) != 0 || counted != 0 {
+		t.Fatalf("Expected no report before the interval, got %v (counted %d times)", *reports, counted)
+	}
+
+	*now = now.Add(progressInterval)
+	p.add(0)
+	for i := 0; i < 500; i++ {
+		p.add(0)
+	}
+	*now = now.Add(progressInterval - time.Millisecond)
+	p.add(0)
+	*now = now.Add(time.Millisecond)
+	p.add(0)
+
+	expected := []string{"⏳ hashing src/: 1,001/30,000 files", "⏳ hashing src/: 1,503/30,000 files"}
+	if fmt.Sprint(*reports) != fmt.Sprint(expected) {
+		t.Errorf("Expected one report per interval %v, got %v", expected, *reports)
+	}
+	if counted != 1 {
+		t.Errorf("Expected the files to be counted once, got %d", counted)
+	}
+	if hashed != 1503 {
+		t.Errorf("Expected every file added to the metrics counter, got %d", hashed)
+	}
+}
+
+func TestProgress_CopyReportsSize(t *testing.T) {
+	var metrics SyncMetrics
+	p, now, reports := fakeProgress("copying src/", &metrics.FilesCopied, &metrics.BytesCopied, nil)
+	for i := 0; i < 8200; i++ {
+		p.add(50 * 1024)
+	}
+	*now = now.Add(progressInterval)
+	p.add(0)
+
+	if len(*reports) != 1 || (*reports)[0] != "⏳ copying src/: 8,201 files, 400.4 MB" {
+		t.Errorf("Expected a copy report with the size so far, got %v", *reports)
+	}
+	if metrics.FilesCopied != 8201 || metrics.BytesCopied != 8200*50*1024 {
+		t.Errorf("Expected the copies in the metrics, got %+v", metrics)
+	}
+
+	// Nil progress (a copy nobody counts) is a no-op
+	var none *progress
+	none.add(10)
+}
+
+func TestCopyPaths_Metrics(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "aaaa\n")
+	upstream.WriteFile("lib/b.go", "bb\n")
+	upstream.WriteFile("lib/skip.tmp", "excluded\n")
+	upstream.WriteFile("README.md", "readme\n")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{
+		{Include: "lib/", Exclude: []string{"*.tmp"}},
+		{Include: "README.md"},
+	}}
+	// The comparison of lib/ stops at its first missing file
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	expected := SyncMetrics{FilesCompared: 2, FilesCopied: 3, BytesCopied: 5 + 3 + 7, FilesHashed: 3}
+	if result.Metrics != expected {
+		t.Errorf("Expected metrics %+v, got %+v", expected, result.Metrics)
+	}
+
+	// Metrics are per run: an up-to-date sync compares and hashes, but copies nothing
+	result = syncFixture(t, source, SyncModeForce, project.Dir)
+	expected = SyncMetrics{FilesCompared: 3, FilesHashed: 3}
+	if result.Metrics != expected {
+		t.Errorf("Expected metrics %+v on the second sync, got %+v", expected, result.Metrics)
+	}
+}
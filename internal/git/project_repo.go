@@ -0,0 +1,105 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// openProjectRepository opens the git repository containing workDir, which may be a
+// subdirectory of it (a service inside a monorepo), and returns it with its worktree root
+func openProjectRepository(workDir string) (*git.Repository, string, error) {
+	absDir, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := git.PlainOpenWithOptions(absDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("%s is not inside a git repository: %w", absDir, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get worktree: %w", err)
+	}
+	return repo, worktree.Filesystem.Root(), nil
+}
+
+// repoRelativePath converts a path relative to workDir (or absolute) into the
+// slash-separated path git uses for it, relative to the repository root
+func repoRelativePath(root, workDir, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		absDir, err := filepath.Abs(workDir)
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(absDir, path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || len(rel) > 3 && rel[:3] == ".."+string(filepath.Separator) {
+		return "", fmt.Errorf("%s is outside the repository at %s", path, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// hasStagedChanges reports whether the index differs from the HEAD commit, i.e. whether a
+// commit would record anything. It compares entry names, hashes and modes, without
+// reading file contents.
+func hasStagedChanges(repo *git.Repository) (bool, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return false, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return len(idx.Entries) > 0, nil // No commit yet
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, fmt.Errorf("failed to read HEAD tree: %w", err)
+	}
+
+	staged := make(map[string]*index.Entry, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		staged[entry.Name] = entry
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	committed := 0
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to walk HEAD tree: %w", err)
+		}
+		if entry.Mode == filemode.Dir {
+			continue
+		}
+		committed++
+		indexed, ok := staged[name]
+		if !ok || indexed.Hash != entry.Hash || indexed.Mode != entry.Mode {
+			return true, nil
+		}
+	}
+	return committed != len(staged), nil
+}
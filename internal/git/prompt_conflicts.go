@@ -0,0 +1,76 @@
+package git
+
+import (
+	"path/filepath"
+	"sync"
+
+	"cherry-go/internal/interactive"
+	"cherry-go/internal/logger"
+)
+
+// ConflictPrompt asks how to settle a file that couldn't be merged, like interactive.ResolveConflict
+type ConflictPrompt func(fileName string, local, remote, merged []byte) interactive.ConflictChoice
+
+// conflictPrompt asks about each file --merge can't merge instead of aborting, when set
+var conflictPrompt ConflictPrompt
+
+// SetConflictPrompt sets how merge mode asks, file by file, to settle conflicts; nil
+// (the default) never asks. Only set it when interactive.ShouldPrompt() allows questions.
+func SetConflictPrompt(prompt ConflictPrompt) {
+	conflictPrompt = prompt
+}
+
+// conflictPromptMu keeps the questions of paths and sources synced concurrently apart
+var conflictPromptMu sync.Mutex
+
+// conflictingFile is a file changed on both sides that couldn't be merged
+type conflictingFile struct {
+	key        string // Its name in the path's tracking
+	localPath  string
+	remotePath string
+	local      []byte
+	remote     []byte
+	merged     []byte // The merge with conflict markers
+}
+
+// settleConflict asks how to settle a conflicting file when merge mode prompts, and
+// applies the answer. It reports whether the file no longer conflicts, in which case its
+// tracked hash is set in hashes. Written markers still leave the file conflicting, so the
+// sync stops before anything is committed.
+func (r *Repository) settleConflict(input processPathInput, file conflictingFile, hashes map[string]string) bool {
+	if conflictPrompt == nil || input.mode != SyncModeMerge || logger.IsDryRun() {
+		return false
+	}
+
+	conflictPromptMu.Lock()
+	choice := conflictPrompt(filepath.ToSlash(r.projectPath(file.localPath)), file.local, file.remote, file.merged)
+	conflictPromptMu.Unlock()
+
+	switch choice {
+	case interactive.ConflictKeepLocal:
+		logger.Info("  ✓ Kept local %s", file.key)
+		hashes[file.key] = input.hasher.HashBytes(file.local)
+		return true
+
+	case interactive.ConflictTakeRemote:
+		if err := r.writeLocalFile(file.localPath, file.remote, r.sourcePerm(file.remotePath)); err != nil {
+			if !isProtectedPathError(err) {
+				r.recordFailure(input, file.remotePath, err)
+			}
+			return true
+		}
+		logger.Info("  ✓ Took remote %s", file.key)
+		hashes[file.key] = input.hasher.HashBytes(file.remote)
+		return true
+
+	case interactive.ConflictWriteMarkers:
+		if err := r.writeLocalFile(file.localPath, file.merged, r.sourcePerm(file.remotePath)); err != nil {
+			if !isProtectedPathError(err) {
+				r.recordFailure(input, file.remotePath, err)
+			}
+			return false
+		}
+		logger.Warning("  ⚠️  Conflict markers written to %s - resolve them and sync again", r.projectPath(file.localPath))
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// ProtectedPathError reports a write refused by an options.protected_paths rule
+type ProtectedPathError struct {
+	Path string // Local path the sync tried to write
+	Rule string // protected_paths entry that matched
+}
+
+func (e *ProtectedPathError) Error() string {
+	return fmt.Sprintf("refusing to write %s: protected by rule '%s'", e.Path, e.Rule)
+}
+
+// isProtectedPathError reports whether err is a refused protected write
+func isProtectedPathError(err error) bool {
+	var protected *ProtectedPathError
+	return errors.As(err, &protected)
+}
+
+// writeCheck vets a local path before it is written; nil allows every write
+type writeCheck func(localPath string) error
+
+// SetOverrideProtected allows writes to protected paths for this run
+func (r *Repository) SetOverrideProtected(override bool) {
+	r.overrideProtected = override
+}
+
+// checkWrite refuses writes to local paths matched by options.protected_paths.
+// Refusals are logged and collected so the sync can report them.
+func (r *Repository) checkWrite(localPath string) error {
+	localPath = r.projectPath(localPath)
+	rule, protected := r.options.ProtectedRule(localPath)
+	if !protected {
+		return nil
+	}
+
+	if r.overrideProtected {
+		logger.Warning("Writing protected path %s (rule '%s' overridden)", localPath, rule)
+		return nil
+	}
+
+	err := &ProtectedPathError{Path: localPath, Rule: rule}
+	logger.Error("🔒 %v", err)
+	r.refused = append(r.refused, err)
+	return err
+}
+
+// writeLocalFile writes content to a local path after the protection check, with the
+// line endings options.eol gives it. A new file is created with perm; an existing one
+// keeps its permissions until applyFileModes sets them.
+func (r *Repository) writeLocalFile(localPath string, content []byte, perm os.FileMode) error {
+	if err := r.checkWrite(localPath); err != nil {
+		return err
+	}
+	if logger.IsDryRun() {
+		return nil
+	}
+	content = r.withLineEnding(localPath, content)
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+	if _, isLink, _ := hash.ReadLink(localPath); isLink {
+		// Replace the link rather than writing into what it points to
+		if err := os.Remove(localPath); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(localPath, content, perm)
+}
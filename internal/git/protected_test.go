@@ -0,0 +1,287 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// protectedFixture is a project synced once from an upstream with a file and a directory
+type protectedFixture struct {
+	upstream *testutil.FixtureRepo
+	project  *testutil.Project
+	source   *config.Source
+}
+
+func newProtectedFixture(t *testing.T) *protectedFixture {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "protected")
+	upstream.WriteFile("LICENSE", "license v1\n")
+	upstream.WriteFile("docs/guide.md", "guide v1\n")
+	upstream.WriteFile("docs/api.md", "api v1\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	f := &protectedFixture{
+		upstream: upstream,
+		project:  project,
+		source: &config.Source{
+			Name:       "protected",
+			Repository: upstream.URL(),
+			Paths:      []config.PathSpec{{Include: "LICENSE"}, {Include: "docs/"}},
+		},
+	}
+
+	f.sync(t, SyncModeMerge, nil, false)
+	project.Commit("initial sync")
+	return f
+}
+
+// updateUpstream rewrites every upstream file with a v2 version
+func (f *protectedFixture) updateUpstream() {
+	f.upstream.WriteFile("LICENSE", "license v2\n")
+	f.upstream.WriteFile("docs/guide.md", "guide v2\n")
+	f.upstream.WriteFile("docs/api.md", "api v2\n")
+	f.upstream.Commit("v2")
+}
+
+func (f *protectedFixture) sync(t *testing.T, mode SyncMode, protected []string, override bool) *CopyResult {
+	t.Helper()
+
+	repo, err := NewRepository(f.source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	repo.SetSyncOptions(config.SyncOptions{ProtectedPaths: protected})
+	repo.SetOverrideProtected(override)
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+
+	result, err := repo.CopyPaths(mode, f.project.Dir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return result
+}
+
+// refusedRules maps each refused path to the rule that refused it
+func refusedRules(result *CopyResult) map[string]string {
+	rules := make(map[string]string)
+	for _, refused := range result.Refused {
+		rules[refused.Path] = refused.Rule
+	}
+	return rules
+}
+
+func TestProtectedPathError(t *testing.T) {
+	err := &ProtectedPathError{Path: "docs/api.md", Rule: "docs/**"}
+	if got := err.Error(); got != "refusing to write docs/api.md: protected by rule 'docs/**'" {
+		t.Errorf("Unexpected error message: %q", got)
+	}
+	if !isProtectedPathError(err) || isProtectedPathError(os.ErrNotExist) {
+		t.Error("isProtectedPathError misidentified an error")
+	}
+}
+
+func TestCopyDir_SkipsRefusedFiles(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "sub/c.txt"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var checked []string
+	check := func(localPath string) error {
+		checked = append(checked, localPath)
+		if filepath.Base(localPath) == "b.txt" {
+			return &ProtectedPathError{Path: localPath, Rule: "b.txt"}
+		}
+		return nil
+	}
+
+	if err := copyDir(src, dst, config.Excludes{}, false, false, check, nil, nil); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+	if len(checked) != 3 {
+		t.Errorf("Expected every file to be checked, got %v", checked)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "b.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the refused file not to be written")
+	}
+	for _, name := range []string{"a.txt", "sub/c.txt"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+		}
+	}
+
+	// A refused single file is reported to the caller
+	err := copyPath(filepath.Join(src, "b.txt"), filepath.Join(dst, "b.txt"), config.Excludes{}, false, false, check, nil, nil)
+	if !isProtectedPathError(err) {
+		t.Errorf("Expected a protected path error for a single file, got %v", err)
+	}
+}
+
+func TestCopyPaths_ProtectedWritesRefused(t *testing.T) {
+	for _, mode := range []struct {
+		name string
+		mode SyncMode
+	}{
+		{"force", SyncModeForce},
+		{"merge", SyncModeMerge},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			f := newProtectedFixture(t)
+			f.updateUpstream()
+			licenseHashes := f.source.Paths[0].Files
+
+			result := f.sync(t, mode.mode, []string{"LICENSE", "docs/api.md"}, false)
+
+			rules := refusedRules(result)
+			if len(rules) != 2 || rules["LICENSE"] != "LICENSE" || rules[filepath.Join("docs", "api.md")] != "docs/api.md" {
+				t.Fatalf("Expected LICENSE and docs/api.md to be refused by their rules, got %v", rules)
+			}
+
+			if got := f.project.ReadFile("LICENSE"); got != "license v1\n" {
+				t.Errorf("Expected protected LICENSE to stay untouched, got %q", got)
+			}
+			if got := f.project.ReadFile("docs/api.md"); got != "api v1\n" {
+				t.Errorf("Expected protected docs/api.md to stay untouched, got %q", got)
+			}
+			if got := f.project.ReadFile("docs/guide.md"); got != "guide v2\n" {
+				t.Errorf("Expected unprotected docs/guide.md to be updated, got %q", got)
+			}
+
+			// Partially synced paths keep their previous tracking state
+			if len(result.UpdatedPaths) != 0 {
+				t.Errorf("Expected no path to count as synced, got %v", result.UpdatedPaths)
+			}
+			if f.source.Paths[0].Files["LICENSE"] != licenseHashes["LICENSE"] {
+				t.Error("Expected the protected file's hash to be left alone")
+			}
+		})
+	}
+}
+
+func TestCopyPaths_ProtectedNewFileNotCreated(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.upstream.WriteFile("docs/internal/notes.md", "notes\n")
+	f.upstream.Commit("add notes")
+
+	result := f.sync(t, SyncModeMerge, []string{"docs/internal/"}, false)
+
+	if rules := refusedRules(result); rules[filepath.Join("docs", "internal", "notes.md")] != "docs/internal/" {
+		t.Errorf("Expected the new file to be refused by its directory rule, got %v", rules)
+	}
+	if f.project.Exists("docs/internal/notes.md") {
+		t.Error("Expected the protected file not to be created")
+	}
+	if _, tracked := f.source.Paths[1].Files[filepath.Join("internal", "notes.md")]; tracked {
+		t.Error("Expected the refused file not to be tracked")
+	}
+}
+
+func TestCopyPaths_OverrideProtected(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.updateUpstream()
+
+	result := f.sync(t, SyncModeForce, []string{"LICENSE", "docs/**"}, true)
+
+	if len(result.Refused) != 0 {
+		t.Errorf("Expected no refusals with the override, got %v", refusedRules(result))
+	}
+	if got := f.project.ReadFile("LICENSE"); got != "license v2\n" {
+		t.Errorf("Expected LICENSE to be overwritten, got %q", got)
+	}
+	if got := f.project.ReadFile("docs/api.md"); got != "api v2\n" {
+		t.Errorf("Expected docs/api.md to be overwritten, got %q", got)
+	}
+	if len(result.UpdatedPaths) != 2 {
+		t.Errorf("Expected both paths to be synced, got %v", result.UpdatedPaths)
+	}
+}
+
+func TestCopyPaths_DetectReportsProtectedDifferences(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.project.WriteFile("LICENSE", "license local\n")
+	f.updateUpstream()
+
+	result := f.sync(t, SyncModeDetect, []string{"LICENSE"}, false)
+
+	if len(result.Conflicts) == 0 {
+		t.Error("Expected detect mode to report differences in the protected file")
+	}
+	if len(result.Refused) != 0 {
+		t.Errorf("Expected detect mode not to attempt protected writes, got %v", refusedRules(result))
+	}
+	if got := f.project.ReadFile("LICENSE"); got != "license local\n" {
+		t.Errorf("Expected LICENSE to be untouched, got %q", got)
+	}
+}
+
+func TestCopyPaths_ProtectedConflictMarkers(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.project.WriteFile("LICENSE", "license local\n")
+	f.updateUpstream()
+
+	result := f.sync(t, SyncModeMarkConflicts, []string{"LICENSE"}, false)
+
+	if rules := refusedRules(result); rules["LICENSE"] != "LICENSE" {
+		t.Errorf("Expected conflict markers to be refused for LICENSE, got %v", rules)
+	}
+	if got := f.project.ReadFile("LICENSE"); strings.Contains(got, "<<<<<<<") {
+		t.Errorf("Expected no conflict markers in the protected file, got %q", got)
+	}
+}
+
+func TestCopyPaths_ProtectedFilesLeftOutOfConflictBranch(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.project.WriteFile("LICENSE", "license local\n")
+	f.project.Commit("local license")
+	f.updateUpstream()
+
+	result := f.sync(t, SyncModeBranch, []string{"LICENSE"}, false)
+
+	if len(result.Conflicts) == 0 {
+		t.Fatal("Expected LICENSE to conflict")
+	}
+	if rules := refusedRules(result); rules["LICENSE"] != "LICENSE" {
+		t.Errorf("Expected the branch write to be refused for LICENSE, got %v", rules)
+	}
+	if result.BranchCreated != "" {
+		t.Errorf("Expected no conflict branch when every conflicting file is protected, got %s", result.BranchCreated)
+	}
+}
+
+func TestFollowRenames_ProtectedSourceNotMoved(t *testing.T) {
+	f := newProtectedFixture(t)
+	f.upstream.RemoveFile("docs/api.md")
+	f.upstream.WriteFile("docs/reference/api.md", "api v1\n")
+	f.upstream.Commit("move api docs")
+
+	result := f.sync(t, SyncModeMerge, []string{"docs/api.md"}, false)
+
+	if len(renameActions(result)) != 0 {
+		t.Errorf("Expected the rename not to be followed, got %+v", renameActions(result))
+	}
+	if rules := refusedRules(result); rules[filepath.Join("docs", "api.md")] != "docs/api.md" {
+		t.Errorf("Expected the move away from docs/api.md to be refused, got %v", rules)
+	}
+	if got := f.project.ReadFile("docs/api.md"); got != "api v1\n" {
+		t.Errorf("Expected protected docs/api.md to stay in place, got %q", got)
+	}
+}
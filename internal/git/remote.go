@@ -0,0 +1,100 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// strictRemotes makes a cached clone that fetches from somewhere other than its
+// configured repository an error instead of being corrected
+var strictRemotes bool
+
+// SetStrictRemotes sets whether mismatched cache remotes fail (--strict-remotes)
+func SetStrictRemotes(strict bool) {
+	strictRemotes = strict
+}
+
+// checkOriginURL makes sure a cached clone's origin is the source's configured repository
+// URL, so fetches never come from elsewhere after a hand edit of the cache. A mismatched
+// origin is pointed back at the configured URL, or is an error with strict remotes.
+func checkOriginURL(repo *git.Repository, source *config.Source, repoPath string) error {
+	current := "no origin remote"
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	switch {
+	case err == nil:
+		urls := remote.Config().URLs
+		if len(urls) == 1 && urls[0] == source.Repository {
+			return nil
+		}
+		current = strings.Join(urls, ", ")
+	case !errors.Is(err, git.ErrRemoteNotFound):
+		return fmt.Errorf("failed to read origin of cached repository %s: %w", repoPath, err)
+	}
+
+	if strictRemotes {
+		return fmt.Errorf("cached repository %s fetches from %s, not the configured %s (--strict-remotes); fix its origin or run cherry-go cache clean",
+			repoPath, current, source.Repository)
+	}
+	if logger.IsDryRun() {
+		logger.DryRunInfo("Would reset origin of the cached repository for %s from %s to %s", source.Name, current, source.Repository)
+		return nil
+	}
+
+	if err := setOriginURL(repo, source.Repository, repoPath); err != nil {
+		return err
+	}
+
+	logger.Warning("⚠️  Cached repository for %s fetched from %s; reset its origin to the configured %s", source.Name, current, source.Repository)
+	return nil
+}
+
+// setOriginURL points a cached clone's origin at repoURL, adding the remote if it is missing
+func setOriginURL(repo *git.Repository, repoURL, repoPath string) error {
+	repoConfig, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read config of cached repository %s: %w", repoPath, err)
+	}
+	origin, ok := repoConfig.Remotes[git.DefaultRemoteName]
+	if !ok {
+		origin = &gitconfig.RemoteConfig{
+			Name:  git.DefaultRemoteName,
+			Fetch: []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf(gitconfig.DefaultFetchRefSpec, git.DefaultRemoteName))},
+		}
+		repoConfig.Remotes[git.DefaultRemoteName] = origin
+	}
+	origin.URLs = []string{repoURL}
+	if err := repo.SetConfig(repoConfig); err != nil {
+		return fmt.Errorf("failed to update origin of cached repository %s: %w", repoPath, err)
+	}
+	return nil
+}
+
+// MoveCache moves the cached clone of oldURL to the source's repository URL and points
+// its origin there, for a source whose repository moved: the clone keeps the history
+// and the commits the source was synced from. It reports whether there was a clone to
+// move; a clone of the new URL that already exists is kept instead.
+func MoveCache(oldURL string, source *config.Source) (bool, error) {
+	cacheManager, err := cache.NewManager()
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize cache manager: %w", err)
+	}
+	moved, err := cacheManager.MoveRepository(oldURL, source.Repository)
+	if err != nil || !moved {
+		return false, err
+	}
+
+	repoPath := cacheManager.GetRepositoryPath(source.Repository)
+	repo, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return true, fmt.Errorf("failed to open cached repository: %w", err)
+	}
+	return true, setOriginURL(repo, source.Repository, repoPath)
+}
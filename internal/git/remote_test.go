@@ -0,0 +1,84 @@
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// setCachedOrigin points the source's cached clone at another URL, as a hand edit would
+func setCachedOrigin(t *testing.T, source *config.Source, url string) {
+	t.Helper()
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	repoConfig, err := repo.repo.Config()
+	if err != nil {
+		t.Fatalf("Failed to read cache config: %v", err)
+	}
+	repoConfig.Remotes[git.DefaultRemoteName].URLs = []string{url}
+	if err := repo.repo.SetConfig(repoConfig); err != nil {
+		t.Fatalf("Failed to write cache config: %v", err)
+	}
+}
+
+// cachedOrigin returns the origin URLs of the source's cached clone
+func cachedOrigin(t *testing.T, repo *Repository) []string {
+	t.Helper()
+	remote, err := repo.repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		t.Fatalf("Failed to read origin: %v", err)
+	}
+	return remote.Config().URLs
+}
+
+func TestNewRepository_CorrectsCachedOrigin(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // configured\n")
+	upstream.Commit("initial")
+	impostor := testutil.NewFixtureRepo(t, "impostor")
+	impostor.WriteFile("lib/a.go", "package lib // impostor\n")
+	impostor.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "lib/"}}}
+	setCachedOrigin(t, source, impostor.URL())
+
+	// Strict remotes refuse to use the clone
+	SetStrictRemotes(true)
+	t.Cleanup(func() { SetStrictRemotes(false) })
+	_, err := NewRepository(source)
+	if err == nil || !strings.Contains(err.Error(), impostor.URL()) || !strings.Contains(err.Error(), "--strict-remotes") {
+		t.Fatalf("Expected a strict-remotes error naming the cached origin, got %v", err)
+	}
+
+	// By default the origin is pointed back at the configured URL before fetching
+	SetStrictRemotes(false)
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if urls := cachedOrigin(t, repo); len(urls) != 1 || urls[0] != upstream.URL() {
+		t.Fatalf("Expected origin to be reset to %s, got %v", upstream.URL(), urls)
+	}
+
+	upstream.WriteFile("lib/a.go", "package lib // configured v2\n")
+	upstream.Commit("update")
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if _, err := repo.CopyPaths(SyncModeForce, project.Dir); err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	if got := project.ReadFile("lib/a.go"); got != "package lib // configured v2\n" {
+		t.Errorf("Expected content from the configured repository, got %q", got)
+	}
+}
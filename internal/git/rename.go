@@ -0,0 +1,238 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// FileActionType describes what a sync did to a single file
+type FileActionType string
+
+const (
+	FileActionAdded   FileActionType = "added"   // New local file
+	FileActionUpdated FileActionType = "updated" // Existing local file rewritten
+	FileActionRenamed FileActionType = "renamed" // Moved upstream and followed locally
+	FileActionDeleted FileActionType = "deleted" // Removed upstream and deleted locally
+)
+
+// FileAction records a per-file change made (or planned, in dry-run) by a sync.
+// Paths are local paths relative to the working directory.
+type FileAction struct {
+	Type       FileActionType
+	Path       string  // Current path (the new name for renames)
+	OldPath    string  // Previous path for renames
+	Similarity float64 // Content similarity for renames, 1.0 for exact moves
+
+	// Change statistics between the old local content and the newly written one
+	Added    int   // Lines added (text files)
+	Removed  int   // Lines removed (text files)
+	Binary   bool  // Binary content - only sizes are compared
+	TooLarge bool  // Over the statistics size cap - only sizes are compared
+	OldSize  int64 // Size before the sync (0 for new files)
+	NewSize  int64 // Size after the sync
+}
+
+// detectRenames pairs files that disappeared upstream with files that appeared upstream.
+// tracked holds the last-synced hashes and upstream the current ones. Files with the same
+// hash are exact renames; otherwise the most similar pair at or above threshold wins.
+// oldContent and newContent load file contents for the similarity comparison.
+func detectRenames(tracked, upstream map[string]string, threshold float64,
+	oldContent, newContent func(relPath string) ([]byte, error)) []FileAction {
+	_, added, removed := hash.NewFileHasher().CompareHashes(tracked, upstream)
+	if len(added) == 0 || len(removed) == 0 {
+		return nil
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	var renames []FileAction
+	claimed := make(map[string]bool)
+	var unmatched []string
+
+	// Exact renames: identical content under a new name
+	for _, oldPath := range removed {
+		matched := false
+		for _, newPath := range added {
+			if !claimed[newPath] && upstream[newPath] == tracked[oldPath] {
+				renames = append(renames, FileAction{Type: FileActionRenamed, Path: newPath, OldPath: oldPath, Similarity: 1})
+				claimed[newPath] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, oldPath)
+		}
+	}
+
+	// Fuzzy renames: moved and modified in the same upstream change
+	for _, oldPath := range unmatched {
+		old, err := oldContent(oldPath)
+		if err != nil {
+			logger.Debug("Skipping rename detection for %s: %v", oldPath, err)
+			continue
+		}
+
+		bestPath, bestScore := "", 0.0
+		for _, newPath := range added {
+			if claimed[newPath] {
+				continue
+			}
+			current, err := newContent(newPath)
+			if err != nil {
+				continue
+			}
+			if score := contentSimilarity(old, current); score > bestScore {
+				bestPath, bestScore = newPath, score
+			}
+		}
+
+		if bestPath != "" && bestScore >= threshold {
+			renames = append(renames, FileAction{Type: FileActionRenamed, Path: bestPath, OldPath: oldPath, Similarity: bestScore})
+			claimed[bestPath] = true
+		}
+	}
+
+	return renames
+}
+
+// contentSimilarity scores two contents from 0 to 1 by the share of lines they have in common
+func contentSimilarity(a, b []byte) float64 {
+	if bytes.Equal(a, b) {
+		return 1
+	}
+
+	linesA := splitLines(a)
+	linesB := splitLines(b)
+	total := len(linesA) + len(linesB)
+	if total == 0 {
+		return 1
+	}
+
+	counts := make(map[string]int, len(linesA))
+	for _, line := range linesA {
+		counts[line]++
+	}
+
+	common := 0
+	for _, line := range linesB {
+		if counts[line] > 0 {
+			counts[line]--
+			common++
+		}
+	}
+
+	return float64(2*common) / float64(total)
+}
+
+// splitLines splits content into lines, keeping line endings so trailing newline changes count
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// followRenames detects upstream renames inside a tracked directory and moves the
+// local copies (and their base snapshots) to the new names before the directory is synced.
+// The returned actions carry local paths.
+func (r *Repository) followRenames(pathSpec config.PathSpec, sourcePath, localPath string, hasher *hash.FileHasher) []FileAction {
+	if !r.options.RenameDetectionEnabled() || len(pathSpec.Files) == 0 {
+		return nil
+	}
+
+	upstream, err := hasher.HashDirectory(sourcePath, pathSpec.Excludes)
+	if err != nil {
+		logger.Debug("Skipping rename detection for %s: %v", pathSpec.Include, err)
+		return nil
+	}
+
+	baseManager := r.baseContentManager()
+
+	// Prefer the snapshot of what upstream looked like, since the local copy may carry edits
+	oldContent := func(relPath string) ([]byte, error) {
+		if baseManager != nil {
+			if content, err := baseManager.GetFileContent(r.source.Name, pathSpec.Include, relPath); err == nil && content != nil {
+				return content, nil
+			}
+		}
+		return os.ReadFile(filepath.Join(localPath, relPath))
+	}
+	newContent := func(relPath string) ([]byte, error) {
+		return os.ReadFile(filepath.Join(sourcePath, relPath))
+	}
+
+	renames := detectRenames(pathSpec.Files, upstream, r.options.RenameSimilarityThreshold(), oldContent, newContent)
+
+	var applied []FileAction
+	for _, rename := range renames {
+		oldLocal := filepath.Join(localPath, rename.OldPath)
+		newLocal := filepath.Join(localPath, rename.Path)
+
+		if _, err := os.Stat(oldLocal); err != nil {
+			logger.Debug("Not following rename %s → %s: local file missing", rename.OldPath, rename.Path)
+			continue
+		}
+		if _, err := os.Stat(newLocal); err == nil {
+			logger.Warning("Not following rename %s → %s: %s already exists locally", rename.OldPath, rename.Path, r.projectPath(newLocal))
+			continue
+		}
+
+		// A move rewrites both paths, so either side being protected blocks it
+		if r.checkWrite(oldLocal) != nil || r.checkWrite(newLocal) != nil {
+			continue
+		}
+
+		if logger.IsDryRun() {
+			logger.DryRunInfo("Would move %s to %s (upstream rename, %.0f%% similar)", r.projectPath(oldLocal), r.projectPath(newLocal), rename.Similarity*100)
+			applied = append(applied, localRename(rename, oldLocal, newLocal))
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(newLocal), 0755); err != nil {
+			logger.Error("Failed to create directory for %s: %v", r.projectPath(newLocal), err)
+			continue
+		}
+		if err := os.Rename(oldLocal, newLocal); err != nil {
+			logger.Error("Failed to move %s to %s: %v", r.projectPath(oldLocal), r.projectPath(newLocal), err)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(oldLocal), localPath)
+
+		if baseManager != nil && !r.freezeTracking {
+			if err := baseManager.RenameSnapshotFile(r.source.Name, pathSpec.Include, rename.OldPath, rename.Path); err != nil {
+				logger.Warning("Failed to carry over base snapshot for %s: %v", rename.Path, err)
+			}
+		}
+
+		logger.Info("↪ Renamed %s → %s (%.0f%% similar)", rename.OldPath, rename.Path, rename.Similarity*100)
+		applied = append(applied, localRename(rename, oldLocal, newLocal))
+	}
+
+	return applied
+}
+
+// localRename rewrites a rename detected on spec-relative paths to local paths
+func localRename(rename FileAction, oldLocal, newLocal string) FileAction {
+	rename.OldPath = oldLocal
+	rename.Path = newLocal
+	return rename
+}
+
+// removeEmptyParents deletes directories left empty by a move, stopping at root
+func removeEmptyParents(dir, root string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return // Not empty (or not removable) - stop here
+		}
+	}
+}
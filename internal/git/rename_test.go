@@ -0,0 +1,230 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// numberedLines builds content with n distinct lines
+func numberedLines(prefix string, n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%s line %d\n", prefix, i)
+	}
+	return b.String()
+}
+
+// renameActions filters the rename actions of a copy result
+func renameActions(result *CopyResult) []FileAction {
+	var renames []FileAction
+	for _, action := range result.FileActions {
+		if action.Type == FileActionRenamed {
+			renames = append(renames, action)
+		}
+	}
+	return renames
+}
+
+func TestContentSimilarity(t *testing.T) {
+	ten := numberedLines("a", 10)
+
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected float64
+	}{
+		{"identical", ten, ten, 1},
+		{"both empty", "", "", 1},
+		{"one line changed", ten, strings.Replace(ten, "a line 10\n", "changed\n", 1), 0.9},
+		{"nothing shared", ten, numberedLines("b", 10), 0},
+	}
+
+	for _, tc := range testCases {
+		if got := contentSimilarity([]byte(tc.a), []byte(tc.b)); got != tc.expected {
+			t.Errorf("%s: contentSimilarity = %v, expected %v", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestDetectRenames(t *testing.T) {
+	contents := map[string]string{
+		"old/exact.go":   "exact\n",
+		"new/exact.go":   "exact\n",
+		"old/fuzzy.go":   numberedLines("f", 10),
+		"new/fuzzy.go":   strings.Replace(numberedLines("f", 10), "f line 10\n", "changed\n", 1),
+		"old/gone.go":    numberedLines("g", 10),
+		"new/unrelated":  numberedLines("u", 10),
+		"kept/stable.go": "stable\n",
+	}
+	read := func(relPath string) ([]byte, error) { return []byte(contents[relPath]), nil }
+
+	tracked := map[string]string{
+		"old/exact.go":   "hash-exact",
+		"old/fuzzy.go":   "hash-fuzzy-old",
+		"old/gone.go":    "hash-gone",
+		"kept/stable.go": "hash-stable",
+	}
+	upstream := map[string]string{
+		"new/exact.go":   "hash-exact",
+		"new/fuzzy.go":   "hash-fuzzy-new",
+		"new/unrelated":  "hash-unrelated",
+		"kept/stable.go": "hash-stable",
+	}
+
+	renames := detectRenames(tracked, upstream, 0.5, read, read)
+	if len(renames) != 2 {
+		t.Fatalf("Expected 2 renames, got %+v", renames)
+	}
+
+	exact, fuzzy := renames[0], renames[1]
+	if exact.OldPath != "old/exact.go" || exact.Path != "new/exact.go" || exact.Similarity != 1 {
+		t.Errorf("Unexpected exact rename: %+v", exact)
+	}
+	if fuzzy.OldPath != "old/fuzzy.go" || fuzzy.Path != "new/fuzzy.go" || fuzzy.Similarity != 0.9 {
+		t.Errorf("Unexpected fuzzy rename: %+v", fuzzy)
+	}
+
+	// A stricter threshold only keeps the exact match
+	if strict := detectRenames(tracked, upstream, 0.95, read, read); len(strict) != 1 {
+		t.Errorf("Expected only the exact rename above 95%%, got %+v", strict)
+	}
+}
+
+func TestCopyPaths_FollowsUpstreamRenames(t *testing.T) {
+	logger.Init()
+
+	stringsContent := numberedLines("strings", 10)
+	mathContent := numberedLines("math", 10)
+
+	upstream := testutil.NewFixtureRepo(t, "renames")
+	upstream.WriteFile("pkg/utils/strings.go", stringsContent)
+	upstream.WriteFile("pkg/utils/math.go", mathContent)
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{
+		Name:       "renames",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "pkg/"}},
+	}
+
+	sync := func() *CopyResult {
+		t.Helper()
+		repo, err := NewRepository(source)
+		if err != nil {
+			t.Fatalf("Failed to open repository: %v", err)
+		}
+		if err := repo.Pull(); err != nil {
+			t.Fatalf("Failed to pull: %v", err)
+		}
+		result, err := repo.CopyPaths(SyncModeMerge, project.Dir)
+		if err != nil {
+			t.Fatalf("CopyPaths failed: %v", err)
+		}
+		return result
+	}
+
+	sync()
+	project.Commit("initial sync")
+
+	// Local edit at the top of math.go
+	localMath := strings.Replace(mathContent, "math line 1\n", "local header\n", 1)
+	project.WriteFile("pkg/utils/math.go", localMath)
+	project.Commit("local edit")
+
+	// Upstream moves strings.go as-is and moves math.go while editing its last line
+	upstream.RemoveFile("pkg/utils/strings.go")
+	upstream.RemoveFile("pkg/utils/math.go")
+	upstream.WriteFile("pkg/strutil/strings.go", stringsContent)
+	upstream.WriteFile("pkg/mathutil/math.go", strings.Replace(mathContent, "math line 10\n", "upstream footer\n", 1))
+	upstream.Commit("reorganize packages")
+
+	result := sync()
+
+	renames := renameActions(result)
+	if len(renames) != 2 {
+		t.Fatalf("Expected 2 rename actions, got %+v", result.FileActions)
+	}
+	if renames[0].OldPath != "pkg/utils/math.go" || renames[0].Path != "pkg/mathutil/math.go" {
+		t.Errorf("Expected rename paths to be local paths, got %+v", renames[0])
+	}
+	if renames[0].Added != 1 || renames[0].Removed != 1 {
+		t.Errorf("Expected the moved-and-modified file to report +1 -1, got %+v", renames[0])
+	}
+
+	if project.Exists("pkg/utils") {
+		t.Error("Expected the emptied pkg/utils directory to be removed")
+	}
+	if got := project.ReadFile("pkg/strutil/strings.go"); got != stringsContent {
+		t.Errorf("Expected strings.go to be moved unchanged, got %q", got)
+	}
+
+	merged := project.ReadFile("pkg/mathutil/math.go")
+	if !strings.Contains(merged, "local header") || !strings.Contains(merged, "upstream footer") {
+		t.Errorf("Expected moved math.go to keep the local edit and gain the upstream one, got %q", merged)
+	}
+
+	baseManager, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open base-content manager: %v", err)
+	}
+	if content, _ := baseManager.GetFileContent("renames", "pkg/", "mathutil/math.go"); content == nil {
+		t.Error("Expected the base snapshot to be available under the new name")
+	}
+}
+
+func TestCopyPaths_RenameDetectionDisabled(t *testing.T) {
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "norenames")
+	upstream.WriteFile("pkg/old.go", "content\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{
+		Name:       "norenames",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "pkg/"}},
+	}
+	disabled := false
+
+	for i := 0; i < 2; i++ {
+		repo, err := NewRepository(source)
+		if err != nil {
+			t.Fatalf("Failed to open repository: %v", err)
+		}
+		repo.SetSyncOptions(config.SyncOptions{RenameDetection: &disabled})
+		if err := repo.Pull(); err != nil {
+			t.Fatalf("Failed to pull: %v", err)
+		}
+
+		result, err := repo.CopyPaths(SyncModeMerge, project.Dir)
+		if err != nil {
+			t.Fatalf("CopyPaths failed: %v", err)
+		}
+		if renames := renameActions(result); len(renames) != 0 {
+			t.Errorf("Expected no renames with detection disabled, got %+v", renames)
+		}
+
+		if i == 0 {
+			upstream.RemoveFile("pkg/old.go")
+			upstream.WriteFile("pkg/new.go", "content\n")
+			upstream.Commit("rename")
+		}
+	}
+
+	// Without rename following, the move is synced as a deletion plus an addition
+	if project.Exists("pkg/old.go") || !project.Exists("pkg/new.go") {
+		t.Error("Expected the old file to be deleted and the new file to be added when renames are not followed")
+	}
+}
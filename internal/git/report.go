@@ -0,0 +1,153 @@
+package git
+
+import (
+	"sort"
+	"time"
+
+	"cherry-go/internal/history"
+)
+
+// SyncReport is the JSON document `sync --output json` prints. Its field names are a
+// stable interface for scripts and bots; add fields, never rename or remove them.
+type SyncReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Sources []SourceReport `json:"sources"`
+	Planned *PlanReport    `json:"planned,omitempty"` // Only in a dry run
+}
+
+// SourceReport is a source's outcome in a SyncReport
+type SourceReport struct {
+	Name      string           `json:"name"`
+	Status    string           `json:"status"` // As in the history: updated, up-to-date, conflicts, branch, skipped, duplicate or failed
+	Updated   []string         `json:"updated"`
+	Conflicts []ConflictReport `json:"conflicts"`
+	Branch    string           `json:"branch"` // Conflict branch created, if any
+	Commit    string           `json:"commit"` // Project commit auto_commit created, if any
+	Error     string           `json:"error"`
+}
+
+// ConflictReport is a conflicting file, named by its path in the working directory
+type ConflictReport struct {
+	Path           string                `json:"path"`
+	Type           string                `json:"type"`
+	UpstreamCommit *UpstreamCommitReport `json:"upstream_commit"` // null when no upstream commit since the last sync was found
+}
+
+// UpstreamCommitReport is the newest upstream commit since the last sync that changed
+// a conflicting file
+type UpstreamCommitReport struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
+// PlanReport is what a dry run would have written to the project's git repository
+type PlanReport struct {
+	Commits  []CommitPlan `json:"commits"`
+	Branches []BranchPlan `json:"branches"`
+}
+
+// CommitPlan is the project commit auto_commit would create for a source
+type CommitPlan struct {
+	Source  string   `json:"source"`
+	Message string   `json:"message"`
+	Paths   []string `json:"paths"`
+}
+
+// BranchPlan is the conflict branch --branch-on-conflict would create for a source
+type BranchPlan struct {
+	Source            string   `json:"source"`
+	Name              string   `json:"name"` // Its timestamp is the dry run's, a real run names it when it runs
+	From              string   `json:"from"`
+	Files             []string `json:"files"`
+	CommitMessage     string   `json:"commit_message"`
+	MergeInstructions string   `json:"merge_instructions"`
+}
+
+// Status sums up a result as the history records it
+func (r SyncResult) Status() string {
+	switch {
+	case r.Error != nil:
+		return history.StatusFailed
+	case r.Duplicate:
+		return history.StatusDuplicate
+	case r.Skipped != nil:
+		return history.StatusSkipped
+	case r.BranchCreated != "":
+		return history.StatusBranch
+	case len(r.Conflicts) > 0:
+		return history.StatusConflicts
+	case r.HasChanges:
+		return history.StatusUpdated
+	default:
+		return history.StatusUpToDate
+	}
+}
+
+// NewSyncReport builds the report of a run's results, sources in name order. A dry run
+// adds the commits and conflict branches it would have created. Lists are never nil, so
+// JSON always has arrays where scripts expect them.
+func NewSyncReport(results []SyncResult, dryRun bool) *SyncReport {
+	sorted := make([]SyncResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].SourceName < sorted[j].SourceName })
+
+	report := &SyncReport{DryRun: dryRun, Sources: make([]SourceReport, 0, len(sorted))}
+	if dryRun {
+		report.Planned = &PlanReport{Commits: []CommitPlan{}, Branches: []BranchPlan{}}
+	}
+
+	for _, result := range sorted {
+		source := SourceReport{
+			Name:      result.SourceName,
+			Status:    result.Status(),
+			Updated:   make([]string, 0, len(result.FileActions)),
+			Conflicts: make([]ConflictReport, 0, len(result.Conflicts)),
+			Branch:    result.BranchCreated,
+			Commit:    result.ProjectCommit,
+		}
+		for _, action := range result.FileActions {
+			source.Updated = append(source.Updated, action.Path)
+		}
+		sort.Strings(source.Updated)
+		for _, conflict := range result.Conflicts {
+			path := conflict.LocalPath
+			if path == "" {
+				path = conflict.Path
+			}
+			conflictReport := ConflictReport{Path: path, Type: string(conflict.Type)}
+			if upstream := conflict.Upstream; upstream != nil {
+				conflictReport.UpstreamCommit = &UpstreamCommitReport{Hash: upstream.Hash, Author: upstream.Author, Date: upstream.When, Subject: upstream.Subject}
+			}
+			source.Conflicts = append(source.Conflicts, conflictReport)
+		}
+		sort.Slice(source.Conflicts, func(i, j int) bool { return source.Conflicts[i].Path < source.Conflicts[j].Path })
+		if result.Error != nil {
+			source.Error = result.Error.Error()
+		} else if result.Skipped != nil {
+			source.Error = result.Skipped.Error()
+		}
+		report.Sources = append(report.Sources, source)
+
+		if report.Planned == nil {
+			continue
+		}
+		if commit := result.PlannedCommit; commit != nil {
+			paths := append([]string{}, commit.Paths...)
+			report.Planned.Commits = append(report.Planned.Commits, CommitPlan{Source: result.SourceName, Message: commit.Message, Paths: paths})
+		}
+		if branch := result.PlannedBranch; branch != nil {
+			files := append([]string{}, branch.FilesCommitted...)
+			report.Planned.Branches = append(report.Planned.Branches, BranchPlan{
+				Source:            result.SourceName,
+				Name:              branch.BranchName,
+				From:              branch.OriginalBranch,
+				Files:             files,
+				CommitMessage:     branch.CommitMessage,
+				MergeInstructions: result.MergeInstructions,
+			})
+		}
+	}
+	return report
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"cherry-go/internal/hash"
+)
+
+func TestNewSyncReport(t *testing.T) {
+	results := []SyncResult{
+		{
+			SourceName:    "tools",
+			HasChanges:    true,
+			FileActions:   []FileAction{{Path: "bin/z.sh"}, {Path: "bin/a.sh"}},
+			ProjectCommit: "abc123",
+		},
+		{
+			SourceName:        "library",
+			Conflicts:         []hash.FileConflict{{Path: "b.go", LocalPath: "vendor/b.go", Type: hash.ConflictTypeModified}, {Path: "a.go"}},
+			BranchCreated:     "cherry-go/sync/library-20250101-120000",
+			PlannedCommit:     &PlannedCommit{Message: "sync library", Paths: []string{"vendor/"}},
+			PlannedBranch:     &ConflictBranchResult{BranchName: "cherry-go/sync/library-20250101-120000", OriginalBranch: "main", FilesCommitted: []string{"vendor/b.go"}},
+			MergeInstructions: "git merge cherry-go/sync/library-20250101-120000",
+		},
+		{SourceName: "broken", Error: errors.New("failed to pull changes")},
+		{SourceName: "private", Skipped: errors.New("authentication required")},
+	}
+
+	report := NewSyncReport(results, true)
+	var names []string
+	for _, source := range report.Sources {
+		names = append(names, source.Name)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("Expected sources in name order, got %v", names)
+	}
+
+	bySource := make(map[string]SourceReport)
+	for _, source := range report.Sources {
+		bySource[source.Name] = source
+	}
+	if tools := bySource["tools"]; tools.Status != "updated" || !reflect.DeepEqual(tools.Updated, []string{"bin/a.sh", "bin/z.sh"}) || tools.Commit != "abc123" {
+		t.Errorf("Unexpected report for tools: %+v", tools)
+	}
+	library := bySource["library"]
+	expectedConflicts := []ConflictReport{{Path: "a.go"}, {Path: "vendor/b.go", Type: string(hash.ConflictTypeModified)}}
+	if library.Status != "branch" || !reflect.DeepEqual(library.Conflicts, expectedConflicts) {
+		t.Errorf("Unexpected report for library: %+v", library)
+	}
+	if broken := bySource["broken"]; broken.Status != "failed" || broken.Error != "failed to pull changes" {
+		t.Errorf("Unexpected report for broken: %+v", broken)
+	}
+	if private := bySource["private"]; private.Status != "skipped" || private.Error != "authentication required" {
+		t.Errorf("Unexpected report for private: %+v", private)
+	}
+	if len(report.Planned.Commits) != 1 || len(report.Planned.Branches) != 1 || report.Planned.Branches[0].From != "main" {
+		t.Errorf("Unexpected plan: %+v", report.Planned)
+	}
+
+	if NewSyncReport(results, false).Planned != nil {
+		t.Error("Expected no plan outside a dry run")
+	}
+}
+
+func TestSyncReport_RoundTrip(t *testing.T) {
+	report := NewSyncReport([]SyncResult{
+		{SourceName: "library", HasChanges: true, FileActions: []FileAction{{Path: "lib/a.go"}}},
+		{SourceName: "empty"},
+	}, true)
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	var decoded SyncReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, report) {
+		t.Errorf("Round trip changed the report:\n%+v\nbecame\n%+v", report, &decoded)
+	}
+
+	// Field names are an interface: scripts read them, and lists are arrays, never null
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	for _, key := range []string{"dry_run", "sources", "planned"} {
+		if _, ok := document[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, data)
+		}
+	}
+	source := document["sources"].([]any)[0].(map[string]any)
+	for _, key := range []string{"name", "status", "updated", "conflicts", "branch", "commit", "error"} {
+		if _, ok := source[key]; !ok {
+			t.Errorf("Expected key %q in a source of %s", key, data)
+		}
+	}
+	if source["conflicts"] == nil {
+		t.Errorf("Expected conflicts to be an empty array, got %s", data)
+	}
+	planned := document["planned"].(map[string]any)
+	if planned["commits"] == nil || planned["branches"] == nil {
+		t.Errorf("Expected planned commits and branches to be arrays, got %s", data)
+	}
+}
@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+
+	"cherry-go/internal/logger"
+)
+
+// unshallowDepth is the depth `git fetch --unshallow` asks for: every commit
+const unshallowDepth = 2147483647
+
+// SetNoFetch keeps a shallow clone as it is instead of fetching history it lacks, for runs
+// that must not contact the remote
+func (r *Repository) SetNoFetch(noFetch bool) {
+	r.noFetch = noFetch
+}
+
+// isShallow reports whether the clone lacks the history below some of its commits
+func (r *Repository) isShallow() bool {
+	shallow, err := r.repo.Storer.Shallow()
+	return err == nil && len(shallow) > 0
+}
+
+// fetchDepth is the depth a fetch asks for. A shallow clone keeps fetching at the source's
+// depth, which also tells the server which commits it lacks, and is unshallowed once the
+// source no longer sets one; a full clone fetches everything, as it always did.
+func (r *Repository) fetchDepth() int {
+	switch {
+	case !r.isShallow():
+		return 0
+	case r.source.Shallow():
+		return r.source.CloneDepth
+	default:
+		return unshallowDepth
+	}
+}
+
+// deepenFor fetches the whole history of a shallow clone that lacks ref (a branch, tag or
+// commit older than the clone's depth), and reports whether it did. Failing to is only a
+// warning: the caller's error about the missing ref stands.
+func (r *Repository) deepenFor(ref string) bool {
+	if !r.isShallow() || r.noFetch || logger.IsDryRun() {
+		return false
+	}
+	logger.Info("%s: '%s' is not in the shallow clone, fetching its full history", r.source.Name, ref)
+	if err := r.fetch(context.Background(), unshallowDepth); err != nil {
+		logger.Warning("⚠️  Failed to fetch the full history of %s: %v", r.source.Name, err)
+		return false
+	}
+	return true
+}
+
+// requireHistory fetches the whole history of a shallow clone before a walk that compares
+// commits, such as a changelog, which would otherwise stop at the clone's depth
+func (r *Repository) requireHistory(purpose string) {
+	if !r.isShallow() || r.noFetch || logger.IsDryRun() {
+		return
+	}
+	logger.Info("%s: fetching the history the shallow clone lacks for %s", r.source.Name, purpose)
+	if err := r.fetch(context.Background(), unshallowDepth); err != nil {
+		logger.Warning("⚠️  Failed to fetch the full history of %s: %v", r.source.Name, err)
+	}
+}
@@ -0,0 +1,175 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// signingPassphraseEnv holds the passphrase of a protected options.signing_key
+const signingPassphraseEnv = "CHERRY_GO_SIGNING_PASSPHRASE"
+
+// commitSigningKey returns the key auto-commits are signed with, or nil when they are
+// left unsigned: options.sign_commits is off, or it is optional and the key can't be used
+func commitSigningKey(options config.SyncOptions) (*openpgp.Entity, error) {
+	policy := options.SignCommitsPolicy()
+	if policy == config.SignCommitsOff {
+		return nil, nil
+	}
+
+	key, err := loadSigningKey(options.SigningKey)
+	if err == nil {
+		return key, nil
+	}
+	if policy == config.SignCommitsOptional {
+		logger.Warning("⚠️  %v; committing unsigned (options.sign_commits is optional)", err)
+		return nil, nil
+	}
+	return nil, err
+}
+
+// loadSigningKey reads an OpenPGP private key that can sign. signingKey is a key file,
+// armored or binary, or else the ID of a key gpg exports. A passphrase protected key is
+// decrypted with CHERRY_GO_SIGNING_PASSPHRASE.
+func loadSigningKey(signingKey string) (*openpgp.Entity, error) {
+	if signingKey == "" {
+		return nil, fmt.Errorf("could not load signing key: options.signing_key is not set")
+	}
+
+	data, err := readSigningKey(signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not load signing key '%s': %w", signingKey, err)
+	}
+	entity, err := parseSigningKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not load signing key '%s': %w", signingKey, err)
+	}
+	return entity, nil
+}
+
+// readSigningKey reads a key file, falling back to `gpg --export-secret-keys` for key
+// IDs, which leaves asking for the passphrase of the exported key to gpg-agent
+func readSigningKey(signingKey string) ([]byte, error) {
+	keyPath := signingKey
+	if strings.HasPrefix(keyPath, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			keyPath = filepath.Join(homeDir, keyPath[2:])
+		}
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return data, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("gpg", "--batch", "--armor", "--export-secret-keys", signingKey)
+	cmd.Stderr = &stderr
+	exported, gpgErr := cmd.Output()
+	if gpgErr != nil || len(exported) == 0 {
+		// gpg's last line says why; earlier ones are warnings about its setup
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+			gpgErr = errors.New(lines[len(lines)-1])
+		} else if gpgErr == nil {
+			gpgErr = errors.New("no such secret key")
+		}
+		return nil, fmt.Errorf("no such file, and gpg could not export it: %w", gpgErr)
+	}
+	return exported, nil
+}
+
+// parseSigningKey picks the first private key in an armored or binary key ring that
+// has a usable signing key, decrypting it when needed
+func parseSigningKey(data []byte) (*openpgp.Entity, error) {
+	var entities openpgp.EntityList
+	var err error
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		key, ok := entity.SigningKey(time.Now())
+		if !ok || key.PrivateKey == nil || key.PrivateKey.Dummy() {
+			continue
+		}
+		if key.PrivateKey.Encrypted {
+			passphrase := os.Getenv(signingPassphraseEnv)
+			if passphrase == "" {
+				return nil, fmt.Errorf("key is passphrase protected; set %s", signingPassphraseEnv)
+			}
+			if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("wrong passphrase from %s: %w", signingPassphraseEnv, err)
+			}
+		}
+		return entity, nil
+	}
+	return nil, errors.New("no private key that can sign")
+}
+
+// commitWithGitCLI commits what is staged with `git commit -S`, so git's own signing
+// config (gpg.format, gpg.program, user.signingKey) applies, SSH signing included. Under
+// sign_commits: optional a commit git fails to sign is made again unsigned.
+func commitWithGitCLI(root, message string, signature *object.Signature, options config.SyncOptions) (string, error) {
+	signFlag := "--gpg-sign"
+	if options.SigningKey != "" {
+		signFlag += "=" + options.SigningKey
+	}
+
+	err := runGitCommit(root, message, signature, signFlag)
+	if err != nil && options.SignCommitsPolicy() == config.SignCommitsOptional {
+		logger.Warning("⚠️  %v; committing unsigned (options.sign_commits is optional)", err)
+		err = runGitCommit(root, message, signature, "--no-gpg-sign")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	output, err := exec.Command("git", "-C", root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the new commit: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// runGitCommit runs git commit in root with the message verbatim and signature as
+// both author and committer. Hooks are skipped, as they are for go-git's commits.
+func runGitCommit(root, message string, signature *object.Signature, signFlag string) error {
+	date := fmt.Sprintf("%d %s", signature.When.Unix(), signature.When.Format("-0700"))
+	cmd := exec.Command("git", "commit", "--quiet", "--no-verify", "--cleanup=verbatim", "--file=-", signFlag)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(message)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME="+signature.Name,
+		"GIT_AUTHOR_EMAIL="+signature.Email,
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME="+signature.Name,
+		"GIT_COMMITTER_EMAIL="+signature.Email,
+		"GIT_COMMITTER_DATE="+date,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("git commit failed: %s", message)
+		}
+		return fmt.Errorf("git commit failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// throwawaySigningKey generates a PGP key, optionally passphrase protected, writes its
+// armored private key to a file and returns the file and the armored public key
+func throwawaySigningKey(t *testing.T, passphrase string) (string, string) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Sync Bot", "", "bot@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA})
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+
+	var public bytes.Buffer
+	writer, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("Failed to armor the public key: %v", err)
+	}
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatalf("Failed to serialize the public key: %v", err)
+	}
+	writer.Close()
+
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), nil); err != nil {
+			t.Fatalf("Failed to protect the key: %v", err)
+		}
+	}
+	var private bytes.Buffer
+	writer, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatalf("Failed to armor the private key: %v", err)
+	}
+	if err := entity.SerializePrivateWithoutSigning(writer, nil); err != nil {
+		t.Fatalf("Failed to serialize the private key: %v", err)
+	}
+	writer.Close()
+
+	keyPath := filepath.Join(t.TempDir(), "signing-key.asc")
+	if err := os.WriteFile(keyPath, private.Bytes(), 0600); err != nil {
+		t.Fatalf("Failed to write the key: %v", err)
+	}
+	return keyPath, public.String()
+}
+
+func TestCreateCommit_SignCommits(t *testing.T) {
+	keyPath, publicKey := throwawaySigningKey(t, "")
+	protectedKeyPath, protectedPublicKey := throwawaySigningKey(t, "hunter2")
+	missingKeyPath := filepath.Join(t.TempDir(), "missing.asc")
+
+	testCases := []struct {
+		name       string
+		options    config.SyncOptions
+		passphrase string
+		publicKey  string // Key the commit must be signed with; unsigned when empty
+		errorMsg   string
+	}{
+		{
+			name:    "unsigned by default",
+			options: config.SyncOptions{SigningKey: keyPath},
+		},
+		{
+			name:      "signed",
+			options:   config.SyncOptions{SignCommits: config.SignCommitsOn, SigningKey: keyPath},
+			publicKey: publicKey,
+		},
+		{
+			name:       "passphrase protected key",
+			options:    config.SyncOptions{SignCommits: config.SignCommitsOn, SigningKey: protectedKeyPath},
+			passphrase: "hunter2",
+			publicKey:  protectedPublicKey,
+		},
+		{
+			name:     "protected key without the passphrase",
+			options:  config.SyncOptions{SignCommits: config.SignCommitsOn, SigningKey: protectedKeyPath},
+			errorMsg: "passphrase protected; set " + signingPassphraseEnv,
+		},
+		{
+			name:     "missing key",
+			options:  config.SyncOptions{SignCommits: config.SignCommitsOn, SigningKey: missingKeyPath},
+			errorMsg: "could not load signing key '" + missingKeyPath + "'",
+		},
+		{
+			name:    "missing key with optional signing",
+			options: config.SyncOptions{SignCommits: config.SignCommitsOptional, SigningKey: missingKeyPath},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger.Init()
+			t.Setenv(signingPassphraseEnv, tc.passphrase)
+			// gpg, should the key ID fallback run, must not touch the real keyring
+			t.Setenv("GNUPGHOME", t.TempDir())
+			project := testutil.NewProject(t)
+			project.WriteFile("README.md", "# project\n")
+			head := project.Commit("initial")
+
+			project.WriteFile("lib/a.go", "package lib\n")
+			hash, err := CreateCommit(project.Dir, "sync lib", []string{"lib/"}, time.Now(), tc.options)
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Fatalf("Expected an error containing %q, got %v", tc.errorMsg, err)
+				}
+				if ref, _ := project.Repo().Head(); ref.Hash().String() != head {
+					t.Errorf("Expected no commit when the key can't be loaded, HEAD moved to %s", ref.Hash())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateCommit failed: %v", err)
+			}
+
+			commit, err := project.Repo().CommitObject(plumbing.NewHash(hash))
+			if err != nil {
+				t.Fatalf("Failed to read the commit: %v", err)
+			}
+			checkCommitSignature(t, commit, tc.publicKey)
+		})
+	}
+}
+
+func TestCreateCommit_SignWithGitCLI(t *testing.T) {
+	logger.Init()
+	project := testutil.NewProject(t)
+	project.WriteFile("README.md", "# project\n")
+	project.Commit("initial")
+
+	// A gpg program that always fails, so git can't sign
+	cfg, err := project.Repo().Config()
+	if err != nil {
+		t.Fatalf("Failed to read the git config: %v", err)
+	}
+	cfg.Raw.Section("gpg").SetOption("program", "false")
+	if err := project.Repo().SetConfig(cfg); err != nil {
+		t.Fatalf("Failed to write the git config: %v", err)
+	}
+
+	options := config.SyncOptions{SignCommits: config.SignCommitsOn, UseGitCLI: true, CommitAuthorName: "Sync Bot", CommitAuthorEmail: "bot@example.com"}
+	project.WriteFile("lib/a.go", "package lib\n")
+	if _, err := CreateCommit(project.Dir, "sync lib", []string{"lib/"}, time.Now(), options); err == nil || !strings.Contains(err.Error(), "git commit failed") {
+		t.Fatalf("Expected git commit to fail signing, got %v", err)
+	}
+
+	options.SignCommits = config.SignCommitsOptional
+	when := time.Date(2025, 3, 14, 15, 9, 26, 0, time.FixedZone("", 3600))
+	hash, err := CreateCommit(project.Dir, "sync lib\n\n# kept verbatim", []string{"lib/"}, when, options)
+	if err != nil {
+		t.Fatalf("CreateCommit failed: %v", err)
+	}
+
+	commit, err := project.Repo().CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		t.Fatalf("Failed to read the commit: %v", err)
+	}
+	checkCommitSignature(t, commit, "")
+	if commit.Message != "sync lib\n\n# kept verbatim" {
+		t.Errorf("Expected the message to be kept verbatim, got %q", commit.Message)
+	}
+	if commit.Author.Name != "Sync Bot" || commit.Committer.Email != "bot@example.com" || !commit.Committer.When.Equal(when) {
+		t.Errorf("Expected Sync Bot <bot@example.com> at %v, got %s <%s> at %v",
+			when, commit.Author.Name, commit.Committer.Email, commit.Committer.When)
+	}
+}
+
+// checkCommitSignature verifies a commit is signed by publicKey, or unsigned when it is empty
+func checkCommitSignature(t *testing.T, commit *object.Commit, publicKey string) {
+	t.Helper()
+	if publicKey == "" {
+		if commit.PGPSignature != "" {
+			t.Errorf("Expected an unsigned commit, got signature %q", commit.PGPSignature)
+		}
+		return
+	}
+	if commit.PGPSignature == "" {
+		t.Fatalf("Expected a signed commit")
+	}
+	if _, err := commit.Verify(publicKey); err != nil {
+		t.Errorf("Expected the signature to verify: %v", err)
+	}
+}
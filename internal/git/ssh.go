@@ -0,0 +1,180 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/interactive"
+	"cherry-go/internal/logger"
+)
+
+// sshPassphraseEnv holds the passphrase of SSH keys for sources without auth.ssh_key_passphrase_env
+const sshPassphraseEnv = "CHERRY_GO_SSH_PASSPHRASE"
+
+// defaultSSHKeys are the keys in ~/.ssh tried, in order, when the SSH agent isn't available
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// Passphrases typed at the prompt, by key path, so concurrent syncs using the same key ask once
+var (
+	promptedPassphrasesMu sync.Mutex
+	promptedPassphrases   = make(map[string]string)
+)
+
+// getSSHAuth configures SSH authentication. A key named by auth.ssh_key, or by -i in
+// GIT_SSH_COMMAND, is used alone; otherwise the SSH agent is used when available, then
+// the default keys in ~/.ssh. When nothing works the error lists every attempt.
+func getSSHAuth(authConfig config.AuthConfig) (transport.AuthMethod, error) {
+	if keyPath := authConfig.SSHKey; keyPath != "" {
+		logger.Debug("Using SSH key: %s", keyPath)
+		publicKeys, err := loadSSHKey(keyPath, authConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SSH key %s: %w", keyPath, err)
+		}
+		return publicKeys, nil
+	}
+
+	var attempts []string
+	if keyPaths := sshCommandKeys(os.Getenv("GIT_SSH_COMMAND")); len(keyPaths) > 0 {
+		for _, keyPath := range keyPaths {
+			logger.Debug("Using SSH key from GIT_SSH_COMMAND: %s", keyPath)
+			publicKeys, err := loadSSHKey(keyPath, authConfig)
+			if err == nil {
+				return publicKeys, nil
+			}
+			attempts = append(attempts, fmt.Sprintf("%s (GIT_SSH_COMMAND): %v", keyPath, err))
+		}
+		return nil, fmt.Errorf("no SSH authentication method worked (tried %s)", strings.Join(attempts, "; "))
+	}
+
+	logger.Debug("Using SSH agent authentication")
+	sshAuth, err := ssh.NewSSHAgentAuth("git")
+	if err == nil {
+		return sshAuth, nil
+	}
+	logger.Debug("SSH agent not available: %v", err)
+	attempts = append(attempts, fmt.Sprintf("SSH agent: %v", err))
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	for _, keyPath := range defaultSSHKeyPaths(homeDir) {
+		if _, err := os.Stat(keyPath); err != nil {
+			attempts = append(attempts, fmt.Sprintf("%s: not found", keyPath))
+			continue
+		}
+		logger.Debug("Falling back to default SSH key: %s", keyPath)
+		publicKeys, err := loadSSHKey(keyPath, authConfig)
+		if err == nil {
+			return publicKeys, nil
+		}
+		logger.Debug("Can't use SSH key %s: %v", keyPath, err)
+		attempts = append(attempts, fmt.Sprintf("%s: %v", keyPath, err))
+	}
+
+	return nil, fmt.Errorf("no SSH authentication method available (tried %s)", strings.Join(attempts, "; "))
+}
+
+// defaultSSHKeyPaths returns the default keys below homeDir in the order they are tried
+func defaultSSHKeyPaths(homeDir string) []string {
+	paths := make([]string, len(defaultSSHKeys))
+	for i, name := range defaultSSHKeys {
+		paths[i] = filepath.Join(homeDir, ".ssh", name)
+	}
+	return paths
+}
+
+// sshCommandKeys returns the identity files passed with -i in a GIT_SSH_COMMAND.
+// cherry-go speaks SSH itself, so other ssh options in the command are ignored.
+func sshCommandKeys(command string) []string {
+	var keys []string
+	fields := strings.Fields(command)
+	for i := 0; i < len(fields); i++ {
+		var keyPath string
+		switch {
+		case fields[i] == "-i" && i+1 < len(fields):
+			i++
+			keyPath = fields[i]
+		case strings.HasPrefix(fields[i], "-i") && len(fields[i]) > 2:
+			keyPath = fields[i][2:]
+		default:
+			continue
+		}
+		keyPath = strings.Trim(keyPath, `"'`)
+		if strings.HasPrefix(keyPath, "~/") {
+			if homeDir, err := os.UserHomeDir(); err == nil {
+				keyPath = filepath.Join(homeDir, keyPath[2:])
+			}
+		}
+		keys = append(keys, keyPath)
+	}
+	return keys
+}
+
+// loadSSHKey reads a private key, decrypting it when it is passphrase protected. The
+// passphrase comes from the source's auth.ssh_key_passphrase_env, CHERRY_GO_SSH_PASSPHRASE
+// or, in an interactive terminal, a prompt.
+func loadSSHKey(keyPath string, authConfig config.AuthConfig) (*ssh.PublicKeys, error) {
+	pemBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := cryptossh.ParsePrivateKey(pemBytes)
+	var missing *cryptossh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, from, ok := sshKeyPassphrase(keyPath, authConfig)
+		if !ok {
+			return nil, fmt.Errorf("key is passphrase protected; set %s or run in a terminal to be asked", passphraseEnvName(authConfig))
+		}
+		signer, err = cryptossh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("wrong passphrase from %s: %w", from, err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.PublicKeys{User: "git", Signer: signer}, nil
+}
+
+// passphraseEnvName is the environment variable a source reads its key passphrase from
+func passphraseEnvName(authConfig config.AuthConfig) string {
+	if authConfig.SSHKeyPassphraseEnv != "" {
+		return authConfig.SSHKeyPassphraseEnv
+	}
+	return sshPassphraseEnv
+}
+
+// sshKeyPassphrase finds the passphrase of a protected key and says where it came from
+func sshKeyPassphrase(keyPath string, authConfig config.AuthConfig) (string, string, bool) {
+	envName := passphraseEnvName(authConfig)
+	if passphrase := os.Getenv(envName); passphrase != "" {
+		return passphrase, envName, true
+	}
+	if !interactive.ShouldPrompt() {
+		return "", "", false
+	}
+
+	promptedPassphrasesMu.Lock()
+	defer promptedPassphrasesMu.Unlock()
+	if passphrase, ok := promptedPassphrases[keyPath]; ok {
+		return passphrase, "the prompt", true
+	}
+	passphrase, err := interactive.ReadSecret(fmt.Sprintf("Passphrase for %s: ", keyPath))
+	if err != nil {
+		logger.Debug("Failed to read passphrase: %v", err)
+		return "", "", false
+	}
+	promptedPassphrases[keyPath] = passphrase
+	return passphrase, "the prompt", true
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// sshTestHome points HOME at an empty directory with no SSH agent, GIT_SSH_COMMAND,
+// passphrase or terminal, and returns its .ssh directory
+func sshTestHome(t *testing.T) string {
+	t.Helper()
+	logger.Init()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("GIT_SSH_COMMAND", "")
+	t.Setenv(sshPassphraseEnv, "")
+	t.Setenv("CI", "true")
+	sshDir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(sshDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return sshDir
+}
+
+// writeTestKey generates a key, writes it in OpenSSH format (encrypted when passphrase
+// isn't empty) and returns its public key
+func writeTestKey(t *testing.T, path string, ecdsaKey bool, passphrase string) cryptossh.PublicKey {
+	t.Helper()
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	if ecdsaKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private, public = key, &key.PublicKey
+	} else {
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		private, public = key, pub
+	}
+
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = cryptossh.MarshalPrivateKeyWithPassphrase(private, "test", []byte(passphrase))
+	} else {
+		block, err = cryptossh.MarshalPrivateKey(private, "test")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sshPublic, err := cryptossh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sshPublic
+}
+
+// assertSSHKey checks that auth authenticates with the expected public key
+func assertSSHKey(t *testing.T, auth interface{}, expected cryptossh.PublicKey) {
+	t.Helper()
+	keys, ok := auth.(*ssh.PublicKeys)
+	if !ok {
+		t.Fatalf("Expected public key authentication, got %T", auth)
+	}
+	if !bytes.Equal(keys.Signer.PublicKey().Marshal(), expected.Marshal()) {
+		t.Errorf("Authenticated with the wrong key")
+	}
+}
+
+func TestGetSSHAuth_DefaultKeyOrder(t *testing.T) {
+	sshDir := sshTestHome(t)
+	writeTestKey(t, filepath.Join(sshDir, "id_rsa"), false, "")
+	ecdsaKey := writeTestKey(t, filepath.Join(sshDir, "id_ecdsa"), true, "")
+
+	auth, err := getSSHAuth(config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("getSSHAuth failed: %v", err)
+	}
+	assertSSHKey(t, auth, ecdsaKey)
+
+	ed25519Key := writeTestKey(t, filepath.Join(sshDir, "id_ed25519"), false, "")
+	auth, err = getSSHAuth(config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("getSSHAuth failed: %v", err)
+	}
+	assertSSHKey(t, auth, ed25519Key)
+}
+
+func TestGetSSHAuth_PassphraseFromEnv(t *testing.T) {
+	sshDir := sshTestHome(t)
+	key := writeTestKey(t, filepath.Join(sshDir, "id_ed25519"), false, "correct horse")
+
+	// Without a passphrase the key is skipped, and the error lists every attempt
+	_, err := getSSHAuth(config.AuthConfig{})
+	if err == nil {
+		t.Fatal("Expected a protected key without passphrase to fail")
+	}
+	for _, attempt := range []string{"SSH agent", "id_ed25519: key is passphrase protected; set " + sshPassphraseEnv, "id_ecdsa: not found", "id_rsa: not found"} {
+		if !strings.Contains(err.Error(), attempt) {
+			t.Errorf("Expected the error to mention %q, got: %v", attempt, err)
+		}
+	}
+
+	t.Setenv(sshPassphraseEnv, "correct horse")
+	auth, err := getSSHAuth(config.AuthConfig{})
+	if err != nil {
+		t.Fatalf("getSSHAuth with %s failed: %v", sshPassphraseEnv, err)
+	}
+	assertSSHKey(t, auth, key)
+
+	// A source's own variable wins over CHERRY_GO_SSH_PASSPHRASE
+	t.Setenv("WORK_KEY_PASSPHRASE", "wrong")
+	_, err = getSSHAuth(config.AuthConfig{SSHKeyPassphraseEnv: "WORK_KEY_PASSPHRASE"})
+	if err == nil || !strings.Contains(err.Error(), "wrong passphrase from WORK_KEY_PASSPHRASE") {
+		t.Errorf("Expected a wrong passphrase error, got: %v", err)
+	}
+}
+
+func TestGetSSHAuth_ConfiguredKey(t *testing.T) {
+	sshDir := sshTestHome(t)
+	writeTestKey(t, filepath.Join(sshDir, "id_ed25519"), false, "")
+	workKey := writeTestKey(t, filepath.Join(sshDir, "work"), true, "s3cret")
+	t.Setenv("WORK_KEY_PASSPHRASE", "s3cret")
+
+	auth, err := getSSHAuth(config.AuthConfig{SSHKey: filepath.Join(sshDir, "work"), SSHKeyPassphraseEnv: "WORK_KEY_PASSPHRASE"})
+	if err != nil {
+		t.Fatalf("getSSHAuth failed: %v", err)
+	}
+	assertSSHKey(t, auth, workKey)
+
+	// GIT_SSH_COMMAND's identity is used instead of the default keys
+	t.Setenv("GIT_SSH_COMMAND", "ssh -o IdentitiesOnly=yes -i ~/.ssh/work")
+	auth, err = getSSHAuth(config.AuthConfig{SSHKeyPassphraseEnv: "WORK_KEY_PASSPHRASE"})
+	if err != nil {
+		t.Fatalf("getSSHAuth with GIT_SSH_COMMAND failed: %v", err)
+	}
+	assertSSHKey(t, auth, workKey)
+}
+
+func TestSSHCommandKeys(t *testing.T) {
+	t.Setenv("HOME", "/home/dev")
+	testCases := []struct {
+		command  string
+		expected []string
+	}{
+		{"", nil},
+		{"ssh -o StrictHostKeyChecking=no", nil},
+		{"ssh -i /keys/deploy", []string{"/keys/deploy"}},
+		{"ssh -i~/.ssh/work -i '/keys/other'", []string{"/home/dev/.ssh/work", "/keys/other"}},
+	}
+	for _, tc := range testCases {
+		if got := sshCommandKeys(tc.command); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("sshCommandKeys(%q) = %v, expected %v", tc.command, got, tc.expected)
+		}
+	}
+}
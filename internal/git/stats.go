@@ -0,0 +1,133 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cherry-go/internal/hash"
+	"cherry-go/internal/merge"
+)
+
+// maxStatFileSize caps the size of files kept in memory for line statistics;
+// larger files are compared by size only
+const maxStatFileSize = 1 << 20
+
+// localFile is the state of a local file captured around a sync
+type localFile struct {
+	size    int64
+	hash    string
+	content []byte // nil when over maxStatFileSize
+}
+
+// captureLocalFiles records the local files of a path spec, keyed by local path
+func captureLocalFiles(localPath string, isDir bool, hasher *hash.FileHasher) map[string]localFile {
+	files := make(map[string]localFile)
+
+	capture := func(path string, info os.FileInfo) {
+		file := localFile{size: info.Size()}
+		if target, isLink, err := hash.ReadLink(path); isLink && err == nil {
+			// A link is recorded by its target, which is all a sync changes about it
+			file.content = []byte(target)
+			file.hash = hasher.HashBytes(file.content)
+		} else if info.Size() <= maxStatFileSize {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return
+			}
+			file.content = content
+			file.hash = hasher.HashBytes(content)
+		} else {
+			h, err := hasher.HashFile(path)
+			if err != nil {
+				return
+			}
+			file.hash = h
+		}
+		files[path] = file
+	}
+
+	if !isDir {
+		if info, err := os.Stat(localPath); err == nil && !info.IsDir() {
+			capture(localPath, info)
+		}
+		return files
+	}
+
+	_ = filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		capture(path, info)
+		return nil
+	})
+	return files
+}
+
+// diffLocalFiles compares local files before and after a sync and returns one action per
+// added, rewritten or deleted file. Followed renames are compared against their old path
+// and keep their rename action, now with statistics attached.
+func diffLocalFiles(before, after map[string]localFile, renames []FileAction) []FileAction {
+	renameByTarget := make(map[string]FileAction, len(renames))
+	renameSources := make(map[string]bool, len(renames))
+	for _, rename := range renames {
+		renameByTarget[rename.Path] = rename
+		renameSources[rename.OldPath] = true
+	}
+
+	paths := make([]string, 0, len(after))
+	for path := range after {
+		paths = append(paths, path)
+	}
+	for path := range before {
+		if _, kept := after[path]; !kept && !renameSources[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var actions []FileAction
+	for _, path := range paths {
+		current, exists := after[path]
+		if !exists {
+			action := FileAction{Type: FileActionDeleted, Path: path}
+			fillStats(&action, before[path], localFile{})
+			actions = append(actions, action)
+			continue
+		}
+
+		action, renamed := renameByTarget[path]
+		previous, existed := before[path]
+		if renamed {
+			previous, existed = before[action.OldPath]
+		} else {
+			action = FileAction{Type: FileActionUpdated, Path: path}
+		}
+
+		if !existed {
+			action.Type = FileActionAdded
+		} else if previous.hash == current.hash && !renamed {
+			continue
+		}
+
+		fillStats(&action, previous, current)
+		actions = append(actions, action)
+	}
+
+	return actions
+}
+
+// fillStats attaches size and line statistics to an action
+func fillStats(action *FileAction, previous, current localFile) {
+	action.OldSize = previous.size
+	action.NewSize = current.size
+
+	switch {
+	case previous.size > maxStatFileSize || current.size > maxStatFileSize:
+		action.TooLarge = true
+	case merge.IsBinary(previous.content) || merge.IsBinary(current.content):
+		action.Binary = true
+	default:
+		action.Added, action.Removed = merge.LineStat(previous.content, current.content)
+	}
+}
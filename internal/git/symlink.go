@@ -0,0 +1,227 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// maxLinkHops bounds how many symbolic links are followed while resolving one path
+const maxLinkHops = 40
+
+// resolveSourceLink follows a tracked include that is, or runs through, a symbolic link
+// in the checked-out clone. Upstream repositories expose things like latest/ pointing at
+// a versioned directory; the link is resolved on every sync so a retarget is just new
+// content under the same tracked path.
+//
+// Directories are read from their target so walks see the files inside; files keep the
+// link path since their base name is their tracking key. target is the resolved path
+// relative to the repository root, empty when include involves no link.
+func resolveSourceLink(repoPath, include string) (sourcePath, target string, err error) {
+	sourcePath = filepath.Join(repoPath, include)
+
+	if _, err := os.Lstat(sourcePath); err != nil {
+		// Missing paths are reported by the caller
+		return sourcePath, "", nil
+	}
+
+	root, err := filepath.EvalSymlinks(repoPath)
+	if err != nil {
+		return sourcePath, "", err
+	}
+	resolved, err := filepath.EvalSymlinks(sourcePath)
+	if err != nil {
+		return sourcePath, "", err
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return sourcePath, "", fmt.Errorf("%s is a symbolic link outside the repository", include)
+	}
+	if rel == filepath.Clean(config.PathKey(include)) {
+		return sourcePath, "", nil
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return sourcePath, "", err
+	}
+	target = config.CanonicalPath(filepath.ToSlash(rel), info.IsDir())
+	if info.IsDir() {
+		sourcePath = resolved
+	}
+	return sourcePath, target, nil
+}
+
+// resolveTreeLink is resolveSourceLink for a committed tree: it returns the path key
+// points to once every symbolic link along it is followed
+func resolveTreeLink(tree *object.Tree, key string) (string, error) {
+	parts := strings.Split(key, "/")
+	for hops, i := 0, 0; i < len(parts); i++ {
+		prefix := path.Join(parts[:i+1]...)
+		entry, err := tree.FindEntry(prefix)
+		if err != nil {
+			return key, nil // Missing paths are reported by the caller
+		}
+		if entry.Mode != filemode.Symlink {
+			continue
+		}
+
+		if hops++; hops > maxLinkHops {
+			return "", fmt.Errorf("too many symbolic links resolving %s", key)
+		}
+		file, err := tree.TreeEntryFile(entry)
+		if err != nil {
+			return "", err
+		}
+		dest, err := file.Contents()
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(dest) {
+			return "", fmt.Errorf("%s is a symbolic link outside the repository", prefix)
+		}
+
+		resolved := path.Join(path.Dir(prefix), dest)
+		if resolved == ".." || strings.HasPrefix(resolved, "../") {
+			return "", fmt.Errorf("%s is a symbolic link outside the repository", prefix)
+		}
+
+		// Start over on the rewritten path: the target may itself run through links
+		parts = append(strings.Split(resolved, "/"), parts[i+1:]...)
+		i = -1
+	}
+	return path.Join(parts...), nil
+}
+
+// copySymlink recreates a symbolic link at dst with the same target, relative or
+// absolute, replacing the file or link there. check (if set) vets dst first.
+func copySymlink(src, dst string, check writeCheck) error {
+	if check != nil {
+		if err := check(dst); err != nil {
+			return err
+		}
+	}
+	target, err := readCheckedOutLink(src)
+	if err != nil {
+		return err
+	}
+	return writeSymlink(dst, target)
+}
+
+// readCheckedOutLink reads a symbolic link in a clone's worktree. go-git checks absolute
+// targets out rebased into the worktree, as its chrooted filesystem does, so that is
+// undone to get the target as written upstream.
+func readCheckedOutLink(path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil || !filepath.IsAbs(target) {
+		return target, err
+	}
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, target); err == nil && !strings.HasPrefix(rel, "..") {
+			return string(filepath.Separator) + rel, nil
+		}
+		break // The worktree's root: the target is outside it
+	}
+	return target, nil
+}
+
+// writeSymlink makes path a symbolic link to target, replacing the file or link there
+func writeSymlink(path, target string) error {
+	if current, isLink, _ := hash.ReadLink(path); isLink && current == target {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(target, path)
+}
+
+// linksBack reports whether the directory link points to dir or a directory above it,
+// which following would copy forever
+func linksBack(dir, link string) bool {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(target, realDir)
+	return err == nil && (rel == "." || !strings.HasPrefix(rel, ".."))
+}
+
+// readEntry reads a file inside a tracked directory. Unless follow is set, a symbolic
+// link reads as its target, as written, with isLink set: links are synced as links.
+func readEntry(path string, follow bool) (content []byte, isLink bool, err error) {
+	if !follow {
+		if target, isLink, err := hash.ReadLink(path); err != nil {
+			return nil, false, err
+		} else if isLink {
+			return []byte(target), true, nil
+		}
+	}
+	content, err = os.ReadFile(path)
+	return content, false, err
+}
+
+// readSourceEntry is readEntry for a file in a clone's worktree
+func readSourceEntry(path string, follow bool) (content []byte, isLink bool, err error) {
+	if !follow {
+		if info, err := os.Lstat(path); err != nil {
+			return nil, false, err
+		} else if hash.IsSymlink(info) {
+			target, err := readCheckedOutLink(path)
+			return []byte(target), true, err
+		}
+	}
+	content, err = os.ReadFile(path)
+	return content, false, err
+}
+
+// hashSourceEntry hashes a file in a clone's worktree for tracking, as HashEntry hashes
+// its local copy
+func hashSourceEntry(hasher *hash.FileHasher, path string, info os.FileInfo) (string, error) {
+	if hasher.FollowSymlinks || !hash.IsSymlink(info) {
+		return hasher.HashFile(path)
+	}
+	target, err := readCheckedOutLink(path)
+	if err != nil {
+		return "", err
+	}
+	return hasher.HashBytes([]byte(target)), nil
+}
+
+// writeLocalEntry writes what readEntry read into the working copy: a link to content
+// when isLink, else the file (created with perm), replacing a link there rather than
+// writing through it
+func (r *Repository) writeLocalEntry(localPath string, content []byte, isLink bool, perm os.FileMode) error {
+	if !isLink {
+		return r.writeLocalFile(localPath, content, perm)
+	}
+	if err := r.checkWrite(localPath); err != nil {
+		return err
+	}
+	if logger.IsDryRun() {
+		return nil
+	}
+	return writeSymlink(localPath, string(content))
+}
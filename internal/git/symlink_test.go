@@ -0,0 +1,313 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// syncFixture pulls a source and copies its paths into the current project
+func syncFixture(t *testing.T, source *config.Source, mode SyncMode, workDir string) *CopyResult {
+	t.Helper()
+	return syncFixtureWith(t, source, mode, workDir, config.SyncOptions{})
+}
+
+// syncFixtureWith is syncFixture with project-wide sync options
+func syncFixtureWith(t *testing.T, source *config.Source, mode SyncMode, workDir string, options config.SyncOptions) *CopyResult {
+	t.Helper()
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	repo.SetSyncOptions(options)
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	result, err := repo.CopyPaths(mode, workDir)
+	if err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return result
+}
+
+func TestCopyPaths_SymlinkedDirectoryRetargeted(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("releases/v1/a.go", "a v1\n")
+	upstream.WriteFile("releases/v1/b.go", "b\n")
+	upstream.WriteFile("releases/v2/a.go", "a v2\n")
+	upstream.WriteFile("releases/v2/b.go", "b\n")
+	upstream.WriteFile("releases/v2/c.go", "c\n")
+	upstream.Symlink("latest", "releases/v1")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "latest/"}}}
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := result.LinkTargets["latest/"]; got != "releases/v1/" {
+		t.Errorf("Expected latest/ to resolve to releases/v1/, got %q", got)
+	}
+	if got := project.ReadFile("latest/a.go"); got != "a v1\n" {
+		t.Errorf("Expected the link target's files to be synced, got %q", got)
+	}
+	if len(result.Failed) > 0 {
+		t.Errorf("Expected no failures, got %v", result.Failed)
+	}
+
+	upstream.Symlink("latest", "releases/v2")
+	upstream.Commit("release v2")
+
+	result = syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := result.LinkTargets["latest/"]; got != "releases/v2/" {
+		t.Errorf("Expected latest/ to resolve to releases/v2/, got %q", got)
+	}
+	if got := project.ReadFile("latest/a.go"); got != "a v2\n" {
+		t.Errorf("Expected the retarget to update a.go, got %q", got)
+	}
+
+	// A retarget is an update of the files that differ, not a delete and re-add of everything
+	var actions []string
+	for _, action := range result.FileActions {
+		actions = append(actions, string(action.Type)+" "+action.Path)
+	}
+	sort.Strings(actions)
+	if len(actions) != 2 || actions[0] != "added latest/c.go" || actions[1] != "updated latest/a.go" {
+		t.Errorf("Expected a.go updated and c.go added, got %v", actions)
+	}
+
+	files := source.Paths[0].Files
+	if len(files) != 3 || files["a.go"] == "" || files["b.go"] == "" || files["c.go"] == "" {
+		t.Errorf("Expected tracking keyed under the link path, got %v", files)
+	}
+
+	// Integrity checks read the link's target too, so nothing looks drifted or extra
+	if fixes := PlanTrackingFixes(source, config.SyncOptions{}); len(fixes) > 0 {
+		t.Errorf("Expected tracking to match upstream through the link, got %v", fixes)
+	}
+}
+
+func TestCopyPaths_SymlinkedFileRetargeted(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("configs/v1.json", "{\"v\": 1}\n")
+	upstream.WriteFile("configs/v2.json", "{\"v\": 2}\n")
+	upstream.Symlink("config.json", "configs/v1.json")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "config.json"}}}
+	syncFixture(t, source, SyncModeMerge, project.Dir)
+
+	upstream.Symlink("config.json", "configs/v2.json")
+	upstream.Commit("point at v2")
+
+	result := syncFixture(t, source, SyncModeMerge, project.Dir)
+	if got := result.LinkTargets["config.json"]; got != "configs/v2.json" {
+		t.Errorf("Expected config.json to resolve to configs/v2.json, got %q", got)
+	}
+	if got := project.ReadFile("config.json"); got != "{\"v\": 2}\n" {
+		t.Errorf("Expected the retargeted content, got %q", got)
+	}
+	if len(result.Conflicts) > 0 {
+		t.Errorf("Expected a clean update, got conflicts %v", result.Conflicts)
+	}
+	if files := source.Paths[0].Files; len(files) != 1 || files["config.json"] == "" {
+		t.Errorf("Expected tracking keyed by the link name, got %v", files)
+	}
+}
+
+func TestCopyPaths_SymlinkOutsideRepository(t *testing.T) {
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("README.md", "readme\n")
+	upstream.Symlink("escape", "../../..")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "escape/"}}}
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	if len(result.UpdatedPaths) > 0 || project.Exists("escape") {
+		t.Errorf("Expected a link leaving the repository not to be synced, got %v", result.UpdatedPaths)
+	}
+}
+
+// newLinkedDirectoryFixture is an upstream dist/ holding a relative link to a directory
+// and a relative link to a file
+func newLinkedDirectoryFixture(t *testing.T) (*testutil.FixtureRepo, *testutil.Project, *config.Source) {
+	t.Helper()
+	logger.Init()
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("dist/v1/a.go", "a v1\n")
+	upstream.WriteFile("dist/v2/a.go", "a v2\n")
+	upstream.Symlink("dist/latest", "v2")
+	upstream.Symlink("dist/current.go", "v2/a.go")
+	upstream.Commit("initial")
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{Name: "library", Repository: upstream.URL(), Paths: []config.PathSpec{{Include: "dist/"}}}
+	return upstream, project, source
+}
+
+// expectLinks checks that each local path is a symbolic link to its target, as written
+func expectLinks(t *testing.T, project *testutil.Project, links map[string]string) {
+	t.Helper()
+	for path, expected := range links {
+		got, err := os.Readlink(project.Path(path))
+		if err != nil || got != expected {
+			t.Errorf("Expected %s to link to %q, got %q (%v)", path, expected, got, err)
+		}
+	}
+}
+
+func TestCopyPaths_SymlinksInsideDirectory(t *testing.T) {
+	upstream, project, source := newLinkedDirectoryFixture(t)
+	// go-git, which commits fixtures and checks out the cache, treats absolute targets as
+	// rooted at the worktree: this commits a link to /opt/shared
+	absTarget := string(filepath.Separator) + filepath.Join("opt", "shared")
+	upstream.Symlink("dist/shared", filepath.Join(upstream.WorkDir, absTarget))
+	upstream.Commit("absolute link")
+
+	result := syncFixture(t, source, SyncModeForce, project.Dir)
+	if len(result.Failed) > 0 {
+		t.Fatalf("Expected no failures, got %v", result.Failed)
+	}
+	expectLinks(t, project, map[string]string{"dist/latest": "v2", "dist/current.go": "v2/a.go", "dist/shared": absTarget})
+
+	// Links are tracked by their target, and not walked into
+	hasher := hash.NewFileHasherFor(config.SyncOptions{})
+	files := source.Paths[0].Files
+	if files["latest"] != hasher.HashBytes([]byte("v2")) || files["shared"] != hasher.HashBytes([]byte(absTarget)) {
+		t.Errorf("Expected links to be hashed by target, got %v", files)
+	}
+	if _, walked := files["latest/a.go"]; walked || len(files) != 5 {
+		t.Errorf("Expected the links themselves to be tracked, got %v", files)
+	}
+	if fixes := PlanTrackingFixes(source, config.SyncOptions{}); len(fixes) > 0 {
+		t.Errorf("Expected tracking to match the links, got %v", fixes)
+	}
+	project.Commit("sync dist")
+
+	if result := syncFixture(t, source, SyncModeDetect, project.Dir); len(result.Conflicts) > 0 {
+		t.Errorf("Expected an unchanged sync to find no conflicts, got %v", result.Conflicts)
+	}
+
+	// A retarget replaces the link, never merging anything
+	upstream.Symlink("dist/latest", "v1")
+	upstream.Symlink("dist/current.go", "v1/a.go")
+	upstream.Commit("back to v1")
+
+	result = syncFixture(t, source, SyncModeMerge, project.Dir)
+	if len(result.Conflicts) > 0 || len(result.Failed) > 0 {
+		t.Fatalf("Expected the retarget to sync cleanly, got conflicts %v, failures %v", result.Conflicts, result.Failed)
+	}
+	expectLinks(t, project, map[string]string{"dist/latest": "v1", "dist/current.go": "v1/a.go"})
+	if got := project.ReadFile("dist/v2/a.go"); got != "a v2\n" {
+		t.Errorf("Expected the old link target to be left alone, got %q", got)
+	}
+	if source.Paths[0].Files["latest"] != hasher.HashBytes([]byte("v1")) {
+		t.Errorf("Expected the retargeted link's hash, got %v", source.Paths[0].Files)
+	}
+	project.Commit("sync v1")
+
+	// A link turned into a file replaces the link, not what it pointed to
+	upstream.RemoveFile("dist/current.go")
+	upstream.WriteFile("dist/current.go", "current\n")
+	upstream.Commit("current is a file")
+
+	result = syncFixture(t, source, SyncModeMerge, project.Dir)
+	if len(result.Conflicts) > 0 || len(result.Failed) > 0 {
+		t.Fatalf("Expected the link to be replaced cleanly, got conflicts %v, failures %v", result.Conflicts, result.Failed)
+	}
+	if info, err := os.Lstat(project.Path("dist/current.go")); err != nil || hash.IsSymlink(info) {
+		t.Errorf("Expected dist/current.go to be a regular file (%v)", err)
+	}
+	if got := project.ReadFile("dist/current.go"); got != "current\n" {
+		t.Errorf("Expected the upstream file, got %q", got)
+	}
+	if got := project.ReadFile("dist/v1/a.go"); got != "a v1\n" {
+		t.Errorf("Expected the link's old target to be left alone, got %q", got)
+	}
+}
+
+func TestCopyPaths_FollowSymlinks(t *testing.T) {
+	_, project, source := newLinkedDirectoryFixture(t)
+
+	result := syncFixtureWith(t, source, SyncModeForce, project.Dir, config.SyncOptions{FollowSymlinks: true})
+	if len(result.Failed) > 0 {
+		t.Fatalf("Expected no failures, got %v", result.Failed)
+	}
+
+	// Links are copied as what they point to
+	for path, expected := range map[string]string{
+		"dist/latest/a.go": "a v2\n",
+		"dist/current.go":  "a v2\n",
+	} {
+		if info, err := os.Lstat(project.Path(path)); err != nil || hash.IsSymlink(info) {
+			t.Errorf("Expected %s to be a regular file (%v)", path, err)
+		} else if got := project.ReadFile(path); got != expected {
+			t.Errorf("Expected %s to hold %q, got %q", path, expected, got)
+		}
+	}
+	if info, err := os.Lstat(project.Path("dist/latest")); err != nil || !info.IsDir() {
+		t.Errorf("Expected dist/latest to be a directory (%v)", err)
+	}
+
+	hasher := hash.NewFileHasherFor(config.SyncOptions{})
+	if got := source.Paths[0].Files["latest/a.go"]; got != hasher.HashBytes([]byte("a v2\n")) {
+		t.Errorf("Expected the followed file to be tracked by content, got %v", source.Paths[0].Files)
+	}
+}
+
+func TestResolveTreeLink(t *testing.T) {
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("releases/v2/a.go", "a\n")
+	upstream.Symlink("releases/current", "v2")
+	upstream.Symlink("latest", "releases/current")
+	upstream.Symlink("escape", "../outside")
+	upstream.Commit("initial")
+
+	commit, err := upstream.Repo().CommitObject(plumbing.NewHash(upstream.Head()))
+	if err != nil {
+		t.Fatalf("Failed to read commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatalf("Failed to read tree: %v", err)
+	}
+
+	testCases := []struct {
+		key      string
+		expected string
+		wantErr  bool
+	}{
+		{key: "releases/v2", expected: "releases/v2"},
+		{key: "latest", expected: "releases/v2"},
+		{key: "latest/a.go", expected: "releases/v2/a.go"},
+		{key: "missing/a.go", expected: "missing/a.go"},
+		{key: "escape", wantErr: true},
+	}
+	for _, tc := range testCases {
+		got, err := resolveTreeLink(tree, tc.key)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %q", tc.key, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.expected {
+			t.Errorf("%s: expected %q, got %q (%v)", tc.key, tc.expected, got, err)
+		}
+	}
+}
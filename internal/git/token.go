@@ -0,0 +1,143 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// Hosts the provider tokens are sent to without any configuration
+const (
+	defaultGitHubHost = "github.com"
+	defaultGitLabHost = "gitlab.com"
+)
+
+// tokenHosts are the hosts GITHUB_TOKEN and GITLAB_TOKEN may be sent to, so a token
+// for one provider never reaches an unrelated Git server
+var tokenHosts = struct {
+	github []string
+	gitlab []string
+}{
+	github: []string{defaultGitHubHost},
+	gitlab: []string{defaultGitLabHost},
+}
+
+// withheldTokens remembers the hosts already warned about a withheld token
+var withheldTokens = struct {
+	sync.Mutex
+	warned map[string]bool
+}{warned: map[string]bool{}}
+
+// SetTokenHosts adds the GitHub and GitLab hosts from options.github_hosts and
+// options.gitlab_hosts to github.com and gitlab.com
+func SetTokenHosts(github, gitlab []string) {
+	tokenHosts.github = append([]string{defaultGitHubHost}, github...)
+	tokenHosts.gitlab = append([]string{defaultGitLabHost}, gitlab...)
+}
+
+// tokenCredential is an environment credential and the rule that selected it
+type tokenCredential struct {
+	auth *http.BasicAuth
+	rule string
+	env  string // Variables it was read from, for diagnostics
+}
+
+// selectToken picks the environment credential for an HTTPS host: the source's own
+// token_env, then GITHUB_TOKEN or GITLAB_TOKEN for their hosts, then GIT_TOKEN and
+// GIT_USERNAME/GIT_PASSWORD for any host. It returns nil if none applies.
+func selectToken(authConfig config.AuthConfig, host string) *tokenCredential {
+	github := matchesHost(host, tokenHosts.github)
+	gitlab := matchesHost(host, tokenHosts.gitlab)
+
+	// GitLab only accepts tokens as the oauth2 user; other servers ignore the username
+	tokenUser := "token"
+	if gitlab {
+		tokenUser = "oauth2"
+	}
+
+	if authConfig.TokenEnv != "" {
+		if token := os.Getenv(authConfig.TokenEnv); token != "" {
+			return &tokenCredential{
+				auth: &http.BasicAuth{Username: tokenUser, Password: token},
+				rule: authConfig.TokenEnv + " (auth.token_env)",
+				env:  authConfig.TokenEnv,
+			}
+		}
+		logger.Debug("auth.token_env %s is not set, falling back to the default token rules", authConfig.TokenEnv)
+	}
+
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && github {
+		return &tokenCredential{
+			auth: &http.BasicAuth{Username: "token", Password: token},
+			rule: "GITHUB_TOKEN (GitHub host)",
+			env:  "GITHUB_TOKEN",
+		}
+	}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" && gitlab {
+		return &tokenCredential{
+			auth: &http.BasicAuth{Username: "oauth2", Password: token},
+			rule: "GITLAB_TOKEN (GitLab host)",
+			env:  "GITLAB_TOKEN",
+		}
+	}
+	if token := os.Getenv("GIT_TOKEN"); token != "" {
+		return &tokenCredential{
+			auth: &http.BasicAuth{Username: tokenUser, Password: token},
+			rule: "GIT_TOKEN (any host)",
+			env:  "GIT_TOKEN",
+		}
+	}
+	if username := os.Getenv("GIT_USERNAME"); username != "" {
+		if password := os.Getenv("GIT_PASSWORD"); password != "" {
+			return &tokenCredential{
+				auth: &http.BasicAuth{Username: username, Password: password},
+				rule: "GIT_USERNAME/GIT_PASSWORD (any host)",
+				env:  "GIT_USERNAME/GIT_PASSWORD",
+			}
+		}
+	}
+
+	warnWithheldToken(host, "GITHUB_TOKEN", !github, "options.github_hosts")
+	warnWithheldToken(host, "GITLAB_TOKEN", !gitlab, "options.gitlab_hosts")
+	return nil
+}
+
+// warnWithheldToken warns, once per host, that a set provider token wasn't sent to a
+// host it isn't configured for, which is usually why a private repository fails to clone
+func warnWithheldToken(host, envVar string, withheld bool, option string) {
+	if !withheld || os.Getenv(envVar) == "" {
+		return
+	}
+
+	withheldTokens.Lock()
+	defer withheldTokens.Unlock()
+	key := envVar + "@" + host
+	if withheldTokens.warned[key] {
+		return
+	}
+	withheldTokens.warned[key] = true
+
+	logger.Warning("⚠️  %s is set but not sent to %s, which isn't one of its hosts; add the host to %s, or set auth.token_env: %s on the source to send it anyway",
+		envVar, host, option, envVar)
+}
+
+// matchesHost reports whether host, with or without its port, is one of hosts
+func matchesHost(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	hostname := host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		hostname = host[:i]
+	}
+	for _, candidate := range hosts {
+		candidate = strings.ToLower(strings.TrimSpace(candidate))
+		if candidate != "" && (candidate == host || candidate == hostname) {
+			return true
+		}
+	}
+	return false
+}
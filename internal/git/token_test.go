@@ -0,0 +1,76 @@
+package git
+
+import (
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+func TestSelectToken(t *testing.T) {
+	logger.Init()
+	t.Cleanup(func() { SetTokenHosts(nil, nil) })
+
+	testCases := []struct {
+		name     string
+		host     string
+		env      map[string]string
+		tokenEnv string
+		ghHosts  []string
+		glHosts  []string
+		user     string
+		password string // empty: no credential
+	}{
+		{name: "GitHub token on github.com", host: "github.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}, user: "token", password: "gh"},
+		{name: "host is case-insensitive", host: "GitHub.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}, user: "token", password: "gh"},
+		{name: "GitHub token withheld from another host", host: "git.example.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}},
+		{name: "GitHub token on a configured enterprise host", host: "ghe.corp.example:8443",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}, ghHosts: []string{"ghe.corp.example"}, user: "token", password: "gh"},
+		{name: "GitHub token not sent to gitlab.com", host: "gitlab.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh", "GITLAB_TOKEN": "gl"}, user: "oauth2", password: "gl"},
+		{name: "GitLab token withheld from github.com", host: "github.com",
+			env: map[string]string{"GITLAB_TOKEN": "gl"}},
+		{name: "GitLab token on a configured self-hosted host", host: "git.corp.example",
+			env: map[string]string{"GITLAB_TOKEN": "gl"}, glHosts: []string{"git.corp.example"}, user: "oauth2", password: "gl"},
+		{name: "generic token for any host", host: "git.example.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh", "GIT_TOKEN": "generic"}, user: "token", password: "generic"},
+		{name: "provider token wins over the generic one on its host", host: "github.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh", "GIT_TOKEN": "generic"}, user: "token", password: "gh"},
+		{name: "username and password for any host", host: "git.example.com",
+			env: map[string]string{"GIT_USERNAME": "me", "GIT_PASSWORD": "secret"}, user: "me", password: "secret"},
+		{name: "token_env opts an unrelated host in", host: "git.example.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}, tokenEnv: "GITHUB_TOKEN", user: "token", password: "gh"},
+		{name: "token_env wins over the host rules", host: "github.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh", "MIRROR_TOKEN": "mirror"}, tokenEnv: "MIRROR_TOKEN", user: "token", password: "mirror"},
+		{name: "unset token_env falls back to the host rules", host: "github.com",
+			env: map[string]string{"GITHUB_TOKEN": "gh"}, tokenEnv: "MIRROR_TOKEN", user: "token", password: "gh"},
+		{name: "nothing set", host: "github.com"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"GITHUB_TOKEN", "GITLAB_TOKEN", "GIT_TOKEN", "GIT_USERNAME", "GIT_PASSWORD", "MIRROR_TOKEN"} {
+				t.Setenv(name, tc.env[name])
+			}
+			SetTokenHosts(tc.ghHosts, tc.glHosts)
+
+			credential := selectToken(config.AuthConfig{Type: "auto", TokenEnv: tc.tokenEnv}, tc.host)
+			if tc.password == "" {
+				if credential != nil {
+					t.Fatalf("Expected no credential for %s, got %s", tc.host, credential.rule)
+				}
+				return
+			}
+			if credential == nil {
+				t.Fatalf("Expected a credential for %s, got none", tc.host)
+			}
+			if credential.auth.Username != tc.user || credential.auth.Password != tc.password {
+				t.Errorf("Expected %s/%s, got %s/%s (%s)", tc.user, tc.password,
+					credential.auth.Username, credential.auth.Password, credential.rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// Kinds of tracking entries corrected by status --fix-tracking
+const (
+	TrackingDrifted = "drifted" // Entry's hash doesn't match the local file
+	TrackingMissing = "missing" // Local file from upstream has no entry
+	TrackingExtra   = "extra"   // Entry for a file that no longer exists locally
+	TrackingMode    = "mode"    // Local file's permissions differ from its file_modes entry
+)
+
+// TrackingFix is a correction of one entry in a path's tracking hashes
+type TrackingFix struct {
+	Include string // Path spec the entry belongs to
+	Path    string // Key in the path's tracking hashes
+	Kind    string
+	OldHash string // Empty for missing entries
+	NewHash string // Empty for extra entries
+	OldMode string // Tracked permissions, for mode entries
+	NewMode string // Local permissions, for mode entries
+}
+
+func (f TrackingFix) String() string {
+	switch f.Kind {
+	case TrackingMissing:
+		return fmt.Sprintf("%s %s: missing, now tracked as %s", f.Include, f.Path, ShortHash(f.NewHash))
+	case TrackingExtra:
+		return fmt.Sprintf("%s %s: no longer exists locally, entry removed", f.Include, f.Path)
+	case TrackingMode:
+		return fmt.Sprintf("%s %s: mode %s, tracked %s", f.Include, f.Path, f.NewMode, f.OldMode)
+	default:
+		return fmt.Sprintf("%s %s: drifted, %s -> %s", f.Include, f.Path, ShortHash(f.OldHash), ShortHash(f.NewHash))
+	}
+}
+
+// PlanTrackingFixes compares each path's tracking hashes with the local files and returns
+// the entries that would make them match. Entries are only added for files upstream has
+// at the path's recorded commit, read from the cached clone, so local additions stay
+// untracked; without a cached clone only existing entries are checked. Nothing is written.
+func PlanTrackingFixes(source *config.Source, options config.SyncOptions) []TrackingFix {
+	hasher := hash.NewFileHasherFor(options)
+	repo, repoErr := openCachedRepository(source)
+
+	var fixes []TrackingFix
+	for _, pathSpec := range source.Paths {
+		localPath := pathSpec.LocalRoot()
+		info, statErr := os.Stat(localPath)
+		isDir := pathSpec.IsPattern() || config.CanonicalPath(pathSpec.Include, true) == pathSpec.Include || (statErr == nil && info.IsDir())
+
+		excludes := config.Excludes{Patterns: pathSpec.Exclude}
+		if isDir {
+			excludes = options.PathExcludes(source, pathSpec)
+		}
+
+		candidates := make(map[string]bool)
+		for key := range pathSpec.Files {
+			candidates[key] = true
+		}
+		upstreamErr := repoErr
+		if repoErr == nil {
+			var upstream map[string]*object.File
+			upstream, upstreamErr = upstreamFiles(repo, pathSpec, isDir, excludes)
+			for key := range upstream {
+				candidates[key] = true
+			}
+		}
+		if upstreamErr != nil {
+			logger.Warning("⚠️  Can't list upstream files of %s (%v); only its existing entries are checked", pathSpec.Include, upstreamErr)
+		}
+
+		keys := make([]string, 0, len(candidates))
+		for key := range candidates {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			local := localPath
+			if isDir {
+				local = filepath.Join(localPath, key)
+			}
+			expected, tracked := pathSpec.Files[key]
+
+			if _, err := os.Lstat(local); os.IsNotExist(err) {
+				if tracked {
+					fixes = append(fixes, TrackingFix{Include: pathSpec.Include, Path: key, Kind: TrackingExtra, OldHash: expected})
+				}
+				continue
+			}
+
+			actual, matches, err := hasher.Matches(local, expected)
+			switch {
+			case err != nil:
+				logger.Warning("⚠️  Can't read %s, leaving its entry alone: %v", local, err)
+			case !tracked:
+				fixes = append(fixes, TrackingFix{Include: pathSpec.Include, Path: key, Kind: TrackingMissing, NewHash: actual})
+			case !matches:
+				fixes = append(fixes, TrackingFix{Include: pathSpec.Include, Path: key, Kind: TrackingDrifted, OldHash: expected, NewHash: actual})
+			}
+
+			if trackedMode, ok := pathSpec.FileModes[key]; ok {
+				if info, err := os.Stat(local); err == nil && config.FormatFileMode(info.Mode()) != trackedMode {
+					localMode := config.FormatFileMode(info.Mode())
+					fixes = append(fixes, TrackingFix{Include: pathSpec.Include, Path: key, Kind: TrackingMode, OldMode: trackedMode, NewMode: localMode})
+				}
+			}
+		}
+	}
+	return fixes
+}
+
+// ApplyTrackingFixes updates the source's tracking hashes with planned fixes
+func ApplyTrackingFixes(source *config.Source, fixes []TrackingFix) {
+	for _, fix := range fixes {
+		for i := range source.Paths {
+			pathSpec := &source.Paths[i]
+			if pathSpec.Include != fix.Include {
+				continue
+			}
+			switch fix.Kind {
+			case TrackingExtra:
+				delete(pathSpec.Files, fix.Path)
+				delete(pathSpec.FileModes, fix.Path)
+				continue
+			case TrackingMode:
+				pathSpec.FileModes[fix.Path] = fix.NewMode
+				continue
+			}
+			if pathSpec.Files == nil {
+				pathSpec.Files = make(map[string]string)
+			}
+			pathSpec.Files[fix.Path] = fix.NewHash
+		}
+	}
+}
+
+// PathDrift is how a path's local files differ from its tracking hashes
+type PathDrift struct {
+	Include   string
+	LocalRoot string
+	Files     []hash.FileConflict // Modified, deleted and added files, keyed like the tracking hashes
+}
+
+// VerifyTracking compares every path's local files with the hashes recorded by the last
+// sync, reading nothing but the project: no network, cache or snapshots. Files a tracked
+// directory doesn't track, and that its excludes don't skip, are reported as added. It
+// returns the paths that drifted, in config order.
+func VerifyTracking(source *config.Source, options config.SyncOptions) ([]PathDrift, error) {
+	hasher := hash.NewFileHasherFor(options)
+
+	var drifts []PathDrift
+	for _, pathSpec := range source.Paths {
+		localPath := pathSpec.LocalRoot()
+		info, statErr := os.Stat(localPath)
+		isDir := pathSpec.IsPattern() || config.CanonicalPath(pathSpec.Include, true) == pathSpec.Include || (statErr == nil && info.IsDir())
+
+		var files []hash.FileConflict
+		if isDir {
+			conflicts, err := hasher.VerifyFileIntegrity(localPath, pathSpec.Files, pathSpec.FileModes)
+			if err != nil {
+				return drifts, err
+			}
+			files = conflicts
+
+			if statErr == nil {
+				local, err := hasher.HashDirectory(localPath, options.PathExcludes(source, pathSpec))
+				if err != nil {
+					return drifts, err
+				}
+				for relPath, actual := range local {
+					key := filepath.ToSlash(relPath)
+					if _, tracked := pathSpec.Files[key]; !tracked {
+						files = append(files, hash.FileConflict{Path: key, Type: hash.ConflictTypeAdded, ActualHash: actual})
+					}
+				}
+			}
+		} else {
+			// A tracked file's only entry is keyed by its upstream name, which local_path may rename
+			for key, expected := range pathSpec.Files {
+				if os.IsNotExist(statErr) {
+					files = append(files, hash.FileConflict{Path: key, Type: hash.ConflictTypeDeleted, ExpectedHash: expected})
+					continue
+				}
+				actual, matches, err := hasher.Matches(localPath, expected)
+				if err != nil {
+					return drifts, err
+				}
+				if !matches {
+					files = append(files, hash.FileConflict{Path: key, Type: hash.ConflictTypeModified, ExpectedHash: expected, ActualHash: actual})
+					continue
+				}
+				if expectedMode, ok := pathSpec.FileModes[key]; ok && config.FormatFileMode(info.Mode()) != expectedMode {
+					files = append(files, hash.FileConflict{
+						Path:         key,
+						Type:         hash.ConflictTypeMode,
+						ExpectedHash: expected,
+						ActualHash:   actual,
+						ExpectedMode: expectedMode,
+						ActualMode:   config.FormatFileMode(info.Mode()),
+					})
+				}
+			}
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+		for i := range files {
+			files[i].LocalPath = localPath
+			if isDir {
+				files[i].LocalPath = filepath.Join(localPath, files[i].Path)
+			}
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+		drifts = append(drifts, PathDrift{Include: pathSpec.Include, LocalRoot: localPath, Files: files})
+	}
+	return drifts, nil
+}
+
+// AcceptDrift records the local state of drifted files as the tracked one: modified files
+// are tracked with their local hash, files whose permissions changed with their local
+// mode, and deleted files lose their entry. Added files stay
+// untracked, since tracking a file upstream doesn't have would make the next sync treat
+// it as removed upstream. It returns the number of entries changed.
+func AcceptDrift(source *config.Source, drifts []PathDrift) int {
+	changed := 0
+	for _, drift := range drifts {
+		for i := range source.Paths {
+			pathSpec := &source.Paths[i]
+			if pathSpec.Include != drift.Include {
+				continue
+			}
+			for _, file := range drift.Files {
+				switch file.Type {
+				case hash.ConflictTypeModified:
+					pathSpec.Files[file.Path] = file.ActualHash
+				case hash.ConflictTypeMode:
+					pathSpec.FileModes[file.Path] = file.ActualMode
+				case hash.ConflictTypeDeleted:
+					delete(pathSpec.Files, file.Path)
+					delete(pathSpec.FileModes, file.Path)
+				default:
+					continue
+				}
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+// RefreshBaseSnapshots rewrites the base-content snapshot of every path that has a recorded
+// commit with upstream's content at that commit, read from the cached clone. It returns
+// the includes whose snapshots were refreshed.
+func RefreshBaseSnapshots(source *config.Source, options config.SyncOptions) ([]string, error) {
+	repo, err := openCachedRepository(source)
+	if err != nil {
+		return nil, err
+	}
+	manager, err := cache.NewBaseContentManager()
+	if err != nil {
+		return nil, err
+	}
+
+	var refreshed []string
+	for _, pathSpec := range source.Paths {
+		isDir := pathSpec.IsPattern() || config.CanonicalPath(pathSpec.Include, true) == pathSpec.Include
+		excludes := config.Excludes{Patterns: pathSpec.Exclude}
+		if isDir {
+			excludes = options.PathExcludes(source, pathSpec)
+		}
+
+		upstream, err := upstreamFiles(repo, pathSpec, isDir, excludes)
+		if err != nil {
+			logger.Warning("⚠️  Not refreshing the snapshot of %s: %v", pathSpec.Include, err)
+			continue
+		}
+
+		files := make(map[string][]byte, len(upstream))
+		for key, file := range upstream {
+			content, err := file.Contents()
+			if err != nil {
+				return refreshed, fmt.Errorf("failed to read %s at %s: %w", file.Name, ShortHash(pathSpec.Commit), err)
+			}
+			files[key] = []byte(content)
+		}
+		if err := manager.SaveSnapshot(source.Name, pathSpec.Include, files); err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, pathSpec.Include)
+	}
+	return refreshed, nil
+}
+
+// openCachedRepository opens a source's existing cache clone without cloning or fetching
+func openCachedRepository(source *config.Source) (*git.Repository, error) {
+	if source.Ephemeral() {
+		return nil, fmt.Errorf("source '%s' is not cached (cache: ephemeral)", source.Name)
+	}
+	manager, err := cache.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	if !manager.RepositoryExists(source.Repository) {
+		return nil, fmt.Errorf("%s is not in the cache (run cherry-go cache warm %s)", source.Repository, source.Name)
+	}
+	return git.PlainOpen(manager.GetRepositoryPath(source.Repository))
+}
+
+// upstreamFiles lists a tracked path's files at its recorded commit, keyed as in its
+// tracking hashes: relative to the directory, or the base name for a single file.
+// Symbolic links are followed as sync does, so a linked include lists its target's files.
+func upstreamFiles(repo *git.Repository, pathSpec config.PathSpec, isDir bool, excludes config.Excludes) (map[string]*object.File, error) {
+	if pathSpec.Commit == "" {
+		return nil, fmt.Errorf("no synced commit recorded")
+	}
+	commit, err := repo.CommitObject(plumbing.NewHash(pathSpec.Commit))
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not in the cached clone: %w", ShortHash(pathSpec.Commit), err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	key := config.PathKey(pathSpec.SourceRoot())
+	target, err := resolveTreeLink(tree, key)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*object.File)
+	if !isDir {
+		file, err := tree.File(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s not found at %s", key, ShortHash(pathSpec.Commit))
+		}
+		files[path.Base(key)] = file
+		return files, nil
+	}
+
+	// A pattern at the repository root reads the whole tree
+	subtree := tree
+	if target != "" {
+		if subtree, err = tree.Tree(target); err != nil {
+			return nil, fmt.Errorf("%s not found at %s", key, ShortHash(pathSpec.Commit))
+		}
+	}
+	err = subtree.Files().ForEach(func(file *object.File) error {
+		relPath := filepath.FromSlash(file.Name)
+		if !shouldExclude(relPath, excludes) {
+			files[relPath] = file
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,201 @@
+package git
+
+import (
+	"os"
+	"testing"
+
+	"cherry-go/internal/cache"
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+// newTrackingFixture syncs lib/ (a.go, b.go, c.go) from an upstream repository with force
+func newTrackingFixture(t *testing.T) (*testutil.Project, *config.Source) {
+	t.Helper()
+	logger.Init()
+
+	upstream := testutil.NewFixtureRepo(t, "library")
+	upstream.WriteFile("lib/a.go", "package lib // a\n")
+	upstream.WriteFile("lib/b.go", "package lib // b\n")
+	upstream.WriteFile("lib/c.go", "package lib // c\n")
+	upstream.Commit("initial")
+
+	project := testutil.NewProject(t)
+	project.Chdir()
+
+	source := &config.Source{
+		Name:       "library",
+		Repository: upstream.URL(),
+		Paths:      []config.PathSpec{{Include: "lib/"}},
+	}
+	repo, err := NewRepository(source)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	if err := repo.Pull(); err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if _, err := repo.CopyPaths(SyncModeForce, project.Dir); err != nil {
+		t.Fatalf("CopyPaths failed: %v", err)
+	}
+	return project, source
+}
+
+func TestPlanTrackingFixes(t *testing.T) {
+	project, source := newTrackingFixture(t)
+	files := source.Paths[0].Files
+	bHash := files["b.go"]
+
+	project.WriteFile("lib/a.go", "package lib // edited\n")    // drifted
+	delete(files, "b.go")                                       // missing entry
+	if err := os.Remove(project.Path("lib/c.go")); err != nil { // extra entry
+		t.Fatalf("Failed to remove c.go: %v", err)
+	}
+	project.WriteFile("lib/local.go", "package lib // mine\n")
+	before := snapshotFiles(t, project, "lib/a.go", "lib/b.go", "lib/local.go")
+
+	fixes := PlanTrackingFixes(source, config.SyncOptions{})
+	kinds := make(map[string]string)
+	for _, fix := range fixes {
+		kinds[fix.Path] = fix.Kind
+	}
+	expected := map[string]string{"a.go": TrackingDrifted, "b.go": TrackingMissing, "c.go": TrackingExtra}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected fixes %v, got %v", expected, fixes)
+	}
+	for path, kind := range expected {
+		if kinds[path] != kind {
+			t.Errorf("Expected %s to be %s, got %q", path, kind, kinds[path])
+		}
+	}
+
+	ApplyTrackingFixes(source, fixes)
+	files = source.Paths[0].Files
+	if _, ok := files["c.go"]; ok {
+		t.Errorf("Expected the entry of the deleted c.go to be removed")
+	}
+	if _, ok := files["local.go"]; ok {
+		t.Errorf("Expected local-only files to stay untracked")
+	}
+	if files["b.go"] != bHash {
+		t.Errorf("Expected b.go to be tracked with its local hash %s, got %s", bHash, files["b.go"])
+	}
+	if fixes := PlanTrackingFixes(source, config.SyncOptions{}); len(fixes) != 0 {
+		t.Errorf("Expected no fixes after applying them, got %v", fixes)
+	}
+
+	after := snapshotFiles(t, project, "lib/a.go", "lib/b.go", "lib/local.go")
+	for path, content := range before {
+		if after[path] != content {
+			t.Errorf("Expected %s to be left untouched", path)
+		}
+	}
+}
+
+func TestPlanTrackingFixes_WithoutCache(t *testing.T) {
+	project, source := newTrackingFixture(t)
+	manager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if err := manager.RemoveRepository(source.Repository); err != nil {
+		t.Fatalf("Failed to remove cached clone: %v", err)
+	}
+
+	delete(source.Paths[0].Files, "b.go")
+	project.WriteFile("lib/a.go", "package lib // edited\n")
+
+	fixes := PlanTrackingFixes(source, config.SyncOptions{})
+	if len(fixes) != 1 || fixes[0].Path != "a.go" || fixes[0].Kind != TrackingDrifted {
+		t.Errorf("Expected only the existing a.go entry to be fixed without a cache, got %v", fixes)
+	}
+}
+
+func TestRefreshBaseSnapshots(t *testing.T) {
+	_, source := newTrackingFixture(t)
+	manager, err := cache.NewBaseContentManager()
+	if err != nil {
+		t.Fatalf("Failed to open snapshots: %v", err)
+	}
+	if err := manager.SaveSnapshot(source.Name, "lib/", map[string][]byte{"a.go": []byte("corrupt\n")}); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	refreshed, err := RefreshBaseSnapshots(source, config.SyncOptions{})
+	if err != nil {
+		t.Fatalf("RefreshBaseSnapshots failed: %v", err)
+	}
+	if len(refreshed) != 1 || refreshed[0] != "lib/" {
+		t.Fatalf("Expected lib/ to be refreshed, got %v", refreshed)
+	}
+
+	snapshot, err := manager.GetSnapshot(source.Name, "lib/")
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	if len(snapshot) != 3 || string(snapshot["a.go"]) != "package lib // a\n" {
+		t.Errorf("Expected the snapshot to hold upstream's content, got %v", snapshot)
+	}
+}
+
+// snapshotFiles reads project files, with "" for missing ones
+func snapshotFiles(t *testing.T, project *testutil.Project, paths ...string) map[string]string {
+	t.Helper()
+	contents := make(map[string]string)
+	for _, path := range paths {
+		if project.Exists(path) {
+			contents[path] = project.ReadFile(path)
+		}
+	}
+	return contents
+}
+
+func TestVerifyTracking(t *testing.T) {
+	project, source := newTrackingFixture(t)
+	source.Paths[0].Exclude = []string{"*.tmp"}
+	manager, err := cache.NewManager()
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	if err := manager.RemoveRepository(source.Repository); err != nil {
+		t.Fatalf("Failed to remove cached clone: %v", err)
+	}
+
+	if drifts, err := VerifyTracking(source, config.SyncOptions{}); err != nil || len(drifts) != 0 {
+		t.Fatalf("Expected no drift right after a sync, got %v, %v", drifts, err)
+	}
+
+	project.WriteFile("lib/a.go", "package lib // edited\n")
+	if err := os.Remove(project.Path("lib/c.go")); err != nil {
+		t.Fatalf("Failed to remove c.go: %v", err)
+	}
+	project.WriteFile("lib/local.go", "package lib // mine\n")
+	project.WriteFile("lib/scratch.tmp", "excluded\n")
+
+	drifts, err := VerifyTracking(source, config.SyncOptions{})
+	if err != nil {
+		t.Fatalf("VerifyTracking failed: %v", err)
+	}
+	if len(drifts) != 1 {
+		t.Fatalf("Expected lib/ to drift, got %v", drifts)
+	}
+	expected := map[string]hash.ConflictType{"a.go": hash.ConflictTypeModified, "c.go": hash.ConflictTypeDeleted, "local.go": hash.ConflictTypeAdded}
+	if len(drifts[0].Files) != len(expected) {
+		t.Fatalf("Expected drift %v, got %v", expected, drifts[0].Files)
+	}
+	for _, file := range drifts[0].Files {
+		if expected[file.Path] != file.Type {
+			t.Errorf("Expected %s to be %s, got %s", file.Path, expected[file.Path], file.Type)
+		}
+	}
+
+	if changed := AcceptDrift(source, drifts); changed != 2 {
+		t.Errorf("Expected 2 entries to change, got %d", changed)
+	}
+	drifts, _ = VerifyTracking(source, config.SyncOptions{})
+	if len(drifts) != 1 || len(drifts[0].Files) != 1 || drifts[0].Files[0].Path != "local.go" {
+		t.Errorf("Expected only the untracked local.go to remain, got %v", drifts)
+	}
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"golang.org/x/net/http/httpproxy"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+)
+
+// httpTransport holds the settings HTTP(S) repositories are reached with
+var httpTransport struct {
+	proxy   string // options.proxy; the environment's proxies apply when empty
+	baseDir string // Directory relative ca_cert paths are read from
+}
+
+// SetHTTPTransport sets the proxy HTTP(S) repositories are reached through (options.proxy,
+// or "" for HTTPS_PROXY and HTTP_PROXY) and the directory relative ca_cert paths are read
+// from, the project root
+func SetHTTPTransport(proxy, baseDir string) {
+	httpTransport.proxy = proxy
+	httpTransport.baseDir = baseDir
+}
+
+// transportOptions returns the extra CA certificates and the proxy a source's repository
+// is cloned and fetched with. Both are empty for SSH and local repositories.
+func transportOptions(source *config.Source) ([]byte, transport.ProxyOptions, error) {
+	var caBundle []byte
+	if source.CACert != "" {
+		path := source.CACert
+		if !filepath.IsAbs(path) && httpTransport.baseDir != "" {
+			path = filepath.Join(httpTransport.baseDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, transport.ProxyOptions{}, fmt.Errorf("failed to read ca_cert of source '%s': %w", source.Name, err)
+		}
+		caBundle = data
+	}
+
+	proxy, err := proxyFor(source.Repository, httpTransport.proxy, os.Getenv)
+	if err != nil {
+		return nil, transport.ProxyOptions{}, err
+	}
+	if proxy != "" {
+		logger.Debug("Reaching %s through proxy %s", source.Repository, redactProxy(proxy))
+	}
+	return caBundle, transport.ProxyOptions{URL: proxy}, nil
+}
+
+// proxyFor returns the proxy URL for an HTTP(S) repository, or "" to connect directly:
+// the configured proxy, else HTTPS_PROXY or HTTP_PROXY as the scheme asks. Hosts listed
+// in NO_PROXY, and localhost, are always reached directly. Lowercase variables work too.
+func proxyFor(repoURL, configured string, getenv func(string) string) (string, error) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", nil
+	}
+
+	env := func(name string) string {
+		if value := getenv(name); value != "" {
+			return value
+		}
+		return getenv(strings.ToLower(name))
+	}
+	proxies := httpproxy.Config{
+		HTTPProxy:  env("HTTP_PROXY"),
+		HTTPSProxy: env("HTTPS_PROXY"),
+		NoProxy:    env("NO_PROXY"),
+	}
+	if configured != "" {
+		proxies.HTTPProxy, proxies.HTTPSProxy = configured, configured
+	}
+
+	proxy, err := proxies.ProxyFunc()(parsed)
+	if err != nil {
+		return "", fmt.Errorf("invalid proxy for %s: %w", parsed.Host, err)
+	}
+	if proxy == nil {
+		return "", nil
+	}
+	return proxy.String(), nil
+}
+
+// redactProxy hides a proxy URL's password for logging
+func redactProxy(proxy string) string {
+	parsed, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return parsed.Redacted()
+}
+
+// withCACertHint adds a hint about ca_cert to errors from verifying an HTTPS server's
+// certificate, which an internal CA the system doesn't trust causes
+func withCACertHint(err error, source *config.Source) error {
+	if err == nil || !isCertificateError(err) {
+		return err
+	}
+	if source.CACert != "" {
+		return fmt.Errorf("%w (the server's certificate isn't signed by a CA in ca_cert %s or the system pool)", err, source.CACert)
+	}
+	return fmt.Errorf("%w (if the server uses an internal CA, set ca_cert on source '%s' to the CA's PEM certificate)", err, source.Name)
+}
+
+// isCertificateError reports whether err comes from TLS certificate verification. go-git
+// doesn't always wrap the underlying error, so the message is checked too.
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var verification *tls.CertificateVerificationError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname) || errors.As(err, &verification) {
+		return true
+	}
+	return strings.Contains(err.Error(), "x509: ")
+}
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestProxyFor(t *testing.T) {
+	env := map[string]string{
+		"HTTPS_PROXY": "http://secure-proxy:3128",
+		"http_proxy":  "http://plain-proxy:3128",
+		"NO_PROXY":    "git.internal,.corp.example.com",
+	}
+	getenv := func(name string) string { return env[name] }
+
+	testCases := []struct {
+		name       string
+		repoURL    string
+		configured string
+		expected   string
+	}{
+		{"https from the environment", "https://github.com/user/repo.git", "", "http://secure-proxy:3128"},
+		{"lowercase http from the environment", "http://github.com/user/repo.git", "", "http://plain-proxy:3128"},
+		{"configured proxy wins", "https://github.com/user/repo.git", "http://configured:8080", "http://configured:8080"},
+		{"NO_PROXY host", "https://git.internal/team/repo.git", "", ""},
+		{"NO_PROXY domain", "https://gitlab.corp.example.com/repo.git", "http://configured:8080", ""},
+		{"localhost", "https://127.0.0.1:8443/repo.git", "", ""},
+		{"ssh", "git@github.com:user/repo.git", "http://configured:8080", ""},
+		{"local clone", "file:///srv/git/repo.git", "http://configured:8080", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := proxyFor(tc.repoURL, tc.configured, getenv)
+			if err != nil {
+				t.Fatalf("proxyFor(%q) failed: %v", tc.repoURL, err)
+			}
+			if got != tc.expected {
+				t.Errorf("proxyFor(%q, %q) = %q, expected %q", tc.repoURL, tc.configured, got, tc.expected)
+			}
+		})
+	}
+}
+
+// newHTTPSRemote serves a fixture repository over smart HTTP behind a self-signed
+// certificate, returning its URL and the certificate in PEM form
+func newHTTPSRemote(t *testing.T, fixture *testutil.FixtureRepo) (string, []byte) {
+	t.Helper()
+	execPath, err := exec.Command("git", "--exec-path").Output()
+	if err != nil {
+		t.Skip("git exec path unavailable, skipping HTTPS test")
+	}
+	backend := filepath.Join(strings.TrimSpace(string(execPath)), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git-http-backend unavailable, skipping HTTPS test")
+	}
+
+	server := httptest.NewUnstartedServer(&cgi.Handler{
+		Path: backend,
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(fixture.BareDir),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	})
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server.URL + "/" + filepath.Base(fixture.BareDir), certificate
+}
+
+// selfSignedCertificate returns a PEM certificate unrelated to the test server's
+func selfSignedCertificate(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCloneRepository_CACert(t *testing.T) {
+	logger.Init()
+	fixture := testutil.NewFixtureRepo(t, "internal")
+	fixture.WriteFile("README.md", "internal\n")
+	fixture.Commit("initial")
+	repoURL, certificate := newHTTPSRemote(t, fixture)
+
+	certDir := t.TempDir()
+	SetHTTPTransport("", certDir)
+	t.Cleanup(func() { SetHTTPTransport("", "") })
+	if err := os.WriteFile(filepath.Join(certDir, "internal-ca.pem"), certificate, 0644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(certDir, "other-ca.pem"), selfSignedCertificate(t), 0644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+
+	// Without ca_cert the self-signed certificate is rejected, with a hint
+	source := &config.Source{Name: "internal", Repository: repoURL}
+	_, err := cloneRepository(context.Background(), source, filepath.Join(t.TempDir(), "clone"))
+	if err == nil || !strings.Contains(err.Error(), "set ca_cert on source 'internal'") {
+		t.Fatalf("Expected a certificate error hinting at ca_cert, got: %v", err)
+	}
+
+	// A ca_cert that doesn't sign the server's certificate points at the file
+	source.CACert = "other-ca.pem"
+	_, err = cloneRepository(context.Background(), source, filepath.Join(t.TempDir(), "clone"))
+	if err == nil || !strings.Contains(err.Error(), "isn't signed by a CA in ca_cert other-ca.pem") {
+		t.Fatalf("Expected a certificate error naming the ca_cert, got: %v", err)
+	}
+
+	// The right ca_cert, relative to the project root, is trusted for clone and fetch
+	source.CACert = "internal-ca.pem"
+	repoPath := filepath.Join(t.TempDir(), "clone")
+	cloned, err := cloneRepository(context.Background(), source, repoPath)
+	if err != nil {
+		t.Fatalf("Expected the clone to trust ca_cert, got: %v", err)
+	}
+	fixture.WriteFile("README.md", "internal v2\n")
+	fixture.Commit("update")
+	repo := &Repository{source: source, repo: cloned, path: repoPath}
+	if err := repo.Fetch(context.Background()); err != nil {
+		t.Fatalf("Expected the fetch to trust ca_cert, got: %v", err)
+	}
+
+	source.CACert = "missing.pem"
+	if _, err := cloneRepository(context.Background(), source, filepath.Join(t.TempDir(), "clone")); err == nil || !strings.Contains(err.Error(), "failed to read ca_cert") {
+		t.Errorf("Expected a missing ca_cert to be reported, got: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+)
+
+// findUntracked returns local files inside a managed directory that are neither tracked
+// from a previous sync nor present upstream, skipping excluded paths. The findings are
+// ConflictTypeAdded entries whose Path is the local path, sorted.
+func findUntracked(localPath, sourcePath string, tracked map[string]string, excludes config.Excludes, hasher *hash.FileHasher) []hash.FileConflict {
+	var findings []hash.FileConflict
+
+	_ = filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil || shouldExclude(relPath, excludes) {
+			return nil
+		}
+		if _, ok := tracked[relPath]; ok {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(sourcePath, relPath)); err == nil {
+			return nil // Comes from upstream, just not synced yet
+		}
+
+		actual, err := hasher.HashEntry(path)
+		if err != nil {
+			return nil
+		}
+		findings = append(findings, hash.FileConflict{
+			Path:       path,
+			Type:       hash.ConflictTypeAdded,
+			ActualHash: actual,
+		})
+		return nil
+	})
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Path < findings[j].Path })
+	return findings
+}
+
+// checkUntracked applies options.untracked to a managed directory. It returns the
+// findings to report and whether syncing the directory may go ahead.
+func (r *Repository) checkUntracked(pathSpec config.PathSpec, sourcePath, localPath string, hasher *hash.FileHasher) ([]hash.FileConflict, bool) {
+	policy := r.options.UntrackedPolicy()
+	if policy == config.UntrackedIgnore {
+		return nil, true
+	}
+
+	findings := findUntracked(localPath, sourcePath, pathSpec.Files, pathSpec.Excludes, hasher)
+	if len(findings) == 0 {
+		return nil, true
+	}
+	for i := range findings {
+		findings[i].Path = r.projectPath(findings[i].Path)
+	}
+	localPath = r.projectPath(localPath)
+
+	if policy == config.UntrackedError {
+		logger.Error("Untracked local files in %s (options.untracked: error) - not syncing it:", localPath)
+		for _, finding := range findings {
+			logger.Error("  - %s", finding.String())
+		}
+		return findings, false
+	}
+
+	logger.Warning("⚠️  Untracked local files in %s (not from upstream, left in place):", localPath)
+	for _, finding := range findings {
+		logger.Warning("  - %s", finding.String())
+	}
+	return findings, true
+}
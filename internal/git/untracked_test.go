@@ -0,0 +1,140 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+	"cherry-go/internal/logger"
+	"cherry-go/internal/testutil"
+)
+
+func TestFindUntracked(t *testing.T) {
+	source := t.TempDir()
+	local := t.TempDir()
+
+	write := func(dir, rel string) {
+		t.Helper()
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(source, "a.go")
+	write(source, "new/upstream.go")
+	write(local, "a.go")               // tracked
+	write(local, "gone.go")            // tracked, deleted upstream
+	write(local, "new/upstream.go")    // upstream but not yet tracked
+	write(local, "notes.tmp")          // excluded
+	write(local, "extra.go")           // untracked
+	write(local, "nested/dir/more.go") // untracked
+
+	tracked := map[string]string{"a.go": "h1", "gone.go": "h2"}
+	findings := findUntracked(local, source, tracked, config.Excludes{Patterns: []string{"*.tmp"}}, hash.NewFileHasher())
+
+	expected := []string{filepath.Join(local, "extra.go"), filepath.Join(local, "nested", "dir", "more.go")}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d untracked files, got %+v", len(expected), findings)
+	}
+	for i, finding := range findings {
+		if finding.Path != expected[i] {
+			t.Errorf("Finding %d: expected %s, got %s", i, expected[i], finding.Path)
+		}
+		if finding.Type != hash.ConflictTypeAdded || finding.ActualHash == "" {
+			t.Errorf("Expected an added finding with a hash, got %+v", finding)
+		}
+	}
+}
+
+func TestCopyPaths_UntrackedPolicies(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           string
+		mode             SyncMode
+		expectedFindings int
+		expectedUpdated  bool
+		expectedUpstream string
+	}{
+		{"default merge", "", SyncModeMerge, 1, true, "a v2\n"},
+		{"report detect", config.UntrackedReport, SyncModeDetect, 1, false, "a v1\n"},
+		{"ignore merge", config.UntrackedIgnore, SyncModeMerge, 0, true, "a v2\n"},
+		{"error merge", config.UntrackedError, SyncModeMerge, 1, false, "a v1\n"},
+		{"report force", config.UntrackedReport, SyncModeForce, 1, true, "a v2\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			logger.Init()
+
+			upstream := testutil.NewFixtureRepo(t, "untracked")
+			upstream.WriteFile("lib/a.go", "a v1\n")
+			upstream.Commit("initial")
+
+			project := testutil.NewProject(t)
+			project.Chdir()
+
+			source := &config.Source{
+				Name:       "untracked",
+				Repository: upstream.URL(),
+				Paths:      []config.PathSpec{{Include: "lib/"}},
+			}
+			options := config.SyncOptions{Untracked: tc.policy}
+
+			sync := func(mode SyncMode) *CopyResult {
+				t.Helper()
+				repo, err := NewRepository(source)
+				if err != nil {
+					t.Fatalf("Failed to open repository: %v", err)
+				}
+				repo.SetSyncOptions(options)
+				if err := repo.Pull(); err != nil {
+					t.Fatalf("Failed to pull: %v", err)
+				}
+				result, err := repo.CopyPaths(mode, project.Dir)
+				if err != nil {
+					t.Fatalf("CopyPaths failed: %v", err)
+				}
+				return result
+			}
+
+			sync(SyncModeMerge)
+			project.WriteFile("lib/local_helper.go", "local\n")
+			project.Commit("local helper")
+
+			upstream.WriteFile("lib/a.go", "a v2\n")
+			upstream.Commit("update a")
+
+			result := sync(tc.mode)
+
+			if len(result.Untracked) != tc.expectedFindings {
+				t.Fatalf("Expected %d untracked findings, got %+v", tc.expectedFindings, result.Untracked)
+			}
+			if tc.expectedFindings > 0 {
+				finding := result.Untracked[0]
+				if finding.Path != filepath.Join("lib", "local_helper.go") || finding.Type != hash.ConflictTypeAdded {
+					t.Errorf("Unexpected finding: %+v", finding)
+				}
+			}
+
+			if updated := len(result.UpdatedPaths) > 0; updated != tc.expectedUpdated {
+				t.Errorf("Expected updated=%t, got paths %v", tc.expectedUpdated, result.UpdatedPaths)
+			}
+			if got := project.ReadFile("lib/a.go"); got != tc.expectedUpstream {
+				t.Errorf("Expected lib/a.go to be %q, got %q", tc.expectedUpstream, got)
+			}
+			// No mode or policy deletes local additions
+			if !project.Exists("lib/local_helper.go") {
+				t.Error("Expected the untracked local file to be left in place")
+			}
+			if _, tracked := source.Paths[0].Files["local_helper.go"]; tracked {
+				t.Error("Expected the untracked file not to become tracked")
+			}
+		})
+	}
+}
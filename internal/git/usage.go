@@ -0,0 +1,54 @@
+package git
+
+import (
+	"os"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/hash"
+)
+
+// PathUsage is the disk space the managed files of a tracked path take in the project
+type PathUsage struct {
+	Include   string
+	LocalPath string
+	Files     int
+	Bytes     int64
+	Missing   bool // Nothing exists at the local path, e.g. before the first sync
+}
+
+// MeasureUsage counts the files and bytes of every path of a source as they are on disk,
+// in config order, reading nothing but the project. Directories and patterns count the
+// files a sync manages, walked and excluded as they are hashed, so local files that the
+// path's excludes, options.exclude or its pattern leave out aren't counted.
+func MeasureUsage(source *config.Source, options config.SyncOptions) ([]PathUsage, error) {
+	hasher := hash.NewFileHasherFor(options)
+
+	usage := make([]PathUsage, 0, len(source.Paths))
+	for _, pathSpec := range source.Paths {
+		entry := PathUsage{Include: pathSpec.Include, LocalPath: pathSpec.LocalRoot()}
+		info, err := os.Lstat(entry.LocalPath)
+		if os.IsNotExist(err) {
+			entry.Missing = true
+			usage = append(usage, entry)
+			continue
+		}
+		if err != nil {
+			return usage, err
+		}
+
+		if info.IsDir() {
+			files, err := hasher.ScanDirectory(entry.LocalPath, options.PathExcludes(source, pathSpec), false)
+			if err != nil {
+				return usage, err
+			}
+			for _, file := range files {
+				entry.Files++
+				entry.Bytes += file.Size
+			}
+		} else {
+			entry.Files, entry.Bytes = 1, info.Size()
+		}
+		usage = append(usage, entry)
+	}
+	return usage, nil
+}
@@ -6,45 +6,163 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
+
+	"cherry-go/internal/config"
+	"cherry-go/internal/merge"
+	"cherry-go/internal/utils"
 )
 
 // FileHasher handles file hashing operations
-type FileHasher struct{}
+type FileHasher struct {
+	// IgnoreTrailingNewline hashes content lacking a final newline as if it had one, so
+	// files that only differ by a final newline hash the same
+	IgnoreTrailingNewline bool
+
+	// NormalizeLineEndings hashes text files with CRLF line endings as if they had LF ones,
+	// so checkouts with either ending hash the same
+	NormalizeLineEndings bool
+
+	// FollowSymlinks hashes what symbolic links inside directories point to instead of
+	// their targets (see HashEntry)
+	FollowSymlinks bool
+}
 
 // NewFileHasher creates a new file hasher
 func NewFileHasher() *FileHasher {
 	return &FileHasher{}
 }
 
+// NewFileHasherFor creates a file hasher that hashes the way the sync options compare
+func NewFileHasherFor(options config.SyncOptions) *FileHasher {
+	return &FileHasher{
+		IgnoreTrailingNewline: options.IgnoreTrailingNewlineEnabled(),
+		NormalizeLineEndings:  options.EOLPolicy() != "",
+		FollowSymlinks:        options.FollowSymlinks,
+	}
+}
+
 // HashFile calculates SHA256 hash of a file
 func (fh *FileHasher) HashFile(filePath string) (string, error) {
+	sum, _, err := fh.hashFile(filePath)
+	return sum, err
+}
+
+// Matches hashes a file and reports whether it matches the expected hash. When a final
+// newline was added or line endings normalized before hashing, the hash of the file as
+// it is on disk matches too, since tracking entries recorded without normalization look
+// like that. Symbolic links are hashed as HashEntry does.
+func (fh *FileHasher) Matches(filePath, expected string) (actual string, matches bool, err error) {
+	if !fh.FollowSymlinks {
+		if target, isLink, err := ReadLink(filePath); err != nil {
+			return "", false, err
+		} else if isLink {
+			actual = fh.HashBytes([]byte(target))
+			return actual, actual == expected, nil
+		}
+	}
+	actual, raw, err := fh.hashFile(filePath)
+	if err != nil {
+		return "", false, err
+	}
+	return actual, actual == expected || (raw != "" && raw == expected), nil
+}
+
+// hashFile returns the file's hash and, when the hasher added a final newline or
+// normalized line endings, the hash of the file as it is on disk
+func (fh *FileHasher) hashFile(filePath string) (sum, raw string, err error) {
+	if fh.NormalizeLineEndings {
+		// Line endings are only told apart from the whole content (binary files keep theirs)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open file %s: %w", filePath, err)
+		}
+		sum = fh.HashBytes(content)
+		if rawSum := fmt.Sprintf("%x", sha256.Sum256(content)); rawSum != sum {
+			raw = rawSum
+		}
+		return sum, raw, nil
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file %s: %w", filePath, err)
+		return "", "", fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer func() { _ = file.Close() }()
 
 	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
-		return "", fmt.Errorf("failed to hash file %s: %w", filePath, err)
+	if !fh.IgnoreTrailingNewline {
+		if _, err := io.Copy(hasher, file); err != nil {
+			return "", "", fmt.Errorf("failed to hash file %s: %w", filePath, err)
+		}
+		return fmt.Sprintf("%x", hasher.Sum(nil)), "", nil
+	}
+
+	rawHasher := sha256.New()
+	last := &lastByte{}
+	if _, err := io.Copy(io.MultiWriter(hasher, rawHasher, last), file); err != nil {
+		return "", "", fmt.Errorf("failed to hash file %s: %w", filePath, err)
+	}
+	if !last.seen || last.b == '\n' {
+		return fmt.Sprintf("%x", hasher.Sum(nil)), "", nil
 	}
+	hasher.Write([]byte{'\n'})
+	return fmt.Sprintf("%x", hasher.Sum(nil)), fmt.Sprintf("%x", rawHasher.Sum(nil)), nil
+}
 
-	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+// lastByte remembers the last byte written to it
+type lastByte struct {
+	b    byte
+	seen bool
+}
+
+func (l *lastByte) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		l.b, l.seen = p[len(p)-1], true
+	}
+	return len(p), nil
 }
 
 // HashBytes calculates SHA256 hash of byte content
 func (fh *FileHasher) HashBytes(content []byte) string {
+	if fh.NormalizeLineEndings {
+		content = merge.NormalizeLineEndings(content)
+	}
+	if fh.IgnoreTrailingNewline {
+		content = merge.EnsureTrailingNewline(content)
+	}
 	hasher := sha256.New()
 	hasher.Write(content)
 	return fmt.Sprintf("%x", hasher.Sum(nil))
 }
 
 // HashDirectory calculates hashes for all files in a directory
-func (fh *FileHasher) HashDirectory(dirPath string, excludes []string) (map[string]string, error) {
-	hashes := make(map[string]string)
+func (fh *FileHasher) HashDirectory(dirPath string, excludes config.Excludes) (map[string]string, error) {
+	entries, err := fh.ScanDirectory(dirPath, excludes, true)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(entries))
+	for relPath, entry := range entries {
+		hashes[relPath] = entry.Hash
+	}
+	return hashes, nil
+}
+
+// FileEntry is a file found by ScanDirectory
+type FileEntry struct {
+	Hash string // Empty unless the scan hashed files
+	Size int64
+}
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+// ScanDirectory walks a directory the way HashDirectory does, skipping the same excluded
+// files, and returns the size of each file and, with withHashes, its hash, keyed by path
+// relative to the directory. Callers that need both read them from one walk. Symbolic
+// links are files of their own, sized and hashed by their target, unless FollowSymlinks.
+func (fh *FileHasher) ScanDirectory(dirPath string, excludes config.Excludes, withHashes bool) (map[string]FileEntry, error) {
+	entries := make(map[string]FileEntry)
+
+	err := Walk(dirPath, fh.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -65,34 +183,29 @@ func (fh *FileHasher) HashDirectory(dirPath string, excludes []string) (map[stri
 			return nil
 		}
 
-		// Calculate hash
-		hash, err := fh.HashFile(path)
-		if err != nil {
-			return err
+		entry := FileEntry{Size: info.Size()}
+		if withHashes {
+			hash, err := fh.HashEntry(path)
+			if err != nil {
+				return err
+			}
+			entry.Hash = hash
 		}
 
-		hashes[relPath] = hash
+		entries[relPath] = entry
 		return nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash directory %s: %w", dirPath, err)
+		return nil, fmt.Errorf("failed to scan directory %s: %w", dirPath, err)
 	}
 
-	return hashes, nil
+	return entries, nil
 }
 
-// shouldExclude checks if a file should be excluded based on patterns
-func (fh *FileHasher) shouldExclude(path string, excludes []string) bool {
-	for _, exclude := range excludes {
-		if matched, _ := filepath.Match(exclude, filepath.Base(path)); matched {
-			return true
-		}
-		if strings.Contains(path, exclude) {
-			return true
-		}
-	}
-	return false
+// shouldExclude checks if a file should be excluded based on gitignore-style patterns
+func (fh *FileHasher) shouldExclude(path string, excludes config.Excludes) bool {
+	return config.IsExcluded(path, excludes)
 }
 
 // CompareHashes compares two hash maps and returns differences
@@ -118,13 +231,14 @@ func (fh *FileHasher) CompareHashes(oldHashes, newHashes map[string]string) (mod
 	return modified, added, removed
 }
 
-// VerifyFileIntegrity checks if local files match expected hashes
-func (fh *FileHasher) VerifyFileIntegrity(baseDir string, expectedHashes map[string]string) (conflicts []FileConflict, err error) {
+// VerifyFileIntegrity checks if local files match expected hashes, and files whose content
+// matches the permissions in expectedModes (may be nil), to catch permission-only changes
+func (fh *FileHasher) VerifyFileIntegrity(baseDir string, expectedHashes, expectedModes map[string]string) (conflicts []FileConflict, err error) {
 	for relPath, expectedHash := range expectedHashes {
 		fullPath := filepath.Join(baseDir, relPath)
 
-		// Check if file exists
-		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		// Check if file exists (a dangling symbolic link still does)
+		if _, err := os.Lstat(fullPath); os.IsNotExist(err) {
 			conflicts = append(conflicts, FileConflict{
 				Path:         relPath,
 				Type:         ConflictTypeDeleted,
@@ -134,20 +248,32 @@ func (fh *FileHasher) VerifyFileIntegrity(baseDir string, expectedHashes map[str
 			continue
 		}
 
-		// Calculate current hash
-		actualHash, err := fh.HashFile(fullPath)
+		// Calculate current hash and compare
+		actualHash, matches, err := fh.Matches(fullPath, expectedHash)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash file %s: %w", fullPath, err)
 		}
-
-		// Compare hashes
-		if actualHash != expectedHash {
+		if !matches {
 			conflicts = append(conflicts, FileConflict{
 				Path:         relPath,
 				Type:         ConflictTypeModified,
 				ExpectedHash: expectedHash,
 				ActualHash:   actualHash,
 			})
+			continue
+		}
+
+		if expectedMode, ok := expectedModes[relPath]; ok {
+			if info, err := os.Stat(fullPath); err == nil && config.FormatFileMode(info.Mode()) != expectedMode {
+				conflicts = append(conflicts, FileConflict{
+					Path:         relPath,
+					Type:         ConflictTypeMode,
+					ExpectedHash: expectedHash,
+					ActualHash:   actualHash,
+					ExpectedMode: expectedMode,
+					ActualMode:   config.FormatFileMode(info.Mode()),
+				})
+			}
 		}
 	}
 
@@ -161,26 +287,64 @@ const (
 	ConflictTypeModified ConflictType = "modified"
 	ConflictTypeDeleted  ConflictType = "deleted"
 	ConflictTypeAdded    ConflictType = "added"
+	ConflictTypeKind     ConflictType = "kind" // File upstream but directory locally, or the reverse
+	ConflictTypeMode     ConflictType = "mode" // Same content, different permissions
+
+	ConflictTypeRemovedUpstream ConflictType = "removed-upstream" // Tracked file deleted upstream, still present locally
 )
 
 // FileConflict represents a conflict between expected and actual file state
 type FileConflict struct {
-	Path         string
+	Path         string // Relative to the tracked directory, or the file name for a tracked file
+	LocalPath    string // Relative to the working directory, set when a sync collects the conflict
 	Type         ConflictType
 	ExpectedHash string
 	ActualHash   string
+	ExpectedMode string               // Tracked permissions of a ConflictTypeMode file, e.g. "0755"
+	ActualMode   string               // Its local permissions
+	Hunks        []merge.ConflictHunk // Conflicting hunks when a three-way merge was attempted
+	Upstream     *UpstreamCommit      // Newest upstream commit since the last sync that changed the file, when found
+}
+
+// UpstreamCommit is the upstream commit a conflict is attributed to
+type UpstreamCommit struct {
+	Hash    string
+	Author  string
+	When    time.Time
+	Subject string
+}
+
+// shortHash abbreviates a hash for display, tolerating short or missing ones
+func shortHash(h string) string {
+	if h == "" {
+		return "none"
+	}
+	if len(h) > 8 {
+		return h[:8]
+	}
+	return h
 }
 
 // String returns a human-readable description of the conflict
 func (fc FileConflict) String() string {
+	path := utils.DisplayPath(fc.Path)
 	switch fc.Type {
 	case ConflictTypeModified:
-		return fmt.Sprintf("Modified: %s (expected: %s, actual: %s)", fc.Path, fc.ExpectedHash[:8], fc.ActualHash[:8])
+		return fmt.Sprintf("Modified: %s (expected: %s, actual: %s)", path, shortHash(fc.ExpectedHash), shortHash(fc.ActualHash))
 	case ConflictTypeDeleted:
-		return fmt.Sprintf("Deleted: %s (expected: %s)", fc.Path, fc.ExpectedHash[:8])
+		return fmt.Sprintf("Deleted: %s (expected: %s)", path, shortHash(fc.ExpectedHash))
 	case ConflictTypeAdded:
-		return fmt.Sprintf("Added: %s (actual: %s)", fc.Path, fc.ActualHash[:8])
+		return fmt.Sprintf("Added: %s (actual: %s)", path, shortHash(fc.ActualHash))
+	case ConflictTypeKind:
+		return fmt.Sprintf("Kind changed: %s (file upstream vs directory locally, or the reverse)", path)
+	case ConflictTypeMode:
+		return fmt.Sprintf("Mode changed: %s (expected: %s, actual: %s)", path, fc.ExpectedMode, fc.ActualMode)
+	case ConflictTypeRemovedUpstream:
+		if fc.ActualHash != fc.ExpectedHash {
+			return fmt.Sprintf("Removed upstream: %s (locally modified, expected: %s, actual: %s)", path, shortHash(fc.ExpectedHash), shortHash(fc.ActualHash))
+		}
+		return fmt.Sprintf("Removed upstream: %s", path)
 	default:
-		return fmt.Sprintf("Unknown conflict: %s", fc.Path)
+		return fmt.Sprintf("Unknown conflict: %s", path)
 	}
 }
@@ -3,7 +3,11 @@ package hash
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
+
+	"cherry-go/internal/config"
 )
 
 func TestHashFile(t *testing.T) {
@@ -71,7 +75,7 @@ func TestHashDirectory(t *testing.T) {
 	}
 
 	hasher := NewFileHasher()
-	excludes := []string{"*.tmp"}
+	excludes := config.Excludes{Patterns: []string{"*.tmp"}}
 
 	hashes, err := hasher.HashDirectory(tmpDir, excludes)
 	if err != nil {
@@ -99,6 +103,53 @@ func TestHashDirectory(t *testing.T) {
 	}
 }
 
+func TestScanDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	for filePath, content := range map[string]string{
+		"a.txt":         "12345",
+		"build/out.bin": "123",
+		"sub/b.txt":     "1234567890",
+	} {
+		fullPath := filepath.Join(tmpDir, filePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", filePath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", filePath, err)
+		}
+	}
+
+	hasher := NewFileHasher()
+	excludes := config.Excludes{Patterns: []string{"build/"}}
+
+	sizes, err := hasher.ScanDirectory(tmpDir, excludes, false)
+	if err != nil {
+		t.Fatalf("Failed to scan directory: %v", err)
+	}
+	expected := map[string]FileEntry{"a.txt": {Size: 5}, filepath.Join("sub", "b.txt"): {Size: 10}}
+	if !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("Expected sizes %v without hashes, got %v", expected, sizes)
+	}
+
+	// With hashes, the same files as HashDirectory
+	entries, err := hasher.ScanDirectory(tmpDir, excludes, true)
+	if err != nil {
+		t.Fatalf("Failed to scan directory: %v", err)
+	}
+	hashes, err := hasher.HashDirectory(tmpDir, excludes)
+	if err != nil {
+		t.Fatalf("Failed to hash directory: %v", err)
+	}
+	if len(entries) != len(hashes) {
+		t.Fatalf("Expected %d entries, got %d", len(hashes), len(entries))
+	}
+	for relPath, entry := range entries {
+		if entry.Hash != hashes[relPath] || entry.Size != expected[relPath].Size {
+			t.Errorf("Unexpected entry for %s: %+v", relPath, entry)
+		}
+	}
+}
+
 func TestCompareHashes(t *testing.T) {
 	hasher := NewFileHasher()
 
@@ -159,7 +210,7 @@ func TestVerifyFileIntegrity(t *testing.T) {
 	}
 
 	// Test 1: No conflicts (file unchanged)
-	conflicts, err := hasher.VerifyFileIntegrity(tmpDir, expectedHashes)
+	conflicts, err := hasher.VerifyFileIntegrity(tmpDir, expectedHashes, nil)
 	if err != nil {
 		t.Fatalf("Failed to verify integrity: %v", err)
 	}
@@ -173,7 +224,7 @@ func TestVerifyFileIntegrity(t *testing.T) {
 		t.Fatalf("Failed to modify test file: %v", err)
 	}
 
-	conflicts, err = hasher.VerifyFileIntegrity(tmpDir, expectedHashes)
+	conflicts, err = hasher.VerifyFileIntegrity(tmpDir, expectedHashes, nil)
 	if err != nil {
 		t.Fatalf("Failed to verify integrity: %v", err)
 	}
@@ -189,7 +240,7 @@ func TestVerifyFileIntegrity(t *testing.T) {
 		t.Fatalf("Failed to remove test file: %v", removeErr)
 	}
 
-	conflicts, err = hasher.VerifyFileIntegrity(tmpDir, expectedHashes)
+	conflicts, err = hasher.VerifyFileIntegrity(tmpDir, expectedHashes, nil)
 	if err != nil {
 		t.Fatalf("Failed to verify integrity: %v", err)
 	}
@@ -201,6 +252,53 @@ func TestVerifyFileIntegrity(t *testing.T) {
 	}
 }
 
+func TestVerifyFileIntegrity_Mode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no executable permission bit")
+	}
+	tmpDir := t.TempDir()
+	script := filepath.Join(tmpDir, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatalf("Failed to set the mode: %v", err)
+	}
+
+	hasher := NewFileHasher()
+	scriptHash, err := hasher.HashFile(script)
+	if err != nil {
+		t.Fatalf("Failed to hash file: %v", err)
+	}
+	hashes := map[string]string{"run.sh": scriptHash}
+	modes := map[string]string{"run.sh": "0755"}
+
+	conflicts, err := hasher.VerifyFileIntegrity(tmpDir, hashes, modes)
+	if err != nil {
+		t.Fatalf("Failed to verify integrity: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got: %v", conflicts)
+	}
+
+	// Only the permissions change
+	if err := os.Chmod(script, 0644); err != nil {
+		t.Fatalf("Failed to set the mode: %v", err)
+	}
+	conflicts, err = hasher.VerifyFileIntegrity(tmpDir, hashes, modes)
+	if err != nil {
+		t.Fatalf("Failed to verify integrity: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Type != ConflictTypeMode || conflicts[0].ExpectedMode != "0755" || conflicts[0].ActualMode != "0644" {
+		t.Errorf("Expected a mode conflict from 0755 to 0644, got: %v", conflicts)
+	}
+
+	// Without tracked modes permissions aren't compared
+	if conflicts, _ := hasher.VerifyFileIntegrity(tmpDir, hashes, nil); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts without tracked modes, got: %v", conflicts)
+	}
+}
+
 func TestFileConflictString(t *testing.T) {
 	testCases := []struct {
 		conflict FileConflict
@@ -233,6 +331,15 @@ func TestFileConflictString(t *testing.T) {
 			},
 			"Added: new.txt (actual: 12345678)",
 		},
+		{
+			FileConflict{
+				Path:         "run.sh",
+				Type:         ConflictTypeMode,
+				ExpectedMode: "0755",
+				ActualMode:   "0644",
+			},
+			"Mode changed: run.sh (expected: 0755, actual: 0644)",
+		},
 	}
 
 	for i, tc := range testCases {
@@ -242,3 +349,102 @@ func TestFileConflictString(t *testing.T) {
 		}
 	}
 }
+
+func TestFileHasher_IgnoreTrailingNewline(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	withNewline := write("with.txt", "line\n")
+	without := write("without.txt", "line")
+
+	plain := NewFileHasher()
+	ignoring := &FileHasher{IgnoreTrailingNewline: true}
+
+	withHash, _ := plain.HashFile(withNewline)
+	rawWithout, _ := plain.HashFile(without)
+	if withHash == rawWithout {
+		t.Fatal("Expected different hashes without normalization")
+	}
+
+	normalized, err := ignoring.HashFile(without)
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if normalized != withHash {
+		t.Errorf("Expected a missing final newline to hash like the file with one")
+	}
+	if got, _ := ignoring.HashFile(withNewline); got != withHash {
+		t.Errorf("Expected files ending with a newline to hash as before")
+	}
+	if got := ignoring.HashBytes([]byte("line")); got != withHash {
+		t.Errorf("Expected HashBytes to normalize like HashFile")
+	}
+	if got := ignoring.HashBytes(nil); got != plain.HashBytes(nil) {
+		t.Errorf("Expected empty content to stay empty")
+	}
+
+	// Entries recorded before normalization still match
+	for _, expected := range []string{withHash, rawWithout} {
+		if _, matches, err := ignoring.Matches(without, expected); err != nil || !matches {
+			t.Errorf("Expected %s to match %.8s, got %t (%v)", without, expected, matches, err)
+		}
+	}
+	if _, matches, _ := plain.Matches(without, withHash); matches {
+		t.Error("Expected no match with the option off")
+	}
+
+	conflicts, err := ignoring.VerifyFileIntegrity(tmpDir, map[string]string{"without.txt": withHash, "with.txt": withHash}, nil)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", conflicts)
+	}
+}
+
+func TestFileHasher_NormalizeLineEndings(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	lf := write("lf.txt", "one\ntwo\n")
+	crlf := write("crlf.txt", "one\r\ntwo\r\n")
+	binary := write("binary.bin", "one\r\n\x00two\r\n")
+
+	plain := NewFileHasher()
+	normalizing := &FileHasher{NormalizeLineEndings: true, IgnoreTrailingNewline: true}
+
+	lfHash, _ := plain.HashFile(lf)
+	rawCRLF, _ := plain.HashFile(crlf)
+	if lfHash == rawCRLF {
+		t.Fatal("Expected different hashes without normalization")
+	}
+	if got, err := normalizing.HashFile(crlf); err != nil || got != lfHash {
+		t.Errorf("Expected a CRLF checkout to hash like LF, got %.8s (%v)", got, err)
+	}
+	if got := normalizing.HashBytes([]byte("one\r\ntwo")); got != lfHash {
+		t.Errorf("Expected HashBytes to normalize like HashFile")
+	}
+	if got, _ := normalizing.HashFile(binary); got != plain.HashBytes([]byte("one\r\n\x00two\r\n")) {
+		t.Errorf("Expected binary files to hash as they are")
+	}
+
+	// Entries recorded before normalization still match
+	for _, expected := range []string{lfHash, rawCRLF} {
+		if _, matches, err := normalizing.Matches(crlf, expected); err != nil || !matches {
+			t.Errorf("Expected %s to match %.8s, got %t (%v)", crlf, expected, matches, err)
+		}
+	}
+	if _, matches, _ := plain.Matches(crlf, lfHash); matches {
+		t.Error("Expected no match with the option off")
+	}
+}
@@ -0,0 +1,115 @@
+package hash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// IsSymlink reports whether info, from os.Lstat or a walk, is a symbolic link
+func IsSymlink(info os.FileInfo) bool {
+	return info != nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// ReadLink returns the target of a symbolic link as written, and whether path is one
+func ReadLink(path string) (target string, isLink bool, err error) {
+	info, err := os.Lstat(path)
+	if err != nil || !IsSymlink(info) {
+		return "", false, err
+	}
+	target, err = os.Readlink(path)
+	return target, true, err
+}
+
+// HashEntry hashes a file inside a tracked directory. Unless FollowSymlinks is set, a
+// symbolic link is hashed by its target as written, the way git stores it, rather than
+// by what it points to.
+func (fh *FileHasher) HashEntry(path string) (string, error) {
+	if !fh.FollowSymlinks {
+		if target, isLink, err := ReadLink(path); err != nil {
+			return "", err
+		} else if isLink {
+			return fh.HashBytes([]byte(target)), nil
+		}
+	}
+	return fh.HashFile(path)
+}
+
+// Walk walks a tracked directory like filepath.Walk, which reports symbolic links as
+// themselves and never descends into them. With follow, links are read through instead:
+// fn sees what they point to, and directories they point to are walked as if they were
+// there, except links back into a directory being walked. Dangling links are reported
+// as links either way.
+func Walk(root string, follow bool, fn filepath.WalkFunc) error {
+	if !follow {
+		return filepath.Walk(root, fn)
+	}
+
+	info, err := statFollowing(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkFollowing(root, info, make(map[string]bool), fn)
+	}
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// walkFollowing walks path for Walk, visiting holding the real directories being walked
+func walkFollowing(path string, info os.FileInfo, visiting map[string]bool, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	if visiting[real] {
+		return nil // A link cycle
+	}
+	if err := fn(path, info, nil); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := fn(path, info, err); err != nil && !errors.Is(err, filepath.SkipDir) {
+			return err
+		}
+		return nil
+	}
+
+	visiting[real] = true
+	defer delete(visiting, real)
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := statFollowing(child)
+		if err != nil {
+			err = fn(child, nil, err)
+		} else {
+			err = walkFollowing(child, childInfo, visiting, fn)
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, filepath.SkipDir):
+			if childInfo == nil || !childInfo.IsDir() {
+				return nil // Skip the rest of this directory, as filepath.Walk does
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// statFollowing stats path through symbolic links, or the link itself when it dangles
+func statFollowing(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return os.Lstat(path)
+	}
+	return info, nil
+}
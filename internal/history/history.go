@@ -0,0 +1,177 @@
+// Package history keeps the project's append-only log of sync runs and the last
+// successful sync of each source
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Dir and FileName place the log next to the project's configuration file
+const (
+	Dir      = ".cherry-go"
+	FileName = "history.jsonl"
+)
+
+// Source statuses recorded for a sync run
+const (
+	StatusUpdated   = "updated"    // Paths were synced
+	StatusUpToDate  = "up-to-date" // Nothing to change
+	StatusConflicts = "conflicts"  // Differences or merge conflicts were left for the user
+	StatusBranch    = "branch"     // Conflicts were written to a conflict branch
+	StatusSkipped   = "skipped"    // Optional source that couldn't be authenticated or cloned
+	StatusDuplicate = "duplicate"  // Selected again after it already synced in the same run
+	StatusFailed    = "failed"
+)
+
+// Entry is one sync run. Each is stored as a line of JSON; fields may be added but
+// existing ones keep their names.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"` // cherry-go version that ran the sync
+	Args    []string  `json:"args"`    // Command line, e.g. ["sync", "--all", "--force"]
+	Sources []Source  `json:"sources"`
+}
+
+// Source is the outcome of a sync run for one source
+type Source struct {
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	UpdatedPaths []string `json:"updated_paths,omitempty"`
+	Commit       string   `json:"commit,omitempty"` // Project commit created by auto_commit
+	Conflicts    int      `json:"conflicts"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// Path returns the history file of the project whose configuration is configFile
+func Path(configFile string) string {
+	return filepath.Join(filepath.Dir(configFile), Dir, FileName)
+}
+
+// Append adds an entry to the end of the history file, creating it if needed. The file
+// is locked while writing, so concurrent runs never interleave their records.
+func Append(path string, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, true); err != nil {
+		return fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries of the history file, oldest first, and how many lines could
+// not be parsed (say, cut short by a crash). A missing file is an empty history.
+func Read(path string) ([]Entry, int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, false); err != nil {
+		return nil, 0, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := make([]Entry, 0, len(lines))
+	malformed := 0
+	for _, line := range lines {
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			malformed++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, malformed, nil
+}
+
+// Prune keeps the newest keep entries of the history file and returns how many were
+// removed. The file is rewritten in place under the lock, so a run appending at the
+// same time waits and then adds its entry after the kept ones.
+func Prune(path string, keep int) (int, error) {
+	if keep < 0 {
+		return 0, fmt.Errorf("cannot keep %d entries", keep)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, true); err != nil {
+		return 0, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) <= keep {
+		return 0, nil
+	}
+	removed := len(lines) - keep
+
+	var kept bytes.Buffer
+	for _, line := range lines[removed:] {
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+	if err := file.Truncate(0); err != nil {
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+	if _, err := file.WriteAt(kept.Bytes(), 0); err != nil {
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+	return removed, nil
+}
+
+// readLines returns the non-empty lines of a history file
+func readLines(r io.Reader) ([][]byte, error) {
+	var lines [][]byte
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, append([]byte(nil), line...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return lines, nil
+}
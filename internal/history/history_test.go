@@ -0,0 +1,137 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEntry(i int) Entry {
+	return Entry{
+		Time:    time.Date(2026, 1, 1, 12, 0, i, 0, time.UTC),
+		Version: "1.2.0",
+		Args:    []string{"sync", "--all"},
+		Sources: []Source{{Name: fmt.Sprintf("source-%d", i), Status: StatusUpdated, UpdatedPaths: []string{"lib/"}, Commit: "abc123"}},
+	}
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), ".cherry-go.yaml"))
+
+	entries, malformed, err := Read(path)
+	if err != nil || len(entries) != 0 || malformed != 0 {
+		t.Fatalf("Expected a missing history to be empty, got %v, %d, %v", entries, malformed, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := Append(path, testEntry(i)); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	entries, malformed, err = Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 3 || malformed != 0 {
+		t.Fatalf("Expected 3 entries, got %d (%d malformed)", len(entries), malformed)
+	}
+	for i, entry := range entries {
+		if entry.Sources[0].Name != fmt.Sprintf("source-%d", i) || !entry.Time.Equal(testEntry(i).Time) {
+			t.Errorf("Entry %d out of order or changed: %+v", i, entry)
+		}
+	}
+}
+
+func TestRead_SkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := Append(path, testEntry(0)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString(`{"time": "2026-01-01T12:00:01Z", "sour` + "\n")
+	_ = file.Close()
+	if err := Append(path, testEntry(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, malformed, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 2 || malformed != 1 {
+		t.Errorf("Expected 2 entries and 1 malformed line, got %d and %d", len(entries), malformed)
+	}
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Append(path, testEntry(i)); err != nil {
+				t.Errorf("Append failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, malformed, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(entries) != 20 || malformed != 0 {
+		t.Errorf("Expected 20 whole entries, got %d (%d malformed)", len(entries), malformed)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if removed, err := Prune(path, 2); err != nil || removed != 0 {
+		t.Fatalf("Expected pruning a missing history to do nothing, got %d, %v", removed, err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := Append(path, testEntry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Prune(path, 2)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Expected 3 entries removed, got %d", removed)
+	}
+	entries, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Sources[0].Name != "source-3" || entries[1].Sources[0].Name != "source-4" {
+		t.Fatalf("Expected the 2 newest entries to be kept, got %+v", entries)
+	}
+
+	// Appending after a prune continues the file
+	if err := Append(path, testEntry(5)); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _, _ := Read(path); len(entries) != 3 {
+		t.Errorf("Expected 3 entries after appending, got %d", len(entries))
+	}
+
+	if removed, err := Prune(path, 10); err != nil || removed != 0 {
+		t.Errorf("Expected nothing to prune, got %d, %v", removed, err)
+	}
+	if _, err := Prune(path, -1); err == nil {
+		t.Error("Expected a negative keep to be rejected")
+	}
+}
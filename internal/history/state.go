@@ -0,0 +1,147 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StateFileName is the file, next to the history, that keeps each source's last
+// successful sync. It changes on every sync, so it stays out of the configuration.
+const StateFileName = "state.yaml"
+
+// State is the last successful sync of each source, by source name
+type State struct {
+	Sources map[string]SourceState `yaml:"sources"`
+}
+
+// SourceState is when a source last synced successfully, and from which upstream commit
+type SourceState struct {
+	LastSyncedAt     time.Time `yaml:"last_synced_at"`
+	LastSyncedCommit string    `yaml:"last_synced_commit,omitempty"`
+}
+
+// StatePath returns the state file of the project whose configuration is configFile
+func StatePath(configFile string) string {
+	return filepath.Join(filepath.Dir(configFile), Dir, StateFileName)
+}
+
+// ReadState returns the state file's contents. A missing file is an empty state.
+func ReadState(path string) (State, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to open sync state: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, false); err != nil {
+		return State{}, fmt.Errorf("failed to lock sync state: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	return decodeState(file)
+}
+
+// RecordSyncs sets the last sync of the given sources, keeping the others' entries. The
+// file is locked while rewritten, so concurrent runs don't lose each other's updates.
+func RecordSyncs(path string, synced map[string]SourceState) error {
+	if len(synced) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open sync state: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, true); err != nil {
+		return fmt.Errorf("failed to lock sync state: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	state, err := decodeState(file)
+	if err != nil {
+		return err
+	}
+	if state.Sources == nil {
+		state.Sources = make(map[string]SourceState, len(synced))
+	}
+	for name, source := range synced {
+		state.Sources[name] = source
+	}
+
+	return rewriteState(file, state)
+}
+
+// ForgetSources drops the entries of the given sources, keeping the others'. The file is
+// locked while rewritten, like RecordSyncs does.
+func ForgetSources(path string, names ...string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open sync state: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := lockFile(file, true); err != nil {
+		return fmt.Errorf("failed to lock sync state: %w", err)
+	}
+	defer func() { _ = unlockFile(file) }()
+
+	state, err := decodeState(file)
+	if err != nil {
+		return err
+	}
+	forgotten := false
+	for _, name := range names {
+		if _, ok := state.Sources[name]; ok {
+			delete(state.Sources, name)
+			forgotten = true
+		}
+	}
+	if !forgotten {
+		return nil
+	}
+
+	return rewriteState(file, state)
+}
+
+// rewriteState replaces the content of a locked state file with state
+func rewriteState(file *os.File, state State) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// decodeState parses a state file; an empty file is an empty state
+func decodeState(r io.Reader) (State, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	var state State
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return state, nil
+}
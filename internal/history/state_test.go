@@ -0,0 +1,75 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordSyncsAndReadState(t *testing.T) {
+	path := StatePath(filepath.Join(t.TempDir(), ".cherry-go.yaml"))
+
+	state, err := ReadState(path)
+	if err != nil || len(state.Sources) != 0 {
+		t.Fatalf("Expected a missing state file to be empty, got %v, %v", state, err)
+	}
+
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := RecordSyncs(path, map[string]SourceState{
+		"lib":   {LastSyncedAt: first, LastSyncedCommit: "abc123"},
+		"tools": {LastSyncedAt: first},
+	}); err != nil {
+		t.Fatalf("RecordSyncs failed: %v", err)
+	}
+
+	// Sources missing from a later run keep their entries
+	second := first.Add(time.Hour)
+	if err := RecordSyncs(path, map[string]SourceState{"lib": {LastSyncedAt: second, LastSyncedCommit: "def456"}}); err != nil {
+		t.Fatalf("RecordSyncs failed: %v", err)
+	}
+
+	state, err = ReadState(path)
+	if err != nil {
+		t.Fatalf("ReadState failed: %v", err)
+	}
+	if lib := state.Sources["lib"]; !lib.LastSyncedAt.Equal(second) || lib.LastSyncedCommit != "def456" {
+		t.Errorf("Expected lib's entry to be replaced, got %+v", lib)
+	}
+	if tools := state.Sources["tools"]; !tools.LastSyncedAt.Equal(first) {
+		t.Errorf("Expected tools' entry to be kept, got %+v", tools)
+	}
+}
+
+func TestForgetSources(t *testing.T) {
+	path := StatePath(filepath.Join(t.TempDir(), ".cherry-go.yaml"))
+	if err := ForgetSources(path, "lib"); err != nil {
+		t.Fatalf("Expected a missing state file to be left alone, got %v", err)
+	}
+
+	synced := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := RecordSyncs(path, map[string]SourceState{"lib": {LastSyncedAt: synced}, "tools": {LastSyncedAt: synced}}); err != nil {
+		t.Fatalf("RecordSyncs failed: %v", err)
+	}
+	if err := ForgetSources(path, "lib", "unknown"); err != nil {
+		t.Fatalf("ForgetSources failed: %v", err)
+	}
+
+	state, err := ReadState(path)
+	if err != nil {
+		t.Fatalf("ReadState failed: %v", err)
+	}
+	if _, ok := state.Sources["lib"]; ok || len(state.Sources) != 1 {
+		t.Errorf("Expected only tools' entry to be left, got %+v", state.Sources)
+	}
+}
+
+func TestReadState_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	if err := os.WriteFile(path, []byte("sources: [not, a, map]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadState(path); err == nil {
+		t.Error("Expected a malformed state file to be reported")
+	}
+}
@@ -0,0 +1,85 @@
+package interactive
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"cherry-go/internal/merge"
+)
+
+// ConflictChoice is how the user settles a file that couldn't be merged
+type ConflictChoice int
+
+const (
+	ConflictSkip         ConflictChoice = iota // Leave the conflict to the sync's usual handling
+	ConflictKeepLocal                          // Keep the local file as it is
+	ConflictTakeRemote                         // Overwrite the local file with the upstream one
+	ConflictWriteMarkers                       // Write the merge with conflict markers into the file
+)
+
+// String returns the choice as shown to the user
+func (c ConflictChoice) String() string {
+	switch c {
+	case ConflictKeepLocal:
+		return "keep local"
+	case ConflictTakeRemote:
+		return "take remote"
+	case ConflictWriteMarkers:
+		return "write markers"
+	default:
+		return "skip"
+	}
+}
+
+// ResolveConflict shows a summary of a file both sides changed and asks how to settle it.
+// merged is the merge with conflict markers. An empty answer, or stdin closing, skips it.
+// Binary files can't take markers, so they are only kept or taken.
+func ResolveConflict(fileName string, local, remote, merged []byte) ConflictChoice {
+	binary := merge.IsBinary(local) || merge.IsBinary(remote)
+	question, retry := "Keep [l]ocal, take [r]emote, write [m]arkers or [s]kip? [s]: ", "Please answer l, r, m or s."
+	if binary {
+		fmt.Printf("\nConflict in %s: binary files differ (%d bytes vs %d bytes)\n", fileName, len(local), len(remote))
+		question, retry = "Keep [l]ocal, take [r]emote or [s]kip? [s]: ", "Please answer l, r or s."
+	} else {
+		fmt.Printf("\nConflict in %s: %d line(s) locally, %d upstream, %d conflicting section(s)\n",
+			fileName, countLines(local), countLines(remote), countConflictSections(merged))
+	}
+
+	for {
+		fmt.Print(question)
+		switch strings.TrimSpace(strings.ToLower(readLine())) {
+		case "l", "local":
+			return ConflictKeepLocal
+		case "r", "remote":
+			return ConflictTakeRemote
+		case "m", "markers":
+			if !binary {
+				return ConflictWriteMarkers
+			}
+		case "", "s", "skip":
+			return ConflictSkip
+		}
+		fmt.Println(retry)
+	}
+}
+
+// countLines counts the lines of content, including a last one without a newline
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
+// countConflictSections counts the conflict markers opening a section in merged content
+func countConflictSections(merged []byte) int {
+	sections := 0
+	for _, line := range bytes.Split(merged, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("<<<<<<<")) {
+			sections++
+		}
+	}
+	return sections
+}
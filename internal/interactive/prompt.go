@@ -0,0 +1,74 @@
+package interactive
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ShouldPrompt reports whether it is safe to ask the user questions:
+// both stdin and stdout must be terminals and the run must not be in CI
+func ShouldPrompt() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout)
+}
+
+// isTerminal checks whether the file is attached to a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// input reads answers from stdin line by line, so that they can hold spaces
+var input = bufio.NewReader(os.Stdin)
+
+// readLine reads an answer without its line ending, "" at the end of input
+func readLine() string {
+	line, _ := input.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// ReadSecret asks for a secret such as a passphrase on the terminal without echoing it.
+// The prompt goes to stderr so it never mixes with command output.
+func ReadSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// Editor returns the command files are edited with, $VISUAL or else $EDITOR, or "" when
+// neither is set
+func Editor() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	return strings.TrimSpace(os.Getenv("EDITOR"))
+}
+
+// EditFile opens a file in an editor command, which may carry arguments ("code --wait"),
+// and waits for it to exit
+func EditFile(editor, path string) error {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return fmt.Errorf("no editor set (use $VISUAL or $EDITOR)")
+	}
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", fields[0], err)
+	}
+	return nil
+}
@@ -100,6 +100,35 @@ func (s *Selector) SelectMixed(files []string, directories []string, prompt stri
 	return selectedFiles, selectedDirs, nil
 }
 
+// SelectOne presents an interactive selector for a single choice and returns its index
+func SelectOne(items []string, prompt string) (int, error) {
+	if len(items) == 0 {
+		return -1, fmt.Errorf("nothing to select")
+	}
+
+	f, err := fzf.New()
+	if err != nil {
+		return -1, fmt.Errorf("failed to create fzf instance: %w", err)
+	}
+
+	if prompt != "" {
+		fmt.Printf("\n%s\n", prompt)
+		fmt.Println("Use arrow keys to navigate, Enter to confirm, Ctrl+C to cancel")
+	}
+
+	indices, err := f.Find(items, func(i int) string {
+		return items[i]
+	})
+	if err != nil {
+		return -1, fmt.Errorf("selection cancelled or failed: %w", err)
+	}
+	if len(indices) == 0 {
+		return -1, fmt.Errorf("no item selected")
+	}
+
+	return indices[0], nil
+}
+
 // PathConfig represents path configuration for a selected item
 type PathConfig struct {
 	SourcePath string
@@ -119,19 +148,17 @@ func ConfigurePaths(items []string, itemType string, defaultBranch string) ([]Pa
 	for i, item := range items {
 		fmt.Printf("Configuring: %s\n", item)
 
-		// Local path configuration
+		// Local path configuration, taken as typed: names may start or end with spaces
 		fmt.Printf("Local path [%s]: ", item)
-		var localPath string
-		_, _ = fmt.Scanln(&localPath)
+		localPath := readLine()
 		if strings.TrimSpace(localPath) == "" {
 			localPath = item
 		}
 
 		// Branch configuration
 		fmt.Printf("Branch [%s]: ", defaultBranch)
-		var branch string
-		_, _ = fmt.Scanln(&branch)
-		if strings.TrimSpace(branch) == "" {
+		branch := strings.TrimSpace(readLine())
+		if branch == "" {
 			branch = defaultBranch
 		}
 
@@ -157,10 +184,7 @@ func AskYesNo(question string, defaultYes bool) bool {
 	}
 
 	fmt.Printf("%s [%s]: ", question, defaultStr)
-	var response string
-	_, _ = fmt.Scanln(&response)
-
-	response = strings.TrimSpace(strings.ToLower(response))
+	response := strings.TrimSpace(strings.ToLower(readLine()))
 
 	if response == "" {
 		return defaultYes
@@ -0,0 +1,44 @@
+package interactive
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// withInput answers prompts with the given text until the test ends
+func withInput(t *testing.T, text string) {
+	t.Helper()
+	previous := input
+	input = bufio.NewReader(strings.NewReader(text))
+	t.Cleanup(func() { input = previous })
+}
+
+func TestConfigurePaths_KeepsNamesAsTyped(t *testing.T) {
+	withInput(t, "docs/release notes #1.md\n feature/x \n\r\n\n")
+
+	configs, err := ConfigurePaths([]string{"notes.md", "docs/café/naïve résumé.md"}, "files", "main")
+	if err != nil {
+		t.Fatalf("ConfigurePaths failed: %v", err)
+	}
+
+	if configs[0].LocalPath != "docs/release notes #1.md" || configs[0].Branch != "feature/x" {
+		t.Errorf("Expected the typed path whole and the branch trimmed, got %+v", configs[0])
+	}
+	if configs[1].LocalPath != "docs/café/naïve résumé.md" || configs[1].Branch != "main" {
+		t.Errorf("Expected empty answers to take the defaults, got %+v", configs[1])
+	}
+}
+
+func TestAskYesNo(t *testing.T) {
+	withInput(t, "yes please\n  Y  \n")
+	if AskYesNo("Continue?", true) {
+		t.Error("Expected an answer other than y/yes to mean no")
+	}
+	if !AskYesNo("Continue?", false) {
+		t.Error("Expected a padded Y to mean yes")
+	}
+	if !AskYesNo("Continue?", true) {
+		t.Error("Expected the default at the end of input")
+	}
+}
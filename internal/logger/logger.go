@@ -15,6 +15,8 @@ var (
 	dryRun         bool
 	verbose        bool
 	verbosityLevel int // 0 = normal, 1 = verbose, 2+ = very verbose (shows diffs)
+	exitFunc       = os.Exit
+	output         io.Writer // Where log lines go; nil means os.Stdout
 )
 
 // CustomHandler implements a custom slog.Handler with TIMESTAMP [SEVERITY] MSG format
@@ -104,7 +106,7 @@ func levelString(level slog.Level) string {
 // Init initializes the structured logger
 func Init() {
 	// Create custom handler with TIMESTAMP [SEVERITY] MSG format
-	handler := NewCustomHandler(os.Stdout, slog.LevelInfo)
+	handler := NewCustomHandler(logWriter(), slog.LevelInfo)
 	logger = slog.New(handler)
 
 	// Set as default logger
@@ -122,17 +124,32 @@ func SetVerbosityLevel(level int) {
 		} else {
 			slogLevel = slog.LevelDebug
 		}
-		handler := NewCustomHandler(os.Stdout, slogLevel)
+		handler := NewCustomHandler(logWriter(), slogLevel)
 		logger = slog.New(handler)
 		slog.SetDefault(logger)
 	} else {
 		verbose = false
-		handler := NewCustomHandler(os.Stdout, slog.LevelInfo)
+		handler := NewCustomHandler(logWriter(), slog.LevelInfo)
 		logger = slog.New(handler)
 		slog.SetDefault(logger)
 	}
 }
 
+// SetOutput sends log lines to w instead of stdout (nil restores stdout), keeping the
+// verbosity level. Errors always go to stderr.
+func SetOutput(w io.Writer) {
+	output = w
+	SetVerbosityLevel(verbosityLevel)
+}
+
+// logWriter returns where log lines go, resolving stdout at call time
+func logWriter() io.Writer {
+	if output == nil {
+		return os.Stdout
+	}
+	return output
+}
+
 // GetVerbosityLevel returns the current verbosity level
 func GetVerbosityLevel() int {
 	return verbosityLevel
@@ -265,13 +282,27 @@ func DryRunInfoContext(msg string, args ...any) {
 // Fatal logs an error message and exits
 func Fatal(format string, v ...interface{}) {
 	Error(format, v...)
-	os.Exit(1)
+	exitFunc(1)
 }
 
 // FatalContext logs an error message with context and exits
 func FatalContext(msg string, args ...any) {
 	ErrorContext(msg, args...)
-	os.Exit(1)
+	exitFunc(1)
+}
+
+// Exit ends the process with code, for commands whose exit code carries a result
+func Exit(code int) {
+	exitFunc(code)
+}
+
+// SetExitFunc replaces the function called by Fatal to terminate the process
+// Note: Used by tests to intercept fatal errors instead of exiting
+func SetExitFunc(f func(int)) {
+	if f == nil {
+		f = os.Exit
+	}
+	exitFunc = f
 }
 
 // WithContext creates a logger with additional context
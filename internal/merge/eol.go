@@ -0,0 +1,47 @@
+package merge
+
+import "bytes"
+
+var (
+	crlf = []byte("\r\n")
+	lf   = []byte("\n")
+)
+
+// NormalizeLineEndings returns text content with its CRLF line endings turned into LF,
+// so files checked out with either ending read the same. Binary content is left alone.
+func NormalizeLineEndings(content []byte) []byte {
+	if !bytes.Contains(content, crlf) || IsBinary(content) {
+		return content
+	}
+	return bytes.ReplaceAll(content, crlf, lf)
+}
+
+// WithLineEndings returns text content with every line ending written as eol, "\n" or
+// "\r\n". Binary content is left alone.
+func WithLineEndings(content []byte, eol string) []byte {
+	if IsBinary(content) {
+		return content
+	}
+	normalized := NormalizeLineEndings(content)
+	if eol != "\r\n" {
+		return normalized
+	}
+	return bytes.ReplaceAll(normalized, lf, crlf)
+}
+
+// LineEnding returns the line ending text content uses, "\r\n" or "\n", judged by its
+// first line; empty for binary content or content without a line break
+func LineEnding(content []byte) string {
+	if IsBinary(content) {
+		return ""
+	}
+	i := bytes.IndexByte(content, '\n')
+	switch {
+	case i < 0:
+		return ""
+	case i > 0 && content[i-1] == '\r':
+		return "\r\n"
+	default:
+		return "\n"
+	}
+}
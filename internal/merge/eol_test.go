@@ -0,0 +1,56 @@
+package merge
+
+import "testing"
+
+func TestLineEndings(t *testing.T) {
+	binary := "a\r\n\x00b\r\n"
+	testCases := []struct {
+		name       string
+		content    string
+		normalized string
+		crlf       string
+		ending     string
+	}{
+		{"LF", "a\nb\n", "a\nb\n", "a\r\nb\r\n", "\n"},
+		{"CRLF", "a\r\nb\r\n", "a\nb\n", "a\r\nb\r\n", "\r\n"},
+		{"mixed", "a\r\nb\nc", "a\nb\nc", "a\r\nb\r\nc", "\r\n"},
+		{"lone CR kept", "a\rb\n", "a\rb\n", "a\rb\r\n", "\n"},
+		{"single line", "abc", "abc", "abc", ""},
+		{"binary left alone", binary, binary, binary, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			content := []byte(tc.content)
+			if got := string(NormalizeLineEndings(content)); got != tc.normalized {
+				t.Errorf("NormalizeLineEndings = %q, expected %q", got, tc.normalized)
+			}
+			if got := string(WithLineEndings(content, "\r\n")); got != tc.crlf {
+				t.Errorf("WithLineEndings CRLF = %q, expected %q", got, tc.crlf)
+			}
+			if got := string(WithLineEndings(content, "\n")); got != tc.normalized {
+				t.Errorf("WithLineEndings LF = %q, expected %q", got, tc.normalized)
+			}
+			if got := LineEnding(content); got != tc.ending {
+				t.Errorf("LineEnding = %q, expected %q", got, tc.ending)
+			}
+			if string(content) != tc.content {
+				t.Errorf("Expected the input to stay untouched, got %q", content)
+			}
+		})
+	}
+}
+
+func TestThreeWayMergeWithOptions_NormalizeLineEndings(t *testing.T) {
+	base := []byte("a\nb\nc\n")
+	local := []byte("a\r\nb\r\nc\r\n")    // The base checked out with CRLF
+	remote := []byte("a\nb\nc changed\n") // Changed upstream
+
+	result, err := ThreeWayMergeWithOptions(base, local, remote, Options{NormalizeLineEndings: true})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if !result.Success || result.HasConflict || string(result.Content) != "a\nb\nc changed\n" {
+		t.Errorf("Expected the upstream change to be taken over the CRLF checkout, got %+v", result)
+	}
+}
@@ -0,0 +1,75 @@
+package merge
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on ConflictHunk.Excerpt
+const (
+	excerptLines   = 3
+	excerptLineLen = 80
+)
+
+// ConflictHunk locates one conflict in merged content with conflict markers
+type ConflictHunk struct {
+	StartLine int    // 1-based line of the <<<<<<< marker
+	EndLine   int    // 1-based line of the >>>>>>> marker
+	Excerpt   string // First lines of the local side, truncated
+}
+
+// ParseConflictHunks finds the conflict hunks in git merge-file output, with or
+// without the diff3 base section. Hunks missing their closing marker are ignored.
+func ParseConflictHunks(content []byte) []ConflictHunk {
+	var hunks []ConflictHunk
+	var current *ConflictHunk
+	var local []string
+	inLocal := false
+
+	lines := bytes.Split(content, []byte("\n"))
+	for i, raw := range lines {
+		line := strings.TrimSuffix(string(raw), "\r")
+		lineNumber := i + 1
+
+		switch {
+		case isMarker(line, '<'):
+			current = &ConflictHunk{StartLine: lineNumber}
+			local = nil
+			inLocal = true
+		case current == nil:
+			continue
+		case isMarker(line, '|'), isMarker(line, '='):
+			inLocal = false
+		case isMarker(line, '>'):
+			current.EndLine = lineNumber
+			current.Excerpt = excerpt(local)
+			hunks = append(hunks, *current)
+			current = nil
+		case inLocal && len(local) < excerptLines:
+			local = append(local, line)
+		}
+	}
+	return hunks
+}
+
+// isMarker reports whether line is a conflict marker: seven marker characters,
+// then the end of the line or a space and a label
+func isMarker(line string, char byte) bool {
+	if len(line) < 7 || strings.Count(line[:7], string(char)) != 7 {
+		return false
+	}
+	return len(line) == 7 || line[7] == ' '
+}
+
+// excerpt joins the first lines of a conflict side, truncating long ones
+func excerpt(lines []string) string {
+	truncated := make([]string, len(lines))
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) > excerptLineLen {
+			line = string([]rune(line)[:excerptLineLen]) + "…"
+		}
+		truncated[i] = line
+	}
+	return strings.Join(truncated, "\n")
+}
@@ -0,0 +1,101 @@
+package merge
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConflictHunks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []ConflictHunk
+	}{
+		{
+			name:     "clean merge",
+			content:  "a\nb\nc\n",
+			expected: nil,
+		},
+		{
+			name: "diff3 hunks",
+			content: "a\n" +
+				"<<<<<<< LOCAL\nB local\n||||||| BASE\nb\n=======\nB remote\n>>>>>>> REMOTE\n" +
+				"c\nd\ne\n" +
+				"<<<<<<< LOCAL\nF local\n||||||| BASE\nf\n=======\nF remote\n>>>>>>> REMOTE\n" +
+				"g\n",
+			expected: []ConflictHunk{
+				{StartLine: 2, EndLine: 8, Excerpt: "B local"},
+				{StartLine: 12, EndLine: 18, Excerpt: "F local"},
+			},
+		},
+		{
+			name:     "merge style without base",
+			content:  "<<<<<<< LOCAL\nmine\n=======\ntheirs\n>>>>>>> REMOTE\nrest\n",
+			expected: []ConflictHunk{{StartLine: 1, EndLine: 5, Excerpt: "mine"}},
+		},
+		{
+			name:     "CRLF line endings",
+			content:  "x\r\n<<<<<<< LOCAL\r\nmine\r\n=======\r\ntheirs\r\n>>>>>>> REMOTE\r\n",
+			expected: []ConflictHunk{{StartLine: 2, EndLine: 6, Excerpt: "mine"}},
+		},
+		{
+			name:     "local side deleted",
+			content:  "<<<<<<< LOCAL\n||||||| BASE\nold\n=======\nnew\n>>>>>>> REMOTE\n",
+			expected: []ConflictHunk{{StartLine: 1, EndLine: 6, Excerpt: ""}},
+		},
+		{
+			name:     "excerpt capped at three lines",
+			content:  "<<<<<<< LOCAL\n1\n2\n3\n4\n=======\nr\n>>>>>>> REMOTE\n",
+			expected: []ConflictHunk{{StartLine: 1, EndLine: 8, Excerpt: "1\n2\n3"}},
+		},
+		{
+			name:     "marker-like content is not a marker",
+			content:  "<<<<<<<<< not a marker\n=======x\n>>>>>>>\n",
+			expected: nil,
+		},
+		{
+			name:     "unterminated hunk ignored",
+			content:  "<<<<<<< LOCAL\nmine\n=======\ntheirs\n",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseConflictHunks([]byte(tc.content))
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseConflictHunks_TruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("é", 100)
+	hunks := ParseConflictHunks([]byte("<<<<<<< LOCAL\n" + long + "\n=======\nr\n>>>>>>> REMOTE\n"))
+	if len(hunks) != 1 {
+		t.Fatalf("Expected one hunk, got %+v", hunks)
+	}
+	if expected := strings.Repeat("é", excerptLineLen) + "…"; hunks[0].Excerpt != expected {
+		t.Errorf("Expected the excerpt to be cut at %d characters, got %q", excerptLineLen, hunks[0].Excerpt)
+	}
+}
+
+func TestThreeWayMerge_ConflictHunks(t *testing.T) {
+	base := []byte("a\nb\nc\nd\ne\nf\ng\n")
+	local := []byte("a\nB local\nc\nd\ne\nF local\ng\n")
+	remote := []byte("a\nB remote\nc\nd\ne\nF remote\ng\n")
+
+	result, err := ThreeWayMerge(base, local, remote)
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	expected := []ConflictHunk{
+		{StartLine: 2, EndLine: 8, Excerpt: "B local"},
+		{StartLine: 12, EndLine: 18, Excerpt: "F local"},
+	}
+	if !reflect.DeepEqual(result.Hunks, expected) {
+		t.Errorf("Expected hunks %+v, got %+v\n%s", expected, result.Hunks, result.Content)
+	}
+}
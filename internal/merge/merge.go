@@ -2,20 +2,57 @@ package merge
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
 
 	"cherry-go/internal/logger"
 )
 
 // MergeResult represents the result of a merge operation
 type MergeResult struct {
-	Success     bool   // Whether the merge was successful (no conflicts)
-	Content     []byte // The merged content (may contain conflict markers if Success is false)
-	HasConflict bool   // Whether there were conflicts that couldn't be auto-resolved
+	Success     bool           // Whether the merge was successful (no conflicts)
+	Content     []byte         // The merged content (may contain conflict markers if Success is false)
+	HasConflict bool           // Whether there were conflicts that couldn't be auto-resolved
+	Hunks       []ConflictHunk // Where the conflict markers are in Content
+	Binary      bool           // A side is binary, so nothing was merged and Content is local as is
+}
+
+// ErrGitUnavailable is returned when the git binary three-way merges run is missing
+var ErrGitUnavailable = errors.New("merge mode needs the git binary for three-way merges (git merge-file), and it was not found in PATH: install git, or sync with --force to overwrite local changes or without --merge to only detect them")
+
+// lookPath finds the git binary
+// Note: Replaced by tests to simulate a machine without git
+var lookPath = exec.LookPath
+
+// gitProbe caches CheckGit's result for the rest of the process
+var gitProbe struct {
+	once sync.Once
+	err  error
+}
+
+// CheckGit reports whether the git binary needed by ThreeWayMerge is available,
+// returning ErrGitUnavailable if not. PATH is only looked up once per process.
+func CheckGit() error {
+	gitProbe.once.Do(func() {
+		if _, err := lookPath("git"); err != nil {
+			gitProbe.err = ErrGitUnavailable
+		}
+	})
+	return gitProbe.err
+}
+
+// Options tune how ThreeWayMergeWithOptions compares contents
+type Options struct {
+	IgnoreTrailingNewline bool // Contents that only differ by a final newline count as unchanged
+	NormalizeLineEndings  bool // CRLF reads as LF; the merged content comes back with LF endings
 }
 
 // ThreeWayMerge performs a git merge-file based three-way merge with diff3 style
@@ -25,8 +62,20 @@ type MergeResult struct {
 // local: the current local content
 // remote: the new remote content
 func ThreeWayMerge(base, local, remote []byte) (MergeResult, error) {
+	return ThreeWayMergeWithOptions(base, local, remote, Options{})
+}
+
+// ThreeWayMergeWithOptions is ThreeWayMerge with its trivial-case checks tuned by options
+func ThreeWayMergeWithOptions(base, local, remote []byte, options Options) (MergeResult, error) {
+	if options.NormalizeLineEndings {
+		base, local, remote = NormalizeLineEndings(base), NormalizeLineEndings(local), NormalizeLineEndings(remote)
+	}
+	same := func(a, b []byte) bool {
+		return SameContent(a, b, options.IgnoreTrailingNewline)
+	}
+
 	// Quick checks for trivial cases
-	if bytes.Equal(base, remote) {
+	if same(base, remote) {
 		// No remote changes - keep local as is
 		return MergeResult{
 			Success: true,
@@ -34,7 +83,7 @@ func ThreeWayMerge(base, local, remote []byte) (MergeResult, error) {
 		}, nil
 	}
 
-	if bytes.Equal(base, local) {
+	if same(base, local) {
 		// No local changes - take remote
 		return MergeResult{
 			Success: true,
@@ -42,7 +91,7 @@ func ThreeWayMerge(base, local, remote []byte) (MergeResult, error) {
 		}, nil
 	}
 
-	if bytes.Equal(local, remote) {
+	if same(local, remote) {
 		// Both made same changes
 		return MergeResult{
 			Success: true,
@@ -50,7 +99,19 @@ func ThreeWayMerge(base, local, remote []byte) (MergeResult, error) {
 		}, nil
 	}
 
+	// Binary files can't be merged line by line, and must never get conflict markers
+	if IsBinary(base) || IsBinary(local) || IsBinary(remote) {
+		return MergeResult{
+			Content:     local,
+			HasConflict: true,
+			Binary:      true,
+		}, nil
+	}
+
 	// Use git merge-file for all other cases
+	if err := CheckGit(); err != nil {
+		return MergeResult{}, err
+	}
 	return gitMergeFileDiff3(base, local, remote)
 }
 
@@ -94,11 +155,15 @@ func gitMergeFileDiff3(base, local, remote []byte) (MergeResult, error) {
 		return MergeResult{}, fmt.Errorf("failed to run git merge-file: %w (stderr: %s)", err, stderr.String())
 	}
 
-	return MergeResult{
+	result := MergeResult{
 		Content:     stdout.Bytes(),
 		Success:     exitCode == 0,
 		HasConflict: exitCode > 0,
-	}, nil
+	}
+	if result.HasConflict {
+		result.Hunks = ParseConflictHunks(result.Content)
+	}
+	return result, nil
 }
 
 // isBinaryFile checks if a file is binary by reading its first bytes
@@ -116,9 +181,7 @@ func isBinaryFile(path string) bool {
 	if err != nil {
 		return false
 	}
-
-	// Check for null bytes (binary indicator)
-	return bytes.Contains(buf[:n], []byte{0})
+	return IsBinary(buf[:n])
 }
 
 // ContainsConflictMarkers checks if content has git conflict markers
@@ -136,20 +199,33 @@ func ContainsConflictMarkers(content []byte) bool {
 	return false
 }
 
-// ShowDiffFromContent displays a three-way diff (base, local, remote) with merge preview
+// ShowDiffFromContent writes a three-way diff (base, local, remote) with merge preview to w
 // Only shows detailed diff if verbosity level >= 2, otherwise shows summary
-func ShowDiffFromContent(base, local, remote []byte, fileName string) {
+// Files that only differ by a trailing newline, and binary files, get a one-line note instead.
+func ShowDiffFromContent(w io.Writer, base, local, remote []byte, fileName string) {
+	if IsBinary(base) || IsBinary(local) || IsBinary(remote) {
+		if logger.GetVerbosityLevel() > 0 {
+			fmt.Fprintf(w, "\n  • %s: binary files differ (%d bytes vs %d bytes)\n", fileName, len(local), len(remote))
+		}
+		return
+	}
+	if OnlyTrailingNewlineDiffers(local, remote) {
+		if logger.GetVerbosityLevel() > 0 {
+			fmt.Fprintf(w, "\n  • %s: differs only by trailing newline\n", fileName)
+		}
+		return
+	}
 	if logger.ShouldShowDiffs() {
 		// Verbosity >= 2: Show detailed diff
-		showDiff3(base, local, remote, fileName)
+		showDiff3(w, base, local, remote, fileName)
 	} else {
 		// Verbosity < 2: Show only summary
-		showConflictSummary(base, local, remote, fileName)
+		showConflictSummary(w, base, local, remote, fileName)
 	}
 }
 
 // showConflictSummary shows a brief summary without detailed diff
-func showConflictSummary(base, local, remote []byte, fileName string) {
+func showConflictSummary(w io.Writer, base, local, remote []byte, fileName string) {
 	// If verbosity is 0, don't show anything (summary will be in final compact log)
 	if logger.GetVerbosityLevel() == 0 {
 		return
@@ -163,18 +239,18 @@ func showConflictSummary(base, local, remote []byte, fileName string) {
 	remoteLines := len(strings.Split(string(remote), "\n"))
 
 	if mergeResult.Success {
-		fmt.Printf("\n  • %s: Auto-merge successful (%d lines in base, %d local, %d remote)\n",
+		fmt.Fprintf(w, "\n  • %s: Auto-merge successful (%d lines in base, %d local, %d remote)\n",
 			fileName, baseLines, localLines, remoteLines)
 	} else {
-		fmt.Printf("\n  • %s: Merge conflict detected (%d lines in base, %d local, %d remote)\n",
+		fmt.Fprintf(w, "\n  • %s: Merge conflict detected (%d lines in base, %d local, %d remote)\n",
 			fileName, baseLines, localLines, remoteLines)
-		fmt.Printf("    → Use -v or --verbose flag multiple times to see detailed diff\n")
+		fmt.Fprintf(w, "    → Use -v or --verbose flag multiple times to see detailed diff\n")
 	}
 }
 
 // showDiff3 displays a three-way diff showing BASE, LOCAL, REMOTE in 3 columns
 // and the MERGE RESULT below spanning the full width
-func showDiff3(base, local, remote []byte, fileName string) {
+func showDiff3(w io.Writer, base, local, remote []byte, fileName string) {
 	// Perform merge to get the result
 	mergeResult, _ := ThreeWayMerge(base, local, remote)
 
@@ -185,27 +261,27 @@ func showDiff3(base, local, remote []byte, fileName string) {
 
 	const colWidth = 36
 	const separator = " │ "
-	const totalWidth = colWidth*3 + len(separator)*2
+	totalWidth := colWidth*3 + runewidth.StringWidth(separator)*2
 
 	// Header
-	fmt.Println()
-	fmt.Printf("┌─── %s ───\n", fileName)
-	fmt.Printf("│\n")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "┌─── %s ───\n", fileName)
+	fmt.Fprintf(w, "│\n")
 
 	// Show merge status
 	if mergeResult.Success {
-		fmt.Printf("│  \033[32m✓ Auto-merge successful\033[0m\n")
+		fmt.Fprintf(w, "│  \033[32m✓ Auto-merge successful\033[0m\n")
 	} else if mergeResult.HasConflict {
-		fmt.Printf("│  \033[31m✗ Merge conflict detected\033[0m\n")
+		fmt.Fprintf(w, "│  \033[31m✗ Merge conflict detected\033[0m\n")
 	}
-	fmt.Printf("│\n")
+	fmt.Fprintf(w, "│\n")
 
 	// Column headers for the 3 versions
-	fmt.Printf("│  \033[90m%-*s\033[0m%s", colWidth, "BASE (last sync)", separator)
-	fmt.Printf("\033[36m%-*s\033[0m%s", colWidth, "LOCAL (yours)", separator)
-	fmt.Printf("\033[33m%-*s\033[0m\n", colWidth, "REMOTE (source)")
+	fmt.Fprintf(w, "│  \033[90m%-*s\033[0m%s", colWidth, "BASE (last sync)", separator)
+	fmt.Fprintf(w, "\033[36m%-*s\033[0m%s", colWidth, "LOCAL (yours)", separator)
+	fmt.Fprintf(w, "\033[33m%-*s\033[0m\n", colWidth, "REMOTE (source)")
 
-	fmt.Printf("│  %s%s%s%s%s\n",
+	fmt.Fprintf(w, "│  %s%s%s%s%s\n",
 		strings.Repeat("─", colWidth), "─┼─",
 		strings.Repeat("─", colWidth), "─┼─",
 		strings.Repeat("─", colWidth))
@@ -236,65 +312,58 @@ func showDiff3(base, local, remote []byte, fileName string) {
 			remoteLine = remoteLines[i]
 		}
 
-		// Truncate long lines
-		if len(baseLine) > colWidth {
-			baseLine = baseLine[:colWidth-3] + "..."
-		}
-		if len(localLine) > colWidth {
-			localLine = localLine[:colWidth-3] + "..."
-		}
-		if len(remoteLine) > colWidth {
-			remoteLine = remoteLine[:colWidth-3] + "..."
-		}
-
 		// Determine if lines changed
 		localChanged := localLine != baseLine
 		remoteChanged := remoteLine != baseLine
 		hasChange := localChanged || remoteChanged
 
+		baseLine = fitColumn(baseLine, colWidth)
+		localLine = fitColumn(localLine, colWidth)
+		remoteLine = fitColumn(remoteLine, colWidth)
+
 		// Print with appropriate formatting
-		fmt.Print("│  ")
+		fmt.Fprint(w, "│  ")
 
 		// BASE column (grey)
-		fmt.Printf("\033[90m%-*s\033[0m%s", colWidth, baseLine, separator)
+		fmt.Fprintf(w, "\033[90m%s\033[0m%s", baseLine, separator)
 
 		// LOCAL column (cyan if changed)
 		if localChanged {
-			fmt.Printf("\033[36m%-*s\033[0m%s", colWidth, localLine, separator)
+			fmt.Fprintf(w, "\033[36m%s\033[0m%s", localLine, separator)
 		} else {
-			fmt.Printf("%-*s%s", colWidth, localLine, separator)
+			fmt.Fprintf(w, "%s%s", localLine, separator)
 		}
 
 		// REMOTE column (yellow if changed)
 		if remoteChanged {
-			fmt.Printf("\033[33m%-*s\033[0m", colWidth, remoteLine)
+			fmt.Fprintf(w, "\033[33m%s\033[0m", remoteLine)
 		} else {
-			fmt.Printf("%-*s", colWidth, remoteLine)
+			fmt.Fprint(w, remoteLine)
 		}
 
 		// Mark changed lines
 		if hasChange {
-			fmt.Print("  \033[31m◄\033[0m")
+			fmt.Fprint(w, "  \033[31m◄\033[0m")
 		}
 
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 
 	if maxLines > maxDisplay {
-		fmt.Printf("│  ... (%d more lines)\n", maxLines-maxDisplay)
+		fmt.Fprintf(w, "│  ... (%d more lines)\n", maxLines-maxDisplay)
 	}
 
 	// Separator before RESULT section
-	fmt.Printf("│\n")
-	fmt.Printf("│  %s\n", strings.Repeat("═", totalWidth))
+	fmt.Fprintf(w, "│\n")
+	fmt.Fprintf(w, "│  %s\n", strings.Repeat("═", totalWidth))
 
 	// RESULT header
 	if mergeResult.Success {
-		fmt.Printf("│  \033[32mRESULT (merged successfully)\033[0m\n")
+		fmt.Fprintf(w, "│  \033[32mRESULT (merged successfully)\033[0m\n")
 	} else {
-		fmt.Printf("│  \033[31mRESULT (with conflicts)\033[0m\n")
+		fmt.Fprintf(w, "│  \033[31mRESULT (with conflicts)\033[0m\n")
 	}
-	fmt.Printf("│  %s\n", strings.Repeat("─", totalWidth))
+	fmt.Fprintf(w, "│  %s\n", strings.Repeat("─", totalWidth))
 
 	// Display RESULT spanning full width
 	maxResultDisplay := 30
@@ -304,30 +373,33 @@ func showDiff3(base, local, remote []byte, fileName string) {
 	}
 
 	for i := 0; i < displayResultLines; i++ {
-		resultLine := resultLines[i]
-
-		// Truncate if needed
-		if len(resultLine) > totalWidth {
-			resultLine = resultLine[:totalWidth-3] + "..."
-		}
+		resultLine := fitColumn(resultLines[i], totalWidth)
 
 		// Color based on content
 		if strings.Contains(resultLine, "<<<<<<<") || strings.Contains(resultLine, ">>>>>>>") || strings.Contains(resultLine, "|||||||") || strings.Contains(resultLine, "=======") {
-			fmt.Printf("│  \033[31m%-*s\033[0m\n", totalWidth, resultLine)
+			fmt.Fprintf(w, "│  \033[31m%s\033[0m\n", resultLine)
 		} else if mergeResult.Success {
-			fmt.Printf("│  \033[32m%-*s\033[0m\n", totalWidth, resultLine)
+			fmt.Fprintf(w, "│  \033[32m%s\033[0m\n", resultLine)
 		} else {
-			fmt.Printf("│  %-*s\n", totalWidth, resultLine)
+			fmt.Fprintf(w, "│  %s\n", resultLine)
 		}
 	}
 
 	if len(resultLines) > maxResultDisplay {
-		fmt.Printf("│  ... (%d more lines)\n", len(resultLines)-maxResultDisplay)
+		fmt.Fprintf(w, "│  ... (%d more lines)\n", len(resultLines)-maxResultDisplay)
 	}
 
-	fmt.Println("│")
-	fmt.Printf("│  \033[31m◄\033[0m = changed from base\n")
-	fmt.Println("└" + strings.Repeat("─", totalWidth+2))
+	fmt.Fprintln(w, "│")
+	fmt.Fprintf(w, "│  \033[31m◄\033[0m = changed from base\n")
+	fmt.Fprintln(w, "└"+strings.Repeat("─", totalWidth+2))
+}
+
+// fitColumn truncates a line to width terminal columns and pads it to exactly that many.
+// Columns, not bytes: accented letters take one and CJK characters two, and a line is
+// never cut inside a character. Tabs are expanded to four spaces.
+func fitColumn(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return runewidth.FillRight(runewidth.Truncate(line, width, "..."), width)
 }
 
 // max returns the larger of two integers
@@ -1,10 +1,16 @@
 package merge
 
 import (
+	"bytes"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+
+	"cherry-go/internal/logger"
 )
 
 func TestThreeWayMerge_CleanMerge(t *testing.T) {
@@ -241,3 +247,166 @@ func TestIsBinaryFile(t *testing.T) {
 		t.Error("Binary file should be detected as binary")
 	}
 }
+
+func TestShowDiffFromContent(t *testing.T) {
+	base := []byte("a\nb\nc\n")
+	local := []byte("a\nB local\nc\n")
+	remote := []byte("a\nB remote\nc\n")
+
+	testCases := []struct {
+		verbosity int
+		contains  []string
+	}{
+		{verbosity: 0},
+		{verbosity: 1, contains: []string{"• x.go: Merge conflict detected", "-v or --verbose"}},
+		{verbosity: 2, contains: []string{"┌─── x.go ───", "BASE (last sync)", "B local", "B remote", "RESULT (with conflicts)"}},
+	}
+
+	t.Cleanup(func() { logger.SetVerbosityLevel(0) })
+	for _, tc := range testCases {
+		logger.SetVerbosityLevel(tc.verbosity)
+		var buf bytes.Buffer
+		ShowDiffFromContent(&buf, base, local, remote, "x.go")
+
+		if len(tc.contains) == 0 && buf.Len() > 0 {
+			t.Errorf("verbosity %d: expected no output, got %q", tc.verbosity, buf.String())
+		}
+		for _, want := range tc.contains {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("verbosity %d: expected %q in output, got:\n%s", tc.verbosity, want, buf.String())
+			}
+		}
+	}
+}
+
+func TestThreeWayMerge_BinaryFiles(t *testing.T) {
+	base := []byte("\x89PNG\r\n\x00v1")
+	local := []byte("\x89PNG\r\n\x00local")
+	remote := []byte("\x89PNG\r\n\x00remote\x00\x01")
+
+	result, err := ThreeWayMerge(base, local, remote)
+	if err != nil {
+		t.Fatalf("ThreeWayMerge failed: %v", err)
+	}
+	if !result.HasConflict || !result.Binary || result.Success {
+		t.Errorf("Expected binary files changed on both sides to conflict, got %+v", result)
+	}
+	if !bytes.Equal(result.Content, local) || ContainsConflictMarkers(result.Content) || len(result.Hunks) > 0 {
+		t.Errorf("Expected the local content as is, without markers, got %q", result.Content)
+	}
+
+	// A text file turned binary upstream conflicts too
+	result, _ = ThreeWayMerge([]byte("text\n"), []byte("local text\n"), remote)
+	if !result.HasConflict || !result.Binary || !bytes.Equal(result.Content, []byte("local text\n")) {
+		t.Errorf("Expected a file turned binary to conflict, got %+v", result)
+	}
+
+	// One side unchanged still takes the other, binary or not
+	if result, _ := ThreeWayMerge(base, base, remote); !result.Success || !bytes.Equal(result.Content, remote) {
+		t.Errorf("Expected an unchanged local binary to take remote, got %+v", result)
+	}
+	if result, _ := ThreeWayMerge(base, local, base); !result.Success || !bytes.Equal(result.Content, local) {
+		t.Errorf("Expected an unchanged remote binary to keep local, got %+v", result)
+	}
+}
+
+func TestShowDiffFromContent_Binary(t *testing.T) {
+	base := []byte("\x00base")
+	local := []byte("\x00local\xff\xfe")
+	remote := []byte("\x00remote content")
+
+	t.Cleanup(func() { logger.SetVerbosityLevel(0) })
+	for _, verbosity := range []int{0, 1, 2} {
+		logger.SetVerbosityLevel(verbosity)
+		var buf bytes.Buffer
+		ShowDiffFromContent(&buf, base, local, remote, "logo.png")
+
+		if verbosity == 0 {
+			if buf.Len() > 0 {
+				t.Errorf("verbosity 0: expected no output, got %q", buf.String())
+			}
+			continue
+		}
+		if want := "• logo.png: binary files differ (8 bytes vs 15 bytes)"; !strings.Contains(buf.String(), want) {
+			t.Errorf("verbosity %d: expected %q, got:\n%s", verbosity, want, buf.String())
+		}
+		if strings.Contains(buf.String(), "local") || strings.Contains(buf.String(), "BASE") {
+			t.Errorf("verbosity %d: expected no content, got:\n%s", verbosity, buf.String())
+		}
+	}
+}
+
+// stubLookPath replaces the git lookup and clears CheckGit's cached result for one test
+func stubLookPath(t *testing.T, lookup func(string) (string, error)) {
+	t.Helper()
+	reset := func() {
+		gitProbe.once = sync.Once{}
+		gitProbe.err = nil
+	}
+	original := lookPath
+	t.Cleanup(func() {
+		lookPath = original
+		reset()
+	})
+	lookPath = lookup
+	reset()
+}
+
+func TestCheckGit_MissingBinary(t *testing.T) {
+	lookups := 0
+	stubLookPath(t, func(file string) (string, error) {
+		lookups++
+		return "", &exec.Error{Name: file, Err: exec.ErrNotFound}
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := CheckGit(); !errors.Is(err, ErrGitUnavailable) {
+			t.Fatalf("Expected ErrGitUnavailable, got %v", err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("Expected PATH to be looked up once, got %d lookups", lookups)
+	}
+
+	// A merge that needs git fails with the same message instead of an exec error
+	_, err := ThreeWayMerge([]byte("a\n"), []byte("b\n"), []byte("c\n"))
+	if !errors.Is(err, ErrGitUnavailable) {
+		t.Errorf("Expected ThreeWayMerge to fail with ErrGitUnavailable, got %v", err)
+	}
+
+	// Trivial merges don't run git and still succeed
+	result, err := ThreeWayMerge([]byte("a\n"), []byte("a\n"), []byte("c\n"))
+	if err != nil || string(result.Content) != "c\n" {
+		t.Errorf("Expected the remote content without running git, got %q, %v", result.Content, err)
+	}
+}
+
+func TestCheckGit_Available(t *testing.T) {
+	stubLookPath(t, func(file string) (string, error) { return "/usr/bin/" + file, nil })
+	if err := CheckGit(); err != nil {
+		t.Errorf("Expected git to be found, got %v", err)
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{"padded", "short", "short     "},
+		{"accented letters take a column each", "café noté", "café noté "},
+		{"wide characters take two", "日本語", "日本語    "},
+		{"truncated by columns", "日本語の文章です", "日本語... "},
+		{"cut by characters, not bytes", "naïve résumé", "naïve r..."},
+		{"tabs expanded", "\tx", "    x     "},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fitColumn(tc.line, 10); got != tc.expected {
+				t.Errorf("fitColumn(%q, 10) = %q, expected %q", tc.line, got, tc.expected)
+			}
+		})
+	}
+}
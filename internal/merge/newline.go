@@ -0,0 +1,36 @@
+package merge
+
+import "bytes"
+
+// EnsureTrailingNewline returns content ending with a newline: a final "\n" is appended to
+// non-empty content that lacks one. Contents that only differ by a final newline read the
+// same through it. Other line endings are left alone, so "\r\n" and "\n" still differ.
+func EnsureTrailingNewline(content []byte) []byte {
+	if len(content) == 0 || content[len(content)-1] == '\n' {
+		return content
+	}
+	normalized := make([]byte, len(content), len(content)+1)
+	copy(normalized, content)
+	return append(normalized, '\n')
+}
+
+// SameContent reports whether a and b are equal. With ignoreTrailingNewline, contents
+// that only differ by a final newline are equal too.
+func SameContent(a, b []byte, ignoreTrailingNewline bool) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	return ignoreTrailingNewline && OnlyTrailingNewlineDiffers(a, b)
+}
+
+// OnlyTrailingNewlineDiffers reports whether a and b differ, and only because one of them
+// ends with a newline the other lacks
+func OnlyTrailingNewlineDiffers(a, b []byte) bool {
+	if len(a) == len(b) {
+		return false
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return len(a) == len(b)+1 && len(b) > 0 && a[len(b)] == '\n' && b[len(b)-1] != '\n' && bytes.Equal(a[:len(b)], b)
+}
@@ -0,0 +1,114 @@
+package merge
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cherry-go/internal/logger"
+)
+
+func TestTrailingNewlineComparisons(t *testing.T) {
+	testCases := []struct {
+		name         string
+		a, b         string
+		onlyNewline  bool
+		sameIgnoring bool
+	}{
+		{"identical", "a\nb\n", "a\nb\n", false, true},
+		{"missing final newline", "a\nb", "a\nb\n", true, true},
+		{"missing final newline reversed", "a\nb\n", "a\nb", true, true},
+		{"extra blank line", "a\nb\n", "a\nb\n\n", false, false},
+		{"empty and newline", "", "\n", false, false},
+		{"CRLF against LF", "a\r\n", "a\n", false, false},
+		{"CRLF missing final newline", "a\r\nb", "a\r\nb\n", true, true},
+		{"other change", "a\nb", "a\nc\n", false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := []byte(tc.a), []byte(tc.b)
+			if got := OnlyTrailingNewlineDiffers(a, b); got != tc.onlyNewline {
+				t.Errorf("OnlyTrailingNewlineDiffers = %t, expected %t", got, tc.onlyNewline)
+			}
+			if got := SameContent(a, b, true); got != tc.sameIgnoring {
+				t.Errorf("SameContent ignoring trailing newline = %t, expected %t", got, tc.sameIgnoring)
+			}
+			if got := SameContent(a, b, false); got != (tc.a == tc.b) {
+				t.Errorf("SameContent = %t, expected %t", got, tc.a == tc.b)
+			}
+			// Normalizing agrees with the comparison, so hashes agree with it too
+			normalized := bytes.Equal(EnsureTrailingNewline(a), EnsureTrailingNewline(b))
+			if normalized != tc.sameIgnoring {
+				t.Errorf("EnsureTrailingNewline makes them equal = %t, expected %t", normalized, tc.sameIgnoring)
+			}
+		})
+	}
+}
+
+func TestEnsureTrailingNewline_LeavesInputAlone(t *testing.T) {
+	content := make([]byte, 3, 10)
+	copy(content, "abc")
+	if got := string(EnsureTrailingNewline(content)); got != "abc\n" {
+		t.Errorf("Expected \"abc\\n\", got %q", got)
+	}
+	if got := string(content[:cap(content)][:4]); got == "abc\n" {
+		t.Error("Expected the input's spare capacity to stay untouched")
+	}
+}
+
+func TestThreeWayMergeWithOptions_TrailingNewline(t *testing.T) {
+	base := []byte("a\nb\n")
+	local := []byte("a\nb")        // Editor dropped the final newline
+	remote := []byte("a\nb\nc\n")  // Upstream added a line
+	sameAsBase := []byte("a\nb\n") // Upstream unchanged
+
+	result, err := ThreeWayMergeWithOptions(base, local, remote, Options{IgnoreTrailingNewline: true})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if !result.Success || string(result.Content) != string(remote) {
+		t.Errorf("Expected the local copy to count as unchanged and remote to be taken, got %+v", result)
+	}
+
+	result, err = ThreeWayMergeWithOptions(base, local, sameAsBase, Options{IgnoreTrailingNewline: true})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if !result.Success || string(result.Content) != string(local) {
+		t.Errorf("Expected the local copy to be kept, got %+v", result)
+	}
+
+	result, err = ThreeWayMergeWithOptions([]byte("x\n"), local, []byte("a\nb\n"), Options{IgnoreTrailingNewline: true})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if !result.Success || string(result.Content) != string(local) {
+		t.Errorf("Expected local and remote to count as the same change, got %+v", result)
+	}
+}
+
+func TestShowDiffFromContent_TrailingNewlineOnly(t *testing.T) {
+	t.Cleanup(func() { logger.SetVerbosityLevel(0) })
+	base := []byte("a\nb\n")
+
+	for _, verbosity := range []int{1, 2} {
+		logger.SetVerbosityLevel(verbosity)
+		var buf bytes.Buffer
+		ShowDiffFromContent(&buf, base, []byte("a\nb"), []byte("a\nb\n"), "x.go")
+
+		if !strings.Contains(buf.String(), "x.go: differs only by trailing newline") {
+			t.Errorf("verbosity %d: expected the trailing newline note, got:\n%s", verbosity, buf.String())
+		}
+		if strings.Contains(buf.String(), "BASE (last sync)") {
+			t.Errorf("verbosity %d: expected no three-way diff, got:\n%s", verbosity, buf.String())
+		}
+	}
+
+	logger.SetVerbosityLevel(0)
+	var buf bytes.Buffer
+	ShowDiffFromContent(&buf, base, []byte("a\nb"), []byte("a\nb\n"), "x.go")
+	if buf.Len() > 0 {
+		t.Errorf("Expected no output without -v, got %q", buf.String())
+	}
+}
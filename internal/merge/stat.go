@@ -0,0 +1,36 @@
+package merge
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// IsBinary reports whether content looks binary, using git's heuristic of
+// a NUL byte within the first 8000 bytes
+func IsBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// LineStat counts the lines added and removed to turn old into new, like `git diff --stat`
+func LineStat(old, new []byte) (added, removed int) {
+	for _, d := range diff.Do(string(old), string(new)) {
+		lines := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") {
+			lines++ // Last line without a trailing newline
+		}
+
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			added += lines
+		case diffmatchpatch.DiffDelete:
+			removed += lines
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,33 @@
+package merge
+
+import "testing"
+
+func TestLineStat(t *testing.T) {
+	testCases := []struct {
+		name           string
+		old, new       string
+		added, removed int
+	}{
+		{"identical", "a\nb\n", "a\nb\n", 0, 0},
+		{"new file", "", "a\nb\nc\n", 3, 0},
+		{"deleted content", "a\nb\n", "", 0, 2},
+		{"one line changed", "a\nb\nc\n", "a\nB\nc\n", 1, 1},
+		{"appended without newline", "a\n", "a\nb", 1, 0},
+	}
+
+	for _, tc := range testCases {
+		added, removed := LineStat([]byte(tc.old), []byte(tc.new))
+		if added != tc.added || removed != tc.removed {
+			t.Errorf("%s: LineStat = +%d -%d, expected +%d -%d", tc.name, added, removed, tc.added, tc.removed)
+		}
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	if IsBinary([]byte("plain text\n")) {
+		t.Error("Expected text not to be binary")
+	}
+	if !IsBinary([]byte{0x89, 'P', 'N', 'G', 0x00, 0x01}) {
+		t.Error("Expected content with NUL bytes to be binary")
+	}
+}
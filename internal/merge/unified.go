@@ -0,0 +1,150 @@
+package merge
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// unifiedContext is how many unchanged lines surround each hunk, as in `diff -u`
+const unifiedContext = 3
+
+// unifiedLine is one line of a line-level diff: ' ' unchanged, '-' removed, '+' added
+type unifiedLine struct {
+	op        byte
+	text      string // Without its line break
+	noNewline bool   // Last line of its file, without a trailing newline
+	oldLine   int    // 1-based line number in old (removed and unchanged lines)
+	newLine   int    // 1-based line number in new (added and unchanged lines)
+}
+
+// UnifiedDiff writes the differences between old and new in unified format, with
+// headers naming them oldName and newName ("/dev/null" for a missing side), so the
+// output can be applied with `git apply` or `patch -p1`. Binary content is reported
+// in one line. Nothing is written when the contents are equal.
+func UnifiedDiff(w io.Writer, oldName, newName string, old, new []byte) {
+	if string(old) == string(new) {
+		return
+	}
+	if IsBinary(old) || IsBinary(new) {
+		fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+	lines := diffLines(string(old), string(new))
+	for start := 0; start < len(lines); {
+		hunk, next := nextHunk(lines, start)
+		if hunk == nil {
+			break
+		}
+		writeHunk(w, hunk)
+		start = next
+	}
+}
+
+// diffLines runs a line-level diff of old and new
+func diffLines(old, new string) []unifiedLine {
+	var lines []unifiedLine
+	oldLine, newLine := 0, 0
+	for _, d := range diff.Do(old, new) {
+		for _, text := range splitLines(d.Text) {
+			line := unifiedLine{text: strings.TrimSuffix(text, "\n"), noNewline: !strings.HasSuffix(text, "\n")}
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				oldLine++
+				newLine++
+				line.op, line.oldLine, line.newLine = ' ', oldLine, newLine
+			case diffmatchpatch.DiffDelete:
+				oldLine++
+				line.op, line.oldLine = '-', oldLine
+			case diffmatchpatch.DiffInsert:
+				newLine++
+				line.op, line.newLine = '+', newLine
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// splitLines splits text after each line break, keeping the breaks
+func splitLines(text string) []string {
+	var lines []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// nextHunk returns the hunk holding the first change at or after start, with its
+// context, and where to continue. Changes at most twice the context apart share a hunk.
+func nextHunk(lines []unifiedLine, start int) ([]unifiedLine, int) {
+	first := start
+	for first < len(lines) && lines[first].op == ' ' {
+		first++
+	}
+	if first == len(lines) {
+		return nil, len(lines)
+	}
+
+	last := first
+	for i := first; i < len(lines); i++ {
+		if lines[i].op == ' ' {
+			continue
+		}
+		if i-last-1 > 2*unifiedContext {
+			break
+		}
+		last = i
+	}
+
+	from := max(start, first-unifiedContext)
+	to := min(len(lines), last+unifiedContext+1)
+	return lines[from:to], to
+}
+
+// writeHunk writes a hunk's range header and lines
+func writeHunk(w io.Writer, hunk []unifiedLine) {
+	// A side without lines (an empty file) is numbered 0
+	oldStart, oldCount, newStart, newCount := 0, 0, 0, 0
+	for _, line := range hunk {
+		if line.op != '+' {
+			if oldCount == 0 {
+				oldStart = line.oldLine
+			}
+			oldCount++
+		}
+		if line.op != '-' {
+			if newCount == 0 {
+				newStart = line.newLine
+			}
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, line := range hunk {
+		fmt.Fprintf(w, "%c%s\n", line.op, line.text)
+		if line.noNewline {
+			fmt.Fprintln(w, `\ No newline at end of file`)
+		}
+	}
+}
+
+// hunkRange formats one side of a hunk header: "start,count", or "start" for one line
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,110 @@
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{
+			name: "modified line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			expected: "--- a/f\n+++ b/f\n" +
+				"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "added file",
+			old:      "",
+			new:      "one\ntwo\n",
+			expected: "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name:     "removed file",
+			old:      "one\n",
+			new:      "",
+			expected: "--- a/f\n+++ b/f\n@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			expected: "--- a/f\n+++ b/f\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:     "identical",
+			old:      "same\n",
+			new:      "same\n",
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			UnifiedDiff(&buf, "a/f", "b/f", []byte(tc.old), []byte(tc.new))
+			if buf.String() != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_Hunks(t *testing.T) {
+	var old, new strings.Builder
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&old, "line %d\n", i)
+		switch i {
+		case 2, 9, 25: // 2 and 9 are 6 lines apart and share a hunk; 25 gets its own
+			fmt.Fprintf(&new, "changed %d\n", i)
+		default:
+			fmt.Fprintf(&new, "line %d\n", i)
+		}
+	}
+
+	var buf bytes.Buffer
+	UnifiedDiff(&buf, "a/f", "b/f", []byte(old.String()), []byte(new.String()))
+	if headers := strings.Count(buf.String(), "\n@@ "); headers != 2 {
+		t.Fatalf("Expected 2 hunks, got %d:\n%s", headers, buf.String())
+	}
+	if !strings.Contains(buf.String(), "@@ -1,12 +1,12 @@\n") || !strings.Contains(buf.String(), "@@ -22,7 +22,7 @@\n") {
+		t.Errorf("Unexpected hunk ranges:\n%s", buf.String())
+	}
+
+	// The patch applies: git turns old into new with it
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "f"), []byte(old.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	apply := exec.Command("git", "apply", "-")
+	apply.Dir = dir
+	apply.Stdin = &buf
+	if out, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("git apply failed: %v\n%s", err, out)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "f")); string(got) != new.String() {
+		t.Errorf("Expected the patch to produce the new content, got:\n%s", got)
+	}
+}
+
+func TestUnifiedDiff_Binary(t *testing.T) {
+	var buf bytes.Buffer
+	UnifiedDiff(&buf, "a/logo.png", "b/logo.png", []byte("\x89PNG\x00\x01"), []byte("\x89PNG\x00\x02"))
+	if buf.String() != "Binary files a/logo.png and b/logo.png differ\n" {
+		t.Errorf("Expected a one-line binary notice, got %q", buf.String())
+	}
+}
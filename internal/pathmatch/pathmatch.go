@@ -0,0 +1,214 @@
+// Package pathmatch matches paths against gitignore-style patterns, the syntax of exclude
+// lists. Patterns apply in order and the last one matching a path decides, so a later
+// "!pattern" brings back a path an earlier pattern excluded.
+//
+//   - "*", "?" and "[...]" match within one path segment; "**" as a whole segment matches
+//     any number of segments ("**/testdata/**", "docs/**/*.png"), and trailing "/**"
+//     everything inside a directory
+//   - a pattern matches whole segments at any depth: "doc" excludes doc/ and a/doc, but
+//     not document_parser.go. A leading "/" anchors it to the root instead. Unlike git, a
+//     slash inside a pattern ("vendor/lib") doesn't anchor it, as exclude lists always
+//     worked that way
+//   - a trailing "/" only matches directories, and everything below them
+//   - a leading "!" negates the pattern; "\!" matches a literal "!". As in git, a file
+//     can't be brought back while a directory above it is excluded
+//   - a pattern segment always matches a name it spells out exactly, so a name holding
+//     "[", "*" or "?" can be given as is; "\" escapes a metacharacter otherwise
+package pathmatch
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// pattern is a compiled pattern
+type pattern struct {
+	text     string // As written, returned by Match
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	segments []string
+}
+
+// compile parses a pattern, returning false for one that matches nothing ("", "/", "!")
+func compile(text string) (pattern, bool) {
+	p := pattern{text: text}
+	body := filepath.ToSlash(text)
+	if rest, ok := strings.CutPrefix(body, "!"); ok {
+		p.negate, body = true, rest
+	}
+	if rest, ok := strings.CutSuffix(body, "/"); ok {
+		p.dirOnly, body = true, rest
+	}
+	if rest, ok := strings.CutPrefix(body, "/"); ok {
+		p.anchored, body = true, rest
+	}
+	if body == "" {
+		return p, false
+	}
+	for _, segment := range strings.Split(body, "/") {
+		// Repeated slashes and consecutive "**" segments mean the same as single ones
+		if segment == "" || segment == "**" && len(p.segments) > 0 && p.segments[len(p.segments)-1] == "**" {
+			continue
+		}
+		p.segments = append(p.segments, segment)
+	}
+	return p, true
+}
+
+// matches reports whether the pattern matches a path given as segments, ignoring negation
+func (p pattern) matches(segments []string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.anchored {
+		return matchSegments(p.segments, segments)
+	}
+	for start := range segments {
+		if matchSegments(p.segments, segments[start:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments reports whether glob segments match all of a path's segments. "**"
+// matches any number of segments, and at least one at the end of the pattern.
+func matchSegments(globs, segments []string) bool {
+	if len(globs) == 0 {
+		return len(segments) == 0
+	}
+	if globs[0] == "**" {
+		if len(globs) == 1 {
+			return len(segments) > 0
+		}
+		for skip := 0; skip <= len(segments); skip++ {
+			if matchSegments(globs[1:], segments[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if !matchSegment(globs[0], segments[0]) {
+		return false
+	}
+	return matchSegments(globs[1:], segments[1:])
+}
+
+// matchSegment reports whether a glob matches one path segment. A glob also matches the
+// name it spells out, so names holding metacharacters can be excluded as written:
+// "[draft] notes.md" and "what?.md" match those files, which "[draft]" as a character
+// class wouldn't, and "notes[1.md" isn't dropped as a malformed glob.
+func matchSegment(glob, segment string) bool {
+	if glob == segment {
+		return true
+	}
+	ok, _ := path.Match(glob, segment)
+	return ok
+}
+
+// Matcher matches paths against a list of patterns
+type Matcher struct {
+	patterns []pattern
+}
+
+// New compiles patterns into a Matcher. Patterns that can match nothing are dropped.
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, text := range patterns {
+		if p, ok := compile(text); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m
+}
+
+// Match returns the pattern that excludes a path relative to the patterns' root, or ""
+// when the path isn't excluded: the last pattern matching the path, or the one excluding
+// a directory above it. isDir tells whether the path itself is a directory.
+func (m *Matcher) Match(relPath string, isDir bool) string {
+	if len(m.patterns) == 0 {
+		return ""
+	}
+	clean := path.Clean(filepath.ToSlash(relPath))
+	if clean == "." || clean == "/" {
+		return ""
+	}
+	segments := strings.Split(strings.TrimPrefix(clean, "/"), "/")
+
+	for i := 1; i < len(segments); i++ {
+		if excludedBy := m.decide(segments[:i], true); excludedBy != "" {
+			return excludedBy
+		}
+	}
+	return m.decide(segments, isDir)
+}
+
+// Excluded reports whether a path relative to the patterns' root is excluded
+func (m *Matcher) Excluded(relPath string, isDir bool) bool {
+	return m.Match(relPath, isDir) != ""
+}
+
+// decide applies every pattern to one path, ignoring the directories above it
+func (m *Matcher) decide(segments []string, isDir bool) string {
+	excludedBy := ""
+	for _, p := range m.patterns {
+		if !p.matches(segments, isDir) {
+			continue
+		}
+		if p.negate {
+			excludedBy = ""
+		} else {
+			excludedBy = p.text
+		}
+	}
+	return excludedBy
+}
+
+// Match is New(patterns).Match(relPath, isDir), for a single lookup
+func Match(patterns []string, relPath string, isDir bool) string {
+	return New(patterns).Match(relPath, isDir)
+}
+
+// Matches reports whether a single pattern, read as if it weren't negated, matches a
+// path or a directory above it, whatever other patterns would decide. It tells whether a
+// pattern has any effect on a set of paths.
+func Matches(text, relPath string) bool {
+	p, ok := compile(text)
+	if !ok {
+		return false
+	}
+	clean := path.Clean(filepath.ToSlash(relPath))
+	if clean == "." || clean == "/" {
+		return false
+	}
+	segments := strings.Split(strings.TrimPrefix(clean, "/"), "/")
+	for i := 1; i <= len(segments); i++ {
+		if p.matches(segments[:i], i < len(segments)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob reports whether a glob matches a whole path, both relative to the same root.
+// Its segments match as a pattern's do, with none of the exclude rules: it is always
+// anchored, never negated and matches files and directories alike.
+func MatchGlob(glob, relPath string) bool {
+	return matchSegments(splitSegments(glob), splitSegments(relPath))
+}
+
+// splitSegments splits a path into its segments, leaving out empty and "." ones
+func splitSegments(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(p), "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
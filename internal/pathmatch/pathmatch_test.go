@@ -0,0 +1,137 @@
+package pathmatch
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		expected string
+	}{
+		// Whole segments, never substrings
+		{"substring of a file name", []string{"doc"}, "src/document_parser.go", false, ""},
+		{"substring of a directory name", []string{"doc"}, "docs/guide.md", false, ""},
+		{"segment at the root", []string{"doc"}, "doc/guide.md", false, "doc"},
+		{"segment at any depth", []string{"doc"}, "src/doc/guide.md", false, "doc"},
+		{"file with the name", []string{"doc"}, "src/doc", false, "doc"},
+
+		// Globs within a segment
+		{"extension anywhere", []string{"*.tmp"}, "a/b/notes.tmp", false, "*.tmp"},
+		{"prefix", []string{"test_*"}, "test_utils.go", false, "test_*"},
+		{"glob with a directory", []string{"sub/*.go"}, "sub/notes.go", false, "sub/*.go"},
+		{"star stays in its segment", []string{"sub/*.go"}, "sub/deep/notes.go", false, ""},
+		{"character class", []string{"file[0-9].txt"}, "file7.txt", false, "file[0-9].txt"},
+
+		// Multi-segment patterns match at any depth, as exclude lists always did
+		{"inner slash at any depth", []string{"vendor/lib"}, "a/vendor/lib/x.go", false, "vendor/lib"},
+		{"inner slash, whole segments", []string{"vendor/lib"}, "vendor/library.go", false, ""},
+
+		// Anchoring
+		{"anchored at the root", []string{"/build"}, "build/out.bin", false, "/build"},
+		{"anchored elsewhere", []string{"/build"}, "src/build/out.bin", false, ""},
+
+		// Double star
+		{"testdata anywhere", []string{"**/testdata/**"}, "pkg/a/testdata/in.txt", false, "**/testdata/**"},
+		{"testdata at the root", []string{"**/testdata/**"}, "testdata/in.txt", false, "**/testdata/**"},
+		{"trailing double star not the directory", []string{"testdata/**"}, "testdata", true, ""},
+		{"double star between", []string{"docs/**/*.png"}, "docs/a/b/img.png", false, "docs/**/*.png"},
+		{"double star matching nothing", []string{"docs/**/*.png"}, "docs/img.png", false, "docs/**/*.png"},
+		{"double star other extension", []string{"docs/**/*.png"}, "docs/a/img.jpg", false, ""},
+
+		// Directories only
+		{"directory contents", []string{"tmp/"}, "tmp/cache.bin", false, "tmp/"},
+		{"directory itself", []string{"tmp/"}, "tmp", true, "tmp/"},
+		{"file with a directory pattern", []string{"tmp/"}, "tmp", false, ""},
+
+		// Negation
+		{"negated file kept", []string{"*.txt", "!keep.txt"}, "a/keep.txt", false, ""},
+		{"others still excluded", []string{"*.txt", "!keep.txt"}, "a/other.txt", false, "*.txt"},
+		{"last pattern wins", []string{"!keep.txt", "*.txt"}, "keep.txt", false, "*.txt"},
+		{"directory contents re-included", []string{"build/*", "!build/keep.txt"}, "build/keep.txt", false, ""},
+		{"excluded directory can't be re-included", []string{"build/", "!build/keep.txt"}, "build/keep.txt", false, "build/"},
+		{"escaped exclamation mark", []string{`\!important.md`}, "!important.md", false, `\!important.md`},
+
+		// Names with spaces, "#", non-ASCII and metacharacters
+		{"spaces and hash", []string{"release notes #1.md"}, "docs/release notes #1.md", false, "release notes #1.md"},
+		{"leading space", []string{" draft.md"}, "docs/ draft.md", false, " draft.md"},
+		{"leading space is part of the name", []string{" draft.md"}, "docs/draft.md", false, ""},
+		{"non-ASCII", []string{"café/*.md"}, "docs/café/naïve.md", false, "café/*.md"},
+		{"brackets spelled out", []string{"[draft] plan.md"}, "docs/[draft] plan.md", false, "[draft] plan.md"},
+		{"brackets still a class", []string{"[draft] plan.md"}, "docs/d plan.md", false, "[draft] plan.md"},
+		{"question mark spelled out", []string{"what?.md"}, "what?.md", false, "what?.md"},
+		{"malformed glob spelled out", []string{"notes[1.md"}, "notes[1.md", false, "notes[1.md"},
+		{"escaped star", []string{`star\*.md`}, "star*.md", false, `star\*.md`},
+		{"escaped star only literal", []string{`star\*.md`}, "starry.md", false, ""},
+
+		// Nothing to match
+		{"no patterns", nil, "main.go", false, ""},
+		{"empty patterns", []string{"", "/", "!"}, "main.go", false, ""},
+		{"root path", []string{"*"}, ".", true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Match(tc.patterns, tc.path, tc.isDir); got != tc.expected {
+				t.Errorf("Match(%q, %q, %t) = %q, expected %q", tc.patterns, tc.path, tc.isDir, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMatcher_Reuse(t *testing.T) {
+	m := New([]string{"*.log", "!debug.log"})
+	for path, expected := range map[string]bool{"app.log": true, "logs/debug.log": false, "main.go": false} {
+		if got := m.Excluded(path, false); got != expected {
+			t.Errorf("Excluded(%q) = %t, expected %t", path, got, expected)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"*.tmp", "a/notes.tmp", true},
+		{"tests/", "tests/unit/a_test.go", true},
+		{"tests/", "tests", false}, // A file named tests isn't a directory
+		{"/build", "src/build/out.bin", false},
+		{"!keep.txt", "tmp/keep.txt", true}, // Negation is ignored
+		{"testdata/**", "pkg/testdata/in.txt", true},
+		{"*.tmp,test_*", "test_a.go", false},
+		{"", "a.go", false},
+	}
+
+	for _, tc := range testCases {
+		if got := Matches(tc.pattern, tc.path); got != tc.expected {
+			t.Errorf("Matches(%q, %q) = %v, expected %v", tc.pattern, tc.path, got, tc.expected)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{"**/*.proto", "user.proto", true},
+		{"**/*.proto", "v1/internal/user.proto", true},
+		{"*.proto", "v1/user.proto", false}, // Always anchored
+		{"v1", "v1/a.txt", false},           // The whole path must match
+		{"v1/**", "v1/a/b.txt", true},
+		{"!*.md", "!a.md", true}, // Never negated
+		{"docs/", "docs", true},  // Files and directories alike
+		{"notes[1].md", "notes[1].md", true},
+		{`notes\[1\].md`, "notes1.md", false},
+	}
+
+	for _, tc := range testCases {
+		if got := MatchGlob(tc.glob, tc.path); got != tc.expected {
+			t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tc.glob, tc.path, got, tc.expected)
+		}
+	}
+}
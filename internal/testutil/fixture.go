@@ -0,0 +1,260 @@
+// Package testutil provides helpers for building local Git fixture repositories
+// so integration tests can exercise sync flows without network access.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DefaultBranch is the branch fixture repositories start on
+const DefaultBranch = "main"
+
+// RequireGit skips the test when the git binary is not available.
+// Local clones over file:// and merge-file both shell out to git.
+func RequireGit(t testing.TB) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping integration test")
+	}
+}
+
+// FixtureRepo is a bare "upstream" repository plus a working clone used to author commits
+type FixtureRepo struct {
+	t       testing.TB
+	BareDir string
+	WorkDir string
+	repo    *git.Repository
+	clock   time.Time
+}
+
+// NewFixtureRepo creates an empty upstream repository named name.git in a temp directory
+func NewFixtureRepo(t testing.TB, name string) *FixtureRepo {
+	t.Helper()
+	RequireGit(t)
+
+	root := t.TempDir()
+	bareDir := filepath.Join(root, name+".git")
+	workDir := filepath.Join(root, name+"-work")
+
+	bare, err := git.PlainInit(bareDir, true)
+	if err != nil {
+		t.Fatalf("Failed to init bare repository: %v", err)
+	}
+	setHead(t, bare, DefaultBranch)
+
+	repo, err := git.PlainInit(workDir, false)
+	if err != nil {
+		t.Fatalf("Failed to init working repository: %v", err)
+	}
+	setHead(t, repo, DefaultBranch)
+
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{bareDir},
+	}); err != nil {
+		t.Fatalf("Failed to add origin remote: %v", err)
+	}
+
+	return &FixtureRepo{
+		t:       t,
+		BareDir: bareDir,
+		WorkDir: workDir,
+		repo:    repo,
+		clock:   time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// setHead points HEAD at the given branch (used before the first commit exists)
+func setHead(t testing.TB, repo *git.Repository, branch string) {
+	t.Helper()
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	if err := repo.Storer.SetReference(head); err != nil {
+		t.Fatalf("Failed to set HEAD: %v", err)
+	}
+}
+
+// URL returns the file:// URL of the upstream repository
+func (f *FixtureRepo) URL() string {
+	return "file://" + filepath.ToSlash(f.BareDir)
+}
+
+// PathURL returns the URL-path form accepted by `add file`/`add directory`
+func (f *FixtureRepo) PathURL(path string) string {
+	return f.URL() + "/" + path
+}
+
+// Repo exposes the working repository for assertions
+func (f *FixtureRepo) Repo() *git.Repository {
+	return f.repo
+}
+
+// WriteFile writes a file in the working clone, creating parent directories
+func (f *FixtureRepo) WriteFile(path, content string) {
+	f.t.Helper()
+	fullPath := filepath.Join(f.WorkDir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		f.t.Fatalf("Failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		f.t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// Chmod sets the permissions of a file in the working clone, e.g. 0755 to commit it as executable
+func (f *FixtureRepo) Chmod(path string, mode os.FileMode) {
+	f.t.Helper()
+	if err := os.Chmod(filepath.Join(f.WorkDir, path), mode); err != nil {
+		f.t.Fatalf("Failed to set the mode of %s: %v", path, err)
+	}
+}
+
+// RemoveFile deletes a file from the working clone
+func (f *FixtureRepo) RemoveFile(path string) {
+	f.t.Helper()
+	if err := os.Remove(filepath.Join(f.WorkDir, path)); err != nil {
+		f.t.Fatalf("Failed to remove %s: %v", path, err)
+	}
+}
+
+// Symlink points path in the working clone at target, replacing any existing link
+func (f *FixtureRepo) Symlink(path, target string) {
+	f.t.Helper()
+	fullPath := filepath.Join(f.WorkDir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		f.t.Fatalf("Failed to create directory for %s: %v", path, err)
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		f.t.Fatalf("Failed to replace %s: %v", path, err)
+	}
+	if err := os.Symlink(target, fullPath); err != nil {
+		f.t.Fatalf("Failed to link %s to %s: %v", path, target, err)
+	}
+}
+
+// Commit stages every change, commits it, and pushes all branches and tags upstream.
+// Commit times advance by one minute per commit so histories are deterministic.
+func (f *FixtureRepo) Commit(message string) string {
+	f.t.Helper()
+	return f.commit(message, nil)
+}
+
+// Merge commits the working clone as a merge of branch into the current branch.
+// No merge is computed: write the merged content before calling it.
+func (f *FixtureRepo) Merge(branch, message string) string {
+	f.t.Helper()
+	other, err := f.repo.ResolveRevision(plumbing.Revision(plumbing.NewBranchReferenceName(branch)))
+	if err != nil {
+		f.t.Fatalf("Failed to resolve %s: %v", branch, err)
+	}
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	return f.commit(message, []plumbing.Hash{head.Hash(), *other})
+}
+
+// AdvanceClock moves the time of the next commits forward by d
+func (f *FixtureRepo) AdvanceClock(d time.Duration) {
+	f.clock = f.clock.Add(d)
+}
+
+func (f *FixtureRepo) commit(message string, parents []plumbing.Hash) string {
+	f.t.Helper()
+
+	worktree, err := f.repo.Worktree()
+	if err != nil {
+		f.t.Fatalf("Failed to get worktree: %v", err)
+	}
+
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		f.t.Fatalf("Failed to stage changes: %v", err)
+	}
+
+	f.clock = f.clock.Add(time.Minute)
+	signature := &object.Signature{Name: "Fixture", Email: "fixture@example.com", When: f.clock}
+	commit, err := worktree.Commit(message, &git.CommitOptions{
+		Author:            signature,
+		Committer:         signature,
+		Parents:           parents,
+		AllowEmptyCommits: true,
+	})
+	if err != nil {
+		f.t.Fatalf("Failed to commit: %v", err)
+	}
+
+	f.Push()
+	return commit.String()
+}
+
+// Push pushes all branches and tags to the upstream repository
+func (f *FixtureRepo) Push() {
+	f.t.Helper()
+	err := f.repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs: []gitconfig.RefSpec{
+			"+refs/heads/*:refs/heads/*",
+			"+refs/tags/*:refs/tags/*",
+		},
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		f.t.Fatalf("Failed to push: %v", err)
+	}
+}
+
+// CreateBranch creates a branch at HEAD and checks it out
+func (f *FixtureRepo) CreateBranch(name string) {
+	f.t.Helper()
+	f.checkout(name, true)
+}
+
+// Checkout switches the working clone to an existing branch
+func (f *FixtureRepo) Checkout(name string) {
+	f.t.Helper()
+	f.checkout(name, false)
+}
+
+func (f *FixtureRepo) checkout(name string, create bool) {
+	f.t.Helper()
+	worktree, err := f.repo.Worktree()
+	if err != nil {
+		f.t.Fatalf("Failed to get worktree: %v", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(name),
+		Create: create,
+	}); err != nil {
+		f.t.Fatalf("Failed to checkout %s: %v", name, err)
+	}
+}
+
+// Tag creates a lightweight tag at HEAD and pushes it
+func (f *FixtureRepo) Tag(name string) {
+	f.t.Helper()
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	if _, err := f.repo.CreateTag(name, head.Hash(), nil); err != nil {
+		f.t.Fatalf("Failed to create tag %s: %v", name, err)
+	}
+	f.Push()
+}
+
+// Head returns the commit hash at HEAD of the working clone
+func (f *FixtureRepo) Head() string {
+	f.t.Helper()
+	head, err := f.repo.Head()
+	if err != nil {
+		f.t.Fatalf("Failed to get HEAD: %v", err)
+	}
+	return head.Hash().String()
+}
@@ -0,0 +1,58 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestFixtureRepo_BranchesAndTags(t *testing.T) {
+	upstream := NewFixtureRepo(t, "fixture")
+	upstream.WriteFile("file.txt", "v1\n")
+	first := upstream.Commit("first")
+	upstream.Tag("v1.0.0")
+
+	upstream.CreateBranch("develop")
+	upstream.WriteFile("file.txt", "develop\n")
+	developHead := upstream.Commit("develop change")
+
+	bare, err := git.PlainOpen(upstream.BareDir)
+	if err != nil {
+		t.Fatalf("Failed to open bare repository: %v", err)
+	}
+
+	testCases := []struct {
+		ref      plumbing.ReferenceName
+		expected string
+	}{
+		{plumbing.NewBranchReferenceName(DefaultBranch), first},
+		{plumbing.NewBranchReferenceName("develop"), developHead},
+		{plumbing.NewTagReferenceName("v1.0.0"), first},
+	}
+
+	for _, tc := range testCases {
+		ref, err := bare.Reference(tc.ref, true)
+		if err != nil {
+			t.Errorf("Expected %s to be pushed upstream: %v", tc.ref, err)
+			continue
+		}
+		if ref.Hash().String() != tc.expected {
+			t.Errorf("Expected %s at %s, got %s", tc.ref, tc.expected, ref.Hash())
+		}
+	}
+}
+
+func TestNewProject_IsolatesHome(t *testing.T) {
+	project := NewProject(t)
+
+	if project.Home == "" || project.CacheDir() == "" {
+		t.Fatal("Expected an isolated home directory")
+	}
+	if !project.Exists("README.md") {
+		t.Error("Expected the project to have an initial commit with README.md")
+	}
+	if _, err := project.Repo().Head(); err != nil {
+		t.Errorf("Expected project HEAD to exist: %v", err)
+	}
+}
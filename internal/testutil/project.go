@@ -0,0 +1,125 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Project is a temporary local repository that cherry-go syncs files into
+type Project struct {
+	t    testing.TB
+	Dir  string
+	Home string
+	repo *git.Repository
+}
+
+// NewProject creates a git-initialized project directory and points HOME at an
+// isolated temp directory so the repository cache and snapshots stay per-test
+func NewProject(t testing.TB) *Project {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("Failed to init project repository: %v", err)
+	}
+
+	p := &Project{t: t, Dir: dir, Home: home, repo: repo}
+
+	// Start with one commit so HEAD exists for branch operations
+	p.WriteFile("README.md", "# test project\n")
+	p.Commit("initial commit")
+
+	return p
+}
+
+// Path returns the absolute path of a project-relative path
+func (p *Project) Path(rel string) string {
+	return filepath.Join(p.Dir, rel)
+}
+
+// CacheDir returns the isolated repository cache directory
+func (p *Project) CacheDir() string {
+	return filepath.Join(p.Home, ".cache", "cherry-go", "repos")
+}
+
+// ConfigPath returns the project's configuration file path
+func (p *Project) ConfigPath() string {
+	return p.Path(".cherry-go.yaml")
+}
+
+// Repo exposes the project repository for assertions
+func (p *Project) Repo() *git.Repository {
+	return p.repo
+}
+
+// WriteFile writes a file in the project, creating parent directories
+func (p *Project) WriteFile(rel, content string) {
+	p.t.Helper()
+	fullPath := p.Path(rel)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		p.t.Fatalf("Failed to create directory for %s: %v", rel, err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		p.t.Fatalf("Failed to write %s: %v", rel, err)
+	}
+}
+
+// ReadFile returns the content of a project file, failing the test if it is missing
+func (p *Project) ReadFile(rel string) string {
+	p.t.Helper()
+	data, err := os.ReadFile(p.Path(rel))
+	if err != nil {
+		p.t.Fatalf("Failed to read %s: %v", rel, err)
+	}
+	return string(data)
+}
+
+// Exists reports whether a project-relative path exists
+func (p *Project) Exists(rel string) bool {
+	_, err := os.Stat(p.Path(rel))
+	return err == nil
+}
+
+// Commit stages every change in the project and commits it
+func (p *Project) Commit(message string) string {
+	p.t.Helper()
+
+	worktree, err := p.repo.Worktree()
+	if err != nil {
+		p.t.Fatalf("Failed to get worktree: %v", err)
+	}
+	if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		p.t.Fatalf("Failed to stage changes: %v", err)
+	}
+
+	commit, err := worktree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: "Tester", Email: "tester@example.com", When: time.Now()},
+	})
+	if err != nil {
+		p.t.Fatalf("Failed to commit: %v", err)
+	}
+	return commit.String()
+}
+
+// Chdir switches the process working directory to the project until the test ends
+func (p *Project) Chdir() {
+	p.t.Helper()
+
+	previous, err := os.Getwd()
+	if err != nil {
+		p.t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(p.Dir); err != nil {
+		p.t.Fatalf("Failed to change directory: %v", err)
+	}
+	p.t.Cleanup(func() { _ = os.Chdir(previous) })
+}
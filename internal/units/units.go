@@ -0,0 +1,313 @@
+// Package units parses the human-friendly durations ("30d", "1.5h") and sizes ("20GB",
+// "512MiB") used by configuration options and flags, so every option accepts the same
+// spellings and reports mistakes the same way.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hints appended to parse errors, naming spellings that work
+const (
+	durationHint = "use a number with a unit, like 90m, 1.5h, 7d or 2w"
+	sizeHint     = "use a number with a unit, like 512KB, 20GB or 1.5GiB"
+)
+
+// ParseError is a duration or size that could not be parsed. Values read from YAML carry
+// their position, and the name of their field once AnnotateField has found it.
+type ParseError struct {
+	Kind   string // "duration" or "size"
+	Value  string
+	Field  string // Dotted path of the YAML field, e.g. "options.timeout"
+	Line   int    // Position in the YAML document, 0 for values not read from YAML
+	Column int
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("invalid %s %q", e.Kind, e.Value)
+	if e.Line > 0 {
+		msg += fmt.Sprintf(" at line %d", e.Line)
+	}
+	if e.Field != "" {
+		msg = e.Field + ": " + msg
+	}
+	return msg + ": " + e.Reason
+}
+
+// durationPattern splits a duration into optional leading weeks and days and the rest,
+// which time.ParseDuration handles
+var durationPattern = regexp.MustCompile(`^(?:([0-9]+(?:\.[0-9]+)?)w)?(?:([0-9]+(?:\.[0-9]+)?)d)?(.*)$`)
+
+// ParseDuration parses a duration like time.ParseDuration, also accepting weeks and days
+// ahead of the smaller units ("2w", "7d", "1d12h", "1.5d"). Negative durations are
+// rejected, and so are bare numbers other than 0, whose unit would be a guess.
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	invalid := func(reason string) error {
+		return &ParseError{Kind: "duration", Value: s, Reason: reason}
+	}
+	if value == "" {
+		return 0, invalid(durationHint)
+	}
+	if value == "0" {
+		return 0, nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return 0, invalid("missing unit; " + durationHint)
+	}
+
+	match := durationPattern.FindStringSubmatch(value)
+	weeks, days, rest := match[1], match[2], match[3]
+	if weeks == "" && days == "" && rest == "" {
+		return 0, invalid(durationHint)
+	}
+
+	total := 0.0
+	for _, part := range []struct {
+		count string
+		size  time.Duration
+	}{{weeks, 7 * 24 * time.Hour}, {days, 24 * time.Hour}} {
+		if part.count == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(part.count, 64)
+		if err != nil {
+			return 0, invalid(durationHint)
+		}
+		total += n * float64(part.size)
+	}
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, invalid(durationHint)
+		}
+		if d < 0 {
+			return 0, invalid("must not be negative")
+		}
+		total += float64(d)
+	}
+	if total > math.MaxInt64 {
+		return 0, invalid("too long")
+	}
+	return time.Duration(total), nil
+}
+
+// Size units, matched case-insensitively: decimal KB, MB… and binary KiB, MiB…
+var sizeUnits = []struct {
+	name string
+	size int64
+}{
+	{"PiB", 1 << 50}, {"PB", 1e15},
+	{"TiB", 1 << 40}, {"TB", 1e12},
+	{"GiB", 1 << 30}, {"GB", 1e9},
+	{"MiB", 1 << 20}, {"MB", 1e6},
+	{"KiB", 1 << 10}, {"KB", 1e3},
+	{"B", 1},
+}
+
+// sizePattern splits a size into its number and unit, which may be separated by a space
+var sizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([A-Za-z]*)$`)
+
+// ParseSize parses a size in bytes: a plain number of bytes, or a number with a decimal
+// (KB, MB, GB, TB, PB) or binary (KiB, MiB, GiB, TiB, PiB) unit, e.g. "20GB", "1.5 GiB".
+// Units are case-insensitive; a fractional size is rounded to whole bytes.
+func ParseSize(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	invalid := func(reason string) error {
+		return &ParseError{Kind: "size", Value: s, Reason: reason}
+	}
+
+	match := sizePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, invalid(sizeHint)
+	}
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, invalid(sizeHint)
+	}
+
+	unit := int64(1)
+	if match[2] != "" {
+		known := false
+		for _, u := range sizeUnits {
+			if strings.EqualFold(match[2], u.name) {
+				unit, known = u.size, true
+				break
+			}
+		}
+		if !known {
+			return 0, invalid(fmt.Sprintf("unknown unit %q; %s", match[2], sizeHint))
+		}
+	}
+
+	size := math.Round(n * float64(unit))
+	if size >= math.MaxInt64 {
+		return 0, invalid("too large")
+	}
+	return int64(size), nil
+}
+
+// FormatDuration writes a duration the way ParseDuration reads it back: in whole weeks or
+// days when it is a multiple of them ("2w", "30d"), else as time.Duration does ("1h30m0s")
+func FormatDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d == 0:
+		return "0"
+	case d%(7*day) == 0:
+		return fmt.Sprintf("%dw", d/(7*day))
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// FormatSize writes a size the way ParseSize reads it back, with the largest unit that
+// divides it exactly ("20GB", "512MiB", "1500B")
+func FormatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if n != 0 && n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.name)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// Duration is a time.Duration read from YAML with ParseDuration and written back with
+// FormatDuration, for configuration options
+type Duration time.Duration
+
+// Std returns the duration as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return FormatDuration(time.Duration(d))
+}
+
+// UnmarshalYAML parses a scalar like "30d" or "1.5h"
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := ParseDuration(node.Value)
+	if err := scalarError(node, err); err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML writes the duration in the form UnmarshalYAML reads
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// Size is a size in bytes read from YAML with ParseSize and written back with
+// FormatSize, for configuration options
+type Size int64
+
+// Bytes returns the size in bytes
+func (s Size) Bytes() int64 {
+	return int64(s)
+}
+
+func (s Size) String() string {
+	return FormatSize(int64(s))
+}
+
+// UnmarshalYAML parses a scalar like "20GB" or a plain number of bytes
+func (s *Size) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := ParseSize(node.Value)
+	if err := scalarError(node, err); err != nil {
+		return err
+	}
+	*s = Size(parsed)
+	return nil
+}
+
+// MarshalYAML writes the size in the form UnmarshalYAML reads
+func (s Size) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// scalarError positions a parse error at its YAML node, and rejects nodes that aren't
+// scalars, like a list where a duration belongs
+func scalarError(node *yaml.Node, err error) error {
+	var parseErr *ParseError
+	if node.Kind != yaml.ScalarNode {
+		kind := "duration"
+		if errors.As(err, &parseErr) {
+			kind = parseErr.Kind
+		}
+		return &ParseError{Kind: kind, Value: node.Tag, Line: node.Line, Column: node.Column, Reason: "expected a single value"}
+	}
+	if errors.As(err, &parseErr) {
+		parseErr.Line, parseErr.Column = node.Line, node.Column
+		return parseErr
+	}
+	return err
+}
+
+// AnnotateField names the YAML field of a ParseError returned while unmarshalling data,
+// so the message says which option is wrong ("options.timeout: invalid duration
+// …"). Other errors are returned unchanged.
+func AnnotateField(data []byte, err error) error {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line == 0 || parseErr.Field != "" {
+		return err
+	}
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil {
+		return err
+	}
+	if field, ok := fieldAt(&root, parseErr.Line, parseErr.Column, ""); ok {
+		parseErr.Field = field
+	}
+	return err
+}
+
+// fieldAt returns the dotted path of the mapping value at line and column below node,
+// with list items as [index]
+func fieldAt(node *yaml.Node, line, column int, path string) (string, bool) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if field, ok := fieldAt(child, line, column, path); ok {
+				return field, true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field := key.Value
+			if path != "" {
+				field = path + "." + key.Value
+			}
+			if value.Line == line && value.Column == column {
+				return field, true
+			}
+			if found, ok := fieldAt(value, line, column, field); ok {
+				return found, true
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			field := fmt.Sprintf("%s[%d]", path, i)
+			if item.Line == line && item.Column == column {
+				return field, true
+			}
+			if found, ok := fieldAt(item, line, column, field); ok {
+				return found, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,237 @@
+package units
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseDuration(t *testing.T) {
+	const day = 24 * time.Hour
+	testCases := []struct {
+		input    string
+		expected time.Duration
+	}{
+		{"0", 0},
+		{"0s", 0},
+		{"500ms", 500 * time.Millisecond},
+		{"45s", 45 * time.Second},
+		{"90m", 90 * time.Minute},
+		{"12h", 12 * time.Hour},
+		{"1.5h", 90 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * day},
+		{"30d", 30 * day},
+		{"1.5d", 36 * time.Hour},
+		{"2w", 14 * day},
+		{"0.5w", 84 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"1w2d", 9 * day},
+		{"1w2d3h4m", 9*day + 3*time.Hour + 4*time.Minute},
+		{" 7d ", 7 * day},
+	}
+	for _, tc := range testCases {
+		if result, err := ParseDuration(tc.input); err != nil || result != tc.expected {
+			t.Errorf("ParseDuration(%q) = %v, %v, expected %v", tc.input, result, err, tc.expected)
+		}
+	}
+}
+
+func TestParseDuration_Rejected(t *testing.T) {
+	testCases := []struct {
+		input  string
+		reason string
+	}{
+		{"", "use a number with a unit"},
+		{"   ", "use a number with a unit"},
+		{"30", "missing unit"},
+		{"1.5", "missing unit"},
+		{"d", "use a number with a unit"},
+		{"w", "use a number with a unit"},
+		{"7days", "use a number with a unit"},
+		{"1h2d", "use a number with a unit"},
+		{"2d1w", "use a number with a unit"},
+		{"-1h", "must not be negative"},
+		{"-7d", "use a number with a unit"},
+		{"soon", "use a number with a unit"},
+		{"1y", "use a number with a unit"},
+		{"100000000w", "too long"},
+	}
+	for _, tc := range testCases {
+		_, err := ParseDuration(tc.input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Kind != "duration" {
+			t.Errorf("ParseDuration(%q): expected a duration ParseError, got %v", tc.input, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.reason) || !strings.Contains(err.Error(), `"`+tc.input+`"`) {
+			t.Errorf("ParseDuration(%q): expected the error to quote the value and mention %q, got: %v", tc.input, tc.reason, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"512B", 512},
+		{"1KB", 1000},
+		{"1KiB", 1024},
+		{"1.5KB", 1500},
+		{"1.5KiB", 1536},
+		{"20MB", 20_000_000},
+		{"20MiB", 20 << 20},
+		{"20GB", 20_000_000_000},
+		{"1.5GiB", 3 << 29},
+		{"2TB", 2_000_000_000_000},
+		{"2TiB", 2 << 40},
+		{"1PB", 1_000_000_000_000_000},
+		{"1PiB", 1 << 50},
+		{"20gb", 20_000_000_000},
+		{"20gib", 20 << 30},
+		{"20 GB", 20_000_000_000},
+		{" 512MiB ", 512 << 20},
+		{"0.5B", 1},
+	}
+	for _, tc := range testCases {
+		if result, err := ParseSize(tc.input); err != nil || result != tc.expected {
+			t.Errorf("ParseSize(%q) = %d, %v, expected %d", tc.input, result, err, tc.expected)
+		}
+	}
+}
+
+func TestParseSize_Rejected(t *testing.T) {
+	testCases := []struct {
+		input  string
+		reason string
+	}{
+		{"", "use a number with a unit"},
+		{"GB", "use a number with a unit"},
+		{"-1GB", "use a number with a unit"},
+		{"20G", `unknown unit "G"`},
+		{"20 gigabytes", `unknown unit "gigabytes"`},
+		{"20GB extra", "use a number with a unit"},
+		{"1,5GB", "use a number with a unit"},
+		{"1e3", "use a number with a unit"},
+		{"9000PiB", "too large"},
+	}
+	for _, tc := range testCases {
+		_, err := ParseSize(tc.input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Kind != "size" {
+			t.Errorf("ParseSize(%q): expected a size ParseError, got %v", tc.input, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("ParseSize(%q): expected the error to mention %q, got: %v", tc.input, tc.reason, err)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	durations := map[time.Duration]string{
+		0:                              "0",
+		14 * 24 * time.Hour:            "2w",
+		30 * 24 * time.Hour:            "30d",
+		36 * time.Hour:                 "36h0m0s",
+		90 * time.Minute:               "1h30m0s",
+		1500 * time.Millisecond:        "1.5s",
+		7*24*time.Hour + 2*time.Second: "168h0m2s",
+	}
+	for d, expected := range durations {
+		formatted := FormatDuration(d)
+		if formatted != expected {
+			t.Errorf("FormatDuration(%v) = %q, expected %q", d, formatted, expected)
+		}
+		if parsed, err := ParseDuration(formatted); err != nil || parsed != d {
+			t.Errorf("ParseDuration(FormatDuration(%v)) = %v, %v", d, parsed, err)
+		}
+	}
+
+	sizes := map[int64]string{
+		0:              "0",
+		1500:           "1500B",
+		1024:           "1KiB",
+		20_000_000_000: "20GB",
+		512 << 20:      "512MiB",
+		3 << 29:        "1536MiB",
+		1 << 50:        "1PiB",
+	}
+	for n, expected := range sizes {
+		formatted := FormatSize(n)
+		if formatted != expected {
+			t.Errorf("FormatSize(%d) = %q, expected %q", n, formatted, expected)
+		}
+		if parsed, err := ParseSize(formatted); err != nil || parsed != n {
+			t.Errorf("ParseSize(FormatSize(%d)) = %d, %v", n, parsed, err)
+		}
+	}
+}
+
+// limits is a configuration section with typed options
+type limits struct {
+	MaxAge  Duration `yaml:"max_age"`
+	MaxSize Size     `yaml:"max_size,omitempty"`
+}
+
+type limitsFile struct {
+	Options limits   `yaml:"options"`
+	Sources []limits `yaml:"sources"`
+}
+
+func TestYAML(t *testing.T) {
+	data := []byte("options:\n  max_age: 30d\n  max_size: 20GB\nsources:\n  - max_age: 1.5h\n    max_size: 1048576\n")
+	var file limitsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if file.Options.MaxAge.Std() != 30*24*time.Hour || file.Options.MaxSize.Bytes() != 20_000_000_000 {
+		t.Errorf("Unexpected options %+v", file.Options)
+	}
+	if file.Sources[0].MaxAge.Std() != 90*time.Minute || file.Sources[0].MaxSize.Bytes() != 1<<20 {
+		t.Errorf("Unexpected source limits %+v", file.Sources[0])
+	}
+
+	out, err := yaml.Marshal(file.Options)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if expected := "max_age: 30d\nmax_size: 20GB\n"; string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
+
+func TestYAML_ErrorsNameTheField(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"nested option", "options:\n  max_age: 30days\n", `options.max_age: invalid duration "30days" at line 2: use a number with a unit`},
+		{"list item", "sources:\n  - max_age: 1d\n  - max_age: 1h\n    max_size: 20G\n", `sources[1].max_size: invalid size "20G" at line 4: unknown unit "G"`},
+		{"bare number", "options:\n  max_age: 30\n", `options.max_age: invalid duration "30" at line 2: missing unit`},
+		{"not a scalar", "options:\n  max_age: [1d]\n", `options.max_age: invalid duration "!!seq" at line 2: expected a single value`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := []byte(tc.data)
+			var file limitsFile
+			err := AnnotateField(data, yaml.Unmarshal(data, &file))
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected an error containing %q, got: %v", tc.expected, err)
+			}
+		})
+	}
+
+	other := errors.New("unrelated")
+	if err := AnnotateField([]byte("a: b\n"), other); err != other {
+		t.Errorf("Expected other errors to pass through unchanged, got %v", err)
+	}
+}
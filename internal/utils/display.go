@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DisplayPath returns a path as it should appear in messages. Paths are shown as they
+// are, spaces, "#" and non-ASCII names included, except that a path whose names start or
+// end with whitespace, or that holds characters that don't print (tabs, newlines,
+// control characters), is quoted with Go escaping, so that " notes.md" doesn't read as
+// "notes.md" and a newline can't break a list apart. Machine-readable output (--porcelain,
+// --json) keeps paths verbatim instead.
+func DisplayPath(path string) string {
+	if needsQuoting(path) {
+		return strconv.Quote(path)
+	}
+	return path
+}
+
+// needsQuoting reports whether a path has a name with surrounding whitespace or a
+// character that doesn't print
+func needsQuoting(path string) bool {
+	for _, r := range path {
+		if !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	for _, name := range strings.Split(strings.ReplaceAll(path, `\`, "/"), "/") {
+		if name != strings.TrimSpace(name) {
+			return true
+		}
+	}
+	return false
+}
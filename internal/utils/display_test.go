@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestDisplayPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"docs/release notes.md", "docs/release notes.md"},
+		{"docs/#ideas.md", "docs/#ideas.md"},
+		{"docs/café/naïve résumé.md", "docs/café/naïve résumé.md"},
+		{"docs/日本語.md", "docs/日本語.md"},
+		{"docs/[draft] plan *?.md", "docs/[draft] plan *?.md"},
+		{" leading space.md", `" leading space.md"`},
+		{"docs/ leading space.md", `"docs/ leading space.md"`},
+		{"docs/trailing space /a.md", `"docs/trailing space /a.md"`},
+		{"docs/tab\there.md", `"docs/tab\there.md"`},
+		{"docs/new\nline.md", `"docs/new\nline.md"`},
+		{`say "hi".md`, `say "hi".md`},
+	}
+
+	for _, tc := range testCases {
+		if got := DisplayPath(tc.path); got != tc.expected {
+			t.Errorf("DisplayPath(%q) = %s, expected %s", tc.path, got, tc.expected)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -28,8 +30,227 @@ func ExtractRepoName(repoURL string) string {
 	return "repo"
 }
 
-// ParseURLPath parses a URL path in the format: repo-url/path or just path
-func ParseURLPath(urlPath string) (repoURL string, filePath string) {
+// RepoURL is a repository URL as given by the user, normalized for the configuration
+type RepoURL struct {
+	URL      string   // Repository URL to clone
+	Branch   string   // Branch named by a browser URL (.../tree/<branch>/...), empty otherwise
+	Path     string   // Path inside the repository, if the input named one
+	Warnings []string // Things worth telling the user, like a plain http URL
+}
+
+// acceptedURLForms is shown with every rejected repository URL
+const acceptedURLForms = `accepted forms:
+  https://github.com/owner/repo.git
+  https://github.com/owner/repo/tree/main/path (branch and path are taken from it)
+  git@github.com:owner/repo.git
+  ssh://git@host:2222/owner/repo.git
+  file:///path/to/repo.git
+  /path/to/repo.git or C:\path\to\repo.git`
+
+// invalidURL explains why a repository URL was rejected and what is accepted instead
+func invalidURL(raw, reason string) error {
+	return fmt.Errorf("invalid repository URL '%s': %s\n%s", raw, reason, acceptedURLForms)
+}
+
+// IsAbsolutePath reports whether p is an absolute local path on any platform: rooted
+// (/srv/repo, \srv\repo), UNC (\\server\share, //server/share) or starting with a
+// Windows drive letter (C:\work, C:/work). Drive letters are recognized everywhere, as
+// configurations travel between platforms; a one-letter scp-like host needs its user
+// (git@c:repo.git).
+func IsAbsolutePath(p string) bool {
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`) {
+		return true
+	}
+	return len(p) >= 2 && p[1] == ':' && ('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z')
+}
+
+// isSCPLike reports whether a URL uses git's scp-like syntax, [user@]host:path: no
+// scheme, a colon before the first slash, and not a local path with a drive letter
+func isSCPLike(raw string) bool {
+	if strings.Contains(raw, "://") || IsAbsolutePath(raw) {
+		return false
+	}
+	colon := strings.Index(raw, ":")
+	slash := strings.Index(raw, "/")
+	return colon > 0 && (slash == -1 || colon < slash)
+}
+
+// NormalizeRepoURL validates a repository URL and cleans it up for the configuration.
+// Browser URLs such as https://github.com/owner/repo/tree/main/lib (or GitLab's /-/tree/,
+// and /blob/ for files) are cut back to the repository, with the branch and path
+// returned separately; a branch name containing slashes can't be told apart from
+// the path, so only its first segment is taken. Query strings and fragments are
+// dropped, and .git is added to two-segment https paths (owner/repo). scp-like
+// (git@host:owner/repo.git), ssh://, git://, file:// URLs and absolute local paths,
+// Windows ones included, are accepted as they are; plain http:// is accepted with a
+// warning.
+func NormalizeRepoURL(raw string) (RepoURL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return RepoURL{}, invalidURL(raw, "it is empty")
+	}
+
+	// Local paths may hold spaces, as C:\Users\Jane Doe\repo does
+	if IsAbsolutePath(raw) {
+		if trimmed := strings.TrimRight(raw, `/\`); trimmed != "" && !strings.HasSuffix(trimmed, ":") {
+			raw = trimmed
+		}
+		return RepoURL{URL: raw}, nil
+	}
+	if strings.ContainsAny(raw, " \t\n") {
+		return RepoURL{}, invalidURL(raw, "it contains whitespace")
+	}
+
+	if isSCPLike(raw) {
+		host, repoPath, _ := strings.Cut(raw, ":")
+		if at := strings.LastIndex(host, "@"); at != -1 {
+			host = host[at+1:]
+		}
+		if host == "" {
+			return RepoURL{}, invalidURL(raw, "missing host before ':'")
+		}
+		if strings.Trim(repoPath, "/") == "" {
+			return RepoURL{}, invalidURL(raw, "missing repository path after ':'")
+		}
+		return RepoURL{URL: strings.TrimSuffix(raw, "/")}, nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return RepoURL{}, invalidURL(raw, err.Error())
+	}
+
+	switch parsed.Scheme {
+	case "https", "http", "ssh", "git", "git+ssh":
+	case "file":
+		if strings.Trim(parsed.Path, "/") == "" {
+			return RepoURL{}, invalidURL(raw, "missing repository path")
+		}
+		return RepoURL{URL: strings.TrimSuffix(raw, "/")}, nil
+	case "":
+		if first, _, _ := strings.Cut(raw, "/"); strings.Contains(first, ".") {
+			return RepoURL{}, invalidURL(raw, fmt.Sprintf("missing scheme (did you mean https://%s?)", raw))
+		}
+		return RepoURL{}, invalidURL(raw, "missing scheme")
+	default:
+		return RepoURL{}, invalidURL(raw, fmt.Sprintf("unsupported scheme '%s'", parsed.Scheme))
+	}
+
+	if parsed.Host == "" {
+		return RepoURL{}, invalidURL(raw, "missing host")
+	}
+	repoPath := strings.Trim(parsed.Path, "/")
+	if repoPath == "" {
+		return RepoURL{}, invalidURL(raw, "missing repository path")
+	}
+
+	var result RepoURL
+	if parsed.Scheme == "http" || parsed.Scheme == "https" {
+		segments := strings.Split(repoPath, "/")
+		// owner/repo come first, so a repository named tree or blob is left alone
+		for i := 2; i < len(segments); i++ {
+			if segments[i] != "tree" && segments[i] != "blob" {
+				continue
+			}
+			if i+1 >= len(segments) || segments[i+1] == "" {
+				return RepoURL{}, invalidURL(raw, fmt.Sprintf("no branch after /%s/", segments[i]))
+			}
+			end := i
+			if segments[i-1] == "-" {
+				end-- // GitLab: owner/repo/-/tree/<branch>
+			}
+			result.Branch = segments[i+1]
+			result.Path = strings.Join(segments[i+2:], "/")
+			repoPath = strings.Join(segments[:end], "/")
+			break
+		}
+
+		if strings.Count(repoPath, "/") == 1 && !strings.HasSuffix(repoPath, ".git") {
+			repoPath += ".git"
+		}
+		parsed.RawQuery, parsed.Fragment = "", ""
+	}
+	if parsed.Scheme == "http" {
+		result.Warnings = append(result.Warnings,
+			"http:// is unencrypted and many hosts only serve https; use https:// unless this server really is http only")
+	}
+
+	parsed.Path = "/" + repoPath
+	parsed.RawPath = ""
+	result.URL = parsed.String()
+	return result, nil
+}
+
+// SameRepository reports whether two repository URLs name the same repository,
+// ignoring a trailing slash or .git suffix
+func SameRepository(a, b string) bool {
+	trim := func(repoURL string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(repoURL), "/"), ".git")
+	}
+	return trim(a) == trim(b)
+}
+
+// ParseURLPath parses a URL path in the format repo-url/path, or just a path. The
+// repository part is normalized and validated with NormalizeRepoURL, so a browser
+// URL (.../tree/<branch>/path) also yields its branch. A local repository is given
+// by its absolute path up to a directory ending in .git (C:\repos\lib.git\docs). A
+// plain path comes back as Path with an empty URL; an absolute one is refused, since
+// paths name files inside a repository. Paths are taken verbatim, spaces, "#" and glob
+// metacharacters included, except after a scheme:// URL, where they are percent-decoded.
+func ParseURLPath(urlPath string) (RepoURL, error) {
+	repoURL, filePath := splitURLPath(urlPath)
+	if repoURL == "" {
+		if IsAbsolutePath(filePath) {
+			return RepoURL{}, fmt.Errorf("'%s' is an absolute local path: give a path inside the repository, "+
+				"or a local repository's path ending in .git followed by one (/srv/repos/lib.git/docs)", filePath)
+		}
+		return RepoURL{Path: filePath}, nil
+	}
+
+	parsed, err := NormalizeRepoURL(repoURL)
+	if err != nil {
+		return RepoURL{}, err
+	}
+	if filePath != "" {
+		parsed.Path = unescapeURLPath(repoURL, filePath)
+	}
+	return parsed, nil
+}
+
+// unescapeURLPath decodes the path after a scheme:// repository URL, as browser URLs
+// are, so "docs/release%20notes.md" names "docs/release notes.md". A path that isn't
+// valid escaping ("100%.md"), or follows a local or scp-like repository, is taken as is.
+func unescapeURLPath(repoURL, filePath string) string {
+	if !strings.Contains(repoURL, "://") {
+		return filePath
+	}
+	if unescaped, err := url.PathUnescape(filePath); err == nil {
+		return unescaped
+	}
+	return filePath
+}
+
+// isBrowserSegment reports whether a URL path segment starts the branch part of a
+// browser URL: GitHub's tree/ and blob/, or GitLab's -/tree/ and -/blob/
+func isBrowserSegment(segment string) bool {
+	return segment == "tree" || segment == "blob" || segment == "-"
+}
+
+// splitURLPath splits repo-url/path into the repository URL and the path after it.
+// Browser URLs are left whole for NormalizeRepoURL to take apart.
+func splitURLPath(urlPath string) (repoURL string, filePath string) {
+	// A local repository ends at its .git directory, whichever separators the path uses
+	if IsAbsolutePath(urlPath) {
+		slashed := strings.ReplaceAll(urlPath, `\`, "/")
+		if repo, rest, found := strings.Cut(slashed, ".git/"); found && strings.Trim(rest, "/") != "" {
+			return urlPath[:len(repo)+len(".git")], rest
+		}
+		if strings.HasSuffix(strings.TrimRight(slashed, "/"), ".git") {
+			return urlPath, ""
+		}
+		return "", urlPath
+	}
+
 	// Check if it contains a full URL
 	if strings.Contains(urlPath, "://") || strings.HasPrefix(urlPath, "git@") {
 		// Find where the repository URL ends and the path begins
@@ -77,6 +298,9 @@ func ParseURLPath(urlPath string) (repoURL string, filePath string) {
 		} else {
 			// HTTPS format: https://host/owner/repo/path/to/file
 			parts := strings.Split(urlPath, "/")
+			if len(parts) > 5 && isBrowserSegment(parts[5]) {
+				return urlPath, ""
+			}
 			if len(parts) >= 5 { // https, "", host, owner, repo, ...
 				repoURL = strings.Join(parts[:5], "/")
 				if !strings.HasSuffix(repoURL, ".git") {
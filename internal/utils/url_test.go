@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRepoURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		url      string
+		branch   string
+		path     string
+		warnings int
+	}{
+		{"https with .git", "https://github.com/user/repo.git", "https://github.com/user/repo.git", "", "", 0},
+		{"https without .git", "https://github.com/user/repo", "https://github.com/user/repo.git", "", "", 0},
+		{"trailing slash", "https://github.com/user/repo/", "https://github.com/user/repo.git", "", "", 0},
+		{"surrounding whitespace", "  https://github.com/user/repo.git\n", "https://github.com/user/repo.git", "", "", 0},
+		{"nested group keeps its path", "https://gitlab.com/group/sub/repo", "https://gitlab.com/group/sub/repo", "", "", 0},
+		{"azure devops path untouched", "https://dev.azure.com/org/project/_git/repo", "https://dev.azure.com/org/project/_git/repo", "", "", 0},
+		{"github tree URL", "https://github.com/user/repo/tree/main/src/lib", "https://github.com/user/repo.git", "main", "src/lib", 0},
+		{"github tree URL at the root", "https://github.com/user/repo/tree/develop", "https://github.com/user/repo.git", "develop", "", 0},
+		{"github blob URL", "https://github.com/user/repo/blob/v1.2.0/README.md", "https://github.com/user/repo.git", "v1.2.0", "README.md", 0},
+		{"gitlab tree URL", "https://gitlab.com/group/sub/repo/-/tree/main/docs", "https://gitlab.com/group/sub/repo", "main", "docs", 0},
+		{"query and fragment dropped", "https://github.com/user/repo?tab=readme#install", "https://github.com/user/repo.git", "", "", 0},
+		{"repository named tree", "https://github.com/user/tree", "https://github.com/user/tree.git", "", "", 0},
+		{"plain http warns", "http://git.internal/team/repo.git", "http://git.internal/team/repo.git", "", "", 1},
+		{"scp-like", "git@github.com:user/repo.git", "git@github.com:user/repo.git", "", "", 0},
+		{"scp-like without user", "git.company.com:team/repo.git", "git.company.com:team/repo.git", "", "", 0},
+		{"ssh URL with port", "ssh://git@host:2222/team/repo.git", "ssh://git@host:2222/team/repo.git", "", "", 0},
+		{"git protocol", "git://host/team/repo.git", "git://host/team/repo.git", "", "", 0},
+		{"file URL", "file:///srv/git/repo.git", "file:///srv/git/repo.git", "", "", 0},
+		{"absolute local path", "/srv/git/repo.git/", "/srv/git/repo.git", "", "", 0},
+		{"drive letter path", `C:\repos\lib.git\`, `C:\repos\lib.git`, "", "", 0},
+		{"drive letter path with spaces", `C:\Users\Jane Doe\lib.git`, `C:\Users\Jane Doe\lib.git`, "", "", 0},
+		{"drive root", `C:\`, `C:\`, "", "", 0},
+		{"UNC path", `\\server\share\lib.git`, `\\server\share\lib.git`, "", "", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NormalizeRepoURL(tc.input)
+			if err != nil {
+				t.Fatalf("NormalizeRepoURL(%q) failed: %v", tc.input, err)
+			}
+			if got.URL != tc.url || got.Branch != tc.branch || got.Path != tc.path {
+				t.Errorf("NormalizeRepoURL(%q) = %+v, expected URL %q, branch %q, path %q", tc.input, got, tc.url, tc.branch, tc.path)
+			}
+			if len(got.Warnings) != tc.warnings {
+				t.Errorf("NormalizeRepoURL(%q) warnings = %v, expected %d", tc.input, got.Warnings, tc.warnings)
+			}
+		})
+	}
+}
+
+func TestNormalizeRepoURL_Rejected(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		reason string
+	}{
+		{"empty", "   ", "empty"},
+		{"whitespace inside", "https://github.com/user/my repo", "whitespace"},
+		{"missing scheme", "github.com/user/repo", "did you mean https://github.com/user/repo?"},
+		{"bare word", "repo", "missing scheme"},
+		{"unsupported scheme", "ftp://host/repo.git", "unsupported scheme 'ftp'"},
+		{"missing host", "https:///user/repo", "missing host"},
+		{"missing path", "https://github.com/", "missing repository path"},
+		{"tree without branch", "https://github.com/user/repo/tree/", "no branch after /tree/"},
+		{"scp-like without host", "git@:user/repo.git", "missing host"},
+		{"scp-like without path", "git@github.com:", "missing repository path"},
+		{"empty file URL", "file://", "missing repository path"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NormalizeRepoURL(tc.input)
+			if err == nil {
+				t.Fatalf("Expected NormalizeRepoURL(%q) to fail", tc.input)
+			}
+			if !strings.Contains(err.Error(), tc.reason) {
+				t.Errorf("Expected the error to mention %q, got: %v", tc.reason, err)
+			}
+			if !strings.Contains(err.Error(), "git@github.com:owner/repo.git") {
+				t.Errorf("Expected the error to list accepted forms, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseURLPath(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		url    string
+		path   string
+		branch string
+	}{
+		{"plain path", "src/main.go", "", "src/main.go", ""},
+		{"https with .git separator", "https://github.com/user/repo.git/src/main.go", "https://github.com/user/repo.git", "src/main.go", ""},
+		{"https without .git", "https://github.com/user/repo/src/lib/", "https://github.com/user/repo.git", "src/lib/", ""},
+		{"https repository only", "https://github.com/user/repo.git", "https://github.com/user/repo.git", "", ""},
+		{"scp-like with path", "git@github.com:user/repo/docs/guide.md", "git@github.com:user/repo.git", "docs/guide.md", ""},
+		{"github blob URL", "https://github.com/user/repo/blob/main/src/main.go", "https://github.com/user/repo.git", "src/main.go", "main"},
+		{"github tree URL", "https://github.com/user/repo/tree/develop/lib", "https://github.com/user/repo.git", "lib", "develop"},
+		{"file URL", "file:///srv/git/repo.git/lib/a.go", "file:///srv/git/repo.git", "lib/a.go", ""},
+		{"scp-like next to a drive letter", "git@c:user/repo.git/lib/a.go", "git@c:user/repo.git", "lib/a.go", ""},
+		{"local repository", "/srv/git/repo.git/lib/a.go", "/srv/git/repo.git", "lib/a.go", ""},
+		{"drive letter repository", `C:\repos\lib.git\docs\notes.md`, `C:\repos\lib.git`, "docs/notes.md", ""},
+		{"drive letter with slashes", "D:/repos/lib.git/src/", "D:/repos/lib.git", "src/", ""},
+		{"drive letter repository only", `C:\repos\lib.git\`, `C:\repos\lib.git`, "", ""},
+		{"UNC repository", `\\server\share\lib.git\docs\notes.md`, `\\server\share\lib.git`, "docs/notes.md", ""},
+		{"UNC with slashes", "//server/share/lib.git/docs/", "//server/share/lib.git", "docs/", ""},
+		{"relative path with a colon", "docs/v2:notes.md", "", "docs/v2:notes.md", ""},
+		{"escaped path", "https://github.com/user/repo.git/docs/release%20notes%20%231.md", "https://github.com/user/repo.git", "docs/release notes #1.md", ""},
+		{"escaped browser URL", "https://github.com/user/repo/blob/main/docs/caf%C3%A9.md", "https://github.com/user/repo.git", "docs/café.md", "main"},
+		{"invalid escape", "https://github.com/user/repo.git/docs/100%.md", "https://github.com/user/repo.git", "docs/100%.md", ""},
+		{"local path with unusual names", "/srv/git/repo.git/docs/ leading #1 %20[draft].md", "/srv/git/repo.git", "docs/ leading #1 %20[draft].md", ""},
+		{"plain path with unusual names", "docs/what? *.md", "", "docs/what? *.md", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseURLPath(tc.input)
+			if err != nil {
+				t.Fatalf("ParseURLPath(%q) failed: %v", tc.input, err)
+			}
+			if got.URL != tc.url || got.Path != tc.path || got.Branch != tc.branch {
+				t.Errorf("ParseURLPath(%q) = %+v, expected URL %q, path %q, branch %q", tc.input, got, tc.url, tc.path, tc.branch)
+			}
+		})
+	}
+
+	if _, err := ParseURLPath("ftp://host/repo.git/lib/a.go"); err == nil {
+		t.Error("Expected an unsupported scheme to be rejected")
+	}
+	for _, local := range []string{`C:\work\notes.md`, "C:/work/notes.md", `\\server\share\notes.md`, "/work/notes.md"} {
+		if _, err := ParseURLPath(local); err == nil || !strings.Contains(err.Error(), "absolute local path") {
+			t.Errorf("Expected ParseURLPath(%q) to refuse an absolute local path, got %v", local, err)
+		}
+	}
+}
+
+func TestIsAbsolutePath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"/srv/repo.git", true},
+		{`C:\work\notes.md`, true},
+		{"c:/work", true},
+		{"C:", true},
+		{`\\server\share`, true},
+		{"//server/share", true},
+		{`\work`, true},
+		{"git@github.com:user/repo.git", false},
+		{"github.com:user/repo.git", false},
+		{"https://github.com/user/repo.git", false},
+		{"lib/a.go", false},
+		{"1:/work", false},
+	}
+
+	for _, tc := range testCases {
+		if got := IsAbsolutePath(tc.path); got != tc.expected {
+			t.Errorf("IsAbsolutePath(%q) = %v, expected %v", tc.path, got, tc.expected)
+		}
+	}
+}
+
+func TestSameRepository(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"https://github.com/user/repo.git", "https://github.com/user/repo", true},
+		{"https://github.com/user/repo/", "https://github.com/user/repo.git", true},
+		{"git@github.com:user/repo.git", "git@github.com:user/repo", true},
+		{"https://github.com/user/repo.git", "https://github.com/user/other.git", false},
+		{"https://github.com/user/repo.git", "git@github.com:user/repo.git", false},
+	}
+
+	for _, tc := range testCases {
+		if got := SameRepository(tc.a, tc.b); got != tc.expected {
+			t.Errorf("SameRepository(%q, %q) = %v, expected %v", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}